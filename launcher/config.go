@@ -16,6 +16,7 @@ package launcher
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -192,6 +193,82 @@ func WithLightstepMetricsSDK(alt bool) Option {
 	}
 }
 
+// WithMetricsPrometheusAddress serves metrics in the Prometheus text
+// format at /metrics on the given listen address, e.g., ":9464",
+// alongside the OTLP exporter.  This requires the Lightstep metrics
+// SDK and cumulative temporality.
+func WithMetricsPrometheusAddress(addr string) Option {
+	return func(c *Config) {
+		c.MetricsPrometheusAddress = addr
+	}
+}
+
+// WithRetryInitialInterval configures the delay before the OTLP
+// metrics exporter first retries an export that failed with a
+// transient error.  Subsequent delays grow exponentially, with
+// randomization, up to WithRetryMaxInterval.
+func WithRetryInitialInterval(d time.Duration) Option {
+	return func(c *Config) {
+		c.MetricExporterRetryInitialInterval = fmt.Sprint(d)
+	}
+}
+
+// WithRetryMaxInterval configures the largest delay between retries
+// of an OTLP metrics export.
+func WithRetryMaxInterval(d time.Duration) Option {
+	return func(c *Config) {
+		c.MetricExporterRetryMaxInterval = fmt.Sprint(d)
+	}
+}
+
+// WithRetryMaxElapsedTime configures how long the OTLP metrics
+// exporter retries one export before the data is dropped.  This is
+// limited to the metric reporting period, so that retries do not
+// delay the next export.
+func WithRetryMaxElapsedTime(d time.Duration) Option {
+	return func(c *Config) {
+		c.MetricExporterRetryMaxElapsedTime = fmt.Sprint(d)
+	}
+}
+
+// WithDiskBuffer keeps OTLP metrics batches that fail to export in
+// files under path, using at most maxBytes, and replays them
+// oldest-first once the endpoint is reachable again.  When the buffer
+// is full, the oldest batches are discarded.  A maxBytes of zero uses
+// pipelines.DefaultDiskBufferSize.
+func WithDiskBuffer(path string, maxBytes int64) Option {
+	return func(c *Config) {
+		c.MetricExporterDiskBufferPath = path
+		c.MetricExporterDiskBufferSize = maxBytes
+	}
+}
+
+// WithMetricExporterProtocol selects the OTLP metrics protocol,
+// either "grpc" (the default) or "http/protobuf".  The HTTP exporter
+// sends to the metrics endpoint at the path /v1/metrics.
+func WithMetricExporterProtocol(protocol string) Option {
+	return func(c *Config) {
+		c.MetricExporterProtocol = protocol
+	}
+}
+
+// WithMetricExporterCompression configures the OTLP metrics exporter's
+// compression, either "gzip" (the default) or "none".
+func WithMetricExporterCompression(compression string) Option {
+	return func(c *Config) {
+		c.MetricExporterCompression = compression
+	}
+}
+
+// WithMetricExporterTLSConfig configures the TLS settings of the OTLP
+// metrics exporter, e.g., to use a custom certificate authority.  This
+// has no effect when the exporter is insecure.
+func WithMetricExporterTLSConfig(cfg *tls.Config) Option {
+	return func(c *Config) {
+		c.metricExporterTLSConfig = cfg
+	}
+}
+
 type DefaultLogger struct {
 }
 
@@ -236,10 +313,19 @@ type Config struct {
 	Propagators                         []string          `env:"OTEL_PROPAGATORS,default=b3"`
 	MetricReportingPeriod               string            `env:"OTEL_EXPORTER_OTLP_METRIC_PERIOD,default=30s"`
 	UseLightstepMetricsSDK              bool              `env:"LS_METRICS_SDK,default=true"`
+	MetricsPrometheusAddress            string            `env:"LS_METRICS_PROMETHEUS_ADDRESS"`
+	MetricExporterRetryInitialInterval  string            `env:"LS_METRICS_RETRY_INITIAL_INTERVAL,default=5s"`
+	MetricExporterRetryMaxInterval      string            `env:"LS_METRICS_RETRY_MAX_INTERVAL,default=30s"`
+	MetricExporterRetryMaxElapsedTime   string            `env:"LS_METRICS_RETRY_MAX_ELAPSED_TIME,default=1m"`
+	MetricExporterDiskBufferPath        string            `env:"LS_METRICS_DISK_BUFFER_PATH"`
+	MetricExporterDiskBufferSize        int64             `env:"LS_METRICS_DISK_BUFFER_SIZE,default=0"`
+	MetricExporterProtocol              string            `env:"OTEL_EXPORTER_OTLP_METRIC_PROTOCOL,default=grpc"`
+	MetricExporterCompression           string            `env:"OTEL_EXPORTER_OTLP_METRIC_COMPRESSION,default=gzip"`
 	ResourceAttributes                  map[string]string
 	Resource                            *resource.Resource
 	logger                              Logger
 	errorHandler                        otel.ErrorHandler
+	metricExporterTLSConfig             *tls.Config
 }
 
 func checkEndpointDefault(value, defValue string) error {
@@ -409,6 +495,15 @@ func setupMetrics(c Config) (func() error, error) {
 		MetricsBuiltinsEnabled:  c.MetricsBuiltinsEnabled,
 		MetricsBuiltinLibraries: c.MetricsBuiltinLibraries,
 		UseLightstepMetricsSDK:  c.UseLightstepMetricsSDK,
+		PrometheusAddress:       c.MetricsPrometheusAddress,
+		RetryInitialInterval:    c.MetricExporterRetryInitialInterval,
+		RetryMaxInterval:        c.MetricExporterRetryMaxInterval,
+		RetryMaxElapsedTime:     c.MetricExporterRetryMaxElapsedTime,
+		DiskBufferPath:          c.MetricExporterDiskBufferPath,
+		DiskBufferSize:          c.MetricExporterDiskBufferSize,
+		Protocol:                c.MetricExporterProtocol,
+		Compression:             c.MetricExporterCompression,
+		TLSConfig:               c.metricExporterTLSConfig,
 	})
 }
 
@@ -16,6 +16,7 @@ package launcher
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"strings"
@@ -384,6 +385,11 @@ func (suite *testSuite) TestDefaultConfig() {
 		Propagators:                         []string{"b3"},
 		Resource:                            resource.NewWithAttributes(semconv.SchemaURL, attributes...),
 		UseLightstepMetricsSDK:              true,
+		MetricExporterRetryInitialInterval:  "5s",
+		MetricExporterRetryMaxInterval:      "30s",
+		MetricExporterRetryMaxElapsedTime:   "1m",
+		MetricExporterProtocol:              "grpc",
+		MetricExporterCompression:           "gzip",
 		logger:                              &suite.testLogger,
 		errorHandler:                        &suite.testErrorHandler,
 	}
@@ -427,6 +433,11 @@ func (suite *testSuite) TestEnvironmentVariables() {
 		MetricsEnabled:                      false,
 		MetricsBuiltinsEnabled:              false,
 		MetricsBuiltinLibraries:             []string{"cputime:stable", "runtime:stable"},
+		MetricExporterRetryInitialInterval:  "2s",
+		MetricExporterRetryMaxInterval:      "30s",
+		MetricExporterRetryMaxElapsedTime:   "1m",
+		MetricExporterProtocol:              "grpc",
+		MetricExporterCompression:           "gzip",
 		logger:                              &suite.testLogger,
 		errorHandler:                        &suite.testErrorHandler,
 	}
@@ -456,6 +467,13 @@ func (suite *testSuite) TestConfigurationOverrides() {
 		WithMetricsEnabled(true),
 		WithMetricsBuiltinsEnabled(true),
 		WithMetricsBuiltinLibraries([]string{"host:stable"}),
+		WithRetryInitialInterval(time.Second),
+		WithRetryMaxInterval(10*time.Second),
+		WithRetryMaxElapsedTime(20*time.Second),
+		WithDiskBuffer("/tmp/metrics", 1<<20),
+		WithMetricExporterProtocol("http/protobuf"),
+		WithMetricExporterCompression("none"),
+		WithMetricExporterTLSConfig(&tls.Config{ServerName: "override-metrics-url"}),
 	)
 
 	attributes := []attribute.KeyValue{
@@ -486,8 +504,16 @@ func (suite *testSuite) TestConfigurationOverrides() {
 		MetricsEnabled:                      true,
 		MetricsBuiltinsEnabled:              true,
 		MetricsBuiltinLibraries:             []string{"host:stable"},
+		MetricExporterRetryInitialInterval:  "1s",
+		MetricExporterRetryMaxInterval:      "10s",
+		MetricExporterRetryMaxElapsedTime:   "20s",
+		MetricExporterDiskBufferPath:        "/tmp/metrics",
+		MetricExporterDiskBufferSize:        1 << 20,
+		MetricExporterProtocol:              "http/protobuf",
+		MetricExporterCompression:           "none",
 		logger:                              &suite.testLogger,
 		errorHandler:                        &suite.testErrorHandler,
+		metricExporterTLSConfig:             &tls.Config{ServerName: "override-metrics-url"},
 	}
 	assert.Equal(expected, config)
 }
@@ -702,6 +728,7 @@ func setEnvironment() {
 	os.Setenv("LS_METRICS_BUILTINS_ENABLED", "false")
 	os.Setenv("LS_METRICS_BUILTIN_LIBRARIES", "cputime:stable,runtime:stable")
 	os.Setenv("LS_METRICS_SDK", "true")
+	os.Setenv("LS_METRICS_RETRY_INITIAL_INTERVAL", "2s")
 }
 
 func unsetEnvironment() {
@@ -722,6 +749,7 @@ func unsetEnvironment() {
 		"LS_METRICS_BUILTINS_ENABLED",
 		"LS_METRICS_BUILTIN_LIBRARIES",
 		"LS_METRICS_SDK",
+		"LS_METRICS_RETRY_INITIAL_INTERVAL",
 	}
 	for _, envvar := range vars {
 		os.Unsetenv(envvar)
@@ -18,9 +18,11 @@ package aggregation // import "github.com/lightstep/otel-launcher-go/lightstep/s
 
 import (
 	"strings"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // These interfaces describe the various ways to access state from an
@@ -53,6 +55,37 @@ type (
 		Gauge() number.Number
 	}
 
+	// ClampedGauge is a Gauge that counts values it clamped into
+	// a configured range.
+	ClampedGauge interface {
+		Gauge
+
+		// ClampedCount returns the number of out-of-range
+		// values that were clamped.
+		ClampedCount() uint64
+	}
+
+	// TimestampedGauge is a Gauge that records the time of its
+	// most recent update.
+	TimestampedGauge interface {
+		Gauge
+
+		// LastUpdateTime returns the time of the most recent
+		// update, which is zero unless timestamps are
+		// configured.
+		LastUpdateTime() time.Time
+	}
+
+	// SequencedGauge is a Gauge that exposes the sequence number
+	// of its most recent update, which orders updates.
+	SequencedGauge interface {
+		Gauge
+
+		// Sequence returns the sequence number of the most
+		// recent update, zero if not set.
+		Sequence() uint64
+	}
+
 	// Histogram returns the count of events in exponential-scale
 	// buckets defined as a function of a scale parameter.  See a
 	// detailed explanation in the OpenTelemetry metrics data
@@ -79,6 +112,34 @@ type (
 		At(uint32) uint64
 	}
 
+	// ExplicitHistogram returns the count of events in buckets
+	// defined by explicit boundaries.  Bucket i counts values in
+	// (Boundaries()[i-1], Boundaries()[i]], so there is one more
+	// bucket than there are boundaries.
+	ExplicitHistogram interface {
+		Aggregation
+		Count() uint64
+		HasASum
+		Boundaries() []float64
+		BucketCounts() []uint64
+	}
+
+	// OptionalMinMax is implemented by aggregations that may be
+	// configured not to track their minimum and maximum values.
+	// Min and Max are meaningful when HasMinMax returns true.
+	OptionalMinMax interface {
+		HasMinMax() bool
+		Min() number.Number
+		Max() number.Number
+	}
+
+	// HasZeroThreshold is implemented by exponential histograms
+	// that count values of magnitude at most ZeroThreshold() in
+	// their zero bucket, rather than only exact zeros.
+	HasZeroThreshold interface {
+		ZeroThreshold() float64
+	}
+
 	// MinMaxSumCount is a low cost HistogramCategory aggregator
 	// that records the Min, Max, Sum, and Count.
 	MinMaxSumCount interface {
@@ -88,8 +149,43 @@ type (
 		Min() number.Number
 		Max() number.Number
 	}
+
+	// Summary returns estimated quantiles of the aggregated
+	// values, along with their count and sum.  This is exported
+	// as an OTLP Summary point, which exists for compatibility
+	// with legacy backends.
+	Summary interface {
+		Aggregation
+		Count() uint64
+		HasASum
+
+		// Quantiles returns the estimated value of each
+		// configured quantile, in increasing order.
+		Quantiles() []QuantileValue
+	}
+
+	// HasExemplars is implemented by aggregations that sample
+	// individual measurements along with their trace context.
+	HasExemplars interface {
+		Exemplars() []Exemplar
+	}
 )
 
+// QuantileValue is the estimated value of one quantile in the range
+// [0, 1] of a Summary.
+type QuantileValue struct {
+	Quantile float64
+	Value    float64
+}
+
+// Exemplar is a single measurement sampled along with the span
+// context that was active when it was recorded.
+type Exemplar struct {
+	Value       number.Number
+	Time        time.Time
+	SpanContext trace.SpanContext
+}
+
 // Category constants describe semantic kind.  For the histogram
 // category there are multiple implementations, for those distinctions
 // as well as Drop, use Kind.
@@ -114,8 +210,16 @@ const (
 	GaugeKind
 	HistogramKind
 	MinMaxSumCountKind
+	ExplicitHistogramKind
+	SummaryKind
+	RateKind
 )
 
+// FirstCustomKind is the lowest Kind available to custom
+// aggregations, see aggregator.Register.  Valid() is false for
+// custom kinds, which views accept once they are registered.
+const FirstCustomKind Kind = 1 << 10
+
 func (k Kind) Category(ik sdkinstrument.Kind) Category {
 	switch k {
 	case AnySumKind:
@@ -126,13 +230,13 @@ func (k Kind) Category(ik sdkinstrument.Kind) Category {
 			return NonMonotonicSumCategory
 		}
 		return UndefinedCategory
-	case MonotonicSumKind:
+	case MonotonicSumKind, RateKind:
 		return MonotonicSumCategory
 	case NonMonotonicSumKind:
 		return NonMonotonicSumCategory
 	case GaugeKind:
 		return GaugeCategory
-	case HistogramKind, MinMaxSumCountKind:
+	case HistogramKind, MinMaxSumCountKind, ExplicitHistogramKind, SummaryKind:
 		return HistogramCategory
 	default:
 		return UndefinedCategory
@@ -148,7 +252,8 @@ func (k Kind) Valid() bool {
 	switch k {
 	case UndefinedKind, DropKind, AnySumKind,
 		MonotonicSumKind, NonMonotonicSumKind,
-		GaugeKind, HistogramKind, MinMaxSumCountKind:
+		GaugeKind, HistogramKind, MinMaxSumCountKind,
+		ExplicitHistogramKind, SummaryKind, RateKind:
 		return true
 	}
 	return false
@@ -172,6 +277,12 @@ func ParseKind(str string) (Kind, bool) {
 		return HistogramKind, true
 	case "minmaxsumcount":
 		return MinMaxSumCountKind, true
+	case "explicit_histogram":
+		return ExplicitHistogramKind, true
+	case "summary":
+		return SummaryKind, true
+	case "rate":
+		return RateKind, true
 	}
 	return UndefinedKind, false
 }
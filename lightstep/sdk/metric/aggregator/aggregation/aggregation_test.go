@@ -35,6 +35,9 @@ func TestParseAggregation(t *testing.T) {
 		{"exponential_histogram", HistogramKind, true},
 		{"histogram", HistogramKind, true},
 		{"minmaxsumcount", MinMaxSumCountKind, true},
+		{"explicit_histogram", ExplicitHistogramKind, true},
+		{"summary", SummaryKind, true},
+		{"rate", RateKind, true},
 		{"otherthing", UndefinedKind, false},
 	} {
 		k, ok := ParseKind(test.input)
@@ -42,3 +45,20 @@ func TestParseAggregation(t *testing.T) {
 		require.Equal(t, test.ok, ok)
 	}
 }
+
+func TestParseTemporality(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		tempo Temporality
+		ok    bool
+	}{
+		{"delta", DeltaTemporality, true},
+		{"Cumulative", CumulativeTemporality, true},
+		{"", UndefinedTemporality, false},
+		{"otherthing", UndefinedTemporality, false},
+	} {
+		tempo, ok := ParseTemporality(test.input)
+		require.Equal(t, test.tempo, tempo)
+		require.Equal(t, test.ok, ok)
+	}
+}
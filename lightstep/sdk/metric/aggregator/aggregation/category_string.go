@@ -37,11 +37,14 @@ func _() {
 	_ = x[GaugeKind-5]
 	_ = x[HistogramKind-6]
 	_ = x[MinMaxSumCountKind-7]
+	_ = x[ExplicitHistogramKind-8]
+	_ = x[SummaryKind-9]
+	_ = x[RateKind-10]
 }
 
-const _Kind_name = "UndefinedKindDropKindAnySumKindMonotonicSumKindNonMonotonicSumKindGaugeKindHistogramKindMinMaxSumCountKind"
+const _Kind_name = "UndefinedKindDropKindAnySumKindMonotonicSumKindNonMonotonicSumKindGaugeKindHistogramKindMinMaxSumCountKindExplicitHistogramKindSummaryKindRateKind"
 
-var _Kind_index = [...]uint8{0, 13, 21, 31, 47, 66, 75, 88, 106}
+var _Kind_index = [...]uint8{0, 13, 21, 31, 47, 66, 75, 88, 106, 127, 138, 146}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
@@ -16,7 +16,11 @@
 
 package aggregation // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
 
-import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+import (
+	"strings"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+)
 
 type Temporality uint8
 
@@ -57,3 +61,15 @@ func (t Temporality) Valid() bool {
 	}
 	return false
 }
+
+// ParseTemporality returns the enumerated constant and true if the
+// string corresponds with a known temporality.
+func ParseTemporality(str string) (Temporality, bool) {
+	switch strings.ToLower(str) {
+	case "cumulative":
+		return CumulativeTemporality, true
+	case "delta":
+		return DeltaTemporality, true
+	}
+	return UndefinedTemporality, false
+}
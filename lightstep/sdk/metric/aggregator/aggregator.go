@@ -15,15 +15,21 @@
 package aggregator // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 
 import (
+	"context"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/lightstep/go-expohisto/mapping/exponent"
+	"github.com/lightstep/go-expohisto/mapping/logarithm"
 	histostruct "github.com/lightstep/go-expohisto/structure"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"go.opentelemetry.io/otel"
+	"go.uber.org/multierr"
 )
 
 // Sentinel errors for Aggregator interface.
@@ -31,38 +37,94 @@ var (
 	ErrNegativeInput = fmt.Errorf("negative value is out of range for this instrument")
 	ErrNaNInput      = fmt.Errorf("NaN value is an invalid input")
 	ErrInfInput      = fmt.Errorf("±Inf value is an invalid input")
+	ErrSumOverflow   = fmt.Errorf("int64 sum overflow")
+	ErrInvalidBucket = fmt.Errorf("histogram bucket is invalid for this instrument")
+
+	// ErrCountOverflow is reported through the OTel error
+	// handler when a histogram count saturates at the maximum
+	// uint64 value instead of wrapping around.
+	ErrCountOverflow = fmt.Errorf("histogram count overflow")
+
+	// ErrIngestDropped is reported when a measurement does not
+	// fit in the ingest queue configured by
+	// metric.WithAsyncIngest.
+	ErrIngestDropped = fmt.Errorf("measurement dropped by full ingest queue")
 )
 
+// MeasurementErrorHandler is called for each measurement dropped by
+// RangeTest, where reason is one of ErrNaNInput, ErrInfInput, or
+// ErrNegativeInput.  The value is encoded according to the
+// descriptor's number kind.
+//
+// MeasurementErrorHandler is also called with reason ErrSumOverflow
+// when an int64 sum configured with a SumOverflowPolicy overflows,
+// in which case the value is the sum after the policy was applied.
+//
+// MeasurementErrorHandler is also called with reason
+// ErrInvalidBucket when pre-bucketed data names a bucket that an
+// output does not have, or an output does not support pre-bucketed
+// data, in which case the value is the bucket index.
+//
+// MeasurementErrorHandler is also called with reason
+// ErrIngestDropped when a measurement is dropped because the ingest
+// queue is full, in which case the value is the measurement.
+type MeasurementErrorHandler func(desc sdkinstrument.Descriptor, value number.Number, reason error)
+
 // RangeTest is a common routine for testing for valid input values.
 // This rejects NaN and Inf values.  This rejects negative values when the
 // aggregation does not support negative values, including
-// monotonic counter metrics and Histogram metrics.
-func RangeTest[N number.Any, Traits number.Traits[N]](num N, desc sdkinstrument.Descriptor) bool {
+// monotonic counter metrics and Histogram metrics.  Rejected values
+// are passed to onError, when it is not nil.
+func RangeTest[N number.Any, Traits number.Traits[N]](num N, desc sdkinstrument.Descriptor, onError MeasurementErrorHandler) bool {
+	return rangeTest[N, Traits](num, desc, onError, false)
+}
+
+// SignedHistogramRangeTest is RangeTest for a MeterProvider that
+// accepts negative histogram measurements, which are recorded in the
+// negative range of an exponential histogram or the lower buckets of
+// an explicit histogram.  Negative counter measurements are still
+// rejected.
+func SignedHistogramRangeTest[N number.Any, Traits number.Traits[N]](num N, desc sdkinstrument.Descriptor, onError MeasurementErrorHandler) bool {
+	return rangeTest[N, Traits](num, desc, onError, true)
+}
+
+func rangeTest[N number.Any, Traits number.Traits[N]](num N, desc sdkinstrument.Descriptor, onError MeasurementErrorHandler, signedHistogram bool) bool {
 	var traits Traits
 
+	reject := func(reason error) bool {
+		if onError != nil {
+			onError(desc, traits.ToNumber(num), reason)
+		}
+		return false
+	}
+
 	if traits.IsInf(num) {
 		doevery.TimePeriod(30*time.Second, func() {
 			otel.Handle(fmt.Errorf("%s: %w", desc.Name, ErrInfInput))
 		})
-		return false
+		return reject(ErrInfInput)
 	}
 
 	if traits.IsNaN(num) {
 		doevery.TimePeriod(30*time.Second, func() {
 			otel.Handle(fmt.Errorf("%s: %w", desc.Name, ErrNaNInput))
 		})
-		return false
+		return reject(ErrNaNInput)
 	}
 
 	// Check for negative values
 	switch desc.Kind {
-	case sdkinstrument.SyncCounter,
-		sdkinstrument.SyncHistogram:
+	case sdkinstrument.SyncHistogram:
+		if signedHistogram {
+			break
+		}
+		fallthrough
+	case sdkinstrument.SyncCounter:
 		if num < 0 {
 			doevery.TimePeriod(30*time.Second, func() {
 				otel.Handle(fmt.Errorf("%s: %w", desc.Name, ErrNegativeInput))
 			})
-			return false
+			return reject(ErrNegativeInput)
 		}
 	}
 	return true
@@ -70,7 +132,11 @@ func RangeTest[N number.Any, Traits number.Traits[N]](num N, desc sdkinstrument.
 
 // JSONHistogramConfig configures the exponential histogram.
 type JSONHistogramConfig struct {
-	MaxSize int32 `json:"max_size"`
+	MaxSize  int32  `json:"max_size"`
+	MaxScale *int32 `json:"max_scale,omitempty"`
+
+	// Boundaries, when set, selects the explicit-bucket histogram.
+	Boundaries []float64 `json:"boundaries,omitempty"`
 }
 
 // JSONConfig supports the configuration for all aggregators in a single struct.
@@ -80,14 +146,435 @@ type JSONConfig struct {
 
 // ToConfig returns a Config from the fixed-JSON represented.
 func (jc JSONConfig) ToConfig() Config {
-	return Config{
+	cfg := Config{
 		Histogram: histostruct.NewConfig(histostruct.WithMaxSize(jc.Histogram.MaxSize)),
 	}
+	if jc.Histogram.MaxScale != nil {
+		cfg.HistogramMaxScale = NewMaxScale(*jc.Histogram.MaxScale)
+	}
+	if jc.Histogram.Boundaries != nil {
+		cfg.HistogramBoundaries = NewBoundaries(jc.Histogram.Boundaries)
+	}
+	return cfg
+}
+
+// Empty returns true when no field is set.
+func (jc JSONConfig) Empty() bool {
+	return jc.Histogram.MaxSize == 0 &&
+		jc.Histogram.MaxScale == nil &&
+		jc.Histogram.Boundaries == nil
 }
 
 // Config supports the configuration for all aggregators in a single struct.
 type Config struct {
 	Histogram histostruct.Config
+
+	// HistogramMaxScale limits the scale of exponential
+	// histogram output, set using NewMaxScale.  Histograms are
+	// recorded at no more than this scale, reduced further to fit
+	// their maximum size.  The zero value imposes no limit.
+	HistogramMaxScale MaxScale
+
+	// HistogramExemplars, when non-nil, enables exemplar sampling
+	// for histograms.
+	HistogramExemplars *ExemplarConfig
+
+	// HistogramTrimEmptyBuckets omits leading and trailing empty
+	// buckets from exponential histogram output.
+	HistogramTrimEmptyBuckets bool
+
+	// HistogramZeroThreshold, when non-zero, is the magnitude at
+	// or below which exponential histogram values are counted as
+	// zero rather than in a bucket.  Valid values are finite and
+	// non-negative; the zero value counts only exact zeros.
+	HistogramZeroThreshold float64
+
+	// HistogramBoundaries, when set, selects the explicit-bucket
+	// histogram in place of the exponential histogram.
+	HistogramBoundaries Boundaries
+
+	// HistogramMaxBuckets, when non-zero, limits the number of
+	// explicit-histogram buckets, see Boundaries.Limit.  This
+	// also applies to boundaries that come from a hint.
+	HistogramMaxBuckets int
+
+	// HistogramNoMinMax disables tracking the minimum and maximum
+	// value of explicit-bucket histograms.
+	HistogramNoMinMax bool
+
+	// GaugeClamp limits the range of gauge values.  The zero
+	// value imposes no limit.
+	GaugeClamp GaugeClamp
+
+	// GaugeTimestamps records the time of each gauge update,
+	// see aggregation.TimestampedGauge.
+	GaugeTimestamps bool
+
+	// GaugeStaleAfter, when non-zero, omits cumulative gauge
+	// points that were not updated within this duration of the
+	// collection.  This implies GaugeTimestamps.
+	GaugeStaleAfter time.Duration
+
+	// GaugeSequence, when non-nil, assigns the sequence number
+	// that orders gauge updates, see gauge.WithSequenceSource.
+	GaugeSequence *GaugeSequence
+
+	// Clock, when non-nil, is the source of gauge update times.
+	// The MeterProvider sets this from its configured clock.
+	Clock Clock
+
+	// SumOverflow selects the behavior of int64 sums that
+	// overflow.  The zero value wraps around.
+	SumOverflow SumOverflowPolicy
+
+	// SumSampling, when non-zero, is the probability that a sum
+	// keeps each measurement, scaled by its inverse.  Valid
+	// values are in (0, 1]; zero and one disable sampling.
+	SumSampling float64
+
+	// SummaryQuantiles are the quantiles estimated by the
+	// summary aggregation.  When undefined, the summary uses
+	// its default quantiles.
+	SummaryQuantiles Quantiles
+
+	// SummaryRelativeError is the relative accuracy of summary
+	// quantile estimates.  Valid values are in [0, 1); zero
+	// selects the default.
+	SummaryRelativeError float64
+}
+
+// Clock is a source of the current time.  Implementations are
+// compared as part of Config, so they should be pointers or other
+// comparable types.
+type Clock interface {
+	Now() time.Time
+}
+
+// ExemplarReservoir samples exemplars for a single aggregator.  Calls
+// are synchronized by the aggregator.
+type ExemplarReservoir interface {
+	// Offer considers one exemplar for sampling.
+	Offer(aggregation.Exemplar)
+
+	// Exemplars returns the sampled exemplars.
+	Exemplars() []aggregation.Exemplar
+
+	// Reset clears the reservoir.
+	Reset()
+}
+
+// ExemplarConfig constructs one ExemplarReservoir per aggregator.
+// This is a pointer in Config so that Config remains comparable.
+type ExemplarConfig struct {
+	newReservoir func() ExemplarReservoir
+}
+
+// NewExemplarConfig returns an ExemplarConfig using the reservoir
+// factory provided.
+func NewExemplarConfig(factory func() ExemplarReservoir) *ExemplarConfig {
+	return &ExemplarConfig{
+		newReservoir: factory,
+	}
+}
+
+// NewReservoir returns a new reservoir, or nil if exemplars are not
+// configured.
+func (ec *ExemplarConfig) NewReservoir() ExemplarReservoir {
+	if ec == nil || ec.newReservoir == nil {
+		return nil
+	}
+	return ec.newReservoir()
+}
+
+// GaugeSequence is a source of the sequence numbers that order gauge
+// updates.  This is a pointer in Config so that Config remains
+// comparable.
+type GaugeSequence struct {
+	next func() uint64
+}
+
+// NewGaugeSequence returns a GaugeSequence using the source provided.
+func NewGaugeSequence(next func() uint64) *GaugeSequence {
+	return &GaugeSequence{
+		next: next,
+	}
+}
+
+// Next returns the next sequence number and true, or false if no
+// source is configured.
+func (gs *GaugeSequence) Next() (uint64, bool) {
+	if gs == nil || gs.next == nil {
+		return 0, false
+	}
+	return gs.next(), true
+}
+
+// MaxScale is an optional limit on the scale of an exponential
+// histogram.  This is a comparable struct, not a pointer, so that
+// Config values can be compared using ==.
+type MaxScale struct {
+	limited bool
+	scale   int32
+}
+
+// NewMaxScale returns a limit on exponential histogram scale.  Valid
+// limits range from -10 to 20, inclusive.
+func NewMaxScale(scale int32) MaxScale {
+	return MaxScale{
+		limited: true,
+		scale:   scale,
+	}
+}
+
+// Get returns the limit and true when a limit is set.
+func (m MaxScale) Get() (int32, bool) {
+	return m.scale, m.limited
+}
+
+// Validate returns the nearest valid MaxScale and an error if the
+// input was out of range.
+func (m MaxScale) Validate() (MaxScale, error) {
+	if !m.limited {
+		return m, nil
+	}
+	err := fmt.Errorf("invalid histogram max scale: %d", m.scale)
+	if m.scale < exponent.MinScale {
+		return NewMaxScale(exponent.MinScale), err
+	}
+	if m.scale > logarithm.MaxScale {
+		return NewMaxScale(logarithm.MaxScale), err
+	}
+	return m, nil
+}
+
+// Boundaries is an optional, immutable list of explicit histogram
+// bucket boundaries.  The boundaries are encoded in a string so that
+// Config values can be compared using ==.
+type Boundaries struct {
+	set     bool
+	encoded string
+}
+
+// NewBoundaries returns explicit histogram bucket boundaries.  Valid
+// boundaries are finite and strictly increasing.
+func NewBoundaries(bounds []float64) Boundaries {
+	return Boundaries{
+		set:     true,
+		encoded: encodeFloat64s(bounds),
+	}
+}
+
+// Defined returns true when boundaries are set.  Note that an empty
+// list of boundaries is defined, it describes a single bucket.
+func (b Boundaries) Defined() bool {
+	return b.set
+}
+
+// Len returns the number of boundaries.
+func (b Boundaries) Len() int {
+	return len(b.encoded) / 8
+}
+
+// At returns the boundary at position i.
+func (b Boundaries) At(i int) float64 {
+	return decodeFloat64(b.encoded, i)
+}
+
+// ToSlice returns a copy of the boundaries.
+func (b Boundaries) ToSlice() []float64 {
+	r := make([]float64, b.Len())
+	for i := range r {
+		r[i] = b.At(i)
+	}
+	return r
+}
+
+// Validate returns undefined Boundaries and an error if the input was
+// not finite and strictly increasing.
+func (b Boundaries) Validate() (Boundaries, error) {
+	for i := 0; i < b.Len(); i++ {
+		v := b.At(i)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return Boundaries{}, fmt.Errorf("invalid histogram boundary: %v", v)
+		}
+		if i > 0 && v <= b.At(i-1) {
+			return Boundaries{}, fmt.Errorf("histogram boundaries are not increasing: %v", b.ToSlice())
+		}
+	}
+	return b, nil
+}
+
+// Limit returns boundaries describing at most maxBuckets buckets,
+// which is at most maxBuckets-1 boundaries.  When there are too many
+// boundaries, they are decimated uniformly: the first and last
+// boundaries are kept along with evenly spaced boundaries between
+// them, which merges runs of adjacent buckets into one.  Zero
+// maxBuckets imposes no limit.
+func (b Boundaries) Limit(maxBuckets int) Boundaries {
+	n := b.Len()
+	keep := maxBuckets - 1
+	if maxBuckets <= 0 || n <= keep {
+		return b
+	}
+	bounds := make([]float64, keep)
+	switch keep {
+	case 0:
+	case 1:
+		bounds[0] = b.At(n / 2)
+	default:
+		for i := range bounds {
+			// Round to nearest; the step (n-1)/(keep-1) is at
+			// least one, so the indices are distinct.
+			bounds[i] = b.At((i*(n-1) + (keep-1)/2) / (keep - 1))
+		}
+	}
+	return NewBoundaries(bounds)
+}
+
+// Quantiles is an optional, immutable list of summary quantiles,
+// encoded like Boundaries so that Config values can be compared
+// using ==.
+type Quantiles struct {
+	set     bool
+	encoded string
+}
+
+// NewQuantiles returns summary quantiles.  Valid quantiles are in
+// the range [0, 1] and strictly increasing.
+func NewQuantiles(quantiles []float64) Quantiles {
+	return Quantiles{
+		set:     true,
+		encoded: encodeFloat64s(quantiles),
+	}
+}
+
+// Defined returns true when quantiles are set.
+func (q Quantiles) Defined() bool {
+	return q.set
+}
+
+// Len returns the number of quantiles.
+func (q Quantiles) Len() int {
+	return len(q.encoded) / 8
+}
+
+// At returns the quantile at position i.
+func (q Quantiles) At(i int) float64 {
+	return decodeFloat64(q.encoded, i)
+}
+
+// ToSlice returns a copy of the quantiles.
+func (q Quantiles) ToSlice() []float64 {
+	r := make([]float64, q.Len())
+	for i := range r {
+		r[i] = q.At(i)
+	}
+	return r
+}
+
+// Validate returns undefined Quantiles and an error if the input was
+// outside [0, 1] or not strictly increasing.
+func (q Quantiles) Validate() (Quantiles, error) {
+	for i := 0; i < q.Len(); i++ {
+		v := q.At(i)
+		if !(v >= 0 && v <= 1) {
+			return Quantiles{}, fmt.Errorf("invalid summary quantile: %v", v)
+		}
+		if i > 0 && v <= q.At(i-1) {
+			return Quantiles{}, fmt.Errorf("summary quantiles are not increasing: %v", q.ToSlice())
+		}
+	}
+	return q, nil
+}
+
+func encodeFloat64s(fs []float64) string {
+	buf := make([]byte, 8*len(fs))
+	for i, f := range fs {
+		binary.LittleEndian.PutUint64(buf[8*i:], math.Float64bits(f))
+	}
+	return string(buf)
+}
+
+func decodeFloat64(encoded string, i int) float64 {
+	e := encoded[8*i : 8*i+8]
+	return math.Float64frombits(uint64(e[0]) | uint64(e[1])<<8 | uint64(e[2])<<16 | uint64(e[3])<<24 |
+		uint64(e[4])<<32 | uint64(e[5])<<40 | uint64(e[6])<<48 | uint64(e[7])<<56)
+}
+
+// GaugeClamp is an optional range that gauge values are clamped
+// into.  Like MaxScale, this is a comparable struct so that Config
+// values can be compared using ==.
+type GaugeClamp struct {
+	limited bool
+	kind    number.Kind
+	min     number.Number
+	max     number.Number
+}
+
+// NewGaugeClamp returns a range of kind-typed numbers for clamping
+// gauge values.
+func NewGaugeClamp(kind number.Kind, min, max number.Number) GaugeClamp {
+	return GaugeClamp{
+		limited: true,
+		kind:    kind,
+		min:     min,
+		max:     max,
+	}
+}
+
+// Get returns the kind of number, the range, and true when a range
+// is set.
+func (c GaugeClamp) Get() (kind number.Kind, min, max number.Number, ok bool) {
+	return c.kind, c.min, c.max, c.limited
+}
+
+// Validate returns an unlimited GaugeClamp and an error if the range
+// is empty or not comparable.
+func (c GaugeClamp) Validate() (GaugeClamp, error) {
+	if !c.limited {
+		return c, nil
+	}
+	var ok bool
+	switch c.kind {
+	case number.Int64Kind:
+		ok = number.ToInt64(c.min) <= number.ToInt64(c.max)
+	case number.Uint64Kind:
+		ok = number.ToUint64(c.min) <= number.ToUint64(c.max)
+	case number.Float64Kind:
+		ok = number.ToFloat64(c.min) <= number.ToFloat64(c.max)
+	}
+	if !ok {
+		return GaugeClamp{}, fmt.Errorf("invalid gauge clamp: [%v, %v]", c.min.CoerceToFloat64(c.kind), c.max.CoerceToFloat64(c.kind))
+	}
+	return c, nil
+}
+
+// SumOverflowPolicy selects what an int64 sum does when an update or
+// merge overflows.
+type SumOverflowPolicy uint8
+
+const (
+	// SumOverflowWrap wraps around, as int64 arithmetic does.
+	// Overflow is not detected.
+	SumOverflowWrap SumOverflowPolicy = iota
+
+	// SumOverflowSaturate holds the sum at math.MaxInt64 or
+	// math.MinInt64.
+	SumOverflowSaturate
+
+	// SumOverflowReset restarts the sum from the increment that
+	// overflowed.  Cumulative points of synchronous instruments
+	// then start at the previous collection, so that consumers
+	// see a counter reset.
+	SumOverflowReset
+)
+
+// Validate returns SumOverflowWrap and an error for unknown policies.
+func (p SumOverflowPolicy) Validate() (SumOverflowPolicy, error) {
+	if p > SumOverflowReset {
+		return SumOverflowWrap, fmt.Errorf("invalid sum overflow policy: %d", p)
+	}
+	return p, nil
 }
 
 // Valid returns true for valid configurations.
@@ -99,9 +586,34 @@ func (c Config) Valid() bool {
 // Valid returns a valid Configuration along with an error if there
 // were invalid settings.  Note that the empty state is considered valid and a correct
 func (c Config) Validate() (Config, error) {
-	var err error
-	c.Histogram, err = c.Histogram.Validate()
-	return c, err
+	var err1, err2, err3, err4, err5, err6, err7, err8, err9, err10, err11 error
+	c.Histogram, err1 = c.Histogram.Validate()
+	c.HistogramMaxScale, err2 = c.HistogramMaxScale.Validate()
+	c.HistogramBoundaries, err3 = c.HistogramBoundaries.Validate()
+	c.GaugeClamp, err4 = c.GaugeClamp.Validate()
+	c.SumOverflow, err5 = c.SumOverflow.Validate()
+	if c.GaugeStaleAfter < 0 {
+		err6 = fmt.Errorf("invalid gauge stale-after duration: %v", c.GaugeStaleAfter)
+		c.GaugeStaleAfter = 0
+	}
+	if !(c.SumSampling >= 0 && c.SumSampling <= 1) {
+		err7 = fmt.Errorf("invalid sum sampling probability: %v", c.SumSampling)
+		c.SumSampling = 0
+	}
+	if c.HistogramMaxBuckets < 0 {
+		err8 = fmt.Errorf("invalid histogram max buckets: %v", c.HistogramMaxBuckets)
+		c.HistogramMaxBuckets = 0
+	}
+	c.SummaryQuantiles, err9 = c.SummaryQuantiles.Validate()
+	if !(c.SummaryRelativeError >= 0 && c.SummaryRelativeError < 1) {
+		err10 = fmt.Errorf("invalid summary relative error: %v", c.SummaryRelativeError)
+		c.SummaryRelativeError = 0
+	}
+	if !(c.HistogramZeroThreshold >= 0 && c.HistogramZeroThreshold <= math.MaxFloat64) {
+		err11 = fmt.Errorf("invalid histogram zero threshold: %v", c.HistogramZeroThreshold)
+		c.HistogramZeroThreshold = 0
+	}
+	return c, multierr.Combine(err1, err2, err3, err4, err5, err6, err7, err8, err9, err10, err11)
 }
 
 // Methods implements a specific aggregation behavior for a specific
@@ -179,5 +691,74 @@ type Methods[N number.Any, Storage any] interface {
 	HasChange(ptr *Storage) bool
 }
 
+// ContextMethods is optionally implemented by Methods that use the
+// context of a measurement, e.g., to sample exemplars.
+type ContextMethods[N number.Any, Storage any] interface {
+	// UpdateContext is Update with the measurement context.
+	UpdateContext(ctx context.Context, ptr *Storage, number N)
+}
+
+// WeightedMethods is optionally implemented by Methods that support
+// a single measurement standing for several identical events, e.g.,
+// for sampled measurements.
+type WeightedMethods[N number.Any, Storage any] interface {
+	// UpdateWeighted is UpdateContext for weight measurements of
+	// the same value.
+	UpdateWeighted(ctx context.Context, ptr *Storage, number N, weight uint64)
+}
+
+// BucketMethods is optionally implemented by Methods of histograms
+// with explicit boundaries, to merge data that was bucketed
+// elsewhere.
+type BucketMethods[N number.Any, Storage any] interface {
+	// UpdateBucket adds count to the bucket at index and sum to
+	// the sum, without changing the minimum and maximum.  Returns
+	// false, leaving the Storage unchanged, when there is no
+	// bucket at index.
+	UpdateBucket(ptr *Storage, index int, count uint64, sum N) bool
+}
+
+// OverflowMethods is optionally implemented by Methods that detect
+// arithmetic overflow.
+type OverflowMethods[N number.Any, Storage any] interface {
+	// TakeOverflow returns the current value and true if the
+	// Storage overflowed since the last call, and clears the
+	// condition.
+	TakeOverflow(ptr *Storage) (N, bool)
+}
+
+// MemoryMethods is optionally implemented by Methods whose Storage
+// refers to variable-size memory, e.g., histogram buckets.
+type MemoryMethods[N number.Any, Storage any] interface {
+	// MemorySize estimates the number of bytes referenced by the
+	// Storage, not including the Storage value itself.
+	MemorySize(ptr *Storage) int
+}
+
+// ResetMethods is optionally implemented by Methods whose cumulative
+// values do not decrease, e.g., monotonic sums, so that a decrease
+// indicates the source of cumulative observations was reset.
+type ResetMethods[N number.Any, Storage any] interface {
+	// IsReset returns true if current could not have followed
+	// prior without a reset.
+	IsReset(prior, current *Storage) bool
+}
+
+// AccumulateMethods is optionally implemented by Methods whose
+// observations may be deltas, e.g., gauges, so that they can be
+// accumulated into a running total.
+type AccumulateMethods[N number.Any, Storage any] interface {
+	// Accumulate adds the value of from to the value of to.
+	Accumulate(from, to *Storage)
+}
+
+// IntervalMethods is optionally implemented by Methods whose output
+// depends on the length of the collection interval, e.g., rates.
+type IntervalMethods[N number.Any, Storage any] interface {
+	// SetInterval is called with the start and end time of each
+	// point before it is output.
+	SetInterval(ptr *Storage, start, end time.Time)
+}
+
 // ConfigSelector is a per-instrument-kind, per-number-kind Config choice.
 type ConfigSelector func(sdkinstrument.Kind) (int64Config, float64Config Config)
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
@@ -40,6 +41,24 @@ type (
 		lock  sync.Mutex
 		value N
 		seq   uint64
+
+		// clamp, min, and max are set from the configuration;
+		// clamped counts the values that were out of range.
+		clamp   bool
+		min     N
+		max     N
+		clamped uint64
+
+		// timestamps is set from the configuration; updated
+		// is the time of the last Update when it is set.
+		timestamps bool
+		updated    time.Time
+
+		// clock, if non-nil, replaces time.Now.
+		clock aggregator.Clock
+
+		// sequence, if non-nil, replaces sequenceVar.
+		sequence *aggregator.GaugeSequence
 	}
 
 	Int64   = State[int64, number.Int64Traits]
@@ -61,10 +80,28 @@ var (
 	_ aggregator.Methods[int64, Int64]     = Int64Methods{}
 	_ aggregator.Methods[float64, Float64] = Float64Methods{}
 
-	_ aggregation.Gauge = &Int64{}
-	_ aggregation.Gauge = &Float64{}
+	_ aggregator.AccumulateMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.AccumulateMethods[float64, Float64] = Float64Methods{}
+
+	_ aggregation.ClampedGauge = &Int64{}
+	_ aggregation.ClampedGauge = &Float64{}
+
+	_ aggregation.TimestampedGauge = &Int64{}
+	_ aggregation.TimestampedGauge = &Float64{}
+
+	_ aggregation.SequencedGauge = &Int64{}
+	_ aggregation.SequencedGauge = &Float64{}
 )
 
+// WithStaleAfter returns the age beyond which gauge points are not
+// reported, for use as the aggregator.Config GaugeStaleAfter field.
+// Gauges are stale when they were not updated within d of the
+// collection, which applies to cumulative synchronous gauges since
+// delta and asynchronous gauges are only reported when updated.
+func WithStaleAfter(d time.Duration) time.Duration {
+	return d
+}
+
 func NewInt64(x int64) *Int64 {
 	return &Int64{
 		value: x,
@@ -79,6 +116,35 @@ func NewFloat64(x float64) *Float64 {
 	}
 }
 
+// WithSequenceSource returns a source of the sequence numbers that
+// order gauge updates, for use as the aggregator.Config GaugeSequence
+// field.  An update whose sequence number is less than that of the
+// current value is ignored.  By default, each update is assigned the
+// next value of a process-wide counter as it begins; a source, e.g.,
+// the ordering carried by recorded events, lets concurrent writes
+// that arrive out of order resolve to the truly latest value.  Zero
+// is reserved for an unset gauge and is treated as 1.
+func WithSequenceSource(next func() uint64) *aggregator.GaugeSequence {
+	return aggregator.NewGaugeSequence(next)
+}
+
+// WithClamp returns a range that gauge values are clamped into, for
+// use as the aggregator.Config GaugeClamp field.  Values outside
+// [min, max] are replaced by the nearest bound, not dropped.
+func WithClamp[N number.Any](min, max N) aggregator.GaugeClamp {
+	switch any(min).(type) {
+	case int64:
+		var t number.Int64Traits
+		return aggregator.NewGaugeClamp(number.Int64Kind, t.ToNumber(int64(min)), t.ToNumber(int64(max)))
+	case uint64:
+		var t number.Uint64Traits
+		return aggregator.NewGaugeClamp(number.Uint64Kind, t.ToNumber(uint64(min)), t.ToNumber(uint64(max)))
+	default:
+		var t number.Float64Traits
+		return aggregator.NewGaugeClamp(number.Float64Kind, t.ToNumber(float64(min)), t.ToNumber(float64(max)))
+	}
+}
+
 var errUnsetGaugeAccess = fmt.Errorf("unset gauge access")
 
 func (g *State[N, Traits]) Gauge() number.Number {
@@ -97,6 +163,31 @@ func (g *State[N, Traits]) Gauge() number.Number {
 	return t.ToNumber(g.value)
 }
 
+// ClampedCount returns the number of values that were clamped into
+// the configured range.
+func (g *State[N, Traits]) ClampedCount() uint64 {
+	return g.clamped
+}
+
+// LastUpdateTime returns the time of the last update, which is zero
+// unless configured by GaugeTimestamps or GaugeStaleAfter.
+func (g *State[N, Traits]) LastUpdateTime() time.Time {
+	return g.updated
+}
+
+// Sequence returns the sequence number of the last update, zero if
+// the gauge is not set.
+func (g *State[N, Traits]) Sequence() uint64 {
+	return g.seq
+}
+
+// ClearUpdateTimeForTesting erases the time of the last update,
+// allowing it to match test gauges exactly.
+func (g *State[N, Traits]) ClearUpdateTimeForTesting() {
+	g.timestamps = false
+	g.updated = time.Time{}
+}
+
 func (g *State[N, Traits]) Kind() aggregation.Kind {
 	return aggregation.GaugeKind
 }
@@ -112,8 +203,31 @@ func (Methods[N, Traits]) Kind() aggregation.Kind {
 	return aggregation.GaugeKind
 }
 
-func (Methods[N, Traits]) Init(state *State[N, Traits], _ aggregator.Config) {
+func (Methods[N, Traits]) Init(state *State[N, Traits], cfg aggregator.Config) {
 	// Note: storage is zero to start
+	state.timestamps = cfg.GaugeTimestamps || cfg.GaugeStaleAfter != 0
+	if state.timestamps {
+		state.clock = cfg.Clock
+	}
+	state.sequence = cfg.GaugeSequence
+
+	kind, min, max, ok := cfg.GaugeClamp.Get()
+	if !ok {
+		return
+	}
+	state.clamp = true
+	state.min = clampBound[N, Traits](kind, min)
+	state.max = clampBound[N, Traits](kind, max)
+}
+
+// clampBound converts a configured bound to N, which is necessary
+// when a view configures instruments of a different number kind.
+func clampBound[N number.Any, Traits number.Traits[N]](kind number.Kind, bound number.Number) N {
+	var t Traits
+	if kind == t.Kind() {
+		return t.FromNumber(bound)
+	}
+	return N(bound.CoerceToFloat64(kind))
 }
 
 func (Methods[N, Traits]) HasChange(ptr *State[N, Traits]) bool {
@@ -126,8 +240,11 @@ func (Methods[N, Traits]) Move(from, to *State[N, Traits]) {
 
 	to.value = from.value
 	to.seq = from.seq
+	to.clamped = from.clamped
+	to.updated = from.updated
 
 	from.seq = 0
+	from.clamped = 0
 }
 
 func (Methods[N, Traits]) Copy(from, to *State[N, Traits]) {
@@ -135,28 +252,80 @@ func (Methods[N, Traits]) Copy(from, to *State[N, Traits]) {
 	defer from.lock.Unlock()
 	to.value = from.value
 	to.seq = from.seq
+	to.clamped = from.clamped
+	to.updated = from.updated
 }
 
 func (Methods[N, Traits]) Update(state *State[N, Traits], number N) {
-	newSeq := atomic.AddUint64(&sequenceVar, 1)
+	newSeq, ok := state.sequence.Next()
+	if !ok {
+		newSeq = atomic.AddUint64(&sequenceVar, 1)
+	} else if newSeq == 0 {
+		newSeq = 1
+	}
+
+	var now time.Time
+	if state.timestamps {
+		if state.clock != nil {
+			now = state.clock.Now()
+		} else {
+			now = time.Now()
+		}
+	}
 
 	state.lock.Lock()
 	defer state.lock.Unlock()
 
+	if newSeq < state.seq {
+		// A later update was already recorded.
+		return
+	}
+
+	if state.clamp {
+		if number < state.min {
+			number = state.min
+			state.clamped++
+		} else if number > state.max {
+			number = state.max
+			state.clamped++
+		}
+	}
+
 	state.value = number
 	state.seq = newSeq
+	state.updated = now
 }
 
 func (Methods[N, Traits]) Merge(from, to *State[N, Traits]) {
 	to.lock.Lock()
 	defer to.lock.Unlock()
 
+	to.clamped += from.clamped
+
 	if from.seq != 0 && from.seq > to.seq {
 		to.value = from.value
 		to.seq = from.seq
+		to.updated = from.updated
 	}
 }
 
+// Accumulate adds the value of from to the value of to, for gauges
+// whose observations are deltas.  An unset from is ignored.
+func (Methods[N, Traits]) Accumulate(from, to *State[N, Traits]) {
+	from.lock.Lock()
+	defer from.lock.Unlock()
+	to.lock.Lock()
+	defer to.lock.Unlock()
+
+	if from.seq == 0 {
+		return
+	}
+	to.value += from.value
+	to.seq = from.seq
+	to.clamped += from.clamped
+	to.updated = from.updated
+}
+
 func (Methods[N, Traits]) ToAggregation(state *State[N, Traits]) aggregation.Aggregation {
 	return state
 }
@@ -17,6 +17,7 @@ package gauge // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/met
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
@@ -116,3 +117,140 @@ func genericLastValueTest[N number.Any, Storage any, Methods aggregator.Methods[
 		require.Equal(t, N(17), nf(agg.(aggregation.Gauge).Gauge()))
 	})
 }
+
+func TestClamp(t *testing.T) {
+	var methods Float64Methods
+	var input, output Float64
+
+	cfg := aggregator.Config{
+		GaugeClamp: WithClamp(-40.0, 125.0),
+	}
+	methods.Init(&input, cfg)
+	methods.Init(&output, aggregator.Config{})
+
+	methods.Update(&input, 20)
+	methods.Update(&input, 1e6)
+	methods.Move(&input, &output)
+
+	require.Equal(t, 125.0, output.Gauge().CoerceToFloat64(number.Float64Kind))
+	require.Equal(t, uint64(1), output.ClampedCount())
+	require.Equal(t, uint64(0), input.ClampedCount())
+
+	// The last value is kept even when it is the in-range value.
+	methods.Update(&input, -1e6)
+	methods.Update(&input, 21)
+	methods.Merge(&input, &output)
+
+	require.Equal(t, 21.0, output.Gauge().CoerceToFloat64(number.Float64Kind))
+	require.Equal(t, uint64(2), output.ClampedCount())
+}
+
+func TestClampKinds(t *testing.T) {
+	var methods Int64Methods
+	var state Int64
+
+	// A float64 range applies to an int64 gauge.
+	methods.Init(&state, aggregator.Config{
+		GaugeClamp: WithClamp(-1.5, 10.5),
+	})
+	methods.Update(&state, -100)
+	require.Equal(t, int64(-1), number.ToInt64(state.Gauge()))
+	require.Equal(t, uint64(1), state.ClampedCount())
+
+	_, err := aggregator.Config{
+		GaugeClamp: WithClamp[int64](10, 1),
+	}.Validate()
+	require.Error(t, err)
+}
+
+func TestLastUpdateTime(t *testing.T) {
+	var methods Float64Methods
+	var input, output Float64
+
+	methods.Init(&input, aggregator.Config{
+		GaugeTimestamps: true,
+	})
+	methods.Init(&output, aggregator.Config{})
+
+	// Timestamps are not recorded by default.
+	methods.Update(&output, 1)
+	require.True(t, output.LastUpdateTime().IsZero())
+
+	before := time.Now()
+	methods.Update(&input, 2)
+	methods.Merge(&input, &output)
+
+	require.Equal(t, input.LastUpdateTime(), output.LastUpdateTime())
+	require.False(t, output.LastUpdateTime().Before(before))
+
+	_, err := aggregator.Config{
+		GaugeStaleAfter: WithStaleAfter(-time.Second),
+	}.Validate()
+	require.Error(t, err)
+}
+
+func TestSequenceSource(t *testing.T) {
+	var methods Int64Methods
+	var state, output Int64
+
+	// The value of each update carries its own ordering.
+	var next uint64
+	methods.Init(&state, aggregator.Config{
+		GaugeSequence: WithSequenceSource(func() uint64 { return next }),
+	})
+	methods.Init(&output, aggregator.Config{})
+
+	record := func(seq uint64, value int64) {
+		next = seq
+		methods.Update(&state, value)
+	}
+
+	record(5, 50)
+	require.Equal(t, uint64(5), state.Sequence())
+
+	// An earlier update that arrives late is ignored.
+	record(3, 30)
+	require.Equal(t, int64(50), number.ToInt64(state.Gauge()))
+	require.Equal(t, uint64(5), state.Sequence())
+
+	record(7, 70)
+	record(7, 71)
+	require.Equal(t, int64(71), number.ToInt64(state.Gauge()))
+
+	// The output keeps the latest value across collections.
+	methods.Move(&state, &output)
+	record(6, 60)
+	methods.Merge(&state, &output)
+	require.Equal(t, int64(71), number.ToInt64(output.Gauge()))
+	require.Equal(t, uint64(7), output.Sequence())
+
+	// Zero is reserved for an unset gauge.
+	var zero Int64
+	methods.Init(&zero, aggregator.Config{
+		GaugeSequence: WithSequenceSource(func() uint64 { return 0 }),
+	})
+	methods.Update(&zero, 1)
+	require.True(t, methods.HasChange(&zero))
+	require.Equal(t, uint64(1), zero.Sequence())
+}
+
+func TestAccumulate(t *testing.T) {
+	var methods Int64Methods
+	var input, total Int64
+
+	methods.Init(&input, aggregator.Config{})
+	methods.Init(&total, aggregator.Config{})
+
+	// An unset input does not change the total.
+	methods.Accumulate(&input, &total)
+	require.False(t, methods.HasChange(&total))
+
+	methods.Update(&input, 3)
+	methods.Accumulate(&input, &total)
+	methods.Update(&input, 4)
+	methods.Accumulate(&input, &total)
+
+	require.True(t, methods.HasChange(&total))
+	require.Equal(t, int64(7), number.ToInt64(total.Gauge()))
+	require.Equal(t, input.Sequence(), total.Sequence())
+}
@@ -0,0 +1,85 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram // import "github.com/lightstep/go-expohisto"
+
+import (
+	"math/rand"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+)
+
+// DefaultExemplarReservoirSize is the size of the reservoir used by
+// DefaultExemplars, following the OpenTelemetry specification's
+// recommendation for exponential histograms.
+const DefaultExemplarReservoirSize = 20
+
+// DefaultExemplars enables exemplar sampling with a fixed-size
+// reservoir.  Because exponential histogram buckets change as the
+// histogram rescales, exemplars are sampled uniformly rather than
+// per bucket.
+var DefaultExemplars = WithExemplarReservoir(func() aggregator.ExemplarReservoir {
+	return NewFixedSizeReservoir(DefaultExemplarReservoirSize)
+})
+
+// WithExemplarReservoir returns an exemplar configuration for use as
+// the aggregator.Config HistogramExemplars field.  The factory is
+// called once per histogram.
+func WithExemplarReservoir(factory func() aggregator.ExemplarReservoir) *aggregator.ExemplarConfig {
+	return aggregator.NewExemplarConfig(factory)
+}
+
+// fixedSizeReservoir implements uniform reservoir sampling.
+type fixedSizeReservoir struct {
+	// count is the number of exemplars offered since Reset.
+	count     int64
+	exemplars []aggregation.Exemplar
+}
+
+var _ aggregator.ExemplarReservoir = &fixedSizeReservoir{}
+
+// NewFixedSizeReservoir returns a reservoir that retains a uniform
+// sample of at most size exemplars.
+func NewFixedSizeReservoir(size int) aggregator.ExemplarReservoir {
+	if size < 1 {
+		size = 1
+	}
+	return &fixedSizeReservoir{
+		exemplars: make([]aggregation.Exemplar, 0, size),
+	}
+}
+
+// Offer implements aggregator.ExemplarReservoir.
+func (r *fixedSizeReservoir) Offer(ex aggregation.Exemplar) {
+	r.count++
+	if len(r.exemplars) < cap(r.exemplars) {
+		r.exemplars = append(r.exemplars, ex)
+		return
+	}
+	if idx := rand.Int63n(r.count); idx < int64(len(r.exemplars)) {
+		r.exemplars[idx] = ex
+	}
+}
+
+// Exemplars implements aggregator.ExemplarReservoir.
+func (r *fixedSizeReservoir) Exemplars() []aggregation.Exemplar {
+	return r.exemplars
+}
+
+// Reset implements aggregator.ExemplarReservoir.
+func (r *fixedSizeReservoir) Reset() {
+	r.count = 0
+	r.exemplars = r.exemplars[:0]
+}
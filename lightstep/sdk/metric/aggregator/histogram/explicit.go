@@ -0,0 +1,447 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.opentelemetry.io/otel"
+)
+
+// The explicit-bucket histogram is selected when the aggregator
+// configuration has HistogramBoundaries, see WithExplicitBoundaries().
+// Bucket i counts values in (boundaries[i-1], boundaries[i]].  Int64
+// values are compared exactly with the boundaries, which need not be
+// integers, e.g., a boundary of 2.5 separates 2 from 3.
+
+type (
+	ExplicitMethods[N number.Any, Traits number.Traits[N]] struct{}
+
+	Explicit[N number.Any, Traits number.Traits[N]] struct {
+		lock       sync.Mutex
+		boundaries aggregator.Boundaries
+		counts     []uint64
+		sum        N
+		count      uint64
+
+		// min and max are tracked unless noMinMax is set.
+		// valueCount is the number of values recorded
+		// individually, which determine min and max, since
+		// pre-bucketed data does not.
+		noMinMax   bool
+		valueCount uint64
+		min        N
+		max        N
+	}
+
+	ExplicitInt64Methods   = ExplicitMethods[int64, number.Int64Traits]
+	ExplicitFloat64Methods = ExplicitMethods[float64, number.Float64Traits]
+
+	ExplicitInt64   = Explicit[int64, number.Int64Traits]
+	ExplicitFloat64 = Explicit[float64, number.Float64Traits]
+)
+
+var (
+	_ aggregator.Methods[int64, ExplicitInt64]     = ExplicitInt64Methods{}
+	_ aggregator.Methods[float64, ExplicitFloat64] = ExplicitFloat64Methods{}
+
+	_ aggregator.WeightedMethods[int64, ExplicitInt64]     = ExplicitInt64Methods{}
+	_ aggregator.WeightedMethods[float64, ExplicitFloat64] = ExplicitFloat64Methods{}
+
+	_ aggregator.MemoryMethods[int64, ExplicitInt64]     = ExplicitInt64Methods{}
+	_ aggregator.MemoryMethods[float64, ExplicitFloat64] = ExplicitFloat64Methods{}
+
+	_ aggregator.BucketMethods[int64, ExplicitInt64]     = ExplicitInt64Methods{}
+	_ aggregator.BucketMethods[float64, ExplicitFloat64] = ExplicitFloat64Methods{}
+
+	_ aggregation.ExplicitHistogram = &ExplicitInt64{}
+	_ aggregation.ExplicitHistogram = &ExplicitFloat64{}
+
+	_ aggregation.OptionalMinMax = &ExplicitInt64{}
+	_ aggregation.OptionalMinMax = &ExplicitFloat64{}
+
+	// DefaultBoundaries are the explicit-bucket boundaries used
+	// by the explicit_histogram aggregation when none are
+	// configured.  These match the OpenTelemetry specification.
+	DefaultBoundaries = []float64{0, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000}
+
+	errMismatchedBoundaries = fmt.Errorf("histogram merge with mismatched boundaries")
+)
+
+// DefaultBoundariesEnv is the environment variable that overrides
+// DefaultBoundaries with a comma-separated list of finite numbers in
+// increasing order.
+const DefaultBoundariesEnv = "OTEL_METRICS_HISTOGRAM_DEFAULT_BOUNDARIES"
+
+// envBoundaries caches the parsed value of DefaultBoundariesEnv, so
+// that an invalid setting is reported once per value.
+var envBoundaries struct {
+	lock   sync.Mutex
+	value  string
+	bounds []float64
+}
+
+// DefaultExplicitBoundaries returns the boundaries used by the
+// explicit_histogram aggregation when neither views nor hints
+// configure them: those set in the environment, otherwise
+// DefaultBoundaries.  An invalid setting is reported through the
+// OpenTelemetry error handler and ignored.
+func DefaultExplicitBoundaries() []float64 {
+	value := strings.TrimSpace(os.Getenv(DefaultBoundariesEnv))
+	if value == "" {
+		return DefaultBoundaries
+	}
+
+	envBoundaries.lock.Lock()
+	defer envBoundaries.lock.Unlock()
+
+	if envBoundaries.bounds == nil || envBoundaries.value != value {
+		bounds, err := parseBoundaries(value)
+		if err != nil {
+			otel.Handle(fmt.Errorf("%s: %w", DefaultBoundariesEnv, err))
+			bounds = DefaultBoundaries
+		}
+		envBoundaries.value = value
+		envBoundaries.bounds = bounds
+	}
+	return envBoundaries.bounds
+}
+
+// parseBoundaries parses a comma-separated list of boundaries.
+func parseBoundaries(value string) ([]float64, error) {
+	var bounds []float64
+	for _, field := range strings.Split(value, ",") {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid histogram boundary: %q", field)
+		}
+		bounds = append(bounds, bound)
+	}
+	if _, err := aggregator.NewBoundaries(bounds).Validate(); err != nil {
+		return nil, err
+	}
+	return bounds, nil
+}
+
+// WithExplicitBoundaries returns boundaries for the explicit-bucket
+// histogram, for use as the aggregator.Config HistogramBoundaries
+// field.  Boundaries must be finite and sorted in increasing order.
+func WithExplicitBoundaries(bounds []float64) aggregator.Boundaries {
+	return aggregator.NewBoundaries(bounds)
+}
+
+// WithMaxBuckets returns the limit on the number of explicit-bucket
+// histogram buckets, for use as the aggregator.Config
+// HistogramMaxBuckets field.  Boundaries in excess of the limit are
+// decimated uniformly when the instrument is compiled, see
+// aggregator.Boundaries.Limit.
+func WithMaxBuckets(n int) int {
+	return n
+}
+
+// WithMinMax returns whether explicit-bucket histograms track their
+// minimum and maximum values, for use as the aggregator.Config
+// HistogramNoMinMax field.  Tracking is enabled by default;
+// WithMinMax(false) saves two comparisons per update.
+func WithMinMax(enabled bool) bool {
+	return !enabled
+}
+
+func NewExplicitFloat64(bounds []float64, fs ...float64) *ExplicitFloat64 {
+	return newExplicit[float64, number.Float64Traits](bounds, fs...)
+}
+
+func NewExplicitInt64(bounds []float64, is ...int64) *ExplicitInt64 {
+	return newExplicit[int64, number.Int64Traits](bounds, is...)
+}
+
+func newExplicit[N number.Any, Traits number.Traits[N]](bounds []float64, values ...N) *Explicit[N, Traits] {
+	var methods ExplicitMethods[N, Traits]
+	agg := &Explicit[N, Traits]{}
+	methods.Init(agg, aggregator.Config{
+		HistogramBoundaries: WithExplicitBoundaries(bounds),
+	})
+	for _, v := range values {
+		methods.Update(agg, v)
+	}
+	return agg
+}
+
+func (h *Explicit[N, Traits]) Kind() aggregation.Kind {
+	return aggregation.ExplicitHistogramKind
+}
+
+func (h *Explicit[N, Traits]) Count() uint64 {
+	return h.count
+}
+
+func (h *Explicit[N, Traits]) Sum() number.Number {
+	var traits Traits
+	return traits.ToNumber(h.sum)
+}
+
+// Boundaries returns a copy of the bucket boundaries.
+func (h *Explicit[N, Traits]) Boundaries() []float64 {
+	return h.boundaries.ToSlice()
+}
+
+// BucketCounts returns a copy of the bucket counts.
+func (h *Explicit[N, Traits]) BucketCounts() []uint64 {
+	return append([]uint64(nil), h.counts...)
+}
+
+// HasMinMax returns true when min and max are tracked and at least
+// one value was recorded individually, not as pre-bucketed data.
+func (h *Explicit[N, Traits]) HasMinMax() bool {
+	return !h.noMinMax && h.valueCount != 0
+}
+
+func (h *Explicit[N, Traits]) Min() number.Number {
+	var traits Traits
+	return traits.ToNumber(h.min)
+}
+
+func (h *Explicit[N, Traits]) Max() number.Number {
+	var traits Traits
+	return traits.ToNumber(h.max)
+}
+
+func (ExplicitMethods[N, Traits]) Kind() aggregation.Kind {
+	return aggregation.ExplicitHistogramKind
+}
+
+func (ExplicitMethods[N, Traits]) Init(agg *Explicit[N, Traits], cfg aggregator.Config) {
+	agg.boundaries = cfg.HistogramBoundaries
+	agg.counts = make([]uint64, agg.boundaries.Len()+1)
+	agg.noMinMax = cfg.HistogramNoMinMax
+}
+
+func (ExplicitMethods[N, Traits]) HasChange(ptr *Explicit[N, Traits]) bool {
+	return ptr.count != 0
+}
+
+// MemorySize counts the bucket counts; the boundaries are shared by
+// all aggregators of an instrument.
+func (ExplicitMethods[N, Traits]) MemorySize(ptr *Explicit[N, Traits]) int {
+	ptr.lock.Lock()
+	defer ptr.lock.Unlock()
+	return 8 * cap(ptr.counts)
+}
+
+func (ExplicitMethods[N, Traits]) Update(agg *Explicit[N, Traits], number N) {
+	agg.update(number, 1)
+}
+
+// UpdateWeighted implements aggregator.WeightedMethods, counting
+// weight observations of number.
+func (ExplicitMethods[N, Traits]) UpdateWeighted(_ context.Context, agg *Explicit[N, Traits], number N, weight uint64) {
+	agg.update(number, weight)
+}
+
+// UpdateBucket implements aggregator.BucketMethods, adding count to
+// the bucket at index, which counts values in (boundaries[index-1],
+// boundaries[index]].
+func (ExplicitMethods[N, Traits]) UpdateBucket(agg *Explicit[N, Traits], index int, count uint64, sum N) bool {
+	agg.lock.Lock()
+	defer agg.lock.Unlock()
+
+	if index < 0 || index >= len(agg.counts) {
+		return false
+	}
+	if count > math.MaxUint64-agg.count {
+		count = math.MaxUint64 - agg.count
+		reportExplicitOverflow()
+	}
+	agg.counts[index] += count
+	agg.sum += sum
+	agg.count += count
+	return true
+}
+
+// bucketIndex returns the index of the bucket that counts number.
+func (h *Explicit[N, Traits]) bucketIndex(num N) int {
+	var traits Traits
+	if traits.Kind() == number.Int64Kind {
+		// Converting to float64 would round values beyond
+		// 2^53, possibly onto a boundary.
+		value := int64(num)
+		return sort.Search(h.boundaries.Len(), func(i int) bool {
+			return int64AtMost(value, h.boundaries.At(i))
+		})
+	}
+	value := float64(num)
+	return sort.Search(h.boundaries.Len(), func(i int) bool {
+		return value <= h.boundaries.At(i)
+	})
+}
+
+// int64AtMost returns value <= bound, computed exactly.  Since value
+// is an integer, this is value <= floor(bound), which is exactly
+// representable when bound is within the range of int64.
+func int64AtMost(value int64, bound float64) bool {
+	switch {
+	case bound >= 0x1p63:
+		return true
+	case bound < -0x1p63:
+		return false
+	}
+	return value <= int64(math.Floor(bound))
+}
+
+func (h *Explicit[N, Traits]) update(number N, weight uint64) {
+	idx := h.bucketIndex(number)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// Each bucket count is at most the total count, so
+	// limiting the weight to what the total count can hold
+	// saturates the counts instead of wrapping around.
+	if weight > math.MaxUint64-h.count {
+		weight = math.MaxUint64 - h.count
+		reportExplicitOverflow()
+	}
+
+	if !h.noMinMax && weight != 0 {
+		if h.valueCount == 0 || number < h.min {
+			h.min = number
+		}
+		if h.valueCount == 0 || number > h.max {
+			h.max = number
+		}
+		h.valueCount = saturatingAdd(h.valueCount, weight)
+	}
+	h.counts[idx] += weight
+	h.sum += number * N(weight)
+	h.count += weight
+}
+
+func (ExplicitMethods[N, Traits]) Move(from, to *Explicit[N, Traits]) {
+	from.lock.Lock()
+	defer from.lock.Unlock()
+
+	to.boundaries = from.boundaries
+	to.counts, from.counts = from.counts, clearCounts(to.counts, len(from.counts))
+	to.sum, from.sum = from.sum, 0
+	to.count, from.count = from.count, 0
+	to.noMinMax = from.noMinMax
+	to.valueCount, from.valueCount = from.valueCount, 0
+	to.min, from.min = from.min, 0
+	to.max, from.max = from.max, 0
+}
+
+func (ExplicitMethods[N, Traits]) Copy(from, to *Explicit[N, Traits]) {
+	from.lock.Lock()
+	defer from.lock.Unlock()
+
+	to.boundaries = from.boundaries
+	to.counts = append(to.counts[:0], from.counts...)
+	to.sum = from.sum
+	to.count = from.count
+	to.noMinMax = from.noMinMax
+	to.valueCount = from.valueCount
+	to.min = from.min
+	to.max = from.max
+}
+
+func (ExplicitMethods[N, Traits]) Merge(from, to *Explicit[N, Traits]) {
+	to.lock.Lock()
+	defer to.lock.Unlock()
+
+	if from.boundaries != to.boundaries {
+		if from.count != 0 {
+			doevery.TimePeriod(30*time.Second, func() {
+				otel.Handle(errMismatchedBoundaries)
+			})
+		}
+		return
+	}
+
+	if len(to.counts) != len(from.counts) {
+		to.counts = clearCounts(to.counts, len(from.counts))
+	}
+	if from.count > math.MaxUint64-to.count {
+		reportExplicitOverflow()
+	}
+	for i, c := range from.counts {
+		to.counts[i] = saturatingAdd(to.counts[i], c)
+	}
+	if from.valueCount != 0 {
+		if to.valueCount == 0 || from.min < to.min {
+			to.min = from.min
+		}
+		if to.valueCount == 0 || from.max > to.max {
+			to.max = from.max
+		}
+	}
+	to.valueCount = saturatingAdd(to.valueCount, from.valueCount)
+	to.sum += from.sum
+	to.count = saturatingAdd(to.count, from.count)
+}
+
+func (ExplicitMethods[N, Traits]) ToAggregation(histo *Explicit[N, Traits]) aggregation.Aggregation {
+	return histo
+}
+
+func (ExplicitMethods[N, Traits]) ToStorage(aggr aggregation.Aggregation) (*Explicit[N, Traits], bool) {
+	r, ok := aggr.(*Explicit[N, Traits])
+	return r, ok
+}
+
+func (ExplicitMethods[N, Traits]) SubtractSwap(operand, argument *Explicit[N, Traits]) {
+	// This can't be called b/c histogram's are only used with synchronous instruments,
+	// which start as delta temporality and thus never subtract.
+	panic("impossible call")
+}
+
+// saturatingAdd returns a+b, or math.MaxUint64 when the sum
+// overflows.
+func saturatingAdd(a, b uint64) uint64 {
+	if b > math.MaxUint64-a {
+		return math.MaxUint64
+	}
+	return a + b
+}
+
+// reportExplicitOverflow reports a saturated count, rate-limited.
+func reportExplicitOverflow() {
+	doevery.TimePeriod(30*time.Second, func() {
+		otel.Handle(aggregator.ErrCountOverflow)
+	})
+}
+
+// clearCounts returns a zeroed slice of the requested size, reusing
+// the input when possible.
+func clearCounts(counts []uint64, size int) []uint64 {
+	if len(counts) != size {
+		return make([]uint64, size)
+	}
+	for i := range counts {
+		counts[i] = 0
+	}
+	return counts
+}
@@ -0,0 +1,311 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/test"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestExplicitInt64(t *testing.T) {
+	test.GenericAggregatorTest[int64, ExplicitInt64, ExplicitInt64Methods](t, number.ToInt64)
+}
+
+func TestExplicitFloat64(t *testing.T) {
+	test.GenericAggregatorTest[float64, ExplicitFloat64, ExplicitFloat64Methods](t, number.ToFloat64)
+}
+
+func TestExplicitBuckets(t *testing.T) {
+	bounds := []float64{0.1, 0.25, 0.5, 1, 2.5, 5}
+
+	// Values equal to a boundary fall into the bucket it bounds.
+	h := NewExplicitFloat64(bounds, -1, 0.1, 0.2, 0.25, 0.3, 1, 4.9, 5, 5.1, 1e9)
+
+	require.Equal(t, bounds, h.Boundaries())
+	require.Equal(t, []uint64{2, 2, 1, 1, 0, 2, 2}, h.BucketCounts())
+	require.Equal(t, uint64(10), h.Count())
+	require.InDelta(t, 1e9+15.85, number.ToFloat64(h.Sum()), 1e-6)
+
+	// The results are copies.
+	h.BucketCounts()[0] = 100
+	h.Boundaries()[0] = 100
+	require.Equal(t, bounds, h.Boundaries())
+	require.Equal(t, uint64(2), h.BucketCounts()[0])
+}
+
+func TestExplicitFractionalBoundaries(t *testing.T) {
+	bounds := []float64{-2.5, 0, 2.5, 5}
+
+	// Integer values are separated by fractional boundaries and,
+	// like floating point values, values equal to a boundary fall
+	// into the bucket it bounds.
+	hi := NewExplicitInt64(bounds, -3, -2, 0, 1, 2, 3, 5, 6)
+	hf := NewExplicitFloat64(bounds, -3, -2.5, -2, 0, 2, 2.5, 3, 5, 6)
+
+	require.Equal(t, []uint64{1, 2, 2, 2, 1}, hi.BucketCounts())
+	require.Equal(t, []uint64{2, 2, 2, 2, 1}, hf.BucketCounts())
+
+	// Integers beyond 2^53 are compared exactly, where their
+	// float64 conversion would round onto the boundary.
+	const big = 1 << 53
+	hb := NewExplicitInt64([]float64{big}, big-1, big, big+1, math.MaxInt64, math.MinInt64)
+	require.Equal(t, []uint64{3, 2}, hb.BucketCounts())
+
+	// Boundaries beyond the range of int64.
+	he := NewExplicitInt64([]float64{-1e300, 1e300}, math.MinInt64, 0, math.MaxInt64)
+	require.Equal(t, []uint64{0, 3, 0}, he.BucketCounts())
+}
+
+func TestExplicitMerge(t *testing.T) {
+	bounds := []float64{1, 10}
+	var methods ExplicitFloat64Methods
+
+	a := NewExplicitFloat64(bounds, 0, 5, 50)
+	b := NewExplicitFloat64(bounds, 1, 10, 100)
+	methods.Merge(a, b)
+
+	require.Equal(t, NewExplicitFloat64(bounds, 0, 5, 50, 1, 10, 100), b)
+
+	var moved ExplicitFloat64
+	methods.Init(&moved, aggregator.Config{
+		HistogramBoundaries: WithExplicitBoundaries(bounds),
+	})
+	methods.Move(b, &moved)
+
+	require.Equal(t, NewExplicitFloat64(bounds, 0, 5, 50, 1, 10, 100), &moved)
+	require.Equal(t, NewExplicitFloat64(bounds), b)
+	require.False(t, methods.HasChange(b))
+}
+
+func TestExplicitUpdateWeighted(t *testing.T) {
+	bounds := []float64{1, 10}
+	var methods ExplicitInt64Methods
+	ctx := context.Background()
+
+	a := NewExplicitInt64(bounds, 5)
+	methods.UpdateWeighted(ctx, a, 0, 3)
+	b := NewExplicitInt64(bounds)
+	methods.UpdateWeighted(ctx, b, 50, 2)
+	methods.Merge(a, b)
+
+	require.Equal(t, NewExplicitInt64(bounds, 5, 0, 0, 0, 50, 50), b)
+	require.Equal(t, []uint64{3, 1, 2}, b.BucketCounts())
+	require.Equal(t, uint64(6), b.Count())
+	require.Equal(t, int64(105), number.ToInt64(b.Sum()))
+}
+
+func TestExplicitUpdateBucket(t *testing.T) {
+	bounds := []float64{1, 10}
+	var methods ExplicitFloat64Methods
+
+	a := NewExplicitFloat64(bounds)
+	require.True(t, methods.UpdateBucket(a, 1, 4, 20))
+	require.True(t, methods.UpdateBucket(a, 2, 1, 40))
+	require.False(t, methods.UpdateBucket(a, 3, 1, 1))
+	require.False(t, methods.UpdateBucket(a, -1, 1, 1))
+
+	// Pre-bucketed data does not determine min and max.
+	require.True(t, methods.HasChange(a))
+	require.False(t, a.HasMinMax())
+
+	// Merges with recorded values.
+	b := NewExplicitFloat64(bounds, 0.5, 50)
+	methods.Merge(a, b)
+	require.Equal(t, []uint64{1, 4, 2}, b.BucketCounts())
+	require.Equal(t, uint64(7), b.Count())
+	require.Equal(t, 110.5, number.ToFloat64(b.Sum()))
+	require.True(t, b.HasMinMax())
+	require.Equal(t, 0.5, number.ToFloat64(b.Min()))
+	require.Equal(t, 50.0, number.ToFloat64(b.Max()))
+
+	methods.Merge(b, a)
+	require.Equal(t, []uint64{1, 8, 3}, a.BucketCounts())
+	require.True(t, a.HasMinMax())
+	require.Equal(t, 0.5, number.ToFloat64(a.Min()))
+	require.Equal(t, 50.0, number.ToFloat64(a.Max()))
+}
+
+func TestExplicitMinMax(t *testing.T) {
+	bounds := []float64{1, 10}
+	var methods ExplicitFloat64Methods
+
+	empty := NewExplicitFloat64(bounds)
+	require.False(t, empty.HasMinMax())
+
+	a := NewExplicitFloat64(bounds, 5, -2, 7)
+	require.True(t, a.HasMinMax())
+	require.Equal(t, -2.0, number.ToFloat64(a.Min()))
+	require.Equal(t, 7.0, number.ToFloat64(a.Max()))
+
+	// Merging into an empty histogram takes the input min/max.
+	methods.Merge(a, empty)
+	require.Equal(t, -2.0, number.ToFloat64(empty.Min()))
+	require.Equal(t, 7.0, number.ToFloat64(empty.Max()))
+
+	// Merging an empty histogram has no effect.
+	methods.Merge(NewExplicitFloat64(bounds), a)
+	require.Equal(t, -2.0, number.ToFloat64(a.Min()))
+	require.Equal(t, 7.0, number.ToFloat64(a.Max()))
+
+	b := NewExplicitFloat64(bounds, 3, 100)
+	methods.Merge(b, a)
+	require.Equal(t, -2.0, number.ToFloat64(a.Min()))
+	require.Equal(t, 100.0, number.ToFloat64(a.Max()))
+
+	var disabled ExplicitFloat64
+	methods.Init(&disabled, aggregator.Config{
+		HistogramBoundaries: WithExplicitBoundaries(bounds),
+		HistogramNoMinMax:   WithMinMax(false),
+	})
+	methods.Update(&disabled, 5)
+	require.False(t, disabled.HasMinMax())
+	require.Equal(t, 0.0, number.ToFloat64(disabled.Max()))
+}
+
+func TestExplicitMergeMismatch(t *testing.T) {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+
+	var methods ExplicitFloat64Methods
+
+	a := NewExplicitFloat64([]float64{1, 10}, 5)
+	b := NewExplicitFloat64([]float64{1, 100}, 5)
+	methods.Merge(a, b)
+
+	require.Equal(t, NewExplicitFloat64([]float64{1, 100}, 5), b)
+	require.Equal(t, 1, len(errs))
+	require.ErrorIs(t, errs[0], errMismatchedBoundaries)
+}
+
+func TestExplicitBoundariesValidate(t *testing.T) {
+	for _, bounds := range [][]float64{
+		{1, 1},
+		{2, 1},
+		{1, math.NaN()},
+		{math.Inf(-1), 0},
+	} {
+		cfg, err := aggregator.Config{
+			HistogramBoundaries: WithExplicitBoundaries(bounds),
+		}.Validate()
+		require.Error(t, err, "%v", bounds)
+		require.False(t, cfg.HistogramBoundaries.Defined())
+	}
+
+	cfg := aggregator.Config{
+		HistogramBoundaries: WithExplicitBoundaries([]float64{-1, 0, 1}),
+	}
+	require.True(t, cfg.Valid())
+	require.Equal(t, cfg, aggregator.Config{
+		HistogramBoundaries: WithExplicitBoundaries([]float64{-1, 0, 1}),
+	})
+}
+
+func TestExplicitBoundariesLimit(t *testing.T) {
+	bounds := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	for _, test := range []struct {
+		maxBuckets int
+		expect     []float64
+	}{
+		{0, bounds},
+		{11, bounds},
+		{10, []float64{0, 1, 2, 3, 5, 6, 7, 8, 9}},
+		{5, []float64{0, 3, 6, 9}},
+		{2, []float64{5}},
+		{1, []float64{}},
+	} {
+		limited := WithExplicitBoundaries(bounds).Limit(WithMaxBuckets(test.maxBuckets))
+		require.True(t, limited.Defined())
+		require.Equal(t, test.expect, limited.ToSlice(), "%d", test.maxBuckets)
+	}
+
+	_, err := aggregator.Config{
+		HistogramMaxBuckets: WithMaxBuckets(-1),
+	}.Validate()
+	require.Error(t, err)
+}
+
+func TestExplicitCountOverflow(t *testing.T) {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+
+	var methods ExplicitInt64Methods
+	ctx := context.Background()
+	bounds := []float64{10}
+
+	// Inject a near-maximum count by merging.
+	near := NewExplicitInt64(bounds)
+	methods.UpdateWeighted(ctx, near, 0, math.MaxUint64-1)
+
+	agg := NewExplicitInt64(bounds)
+	methods.Merge(near, agg)
+	require.Equal(t, uint64(math.MaxUint64-1), agg.Count())
+	require.Empty(t, errs)
+
+	// The counts saturate.
+	methods.Update(agg, 1)
+	methods.Update(agg, 1)
+	methods.Update(agg, 20)
+	require.Equal(t, uint64(math.MaxUint64), agg.Count())
+	require.Equal(t, []uint64{math.MaxUint64, 0}, agg.BucketCounts())
+	require.Equal(t, int64(1), number.ToInt64(agg.Sum()))
+
+	// Merged buckets saturate individually.
+	methods.Merge(NewExplicitInt64(bounds, 1, 20), agg)
+	require.Equal(t, uint64(math.MaxUint64), agg.Count())
+	require.Equal(t, []uint64{math.MaxUint64, 1}, agg.BucketCounts())
+
+	// The error is rate-limited.
+	require.Equal(t, 1, len(errs))
+	require.ErrorIs(t, errs[0], aggregator.ErrCountOverflow)
+}
+
+func TestDefaultExplicitBoundaries(t *testing.T) {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+
+	t.Setenv(DefaultBoundariesEnv, "")
+	require.Equal(t, DefaultBoundaries, DefaultExplicitBoundaries())
+
+	t.Setenv(DefaultBoundariesEnv, "0.5, 1,2.5")
+	require.Equal(t, []float64{0.5, 1, 2.5}, DefaultExplicitBoundaries())
+	require.Empty(t, errs)
+
+	for _, invalid := range []string{"1,x", "2,1", "1,,2", "1,Inf"} {
+		t.Setenv(DefaultBoundariesEnv, invalid)
+		require.Equal(t, DefaultBoundaries, DefaultExplicitBoundaries())
+		require.Equal(t, DefaultBoundaries, DefaultExplicitBoundaries())
+	}
+
+	// Each invalid value is reported once.
+	require.Equal(t, 4, len(errs))
+	require.Contains(t, errs[0].Error(), DefaultBoundariesEnv)
+}
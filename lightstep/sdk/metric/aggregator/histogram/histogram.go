@@ -15,23 +15,94 @@
 package histogram // import "github.com/lightstep/go-expohisto"
 
 import (
+	"context"
+	"math"
 	"sync"
+	"time"
 
+	"github.com/lightstep/go-expohisto/mapping"
+	"github.com/lightstep/go-expohisto/mapping/exponent"
+	"github.com/lightstep/go-expohisto/mapping/logarithm"
 	"github.com/lightstep/go-expohisto/structure"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // The methods in this file adapt the basic structure in ./structure
 // to the Methods interface pattern used in this SDK.
 
 type (
-	Methods[N number.Any, Traits number.Traits[N]] struct{}
+	Methods[N number.Signed, Traits number.Traits[N]] struct{}
 
-	Histogram[N number.Any, Traits number.Traits[N]] struct {
+	Histogram[N number.Signed, Traits number.Traits[N]] struct {
 		lock      sync.Mutex
 		Histogram structure.Histogram[N]
+
+		// maxScale optionally limits the output scale.
+		maxScale aggregator.MaxScale
+
+		// scaled records the values in place of Histogram when
+		// maxScale limits the scale, or when a merge combines
+		// histograms with such a limit.  scaled is in use when
+		// mapping is set.  Its buckets are
+		// those of scale base: each value is recorded as the
+		// substitute whose bucket at logarithm.MaxScale has the
+		// index of the value's bucket at scale base.  The scale
+		// of the output is the scale of scaled less
+		// logarithm.MaxScale-base.  stats summarizes the values
+		// recorded in scaled, whose own sum, min, and max are
+		// those of the substitutes.
+		scaled  structure.Histogram[float64]
+		base    int32
+		mapping mapping.Mapping
+		stats   zeroValues[N]
+
+		// config is the structure configuration, for
+		// rebuilding scaled.
+		config structure.Config
+
+		// trim omits empty buckets at either end of the output.
+		trim bool
+
+		// zeroThreshold is the magnitude at or below which
+		// values are counted as zero.
+		zeroThreshold float64
+
+		// zero summarizes the values that the zero threshold
+		// counted as zero, which are not recorded in
+		// Histogram so that Sum, Min, and Max stay exact.
+		zero zeroValues[N]
+
+		// exemplars is nil unless exemplars are configured.
+		exemplars aggregator.ExemplarReservoir
+	}
+
+	// zeroValues summarizes a set of values, e.g., those within
+	// the zero threshold.
+	zeroValues[N number.Signed] struct {
+		count    uint64
+		sum      N
+		min, max N
+	}
+
+	// downscaled presents Buckets at a lower scale than the one
+	// they were recorded at, by combining 2**shift adjacent
+	// buckets into one.
+	downscaled struct {
+		aggregation.Buckets
+		shift int32
+	}
+
+	// trimmed presents a sub-range of Buckets, starting at
+	// position start.
+	trimmed struct {
+		aggregation.Buckets
+		start  uint32
+		length uint32
 	}
 
 	Config = structure.Config
@@ -50,6 +121,21 @@ var (
 
 	_ aggregation.Histogram = &Histogram[int64, number.Int64Traits]{}
 	_ aggregation.Histogram = &Histogram[float64, number.Float64Traits]{}
+
+	_ aggregation.HasExemplars = &Histogram[int64, number.Int64Traits]{}
+	_ aggregation.HasExemplars = &Histogram[float64, number.Float64Traits]{}
+
+	_ aggregation.HasZeroThreshold = &Histogram[int64, number.Int64Traits]{}
+	_ aggregation.HasZeroThreshold = &Histogram[float64, number.Float64Traits]{}
+
+	_ aggregator.ContextMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.ContextMethods[float64, Float64] = Float64Methods{}
+
+	_ aggregator.WeightedMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.WeightedMethods[float64, Float64] = Float64Methods{}
+
+	_ aggregator.MemoryMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.MemoryMethods[float64, Float64] = Float64Methods{}
 )
 
 const (
@@ -61,12 +147,14 @@ const (
 func NewFloat64(cfg Config, fs ...float64) *Float64 {
 	return &Float64{
 		Histogram: *structure.NewFloat64(cfg, fs...),
+		config:    cfg,
 	}
 }
 
 func NewInt64(cfg Config, is ...int64) *Int64 {
 	return &Int64{
 		Histogram: *structure.NewInt64(cfg, is...),
+		config:    cfg,
 	}
 }
 
@@ -78,81 +166,637 @@ func WithMaxSize(sz int32) Option {
 	return structure.WithMaxSize(sz)
 }
 
+// WithMaxScale returns a limit on the scale of histogram output, for
+// use as the aggregator.Config HistogramMaxScale field.  Histograms
+// are recorded at no more than this scale, so that their memory is
+// bounded by the buckets at this scale.
+func WithMaxScale(scale int32) aggregator.MaxScale {
+	return aggregator.NewMaxScale(scale)
+}
+
 func (h *Histogram[N, Traits]) Kind() aggregation.Kind {
 	return aggregation.HistogramKind
 }
 
 func (h *Histogram[N, Traits]) Max() number.Number {
 	var traits Traits
-	return traits.ToNumber(h.Histogram.Max())
+	return traits.ToNumber(h.summary().max)
 }
 
 func (h *Histogram[N, Traits]) Min() number.Number {
 	var traits Traits
-	return traits.ToNumber(h.Histogram.Min())
+	return traits.ToNumber(h.summary().min)
 }
 
 func (h *Histogram[N, Traits]) Sum() number.Number {
 	var traits Traits
-	return traits.ToNumber(h.Histogram.Sum())
+	return traits.ToNumber(h.summary().sum)
 }
 
 func (h *Histogram[N, Traits]) Count() uint64 {
-	return h.Histogram.Count()
+	return h.Histogram.Count() + h.zero.count + h.stats.count
+}
+
+// summary combines the values recorded in Histogram, within the zero
+// threshold, and in scaled.
+func (h *Histogram[N, Traits]) summary() zeroValues[N] {
+	sum := zeroValues[N]{
+		count: h.Histogram.Count(),
+		sum:   h.Histogram.Sum(),
+		min:   h.Histogram.Min(),
+		max:   h.Histogram.Max(),
+	}
+	sum.merge(&h.zero)
+	sum.merge(&h.stats)
+	return sum
 }
 
+// ZeroCount includes the values within the zero threshold, along
+// with the buckets that a merge brought within the threshold.
 func (h *Histogram[N, Traits]) ZeroCount() uint64 {
-	return h.Histogram.ZeroCount()
+	_, negZeros := h.buckets(h.negative())
+	_, posZeros := h.buckets(h.positive())
+	return h.Histogram.ZeroCount() + h.scaled.ZeroCount() + h.zero.count + negZeros + posZeros
+}
+
+// ZeroThreshold implements aggregation.HasZeroThreshold.
+func (h *Histogram[N, Traits]) ZeroThreshold() float64 {
+	return h.zeroThreshold
 }
 
 func (h *Histogram[N, Traits]) Negative() aggregation.Buckets {
-	return h.Histogram.Negative()
+	b, _ := h.buckets(h.negative())
+	return b
 }
 
 func (h *Histogram[N, Traits]) Positive() aggregation.Buckets {
-	return h.Histogram.Positive()
+	b, _ := h.buckets(h.positive())
+	return b
 }
 
 func (h *Histogram[N, Traits]) Scale() int32 {
+	scale := h.recordedScale()
+	if limit, ok := h.maxScale.Get(); ok && scale > limit {
+		return limit
+	}
+	return scale
+}
+
+// isScaled returns true when scaled holds the buckets.  Merge keeps
+// buckets in only one of Histogram and scaled.
+func (h *Histogram[N, Traits]) isScaled() bool {
+	return h.scaled.Count() != h.scaled.ZeroCount()
+}
+
+// recordedScale returns the scale of the recorded buckets.
+func (h *Histogram[N, Traits]) recordedScale() int32 {
+	if h.isScaled() {
+		return h.scaled.Scale() - (logarithm.MaxScale - h.base)
+	}
 	return h.Histogram.Scale()
 }
 
+// positive returns the recorded positive buckets.
+func (h *Histogram[N, Traits]) positive() *structure.Buckets {
+	if h.isScaled() {
+		return h.scaled.Positive()
+	}
+	return h.Histogram.Positive()
+}
+
+// negative returns the recorded negative buckets.
+func (h *Histogram[N, Traits]) negative() *structure.Buckets {
+	if h.isScaled() {
+		return h.scaled.Negative()
+	}
+	return h.Histogram.Negative()
+}
+
+// limited returns true when maxScale limits the recording scale.
+func (h *Histogram[N, Traits]) limited() bool {
+	limit, ok := h.maxScale.Get()
+	return ok && limit < logarithm.MaxScale
+}
+
+// substitute returns a value in the middle of the bucket of index at
+// logarithm.MaxScale, which is recorded in scaled in place of the
+// values whose bucket at scale base has this index.
+func substitute(index int32) float64 {
+	return (lowerBoundary(maxScaleMapping, index) + lowerBoundary(maxScaleMapping, index+1)) / 2
+}
+
+// maxScaleMapping is the mapping of the scale that structure
+// histograms begin recording at.
+var maxScaleMapping = newMapping(logarithm.MaxScale)
+
+// recordScaled records incr observations of value in scaled.
+func (h *Histogram[N, Traits]) recordScaled(value float64, incr uint64) {
+	if value == 0 {
+		h.scaled.UpdateByIncr(0, incr)
+		return
+	}
+	h.recordIndex(math.Signbit(value), h.mapping.MapToIndex(math.Abs(value)), incr)
+}
+
+// recordIndex records incr observations in scaled, in the bucket of
+// index at scale base.
+func (h *Histogram[N, Traits]) recordIndex(negative bool, index int32, incr uint64) {
+	value := substitute(index)
+	if negative {
+		value = -value
+	}
+	h.scaled.UpdateByIncr(value, incr)
+}
+
+// rebase moves the recorded buckets into scaled at scale base, which
+// is at most the recorded scale.  The values recorded in Histogram
+// are moved into stats.
+func (h *Histogram[N, Traits]) rebase(base int32) {
+	from := h.recordedScale()
+	pos, neg := h.positive(), h.negative()
+	zeros := h.Histogram.ZeroCount() + h.scaled.ZeroCount()
+
+	var next structure.Histogram[float64]
+	next.Init(h.config)
+	h.scaled, next = next, h.scaled
+	h.base = base
+	h.mapping = newMapping(base)
+
+	h.recordBuckets(false, pos, from)
+	h.recordBuckets(true, neg, from)
+	if zeros != 0 {
+		h.scaled.UpdateByIncr(0, zeros)
+	}
+	if h.Histogram.Count() != 0 {
+		h.stats.merge(&zeroValues[N]{
+			count: h.Histogram.Count(),
+			sum:   h.Histogram.Sum(),
+			min:   h.Histogram.Min(),
+			max:   h.Histogram.Max(),
+		})
+		h.Histogram.Clear()
+	}
+}
+
+// recordBuckets records buckets at scale, which is at least base, in
+// scaled.
+func (h *Histogram[N, Traits]) recordBuckets(negative bool, b aggregation.Buckets, scale int32) {
+	shift := scale - h.base
+	for i := uint32(0); i < b.Len(); i++ {
+		if count := b.At(i); count != 0 {
+			h.recordIndex(negative, (b.Offset()+int32(i))>>shift, count)
+		}
+	}
+}
+
+// mergeScaled merges from into h, recording both in scaled at the
+// lesser of their scales and the maximum scale.
+func (h *Histogram[N, Traits]) mergeScaled(from *Histogram[N, Traits]) {
+	base := int32(logarithm.MaxScale)
+	if limit, ok := h.maxScale.Get(); ok {
+		base = limit
+	}
+	if h.isScaled() || h.Histogram.Count() != h.Histogram.ZeroCount() {
+		base = int32min(base, h.recordedScale())
+	}
+	fromScaled := from.isScaled()
+	fromBuckets := fromScaled || from.Histogram.Count() != from.Histogram.ZeroCount()
+	if fromBuckets {
+		base = int32min(base, from.recordedScale())
+	}
+	if h.mapping == nil || h.base != base || h.Histogram.Count() != 0 {
+		h.rebase(base)
+	}
+
+	if fromScaled && from.base == base {
+		h.scaled.MergeFrom(&from.scaled)
+	} else {
+		if fromBuckets {
+			h.recordBuckets(false, from.positive(), from.recordedScale())
+			h.recordBuckets(true, from.negative(), from.recordedScale())
+		}
+		if zeros := from.Histogram.ZeroCount() + from.scaled.ZeroCount(); zeros != 0 {
+			h.scaled.UpdateByIncr(0, zeros)
+		}
+	}
+	h.stats.merge(&from.stats)
+	if from.Histogram.Count() != 0 {
+		h.stats.merge(&zeroValues[N]{
+			count: from.Histogram.Count(),
+			sum:   from.Histogram.Sum(),
+			min:   from.Histogram.Min(),
+			max:   from.Histogram.Max(),
+		})
+	}
+}
+
+func int32min(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Exemplars implements aggregation.HasExemplars.
+func (h *Histogram[N, Traits]) Exemplars() []aggregation.Exemplar {
+	if h.exemplars == nil {
+		return nil
+	}
+	return h.exemplars.Exemplars()
+}
+
+// Boundaries returns the bucket boundaries of the output in the form
+// of an explicit histogram, for use with BucketCounts(): bucket i
+// counts values in the range (Boundaries()[i-1], Boundaries()[i]].
+// The boundaries are those of the negative buckets, zero, and those
+// of the positive buckets; as in the exponential histogram, negative
+// buckets include their lower boundary instead of their upper
+// boundary.  The result is a new slice.
+func (h *Histogram[N, Traits]) Boundaries() []float64 {
+	bounds, _ := h.explicitBuckets()
+	return bounds
+}
+
+// BucketCounts returns the bucket counts of the output in the form of
+// an explicit histogram, see Boundaries().  The zero bucket is
+// the bucket whose upper boundary is zero.  The result is a new
+// slice.
+func (h *Histogram[N, Traits]) BucketCounts() []uint64 {
+	_, counts := h.explicitBuckets()
+	return counts
+}
+
+// explicitBuckets computes Boundaries() and BucketCounts(), of which
+// there is one more.
+func (h *Histogram[N, Traits]) explicitBuckets() ([]float64, []uint64) {
+	m := newMapping(h.Scale())
+	neg := h.Negative()
+	pos := h.Positive()
+
+	bounds := make([]float64, 0, neg.Len()+pos.Len()+1)
+	counts := make([]uint64, 0, neg.Len()+pos.Len()+2)
+
+	// Negative buckets cover [-base**(index+1), -base**index),
+	// these are output in descending order of index.
+	for i := neg.Len(); i > 0; i-- {
+		bounds = append(bounds, -lowerBoundary(m, neg.Offset()+int32(i-1)))
+		counts = append(counts, neg.At(i-1))
+	}
+
+	bounds = append(bounds, 0)
+	counts = append(counts, h.ZeroCount())
+
+	// Positive buckets cover (base**index, base**(index+1)].
+	for i := uint32(0); i < pos.Len(); i++ {
+		bounds = append(bounds, lowerBoundary(m, pos.Offset()+int32(i)+1))
+		counts = append(counts, pos.At(i))
+	}
+
+	// The last bucket, above the largest boundary, is empty.
+	counts = append(counts, 0)
+	return bounds, counts
+}
+
+// newMapping returns the mapping function for scale.
+func newMapping(scale int32) mapping.Mapping {
+	if scale <= 0 {
+		m, _ := exponent.NewMapping(scale)
+		return m
+	}
+	m, _ := logarithm.NewMapping(scale)
+	return m
+}
+
+// lowerBoundary returns the lower boundary of a bucket, approximated
+// outside the range of normal floating point values.
+func lowerBoundary(m mapping.Mapping, index int32) float64 {
+	if b, err := m.LowerBoundary(index); err == nil {
+		return b
+	}
+	return math.Exp2(math.Ldexp(float64(index), -int(m.Scale())))
+}
+
+// buckets returns b, downscaled if it exceeds the maximum scale and
+// trimmed if configured.  Buckets that lie entirely within the zero
+// threshold, which happens when a merge raises the threshold, are
+// removed and their total count is returned for the zero bucket.
+func (h *Histogram[N, Traits]) buckets(b *structure.Buckets) (aggregation.Buckets, uint64) {
+	var r aggregation.Buckets = b
+	if shift := h.recordedScale() - h.Scale(); shift > 0 {
+		r = downscaled{
+			Buckets: b,
+			shift:   shift,
+		}
+	}
+	var zeros uint64
+	if h.zeroThreshold != 0 && r.Len() != 0 {
+		m := newMapping(h.Scale())
+		start := uint32(0)
+		for start < r.Len() && lowerBoundary(m, r.Offset()+int32(start)+1) <= h.zeroThreshold {
+			zeros += r.At(start)
+			start++
+		}
+		if start != 0 {
+			r = trimmed{
+				Buckets: r,
+				start:   start,
+				length:  r.Len() - start,
+			}
+		}
+	}
+	if h.trim {
+		r = trimBuckets(r)
+	}
+	return r, zeros
+}
+
+// trimBuckets returns b without its leading and trailing empty
+// buckets.
+func trimBuckets(b aggregation.Buckets) aggregation.Buckets {
+	size := b.Len()
+	start := uint32(0)
+	for start < size && b.At(start) == 0 {
+		start++
+	}
+	end := size
+	for end > start && b.At(end-1) == 0 {
+		end--
+	}
+	if start == 0 && end == size {
+		return b
+	}
+	return trimmed{
+		Buckets: b,
+		start:   start,
+		length:  end - start,
+	}
+}
+
+func (t trimmed) Offset() int32 {
+	return t.Buckets.Offset() + int32(t.start)
+}
+
+func (t trimmed) Len() uint32 {
+	return t.length
+}
+
+func (t trimmed) At(pos uint32) uint64 {
+	return t.Buckets.At(t.start + pos)
+}
+
+func (d downscaled) Offset() int32 {
+	return d.Buckets.Offset() >> d.shift
+}
+
+func (d downscaled) Len() uint32 {
+	size := d.Buckets.Len()
+	if size == 0 {
+		return 0
+	}
+	last := (d.Buckets.Offset() + int32(size) - 1) >> d.shift
+	return uint32(last-d.Offset()) + 1
+}
+
+func (d downscaled) At(pos uint32) uint64 {
+	// Compute the range of input positions that map into
+	// the output bucket, then clip it to the input range.
+	offset := int64(d.Buckets.Offset())
+	low := (int64(d.Offset())+int64(pos))<<d.shift - offset
+	high := low + int64(1)<<d.shift - 1
+
+	if low < 0 {
+		low = 0
+	}
+	if last := int64(d.Buckets.Len()) - 1; high > last {
+		high = last
+	}
+
+	var sum uint64
+	for i := low; i <= high; i++ {
+		sum += d.Buckets.At(uint32(i))
+	}
+	return sum
+}
+
 func (Methods[N, Traits]) Kind() aggregation.Kind {
 	return aggregation.HistogramKind
 }
 
 func (Methods[N, Traits]) Init(agg *Histogram[N, Traits], cfg aggregator.Config) {
 	agg.Histogram.Init(cfg.Histogram)
+	agg.config = cfg.Histogram
+	agg.maxScale = cfg.HistogramMaxScale
+	if agg.limited() {
+		agg.scaled.Init(cfg.Histogram)
+		agg.base, _ = agg.maxScale.Get()
+		agg.mapping = newMapping(agg.base)
+	}
+	agg.trim = cfg.HistogramTrimEmptyBuckets
+	agg.zeroThreshold = cfg.HistogramZeroThreshold
+	agg.exemplars = cfg.HistogramExemplars.NewReservoir()
 }
 
 func (Methods[N, Traits]) HasChange(ptr *Histogram[N, Traits]) bool {
 	return ptr.Count() != 0
 }
 
+// MemorySize counts each bucket in use as 8 bytes, which is the
+// widest backing array; narrower counters use less.
+func (Methods[N, Traits]) MemorySize(ptr *Histogram[N, Traits]) int {
+	ptr.lock.Lock()
+	defer ptr.lock.Unlock()
+	return 8 * int(ptr.positive().Len()+ptr.negative().Len())
+}
+
 func (Methods[N, Traits]) Update(agg *Histogram[N, Traits], number N) {
 	agg.lock.Lock()
 	defer agg.lock.Unlock()
-	agg.Histogram.Update(number)
+	agg.update(number, 1)
+}
+
+// UpdateContext implements aggregator.ContextMethods.  When exemplars
+// are configured, measurements made in the context of a sampled span
+// are offered to the reservoir.
+func (Methods[N, Traits]) UpdateContext(ctx context.Context, agg *Histogram[N, Traits], number N) {
+	agg.lock.Lock()
+	defer agg.lock.Unlock()
+	agg.update(number, 1)
+	agg.offerExemplar(ctx, number)
+}
+
+// UpdateWeighted implements aggregator.WeightedMethods.  The
+// measurement is offered to the exemplar reservoir once, regardless
+// of its weight.
+func (Methods[N, Traits]) UpdateWeighted(ctx context.Context, agg *Histogram[N, Traits], number N, weight uint64) {
+	agg.lock.Lock()
+	defer agg.lock.Unlock()
+	agg.update(number, weight)
+	agg.offerExemplar(ctx, number)
+}
+
+// update records incr observations of number.  The total count
+// saturates instead of wrapping around; since each bucket count is at
+// most the total, so do the buckets.  The caller holds the lock.
+func (h *Histogram[N, Traits]) update(number N, incr uint64) {
+	if count := h.Count(); incr > math.MaxUint64-count {
+		incr = math.MaxUint64 - count
+		reportOverflow()
+		if incr == 0 {
+			return
+		}
+	}
+	if h.zeroThreshold != 0 && math.Abs(float64(number)) <= h.zeroThreshold {
+		h.zero.update(number, incr)
+		return
+	}
+	if h.limited() {
+		h.stats.update(number, incr)
+		h.recordScaled(float64(number), incr)
+		return
+	}
+	h.Histogram.UpdateByIncr(number, incr)
+}
+
+// update records incr observations of number.
+func (z *zeroValues[N]) update(number N, incr uint64) {
+	if z.count == 0 || number < z.min {
+		z.min = number
+	}
+	if z.count == 0 || number > z.max {
+		z.max = number
+	}
+	z.count += incr
+	z.sum += number * N(incr)
+}
+
+// merge adds the observations of from.
+func (z *zeroValues[N]) merge(from *zeroValues[N]) {
+	if from.count == 0 {
+		return
+	}
+	if z.count == 0 || from.min < z.min {
+		z.min = from.min
+	}
+	if z.count == 0 || from.max > z.max {
+		z.max = from.max
+	}
+	z.count += from.count
+	z.sum += from.sum
+}
+
+// reportOverflow reports a saturated count, rate-limited.
+func reportOverflow() {
+	doevery.TimePeriod(30*time.Second, func() {
+		otel.Handle(aggregator.ErrCountOverflow)
+	})
+}
+
+// offerExemplar offers a measurement to the exemplar reservoir, if
+// any, when made in the context of a sampled span.  The caller
+// holds the lock.
+func (h *Histogram[N, Traits]) offerExemplar(ctx context.Context, number N) {
+	if h.exemplars == nil {
+		return
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsSampled() {
+		var traits Traits
+		h.exemplars.Offer(aggregation.Exemplar{
+			Value:       traits.ToNumber(number),
+			Time:        time.Now(),
+			SpanContext: sc,
+		})
+	}
 }
 
 func (Methods[N, Traits]) Move(from, to *Histogram[N, Traits]) {
 	to.Histogram.Clear()
+	if to.mapping != nil {
+		to.scaled.Clear()
+	}
+	to.zero = zeroValues[N]{}
+	to.stats = zeroValues[N]{}
+	if to.exemplars != nil {
+		to.exemplars.Reset()
+	}
 
 	from.lock.Lock()
 	defer from.lock.Unlock()
 	from.Histogram.Swap(&to.Histogram)
+	from.scaled.Swap(&to.scaled)
+	from.base, to.base = to.base, from.base
+	from.mapping, to.mapping = to.mapping, from.mapping
+	from.zero, to.zero = to.zero, from.zero
+	from.stats, to.stats = to.stats, from.stats
+	from.zeroThreshold, to.zeroThreshold = to.zeroThreshold, from.zeroThreshold
+	if from.exemplars != nil && to.exemplars != nil {
+		from.exemplars, to.exemplars = to.exemplars, from.exemplars
+	}
 }
 
 func (Methods[N, Traits]) Copy(from, to *Histogram[N, Traits]) {
 	from.lock.Lock()
 	defer from.lock.Unlock()
 	from.Histogram.CopyInto(&to.Histogram)
+	if from.mapping != nil {
+		from.scaled.CopyInto(&to.scaled)
+	} else if to.mapping != nil {
+		to.scaled.Clear()
+	}
+	to.base = from.base
+	to.mapping = from.mapping
+	to.zero = from.zero
+	to.stats = from.stats
+	to.zeroThreshold = from.zeroThreshold
+	if to.exemplars != nil {
+		to.exemplars.Reset()
+		mergeExemplars(from, to)
+	}
 }
 
+// Merge combines two histograms at the lesser of their scales,
+// downscaling the finer of the two first.  A histogram without
+// buckets, i.e., empty or all zeros, has no scale of its own and is
+// combined at the scale of the other.  A merge that would overflow
+// the total count is dropped, since the buckets cannot be saturated
+// individually.
+//
+// The result has the greater of the two zero thresholds.  Buckets
+// that lie entirely within the greater threshold are output as
+// zeros, see buckets(); a bucket that straddles the threshold cannot
+// be divided and remains a bucket.
 func (Methods[N, Traits]) Merge(from, to *Histogram[N, Traits]) {
 	to.lock.Lock()
 	defer to.lock.Unlock()
-	to.Histogram.MergeFrom(&from.Histogram)
+	if from.Count() > math.MaxUint64-to.Count() {
+		reportOverflow()
+		return
+	}
+	if from.zeroThreshold > to.zeroThreshold {
+		to.zeroThreshold = from.zeroThreshold
+	}
+	to.zero.merge(&from.zero)
+	if to.limited() || to.isScaled() || from.isScaled() {
+		to.mergeScaled(from)
+	} else if from.Histogram.Count() == from.Histogram.ZeroCount() {
+		// MergeFrom() treats this as scale 0, which would
+		// needlessly downscale the result.
+		if zeros := from.Histogram.ZeroCount(); zeros != 0 {
+			to.update(0, zeros)
+		}
+	} else {
+		to.Histogram.MergeFrom(&from.Histogram)
+	}
+	mergeExemplars(from, to)
+}
+
+// mergeExemplars offers the exemplars of one histogram to another.
+func mergeExemplars[N number.Signed, Traits number.Traits[N]](from, to *Histogram[N, Traits]) {
+	if from.exemplars == nil || to.exemplars == nil {
+		return
+	}
+	for _, ex := range from.exemplars.Exemplars() {
+		to.exemplars.Offer(ex)
+	}
 }
 
 func (Methods[N, Traits]) ToAggregation(histo *Histogram[N, Traits]) aggregation.Aggregation {
@@ -15,13 +15,21 @@
 package histogram // import "github.com/lightstep/go-expohisto"
 
 import (
+	"context"
+	"math"
+	"sort"
+	"sync"
 	"testing"
 
+	"github.com/lightstep/go-expohisto/mapping/logarithm"
 	"github.com/lightstep/go-expohisto/structure"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/test"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func RequireEqualValues[N structure.ValueType, Traits number.Traits[N]](t *testing.T, a, b *Histogram[N, Traits]) {
@@ -78,6 +86,79 @@ func TestMerge(t *testing.T) {
 	RequireEqualValues(t, h5, h4)
 }
 
+func TestMergeScales(t *testing.T) {
+	var mf Float64Methods
+
+	newHist := func(values ...float64) *Float64 {
+		var h Float64
+		mf.Init(&h, aggregator.Config{Histogram: NewConfig(WithMaxSize(8))})
+		for _, v := range values {
+			mf.Update(&h, v)
+		}
+		return &h
+	}
+	fine := func() *Float64 { return newHist(1.01, 1.1, 1.2, 1.25) }
+	coarse := func() *Float64 { return newHist(1, 1.5, 2, 2.5, 3) }
+
+	require.Equal(t, int32(4), fine().Scale())
+	require.Equal(t, int32(2), coarse().Scale())
+
+	// At scale 2, the fine values fall into buckets 0 and 1.
+	expect := testBuckets{offset: -1, counts: []uint64{1, 2, 2, 1, 1, 0, 1, 1}}
+
+	for _, test := range []struct {
+		name     string
+		from, to *Float64
+	}{
+		{"fine into coarse", fine(), coarse()},
+		{"coarse into fine", coarse(), fine()},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mf.Merge(test.from, test.to)
+
+			require.Equal(t, int32(2), test.to.Scale())
+			require.Equal(t, uint64(9), test.to.Count())
+			require.Equal(t, 1.0, number.ToFloat64(test.to.Min()))
+			require.Equal(t, 3.0, number.ToFloat64(test.to.Max()))
+			require.InDelta(t, 14.56, number.ToFloat64(test.to.Sum()), 1e-9)
+			requireEqualBuckets(t, expect, test.to.Positive())
+			require.Equal(t, uint32(0), test.to.Negative().Len())
+		})
+	}
+
+	// Histograms without buckets do not change the scale.
+	h := fine()
+	mf.Merge(newHist(), h)
+	mf.Merge(newHist(0, 0), h)
+
+	require.Equal(t, int32(4), h.Scale())
+	require.Equal(t, uint64(6), h.Count())
+	require.Equal(t, uint64(2), h.ZeroCount())
+	require.Equal(t, 0.0, number.ToFloat64(h.Min()))
+	requireEqualBuckets(t, fine().Positive(), h.Positive())
+}
+
+func TestUpdateWeighted(t *testing.T) {
+	var mf Float64Methods
+	ctx := context.Background()
+
+	h1 := NewFloat64(NewConfig())
+	mf.UpdateWeighted(ctx, h1, 2, 3)
+	mf.UpdateWeighted(ctx, h1, -4, 1)
+
+	h2 := NewFloat64(NewConfig())
+	mf.UpdateWeighted(ctx, h2, 8, 2)
+	mf.UpdateWeighted(ctx, h2, 0, 5)
+
+	mf.Merge(h2, h1)
+
+	require.Equal(t, -4.0, number.ToFloat64(h1.Min()))
+	require.Equal(t, 8.0, number.ToFloat64(h1.Max()))
+	require.Equal(t, uint64(5), h1.ZeroCount())
+
+	RequireEqualValues(t, NewFloat64(NewConfig(), 2, 2, 2, -4, 8, 8, 0, 0, 0, 0, 0), h1)
+}
+
 func TestAggregatorToFrom(t *testing.T) {
 	var mi Int64Methods
 	var mf Float64Methods
@@ -104,3 +185,478 @@ func TestInt64Histogram(t *testing.T) {
 func TestFloat64Histogram(t *testing.T) {
 	test.GenericAggregatorTest[float64, Float64, Float64Methods](t, number.ToFloat64)
 }
+
+func TestMaxScale(t *testing.T) {
+	var mf Float64Methods
+	var limited, unlimited Float64
+
+	cfg := aggregator.Config{
+		HistogramMaxScale: WithMaxScale(0),
+	}
+	mf.Init(&limited, cfg)
+	mf.Init(&unlimited, aggregator.Config{})
+
+	for _, v := range []float64{1, 1.5, 2, 3, 4, 5, 6, 7, 8, -1, -3} {
+		mf.Update(&limited, v)
+		mf.Update(&unlimited, v)
+	}
+
+	// Unlimited data is recorded at a higher scale.
+	require.Less(t, int32(0), unlimited.Scale())
+
+	// At scale 0, bucket index i covers (2**i, 2**(i+1)].
+	require.Equal(t, int32(0), limited.Scale())
+	require.Equal(t, uint64(11), limited.Count())
+	require.Equal(t, -3.0, number.ToFloat64(limited.Min()))
+	require.Equal(t, 8.0, number.ToFloat64(limited.Max()))
+	require.Equal(t, 33.5, number.ToFloat64(limited.Sum()))
+
+	// Storage is bounded by the buckets at the limit.
+	require.Equal(t, 8*(4+3), mf.MemorySize(&limited))
+	require.Less(t, mf.MemorySize(&limited), mf.MemorySize(&unlimited))
+
+	pos := limited.Positive()
+	require.Equal(t, int32(-1), pos.Offset())
+	require.Equal(t, uint32(4), pos.Len())
+	require.Equal(t, []uint64{1, 2, 2, 4}, []uint64{pos.At(0), pos.At(1), pos.At(2), pos.At(3)})
+
+	neg := limited.Negative()
+	require.Equal(t, int32(-1), neg.Offset())
+	require.Equal(t, uint32(3), neg.Len())
+	require.Equal(t, []uint64{1, 0, 1}, []uint64{neg.At(0), neg.At(1), neg.At(2)})
+
+	// The limit survives Move and Merge.
+	var moved, merged Float64
+	mf.Init(&moved, cfg)
+	mf.Init(&merged, cfg)
+
+	mf.Move(&limited, &moved)
+	mf.Merge(&moved, &merged)
+	mf.Merge(&moved, &merged)
+
+	require.Equal(t, int32(0), merged.Scale())
+	require.Equal(t, uint64(22), merged.Count())
+	require.Equal(t, uint64(8), merged.Positive().At(3))
+	require.Equal(t, 67.0, number.ToFloat64(merged.Sum()))
+	require.Equal(t, 8*(4+3), mf.MemorySize(&merged))
+
+	// Merging unlimited data reduces it to the limit.
+	mf.Merge(&unlimited, &merged)
+	require.Equal(t, int32(0), merged.Scale())
+	require.Equal(t, uint64(33), merged.Count())
+	require.Equal(t, uint64(12), merged.Positive().At(3))
+	require.Equal(t, -3.0, number.ToFloat64(merged.Min()))
+	require.Equal(t, 100.5, number.ToFloat64(merged.Sum()))
+
+	// A lower limit downscales further to fit the maximum size.
+	var narrow Float64
+	mf.Init(&narrow, aggregator.Config{
+		Histogram:         NewConfig(WithMaxSize(2)),
+		HistogramMaxScale: WithMaxScale(0),
+	})
+	for _, v := range []float64{1, 1.5, 2, 3, 4, 5, 6, 7, 8} {
+		mf.Update(&narrow, v)
+	}
+	require.Equal(t, int32(-2), narrow.Scale())
+	require.Equal(t, uint32(2), narrow.Positive().Len())
+	require.Equal(t, uint64(9), narrow.Count())
+}
+
+// TestIntrospection tests the accessors that explain how an
+// exponential histogram downscaled, via the aggregation.Histogram
+// interface that is returned in data.Point.
+func TestIntrospection(t *testing.T) {
+	h := NewFloat64(NewConfig(WithMaxSize(4)), 0, 0, 1)
+
+	var agg aggregation.Histogram = h
+
+	// A single value is recorded at the maximum scale.
+	require.Equal(t, int32(logarithm.MaxScale), agg.Scale())
+	require.Equal(t, uint64(2), agg.ZeroCount())
+	require.Equal(t, uint32(1), agg.Positive().Len())
+
+	// A wide range of values forces a downscale so that the
+	// positive range fits in 4 buckets.
+	var mf Float64Methods
+	mf.Update(h, 1000)
+
+	require.Equal(t, int32(-2), agg.Scale())
+	require.Equal(t, uint64(2), agg.ZeroCount())
+	require.Equal(t, uint64(4), agg.Count())
+
+	// At scale -2, bucket index i covers (16**i, 16**(i+1)].
+	pos := agg.Positive()
+	require.Equal(t, int32(-1), pos.Offset())
+	require.Equal(t, uint32(4), pos.Len())
+	require.Equal(t, []uint64{1, 0, 0, 1}, []uint64{pos.At(0), pos.At(1), pos.At(2), pos.At(3)})
+	require.Equal(t, uint32(0), agg.Negative().Len())
+}
+
+func TestMaxScaleValidate(t *testing.T) {
+	cfg, err := aggregator.Config{HistogramMaxScale: aggregator.NewMaxScale(21)}.Validate()
+	require.Error(t, err)
+	require.Equal(t, aggregator.NewMaxScale(20), cfg.HistogramMaxScale)
+
+	cfg, err = aggregator.Config{HistogramMaxScale: aggregator.NewMaxScale(-11)}.Validate()
+	require.Error(t, err)
+	require.Equal(t, aggregator.NewMaxScale(-10), cfg.HistogramMaxScale)
+
+	cfg, err = aggregator.Config{HistogramMaxScale: aggregator.NewMaxScale(-10)}.Validate()
+	require.NoError(t, err)
+	require.Equal(t, aggregator.NewMaxScale(-10), cfg.HistogramMaxScale)
+}
+
+// testBuckets is a Buckets with explicit contents.
+type testBuckets struct {
+	offset int32
+	counts []uint64
+}
+
+func (b testBuckets) Offset() int32      { return b.offset }
+func (b testBuckets) Len() uint32        { return uint32(len(b.counts)) }
+func (b testBuckets) At(i uint32) uint64 { return b.counts[i] }
+
+func TestBoundariesAndBucketCounts(t *testing.T) {
+	// No value is a power of two, which are boundaries at
+	// every scale.
+	values := []float64{-100, -3, -3, 0, 1.5, 3, 5, 5, 100}
+
+	for _, size := range []int32{MinSize, 32, DefaultMaxSize} {
+		h := NewFloat64(NewConfig(WithMaxSize(size)), values...)
+		bounds := h.Boundaries()
+		counts := h.BucketCounts()
+
+		require.Equal(t, len(bounds)+1, len(counts))
+		require.True(t, sort.Float64sAreSorted(bounds))
+
+		expect := make([]uint64, len(counts))
+		for _, v := range values {
+			expect[sort.SearchFloat64s(bounds, v)]++
+		}
+		require.Equal(t, expect, counts, "size %d", size)
+	}
+
+	// Each bucket is a power of two at scale 0.
+	h := NewFloat64(NewConfig(WithMaxSize(MinSize)), 1.5, 3, 3, 0, -1.5, -3)
+	require.Equal(t, int32(0), h.Scale())
+	require.Equal(t, []float64{-2, -1, 0, 2, 4}, h.Boundaries())
+	require.Equal(t, []uint64{1, 1, 1, 1, 2, 0}, h.BucketCounts())
+
+	// The empty histogram has only the zero boundary.
+	h = NewFloat64(NewConfig())
+	require.Equal(t, []float64{0}, h.Boundaries())
+	require.Equal(t, []uint64{0, 0}, h.BucketCounts())
+}
+
+func TestTrimBuckets(t *testing.T) {
+	for _, test := range []struct {
+		input  testBuckets
+		offset int32
+		counts []uint64
+	}{
+		{testBuckets{-3, []uint64{0, 0, 1, 0, 2, 0}}, -1, []uint64{1, 0, 2}},
+		{testBuckets{5, []uint64{1, 0, 2}}, 5, []uint64{1, 0, 2}},
+		{testBuckets{5, []uint64{0, 0}}, 7, nil},
+		{testBuckets{5, nil}, 5, nil},
+	} {
+		trim := trimBuckets(test.input)
+		requireEqualBuckets(t, testBuckets{test.offset, test.counts}, trim)
+
+		// Trimming is lossless: every index maps to the
+		// same count as before.
+		for i := uint32(0); i < test.input.Len(); i++ {
+			idx := test.input.Offset() + int32(i)
+			pos := idx - trim.Offset()
+			if pos < 0 || pos >= int32(trim.Len()) {
+				require.Equal(t, uint64(0), test.input.At(i))
+				continue
+			}
+			require.Equal(t, test.input.At(i), trim.At(uint32(pos)))
+		}
+	}
+}
+
+func TestTrimEmptyBucketsMerge(t *testing.T) {
+	var mf Float64Methods
+	var trimmed, untrimmed Float64
+
+	mf.Init(&trimmed, aggregator.Config{HistogramTrimEmptyBuckets: true})
+	mf.Init(&untrimmed, aggregator.Config{})
+
+	for _, v := range []float64{1, 2, 4, 8, -1, -4} {
+		mf.Update(&trimmed, v)
+		mf.Update(&untrimmed, v)
+	}
+
+	// Merging in either direction yields identical values, since
+	// trimming applies to the output only.
+	var intoTrimmed, intoUntrimmed Float64
+	mf.Init(&intoTrimmed, aggregator.Config{HistogramTrimEmptyBuckets: true})
+	mf.Init(&intoUntrimmed, aggregator.Config{})
+
+	mf.Merge(&trimmed, &intoTrimmed)
+	mf.Merge(&untrimmed, &intoTrimmed)
+	mf.Merge(&untrimmed, &intoUntrimmed)
+	mf.Merge(&trimmed, &intoUntrimmed)
+
+	RequireEqualValues(t, &intoTrimmed, &intoUntrimmed)
+	require.Equal(t, uint64(12), intoTrimmed.Count())
+}
+
+func TestExemplars(t *testing.T) {
+	var mf Float64Methods
+	var h1, h2, h3 Float64
+
+	cfg := aggregator.Config{
+		HistogramExemplars: WithExemplarReservoir(func() aggregator.ExemplarReservoir {
+			return NewFixedSizeReservoir(2)
+		}),
+	}
+	mf.Init(&h1, cfg)
+	mf.Init(&h2, cfg)
+	mf.Init(&h3, cfg)
+
+	sampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	unsampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{2},
+		SpanID:  trace.SpanID{2},
+	}))
+
+	// Only measurements in a sampled context are offered.
+	mf.UpdateContext(unsampled, &h1, 1)
+	mf.UpdateContext(context.Background(), &h1, 2)
+	mf.Update(&h1, 3)
+	require.Equal(t, 0, len(h1.Exemplars()))
+
+	mf.UpdateContext(sampled, &h1, 4)
+	require.Equal(t, uint64(4), h1.Count())
+	require.Equal(t, 1, len(h1.Exemplars()))
+	require.Equal(t, 4.0, number.ToFloat64(h1.Exemplars()[0].Value))
+	require.Equal(t, trace.TraceID{1}, h1.Exemplars()[0].SpanContext.TraceID())
+
+	// Move transfers the exemplars and resets the source.
+	mf.Move(&h1, &h2)
+	require.Equal(t, 0, len(h1.Exemplars()))
+	require.Equal(t, 1, len(h2.Exemplars()))
+
+	// The reservoir size is fixed.
+	for i := 0; i < 10; i++ {
+		mf.UpdateContext(sampled, &h1, float64(i))
+	}
+	require.Equal(t, 2, len(h1.Exemplars()))
+
+	mf.Merge(&h1, &h2)
+	require.Equal(t, 2, len(h2.Exemplars()))
+
+	mf.Copy(&h2, &h3)
+	require.Equal(t, h2.Exemplars(), h3.Exemplars())
+
+	// Without configuration there are no exemplars.
+	var plain Float64
+	mf.Init(&plain, aggregator.Config{})
+	mf.UpdateContext(sampled, &plain, 1)
+	require.Nil(t, plain.Exemplars())
+}
+
+func TestExemplarsConcurrency(t *testing.T) {
+	var mf Float64Methods
+	var current, output, discard Float64
+
+	cfg := aggregator.Config{
+		HistogramExemplars: DefaultExemplars,
+	}
+	mf.Init(&current, cfg)
+	mf.Init(&output, cfg)
+	mf.Init(&discard, cfg)
+
+	sampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	const (
+		numRoutines = 10
+		numUpdates  = 1000
+	)
+	var wg sync.WaitGroup
+	wg.Add(numRoutines)
+	for i := 0; i < numRoutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < numUpdates; j++ {
+				mf.UpdateContext(sampled, &current, float64(j))
+			}
+		}()
+	}
+
+	var total uint64
+	collect := func() {
+		mf.Move(&current, &discard)
+		mf.Merge(&discard, &output)
+		total += discard.Count()
+	}
+	for i := 0; i < 100; i++ {
+		collect()
+	}
+	wg.Wait()
+	collect()
+
+	require.Equal(t, uint64(numRoutines*numUpdates), total)
+	require.Equal(t, DefaultExemplarReservoirSize, len(output.Exemplars()))
+}
+
+func TestCountOverflow(t *testing.T) {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+
+	var mf Float64Methods
+	ctx := context.Background()
+
+	// Inject a near-maximum count by merging.
+	near := NewFloat64(NewConfig())
+	mf.UpdateWeighted(ctx, near, 2, math.MaxUint64-1)
+
+	agg := NewFloat64(NewConfig())
+	mf.Merge(near, agg)
+	require.Equal(t, uint64(math.MaxUint64-1), agg.Count())
+	require.Empty(t, errs)
+
+	// The count saturates.
+	mf.Update(agg, 2)
+	mf.Update(agg, 2)
+	mf.UpdateWeighted(ctx, agg, 2, 10)
+	require.Equal(t, uint64(math.MaxUint64), agg.Count())
+	require.Equal(t, uint64(math.MaxUint64), agg.Positive().At(0))
+
+	// A merge that would overflow is dropped.
+	mf.Merge(NewFloat64(NewConfig(), 2, 4), agg)
+	require.Equal(t, uint64(math.MaxUint64), agg.Count())
+	require.Equal(t, uint32(1), agg.Positive().Len())
+
+	// The error is rate-limited.
+	require.Equal(t, 1, len(errs))
+	require.ErrorIs(t, errs[0], aggregator.ErrCountOverflow)
+}
+
+func TestZeroThreshold(t *testing.T) {
+	var mf Float64Methods
+	var fh Float64
+	mf.Init(&fh, aggregator.Config{
+		HistogramZeroThreshold: 1,
+	})
+
+	// Values at or below the threshold in magnitude are zeros.
+	for _, v := range []float64{1, -1, -0.5, 1.0000001, 2, -3} {
+		mf.Update(&fh, v)
+	}
+	require.Equal(t, 1.0, fh.ZeroThreshold())
+	require.Equal(t, uint64(6), fh.Count())
+	require.Equal(t, uint64(3), fh.ZeroCount())
+	require.InDelta(t, -0.4999999, number.ToFloat64(fh.Sum()), 1e-9)
+	require.Equal(t, -3.0, number.ToFloat64(fh.Min()))
+	require.Equal(t, 2.0, number.ToFloat64(fh.Max()))
+	require.Equal(t, uint64(2), sumBuckets(fh.Positive()))
+	require.Equal(t, uint64(1), sumBuckets(fh.Negative()))
+
+	var mi Int64Methods
+	var ih Int64
+	mi.Init(&ih, aggregator.Config{
+		HistogramZeroThreshold: 1,
+	})
+	for _, v := range []int64{0, 1, -1, 2} {
+		mi.Update(&ih, v)
+	}
+	require.Equal(t, uint64(4), ih.Count())
+	require.Equal(t, uint64(3), ih.ZeroCount())
+	require.Equal(t, int64(2), number.ToInt64(ih.Sum()))
+	require.Equal(t, int64(-1), number.ToInt64(ih.Min()))
+
+	// Only zeros: the min and max come from the zero values.
+	var zh Float64
+	mf.Init(&zh, aggregator.Config{
+		HistogramZeroThreshold: 1,
+	})
+	mf.Update(&zh, 0.5)
+	mf.Update(&zh, 0.25)
+	require.Equal(t, 0.25, number.ToFloat64(zh.Min()))
+	require.Equal(t, 0.5, number.ToFloat64(zh.Max()))
+
+	// Copy and Move carry the zero values.
+	var cp, mv Float64
+	mf.Init(&cp, aggregator.Config{})
+	mf.Init(&mv, aggregator.Config{})
+	mf.Copy(&fh, &cp)
+	require.Equal(t, uint64(3), cp.ZeroCount())
+	mf.Move(&fh, &mv)
+	require.Equal(t, uint64(3), mv.ZeroCount())
+	require.Equal(t, 1.0, mv.ZeroThreshold())
+	require.Equal(t, uint64(0), fh.Count())
+
+	_, err := aggregator.Config{HistogramZeroThreshold: -1}.Validate()
+	require.Error(t, err)
+	_, err = aggregator.Config{HistogramZeroThreshold: math.Inf(1)}.Validate()
+	require.Error(t, err)
+}
+
+func TestZeroThresholdMerge(t *testing.T) {
+	var mf Float64Methods
+	var low, high Float64
+	mf.Init(&low, aggregator.Config{})
+	mf.Init(&high, aggregator.Config{
+		HistogramZeroThreshold: 1,
+	})
+
+	mf.Update(&low, 0.1)
+	mf.Update(&low, 0.5)
+	mf.Update(&low, -1)
+	mf.Update(&low, 3)
+	mf.Update(&high, 0.25)
+
+	// The lower threshold is raised and its buckets within the
+	// greater threshold are output as zeros.
+	mf.Merge(&high, &low)
+	require.Equal(t, 1.0, low.ZeroThreshold())
+	require.Equal(t, uint64(5), low.Count())
+	require.Equal(t, uint64(4), low.ZeroCount())
+	require.Equal(t, uint64(1), sumBuckets(low.Positive()))
+	require.Equal(t, uint64(0), sumBuckets(low.Negative()))
+	require.Equal(t, 0.25+0.1+0.5-1+3, number.ToFloat64(low.Sum()))
+	require.Equal(t, -1.0, number.ToFloat64(low.Min()))
+	require.Equal(t, 3.0, number.ToFloat64(low.Max()))
+
+	// A bucket that straddles the threshold is not divided:
+	// at scale 0, 0.7 is in the bucket (0.5, 1].
+	var coarse, mid Float64
+	mf.Init(&coarse, aggregator.Config{
+		Histogram: NewConfig(WithMaxSize(4)),
+	})
+	mf.Init(&mid, aggregator.Config{
+		HistogramZeroThreshold: 0.75,
+	})
+	mf.Update(&coarse, 0.7)
+	mf.Update(&coarse, 8)
+	require.Equal(t, int32(0), coarse.Scale())
+	mf.Update(&mid, 0.7)
+
+	mf.Merge(&coarse, &mid)
+	require.Equal(t, 0.75, mid.ZeroThreshold())
+	require.Equal(t, uint64(3), mid.Count())
+	require.Equal(t, uint64(1), mid.ZeroCount())
+	require.Equal(t, uint64(2), sumBuckets(mid.Positive()))
+}
+
+func sumBuckets(b aggregation.Buckets) uint64 {
+	var sum uint64
+	for i := uint32(0); i < b.Len(); i++ {
+		sum += b.At(i)
+	}
+	return sum
+}
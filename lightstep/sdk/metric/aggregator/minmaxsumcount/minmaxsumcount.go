@@ -15,6 +15,7 @@
 package minmaxsumcount // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/minmaxsumcount"
 
 import (
+	"context"
 	"sync"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
@@ -57,6 +58,9 @@ var (
 	_ aggregator.Methods[int64, Int64]     = Int64Methods{}
 	_ aggregator.Methods[float64, Float64] = Float64Methods{}
 
+	_ aggregator.WeightedMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.WeightedMethods[float64, Float64] = Float64Methods{}
+
 	_ aggregation.MinMaxSumCount = &Int64{}
 	_ aggregation.MinMaxSumCount = &Float64{}
 )
@@ -126,23 +130,32 @@ func (Methods[N, Traits]) Copy(from, to *State[N, Traits]) {
 }
 
 func (Methods[N, Traits]) Update(state *State[N, Traits], number N) {
-	state.lock.Lock()
-	defer state.lock.Unlock()
+	state.update(number, 1)
+}
+
+// UpdateWeighted implements aggregator.WeightedMethods.
+func (Methods[N, Traits]) UpdateWeighted(_ context.Context, state *State[N, Traits], number N, weight uint64) {
+	state.update(number, weight)
+}
+
+func (g *State[N, Traits]) update(number N, weight uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
 
-	if state.count == 0 {
-		state.min = number
-		state.max = number
+	if g.count == 0 {
+		g.min = number
+		g.max = number
 	} else {
-		if number < state.min {
-			state.min = number
+		if number < g.min {
+			g.min = number
 		}
-		if number > state.max {
-			state.max = number
+		if number > g.max {
+			g.max = number
 		}
 	}
 
-	state.sum += number
-	state.count++
+	g.sum += number * N(weight)
+	g.count += weight
 }
 
 func (Methods[N, Traits]) Merge(from, to *State[N, Traits]) {
@@ -15,6 +15,7 @@
 package minmaxsumcount // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/minmaxsumcount"
 
 import (
+	"context"
 	"testing"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
@@ -101,4 +102,17 @@ func genericMinMaxSumCountTest[N number.Any, Storage any, Methods aggregator.Met
 		require.Equal(t, expect, second)
 	})
 
+	t.Run("weighted", func(t *testing.T) {
+		wm := any(methods).(aggregator.WeightedMethods[N, Storage])
+		first := init(4)
+		second := init()
+
+		wm.UpdateWeighted(context.Background(), first, 2, 3)
+		wm.UpdateWeighted(context.Background(), second, 9, 2)
+
+		expect := init(4, 2, 2, 2, 9, 9)
+
+		methods.Merge(first, second)
+		require.Equal(t, expect, second)
+	})
 }
@@ -0,0 +1,177 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rate implements an aggregation of monotonic sums that
+// outputs the per-second rate of change of the sum as a gauge.
+package rate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/rate"
+
+import (
+	"context"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+)
+
+// The rate aggregation accumulates a delta sum, the same as a
+// monotonic sum, and divides it by the length of the interval in
+// seconds when the point is output.  Intervals that are empty or
+// have no change have no rate and are not output.
+
+type (
+	Methods[N number.Any, Traits number.Traits[N]] struct{}
+
+	State[N number.Any, Traits number.Traits[N]] struct {
+		sum N
+
+		// rate is the per-second rate of the sum, set by
+		// SetInterval.  hasRate is false when the interval
+		// was empty.
+		rate    float64
+		hasRate bool
+	}
+
+	Int64   = State[int64, number.Int64Traits]
+	Float64 = State[float64, number.Float64Traits]
+	Uint64  = State[uint64, number.Uint64Traits]
+
+	Int64Methods   = Methods[int64, number.Int64Traits]
+	Float64Methods = Methods[float64, number.Float64Traits]
+	Uint64Methods  = Methods[uint64, number.Uint64Traits]
+)
+
+var (
+	_ aggregator.Methods[int64, Int64]     = Int64Methods{}
+	_ aggregator.Methods[float64, Float64] = Float64Methods{}
+	_ aggregator.Methods[uint64, Uint64]   = Uint64Methods{}
+
+	_ aggregator.IntervalMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.IntervalMethods[float64, Float64] = Float64Methods{}
+	_ aggregator.IntervalMethods[uint64, Uint64]   = Uint64Methods{}
+
+	_ aggregator.ResetMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.ResetMethods[float64, Float64] = Float64Methods{}
+	_ aggregator.ResetMethods[uint64, Uint64]   = Uint64Methods{}
+
+	_ aggregator.WeightedMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.WeightedMethods[float64, Float64] = Float64Methods{}
+	_ aggregator.WeightedMethods[uint64, Uint64]   = Uint64Methods{}
+
+	_ aggregation.Gauge = &Int64{}
+	_ aggregation.Gauge = &Float64{}
+	_ aggregation.Gauge = &Uint64{}
+)
+
+// NewInt64 returns the rate of sum over an interval of the given
+// length.
+func NewInt64(sum int64, interval time.Duration) *Int64 {
+	return newState[int64, number.Int64Traits](sum, interval)
+}
+
+// NewFloat64 returns the rate of sum over an interval of the given
+// length.
+func NewFloat64(sum float64, interval time.Duration) *Float64 {
+	return newState[float64, number.Float64Traits](sum, interval)
+}
+
+func newState[N number.Any, Traits number.Traits[N]](sum N, interval time.Duration) *State[N, Traits] {
+	var methods Methods[N, Traits]
+	state := &State[N, Traits]{sum: sum}
+	start := time.Unix(0, 0)
+	methods.SetInterval(state, start, start.Add(interval))
+	return state
+}
+
+func (s *State[N, Traits]) Kind() aggregation.Kind {
+	return aggregation.RateKind
+}
+
+// Gauge returns the per-second rate, which is always a float64.
+func (s *State[N, Traits]) Gauge() number.Number {
+	var t number.Float64Traits
+	return t.ToNumber(s.rate)
+}
+
+func (Methods[N, Traits]) Kind() aggregation.Kind {
+	return aggregation.RateKind
+}
+
+func (Methods[N, Traits]) Init(state *State[N, Traits], _ aggregator.Config) {
+	// Note: storage is zero to start
+}
+
+func (Methods[N, Traits]) Update(state *State[N, Traits], value N) {
+	var t Traits
+	t.AddAtomic(&state.sum, value)
+}
+
+// UpdateWeighted implements aggregator.WeightedMethods, adding
+// value times weight.
+func (Methods[N, Traits]) UpdateWeighted(_ context.Context, state *State[N, Traits], value N, weight uint64) {
+	var t Traits
+	t.AddAtomic(&state.sum, value*N(weight))
+}
+
+func (Methods[N, Traits]) Move(from, to *State[N, Traits]) {
+	var t Traits
+	to.sum = t.SwapAtomic(&from.sum, 0)
+	to.rate = from.rate
+	to.hasRate = from.hasRate
+}
+
+func (Methods[N, Traits]) Copy(from, to *State[N, Traits]) {
+	var t Traits
+	to.sum = t.GetAtomic(&from.sum)
+	to.rate = from.rate
+	to.hasRate = from.hasRate
+}
+
+func (Methods[N, Traits]) Merge(from, to *State[N, Traits]) {
+	var t Traits
+	t.AddAtomic(&to.sum, from.sum)
+}
+
+func (Methods[N, Traits]) SubtractSwap(operand, argument *State[N, Traits]) {
+	operand.sum = argument.sum - operand.sum
+}
+
+// IsReset returns true when the cumulative sum decreased.
+func (Methods[N, Traits]) IsReset(prior, current *State[N, Traits]) bool {
+	return current.sum < prior.sum
+}
+
+// SetInterval implements aggregator.IntervalMethods, computing the
+// rate of the sum over the interval.
+func (Methods[N, Traits]) SetInterval(state *State[N, Traits], start, end time.Time) {
+	elapsed := end.Sub(start).Seconds()
+	state.hasRate = elapsed > 0
+	state.rate = 0
+	if state.hasRate {
+		state.rate = float64(state.sum) / elapsed
+	}
+}
+
+func (Methods[N, Traits]) HasChange(ptr *State[N, Traits]) bool {
+	return ptr.hasRate && ptr.sum != 0
+}
+
+func (Methods[N, Traits]) ToAggregation(state *State[N, Traits]) aggregation.Aggregation {
+	return state
+}
+
+func (Methods[N, Traits]) ToStorage(aggr aggregation.Aggregation) (*State[N, Traits], bool) {
+	r, ok := aggr.(*State[N, Traits])
+	return r, ok
+}
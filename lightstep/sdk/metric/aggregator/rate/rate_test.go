@@ -0,0 +1,98 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/rate"
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRate(t *testing.T) {
+	var methods Int64Methods
+	var input, output Int64
+	methods.Init(&input, aggregator.Config{})
+	methods.Init(&output, aggregator.Config{})
+
+	for i := 0; i < 10; i++ {
+		methods.Update(&input, 3)
+	}
+	methods.Move(&input, &output)
+
+	start := time.Unix(100, 0)
+	methods.SetInterval(&output, start, start.Add(10*time.Second))
+	require.True(t, methods.HasChange(&output))
+
+	g, ok := methods.ToAggregation(&output).(aggregation.Gauge)
+	require.True(t, ok)
+	require.Equal(t, aggregation.RateKind, g.Kind())
+	require.Equal(t, 3.0, number.ToFloat64(g.Gauge()))
+	require.Equal(t, NewInt64(30, 10*time.Second), &output)
+
+	// Rates are not sums.
+	_, isSum := methods.ToAggregation(&output).(aggregation.HasASum)
+	require.False(t, isSum)
+
+	// The input was reset by Move.
+	methods.SetInterval(&input, start, start.Add(time.Second))
+	require.False(t, methods.HasChange(&input))
+}
+
+func TestRateUpdateWeighted(t *testing.T) {
+	var methods Float64Methods
+	var state Float64
+	methods.Init(&state, aggregator.Config{})
+	methods.Update(&state, 1)
+	methods.UpdateWeighted(context.Background(), &state, 1.5, 4)
+
+	start := time.Unix(100, 0)
+	methods.SetInterval(&state, start, start.Add(2*time.Second))
+	require.Equal(t, 3.5, number.ToFloat64(state.Gauge()))
+}
+
+func TestRateEmptyInterval(t *testing.T) {
+	var methods Float64Methods
+	var state Float64
+	methods.Init(&state, aggregator.Config{})
+	methods.Update(&state, 1.5)
+
+	start := time.Unix(100, 0)
+	methods.SetInterval(&state, start, start)
+	require.False(t, methods.HasChange(&state))
+
+	methods.SetInterval(&state, start, start.Add(-time.Second))
+	require.False(t, methods.HasChange(&state))
+
+	methods.SetInterval(&state, start, start.Add(500*time.Millisecond))
+	require.True(t, methods.HasChange(&state))
+	require.Equal(t, 3.0, number.ToFloat64(state.Gauge()))
+}
+
+func TestRateReset(t *testing.T) {
+	var methods Int64Methods
+	prior := NewInt64(10, time.Second)
+	current := NewInt64(4, time.Second)
+
+	require.True(t, methods.IsReset(prior, current))
+	require.False(t, methods.IsReset(current, prior))
+
+	methods.SubtractSwap(current, prior)
+	require.Equal(t, int64(6), current.sum)
+}
@@ -0,0 +1,102 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregator // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+)
+
+// Factory describes a custom aggregation, which views select by
+// passing its registered Kind to view.WithAggregation.
+//
+// The Methods of a custom aggregation use type-erased Storage.  Init
+// sets `*ptr` to the aggregator state, which should be a pointer,
+// and the other methods modify that state in place.  ToAggregation
+// returns an Aggregation whose Kind() is the registered Kind, and
+// ToStorage accepts any Aggregation that ToAggregation returned,
+// returning a Storage that refers to the same state.
+type Factory struct {
+	// Category determines which instruments the aggregation is
+	// compatible with, as for the built-in aggregations.
+	Category aggregation.Category
+
+	// Int64, Float64, and Uint64 are the Methods for each number
+	// kind.  Instruments of a number kind with nil Methods use
+	// the default aggregation instead.
+	Int64   Methods[int64, any]
+	Float64 Methods[float64, any]
+	Uint64  Methods[uint64, any]
+}
+
+var registry struct {
+	lock      sync.RWMutex
+	factories map[aggregation.Kind]Factory
+}
+
+// Register makes a custom aggregation available to views under kind,
+// which must be at least aggregation.FirstCustomKind and may be
+// registered only once.  Register is meant to be called during
+// initialization, before the views that use kind are compiled.
+func Register(kind aggregation.Kind, factory Factory) error {
+	if kind < aggregation.FirstCustomKind {
+		return fmt.Errorf("custom aggregation kind %v is reserved", kind)
+	}
+	if factory.Int64 == nil && factory.Float64 == nil && factory.Uint64 == nil {
+		return fmt.Errorf("custom aggregation kind %v has no methods", kind)
+	}
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	if _, has := registry.factories[kind]; has {
+		return fmt.Errorf("custom aggregation kind %v is already registered", kind)
+	}
+	if registry.factories == nil {
+		registry.factories = map[aggregation.Kind]Factory{}
+	}
+	registry.factories[kind] = factory
+	return nil
+}
+
+// Lookup returns the Factory registered for kind and true, or false
+// if kind is not a registered custom aggregation.
+func Lookup(kind aggregation.Kind) (Factory, bool) {
+	if kind < aggregation.FirstCustomKind {
+		return Factory{}, false
+	}
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	factory, has := registry.factories[kind]
+	return factory, has
+}
+
+// Supports returns true if the factory has Methods for the number
+// kind.
+func (f Factory) Supports(kind number.Kind) bool {
+	switch kind {
+	case number.Int64Kind:
+		return f.Int64 != nil
+	case number.Float64Kind:
+		return f.Float64 != nil
+	case number.Uint64Kind:
+		return f.Uint64 != nil
+	}
+	return false
+}
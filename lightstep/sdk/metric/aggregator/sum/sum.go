@@ -15,6 +15,12 @@
 package sum // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
 
 import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
@@ -32,6 +38,16 @@ type (
 
 	State[N number.Any, Traits number.Traits[N], M Monotonicity] struct {
 		value N
+
+		// policy applies to int64 sums, see WithOverflowPolicy.
+		policy aggregator.SumOverflowPolicy
+
+		// overflowed is set to 1 when an int64 sum overflows.
+		overflowed uint32
+
+		// sampling is the probability of keeping each
+		// Update, see WithSampling.  Zero means no sampling.
+		sampling float64
 	}
 
 	MonotonicInt64    = State[int64, number.Int64Traits, Monotonic]
@@ -40,13 +56,56 @@ type (
 	MonotonicFloat64    = State[float64, number.Float64Traits, Monotonic]
 	NonMonotonicFloat64 = State[float64, number.Float64Traits, NonMonotonic]
 
+	MonotonicUint64 = State[uint64, number.Uint64Traits, Monotonic]
+
 	MonotonicInt64Methods   = Methods[int64, number.Int64Traits, Monotonic]
 	MonotonicFloat64Methods = Methods[float64, number.Float64Traits, Monotonic]
 
 	NonMonotonicInt64Methods   = Methods[int64, number.Int64Traits, NonMonotonic]
 	NonMonotonicFloat64Methods = Methods[float64, number.Float64Traits, NonMonotonic]
+
+	MonotonicUint64Methods = Methods[uint64, number.Uint64Traits, Monotonic]
 )
 
+const (
+	// Wrap is the default overflow policy, see aggregator.SumOverflowWrap.
+	Wrap = aggregator.SumOverflowWrap
+
+	// Saturate is the saturating overflow policy, see
+	// aggregator.SumOverflowSaturate.
+	Saturate = aggregator.SumOverflowSaturate
+
+	// Reset is the resetting overflow policy, see
+	// aggregator.SumOverflowReset.
+	Reset = aggregator.SumOverflowReset
+)
+
+// WithOverflowPolicy returns the behavior of int64 sums that
+// overflow, for use as the aggregator.Config SumOverflow field.  When
+// the policy is Saturate or Reset, overflow is reported to the
+// MeterProvider's measurement error handler with
+// aggregator.ErrSumOverflow.  Sums of other number kinds are not
+// affected.
+func WithOverflowPolicy(policy aggregator.SumOverflowPolicy) aggregator.SumOverflowPolicy {
+	return policy
+}
+
+// WithSampling returns the probability that a sum keeps each
+// measurement, for use as the aggregator.Config SumSampling field.
+// Kept measurements are scaled by 1/p, so the expected value of the
+// sum is unchanged; integer sums round the scaled value up or down at
+// random in proportion to its fraction, which keeps them unbiased.
+//
+// Sampling trades accuracy for cost: measurements that are not kept
+// skip the atomic update of the shared sum, which reduces contention
+// for very hot counters, but the variance of each collected sum grows
+// as (1-p)/p times the sum of squared measurements.  Over many
+// collections the cumulative total remains unbiased and its relative
+// error shrinks.  Weighted updates are not sampled.
+func WithSampling(p float64) float64 {
+	return p
+}
+
 func NewMonotonicInt64(x int64) *MonotonicInt64 {
 	return &MonotonicInt64{value: x}
 }
@@ -63,6 +122,10 @@ func NewNonMonotonicFloat64(x float64) *NonMonotonicFloat64 {
 	return &NonMonotonicFloat64{value: x}
 }
 
+func NewMonotonicUint64(x uint64) *MonotonicUint64 {
+	return &MonotonicUint64{value: x}
+}
+
 func (Monotonic) kind() aggregation.Kind {
 	return aggregation.MonotonicSumKind
 }
@@ -76,11 +139,22 @@ var (
 	_ aggregator.Methods[float64, MonotonicFloat64]    = Methods[float64, number.Float64Traits, Monotonic]{}
 	_ aggregator.Methods[int64, NonMonotonicInt64]     = Methods[int64, number.Int64Traits, NonMonotonic]{}
 	_ aggregator.Methods[float64, NonMonotonicFloat64] = Methods[float64, number.Float64Traits, NonMonotonic]{}
+	_ aggregator.Methods[uint64, MonotonicUint64]      = Methods[uint64, number.Uint64Traits, Monotonic]{}
+
+	_ aggregator.WeightedMethods[int64, MonotonicInt64]        = MonotonicInt64Methods{}
+	_ aggregator.WeightedMethods[float64, MonotonicFloat64]    = MonotonicFloat64Methods{}
+	_ aggregator.WeightedMethods[int64, NonMonotonicInt64]     = NonMonotonicInt64Methods{}
+	_ aggregator.WeightedMethods[float64, NonMonotonicFloat64] = NonMonotonicFloat64Methods{}
+	_ aggregator.WeightedMethods[uint64, MonotonicUint64]      = MonotonicUint64Methods{}
+
+	_ aggregator.OverflowMethods[int64, MonotonicInt64]    = MonotonicInt64Methods{}
+	_ aggregator.OverflowMethods[int64, NonMonotonicInt64] = NonMonotonicInt64Methods{}
 
 	_ aggregation.Sum = &MonotonicInt64{}
 	_ aggregation.Sum = &MonotonicFloat64{}
 	_ aggregation.Sum = &NonMonotonicInt64{}
 	_ aggregation.Sum = &NonMonotonicFloat64{}
+	_ aggregation.Sum = &MonotonicUint64{}
 )
 
 func (s *State[N, Traits, M]) Sum() number.Number {
@@ -103,13 +177,20 @@ func (Methods[N, Traits, M]) Kind() aggregation.Kind {
 	return m.kind()
 }
 
-func (Methods[N, Traits, M]) Init(state *State[N, Traits, M], _ aggregator.Config) {
+func (Methods[N, Traits, M]) Init(state *State[N, Traits, M], cfg aggregator.Config) {
 	// Note: storage is zero to start
+	state.policy = cfg.SumOverflow
+	if cfg.SumSampling > 0 && cfg.SumSampling < 1 {
+		state.sampling = cfg.SumSampling
+	}
 }
 
 func (Methods[N, Traits, M]) Move(from, to *State[N, Traits, M]) {
 	var t Traits
 	to.value = t.SwapAtomic(&from.value, 0)
+	if from.policy != Wrap {
+		to.overflowed = atomic.SwapUint32(&from.overflowed, 0)
+	}
 }
 
 func (Methods[N, Traits, M]) HasChange(ptr *State[N, Traits, M]) bool {
@@ -117,8 +198,125 @@ func (Methods[N, Traits, M]) HasChange(ptr *State[N, Traits, M]) bool {
 }
 
 func (Methods[N, Traits, M]) Update(state *State[N, Traits, M], value N) {
+	if state.sampling != 0 {
+		var kept bool
+		if value, kept = state.sample(value); !kept {
+			return
+		}
+	}
+	state.add(value)
+}
+
+// randPool holds random sources for sampling, since a shared
+// *rand.Rand requires a lock and the global source is contended.
+var randPool = sync.Pool{
+	New: func() any {
+		return rand.New(rand.NewSource(rand.Int63()))
+	},
+}
+
+// sample returns whether to keep value and, if so, value scaled by
+// the inverse of the sampling probability.
+func (s *State[N, Traits, M]) sample(value N) (N, bool) {
+	rnd := randPool.Get().(*rand.Rand)
+	defer randPool.Put(rnd)
+
+	if rnd.Float64() >= s.sampling {
+		return 0, false
+	}
+	scaled := float64(value) / s.sampling
+	if _, isFloat := any(value).(float64); isFloat {
+		return N(scaled), true
+	}
+	whole := math.Floor(scaled)
+	if rnd.Float64() < scaled-whole {
+		whole++
+	}
+	return N(whole), true
+}
+
+// UpdateWeighted implements aggregator.WeightedMethods, adding
+// value times weight.  When the overflow policy applies, a product
+// that overflows int64 is saturated and counts as an overflow.
+func (Methods[N, Traits, M]) UpdateWeighted(_ context.Context, state *State[N, Traits, M], value N, weight uint64) {
+	if state.policy != Wrap {
+		if v, ok := any(value).(int64); ok {
+			if product, overflow := mulInt64(v, weight); overflow {
+				state.add(N(product))
+				atomic.StoreUint32(&state.overflowed, 1)
+				return
+			}
+		}
+	}
+	state.add(value * N(weight))
+}
+
+// mulInt64 returns value times weight and false, or the saturated
+// product and true if it overflows.
+func mulInt64(value int64, weight uint64) (int64, bool) {
+	if value == 0 || weight == 0 {
+		return 0, false
+	}
+	limit := uint64(math.MaxInt64)
+	if value < 0 {
+		limit++
+	}
+	magnitude := uint64(value)
+	if value < 0 {
+		magnitude = -magnitude
+	}
+	if weight > limit/magnitude {
+		if value < 0 {
+			return math.MinInt64, true
+		}
+		return math.MaxInt64, true
+	}
+	return value * int64(weight), false
+}
+
+// add adds value to the sum, applying the overflow policy to int64
+// sums.
+func (s *State[N, Traits, M]) add(value N) {
+	var ptr *int64
+	if s.policy != Wrap {
+		ptr, _ = any(&s.value).(*int64)
+	}
+	if ptr == nil {
+		var t Traits
+		t.AddAtomic(&s.value, value)
+		return
+	}
+	incr := int64(value)
+	for {
+		old := atomic.LoadInt64(ptr)
+		sum := old + incr
+		overflow := (incr > 0 && sum < old) || (incr < 0 && sum > old)
+		if overflow {
+			switch {
+			case s.policy == Reset:
+				sum = incr
+			case incr > 0:
+				sum = math.MaxInt64
+			default:
+				sum = math.MinInt64
+			}
+		}
+		if atomic.CompareAndSwapInt64(ptr, old, sum) {
+			if overflow {
+				atomic.StoreUint32(&s.overflowed, 1)
+			}
+			return
+		}
+	}
+}
+
+// TakeOverflow implements aggregator.OverflowMethods.
+func (Methods[N, Traits, M]) TakeOverflow(state *State[N, Traits, M]) (N, bool) {
+	if atomic.SwapUint32(&state.overflowed, 0) == 0 {
+		return 0, false
+	}
 	var t Traits
-	t.AddAtomic(&state.value, value)
+	return t.GetAtomic(&state.value), true
 }
 
 func (Methods[N, Traits, M]) Copy(from, to *State[N, Traits, M]) {
@@ -127,8 +325,10 @@ func (Methods[N, Traits, M]) Copy(from, to *State[N, Traits, M]) {
 }
 
 func (Methods[N, Traits, M]) Merge(from, to *State[N, Traits, M]) {
-	var t Traits
-	t.AddAtomic(&to.value, from.value)
+	to.add(from.value)
+	if from.overflowed != 0 {
+		atomic.StoreUint32(&to.overflowed, 1)
+	}
 }
 
 func (Methods[N, Traits, M]) ToAggregation(state *State[N, Traits, M]) aggregation.Aggregation {
@@ -143,3 +343,9 @@ func (Methods[N, Traits, M]) ToStorage(aggr aggregation.Aggregation) (*State[N,
 func (Methods[N, Traits, M]) SubtractSwap(operand, argument *State[N, Traits, M]) {
 	operand.value = argument.value - operand.value
 }
+
+// IsReset returns true when a monotonic sum decreased.
+func (Methods[N, Traits, M]) IsReset(prior, current *State[N, Traits, M]) bool {
+	var m M
+	return m.kind() == aggregation.MonotonicSumKind && current.value < prior.value
+}
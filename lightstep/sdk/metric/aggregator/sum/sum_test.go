@@ -15,9 +15,12 @@
 package sum // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
 
 import (
+	"context"
+	"math"
 	"testing"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/test"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/stretchr/testify/require"
@@ -28,6 +31,7 @@ func TestMonotonicity(t *testing.T) {
 	require.True(t, NewMonotonicFloat64(2).IsMonotonic())
 	require.False(t, NewNonMonotonicInt64(3).IsMonotonic())
 	require.False(t, NewNonMonotonicFloat64(4).IsMonotonic())
+	require.True(t, NewMonotonicUint64(5).IsMonotonic())
 }
 
 func TestInt64MonotonicSum(t *testing.T) {
@@ -38,6 +42,157 @@ func TestFloat64MonotonicSum(t *testing.T) {
 	test.GenericAggregatorTest[float64, MonotonicFloat64, MonotonicFloat64Methods](t, number.ToFloat64)
 }
 
+func TestUint64MonotonicSum(t *testing.T) {
+	test.GenericAggregatorTest[uint64, MonotonicUint64, MonotonicUint64Methods](t, number.ToUint64)
+}
+
+func TestUint64Saturation(t *testing.T) {
+	var methods MonotonicUint64Methods
+	var state, other MonotonicUint64
+
+	methods.Init(&state, aggregator.Config{})
+	methods.Init(&other, aggregator.Config{})
+
+	methods.Update(&state, math.MaxUint64-1)
+	methods.Update(&state, 1)
+	require.Equal(t, uint64(math.MaxUint64), number.ToUint64(state.Sum()))
+
+	// Further updates and merges do not overflow.
+	methods.Update(&state, 10)
+	require.Equal(t, uint64(math.MaxUint64), number.ToUint64(state.Sum()))
+
+	methods.Update(&other, 10)
+	methods.Merge(&state, &other)
+	require.Equal(t, uint64(math.MaxUint64), number.ToUint64(other.Sum()))
+}
+
+func TestInt64Overflow(t *testing.T) {
+	var methods NonMonotonicInt64Methods
+	cfg := aggregator.Config{
+		SumOverflow: WithOverflowPolicy(Saturate),
+	}
+	var state, other NonMonotonicInt64
+
+	methods.Init(&state, cfg)
+	methods.Init(&other, cfg)
+
+	methods.Update(&state, math.MaxInt64-1)
+	_, overflow := methods.TakeOverflow(&state)
+	require.False(t, overflow)
+
+	methods.Update(&state, 10)
+	value, overflow := methods.TakeOverflow(&state)
+	require.True(t, overflow)
+	require.Equal(t, int64(math.MaxInt64), value)
+	_, overflow = methods.TakeOverflow(&state)
+	require.False(t, overflow)
+
+	// Negative overflow saturates at the minimum.
+	methods.Update(&other, math.MinInt64)
+	methods.Update(&other, -1)
+	require.Equal(t, int64(math.MinInt64), number.ToInt64(other.Sum()))
+
+	// Overflow in the source of a Move or Merge is carried over.
+	var moved NonMonotonicInt64
+	methods.Init(&moved, cfg)
+	methods.Move(&other, &moved)
+	methods.Merge(&moved, &state)
+	value, overflow = methods.TakeOverflow(&state)
+	require.True(t, overflow)
+	require.Equal(t, int64(-1), value)
+
+	// Reset restarts from the increment that overflowed.
+	var reset NonMonotonicInt64
+	methods.Init(&reset, aggregator.Config{
+		SumOverflow: WithOverflowPolicy(Reset),
+	})
+	methods.Update(&reset, 5)
+	methods.Update(&reset, math.MaxInt64)
+	value, overflow = methods.TakeOverflow(&reset)
+	require.True(t, overflow)
+	require.Equal(t, int64(math.MaxInt64), value)
+
+	// A weighted product that overflows saturates.
+	var weighted NonMonotonicInt64
+	methods.Init(&weighted, cfg)
+	methods.UpdateWeighted(context.Background(), &weighted, -1<<40, 1<<30)
+	value, overflow = methods.TakeOverflow(&weighted)
+	require.True(t, overflow)
+	require.Equal(t, int64(math.MinInt64), value)
+
+	methods.UpdateWeighted(context.Background(), &other, math.MinInt64/4, 5)
+	_, overflow = methods.TakeOverflow(&other)
+	require.True(t, overflow)
+
+	// The default wraps around and is not detected.
+	var wrap MonotonicInt64
+	var mmethods MonotonicInt64Methods
+	mmethods.Init(&wrap, aggregator.Config{})
+	mmethods.Update(&wrap, math.MaxInt64)
+	mmethods.Update(&wrap, 1)
+	require.Equal(t, int64(math.MinInt64), number.ToInt64(wrap.Sum()))
+	_, overflow = mmethods.TakeOverflow(&wrap)
+	require.False(t, overflow)
+}
+
+func TestOverflowPolicyValidate(t *testing.T) {
+	_, err := aggregator.Config{
+		SumOverflow: WithOverflowPolicy(Reset + 1),
+	}.Validate()
+	require.Error(t, err)
+}
+
+// testSampling records 1000 updates of 3 per collection with
+// sampling probability 0.25 and checks that the cumulative total of
+// 100 collections is close to the unsampled total.
+func testSampling[N number.Any, Storage any, Methods aggregator.Methods[N, Storage]](t *testing.T, toFloat64 func(number.Number) float64) {
+	var methods Methods
+	var state, snapshot Storage
+	cfg := aggregator.Config{
+		SumSampling: WithSampling(0.25),
+	}
+	methods.Init(&state, cfg)
+	methods.Init(&snapshot, cfg)
+
+	var total float64
+	for c := 0; c < 100; c++ {
+		for i := 0; i < 1000; i++ {
+			methods.Update(&state, 3)
+		}
+		methods.Move(&state, &snapshot)
+		total += toFloat64(methods.ToAggregation(&snapshot).(aggregation.Sum).Sum())
+	}
+	require.InEpsilon(t, 300000, total, 0.03)
+}
+
+func TestSampling(t *testing.T) {
+	t.Run("int64", func(t *testing.T) {
+		testSampling[int64, MonotonicInt64, MonotonicInt64Methods](t, func(n number.Number) float64 {
+			return float64(number.ToInt64(n))
+		})
+	})
+	t.Run("float64", func(t *testing.T) {
+		testSampling[float64, MonotonicFloat64, MonotonicFloat64Methods](t, number.ToFloat64)
+	})
+}
+
+func TestSamplingDisabled(t *testing.T) {
+	for _, p := range []float64{0, 1} {
+		var methods MonotonicInt64Methods
+		var state MonotonicInt64
+		methods.Init(&state, aggregator.Config{SumSampling: WithSampling(p)})
+		for i := 0; i < 100; i++ {
+			methods.Update(&state, 1)
+		}
+		require.Equal(t, int64(100), number.ToInt64(state.Sum()))
+	}
+
+	for _, p := range []float64{-0.5, 1.5, math.NaN()} {
+		_, err := aggregator.Config{SumSampling: WithSampling(p)}.Validate()
+		require.Error(t, err)
+	}
+}
+
 func TestInt64NonMonotonicSum(t *testing.T) {
 	test.GenericAggregatorTest[int64, NonMonotonicInt64, NonMonotonicInt64Methods](t, number.ToInt64)
 }
@@ -70,4 +225,15 @@ func TestSubtract(t *testing.T) {
 	genericSubtractTest[float64, MonotonicFloat64, MonotonicFloat64Methods](t)
 	genericSubtractTest[int64, NonMonotonicInt64, NonMonotonicInt64Methods](t)
 	genericSubtractTest[float64, NonMonotonicFloat64, NonMonotonicFloat64Methods](t)
+	genericSubtractTest[uint64, MonotonicUint64, MonotonicUint64Methods](t)
+}
+
+func TestIsReset(t *testing.T) {
+	var mono MonotonicInt64Methods
+	require.True(t, mono.IsReset(NewMonotonicInt64(10), NewMonotonicInt64(3)))
+	require.False(t, mono.IsReset(NewMonotonicInt64(10), NewMonotonicInt64(10)))
+	require.False(t, mono.IsReset(NewMonotonicInt64(3), NewMonotonicInt64(10)))
+
+	var nonMono NonMonotonicInt64Methods
+	require.False(t, nonMono.IsReset(NewNonMonotonicInt64(10), NewNonMonotonicInt64(3)))
 }
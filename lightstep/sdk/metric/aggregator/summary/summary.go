@@ -0,0 +1,397 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/summary"
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+)
+
+// The summary aggregation estimates quantiles using a sketch of
+// logarithmically-spaced buckets, in the manner of DDSketch.  For
+// relative error alpha, let gamma = (1+alpha)/(1-alpha).  Bucket k
+// counts the values in (gamma**(k-1), gamma**k], all of which are
+// within alpha of the estimate 2*gamma**k/(gamma+1).  Negative
+// values are counted by magnitude in a separate set of buckets.
+//
+// Sketches with the same relative error merge exactly.  Otherwise,
+// each input bucket is counted at its estimated value, so the merged
+// error is at most the sum of the two relative errors.
+
+type (
+	Methods[N number.Any, Traits number.Traits[N]] struct{}
+
+	State[N number.Any, Traits number.Traits[N]] struct {
+		lock      sync.Mutex
+		quantiles aggregator.Quantiles
+		gamma     float64
+		logGamma  float64
+
+		// positive and negative map bucket index to count.
+		positive map[int]uint64
+		negative map[int]uint64
+		zero     uint64
+
+		sum   N
+		count uint64
+		min   N
+		max   N
+	}
+
+	Int64   = State[int64, number.Int64Traits]
+	Float64 = State[float64, number.Float64Traits]
+
+	Int64Methods   = Methods[int64, number.Int64Traits]
+	Float64Methods = Methods[float64, number.Float64Traits]
+)
+
+var (
+	_ aggregator.Methods[int64, Int64]     = Int64Methods{}
+	_ aggregator.Methods[float64, Float64] = Float64Methods{}
+
+	_ aggregator.WeightedMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.WeightedMethods[float64, Float64] = Float64Methods{}
+
+	_ aggregator.MemoryMethods[int64, Int64]     = Int64Methods{}
+	_ aggregator.MemoryMethods[float64, Float64] = Float64Methods{}
+
+	_ aggregation.Summary = &Int64{}
+	_ aggregation.Summary = &Float64{}
+
+	// DefaultQuantiles are estimated when the aggregator
+	// configuration has no SummaryQuantiles.
+	DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+)
+
+// DefaultRelativeError is the relative accuracy of quantile
+// estimates when the aggregator configuration has no
+// SummaryRelativeError.
+const DefaultRelativeError = 0.01
+
+// WithQuantiles returns the quantiles to estimate, for use as the
+// aggregator.Config SummaryQuantiles field.  Quantiles must be in
+// the range [0, 1] and sorted in increasing order.
+func WithQuantiles(quantiles []float64) aggregator.Quantiles {
+	return aggregator.NewQuantiles(quantiles)
+}
+
+// WithRelativeError returns the relative accuracy of quantile
+// estimates, for use as the aggregator.Config SummaryRelativeError
+// field.  Smaller values use more buckets: the number of buckets
+// needed to cover a range of values grows as 1/relErr.
+func WithRelativeError(relErr float64) float64 {
+	return relErr
+}
+
+func NewInt64(cfg aggregator.Config, vals ...int64) *Int64 {
+	return newState[int64, number.Int64Traits](cfg, vals...)
+}
+
+func NewFloat64(cfg aggregator.Config, vals ...float64) *Float64 {
+	return newState[float64, number.Float64Traits](cfg, vals...)
+}
+
+func newState[N number.Any, Traits number.Traits[N]](cfg aggregator.Config, values ...N) *State[N, Traits] {
+	var methods Methods[N, Traits]
+	agg := &State[N, Traits]{}
+	methods.Init(agg, cfg)
+	for _, v := range values {
+		methods.Update(agg, v)
+	}
+	return agg
+}
+
+func (s *State[N, Traits]) Kind() aggregation.Kind {
+	return aggregation.SummaryKind
+}
+
+func (s *State[N, Traits]) Count() uint64 {
+	return s.count
+}
+
+func (s *State[N, Traits]) Sum() number.Number {
+	var traits Traits
+	return traits.ToNumber(s.sum)
+}
+
+// Quantiles returns the estimated value of each configured quantile,
+// or nil when no values were recorded.  The 0 and 1 quantiles are
+// the exact minimum and maximum values.
+func (s *State[N, Traits]) Quantiles() []aggregation.QuantileValue {
+	if s.count == 0 {
+		return nil
+	}
+
+	// Visit the buckets in increasing order of value: negative
+	// buckets by decreasing magnitude, zero, then positive.
+	type bucket struct {
+		value float64
+		count uint64
+	}
+	buckets := make([]bucket, 0, len(s.negative)+len(s.positive)+1)
+	for _, k := range sortedKeys(s.negative) {
+		buckets = append(buckets, bucket{-s.estimate(k), s.negative[k]})
+	}
+	for i, j := 0, len(buckets)-1; i < j; i, j = i+1, j-1 {
+		buckets[i], buckets[j] = buckets[j], buckets[i]
+	}
+	if s.zero != 0 {
+		buckets = append(buckets, bucket{0, s.zero})
+	}
+	for _, k := range sortedKeys(s.positive) {
+		buckets = append(buckets, bucket{s.estimate(k), s.positive[k]})
+	}
+
+	minValue, maxValue := float64(s.min), float64(s.max)
+	result := make([]aggregation.QuantileValue, s.quantiles.Len())
+
+	var idx int
+	var cumulative uint64
+	for i := range result {
+		q := s.quantiles.At(i)
+		result[i].Quantile = q
+
+		switch q {
+		case 0:
+			result[i].Value = minValue
+			continue
+		case 1:
+			result[i].Value = maxValue
+			continue
+		}
+
+		// Find the bucket containing the value at this
+		// zero-based rank.  The quantiles are increasing,
+		// so the search continues from the prior bucket.
+		rank := q * float64(s.count-1)
+		for idx < len(buckets) && float64(cumulative+buckets[idx].count) <= rank {
+			cumulative += buckets[idx].count
+			idx++
+		}
+		value := maxValue
+		if idx < len(buckets) {
+			value = buckets[idx].value
+		}
+		result[i].Value = math.Min(maxValue, math.Max(minValue, value))
+	}
+	return result
+}
+
+// RelativeError returns the relative accuracy of the quantile
+// estimates.
+func (s *State[N, Traits]) RelativeError() float64 {
+	return (s.gamma - 1) / (s.gamma + 1)
+}
+
+// estimate returns the value counted by positive bucket k.
+func (s *State[N, Traits]) estimate(k int) float64 {
+	return 2 * math.Exp(float64(k)*s.logGamma) / (s.gamma + 1)
+}
+
+// index returns the bucket of a positive value.
+func (s *State[N, Traits]) index(value float64) int {
+	return int(math.Ceil(math.Log(value) / s.logGamma))
+}
+
+func (Methods[N, Traits]) Kind() aggregation.Kind {
+	return aggregation.SummaryKind
+}
+
+func (Methods[N, Traits]) Init(agg *State[N, Traits], cfg aggregator.Config) {
+	agg.quantiles = cfg.SummaryQuantiles
+	if !agg.quantiles.Defined() {
+		agg.quantiles = WithQuantiles(DefaultQuantiles)
+	}
+	relErr := cfg.SummaryRelativeError
+	if relErr == 0 {
+		relErr = DefaultRelativeError
+	}
+	agg.gamma = (1 + relErr) / (1 - relErr)
+	agg.logGamma = math.Log(agg.gamma)
+}
+
+func (Methods[N, Traits]) HasChange(ptr *State[N, Traits]) bool {
+	return ptr.count != 0
+}
+
+// MemorySize counts a key and a count per non-empty bucket.
+func (Methods[N, Traits]) MemorySize(ptr *State[N, Traits]) int {
+	ptr.lock.Lock()
+	defer ptr.lock.Unlock()
+	return 16 * (len(ptr.positive) + len(ptr.negative))
+}
+
+func (Methods[N, Traits]) Update(agg *State[N, Traits], number N) {
+	agg.update(float64(number), number, 1)
+}
+
+// UpdateWeighted implements aggregator.WeightedMethods, counting
+// weight observations of number.
+func (Methods[N, Traits]) UpdateWeighted(_ context.Context, agg *State[N, Traits], number N, weight uint64) {
+	agg.update(float64(number), number, weight)
+}
+
+func (s *State[N, Traits]) update(value float64, number N, weight uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if weight == 0 {
+		return
+	}
+	if s.count == 0 || number < s.min {
+		s.min = number
+	}
+	if s.count == 0 || number > s.max {
+		s.max = number
+	}
+	s.sum += number * N(weight)
+	s.count += weight
+	s.insert(value, weight)
+}
+
+// insert counts weight observations of value; the lock is held.
+func (s *State[N, Traits]) insert(value float64, weight uint64) {
+	switch {
+	case value > 0:
+		if s.positive == nil {
+			s.positive = map[int]uint64{}
+		}
+		s.positive[s.index(value)] += weight
+	case value < 0:
+		if s.negative == nil {
+			s.negative = map[int]uint64{}
+		}
+		s.negative[s.index(-value)] += weight
+	default:
+		s.zero += weight
+	}
+}
+
+func (Methods[N, Traits]) Move(from, to *State[N, Traits]) {
+	from.lock.Lock()
+	defer from.lock.Unlock()
+
+	to.quantiles = from.quantiles
+	to.gamma = from.gamma
+	to.logGamma = from.logGamma
+	to.positive, from.positive = from.positive, clearBuckets(to.positive)
+	to.negative, from.negative = from.negative, clearBuckets(to.negative)
+	to.zero, from.zero = from.zero, 0
+	to.sum, from.sum = from.sum, 0
+	to.count, from.count = from.count, 0
+	to.min, from.min = from.min, 0
+	to.max, from.max = from.max, 0
+}
+
+func (Methods[N, Traits]) Copy(from, to *State[N, Traits]) {
+	from.lock.Lock()
+	defer from.lock.Unlock()
+
+	to.quantiles = from.quantiles
+	to.gamma = from.gamma
+	to.logGamma = from.logGamma
+	to.positive = copyBuckets(clearBuckets(to.positive), from.positive)
+	to.negative = copyBuckets(clearBuckets(to.negative), from.negative)
+	to.zero = from.zero
+	to.sum = from.sum
+	to.count = from.count
+	to.min = from.min
+	to.max = from.max
+}
+
+func (Methods[N, Traits]) Merge(from, to *State[N, Traits]) {
+	to.lock.Lock()
+	defer to.lock.Unlock()
+
+	if from.count == 0 {
+		return
+	}
+	if to.count == 0 || from.min < to.min {
+		to.min = from.min
+	}
+	if to.count == 0 || from.max > to.max {
+		to.max = from.max
+	}
+	to.sum += from.sum
+	to.count += from.count
+	to.zero += from.zero
+
+	if from.gamma == to.gamma {
+		to.positive = copyBuckets(to.positive, from.positive)
+		to.negative = copyBuckets(to.negative, from.negative)
+		return
+	}
+
+	// The bucket sizes differ, re-insert each bucket's estimate.
+	for k, c := range from.positive {
+		to.insert(from.estimate(k), c)
+	}
+	for k, c := range from.negative {
+		to.insert(-from.estimate(k), c)
+	}
+}
+
+func (Methods[N, Traits]) ToAggregation(agg *State[N, Traits]) aggregation.Aggregation {
+	return agg
+}
+
+func (Methods[N, Traits]) ToStorage(aggr aggregation.Aggregation) (*State[N, Traits], bool) {
+	r, ok := aggr.(*State[N, Traits])
+	return r, ok
+}
+
+func (Methods[N, Traits]) SubtractSwap(operand, argument *State[N, Traits]) {
+	// This can't be called b/c summaries are only used with synchronous instruments,
+	// which start as delta temporality and thus never subtract.
+	panic("impossible call")
+}
+
+// clearBuckets empties a bucket map for reuse.
+func clearBuckets(buckets map[int]uint64) map[int]uint64 {
+	for k := range buckets {
+		delete(buckets, k)
+	}
+	return buckets
+}
+
+// copyBuckets adds the counts of from into to, which is allocated
+// if necessary and returned.
+func copyBuckets(to, from map[int]uint64) map[int]uint64 {
+	if len(from) == 0 {
+		return to
+	}
+	if to == nil {
+		to = make(map[int]uint64, len(from))
+	}
+	for k, c := range from {
+		to[k] += c
+	}
+	return to
+}
+
+func sortedKeys(buckets map[int]uint64) []int {
+	keys := make([]int, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/test"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInt64Summary(t *testing.T) {
+	test.GenericAggregatorTest[int64, Int64, Int64Methods](t, number.ToInt64)
+}
+
+func TestFloat64Summary(t *testing.T) {
+	test.GenericAggregatorTest[float64, Float64, Float64Methods](t, number.ToFloat64)
+}
+
+// requireAccurate checks each quantile estimate against the exact
+// value from sorted input.
+func requireAccurate(t *testing.T, agg aggregation.Summary, sorted []float64, relErr float64) {
+	qvs := agg.Quantiles()
+	require.NotEmpty(t, qvs)
+
+	for _, qv := range qvs {
+		exact := sorted[int(qv.Quantile*float64(len(sorted)-1))]
+		require.InDelta(t, exact, qv.Value, relErr*math.Abs(exact), "quantile %v", qv.Quantile)
+	}
+}
+
+func TestAccuracy(t *testing.T) {
+	for _, relErr := range []float64{0.001, 0.01, 0.05} {
+		cfg := aggregator.Config{
+			SummaryQuantiles:     WithQuantiles([]float64{0, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 1}),
+			SummaryRelativeError: WithRelativeError(relErr),
+		}
+		rnd := rand.New(rand.NewSource(int64(relErr * 1e6)))
+
+		var values []float64
+		for i := 0; i < 10000; i++ {
+			// Log-uniform values, both signs.
+			v := math.Exp(rnd.Float64()*20 - 10)
+			if i%3 == 0 {
+				v = -v
+			}
+			values = append(values, v)
+		}
+		agg := NewFloat64(cfg, values...)
+		sort.Float64s(values)
+
+		require.Equal(t, relErr, math.Round(agg.RelativeError()*1e9)/1e9)
+		requireAccurate(t, agg, values, relErr)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	agg := NewInt64(aggregator.Config{}, 1, 2, 3, 4)
+
+	require.Equal(t, uint64(4), agg.Count())
+	require.Equal(t, int64(10), number.ToInt64(agg.Sum()))
+	require.InDelta(t, DefaultRelativeError, agg.RelativeError(), 1e-12)
+
+	var quantiles []float64
+	for _, qv := range agg.Quantiles() {
+		quantiles = append(quantiles, qv.Quantile)
+	}
+	require.Equal(t, DefaultQuantiles, quantiles)
+}
+
+func TestZeroAndExtremes(t *testing.T) {
+	cfg := aggregator.Config{
+		SummaryQuantiles: WithQuantiles([]float64{0, 0.5, 1}),
+	}
+	agg := NewFloat64(cfg, -3, 0, 0, 0, 7)
+
+	require.Equal(t, []aggregation.QuantileValue{
+		{Quantile: 0, Value: -3},
+		{Quantile: 0.5, Value: 0},
+		{Quantile: 1, Value: 7},
+	}, agg.Quantiles())
+}
+
+func TestMergeSameError(t *testing.T) {
+	var methods Float64Methods
+	cfg := aggregator.Config{}
+
+	var values []float64
+	for i := 1; i <= 1000; i++ {
+		values = append(values, float64(i))
+	}
+
+	// Merging the halves is the same as updating once.
+	whole := NewFloat64(cfg, values...)
+	merged := NewFloat64(cfg, values[:500]...)
+	methods.Merge(NewFloat64(cfg, values[500:]...), merged)
+
+	require.Equal(t, whole.Quantiles(), merged.Quantiles())
+	require.Equal(t, whole.Count(), merged.Count())
+	require.Equal(t, whole.Sum(), merged.Sum())
+}
+
+func TestMergeDifferentError(t *testing.T) {
+	var methods Float64Methods
+	fine := aggregator.Config{SummaryRelativeError: WithRelativeError(0.001)}
+	coarse := aggregator.Config{SummaryRelativeError: WithRelativeError(0.02)}
+
+	var values []float64
+	for i := 1; i <= 1000; i++ {
+		values = append(values, float64(i))
+	}
+
+	// The input buckets are approximated in the output, whose
+	// error bound grows by the input's relative error.
+	merged := NewFloat64(coarse, values[:500]...)
+	methods.Merge(NewFloat64(fine, values[500:]...), merged)
+
+	require.Equal(t, uint64(1000), merged.Count())
+	requireAccurate(t, merged, values, 0.021)
+}
+
+func TestMoveCopy(t *testing.T) {
+	var methods Int64Methods
+	cfg := aggregator.Config{}
+
+	from := NewInt64(cfg, 1, 10, 100)
+	expect := from.Quantiles()
+
+	var moved, copied Int64
+	methods.Init(&moved, cfg)
+	methods.Init(&copied, cfg)
+
+	methods.Copy(from, &copied)
+	methods.Move(from, &moved)
+
+	require.Equal(t, expect, copied.Quantiles())
+	require.Equal(t, expect, moved.Quantiles())
+	require.False(t, methods.HasChange(from))
+	require.Nil(t, from.Quantiles())
+
+	// The moved-from state is reusable.
+	methods.Update(from, 5)
+	require.Equal(t, uint64(1), from.Count())
+	require.Equal(t, uint64(3), moved.Count())
+	require.Greater(t, methods.MemorySize(&moved), 0)
+}
@@ -38,6 +38,13 @@ func GenericAggregatorTest[N number.Any, Storage any, Methods aggregator.Methods
 		} else if h, ok := agg.(aggregation.Histogram); ok {
 			require.Equal(t, uint64(0), h.Count())
 			require.Equal(t, N(0), nf(h.Sum()))
+		} else if h, ok := agg.(aggregation.ExplicitHistogram); ok {
+			require.Equal(t, uint64(0), h.Count())
+			require.Equal(t, N(0), nf(h.Sum()))
+		} else if h, ok := agg.(aggregation.Summary); ok {
+			require.Equal(t, uint64(0), h.Count())
+			require.Equal(t, N(0), nf(h.Sum()))
+			require.Nil(t, h.Quantiles())
 		} else if s, ok := agg.(aggregation.Sum); ok {
 			require.Equal(t, N(0), nf(s.Sum()))
 		} else if mmsc, ok := agg.(aggregation.MinMaxSumCount); ok {
@@ -15,8 +15,14 @@
 package metric // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric"
 
 import (
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/asyncstate"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 )
 
@@ -32,6 +38,54 @@ type config struct {
 	// views is a slice of *Views instances corresponding with readers.
 	// the i'th views applies to the i'th reader.
 	views []*view.Views
+
+	// onMeasurementError is called for invalid measurements.
+	onMeasurementError aggregator.MeasurementErrorHandler
+
+	// selfMeter, if not nil, records collection durations.
+	selfMeter metric.Meter
+
+	// clock is the source of collection and gauge timestamps.
+	clock Clock
+
+	// internAttributes enables the attribute intern pool.
+	internAttributes bool
+
+	// callbackRunner executes asynchronous callbacks.
+	callbackRunner asyncstate.Runner
+
+	// negativeHistogramValues permits negative histogram
+	// measurements.
+	negativeHistogramValues bool
+
+	// descriptorProcessor, if not nil, rewrites instrument
+	// descriptors before views are applied.
+	descriptorProcessor func(sdkinstrument.Descriptor) sdkinstrument.Descriptor
+
+	// maxInstruments, if positive, limits the number of distinct
+	// instruments.
+	maxInstruments int
+
+	// cardinalityEstimation enables the estimated attribute-set
+	// count of synchronous instruments.
+	cardinalityEstimation bool
+
+	// asyncIngest, if positive, is the size of the queue that
+	// synchronous measurements are aggregated through.
+	asyncIngest int
+}
+
+// Clock is a source of the current time, see WithClock.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the default Clock.
+type realClock struct{}
+
+// Now implements Clock.
+func (realClock) Now() time.Time {
+	return time.Now()
 }
 
 // Option applies a configuration option value to a MeterProvider.
@@ -68,3 +122,195 @@ func WithReader(r Reader, opts ...view.Option) Option {
 		return cfg
 	})
 }
+
+// WithMeasurementErrorHandler configures a function that is called
+// for each measurement that is dropped because it is invalid for the
+// instrument, i.e., negative values for counters and histograms and
+// NaN or infinite values.  The handler is called synchronously from
+// the instrument's Add or Record method, before any aggregator state
+// is modified.  By default, invalid measurements are dropped and
+// reported through otel.Handle at a limited rate.
+//
+// The handler is also called during collection when an int64 sum
+// configured with sum.WithOverflowPolicy() overflows, with reason
+// aggregator.ErrSumOverflow.
+func WithMeasurementErrorHandler(h aggregator.MeasurementErrorHandler) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.onMeasurementError = h
+		return cfg
+	})
+}
+
+// WithClock configures the source of the current time used for the
+// start time of the MeterProvider, the time of each collection, and
+// gauge update times.  Durations measured by WithSelfObservability
+// are not affected.  By default, the real-time clock is used.  The
+// clock should be comparable, such as a pointer, since it becomes
+// part of aggregator.Config.
+func WithClock(clock Clock) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.clock = clock
+		return cfg
+	})
+}
+
+// WithDescriptorProcessor configures a function that rewrites the
+// descriptor of each new instrument before views are applied, e.g.,
+// to enforce naming conventions for third-party instrumentation.
+// View clauses match the rewritten name, and the rewritten name,
+// description, and unit are output unless a view replaces them.
+//
+// The processor is called before the aggregation hint, if any, is
+// parsed from the description, so it should preserve a hint.
+// Changes to the instrument kind and number kind are ignored.  The
+// processor is called once per reader for each new instrument, and
+// should be deterministic.
+func WithDescriptorProcessor(fn func(sdkinstrument.Descriptor) sdkinstrument.Descriptor) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.descriptorProcessor = fn
+		return cfg
+	})
+}
+
+// WithMaxInstruments limits the number of distinct instruments
+// created through all Meters of the MeterProvider to n.  Past the
+// limit, creating a new instrument returns one that performs no
+// operations along with an error wrapping ErrTooManyInstruments,
+// which is also passed to otel.Handle.  Instruments that were already
+// created continue to be returned for a repeat registration.  By
+// default, or when n is not positive, the number of instruments is
+// not limited.
+func WithMaxInstruments(n int) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.maxInstruments = n
+		return cfg
+	})
+}
+
+// WithAttributeInterning, when true, causes the synchronous
+// instruments of the MeterProvider to share one copy of each
+// attribute set in use, instead of one copy per instrument.  This
+// saves memory and allocations when many instruments are used with
+// the same attributes, at the cost of a shared lock taken when an
+// instrument first uses (or stops using) an attribute set.
+func WithAttributeInterning(enabled bool) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.internAttributes = enabled
+		return cfg
+	})
+}
+
+// WithNegativeHistogramValues, when true, causes histogram
+// instruments to accept negative measurements instead of dropping
+// them with aggregator.ErrNegativeInput.  Exponential histograms
+// record negative values in a bucket range separate from positive
+// values; explicit histograms count them in the buckets below the
+// first non-negative boundary.  Counters continue to reject negative
+// values.
+func WithNegativeHistogramValues(enabled bool) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.negativeHistogramValues = enabled
+		return cfg
+	})
+}
+
+// WithCardinalityEstimation, when true, causes each synchronous
+// instrument to estimate the number of distinct attribute sets it has
+// been used with, using a HyperLogLog sketch of about 1KiB with a
+// standard error of about 3%.  The sketch is updated only when an
+// attribute set is not already in use, which adds no cost to
+// measurements of active attribute sets.  The estimate is observed
+// by WithSelfObservability, giving early warning of cardinality
+// growth independent of view.WithCardinalityLimit.
+func WithCardinalityEstimation(enabled bool) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.cardinalityEstimation = enabled
+		return cfg
+	})
+}
+
+// WithAsyncIngest causes the Add and Record methods of synchronous
+// instruments, and their variants for attribute sets, batches,
+// weights, buckets, and start times, to enqueue each measurement on a
+// lock-free ring buffer
+// with room for at least bufferSize measurements, instead of
+// aggregating it in the calling goroutine.  A background goroutine
+// drains the buffer into the aggregators, and each collection drains
+// the measurements that are waiting first.  This trades latency and
+// an allocation per measurement for lower contention among the
+// goroutines that make measurements.
+//
+// When the buffer is full, measurements are dropped, reported to the
+// handler configured by WithMeasurementErrorHandler with reason
+// aggregator.ErrIngestDropped, and counted; the count is observed by
+// WithSelfObservability.  Shutdown drains the buffer and stops the
+// goroutine, after which measurements are aggregated synchronously.
+// By default, or when bufferSize is not positive, measurements are
+// aggregated synchronously.
+func WithAsyncIngest(bufferSize int) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.asyncIngest = bufferSize
+		return cfg
+	})
+}
+
+// WithCallbackTimeout limits the time each asynchronous callback
+// may run during a collection.  A callback that exceeds d is
+// abandoned: the collection proceeds without waiting for it, its
+// observations from this collection are ignored, an error is
+// reported through otel.Handle, and the observations from its last
+// completed run are reported again.  The context passed to the
+// callback is canceled at the deadline.  An abandoned callback
+// continues until its function returns, since it cannot be stopped;
+// until then, later collections do not call it again, and report
+// the observations from its last completed run instead.  By default,
+// callbacks have no timeout other than the collection's context.
+func WithCallbackTimeout(d time.Duration) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.callbackRunner.Timeout = d
+		return cfg
+	})
+}
+
+// WithCallbackConcurrency allows up to n asynchronous callbacks to
+// execute at once during a collection.  By default, callbacks run
+// sequentially in registration order.  When callbacks run
+// concurrently and more than one observes the same gauge with the
+// same attributes, which value is kept is not defined.
+func WithCallbackConcurrency(n int) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.callbackRunner.Concurrency = n
+		return cfg
+	})
+}
+
+// WithSelfObservability configures a Meter that the MeterProvider
+// uses to record the wall time of each collection, as histograms
+// named otel.sdk.metric.snapshot.duration (processing instruments
+// with SnapshotAndProcess) and otel.sdk.metric.collect.duration
+// (producing points with Collect), in seconds, with a reader
+// attribute naming the Reader.  The meter should belong to a
+// different MeterProvider, otherwise each collection records into
+// the next one.
+//
+// When the SDK is built with the otelmetriclockstats tag, the meter
+// also observes counters named otel.sdk.metric.lock.waits and
+// otel.sdk.metric.lock.wait_time (in seconds) describing contention
+// for the lock of each synchronous instrument, with scope and
+// instrument attributes naming the Meter and the instrument.
+//
+// With WithCardinalityEstimation, the meter also observes a gauge
+// named otel.sdk.metric.cardinality, the estimated number of distinct
+// attribute sets of each synchronous instrument, with the same scope
+// and instrument attributes.
+//
+// With WithAsyncIngest, the meter also observes a counter named
+// otel.sdk.metric.ingest.dropped, the number of measurements of each
+// synchronous instrument dropped because the ingest buffer was full,
+// with the same scope and instrument attributes.
+func WithSelfObservability(meter metric.Meter) Option {
+	return optionFunction(func(cfg config) config {
+		cfg.selfMeter = meter
+		return cfg
+	})
+}
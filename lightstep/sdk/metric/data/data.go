@@ -49,6 +49,12 @@ type (
 		// Library describes the instrumentation scope.
 		Library instrumentation.Library
 
+		// Resource, when non-nil, replaces the Metrics Resource
+		// for this scope.  This is the MeterProvider's Resource
+		// merged with resource attributes of the Meter, see
+		// MeterProvider.MeterWithResource.
+		Resource *resource.Resource
+
 		// Instruments is a slice of metric data, one per Instrument
 		// in the scope.
 		Instruments []Instrument
@@ -96,6 +102,48 @@ func (m *Metrics) Reset() {
 	resetScopes(&m.Scopes)
 }
 
+// ByResource groups the Scopes by their Resource, for exporters that
+// encode one Resource per batch.  Scopes without a Resource use the
+// Metrics Resource.  Groups are ordered by first appearance.  When
+// no Scope has a Resource, the result is m itself.
+func (m Metrics) ByResource() []Metrics {
+	override := false
+	for _, scope := range m.Scopes {
+		override = override || scope.Resource != nil
+	}
+	if !override {
+		return []Metrics{m}
+	}
+
+	type resKey struct {
+		attrs  attribute.Distinct
+		schema string
+	}
+	var groups []Metrics
+	index := map[resKey]int{}
+
+	for _, scope := range m.Scopes {
+		res := scope.Resource
+		if res == nil {
+			res = m.Resource
+		}
+		key := resKey{
+			attrs:  res.Equivalent(),
+			schema: res.SchemaURL(),
+		}
+		idx, ok := index[key]
+		if !ok {
+			idx = len(groups)
+			index[key] = idx
+			groups = append(groups, Metrics{
+				Resource: res,
+			})
+		}
+		groups[idx].Scopes = append(groups[idx].Scopes, scope)
+	}
+	return groups
+}
+
 func resetScopes(ss *[]Scope) {
 	for i := range *ss {
 		(*ss)[i].Reset()
@@ -0,0 +1,138 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+
+import (
+	"math"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FNV-1a parameters for 64-bit hashes.
+const (
+	fnvOffset64 uint64 = 14695981039346656037
+	fnvPrime64  uint64 = 1099511628211
+)
+
+// Type tags of the encoding hashed by HashAttributes.  These are
+// fixed here, rather than using attribute.Type, so that the hash
+// does not depend on the version of the attribute package.
+const (
+	hashInvalid byte = iota
+	hashBool
+	hashInt64
+	hashFloat64
+	hashString
+	hashBoolSlice
+	hashInt64Slice
+	hashFloat64Slice
+	hashStringSlice
+)
+
+// HashAttributes returns a stable 64-bit hash of an attribute set,
+// e.g., to partition points among exporter shards.  Sets are sorted
+// and deduplicated by construction, so equal sets hash equal
+// regardless of the order of the attributes they were built from.
+//
+// The hash is the 64-bit FNV-1a hash of an encoding of each
+// attribute in order: the key, a type tag, and the value, with
+// numbers as 8 little-endian bytes and strings and slices preceded
+// by their length.  The algorithm is fixed, so hashes may be
+// persisted and compared across processes and releases.  It is not
+// a cryptographic hash.
+func HashAttributes(set attribute.Set) uint64 {
+	h := attributeHash(fnvOffset64)
+	for iter := set.Iter(); iter.Next(); {
+		attr := iter.Attribute()
+		h.string(string(attr.Key))
+		h.value(attr.Value)
+	}
+	return uint64(h)
+}
+
+// attributeHash is the state of an FNV-1a hash.
+type attributeHash uint64
+
+func (h *attributeHash) byte(b byte) {
+	*h = attributeHash((uint64(*h) ^ uint64(b)) * fnvPrime64)
+}
+
+func (h *attributeHash) uint64(x uint64) {
+	for i := 0; i < 8; i++ {
+		h.byte(byte(x >> (8 * i)))
+	}
+}
+
+func (h *attributeHash) bool(b bool) {
+	if b {
+		h.byte(1)
+		return
+	}
+	h.byte(0)
+}
+
+func (h *attributeHash) string(s string) {
+	h.uint64(uint64(len(s)))
+	for i := 0; i < len(s); i++ {
+		h.byte(s[i])
+	}
+}
+
+func (h *attributeHash) value(v attribute.Value) {
+	switch v.Type() {
+	case attribute.BOOL:
+		h.byte(hashBool)
+		h.bool(v.AsBool())
+	case attribute.INT64:
+		h.byte(hashInt64)
+		h.uint64(uint64(v.AsInt64()))
+	case attribute.FLOAT64:
+		h.byte(hashFloat64)
+		h.uint64(math.Float64bits(v.AsFloat64()))
+	case attribute.STRING:
+		h.byte(hashString)
+		h.string(v.AsString())
+	case attribute.BOOLSLICE:
+		h.byte(hashBoolSlice)
+		s := v.AsBoolSlice()
+		h.uint64(uint64(len(s)))
+		for _, b := range s {
+			h.bool(b)
+		}
+	case attribute.INT64SLICE:
+		h.byte(hashInt64Slice)
+		s := v.AsInt64Slice()
+		h.uint64(uint64(len(s)))
+		for _, i := range s {
+			h.uint64(uint64(i))
+		}
+	case attribute.FLOAT64SLICE:
+		h.byte(hashFloat64Slice)
+		s := v.AsFloat64Slice()
+		h.uint64(uint64(len(s)))
+		for _, f := range s {
+			h.uint64(math.Float64bits(f))
+		}
+	case attribute.STRINGSLICE:
+		h.byte(hashStringSlice)
+		s := v.AsStringSlice()
+		h.uint64(uint64(len(s)))
+		for _, str := range s {
+			h.string(str)
+		}
+	default:
+		h.byte(hashInvalid)
+	}
+}
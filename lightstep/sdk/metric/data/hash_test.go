@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestHashAttributesStable(t *testing.T) {
+	// The FNV-1a offset basis.
+	require.Equal(t, uint64(14695981039346656037), HashAttributes(attribute.NewSet()))
+
+	// Computed independently from the documented encoding.
+	require.Equal(t, uint64(3937260992196528584), HashAttributes(attribute.NewSet(
+		attribute.String("a", "x"),
+		attribute.Int("b", 1),
+	)))
+}
+
+func TestHashAttributesOrder(t *testing.T) {
+	kvs := []attribute.KeyValue{
+		attribute.String("s", "x"),
+		attribute.Int("i", 1),
+		attribute.Float64("f", 1.5),
+		attribute.Bool("b", true),
+		attribute.StringSlice("ss", []string{"p", "q"}),
+		attribute.Int64Slice("is", []int64{1, 2}),
+		attribute.Float64Slice("fs", []float64{0.5}),
+		attribute.BoolSlice("bs", []bool{true, false}),
+	}
+	expect := HashAttributes(attribute.NewSet(kvs...))
+
+	reversed := make([]attribute.KeyValue, len(kvs))
+	for i := range kvs {
+		reversed[len(kvs)-1-i] = kvs[i]
+	}
+	require.Equal(t, expect, HashAttributes(attribute.NewSet(reversed...)))
+
+	// The last duplicate wins, as in attribute.NewSet.
+	dups := append([]attribute.KeyValue{attribute.String("s", "y")}, kvs...)
+	require.Equal(t, expect, HashAttributes(attribute.NewSet(dups...)))
+}
+
+func TestHashAttributesDistinct(t *testing.T) {
+	sets := []attribute.Set{
+		attribute.NewSet(),
+		attribute.NewSet(attribute.String("a", "")),
+		attribute.NewSet(attribute.String("a", "b")),
+		attribute.NewSet(attribute.String("ab", "")),
+		attribute.NewSet(attribute.Int("a", 0)),
+		attribute.NewSet(attribute.Bool("a", false)),
+		attribute.NewSet(attribute.Float64("a", 0)),
+		attribute.NewSet(attribute.StringSlice("a", nil)),
+		attribute.NewSet(attribute.StringSlice("a", []string{""})),
+		attribute.NewSet(attribute.String("a", "b"), attribute.String("c", "")),
+		attribute.NewSet(attribute.String("a", ""), attribute.String("bc", "")),
+	}
+	seen := map[uint64]int{}
+	for i, set := range sets {
+		h := HashAttributes(set)
+		prev, has := seen[h]
+		require.False(t, has, "sets %d and %d", prev, i)
+		seen[h] = i
+	}
+}
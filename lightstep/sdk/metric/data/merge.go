@@ -0,0 +1,192 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+
+import (
+	"fmt"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/gauge"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/minmaxsumcount"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/summary"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.uber.org/multierr"
+)
+
+var (
+	// ErrIncompatibleScope is returned when merging Scopes from
+	// different instrumentation libraries.
+	ErrIncompatibleScope = fmt.Errorf("cannot merge scopes with different libraries")
+
+	// ErrIncompatibleNumberKind is returned when merging
+	// instruments with the same name and different number kinds.
+	ErrIncompatibleNumberKind = fmt.Errorf("cannot merge instruments with different number kinds")
+
+	// ErrIncompatibleInstrument is returned when merging
+	// instruments with the same name and different descriptors.
+	ErrIncompatibleInstrument = fmt.Errorf("cannot merge instruments with different descriptors")
+
+	// ErrIncompatibleTemporality is returned when merging points
+	// with different temporality.
+	ErrIncompatibleTemporality = fmt.Errorf("cannot merge points with different temporality")
+
+	// ErrIncompatibleAggregation is returned when merging points
+	// with different aggregations.
+	ErrIncompatibleAggregation = fmt.Errorf("cannot merge points with different aggregations")
+)
+
+// mergeFunc merges one aggregation into another, returning false
+// when either is not the expected type.
+type mergeFunc func(from, to aggregation.Aggregation) bool
+
+// mergeFuncs lists one mergeFunc per aggregator storage type.
+var mergeFuncs = concat(
+	anyMergeFuncs[int64, number.Int64Traits](),
+	anyMergeFuncs[uint64, number.Uint64Traits](),
+	anyMergeFuncs[float64, number.Float64Traits](),
+	[]mergeFunc{
+		mergeWith[int64, histogram.Int64, histogram.Int64Methods],
+		mergeWith[float64, histogram.Float64, histogram.Float64Methods],
+	},
+)
+
+func anyMergeFuncs[N number.Any, Traits number.Traits[N]]() []mergeFunc {
+	return []mergeFunc{
+		mergeWith[N, sum.State[N, Traits, sum.Monotonic], sum.Methods[N, Traits, sum.Monotonic]],
+		mergeWith[N, sum.State[N, Traits, sum.NonMonotonic], sum.Methods[N, Traits, sum.NonMonotonic]],
+		mergeWith[N, gauge.State[N, Traits], gauge.Methods[N, Traits]],
+		mergeWith[N, minmaxsumcount.State[N, Traits], minmaxsumcount.Methods[N, Traits]],
+		mergeWith[N, histogram.Explicit[N, Traits], histogram.ExplicitMethods[N, Traits]],
+		mergeWith[N, summary.State[N, Traits], summary.Methods[N, Traits]],
+	}
+}
+
+func concat[T any](lists ...[]T) []T {
+	var r []T
+	for _, l := range lists {
+		r = append(r, l...)
+	}
+	return r
+}
+
+// mergeWith merges using the aggregator's Methods.Merge().
+func mergeWith[N number.Any, Storage any, Methods aggregator.Methods[N, Storage]](from, to aggregation.Aggregation) bool {
+	var methods Methods
+	fromS, ok := methods.ToStorage(from)
+	if !ok {
+		return false
+	}
+	toS, ok := methods.ToStorage(to)
+	if !ok {
+		return false
+	}
+	methods.Merge(fromS, toS)
+	return true
+}
+
+// Merge merges the instruments of other into this Scope, see
+// MergeInstruments.
+func (s *Scope) Merge(other Scope) error {
+	if s.Library != other.Library {
+		return fmt.Errorf("%w: %s and %s", ErrIncompatibleScope, s.Library.Name, other.Library.Name)
+	}
+	return MergeInstruments(&s.Instruments, other.Instruments)
+}
+
+// MergeInstruments merges the instruments in from into the
+// instruments in to, matching instruments by name and points by
+// attribute set, for example to combine the output of several
+// readers.  Matching points are merged using the aggregator's Merge
+// method, which modifies the aggregations in to; other points are
+// appended and share their aggregation with from.
+//
+// Instruments and points that are incompatible are not merged, and
+// an error is returned for each of them.
+func MergeInstruments(to *[]Instrument, from []Instrument) error {
+	var errs error
+	for _, fromInst := range from {
+		toInst := findInstrument(*to, fromInst.Descriptor.Name)
+		if toInst == nil {
+			inst := ReallocateFrom(to)
+			inst.Descriptor = fromInst.Descriptor
+			inst.Points = append(inst.Points[:0], fromInst.Points...)
+			continue
+		}
+		if toInst.Descriptor.NumberKind != fromInst.Descriptor.NumberKind {
+			errs = multierr.Append(errs, fmt.Errorf("%w: %s", ErrIncompatibleNumberKind, fromInst.Descriptor.Name))
+			continue
+		}
+		if toInst.Descriptor != fromInst.Descriptor {
+			errs = multierr.Append(errs, fmt.Errorf("%w: %s", ErrIncompatibleInstrument, fromInst.Descriptor.Name))
+			continue
+		}
+		for _, fromPt := range fromInst.Points {
+			if err := mergePoint(toInst, fromPt); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("%w: %s", err, fromInst.Descriptor.Name))
+			}
+		}
+	}
+	return errs
+}
+
+// mergePoint merges one point into the matching point of inst, or
+// appends it.
+func mergePoint(inst *Instrument, from Point) error {
+	var to *Point
+	for i := range inst.Points {
+		if inst.Points[i].Attributes.Equals(&from.Attributes) {
+			to = &inst.Points[i]
+			break
+		}
+	}
+	if to == nil {
+		inst.Points = append(inst.Points, from)
+		return nil
+	}
+	if to.Temporality != from.Temporality {
+		return ErrIncompatibleTemporality
+	}
+	if !mergeAggregation(from.Aggregation, to.Aggregation) {
+		return ErrIncompatibleAggregation
+	}
+	if from.Start.Before(to.Start) {
+		to.Start = from.Start
+	}
+	if from.End.After(to.End) {
+		to.End = from.End
+	}
+	return nil
+}
+
+func mergeAggregation(from, to aggregation.Aggregation) bool {
+	for _, merge := range mergeFuncs {
+		if merge(from, to) {
+			return true
+		}
+	}
+	return false
+}
+
+func findInstrument(insts []Instrument, name string) *Instrument {
+	for i := range insts {
+		if insts[i].Descriptor.Name == name {
+			return &insts[i]
+		}
+	}
+	return nil
+}
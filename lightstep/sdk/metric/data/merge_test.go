@@ -0,0 +1,198 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+)
+
+var (
+	mergeLib = instrumentation.Library{
+		Name: "test",
+	}
+
+	endTime   = time.Now()
+	startTime = endTime.Add(-time.Second)
+)
+
+func testDesc(name string, nk number.Kind) sdkinstrument.Descriptor {
+	return sdkinstrument.Descriptor{
+		Name:       name,
+		Kind:       sdkinstrument.SyncCounter,
+		NumberKind: nk,
+	}
+}
+
+func sumPoint(value int64, tempo aggregation.Temporality, kvs ...attribute.KeyValue) Point {
+	return Point{
+		Attributes:  attribute.NewSet(kvs...),
+		Aggregation: sum.NewMonotonicInt64(value),
+		Temporality: tempo,
+		Start:       startTime,
+		End:         endTime,
+	}
+}
+
+func TestScopeMerge(t *testing.T) {
+	const cumulative = aggregation.CumulativeTemporality
+	attrA := attribute.String("a", "1")
+	attrB := attribute.String("b", "2")
+
+	scope := Scope{
+		Library: mergeLib,
+		Instruments: []Instrument{
+			{
+				Descriptor: testDesc("counter", number.Int64Kind),
+				Points: []Point{
+					sumPoint(1, cumulative, attrA),
+				},
+			},
+		},
+	}
+	later := sumPoint(10, cumulative, attrA)
+	later.End = endTime.Add(time.Second)
+
+	err := scope.Merge(Scope{
+		Library: mergeLib,
+		Instruments: []Instrument{
+			{
+				Descriptor: testDesc("counter", number.Int64Kind),
+				Points: []Point{
+					later,
+					sumPoint(100, cumulative, attrB),
+				},
+			},
+			{
+				Descriptor: testDesc("histogram", number.Float64Kind),
+				Points: []Point{
+					{
+						Attributes:  attribute.NewSet(),
+						Aggregation: histogram.NewFloat64(histogram.NewConfig(), 1, 2),
+						Temporality: cumulative,
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(scope.Instruments))
+	require.Equal(t, "counter", scope.Instruments[0].Descriptor.Name)
+	require.Equal(t, "histogram", scope.Instruments[1].Descriptor.Name)
+
+	points := scope.Instruments[0].Points
+	require.Equal(t, 2, len(points))
+	require.Equal(t, sum.NewMonotonicInt64(11), points[0].Aggregation)
+	require.Equal(t, startTime, points[0].Start)
+	require.Equal(t, later.End, points[0].End)
+	require.Equal(t, sum.NewMonotonicInt64(100), points[1].Aggregation)
+
+	// Merge again, the histogram point is merged.
+	err = MergeInstruments(&scope.Instruments, []Instrument{
+		{
+			Descriptor: testDesc("histogram", number.Float64Kind),
+			Points: []Point{
+				{
+					Attributes:  attribute.NewSet(),
+					Aggregation: histogram.NewFloat64(histogram.NewConfig(), 3),
+					Temporality: cumulative,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), scope.Instruments[1].Points[0].Aggregation.(aggregation.Histogram).Count())
+}
+
+func TestScopeMergeErrors(t *testing.T) {
+	const (
+		cumulative = aggregation.CumulativeTemporality
+		delta      = aggregation.DeltaTemporality
+	)
+	scope := Scope{
+		Library: mergeLib,
+		Instruments: []Instrument{
+			{
+				Descriptor: testDesc("counter", number.Int64Kind),
+				Points: []Point{
+					sumPoint(1, cumulative),
+				},
+			},
+			{
+				Descriptor: testDesc("other", number.Int64Kind),
+				Points: []Point{
+					sumPoint(1, cumulative),
+				},
+			},
+		},
+	}
+
+	err := scope.Merge(Scope{
+		Library: instrumentation.Library{
+			Name: "different",
+		},
+	})
+	require.ErrorIs(t, err, ErrIncompatibleScope)
+
+	err = scope.Merge(Scope{
+		Library: mergeLib,
+		Instruments: []Instrument{
+			{
+				Descriptor: testDesc("counter", number.Int64Kind),
+				Points: []Point{
+					sumPoint(1, delta),
+				},
+			},
+			{
+				Descriptor: testDesc("other", number.Float64Kind),
+				Points: []Point{
+					sumPoint(1, cumulative),
+				},
+			},
+		},
+	})
+	require.ErrorIs(t, err, ErrIncompatibleTemporality)
+	require.ErrorIs(t, err, ErrIncompatibleNumberKind)
+	require.Contains(t, err.Error(), "counter")
+	require.Contains(t, err.Error(), "other")
+
+	// Neither point was modified.
+	require.Equal(t, sum.NewMonotonicInt64(1), scope.Instruments[0].Points[0].Aggregation)
+	require.Equal(t, sum.NewMonotonicInt64(1), scope.Instruments[1].Points[0].Aggregation)
+
+	err = MergeInstruments(&scope.Instruments, []Instrument{
+		{
+			Descriptor: testDesc("counter", number.Int64Kind),
+			Points: []Point{
+				{
+					Attributes:  attribute.NewSet(),
+					Aggregation: sum.NewNonMonotonicInt64(1),
+					Temporality: cumulative,
+				},
+			},
+		},
+	})
+	require.ErrorIs(t, err, ErrIncompatibleAggregation)
+}
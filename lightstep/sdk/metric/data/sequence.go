@@ -16,6 +16,8 @@ package data // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metr
 
 import (
 	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 )
 
 // Sequence provides the three relevant timestamps that are used by
@@ -39,7 +41,38 @@ type Collector interface {
 	// snapshots into output.
 	Collect(sequence Sequence, output *[]Instrument)
 
+	// CollectInto gathers data points from processed accumulator
+	// snapshots, passing them one at a time to the callback
+	// instead of appending them to a slice.  The Point and its
+	// Aggregation are only valid for the duration of the
+	// callback, which must copy anything it intends to keep.
+	// When the callback returns an error, collection stops and
+	// the error is returned; the remaining points are not
+	// reported by this collection.
+	// Unlike Collect, CollectInto does not apply a view's point
+	// processor.
+	CollectInto(sequence Sequence, callback func(Point) error) error
+
+	// Descriptor describes the Instrument being collected, for
+	// callers of CollectInto.
+	Descriptor() sdkinstrument.Descriptor
+
 	// Size returns the number of entries held in memory.  Size()
 	// is meant to be called following Collect().
 	Size() int
+
+	// MemorySize estimates the number of bytes held in memory
+	// by the entries counted in Size(), including attributes and
+	// aggregator storage.
+	MemorySize() int
+}
+
+// ChangedCollector is implemented by Collectors whose output includes
+// points that were not updated since the previous collection, i.e.,
+// cumulative points of synchronous instruments.
+type ChangedCollector interface {
+	// CollectChanged is Collect for the points that were
+	// updated since the previous collection of the Collector,
+	// by either method.
+	CollectChanged(sequence Sequence, output *[]Instrument)
 }
@@ -0,0 +1,68 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+
+import (
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// sortablePoints orders points by the encoding of their attributes.
+type sortablePoints struct {
+	points []Point
+	keys   []string
+}
+
+var _ sort.Interface = sortablePoints{}
+
+func (s sortablePoints) Len() int {
+	return len(s.points)
+}
+
+func (s sortablePoints) Less(i, j int) bool {
+	return s.keys[i] < s.keys[j]
+}
+
+func (s sortablePoints) Swap(i, j int) {
+	s.points[i], s.points[j] = s.points[j], s.points[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// SortPoints sorts points by the canonical encoding of their
+// attribute sets, which orders points that follow map iteration
+// deterministically, e.g., for comparing collections in tests.  The
+// sort is stable and does not modify the points.
+func SortPoints(points []Point) {
+	enc := attribute.DefaultEncoder()
+	keys := make([]string, len(points))
+	for i := range points {
+		keys[i] = points[i].Attributes.Encoded(enc)
+	}
+	sort.Stable(sortablePoints{
+		points: points,
+		keys:   keys,
+	})
+}
+
+// SortPoints sorts the points of every instrument in m, see
+// SortPoints.
+func (m *Metrics) SortPoints() {
+	for i := range m.Scopes {
+		for j := range m.Scopes[i].Instruments {
+			SortPoints(m.Scopes[i].Instruments[j].Points)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSortPoints(t *testing.T) {
+	const cumulative = aggregation.CumulativeTemporality
+
+	points := []Point{
+		sumPoint(1, cumulative, attribute.String("b", "1")),
+		sumPoint(2, cumulative, attribute.String("a", "2")),
+		sumPoint(3, cumulative),
+		sumPoint(4, cumulative, attribute.String("a", "1"), attribute.String("b", "1")),
+		sumPoint(5, cumulative, attribute.String("a", "1")),
+		sumPoint(6, cumulative, attribute.String("a", "2")),
+	}
+
+	metrics := Metrics{
+		Scopes: []Scope{
+			{
+				Instruments: []Instrument{
+					{
+						Descriptor: testDesc("counter", number.Int64Kind),
+						Points:     points,
+					},
+				},
+			},
+		},
+	}
+	metrics.SortPoints()
+
+	var sums []int64
+	for _, pt := range points {
+		sums = append(sums, number.ToInt64(pt.Aggregation.(*sum.MonotonicInt64).Sum()))
+	}
+	// Equal attribute sets keep their order.
+	require.Equal(t, []int64{3, 5, 4, 2, 6, 1}, sums)
+}
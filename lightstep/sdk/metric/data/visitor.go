@@ -0,0 +1,46 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+
+import (
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// Visitor receives the result of a single collection one point at a
+// time, instead of as a Metrics value.  This lets exporters build
+// their own representation without an intermediate copy of every
+// Point.  Calls are made in the same order as the corresponding
+// Scopes, Instruments, and Points appear in Metrics.
+type Visitor interface {
+	// VisitScope begins the instruments of a scope.  The resource
+	// is the Scope's Resource, if any, otherwise the Metrics
+	// Resource.
+	VisitScope(res *resource.Resource, library instrumentation.Library)
+
+	// VisitInstrument begins the points of an instrument in the
+	// current scope.  It is called for every instrument, even
+	// those without points.  The size is the number of points
+	// expected, a hint for preallocation.
+	VisitInstrument(desc sdkinstrument.Descriptor, size int)
+
+	// VisitPoint receives one point of the current instrument.
+	// The Point and its Aggregation are only valid for the
+	// duration of the call, which must copy anything it intends
+	// to keep.  When VisitPoint returns an error, collection
+	// stops and the error is returned.
+	VisitPoint(point Point) error
+}
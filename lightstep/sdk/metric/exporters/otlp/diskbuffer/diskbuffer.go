@@ -0,0 +1,318 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diskbuffer provides an OTLP client that keeps metrics on
+// disk while the receiving endpoint is unreachable and replays them,
+// oldest first, once it is reachable again.
+package diskbuffer // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp/diskbuffer"
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
+	"go.opentelemetry.io/otel"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// fileSuffix names complete batch files.
+	fileSuffix = ".otlp"
+
+	// tmpSuffix names batch files being written, which are
+	// renamed once complete.
+	tmpSuffix = ".tmp"
+
+	// headerSize is the size of the length and CRC-32 checksum
+	// that precede each batch.
+	headerSize = 8
+)
+
+var (
+	// ErrCorrupt is reported for batch files that are partial
+	// or fail their checksum.  These are removed.
+	ErrCorrupt = errors.New("corrupt disk buffer file")
+
+	// ErrTooLarge is returned for a batch that does not fit in
+	// the buffer by itself.  The batch is dropped.
+	ErrTooLarge = errors.New("batch is larger than the disk buffer")
+
+	// ErrRejected is reported for buffered batches that the
+	// receiver rejected permanently.  These are removed.
+	ErrRejected = errors.New("disk buffer batch rejected")
+)
+
+// Client is an otlp.Client that buffers batches on disk when the
+// wrapped client fails to upload them.  The buffer is a bounded
+// ring: the oldest batches are discarded to make room for new ones.
+// Buffered batches are replayed before each new batch, so a batch is
+// uploaded only after every batch buffered before it.
+type Client struct {
+	client   otlp.Client
+	dir      string
+	maxBytes int64
+
+	// lock serializes uploads and protects below.
+	lock    sync.Mutex
+	entries []entry
+	size    int64
+	nextSeq uint64
+}
+
+// entry is one buffered batch.
+type entry struct {
+	seq  uint64
+	size int64
+}
+
+var _ otlp.Client = (*Client)(nil)
+
+// New returns a Client that buffers up to maxBytes of batches in
+// files inside dir, which is created if it does not exist.  Batches
+// left in dir by a previous process are replayed; files that are
+// partial or corrupt are removed.
+func New(client otlp.Client, dir string, maxBytes int64) (*Client, error) {
+	if maxBytes <= headerSize {
+		return nil, fmt.Errorf("invalid disk buffer size: %d", maxBytes)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	c := &Client{
+		client:   client,
+		dir:      dir,
+		maxBytes: maxBytes,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Start starts the wrapped client.
+func (c *Client) Start(ctx context.Context) error {
+	return c.client.Start(ctx)
+}
+
+// Stop stops the wrapped client.  Buffered batches stay on disk for
+// the next process.
+func (c *Client) Stop(ctx context.Context) error {
+	return c.client.Stop(ctx)
+}
+
+// UploadMetrics replays the buffered batches, then uploads
+// protoMetrics.  When either fails, protoMetrics is buffered and the
+// upload error is returned, except that protoMetrics is not buffered
+// when the receiver rejected it permanently (see otlp.Permanent).
+func (c *Client) UploadMetrics(ctx context.Context, protoMetrics *metricpb.ResourceMetrics) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	err := c.replay(ctx)
+	if err == nil {
+		err = c.client.UploadMetrics(ctx, protoMetrics)
+	}
+	if err == nil || otlp.Permanent(err) {
+		return err
+	}
+	if spillErr := c.spill(protoMetrics); spillErr != nil {
+		return multierr.Append(err, spillErr)
+	}
+	return fmt.Errorf("buffered on disk: %w", err)
+}
+
+// load finds the batches in the directory, removing incomplete and
+// corrupt files.
+func (c *Client) load() error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := file.Name()
+
+		if strings.HasSuffix(name, tmpSuffix) {
+			c.remove(name)
+			continue
+		}
+		if file.IsDir() || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, fileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		e := entry{seq: seq}
+		if _, err := c.read(e); err != nil {
+			c.report(err)
+			c.remove(name)
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return err
+		}
+		e.size = info.Size()
+		c.entries = append(c.entries, e)
+		c.size += e.size
+	}
+
+	sort.Slice(c.entries, func(i, j int) bool {
+		return c.entries[i].seq < c.entries[j].seq
+	})
+	if len(c.entries) != 0 {
+		c.nextSeq = c.entries[len(c.entries)-1].seq + 1
+	}
+
+	// The size may have been reduced since the files were written.
+	c.evict(0)
+	return nil
+}
+
+// replay uploads the buffered batches, oldest first, removing each
+// one after it is uploaded.  Batches that the receiver rejects
+// permanently (see otlp.Permanent) are removed and reported, so that
+// they do not block the batches buffered after them.
+func (c *Client) replay(ctx context.Context) error {
+	for len(c.entries) != 0 {
+		e := c.entries[0]
+
+		rm, err := c.read(e)
+		if err == nil {
+			err = c.client.UploadMetrics(ctx, rm)
+			if err != nil && !otlp.Permanent(err) {
+				return err
+			}
+			if err != nil {
+				err = fmt.Errorf("%w: %s: %v", ErrRejected, c.path(e), err)
+			}
+		}
+		if err != nil {
+			c.report(err)
+		}
+		c.dropOldest()
+	}
+	return nil
+}
+
+// spill writes a batch to a new file, first discarding the oldest
+// batches as needed to stay within the size limit.
+func (c *Client) spill(rm *metricpb.ResourceMetrics) error {
+	data, err := proto.Marshal(rm)
+	if err != nil {
+		return err
+	}
+	size := int64(headerSize + len(data))
+	if size > c.maxBytes {
+		return ErrTooLarge
+	}
+	c.evict(size)
+
+	buf := make([]byte, size)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(data))
+	copy(buf[headerSize:], data)
+
+	e := entry{seq: c.nextSeq, size: size}
+	name := c.path(e)
+
+	// Write then rename, so that complete files are never
+	// partial.
+	if err := os.WriteFile(name+tmpSuffix, buf, 0o600); err != nil {
+		_ = os.Remove(name + tmpSuffix)
+		return err
+	}
+	if err := os.Rename(name+tmpSuffix, name); err != nil {
+		_ = os.Remove(name + tmpSuffix)
+		return err
+	}
+	c.nextSeq++
+	c.entries = append(c.entries, e)
+	c.size += size
+	return nil
+}
+
+// evict discards the oldest batches until there is room for size
+// more bytes.
+func (c *Client) evict(size int64) {
+	for len(c.entries) != 0 && c.size+size > c.maxBytes {
+		c.dropOldest()
+	}
+}
+
+// dropOldest removes the oldest batch.
+func (c *Client) dropOldest() {
+	e := c.entries[0]
+	c.remove(filepath.Base(c.path(e)))
+	c.entries = c.entries[1:]
+	c.size -= e.size
+}
+
+// read reads and checks a batch file.
+func (c *Client) read(e entry) (*metricpb.ResourceMetrics, error) {
+	name := c.path(e)
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < headerSize {
+		return nil, fmt.Errorf("%w: %s: partial header", ErrCorrupt, name)
+	}
+	length := binary.BigEndian.Uint32(buf[0:4])
+	data := buf[headerSize:]
+	if uint64(len(data)) != uint64(length) {
+		return nil, fmt.Errorf("%w: %s: have %d bytes, expected %d", ErrCorrupt, name, len(data), length)
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(buf[4:8]) {
+		return nil, fmt.Errorf("%w: %s: checksum mismatch", ErrCorrupt, name)
+	}
+	rm := &metricpb.ResourceMetrics{}
+	if err := proto.Unmarshal(data, rm); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorrupt, name, err)
+	}
+	return rm, nil
+}
+
+// path returns the file name of a batch, in which the sequence number
+// is zero-padded so that names sort in sequence order.
+func (c *Client) path(e entry) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%020d%s", e.seq, fileSuffix))
+}
+
+// remove removes a file from the directory, reporting errors.
+func (c *Client) remove(name string) {
+	if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !os.IsNotExist(err) {
+		c.report(err)
+	}
+}
+
+// report passes an error to the OTel error handler, rate-limited.
+func (c *Client) report(err error) {
+	doevery.TimePeriod(30*time.Second, func() {
+		otel.Handle(fmt.Errorf("disk buffer: %w", err))
+	})
+}
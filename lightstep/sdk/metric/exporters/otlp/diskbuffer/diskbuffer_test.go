@@ -0,0 +1,223 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskbuffer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp"
+	"github.com/stretchr/testify/require"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+// testClient records the uploaded batches by schema URL.
+type testClient struct {
+	fail     bool
+	reject   map[string]error
+	uploaded []string
+}
+
+func (*testClient) Start(context.Context) error { return nil }
+func (*testClient) Stop(context.Context) error  { return nil }
+
+func (tc *testClient) UploadMetrics(_ context.Context, rm *metricpb.ResourceMetrics) error {
+	if tc.fail {
+		return errUnavailable
+	}
+	if err := tc.reject[rm.SchemaUrl]; err != nil {
+		return err
+	}
+	tc.uploaded = append(tc.uploaded, rm.SchemaUrl)
+	return nil
+}
+
+func batch(i int) *metricpb.ResourceMetrics {
+	return &metricpb.ResourceMetrics{SchemaUrl: fmt.Sprint("batch", i)}
+}
+
+func batchNames(from, to int) []string {
+	var names []string
+	for i := from; i <= to; i++ {
+		names = append(names, fmt.Sprint("batch", i))
+	}
+	return names
+}
+
+func upload(c *Client, i int) error {
+	return c.UploadMetrics(context.Background(), batch(i))
+}
+
+func TestReplayOldestFirst(t *testing.T) {
+	ctx := context.Background()
+	tc := &testClient{}
+	c, err := New(tc, t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(ctx))
+
+	require.NoError(t, upload(c, 1))
+
+	tc.fail = true
+	for i := 2; i <= 4; i++ {
+		require.ErrorIs(t, upload(c, i), errUnavailable)
+	}
+	require.Equal(t, 3, len(c.entries))
+
+	tc.fail = false
+	require.NoError(t, upload(c, 5))
+	require.Equal(t, batchNames(1, 5), tc.uploaded)
+	require.Equal(t, 0, len(c.entries))
+	require.Equal(t, int64(0), c.size)
+
+	files, err := os.ReadDir(c.dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	require.NoError(t, c.Stop(ctx))
+}
+
+// TestReplayRejected tests that batches rejected permanently are
+// dropped instead of blocking the batches after them.
+func TestReplayRejected(t *testing.T) {
+	tc := &testClient{}
+	c, err := New(tc, t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	tc.fail = true
+	for i := 1; i <= 3; i++ {
+		require.ErrorIs(t, upload(c, i), errUnavailable)
+	}
+
+	tc.fail = false
+	tc.reject = map[string]error{
+		"batch2": status.Error(codes.InvalidArgument, "invalid"),
+		"batch5": &otlp.HTTPStatusError{StatusCode: 400, Status: "400 Bad Request"},
+	}
+	require.NoError(t, upload(c, 4))
+	require.Equal(t, []string{"batch1", "batch3", "batch4"}, tc.uploaded)
+
+	// A rejected new batch is not buffered.
+	require.Error(t, upload(c, 5))
+	require.Equal(t, 0, len(c.entries))
+
+	// Retryable statuses are buffered.
+	tc.reject["batch6"] = &otlp.HTTPStatusError{StatusCode: 429, Status: "429 Too Many Requests"}
+	require.Error(t, upload(c, 6))
+	require.Equal(t, 1, len(c.entries))
+}
+
+func TestBounded(t *testing.T) {
+	tc := &testClient{fail: true}
+
+	// Room for three batches.
+	data, err := proto.Marshal(batch(10))
+	require.NoError(t, err)
+	size := int64(headerSize + len(data))
+	c, err := New(tc, t.TempDir(), 3*size)
+	require.NoError(t, err)
+
+	for i := 1; i <= 10; i++ {
+		require.ErrorIs(t, upload(c, i), errUnavailable)
+		require.LessOrEqual(t, c.size, c.maxBytes)
+	}
+
+	tc.fail = false
+	require.NoError(t, upload(c, 11))
+	require.Equal(t, batchNames(8, 11), tc.uploaded)
+}
+
+func TestTooLarge(t *testing.T) {
+	tc := &testClient{fail: true}
+	c, err := New(tc, t.TempDir(), headerSize+1)
+	require.NoError(t, err)
+
+	err = upload(c, 1)
+	require.ErrorIs(t, err, errUnavailable)
+	require.ErrorIs(t, err, ErrTooLarge)
+	require.Equal(t, 0, len(c.entries))
+}
+
+func TestRestart(t *testing.T) {
+	dir := t.TempDir()
+	tc := &testClient{fail: true}
+	c, err := New(tc, dir, 1<<20)
+	require.NoError(t, err)
+
+	for i := 1; i <= 4; i++ {
+		require.ErrorIs(t, upload(c, i), errUnavailable)
+	}
+
+	// Truncate the newest file, corrupt the second, and leave
+	// behind an incomplete write and an unrelated file.
+	newest := c.path(c.entries[3])
+	buf, err := os.ReadFile(newest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(newest, buf[:len(buf)-1], 0o600))
+
+	second := c.path(c.entries[1])
+	buf, err = os.ReadFile(second)
+	require.NoError(t, err)
+	buf[len(buf)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(second, buf, 0o600))
+
+	require.NoError(t, os.WriteFile(c.path(entry{seq: 4})+tmpSuffix, []byte{1, 2, 3}, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), nil, 0o600))
+
+	tc = &testClient{}
+	c, err = New(tc, dir, 1<<20)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(c.entries))
+	require.Equal(t, uint64(3), c.nextSeq)
+
+	require.NoError(t, upload(c, 5))
+	require.Equal(t, []string{"batch1", "batch3", "batch5"}, tc.uploaded)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files))
+	require.Equal(t, "README", files[0].Name())
+}
+
+func TestRestartSmaller(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(&testClient{fail: true}, dir, 1<<20)
+	require.NoError(t, err)
+
+	for i := 1; i <= 4; i++ {
+		require.ErrorIs(t, upload(c, i), errUnavailable)
+	}
+
+	// The oldest batches are discarded to fit the new limit.
+	tc := &testClient{}
+	c, err = New(tc, dir, 2*c.entries[0].size)
+	require.NoError(t, err)
+
+	require.NoError(t, upload(c, 5))
+	require.Equal(t, batchNames(3, 5), tc.uploaded)
+}
+
+func TestInvalidSize(t *testing.T) {
+	_, err := New(&testClient{}, t.TempDir(), 0)
+	require.Error(t, err)
+}
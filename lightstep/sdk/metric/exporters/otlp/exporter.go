@@ -23,6 +23,7 @@ import (
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp/internal/metrictransform"
+	"go.uber.org/multierr"
 )
 
 var (
@@ -40,17 +41,39 @@ type Exporter struct {
 	stopOnce  sync.Once
 }
 
-// ExportMetrics exports a batch of metrics.
+// ExportMetrics exports a batch of metrics.  Scopes with a distinct
+// Resource are uploaded separately, one ResourceMetrics per Resource.
 func (e *Exporter) ExportMetrics(ctx context.Context, metrics data.Metrics) error {
-	rm, err := metrictransform.Metrics(metrics)
-	if err != nil {
-		return err
-	}
-	if rm == nil {
-		return nil
+	var errs error
+	for _, group := range metrics.ByResource() {
+		rm, err := metrictransform.Metrics(group)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		if rm == nil {
+			continue
+		}
+		errs = multierr.Append(errs, e.client.UploadMetrics(ctx, rm))
 	}
+	return errs
+}
 
-	return e.client.UploadMetrics(ctx, rm)
+// ExportStream collects from the producer directly into OTLP, without
+// an intermediate data.Metrics, and uploads one ResourceMetrics per
+// Resource.  Data collected before a collection error is uploaded.
+func (e *Exporter) ExportStream(ctx context.Context, producer metric.StreamProducer) error {
+	var builder metrictransform.Builder
+
+	errs := producer.ProduceStream(ctx, &builder)
+
+	rms, err := builder.ResourceMetrics()
+	errs = multierr.Append(errs, err)
+
+	for _, rm := range rms {
+		errs = multierr.Append(errs, e.client.UploadMetrics(ctx, rm))
+	}
+	return errs
 }
 
 // Start establishes a connection to the receiving endpoint.
@@ -102,7 +125,7 @@ func (e *Exporter) ShutdownMetrics(ctx context.Context, metrics data.Metrics) er
 	return err
 }
 
-var _ metric.PushExporter = (*Exporter)(nil)
+var _ metric.StreamExporter = (*Exporter)(nil)
 
 // New constructs a new Exporter and starts it.
 func New(ctx context.Context, client Client, opts ...Option) (*Exporter, error) {
@@ -0,0 +1,89 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// discardClient is a Client that drops uploads.
+type discardClient struct{}
+
+func (discardClient) Start(context.Context) error { return nil }
+func (discardClient) Stop(context.Context) error  { return nil }
+
+func (discardClient) UploadMetrics(context.Context, *metricpb.ResourceMetrics) error {
+	return nil
+}
+
+// largeScope returns a reader for one meter with 100 counters of 100
+// attribute sets each.
+func largeScope(b *testing.B) *metric.ManualReader {
+	ctx := context.Background()
+	rdr := metric.NewManualReader("bench")
+	provider := metric.NewMeterProvider(metric.WithResource(resource.Empty()), metric.WithReader(rdr))
+	meter := provider.Meter("bench")
+
+	for i := 0; i < 100; i++ {
+		ctr, err := meter.SyncInt64().Counter(fmt.Sprint("counter", i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for j := 0; j < 100; j++ {
+			ctr.Add(ctx, 1, attribute.Int("j", j))
+		}
+	}
+	return rdr
+}
+
+func BenchmarkExportMetrics(b *testing.B) {
+	ctx := context.Background()
+	rdr := largeScope(b)
+	exp := NewUnstarted(discardClient{})
+
+	var output data.Metrics
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		output = rdr.Produce(&output)
+		if err := exp.ExportMetrics(ctx, output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExportStream(b *testing.B) {
+	ctx := context.Background()
+	rdr := largeScope(b)
+	exp := NewUnstarted(discardClient{})
+	producer := rdr.Producer.(metric.StreamProducer)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := exp.ExportStream(ctx, producer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
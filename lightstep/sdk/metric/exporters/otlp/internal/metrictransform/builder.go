@@ -0,0 +1,111 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrictransform // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp/internal/metrictransform"
+
+import (
+	"fmt"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"go.uber.org/multierr"
+)
+
+// Builder is a data.Visitor that transforms a streamed collection
+// directly into OTLP, one ResourceMetrics per distinct Resource as
+// for data.Metrics.ByResource.  The zero value is ready to use.
+type Builder struct {
+	resources []*metricspb.ResourceMetrics
+	index     map[resourceKey]*metricspb.ResourceMetrics
+
+	scope  *metricspb.ScopeMetrics
+	desc   sdkinstrument.Descriptor
+	size   int
+	metric *metricspb.Metric
+	skip   bool
+	err    error
+}
+
+// resourceKey identifies a distinct Resource.
+type resourceKey struct {
+	attrs  attribute.Distinct
+	schema string
+}
+
+var _ data.Visitor = &Builder{}
+
+// VisitScope implements data.Visitor.
+func (b *Builder) VisitScope(res *resource.Resource, library instrumentation.Library) {
+	key := resourceKey{
+		attrs:  res.Equivalent(),
+		schema: res.SchemaURL(),
+	}
+	rm, ok := b.index[key]
+	if !ok {
+		rm = &metricspb.ResourceMetrics{
+			Resource:  Resource(res),
+			SchemaUrl: res.SchemaURL(),
+		}
+		if b.index == nil {
+			b.index = map[resourceKey]*metricspb.ResourceMetrics{}
+		}
+		b.index[key] = rm
+		b.resources = append(b.resources, rm)
+	}
+	b.scope = &metricspb.ScopeMetrics{
+		Scope:     Library(library),
+		SchemaUrl: library.SchemaURL,
+	}
+	rm.ScopeMetrics = append(rm.ScopeMetrics, b.scope)
+}
+
+// VisitInstrument implements data.Visitor.
+func (b *Builder) VisitInstrument(desc sdkinstrument.Descriptor, size int) {
+	b.desc = desc
+	b.size = size
+	b.metric = nil
+	b.skip = false
+}
+
+// VisitPoint implements data.Visitor.  Instruments that cannot be
+// transformed are skipped, their error is returned by
+// ResourceMetrics, and collection continues.
+func (b *Builder) VisitPoint(pt data.Point) error {
+	if b.skip {
+		return nil
+	}
+	if b.metric == nil {
+		mm, err := newMetric(&b.desc, pt, b.size)
+		if err != nil {
+			b.err = multierr.Append(b.err, fmt.Errorf("%s: %w", b.desc.Name, err))
+			b.skip = true
+			return nil
+		}
+		b.metric = mm
+		b.scope.Metrics = append(b.scope.Metrics, mm)
+	}
+	appendPoint(b.metric, &b.desc, pt)
+	return nil
+}
+
+// ResourceMetrics returns the transformed collection, ordered by the
+// first appearance of each Resource, and any errors from instruments
+// that were skipped.
+func (b *Builder) ResourceMetrics() ([]*metricspb.ResourceMetrics, error) {
+	return b.resources, b.err
+}
@@ -0,0 +1,138 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrictransform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp/internal/otlptest"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/test"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// visitMetrics passes metrics to the visitor, as a
+// metric.StreamProducer would.
+func visitMetrics(visitor data.Visitor, metrics data.Metrics) {
+	for _, scope := range metrics.Scopes {
+		res := scope.Resource
+		if res == nil {
+			res = metrics.Resource
+		}
+		visitor.VisitScope(res, scope.Library)
+
+		for _, inst := range scope.Instruments {
+			visitor.VisitInstrument(inst.Descriptor, len(inst.Points))
+
+			for _, pt := range inst.Points {
+				_ = visitor.VisitPoint(pt)
+			}
+		}
+	}
+}
+
+// unknownAgg is an Aggregation that cannot be transformed.
+type unknownAgg struct{}
+
+func (unknownAgg) Kind() aggregation.Kind {
+	return aggregation.FirstCustomKind
+}
+
+func TestBuilderByResource(t *testing.T) {
+	input := test.Metrics(
+		testResource0,
+		test.Scope(
+			testScope0,
+			test.Instrument(
+				testInt64(),
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(1), testCumulative, testAttrs0...),
+			),
+		),
+		test.Scope(
+			testScope1,
+			test.Instrument(
+				testInt64(),
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(2), testCumulative, testAttrs0...),
+			),
+		),
+		test.Scope(
+			testScope0,
+			test.Instrument(
+				testInt64(),
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(3), testCumulative, testAttrs0...),
+			),
+		),
+	)
+	input.Scopes[1].Resource = testResource1
+
+	var builder Builder
+	visitMetrics(&builder, input)
+	streamed, err := builder.ResourceMetrics()
+	require.NoError(t, err)
+
+	// Resources are grouped in order of first appearance.
+	groups := input.ByResource()
+	require.Equal(t, len(groups), len(streamed))
+
+	for i, group := range groups {
+		expect, err := Metrics(group)
+		require.NoError(t, err)
+		require.Equal(t, "", cmp.Diff(streamed[i], expect, protocmp.Transform()))
+	}
+}
+
+func TestBuilderUnimplemented(t *testing.T) {
+	input := test.Metrics(
+		testResource0,
+		test.Scope(
+			testScope0,
+			test.Instrument(
+				testInt64(),
+				test.Point(startTime, endTime, unknownAgg{}, testCumulative, testAttrs0...),
+			),
+			test.Instrument(
+				testFloat64(),
+				test.Point(startTime, endTime, sum.NewMonotonicFloat64(2), testCumulative, testAttrs0...),
+			),
+		),
+	)
+
+	var builder Builder
+	visitMetrics(&builder, input)
+	streamed, err := builder.ResourceMetrics()
+	require.True(t, errors.Is(err, ErrUnimplementedAgg))
+
+	// The other instrument is transformed.
+	require.Equal(t, "", cmp.Diff(streamed[0], otlptest.ResourceMetrics(
+		expectResource0,
+		testSchema,
+		otlptest.ScopeMetrics(
+			expectScope0,
+			otlptest.Sum(
+				testName,
+				testDesc,
+				testUnit,
+				expectCumulative,
+				true, // monotonic
+				otlptest.Float64DataPoint(expectAttrs0, startTime, endTime, 2),
+			),
+		),
+	), protocmp.Transform()))
+}
@@ -18,6 +18,7 @@ package metrictransform // import "github.com/lightstep/otel-launcher-go/lightst
 
 import (
 	"errors"
+	"math"
 	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
@@ -25,6 +26,7 @@ import (
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 var (
@@ -60,56 +62,12 @@ func Metrics(metrics data.Metrics) (*metricspb.ResourceMetrics, error) {
 			if len(inst.Points) == 0 {
 				continue
 			}
-			mm := &metricspb.Metric{
-				Name:        inst.Descriptor.Name,
-				Unit:        string(inst.Descriptor.Unit),
-				Description: inst.Descriptor.Description,
+			mm, err := newMetric(&inst.Descriptor, inst.Points[0], len(inst.Points))
+			if err != nil {
+				return nil, err
 			}
-			point0 := inst.Points[0]
-			switch point0.Aggregation.Kind() {
-			case aggregation.MonotonicSumKind:
-				mm.Data = &metricspb.Metric_Sum{
-					Sum: &metricspb.Sum{
-						AggregationTemporality: Temporality(point0.Temporality),
-						IsMonotonic:            true,
-						DataPoints:             NumberPoints(&inst.Descriptor, inst.Points, sumToValue),
-					},
-				}
-			case aggregation.NonMonotonicSumKind:
-				mm.Data = &metricspb.Metric_Sum{
-					Sum: &metricspb.Sum{
-						AggregationTemporality: Temporality(point0.Temporality),
-						IsMonotonic:            false,
-						DataPoints:             NumberPoints(&inst.Descriptor, inst.Points, sumToValue),
-					},
-				}
-			case aggregation.HistogramKind:
-				mm.Data = &metricspb.Metric_ExponentialHistogram{
-					ExponentialHistogram: &metricspb.ExponentialHistogram{
-						AggregationTemporality: Temporality(point0.Temporality),
-						DataPoints:             HistogramPoints(&inst.Descriptor, inst.Points),
-					},
-				}
-			case aggregation.GaugeKind:
-				pts := NumberPoints(&inst.Descriptor, inst.Points, gaugeToValue)
-				// Note: Gauge start time documented as optional.  Leave it off.
-				for _, np := range pts {
-					np.StartTimeUnixNano = 0
-				}
-				mm.Data = &metricspb.Metric_Gauge{
-					Gauge: &metricspb.Gauge{
-						DataPoints: pts,
-					},
-				}
-			case aggregation.MinMaxSumCountKind:
-				mm.Data = &metricspb.Metric_Histogram{
-					Histogram: &metricspb.Histogram{
-						AggregationTemporality: Temporality(point0.Temporality),
-						DataPoints:             MinMaxSumCountPoints(&inst.Descriptor, inst.Points, point0.Temporality),
-					},
-				}
-			default:
-				return nil, ErrUnimplementedAgg
+			for _, pt := range inst.Points {
+				appendPoint(mm, &inst.Descriptor, pt)
 			}
 			sc.Metrics = append(sc.Metrics, mm)
 		}
@@ -119,6 +77,82 @@ func Metrics(metrics data.Metrics) (*metricspb.ResourceMetrics, error) {
 
 }
 
+// newMetric returns an OTLP Metric for the instrument, with data of
+// the kind determined by its first point and capacity for size
+// points.
+func newMetric(desc *sdkinstrument.Descriptor, point0 data.Point, size int) (*metricspb.Metric, error) {
+	mm := &metricspb.Metric{
+		Name:        desc.Name,
+		Unit:        string(desc.Unit),
+		Description: desc.Description,
+	}
+	tempo := Temporality(point0.Temporality)
+
+	switch kind := point0.Aggregation.Kind(); kind {
+	case aggregation.MonotonicSumKind, aggregation.NonMonotonicSumKind:
+		mm.Data = &metricspb.Metric_Sum{
+			Sum: &metricspb.Sum{
+				AggregationTemporality: tempo,
+				IsMonotonic:            kind == aggregation.MonotonicSumKind,
+				DataPoints:             make([]*metricspb.NumberDataPoint, 0, size),
+			},
+		}
+	case aggregation.HistogramKind:
+		mm.Data = &metricspb.Metric_ExponentialHistogram{
+			ExponentialHistogram: &metricspb.ExponentialHistogram{
+				AggregationTemporality: tempo,
+				DataPoints:             make([]*metricspb.ExponentialHistogramDataPoint, 0, size),
+			},
+		}
+	case aggregation.GaugeKind, aggregation.RateKind:
+		mm.Data = &metricspb.Metric_Gauge{
+			Gauge: &metricspb.Gauge{
+				DataPoints: make([]*metricspb.NumberDataPoint, 0, size),
+			},
+		}
+	case aggregation.ExplicitHistogramKind, aggregation.MinMaxSumCountKind:
+		mm.Data = &metricspb.Metric_Histogram{
+			Histogram: &metricspb.Histogram{
+				AggregationTemporality: tempo,
+				DataPoints:             make([]*metricspb.HistogramDataPoint, 0, size),
+			},
+		}
+	case aggregation.SummaryKind:
+		mm.Data = &metricspb.Metric_Summary{
+			Summary: &metricspb.Summary{
+				DataPoints: make([]*metricspb.SummaryDataPoint, 0, size),
+			},
+		}
+	default:
+		return nil, ErrUnimplementedAgg
+	}
+	return mm, nil
+}
+
+// appendPoint transforms one point of the instrument and appends it
+// to the data of mm, which was returned by newMetric.
+func appendPoint(mm *metricspb.Metric, desc *sdkinstrument.Descriptor, pt data.Point) {
+	switch md := mm.Data.(type) {
+	case *metricspb.Metric_Sum:
+		md.Sum.DataPoints = append(md.Sum.DataPoints, NumberPoint(desc, pt, sumToValue))
+	case *metricspb.Metric_ExponentialHistogram:
+		md.ExponentialHistogram.DataPoints = append(md.ExponentialHistogram.DataPoints, HistogramPoint(desc, pt))
+	case *metricspb.Metric_Gauge:
+		np := NumberPoint(desc, pt, gaugeToValue)
+		// Note: Gauge start time documented as optional.  Leave it off.
+		np.StartTimeUnixNano = 0
+		md.Gauge.DataPoints = append(md.Gauge.DataPoints, np)
+	case *metricspb.Metric_Histogram:
+		if pt.Aggregation.Kind() == aggregation.MinMaxSumCountKind {
+			md.Histogram.DataPoints = append(md.Histogram.DataPoints, MinMaxSumCountPoint(desc, pt))
+		} else {
+			md.Histogram.DataPoints = append(md.Histogram.DataPoints, ExplicitHistogramPoint(desc, pt))
+		}
+	case *metricspb.Metric_Summary:
+		md.Summary.DataPoints = append(md.Summary.DataPoints, SummaryPoint(desc, pt))
+	}
+}
+
 func sumToValue(pt data.Point) number.Number {
 	return pt.Aggregation.(aggregation.Sum).Sum()
 }
@@ -127,56 +161,107 @@ func gaugeToValue(pt data.Point) number.Number {
 	return pt.Aggregation.(aggregation.Gauge).Gauge()
 }
 
-func NumberPoints(desc *sdkinstrument.Descriptor, points []data.Point, p2v func(data.Point) number.Number) []*metricspb.NumberDataPoint {
-	results := make([]*metricspb.NumberDataPoint, len(points))
-	for i, pt := range points {
-		results[i] = &metricspb.NumberDataPoint{
-			Attributes:        Attributes(pt.Attributes),
-			StartTimeUnixNano: toNanos(pt.Start),
-			TimeUnixNano:      toNanos(pt.End),
+// NumberPoint transforms a sum or gauge point.
+func NumberPoint(desc *sdkinstrument.Descriptor, pt data.Point, p2v func(data.Point) number.Number) *metricspb.NumberDataPoint {
+	result := &metricspb.NumberDataPoint{
+		Attributes:        Attributes(pt.Attributes),
+		StartTimeUnixNano: toNanos(pt.Start),
+		TimeUnixNano:      toNanos(pt.End),
+	}
+	value := p2v(pt)
+	switch desc.NumberKind {
+	case number.Float64Kind:
+		result.Value = &metricspb.NumberDataPoint_AsDouble{
+			AsDouble: number.ToFloat64(value),
 		}
-		value := p2v(pt)
-		if desc.NumberKind == number.Float64Kind {
-			results[i].Value = &metricspb.NumberDataPoint_AsDouble{
-				AsDouble: number.ToFloat64(value),
-			}
-		} else {
-			results[i].Value = &metricspb.NumberDataPoint_AsInt{
-				AsInt: number.ToInt64(value),
-			}
+	case number.Uint64Kind:
+		result.Value = &metricspb.NumberDataPoint_AsInt{
+			AsInt: saturatingInt64(number.ToUint64(value)),
+		}
+	default:
+		result.Value = &metricspb.NumberDataPoint_AsInt{
+			AsInt: number.ToInt64(value),
 		}
 	}
-	return results
+	return result
 }
 
-func HistogramPoints(desc *sdkinstrument.Descriptor, points []data.Point) []*metricspb.ExponentialHistogramDataPoint {
-	results := make([]*metricspb.ExponentialHistogramDataPoint, len(points))
-	for i, pt := range points {
-		hist := pt.Aggregation.(aggregation.Histogram)
-		// Note: We assume that inputs are non-negative by the
-		// OTel API contract; If inputs are negative, we're
-		// supposed to drop the sum.
-		sum := hist.Sum().CoerceToFloat64(desc.NumberKind)
+// HistogramPoint transforms an exponential histogram point.
+func HistogramPoint(desc *sdkinstrument.Descriptor, pt data.Point) *metricspb.ExponentialHistogramDataPoint {
+	hist := pt.Aggregation.(aggregation.Histogram)
+	// Note: We assume that inputs are non-negative by the
+	// OTel API contract; If inputs are negative, we're
+	// supposed to drop the sum.
+	sum := hist.Sum().CoerceToFloat64(desc.NumberKind)
 
-		var minp, maxp *float64
+	var minp, maxp *float64
 
-		if hist.Count() != 0 {
-			minp = float64Ptr(hist.Min().CoerceToFloat64(desc.NumberKind))
-			maxp = float64Ptr(hist.Max().CoerceToFloat64(desc.NumberKind))
-		}
+	if hist.Count() != 0 {
+		minp = float64Ptr(hist.Min().CoerceToFloat64(desc.NumberKind))
+		maxp = float64Ptr(hist.Max().CoerceToFloat64(desc.NumberKind))
+	}
+
+	result := &metricspb.ExponentialHistogramDataPoint{
+		Attributes:        Attributes(pt.Attributes),
+		StartTimeUnixNano: toNanos(pt.Start),
+		TimeUnixNano:      toNanos(pt.End),
+		Count:             hist.Count(),
+		Sum:               &sum,
+		ZeroCount:         hist.ZeroCount(),
+		Scale:             hist.Scale(),
+		Min:               minp,
+		Max:               maxp,
+		Positive:          HistogramBuckets(hist.Positive()),
+		Negative:          HistogramBuckets(hist.Negative()),
+	}
+
+	if ex, ok := pt.Aggregation.(aggregation.HasExemplars); ok {
+		result.Exemplars = Exemplars(desc, ex.Exemplars())
+	}
+	if zt, ok := pt.Aggregation.(aggregation.HasZeroThreshold); ok && zt.ZeroThreshold() != 0 {
+		setZeroThreshold(result, zt.ZeroThreshold())
+	}
+	return result
+}
+
+// zeroThresholdField is the number of the zero_threshold field of
+// ExponentialHistogramDataPoint, which is newer than the generated
+// code in use.
+const zeroThresholdField protowire.Number = 14
 
-		results[i] = &metricspb.ExponentialHistogramDataPoint{
-			Attributes:        Attributes(pt.Attributes),
-			StartTimeUnixNano: toNanos(pt.Start),
-			TimeUnixNano:      toNanos(pt.End),
-			Count:             hist.Count(),
-			Sum:               &sum,
-			ZeroCount:         hist.ZeroCount(),
-			Scale:             hist.Scale(),
-			Min:               minp,
-			Max:               maxp,
-			Positive:          HistogramBuckets(hist.Positive()),
-			Negative:          HistogramBuckets(hist.Negative()),
+// setZeroThreshold sets the zero_threshold field of an exponential
+// histogram point, encoded as an unknown field so that it is
+// marshaled along with the known fields.
+func setZeroThreshold(pt *metricspb.ExponentialHistogramDataPoint, threshold float64) {
+	var b []byte
+	b = protowire.AppendTag(b, zeroThresholdField, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(threshold))
+	pt.ProtoReflect().SetUnknown(b)
+}
+
+// Exemplars transforms sampled exemplars into OTLP exemplars.
+func Exemplars(desc *sdkinstrument.Descriptor, exemplars []aggregation.Exemplar) []*metricspb.Exemplar {
+	if len(exemplars) == 0 {
+		return nil
+	}
+	results := make([]*metricspb.Exemplar, len(exemplars))
+	for i, ex := range exemplars {
+		traceID := ex.SpanContext.TraceID()
+		spanID := ex.SpanContext.SpanID()
+
+		results[i] = &metricspb.Exemplar{
+			TimeUnixNano: toNanos(ex.Time),
+			TraceId:      traceID[:],
+			SpanId:       spanID[:],
+		}
+		if desc.NumberKind == number.Float64Kind {
+			results[i].Value = &metricspb.Exemplar_AsDouble{
+				AsDouble: number.ToFloat64(ex.Value),
+			}
+		} else {
+			results[i].Value = &metricspb.Exemplar_AsInt{
+				AsInt: number.ToInt64(ex.Value),
+			}
 		}
 	}
 	return results
@@ -200,32 +285,84 @@ func float64Ptr(x float64) *float64 {
 	return &x
 }
 
-func MinMaxSumCountPoints(desc *sdkinstrument.Descriptor, points []data.Point, tempo aggregation.Temporality) []*metricspb.HistogramDataPoint {
-	results := make([]*metricspb.HistogramDataPoint, len(points))
-	for i, pt := range points {
-		mmsc := pt.Aggregation.(aggregation.MinMaxSumCount)
+// saturatingInt64 converts uint64 values for OTLP, which has no
+// unsigned integer point, limiting them to math.MaxInt64.
+func saturatingInt64(x uint64) int64 {
+	if x > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(x)
+}
 
-		// See note about optional sum at top of minmaxsumcount.go
-		sum := mmsc.Sum().CoerceToFloat64(desc.NumberKind)
+// ExplicitHistogramPoint transforms an explicit histogram point.
+func ExplicitHistogramPoint(desc *sdkinstrument.Descriptor, pt data.Point) *metricspb.HistogramDataPoint {
+	hist := pt.Aggregation.(aggregation.ExplicitHistogram)
 
-		var min, max *float64
+	// See the note about non-negative inputs in HistogramPoint.
+	sum := hist.Sum().CoerceToFloat64(desc.NumberKind)
 
-		if mmsc.Count() != 0 {
-			min = float64Ptr(mmsc.Min().CoerceToFloat64(desc.NumberKind))
-			max = float64Ptr(mmsc.Max().CoerceToFloat64(desc.NumberKind))
-		}
-		pt := &metricspb.HistogramDataPoint{
-			Attributes:        Attributes(pt.Attributes),
-			StartTimeUnixNano: toNanos(pt.Start),
-			TimeUnixNano:      toNanos(pt.End),
-			Count:             mmsc.Count(),
-			Sum:               &sum,
-		}
-		if tempo == aggregation.DeltaTemporality {
-			pt.Min = min
-			pt.Max = max
-		}
-		results[i] = pt
+	result := &metricspb.HistogramDataPoint{
+		Attributes:        Attributes(pt.Attributes),
+		StartTimeUnixNano: toNanos(pt.Start),
+		TimeUnixNano:      toNanos(pt.End),
+		Count:             hist.Count(),
+		Sum:               &sum,
+		BucketCounts:      hist.BucketCounts(),
+		ExplicitBounds:    hist.Boundaries(),
 	}
-	return results
+	if mm, ok := pt.Aggregation.(aggregation.OptionalMinMax); ok && mm.HasMinMax() {
+		result.Min = float64Ptr(mm.Min().CoerceToFloat64(desc.NumberKind))
+		result.Max = float64Ptr(mm.Max().CoerceToFloat64(desc.NumberKind))
+	}
+	return result
+}
+
+// SummaryPoint transforms a summary point.  OTLP summaries have no
+// temporality, the start time distinguishes delta from cumulative.
+func SummaryPoint(desc *sdkinstrument.Descriptor, pt data.Point) *metricspb.SummaryDataPoint {
+	summ := pt.Aggregation.(aggregation.Summary)
+
+	result := &metricspb.SummaryDataPoint{
+		Attributes:        Attributes(pt.Attributes),
+		StartTimeUnixNano: toNanos(pt.Start),
+		TimeUnixNano:      toNanos(pt.End),
+		Count:             summ.Count(),
+		Sum:               summ.Sum().CoerceToFloat64(desc.NumberKind),
+	}
+	for _, qv := range summ.Quantiles() {
+		result.QuantileValues = append(result.QuantileValues, &metricspb.SummaryDataPoint_ValueAtQuantile{
+			Quantile: qv.Quantile,
+			Value:    qv.Value,
+		})
+	}
+	return result
+}
+
+// MinMaxSumCountPoint transforms a MinMaxSumCount point into a
+// histogram point without buckets.  Min and max are included only
+// for delta temporality.
+func MinMaxSumCountPoint(desc *sdkinstrument.Descriptor, pt data.Point) *metricspb.HistogramDataPoint {
+	mmsc := pt.Aggregation.(aggregation.MinMaxSumCount)
+
+	// See note about optional sum at top of minmaxsumcount.go
+	sum := mmsc.Sum().CoerceToFloat64(desc.NumberKind)
+
+	var min, max *float64
+
+	if mmsc.Count() != 0 {
+		min = float64Ptr(mmsc.Min().CoerceToFloat64(desc.NumberKind))
+		max = float64Ptr(mmsc.Max().CoerceToFloat64(desc.NumberKind))
+	}
+	result := &metricspb.HistogramDataPoint{
+		Attributes:        Attributes(pt.Attributes),
+		StartTimeUnixNano: toNanos(pt.Start),
+		TimeUnixNano:      toNanos(pt.End),
+		Count:             mmsc.Count(),
+		Sum:               &sum,
+	}
+	if pt.Temporality == aggregation.DeltaTemporality {
+		result.Min = min
+		result.Max = max
+	}
+	return result
 }
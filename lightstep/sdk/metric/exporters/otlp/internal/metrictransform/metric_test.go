@@ -20,11 +20,14 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/gauge"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/minmaxsumcount"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/rate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/summary"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp/internal/otlptest"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/test"
@@ -35,7 +38,9 @@ import (
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/instrument"
 	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
 	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/testing/protocmp"
 )
 
@@ -97,6 +102,10 @@ func testFloat64() sdkinstrument.Descriptor {
 	return testInst(number.Float64Kind)
 }
 
+func testUint64() sdkinstrument.Descriptor {
+	return testInst(number.Uint64Kind)
+}
+
 func TestMetricTransform(t *testing.T) {
 	for _, test := range []struct {
 		input   data.Metrics
@@ -130,6 +139,34 @@ func TestMetricTransform(t *testing.T) {
 				),
 			),
 		},
+		// uint64 counter, out of int64 range, resource0, scope0, attrs0, cumulative
+		{
+			input: test.Metrics(
+				testResource0,
+				test.Scope(
+					testScope0,
+					test.Instrument(
+						testUint64(),
+						test.Point(startTime, endTime, sum.NewMonotonicUint64(math.MaxUint64), testCumulative, testAttrs0...),
+					),
+				),
+			),
+			encoded: otlptest.ResourceMetrics(
+				expectResource0,
+				testSchema,
+				otlptest.ScopeMetrics(
+					expectScope0,
+					otlptest.Sum(
+						testName,
+						testDesc,
+						testUnit,
+						expectCumulative,
+						true, // monotonic
+						otlptest.Int64DataPoint(expectAttrs0, startTime, endTime, math.MaxInt64),
+					),
+				),
+			),
+		},
 		// float64 updowncounter, resource1, scope1, attrs1, delta
 		{
 			input: test.Metrics(
@@ -184,6 +221,32 @@ func TestMetricTransform(t *testing.T) {
 				),
 			),
 		},
+		// rate, resource2, scope0, attrs0
+		{
+			input: test.Metrics(
+				testResource2,
+				test.Scope(
+					testScope0,
+					test.Instrument(
+						testFloat64(),
+						test.Point(middleTime, endTime, rate.NewInt64(30, 10*time.Second), testDelta, testAttrs0...),
+					),
+				),
+			),
+			encoded: otlptest.ResourceMetrics(
+				expectResource2,
+				testSchema,
+				otlptest.ScopeMetrics(
+					expectScope0,
+					otlptest.Gauge(
+						testName,
+						testDesc,
+						testUnit,
+						otlptest.Float64DataPoint(expectAttrs0, noTime, endTime, 3),
+					),
+				),
+			),
+		},
 		// histogram int64, resource1, scope1, attrs1, cumulative, only positive
 		{
 			input: test.Metrics(
@@ -408,10 +471,171 @@ func TestMetricTransform(t *testing.T) {
 				),
 			),
 		},
+		// explicit histogram
+		{
+			input: test.Metrics(
+				testResource1,
+				test.Scope(
+					testScope0,
+					test.Instrument(
+						testFloat64(),
+						test.Point(
+							startTime,
+							endTime,
+							histogram.NewExplicitFloat64([]float64{1, 2.5}, 0.5, 1, 2, 3),
+							testDelta,
+							testAttrs1...,
+						),
+					),
+				),
+			),
+			encoded: otlptest.ResourceMetrics(
+				expectResource1,
+				noSchema,
+				otlptest.ScopeMetrics(
+					expectScope0,
+					otlptest.ExplicitHistogram(
+						testName,
+						testDesc,
+						testUnit,
+						expectDelta,
+						otlptest.ExplicitHistogramDataPoint(
+							expectAttrs1, startTime, endTime,
+							6.5, 4, 0.5, 3, []float64{1, 2.5}, []uint64{2, 1, 1},
+						),
+					),
+				),
+			),
+		},
+		// summary
+		{
+			input: test.Metrics(
+				testResource1,
+				test.Scope(
+					testScope0,
+					test.Instrument(
+						testInt64(),
+						test.Point(
+							startTime,
+							endTime,
+							summary.NewInt64(aggregator.Config{
+								SummaryQuantiles: summary.WithQuantiles([]float64{0, 0.5, 1}),
+							}, 0, 0, 3),
+							testCumulative,
+							testAttrs1...,
+						),
+					),
+				),
+			),
+			encoded: otlptest.ResourceMetrics(
+				expectResource1,
+				noSchema,
+				otlptest.ScopeMetrics(
+					expectScope0,
+					otlptest.Summary(
+						testName,
+						testDesc,
+						testUnit,
+						otlptest.SummaryDataPoint(
+							expectAttrs1, startTime, endTime,
+							3, 3, []float64{0, 0.5, 1}, []float64{0, 0, 3},
+						),
+					),
+				),
+			),
+		},
 	} {
 		asproto, err := Metrics(test.input)
 		require.NoError(t, err)
 
 		require.Equal(t, "", cmp.Diff(asproto, test.encoded, protocmp.Transform()))
+
+		// The Builder produces the same encoding from a stream.
+		var builder Builder
+		visitMetrics(&builder, test.input)
+		streamed, err := builder.ResourceMetrics()
+		require.NoError(t, err)
+		require.Equal(t, 1, len(streamed))
+
+		require.Equal(t, "", cmp.Diff(streamed[0], test.encoded, protocmp.Transform()))
 	}
 }
+
+func TestExemplars(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	fdesc := testFloat64()
+	idesc := testInt64()
+
+	require.Nil(t, Exemplars(&fdesc, nil))
+
+	require.Equal(t, "", cmp.Diff([]*metricspb.Exemplar{
+		{
+			TimeUnixNano: toNanos(endTime),
+			TraceId:      []byte{1, 2, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			SpanId:       []byte{4, 5, 6, 0, 0, 0, 0, 0},
+			Value:        &metricspb.Exemplar_AsDouble{AsDouble: 1.5},
+		},
+	}, Exemplars(&fdesc, []aggregation.Exemplar{
+		{
+			Value:       number.Float64Traits{}.ToNumber(1.5),
+			Time:        endTime,
+			SpanContext: sc,
+		},
+	}), protocmp.Transform()))
+
+	require.Equal(t, "", cmp.Diff([]*metricspb.Exemplar{
+		{
+			TimeUnixNano: toNanos(endTime),
+			TraceId:      []byte{1, 2, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			SpanId:       []byte{4, 5, 6, 0, 0, 0, 0, 0},
+			Value:        &metricspb.Exemplar_AsInt{AsInt: 7},
+		},
+	}, Exemplars(&idesc, []aggregation.Exemplar{
+		{
+			Value:       number.Int64Traits{}.ToNumber(7),
+			Time:        endTime,
+			SpanContext: sc,
+		},
+	}), protocmp.Transform()))
+}
+
+func TestHistogramZeroThreshold(t *testing.T) {
+	desc := testFloat64()
+	cfg := aggregator.Config{
+		Histogram:              histogram.NewConfig(),
+		HistogramZeroThreshold: 0.5,
+	}
+	var methods histogram.Float64Methods
+	var agg histogram.Float64
+	methods.Init(&agg, cfg)
+	for _, v := range []float64{0.25, -0.5, 0.75} {
+		methods.Update(&agg, v)
+	}
+
+	pt := HistogramPoint(&desc, data.Point{
+		Aggregation: &agg,
+	})
+	require.Equal(t, uint64(3), pt.Count)
+	require.Equal(t, uint64(2), pt.ZeroCount)
+
+	num, typ, n := protowire.ConsumeTag(pt.ProtoReflect().GetUnknown())
+	require.Greater(t, n, 0)
+	require.Equal(t, zeroThresholdField, num)
+	require.Equal(t, protowire.Fixed64Type, typ)
+	bits, _ := protowire.ConsumeFixed64(pt.ProtoReflect().GetUnknown()[n:])
+	require.Equal(t, 0.5, math.Float64frombits(bits))
+
+	// Without a threshold, there is no unknown field.
+	var plain histogram.Float64
+	methods.Init(&plain, aggregator.Config{Histogram: histogram.NewConfig()})
+	methods.Update(&plain, 0.25)
+	pt = HistogramPoint(&desc, data.Point{
+		Aggregation: &plain,
+	})
+	require.Equal(t, uint64(0), pt.ZeroCount)
+	require.Empty(t, pt.ProtoReflect().GetUnknown())
+}
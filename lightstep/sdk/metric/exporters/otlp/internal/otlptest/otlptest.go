@@ -202,6 +202,29 @@ func MinMaxSumCountDataPoint(attributes []*commonpb.KeyValue, start, end time.Ti
 	return dp
 }
 
+func ExplicitHistogramDataPoint(attributes []*commonpb.KeyValue, start, end time.Time, sum float64, count uint64, min, max float64, bounds []float64, counts []uint64) *metricspb.HistogramDataPoint {
+	dp := &metricspb.HistogramDataPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: toNanos(start),
+		TimeUnixNano:      toNanos(end),
+		Sum:               &sum,
+		Count:             count,
+		ExplicitBounds:    bounds,
+		BucketCounts:      counts,
+	}
+	if !math.IsNaN(min) {
+		dp.Min = &min
+	}
+	if !math.IsNaN(max) {
+		dp.Max = &max
+	}
+	return dp
+}
+
+func ExplicitHistogram(name, desc, unit string, tempo metricspb.AggregationTemporality, idps ...*metricspb.HistogramDataPoint) *metricspb.Metric {
+	return MinMaxSumCount(name, desc, unit, tempo, idps...)
+}
+
 func MinMaxSumCount(name, desc, unit string, tempo metricspb.AggregationTemporality, idps ...*metricspb.HistogramDataPoint) *metricspb.Metric {
 	return &metricspb.Metric{
 		Name:        name,
@@ -215,3 +238,33 @@ func MinMaxSumCount(name, desc, unit string, tempo metricspb.AggregationTemporal
 		},
 	}
 }
+
+func SummaryDataPoint(attributes []*commonpb.KeyValue, start, end time.Time, sum float64, count uint64, quantiles, values []float64) *metricspb.SummaryDataPoint {
+	dp := &metricspb.SummaryDataPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: toNanos(start),
+		TimeUnixNano:      toNanos(end),
+		Sum:               sum,
+		Count:             count,
+	}
+	for i, q := range quantiles {
+		dp.QuantileValues = append(dp.QuantileValues, &metricspb.SummaryDataPoint_ValueAtQuantile{
+			Quantile: q,
+			Value:    values[i],
+		})
+	}
+	return dp
+}
+
+func Summary(name, desc, unit string, idps ...*metricspb.SummaryDataPoint) *metricspb.Metric {
+	return &metricspb.Metric{
+		Name:        name,
+		Description: desc,
+		Unit:        unit,
+		Data: &metricspb.Metric_Summary{
+			Summary: &metricspb.Summary{
+				DataPoints: idps,
+			},
+		},
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlpmetrichttp
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// testCollector records the requests it receives and replies with
+// the configured status codes, in order, then with 200.
+type testCollector struct {
+	lock     sync.Mutex
+	statuses []int
+	response *colmetricpb.ExportMetricsServiceResponse
+	headers  []http.Header
+	requests []*colmetricpb.ExportMetricsServiceRequest
+}
+
+func (tc *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req := &colmetricpb.ExportMetricsServiceRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	tc.headers = append(tc.headers, r.Header.Clone())
+	tc.requests = append(tc.requests, req)
+
+	if len(tc.statuses) != 0 {
+		status := tc.statuses[0]
+		tc.statuses = tc.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	resp := tc.response
+	if resp == nil {
+		resp = &colmetricpb.ExportMetricsServiceResponse{}
+	}
+	out, _ := proto.Marshal(resp)
+	w.Header().Set("Content-Type", contentTypeProto)
+	_, _ = w.Write(out)
+}
+
+// errorRecorder is an OTel error handler.
+type errorRecorder struct {
+	lock   sync.Mutex
+	errors []error
+}
+
+func (er *errorRecorder) Handle(err error) {
+	er.lock.Lock()
+	defer er.lock.Unlock()
+	er.errors = append(er.errors, err)
+}
+
+func newTestClient(t *testing.T, tc *testCollector, opts ...Option) *client {
+	srv := httptest.NewServer(tc)
+	t.Cleanup(srv.Close)
+
+	opts = append([]Option{
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Second,
+		}),
+	}, opts...)
+	return NewClient(opts...).(*client)
+}
+
+func testBatch() *metricpb.ResourceMetrics {
+	return &metricpb.ResourceMetrics{SchemaUrl: "test"}
+}
+
+func TestUploadGzip(t *testing.T) {
+	ctx := context.Background()
+	tc := &testCollector{}
+	c := newTestClient(t, tc,
+		WithCompression(GzipCompression),
+		WithHeaders(map[string]string{"lightstep-access-token": "token"}),
+	)
+	require.NoError(t, c.Start(ctx))
+	require.NoError(t, c.UploadMetrics(ctx, testBatch()))
+	require.NoError(t, c.Stop(ctx))
+
+	require.Equal(t, 1, len(tc.requests))
+	require.Equal(t, "test", tc.requests[0].ResourceMetrics[0].SchemaUrl)
+	require.Equal(t, "gzip", tc.headers[0].Get("Content-Encoding"))
+	require.Equal(t, contentTypeProto, tc.headers[0].Get("Content-Type"))
+	require.Equal(t, "token", tc.headers[0].Get("lightstep-access-token"))
+}
+
+func TestUploadRetry(t *testing.T) {
+	ctx := context.Background()
+	tc := &testCollector{
+		statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+	}
+	c := newTestClient(t, tc)
+	require.NoError(t, c.UploadMetrics(ctx, testBatch()))
+	require.Equal(t, 3, len(tc.requests))
+}
+
+func TestUploadPermanentError(t *testing.T) {
+	ctx := context.Background()
+	tc := &testCollector{
+		statuses: []int{http.StatusBadRequest},
+	}
+	c := newTestClient(t, tc)
+	require.Error(t, c.UploadMetrics(ctx, testBatch()))
+	require.Equal(t, 1, len(tc.requests))
+}
+
+func TestUploadPartialSuccess(t *testing.T) {
+	er := &errorRecorder{}
+	otel.SetErrorHandler(er)
+
+	ctx := context.Background()
+	tc := &testCollector{
+		response: &colmetricpb.ExportMetricsServiceResponse{
+			PartialSuccess: &colmetricpb.ExportMetricsPartialSuccess{
+				RejectedDataPoints: 3,
+				ErrorMessage:       "invalid name",
+			},
+		},
+	}
+	c := newTestClient(t, tc)
+	require.NoError(t, c.UploadMetrics(ctx, testBatch()))
+	require.Equal(t, 1, len(tc.requests))
+
+	require.Equal(t, 1, len(er.errors))
+	require.Contains(t, er.errors[0].Error(), "3 points rejected")
+	require.Contains(t, er.errors[0].Error(), "invalid name")
+
+	// A full success is not reported.
+	tc.response = nil
+	require.NoError(t, c.UploadMetrics(ctx, testBatch()))
+	require.Equal(t, 1, len(er.errors))
+}
+
+func TestUploadPartialSuccessInstruments(t *testing.T) {
+	er := &errorRecorder{}
+	otel.SetErrorHandler(er)
+
+	ctx := context.Background()
+	tc := &testCollector{
+		response: &colmetricpb.ExportMetricsServiceResponse{
+			PartialSuccess: &colmetricpb.ExportMetricsPartialSuccess{
+				RejectedDataPoints: 2,
+				ErrorMessage:       "invalid unit for http.server.duration; http.server.duration.max: bad type",
+			},
+		},
+	}
+	batch := &metricpb.ResourceMetrics{
+		ScopeMetrics: []*metricpb.ScopeMetrics{{
+			Metrics: []*metricpb.Metric{
+				{Name: "http.server.duration"},
+				{Name: "http.server"},
+				{Name: "process.cpu.time"},
+			},
+		}},
+	}
+	c := newTestClient(t, tc)
+	require.NoError(t, c.UploadMetrics(ctx, batch))
+
+	// The partial success is not retried.
+	require.Equal(t, 1, len(tc.requests))
+	require.Equal(t, 1, len(er.errors))
+
+	var pse *otlp.PartialSuccessError
+	require.True(t, errors.As(er.errors[0], &pse))
+	require.Equal(t, int64(2), pse.RejectedDataPoints)
+	require.Equal(t, []string{"http.server.duration"}, pse.Instruments)
+	require.Contains(t, pse.Error(), "(instruments: http.server.duration)")
+}
@@ -0,0 +1,113 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// PartialSuccessError describes the data points that the collector
+// rejected from an otherwise successful export.  Clients pass it to
+// the OpenTelemetry error handler instead of returning it from
+// UploadMetrics, because the accepted points must not be retried.
+type PartialSuccessError struct {
+	// RejectedDataPoints is the number of points the collector
+	// rejected.
+	RejectedDataPoints int64
+
+	// ErrorMessage is the explanation given by the collector.
+	ErrorMessage string
+
+	// Instruments are the names of the exported metrics that
+	// ErrorMessage mentions, in sorted order.  These are the
+	// affected instruments when the collector names them.
+	Instruments []string
+}
+
+// NewPartialSuccessError returns a PartialSuccessError for the
+// exported batch, or nil when the response reports neither rejected
+// points nor a message.
+func NewPartialSuccessError(rejected int64, message string, batch *metricpb.ResourceMetrics) *PartialSuccessError {
+	if rejected == 0 && message == "" {
+		return nil
+	}
+	return &PartialSuccessError{
+		RejectedDataPoints: rejected,
+		ErrorMessage:       message,
+		Instruments:        mentionedMetrics(message, batch),
+	}
+}
+
+func (e *PartialSuccessError) Error() string {
+	msg := fmt.Sprintf("metrics partial failure: %d points rejected: %s", e.RejectedDataPoints, e.ErrorMessage)
+	if len(e.Instruments) != 0 {
+		msg += fmt.Sprintf(" (instruments: %s)", strings.Join(e.Instruments, ", "))
+	}
+	return msg
+}
+
+// mentionedMetrics returns the distinct names of metrics in batch
+// that occur in message as a whole word.
+func mentionedMetrics(message string, batch *metricpb.ResourceMetrics) []string {
+	if message == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, sm := range batch.GetScopeMetrics() {
+		for _, m := range sm.GetMetrics() {
+			name := m.GetName()
+			if name == "" || seen[name] || !mentions(message, name) {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mentions returns true if name occurs in message and is not part
+// of a longer name.
+func mentions(message, name string) bool {
+	for offset := 0; ; {
+		idx := strings.Index(message[offset:], name)
+		if idx < 0 {
+			return false
+		}
+		start := offset + idx
+		end := start + len(name)
+		if (start == 0 || !isNameByte(message[start-1])) &&
+			(end == len(message) || !isNameByte(message[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// isNameByte returns true for the bytes that may continue a metric
+// name.
+func isNameByte(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return c == '_' || c == '.' || c == '-' || c == '/'
+}
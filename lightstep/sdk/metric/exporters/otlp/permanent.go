@@ -0,0 +1,74 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp"
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPStatusError is returned by clients when the receiver responds
+// to an export with an HTTP status that is not retried.
+type HTTPStatusError struct {
+	// URL is the export endpoint.
+	URL string
+
+	// StatusCode and Status are the response status.
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("failed to send metrics to %s: %s", e.URL, e.Status)
+}
+
+// Permanent returns true when err shows that the receiver rejected
+// the exported data itself, so that uploading the same data again
+// will fail again: an HTTP 4xx status or a gRPC status code that the
+// OTLP specification does not list as retryable.  Timeouts,
+// throttling, and authentication failures are not permanent, since
+// the same data may be accepted later, nor are other errors, e.g.,
+// network errors.
+func Permanent(err error) bool {
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusUnauthorized,
+			http.StatusForbidden:
+			return false
+		}
+		return httpErr.StatusCode >= 400 && httpErr.StatusCode < 500
+	}
+	var grpcErr interface {
+		GRPCStatus() *status.Status
+	}
+	if errors.As(err, &grpcErr) {
+		switch grpcErr.GRPCStatus().Code() {
+		case codes.InvalidArgument,
+			codes.NotFound,
+			codes.AlreadyExists,
+			codes.FailedPrecondition,
+			codes.Unimplemented:
+			return true
+		}
+	}
+	return false
+}
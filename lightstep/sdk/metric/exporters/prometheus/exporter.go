@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prometheus exposes the metrics of a MeterProvider in the
+// Prometheus text exposition format.  The Exporter is a pull-based
+// Reader: register it with metric.WithReader and serve it as an
+// http.Handler, typically at /metrics.
+package prometheus // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/prometheus"
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
+	"go.opentelemetry.io/otel"
+)
+
+// Exporter collects from its Producer each time it is scraped.
+type Exporter struct {
+	lock     sync.Mutex
+	producer metric.Producer
+	data     data.Metrics
+}
+
+var (
+	_ metric.Reader = (*Exporter)(nil)
+	_ http.Handler  = (*Exporter)(nil)
+)
+
+// New returns an Exporter that must be registered with a
+// MeterProvider before it is served.  Instruments viewed with Delta
+// temporality cannot be represented in Prometheus and are omitted
+// from its output; configure the reader with cumulative temporality.
+func New() *Exporter {
+	return &Exporter{}
+}
+
+// String returns "prometheus".
+func (e *Exporter) String() string {
+	return "prometheus"
+}
+
+// Register stores the Producer used to collect on each scrape.
+func (e *Exporter) Register(p metric.Producer) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.producer != nil {
+		otel.Handle(fmt.Errorf("%v: %w", e, metric.ErrMultipleReaderRegistration))
+		return
+	}
+	e.producer = p
+}
+
+// ForceFlush is a no-op, always returns nil.
+func (e *Exporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+// Shutdown is a no-op, always returns nil.
+func (e *Exporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// ServeHTTP collects and writes the current metrics.  Scrapers that
+// accept the OpenMetrics format receive it, including exemplars, and
+// others receive the text exposition format.  Concurrent scrapes are
+// serialized.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.producer == nil {
+		http.Error(w, "prometheus exporter is not registered", http.StatusServiceUnavailable)
+		return
+	}
+	if cp, ok := e.producer.(metric.ContextProducer); ok {
+		var err error
+		e.data, err = cp.ProduceContext(r.Context(), &e.data)
+		if err != nil {
+			handle(err)
+		}
+	} else {
+		e.data = e.producer.Produce(&e.data)
+	}
+
+	write, contentType := WriteText, ContentType
+	if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		write, contentType = WriteOpenMetrics, OpenMetricsContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	if err := write(w, e.data); err != nil {
+		handle(err)
+	}
+}
+
+// handle reports errors through the OpenTelemetry error handler at
+// most once per 30 seconds, since scrapes repeat the same problems.
+func handle(err error) {
+	doevery.TimePeriod(30*time.Second, func() {
+		otel.Handle(fmt.Errorf("prometheus exporter: %w", err))
+	})
+}
@@ -0,0 +1,333 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/summary"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func scrape(t *testing.T, h http.Handler) string {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	return rec.Body.String()
+}
+
+func TestServeHTTP(t *testing.T) {
+	ctx := context.Background()
+	exp := New()
+	provider := metric.NewMeterProvider(
+		metric.WithResource(resource.Empty()),
+		metric.WithReader(
+			exp,
+			view.WithClause(
+				view.MatchInstrumentName("latency"),
+				view.WithAggregatorConfig(aggregator.Config{
+					HistogramBoundaries: histogram.WithExplicitBoundaries([]float64{1, 5}),
+				}),
+			),
+		),
+	)
+	meter := provider.Meter("test")
+
+	requests, err := meter.SyncInt64().Counter("http.requests", instrument.WithDescription("Number of requests"))
+	require.NoError(t, err)
+	queue, err := meter.SyncFloat64().UpDownCounter("queue.size")
+	require.NoError(t, err)
+	temp, err := meter.(sdkinstrument.GaugeProvider).Int64Gauge("temperature")
+	require.NoError(t, err)
+	latency, err := meter.SyncFloat64().Histogram("latency")
+	require.NoError(t, err)
+
+	requests.Add(ctx, 3, attribute.String("code", "200"), attribute.String("method", "GET"))
+	requests.Add(ctx, 1, attribute.String("code", "500"), attribute.String("method", "GET"))
+	queue.Add(ctx, 2.5)
+	temp.Record(ctx, -4, attribute.String("room", `a "b"`))
+	latency.Record(ctx, 0.5)
+	latency.Record(ctx, 3)
+	latency.Record(ctx, 7)
+
+	require.Equal(t, `# HELP http_requests_total Number of requests
+# TYPE http_requests_total counter
+http_requests_total{code="200",method="GET"} 3
+http_requests_total{code="500",method="GET"} 1
+# TYPE latency histogram
+latency_bucket{le="1"} 1
+latency_bucket{le="5"} 2
+latency_bucket{le="+Inf"} 3
+latency_sum 10.5
+latency_count 3
+# TYPE queue_size gauge
+queue_size 2.5
+# TYPE temperature gauge
+temperature{room="a \"b\""} -4
+`, scrape(t, exp))
+}
+
+func TestServeOpenMetrics(t *testing.T) {
+	ctx := context.Background()
+	exp := New()
+	provider := metric.NewMeterProvider(
+		metric.WithResource(resource.Empty()),
+		metric.WithReader(exp),
+	)
+	requests, err := provider.Meter("test").SyncInt64().Counter("http.requests", instrument.WithDescription("Number of requests"))
+	require.NoError(t, err)
+	requests.Add(ctx, 3)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0,text/plain;q=0.5")
+	rec := httptest.NewRecorder()
+	exp.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, OpenMetricsContentType, rec.Header().Get("Content-Type"))
+	require.Equal(t, `# HELP http_requests Number of requests
+# TYPE http_requests counter
+http_requests_total 3
+# EOF
+`, rec.Body.String())
+}
+
+// exemplarSum and exemplarHistogram are aggregations with fixed
+// exemplars.
+type exemplarSum struct {
+	*sum.MonotonicFloat64
+	exemplars []aggregation.Exemplar
+}
+
+type exemplarHistogram struct {
+	*histogram.Float64
+	exemplars []aggregation.Exemplar
+}
+
+func (e exemplarSum) Exemplars() []aggregation.Exemplar {
+	return e.exemplars
+}
+
+func (e exemplarHistogram) Exemplars() []aggregation.Exemplar {
+	return e.exemplars
+}
+
+func TestOpenMetricsExemplars(t *testing.T) {
+	cumulative := aggregation.CumulativeTemporality
+	span := func(id byte) trace.SpanContext {
+		return trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{id},
+			SpanID:     trace.SpanID{id},
+			TraceFlags: trace.FlagsSampled,
+		})
+	}
+	exemplar := func(value float64, sec int64, id byte) aggregation.Exemplar {
+		return aggregation.Exemplar{
+			Value:       number.Float64Traits{}.ToNumber(value),
+			Time:        time.Unix(sec, 500000000),
+			SpanContext: span(id),
+		}
+	}
+
+	metrics := data.Metrics{
+		Scopes: []data.Scope{{
+			Instruments: []data.Instrument{
+				{
+					Descriptor: sdkinstrument.NewDescriptor("requests", sdkinstrument.SyncCounter, number.Float64Kind, "", ""),
+					Points: []data.Point{{
+						Aggregation: exemplarSum{
+							MonotonicFloat64: sum.NewMonotonicFloat64(3),
+							exemplars: []aggregation.Exemplar{
+								exemplar(1, 10, 1),
+								exemplar(2, 20, 2),
+							},
+						},
+						Temporality: cumulative,
+					}},
+				},
+				{
+					Descriptor: sdkinstrument.NewDescriptor("latency", sdkinstrument.SyncHistogram, number.Float64Kind, "", ""),
+					Points: []data.Point{{
+						Aggregation: exemplarHistogram{
+							Float64: histogram.NewFloat64(histogram.NewConfig(histogram.WithMaxSize(4)), 0, 2, 3, 4, 8),
+							exemplars: []aggregation.Exemplar{
+								// Two in the bucket (2, 4], of
+								// which the latest is output.
+								exemplar(3, 30, 3),
+								exemplar(4, 10, 4),
+								exemplar(8, 20, 5),
+							},
+						},
+						Temporality: cumulative,
+					}},
+				},
+			},
+		}},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, WriteOpenMetrics(&sb, metrics))
+	require.Equal(t, `# TYPE latency histogram
+latency_bucket{le="0"} 1
+latency_bucket{le="2"} 2
+latency_bucket{le="4"} 4 # {trace_id="03000000000000000000000000000000",span_id="0300000000000000"} 3 30.500000000
+latency_bucket{le="8"} 5 # {trace_id="05000000000000000000000000000000",span_id="0500000000000000"} 8 20.500000000
+latency_bucket{le="+Inf"} 5
+latency_sum 17
+latency_count 5
+# TYPE requests counter
+requests_total 3 # {trace_id="02000000000000000000000000000000",span_id="0200000000000000"} 2 20.500000000
+# EOF
+`, sb.String())
+
+	// The text exposition format has no exemplars.
+	sb.Reset()
+	require.NoError(t, WriteText(&sb, metrics))
+	require.NotContains(t, sb.String(), "trace_id")
+}
+
+func TestServeHTTPUnregistered(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestDeltaTemporality(t *testing.T) {
+	ctx := context.Background()
+	rdr := metric.NewManualReader("test")
+	provider := metric.NewMeterProvider(
+		metric.WithResource(resource.Empty()),
+		metric.WithReader(
+			rdr,
+			view.WithDefaultAggregationTemporalitySelector(view.DeltaPreferredTemporality),
+		),
+	)
+	meter := provider.Meter("test")
+
+	counter, err := meter.SyncInt64().Counter("delta.counter")
+	require.NoError(t, err)
+	updown, err := meter.SyncInt64().UpDownCounter("cumulative.updown")
+	require.NoError(t, err)
+
+	counter.Add(ctx, 1)
+	updown.Add(ctx, 1)
+
+	var sb strings.Builder
+	err = WriteText(&sb, rdr.Produce(nil))
+	require.ErrorIs(t, err, ErrDeltaTemporality)
+	require.Contains(t, err.Error(), "delta.counter")
+	require.Equal(t, "# TYPE cumulative_updown gauge\ncumulative_updown 1\n", sb.String())
+}
+
+func TestExponentialHistogram(t *testing.T) {
+	cfg := histogram.NewConfig(histogram.WithMaxSize(4))
+	cumulative := aggregation.CumulativeTemporality
+
+	metrics := data.Metrics{
+		Scopes: []data.Scope{{
+			Instruments: []data.Instrument{
+				{
+					Descriptor: sdkinstrument.NewDescriptor("positive", sdkinstrument.SyncHistogram, number.Float64Kind, "", ""),
+					Points: []data.Point{{
+						Aggregation: histogram.NewFloat64(cfg, 0, 2, 4, 8),
+						Temporality: cumulative,
+					}},
+				},
+				{
+					Descriptor: sdkinstrument.NewDescriptor("negative", sdkinstrument.SyncHistogram, number.Float64Kind, "", ""),
+					Points: []data.Point{{
+						Aggregation: histogram.NewFloat64(cfg, -1),
+						Temporality: cumulative,
+					}},
+				},
+			},
+		}},
+	}
+
+	var sb strings.Builder
+	err := WriteText(&sb, metrics)
+	require.ErrorIs(t, err, ErrNegativeBuckets)
+	require.Equal(t, `# TYPE positive histogram
+positive_bucket{le="0"} 1
+positive_bucket{le="2"} 2
+positive_bucket{le="4"} 3
+positive_bucket{le="8"} 4
+positive_bucket{le="+Inf"} 4
+positive_sum 14
+positive_count 4
+`, sb.String())
+}
+
+func TestSummary(t *testing.T) {
+	cfg := aggregator.Config{
+		SummaryQuantiles: summary.WithQuantiles([]float64{0, 0.5, 1}),
+	}
+	metrics := data.Metrics{
+		Scopes: []data.Scope{{
+			Instruments: []data.Instrument{{
+				Descriptor: sdkinstrument.NewDescriptor("latency", sdkinstrument.SyncHistogram, number.Float64Kind, "", ""),
+				Points: []data.Point{{
+					Attributes:  attribute.NewSet(attribute.String("path", "/")),
+					Aggregation: summary.NewFloat64(cfg, 0, 0, 0, 8),
+					Temporality: aggregation.CumulativeTemporality,
+				}},
+			}},
+		}},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, WriteText(&sb, metrics))
+	require.Equal(t, `# TYPE latency summary
+latency{path="/",quantile="0"} 0
+latency{path="/",quantile="0.5"} 0
+latency{path="/",quantile="1"} 8
+latency_sum{path="/"} 8
+latency_count{path="/"} 4
+`, sb.String())
+}
+
+func TestSanitize(t *testing.T) {
+	for _, test := range []struct {
+		input  string
+		metric string
+		label  string
+	}{
+		{"http.server.duration", "http_server_duration", "http_server_duration"},
+		{"ns:name", "ns:name", "ns_name"},
+		{"9lives", "_9lives", "_9lives"},
+		{"a-b c", "a_b_c", "a_b_c"},
+	} {
+		require.Equal(t, test.metric, sanitize(test.input, true), "%s", test.input)
+		require.Equal(t, test.label, sanitize(test.input, false), "%s", test.input)
+	}
+}
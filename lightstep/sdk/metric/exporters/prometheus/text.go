@@ -0,0 +1,418 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/prometheus"
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/multierr"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// OpenMetricsContentType is the media type of the OpenMetrics text
+// format.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+var (
+	// ErrDeltaTemporality is returned for instruments with Delta
+	// temporality, which Prometheus cannot represent.
+	ErrDeltaTemporality = errors.New("delta temporality is not supported")
+
+	// ErrNegativeBuckets is returned for exponential histograms
+	// with negative buckets, which cannot be converted to
+	// Prometheus buckets.
+	ErrNegativeBuckets = errors.New("exponential histogram has negative buckets")
+
+	// ErrUnsupportedAggregation is returned for aggregations that
+	// have no Prometheus representation.
+	ErrUnsupportedAggregation = errors.New("unsupported aggregation")
+
+	// ErrTypeConflict is returned when instruments of different
+	// types map to the same Prometheus metric name.
+	ErrTypeConflict = errors.New("conflicting metric types")
+)
+
+// family is the output for one Prometheus metric name.
+type family struct {
+	name    string
+	help    string
+	typ     string
+	samples strings.Builder
+
+	// openMetrics selects the OpenMetrics format, which adds
+	// exemplars to samples.
+	openMetrics bool
+}
+
+// WriteText writes metrics in the Prometheus text exposition format.
+// Monotonic sums are written as counters, non-monotonic sums and
+// gauges as gauges, and explicit-boundary histograms as histograms.
+// Exponential histograms are written as histograms having one
+// bucket per exponential bucket; those with negative buckets are
+// omitted.  Instruments that cannot be represented are omitted and
+// returned as a combined error, after the remaining output is written.
+// The points of metrics are sorted in place.
+func WriteText(w io.Writer, metrics data.Metrics) error {
+	return write(w, metrics, false)
+}
+
+// WriteOpenMetrics writes metrics in the OpenMetrics text format, as
+// for WriteText, including the exemplars of aggregations that sample
+// them (see aggregation.HasExemplars) with their trace and span IDs.
+// OpenMetrics permits one exemplar per sample line, so each histogram
+// bucket line carries the most recent exemplar in that bucket and
+// each counter line the most recent exemplar of the point.
+func WriteOpenMetrics(w io.Writer, metrics data.Metrics) error {
+	return write(w, metrics, true)
+}
+
+// write implements WriteText and WriteOpenMetrics.
+func write(w io.Writer, metrics data.Metrics, openMetrics bool) error {
+	var errs error
+	families := map[string]*family{}
+	var names []string
+
+	for si := range metrics.Scopes {
+		for ii := range metrics.Scopes[si].Instruments {
+			inst := &metrics.Scopes[si].Instruments[ii]
+			if len(inst.Points) == 0 {
+				continue
+			}
+			typ, err := familyType(inst)
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("%s: %w", inst.Descriptor.Name, err))
+				continue
+			}
+			name := metricName(inst.Descriptor.Name, typ)
+
+			f, ok := families[name]
+			if !ok {
+				f = &family{
+					name:        name,
+					help:        inst.Descriptor.Description,
+					typ:         typ,
+					openMetrics: openMetrics,
+				}
+				families[name] = f
+				names = append(names, name)
+			} else if f.typ != typ {
+				errs = multierr.Append(errs, fmt.Errorf("%s: %w: %s and %s", name, ErrTypeConflict, f.typ, typ))
+				continue
+			}
+
+			data.SortPoints(inst.Points)
+			if err := f.writePoints(inst); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("%s: %w", inst.Descriptor.Name, err))
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		if f.samples.Len() == 0 {
+			continue
+		}
+		familyName := f.name
+		if openMetrics && f.typ == "counter" {
+			// OpenMetrics counter samples have a suffix
+			// that the family name does not.
+			familyName = strings.TrimSuffix(familyName, "_total")
+		}
+		if f.help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", familyName, escapeHelp(f.help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", familyName, f.typ)
+		_, _ = bw.WriteString(f.samples.String())
+	}
+	if openMetrics {
+		_, _ = bw.WriteString("# EOF\n")
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return errs
+}
+
+// familyType returns the Prometheus type of an instrument, or an
+// error if it cannot be represented.
+func familyType(inst *data.Instrument) (string, error) {
+	kind := inst.Points[0].Aggregation.Kind()
+
+	if kind != aggregation.GaugeKind && kind != aggregation.RateKind {
+		for _, pt := range inst.Points {
+			if pt.Temporality == aggregation.DeltaTemporality {
+				return "", ErrDeltaTemporality
+			}
+		}
+	}
+
+	switch kind {
+	case aggregation.MonotonicSumKind:
+		return "counter", nil
+	case aggregation.NonMonotonicSumKind, aggregation.GaugeKind, aggregation.RateKind:
+		return "gauge", nil
+	case aggregation.ExplicitHistogramKind, aggregation.HistogramKind:
+		return "histogram", nil
+	case aggregation.SummaryKind:
+		return "summary", nil
+	}
+	return "", fmt.Errorf("%w: %v", ErrUnsupportedAggregation, kind)
+}
+
+// writePoints appends the samples of inst to the family.  Points
+// that cannot be converted are skipped and reported as an error.
+func (f *family) writePoints(inst *data.Instrument) error {
+	var err error
+	nk := inst.Descriptor.NumberKind
+
+	for _, pt := range inst.Points {
+		var exemplars []aggregation.Exemplar
+		if he, ok := pt.Aggregation.(aggregation.HasExemplars); ok && f.openMetrics {
+			exemplars = he.Exemplars()
+		}
+
+		switch agg := pt.Aggregation.(type) {
+		case aggregation.Sum:
+			ex := ""
+			if agg.IsMonotonic() {
+				ex = formatExemplar(latestExemplar(exemplars, nil), nk)
+			}
+			f.labeledSample("", pt.Attributes, "", "", formatNumber(agg.Sum(), nk), ex)
+		case aggregation.Gauge:
+			f.sample("", pt.Attributes, "", formatNumber(agg.Gauge(), nk))
+		case aggregation.Histogram:
+			// Note: this precedes ExplicitHistogram, which
+			// the exponential histogram also implements.
+			if agg.Negative().Len() != 0 {
+				err = ErrNegativeBuckets
+				continue
+			}
+			// Exponential bucket i holds values between
+			// base**i and base**(i+1), base = 2**(2**-scale).
+			pos := agg.Positive()
+			bounds := make([]float64, 0, pos.Len()+1)
+			counts := make([]uint64, 0, pos.Len()+1)
+
+			bounds = append(bounds, 0)
+			counts = append(counts, agg.ZeroCount())
+			for i := uint32(0); i < pos.Len(); i++ {
+				bounds = append(bounds, math.Exp2(float64(pos.Offset()+int32(i)+1)*math.Exp2(-float64(agg.Scale()))))
+				counts = append(counts, pos.At(i))
+			}
+			f.histogram(pt.Attributes, bounds, counts, agg.Count(), agg.Sum(), exemplars, nk)
+		case aggregation.ExplicitHistogram:
+			f.histogram(pt.Attributes, agg.Boundaries(), agg.BucketCounts(), agg.Count(), agg.Sum(), exemplars, nk)
+		case aggregation.Summary:
+			for _, qv := range agg.Quantiles() {
+				f.labeledSample("", pt.Attributes, "quantile", formatFloat(qv.Quantile), formatFloat(qv.Value), "")
+			}
+			f.sample("_sum", pt.Attributes, "", formatNumber(agg.Sum(), nk))
+			f.sample("_count", pt.Attributes, "", strconv.FormatUint(agg.Count(), 10))
+		}
+	}
+	return err
+}
+
+// histogram writes the cumulative buckets, sum, and count of a
+// histogram point.  Bucket i counts values up to bounds[i], and
+// the +Inf bucket counts the remainder.  Each bucket line has the
+// most recent of the exemplars in the bucket, if any.
+func (f *family) histogram(attrs attribute.Set, bounds []float64, counts []uint64, count uint64, sum number.Number, exemplars []aggregation.Exemplar, nk number.Kind) {
+	var cumulative uint64
+	for i, bound := range bounds {
+		cumulative += counts[i]
+		ex := latestExemplar(exemplars, func(v float64) bool {
+			return v <= bound && (i == 0 || v > bounds[i-1])
+		})
+		f.labeledSample("_bucket", attrs, "le", formatFloat(bound), strconv.FormatUint(cumulative, 10), formatExemplar(ex, nk))
+	}
+	ex := latestExemplar(exemplars, func(v float64) bool {
+		return len(bounds) == 0 || v > bounds[len(bounds)-1]
+	})
+	f.labeledSample("_bucket", attrs, "le", "+Inf", strconv.FormatUint(count, 10), formatExemplar(ex, nk))
+	f.sample("_sum", attrs, "", formatNumber(sum, nk))
+	f.sample("_count", attrs, "", strconv.FormatUint(count, 10))
+}
+
+// latestExemplar returns the most recent exemplar whose value
+// satisfies match, which may be nil to match any, or nil if none do.
+func latestExemplar(exemplars []aggregation.Exemplar, match func(float64) bool) *aggregation.Exemplar {
+	var latest *aggregation.Exemplar
+	for i := range exemplars {
+		ex := &exemplars[i]
+		if match != nil && !match(number.ToFloat64(ex.Value)) {
+			continue
+		}
+		if latest == nil || ex.Time.After(latest.Time) {
+			latest = ex
+		}
+	}
+	return latest
+}
+
+// formatExemplar returns the OpenMetrics exemplar of a sample line,
+// without the leading "# ", or the empty string for nil.
+func formatExemplar(ex *aggregation.Exemplar, nk number.Kind) string {
+	if ex == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	if ex.SpanContext.HasTraceID() {
+		sb.WriteString(`trace_id="`)
+		sb.WriteString(ex.SpanContext.TraceID().String())
+		sb.WriteByte('"')
+	}
+	if ex.SpanContext.HasSpanID() {
+		if sb.Len() > 1 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(`span_id="`)
+		sb.WriteString(ex.SpanContext.SpanID().String())
+		sb.WriteByte('"')
+	}
+	sb.WriteString("} ")
+	sb.WriteString(formatNumber(ex.Value, nk))
+	if !ex.Time.IsZero() {
+		sb.WriteByte(' ')
+		fmt.Fprintf(&sb, "%d.%09d", ex.Time.Unix(), ex.Time.Nanosecond())
+	}
+	return sb.String()
+}
+
+// sample writes one line; le is the bucket label, empty if none.
+func (f *family) sample(suffix string, attrs attribute.Set, le, value string) {
+	f.labeledSample(suffix, attrs, "le", le, value, "")
+}
+
+// labeledSample writes one line with an additional label, which is
+// omitted when its value is empty, and an exemplar, which is
+// omitted when empty.
+func (f *family) labeledSample(suffix string, attrs attribute.Set, label, labelValue, value, exemplar string) {
+	f.samples.WriteString(f.name)
+	f.samples.WriteString(suffix)
+
+	if attrs.Len() != 0 || labelValue != "" {
+		f.samples.WriteByte('{')
+		for iter := attrs.Iter(); iter.Next(); {
+			idx, kv := iter.IndexedAttribute()
+			if idx > 0 {
+				f.samples.WriteByte(',')
+			}
+			f.samples.WriteString(sanitize(string(kv.Key), false))
+			f.samples.WriteString(`="`)
+			f.samples.WriteString(escapeLabel(kv.Value.Emit()))
+			f.samples.WriteByte('"')
+		}
+		if labelValue != "" {
+			if attrs.Len() != 0 {
+				f.samples.WriteByte(',')
+			}
+			f.samples.WriteString(label)
+			f.samples.WriteString(`="`)
+			f.samples.WriteString(labelValue)
+			f.samples.WriteByte('"')
+		}
+		f.samples.WriteByte('}')
+	}
+	f.samples.WriteByte(' ')
+	f.samples.WriteString(value)
+	if exemplar != "" {
+		f.samples.WriteString(" # ")
+		f.samples.WriteString(exemplar)
+	}
+	f.samples.WriteByte('\n')
+}
+
+// metricName returns the sanitized Prometheus name of an instrument.
+// Counters have a "_total" suffix.
+func metricName(name, typ string) string {
+	name = sanitize(name, true)
+	if typ == "counter" && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return name
+}
+
+// sanitize replaces characters that are not valid in a Prometheus
+// metric name (allowColon) or label name with underscores.
+func sanitize(name string, allowColon bool) string {
+	valid := func(i int, r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' ||
+			(r == ':' && allowColon) || (r >= '0' && r <= '9' && i > 0)
+	}
+	var sb strings.Builder
+	for i, r := range name {
+		if valid(i, r) {
+			sb.WriteRune(r)
+			continue
+		}
+		if i == 0 && r >= '0' && r <= '9' {
+			sb.WriteByte('_')
+			sb.WriteRune(r)
+			continue
+		}
+		sb.WriteByte('_')
+	}
+	return sb.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func formatNumber(n number.Number, nk number.Kind) string {
+	if nk == number.Int64Kind {
+		return strconv.FormatInt(number.ToInt64(n), 10)
+	}
+	return formatFloat(n.CoerceToFloat64(nk))
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -18,8 +18,10 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/pipeline"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
@@ -41,7 +43,35 @@ type (
 
 		// store is a map from instrument to set of values
 		// observed during one collection.
-		store map[*Instrument]map[attribute.Set]viewstate.Accumulator
+		store map[*Instrument]*observations
+	}
+
+	// observations are the values observed by one instrument
+	// during one collection.  Each callback observes into its own
+	// accumulator per attribute set, so that observations from
+	// multiple callbacks combine the same way as attribute sets
+	// that a view's keys filter combines: sums are added and the
+	// last gauge value is kept.
+	observations struct {
+		// accumulators is keyed by callback and attribute set.
+		accumulators map[observationKey]viewstate.Accumulator
+
+		// ordered lists accumulators in the order they were
+		// created, which defines the last gauge value.
+		ordered []orderedAccumulator
+	}
+
+	// orderedAccumulator is one entry of observations.ordered.
+	orderedAccumulator struct {
+		callback    *callbackState
+		accumulator viewstate.Accumulator
+	}
+
+	// observationKey identifies one attribute set observed
+	// during one callback invocation.
+	observationKey struct {
+		callback *callbackState
+		set      attribute.Set
 	}
 
 	// Instrument is the implementation object associated with one
@@ -51,8 +81,10 @@ type (
 		// registered with instruments from the same provider.
 		opaque interface{}
 
-		// compiled is the per-pipeline compiled instrument.
-		compiled pipeline.Register[viewstate.Instrument]
+		// compiled is the per-pipeline compiled instrument,
+		// protected by compiledLock.
+		compiledLock sync.RWMutex
+		compiled     pipeline.Register[viewstate.Instrument]
 
 		// descriptor describes the API-level instrument.
 		//
@@ -63,6 +95,10 @@ type (
 		// semantics, should the range test be based on the
 		// aggregation, not the original instrument?
 		descriptor sdkinstrument.Descriptor
+
+		// onError is called for measurements that fail the
+		// range test, if not nil.
+		onError aggregator.MeasurementErrorHandler
 	}
 
 	// contextKey is used with context.WithValue() to lookup
@@ -71,16 +107,19 @@ type (
 	contextKey struct{}
 )
 
+var errDuplicateObservation = fmt.Errorf("duplicate observation in one callback")
+
 func NewState(pipe int) *State {
 	return &State{
 		pipe:  pipe,
-		store: map[*Instrument]map[attribute.Set]viewstate.Accumulator{},
+		store: map[*Instrument]*observations{},
 	}
 }
 
 // NewInstrument returns a new Instrument; this compiles individual
-// instruments for each reader.
-func NewInstrument(desc sdkinstrument.Descriptor, opaque interface{}, compiled pipeline.Register[viewstate.Instrument]) *Instrument {
+// instruments for each reader.  The onError handler, if not nil, is
+// called for invalid measurements.
+func NewInstrument(desc sdkinstrument.Descriptor, opaque interface{}, compiled pipeline.Register[viewstate.Instrument], onError aggregator.MeasurementErrorHandler) *Instrument {
 	// Note: we return a non-nil instrument even when all readers
 	// disabled the instrument. This ensures that certain error
 	// checks still work (wrong meter, wrong callback, etc).
@@ -88,6 +127,7 @@ func NewInstrument(desc sdkinstrument.Descriptor, opaque interface{}, compiled p
 		opaque:     opaque,
 		descriptor: desc,
 		compiled:   compiled,
+		onError:    onError,
 	}
 }
 
@@ -97,15 +137,38 @@ func (inst *Instrument) SnapshotAndProcess(state *State) {
 	state.lock.Lock()
 	defer state.lock.Unlock()
 
-	for _, acc := range state.store[inst] {
+	obs := state.store[inst]
+	if obs == nil {
+		return
+	}
+	for _, entry := range obs.ordered {
+		if entry.callback.isAbandoned() {
+			// Observations of a callback that timed out
+			// are replaced by its last-known values.
+			continue
+		}
 		// SnapshotAndProcess is always final for asynchronous state, since
 		// the map is built anew for each collection.
-		acc.SnapshotAndProcess(true)
+		entry.accumulator.SnapshotAndProcess(true)
 	}
 }
 
-func (inst *Instrument) getOrCreate(cs *callbackState, attrs []attribute.KeyValue) viewstate.Accumulator {
+// SetCompiled replaces the per-pipeline compiled instruments, as
+// returned by viewstate.Compiler.Lookup after the views change.
+// Observations made after this call use the replacement;
+// observations made earlier in the same collection update the
+// outputs they were made with.
+func (inst *Instrument) SetCompiled(compiled pipeline.Register[viewstate.Instrument]) {
+	inst.compiledLock.Lock()
+	defer inst.compiledLock.Unlock()
+
+	inst.compiled = compiled
+}
+
+func (inst *Instrument) getOrCreate(cs *callbackState, set attribute.Set) viewstate.Accumulator {
+	inst.compiledLock.RLock()
 	comp := inst.compiled[cs.state.pipe]
+	inst.compiledLock.RUnlock()
 
 	if comp == nil {
 		// The view disabled the instrument.
@@ -115,19 +178,37 @@ func (inst *Instrument) getOrCreate(cs *callbackState, attrs []attribute.KeyValu
 	cs.state.lock.Lock()
 	defer cs.state.lock.Unlock()
 
-	imap, has := cs.state.store[inst]
+	if cs.isAbandoned() {
+		return nil
+	}
+
+	obs, has := cs.state.store[inst]
 
 	if !has {
-		imap = map[attribute.Set]viewstate.Accumulator{}
-		cs.state.store[inst] = imap
+		obs = &observations{
+			accumulators: map[observationKey]viewstate.Accumulator{},
+		}
+		cs.state.store[inst] = obs
 	}
 
-	aset := attribute.NewSet(attrs...)
-	se, has := imap[aset]
-	if !has {
-		se = comp.NewAccumulator(aset)
-		imap[aset] = se
+	key := observationKey{
+		callback: cs,
+		set:      set,
+	}
+	se, has := obs.accumulators[key]
+	if has {
+		// The last observation is kept.
+		doevery.TimePeriod(30*time.Second, func() {
+			otel.Handle(fmt.Errorf("%w: %s{%s}", errDuplicateObservation, inst.descriptor.Name, key.set.Encoded(attribute.DefaultEncoder())))
+		})
+		return se
 	}
+	se = comp.NewAccumulator(key.set)
+	obs.accumulators[key] = se
+	obs.ordered = append(obs.ordered, orderedAccumulator{
+		callback:    cs,
+		accumulator: se,
+	})
 	return se
 }
 
@@ -139,6 +220,10 @@ func capture[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *I
 	}
 
 	cs := lookup.(*callbackState)
+	if cs.isAbandoned() {
+		// The callback timed out, see Runner.
+		return
+	}
 	cb := cs.getCallback()
 	if cb == nil {
 		otel.Handle(fmt.Errorf("async instrument used after callback return"))
@@ -149,11 +234,17 @@ func capture[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *I
 		return
 	}
 
-	if !aggregator.RangeTest[N, Traits](value, inst.descriptor) {
+	if !aggregator.RangeTest[N, Traits](value, inst.descriptor, inst.onError) {
 		return
 	}
 
-	if acc := inst.getOrCreate(cs, attrs); acc != nil {
+	set := attribute.NewSet(attrs...)
+	if acc := inst.getOrCreate(cs, set); acc != nil {
 		acc.(viewstate.Updater[N]).Update(value)
 	}
+	cs.remember(func(replay *callbackState) {
+		if acc := inst.getOrCreate(replay, set); acc != nil {
+			acc.(viewstate.Updater[N]).Update(value)
+		}
+	})
 }
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"math"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/gauge"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/pipeline"
@@ -34,6 +36,7 @@ import (
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric/instrument"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 )
@@ -96,7 +99,7 @@ func testState(num int) *State {
 func testObserver[N number.Any, Traits number.Traits[N]](tsdk *testSDK, name string, ik sdkinstrument.Kind, opts ...instrument.Option) Observer[N, Traits] {
 	var t Traits
 	desc := test.Descriptor(name, ik, t.Kind(), opts...)
-	impl := NewInstrument(desc, tsdk, tsdk.compile(desc))
+	impl := NewInstrument(desc, tsdk, tsdk.compile(desc), nil)
 	return NewObserver[N, Traits](impl)
 }
 
@@ -420,3 +423,234 @@ func TestOutOfRangeValues(t *testing.T) {
 	require.True(t, haveNaN)
 	require.True(t, haveInf)
 }
+
+func TestMultipleCallbacks(t *testing.T) {
+	otelErrs := test.OTelErrors()
+
+	tt := testAsync("test")
+
+	c := testObserver[float64, number.Float64Traits](tt, "counter", sdkinstrument.AsyncCounter)
+	g := testObserver[float64, number.Float64Traits](tt, "gauge", sdkinstrument.AsyncGauge)
+
+	cb1, _ := NewCallback([]instrument.Asynchronous{c, g}, tt, func(ctx context.Context) {
+		c.Observe(ctx, 10)
+		g.Observe(ctx, 10)
+	})
+	cb2, _ := NewCallback([]instrument.Asynchronous{c, g}, tt, func(ctx context.Context) {
+		c.Observe(ctx, 1)
+		g.Observe(ctx, 1)
+	})
+
+	// Each callback observes its own attribute set, as well.
+	cb3, _ := NewCallback([]instrument.Asynchronous{c}, tt, func(ctx context.Context) {
+		c.Observe(ctx, 100, attribute.String("a", "b"))
+	})
+
+	for i := 0; i < 2; i++ {
+		state := testState(i)
+
+		cb1.Run(context.Background(), state)
+		cb2.Run(context.Background(), state)
+		cb3.Run(context.Background(), state)
+
+		c.inst.SnapshotAndProcess(state)
+		g.inst.SnapshotAndProcess(state)
+
+		// Sums are added across callbacks, the last gauge
+		// value is kept.
+		test.RequireEqualMetrics(
+			t,
+			test.CollectScope(
+				t,
+				tt.compilers[i].Collectors(),
+				testSequence,
+			),
+			test.Instrument(
+				c.inst.descriptor,
+				test.Point(startTime, endTime, sum.NewMonotonicFloat64(11), aggregation.CumulativeTemporality),
+				test.Point(startTime, endTime, sum.NewMonotonicFloat64(100), aggregation.CumulativeTemporality, attribute.String("a", "b")),
+			),
+			test.Instrument(
+				g.inst.descriptor,
+				test.Point(startTime, endTime, gauge.NewFloat64(1), aggregation.CumulativeTemporality),
+			),
+		)
+	}
+	require.Nil(t, *otelErrs)
+}
+
+func TestDuplicateObservation(t *testing.T) {
+	otelErrs := test.OTelErrors()
+
+	tt := testAsync("test")
+
+	c := testObserver[float64, number.Float64Traits](tt, "counter", sdkinstrument.AsyncCounter)
+
+	cb, _ := NewCallback([]instrument.Asynchronous{c}, tt, func(ctx context.Context) {
+		c.Observe(ctx, 10)
+		c.Observe(ctx, 20)
+	})
+
+	state := testState(0)
+	cb.Run(context.Background(), state)
+	c.inst.SnapshotAndProcess(state)
+
+	// The last observation is kept.
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(
+			t,
+			tt.compilers[0].Collectors(),
+			testSequence,
+		),
+		test.Instrument(
+			c.inst.descriptor,
+			test.Point(startTime, endTime, sum.NewMonotonicFloat64(20), aggregation.CumulativeTemporality),
+		),
+	)
+
+	require.Equal(t, 1, len(*otelErrs))
+	require.ErrorIs(t, (*otelErrs)[0], errDuplicateObservation)
+}
+
+func TestRunnerConcurrency(t *testing.T) {
+	otelErrs := test.OTelErrors()
+
+	tt := testAsync("test")
+
+	c := testObserver[int64, number.Int64Traits](tt, "counter", sdkinstrument.AsyncCounter)
+
+	// Each callback waits for the others to start, which
+	// requires them to run concurrently.
+	var started sync.WaitGroup
+	started.Add(3)
+
+	var callbacks []*Callback
+	for _, value := range []int64{1, 10, 100} {
+		value := value
+		cb, _ := NewCallback([]instrument.Asynchronous{c}, tt, func(ctx context.Context) {
+			started.Done()
+			started.Wait()
+			c.Observe(ctx, value)
+		})
+		callbacks = append(callbacks, cb)
+	}
+
+	state := testState(0)
+	Runner{Concurrency: 3}.Run(context.Background(), callbacks, state)
+	c.inst.SnapshotAndProcess(state)
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(
+			t,
+			tt.compilers[0].Collectors(),
+			testSequence,
+		),
+		test.Instrument(
+			c.inst.descriptor,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(111), aggregation.CumulativeTemporality),
+		),
+	)
+	require.Nil(t, *otelErrs)
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	otelErrs := test.OTelErrors()
+
+	tt := testAsync("test")
+
+	c := testObserver[int64, number.Int64Traits](tt, "counter", sdkinstrument.AsyncCounter)
+	g := testObserver[int64, number.Int64Traits](tt, "gauge", sdkinstrument.AsyncGauge)
+
+	var release, finished chan struct{}
+	var value int64
+	var calls int
+
+	cb, _ := NewCallback([]instrument.Asynchronous{c, g}, tt, func(ctx context.Context) {
+		calls++
+		c.Observe(ctx, value, attribute.String("a", "b"))
+		g.Observe(ctx, value)
+		if release != nil {
+			<-release
+			// Observations after the timeout are dropped.
+			c.Observe(ctx, value)
+			close(finished)
+		}
+	})
+	// A second callback that completes is not affected.
+	other, _ := NewCallback([]instrument.Asynchronous{c}, tt, func(ctx context.Context) {
+		c.Observe(ctx, 1)
+	})
+
+	runner := Runner{
+		Timeout:     10 * time.Millisecond,
+		Concurrency: 2,
+	}
+
+	expect := func(cval, gval int64) {
+		test.RequireEqualMetrics(
+			t,
+			test.CollectScope(
+				t,
+				tt.compilers[0].Collectors(),
+				testSequence,
+			),
+			test.Instrument(
+				c.inst.descriptor,
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(cval), aggregation.CumulativeTemporality, attribute.String("a", "b")),
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(1), aggregation.CumulativeTemporality),
+			),
+			test.Instrument(
+				g.inst.descriptor,
+				test.Point(startTime, endTime, gauge.NewInt64(gval), aggregation.CumulativeTemporality),
+			),
+		)
+	}
+
+	collect := func() {
+		state := testState(0)
+		runner.Run(context.Background(), []*Callback{cb, other}, state)
+		c.inst.SnapshotAndProcess(state)
+		g.inst.SnapshotAndProcess(state)
+	}
+
+	// The first run completes.
+	value = 10
+	collect()
+	expect(10, 10)
+	require.Nil(t, *otelErrs)
+
+	// The second run times out, the first run's values are used.
+	value = 20
+	release = make(chan struct{})
+	finished = make(chan struct{})
+	collect()
+	expect(10, 10)
+
+	require.Equal(t, 1, len(*otelErrs))
+	require.ErrorIs(t, (*otelErrs)[0], context.DeadlineExceeded)
+
+	// The callback is not called again while the abandoned run
+	// continues, the first run's values are used.
+	collect()
+	expect(10, 10)
+	require.Equal(t, 2, calls)
+
+	require.Equal(t, 2, len(*otelErrs))
+	require.ErrorIs(t, (*otelErrs)[1], errCallbackInProgress)
+
+	close(release)
+	<-finished
+	require.Equal(t, 2, len(*otelErrs))
+	require.Eventually(t, func() bool {
+		return cb.start(testState(0))
+	}, time.Second, time.Millisecond)
+	cb.stop(testState(0))
+
+	// The third run completes.
+	value = 30
+	release = nil
+	collect()
+	expect(30, 30)
+}
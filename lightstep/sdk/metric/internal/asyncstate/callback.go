@@ -31,6 +31,20 @@ type Callback struct {
 	// instruments are the set of instruments permitted to be used
 	// inside this callback.
 	instruments map[*Instrument]struct{}
+
+	// lastLock protects last and running.
+	lastLock sync.Mutex
+
+	// last holds the observations made by the last completed
+	// run of this callback, per pipeline, replayed when a later
+	// run times out.  This is only maintained by a Runner with
+	// a timeout.
+	last map[int][]func(*callbackState)
+
+	// running is true for each pipeline while a run with a
+	// timeout has not returned, including after it is
+	// abandoned.
+	running map[int]bool
 }
 
 // NewCallback returns a new Callback; this checks that each of the
@@ -67,14 +81,66 @@ func NewCallback(instruments []instrument.Asynchronous, opaque interface{}, func
 // Run executes the callback after setting up the appropriate context
 // for a specific reader.
 func (c *Callback) Run(ctx context.Context, state *State) {
-	cp := &callbackState{
-		callback: c,
-		state:    state,
-	}
+	cp := c.newState(state, false)
 	c.function(context.WithValue(ctx, contextKey{}, cp))
 	cp.invalidate()
 }
 
+func (c *Callback) newState(state *State, recording bool) *callbackState {
+	return &callbackState{
+		callback:  c,
+		state:     state,
+		recording: recording,
+	}
+}
+
+// saveLast stores the observations of a completed run.
+func (c *Callback) saveLast(cp *callbackState) {
+	c.lastLock.Lock()
+	defer c.lastLock.Unlock()
+	if c.last == nil {
+		c.last = map[int][]func(*callbackState){}
+	}
+	c.last[cp.state.pipe] = cp.recorded
+}
+
+// start marks the beginning of a run with a timeout for the pipeline
+// of state, returning false when the previous run has not returned.
+func (c *Callback) start(state *State) bool {
+	c.lastLock.Lock()
+	defer c.lastLock.Unlock()
+	if c.running[state.pipe] {
+		return false
+	}
+	if c.running == nil {
+		c.running = map[int]bool{}
+	}
+	c.running[state.pipe] = true
+	return true
+}
+
+// stop marks the return of a run started by start.
+func (c *Callback) stop(state *State) {
+	c.lastLock.Lock()
+	defer c.lastLock.Unlock()
+	delete(c.running, state.pipe)
+}
+
+// replayLast repeats the observations of the last completed run
+// into state.
+func (c *Callback) replayLast(state *State) {
+	c.lastLock.Lock()
+	last := c.last[state.pipe]
+	c.lastLock.Unlock()
+
+	replay := &callbackState{
+		state: state,
+	}
+	for _, f := range last {
+		f(replay)
+	}
+}
+
 // callbackState is used to lookup the current callback and
 // pipeline from within an executing callback function.
 type callbackState struct {
@@ -87,6 +153,16 @@ type callbackState struct {
 
 	// state is a single collection of data.
 	state *State
+
+	// abandoned is set when the callback exceeds its timeout,
+	// after which its observations are ignored.
+	abandoned bool
+
+	// recording indicates to save observations in recorded.
+	recording bool
+
+	// recorded lists functions that repeat each observation.
+	recorded []func(*callbackState)
 }
 
 func (cp *callbackState) invalidate() {
@@ -100,3 +176,46 @@ func (cp *callbackState) getCallback() *Callback {
 	defer cp.lock.Unlock()
 	return cp.callback
 }
+
+// abandon invalidates the callback and causes its observations to
+// be ignored, returning false if the callback had already finished.
+// This holds the state lock so that getOrCreate does not race with
+// the abandoned flag.
+func (cp *callbackState) abandon() bool {
+	cp.state.lock.Lock()
+	defer cp.state.lock.Unlock()
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if cp.callback == nil {
+		return false
+	}
+	cp.callback = nil
+	cp.abandoned = true
+	return true
+}
+
+func (cp *callbackState) isAbandoned() bool {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	return cp.abandoned
+}
+
+// remember saves a function that repeats one observation, if
+// the Runner requested it.
+func (cp *callbackState) remember(f func(*callbackState)) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if !cp.recording || cp.abandoned {
+		return
+	}
+	cp.recorded = append(cp.recorded, f)
+}
+
+// finish invalidates the callback and reports whether it completed
+// before being abandoned.
+func (cp *callbackState) finish() bool {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	cp.callback = nil
+	return !cp.abandoned
+}
@@ -0,0 +1,133 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package asyncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/asyncstate"
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
+	"go.opentelemetry.io/otel"
+)
+
+var errCallbackInProgress = fmt.Errorf("asynchronous callback skipped: its abandoned run has not returned")
+
+// Runner executes the callbacks of one collection.  The zero value
+// runs callbacks sequentially without a timeout.
+type Runner struct {
+	// Timeout limits the duration of each callback, if positive.
+	// A callback that exceeds its timeout is abandoned: its
+	// observations are ignored and the observations from its last
+	// completed run are used instead.  An abandoned callback
+	// keeps running until its function returns, and it is not
+	// run again for the same pipeline until then.
+	Timeout time.Duration
+
+	// Concurrency is the maximum number of callbacks that
+	// execute at once, values less than 2 run sequentially.
+	Concurrency int
+}
+
+// Run executes callbacks for state, returning when every callback
+// has either finished or been abandoned.
+func (r Runner) Run(ctx context.Context, callbacks []*Callback, state *State) {
+	if r.Timeout <= 0 && r.Concurrency <= 1 {
+		for _, cb := range callbacks {
+			if ctx.Err() != nil {
+				return
+			}
+			cb.Run(ctx, state)
+		}
+		return
+	}
+
+	concurrency := r.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, cb := range callbacks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		wg.Add(1)
+		go func(cb *Callback) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.runOne(ctx, cb, state)
+		}(cb)
+	}
+}
+
+// runOne executes a single callback, applying the timeout.
+func (r Runner) runOne(ctx context.Context, cb *Callback, state *State) {
+	if r.Timeout <= 0 {
+		cb.Run(ctx, state)
+		return
+	}
+
+	if !cb.start(state) {
+		// The run abandoned by an earlier collection has not
+		// returned.  Rather than start another goroutine for
+		// the same callback, which would be unbounded while
+		// the callback blocks, its last observations are used.
+		doevery.TimePeriod(30*time.Second, func() {
+			otel.Handle(errCallbackInProgress)
+		})
+		cb.replayLast(state)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	cp := cb.newState(state, true)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer cb.stop(state)
+		cb.function(context.WithValue(ctx, contextKey{}, cp))
+		if cp.finish() {
+			cb.saveLast(cp)
+		}
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	if !cp.abandon() {
+		// Finished as the timeout expired.
+		<-done
+		return
+	}
+
+	doevery.TimePeriod(30*time.Second, func() {
+		otel.Handle(fmt.Errorf("asynchronous callback abandoned: %w", ctx.Err()))
+	})
+
+	cb.replayLast(state)
+}
@@ -0,0 +1,100 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+const (
+	// sketchPrecision is the number of hash bits that select a
+	// register, for a standard error of about 3%.
+	sketchPrecision = 10
+
+	// sketchRegisters is the number of registers, packed four
+	// per word.
+	sketchRegisters = 1 << sketchPrecision
+)
+
+// cardinalitySketch is a HyperLogLog estimate of the number of
+// distinct attribute-set fingerprints, see
+// CardinalityEstimationProvider.  Add is safe to call concurrently
+// and does not allocate.
+type cardinalitySketch struct {
+	// words holds the 8-bit registers, updated atomically.
+	words [sketchRegisters / 4]uint32
+}
+
+// mixFingerprint finalizes an attribute fingerprint, which is a sum
+// of mixed values, so that all of its bits are well distributed.
+func mixFingerprint(fp uint64) uint64 {
+	fp ^= fp >> 33
+	fp *= 0xff51afd7ed558ccd
+	fp ^= fp >> 33
+	fp *= 0xc4ceb9fe1a85ec53
+	fp ^= fp >> 33
+	return fp
+}
+
+// Add records an attribute-set fingerprint.
+func (s *cardinalitySketch) Add(fp uint64) {
+	h := mixFingerprint(fp)
+	idx := h >> (64 - sketchPrecision)
+	// The rank is the position of the first one bit in the
+	// remaining bits, at most 64-sketchPrecision+1.
+	rank := uint32(bits.LeadingZeros64(h<<sketchPrecision|1<<(sketchPrecision-1))) + 1
+
+	word := &s.words[idx/4]
+	shift := (idx % 4) * 8
+
+	for {
+		old := atomic.LoadUint32(word)
+		if (old>>shift)&0xff >= rank {
+			return
+		}
+		updated := old&^(0xff<<shift) | rank<<shift
+		if atomic.CompareAndSwapUint32(word, old, updated) {
+			return
+		}
+	}
+}
+
+// Estimate returns the estimated number of distinct fingerprints.
+func (s *cardinalitySketch) Estimate() int64 {
+	const m = float64(sketchRegisters)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	var sum float64
+	var zeros int
+	for i := range s.words {
+		word := atomic.LoadUint32(&s.words[i])
+		for j := 0; j < 4; j++ {
+			reg := (word >> (j * 8)) & 0xff
+			if reg == 0 {
+				zeros++
+			}
+			sum += math.Ldexp(1, -int(reg))
+		}
+	}
+	est := alpha * m * m / sum
+
+	// Use linear counting for small cardinalities.
+	if est <= 2.5*m && zeros != 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+	return int64(est + 0.5)
+}
@@ -0,0 +1,36 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncstate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestCardinalitySketch(t *testing.T) {
+	for _, size := range []int{0, 1, 10, 1000, 100000} {
+		var sketch cardinalitySketch
+
+		for i := 0; i < size; i++ {
+			fp := fingerprintAttributes([]attribute.KeyValue{attribute.Int("i", i)})
+			// Repeats do not change the estimate.
+			sketch.Add(fp)
+			sketch.Add(fp)
+		}
+		require.InEpsilon(t, float64(size)+1, float64(sketch.Estimate())+1, 0.1, "size %d", size)
+	}
+}
@@ -16,8 +16,10 @@ package syncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk
 
 import (
 	"context"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric/instrument"
 	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
@@ -37,6 +39,18 @@ var (
 	_ syncint64.UpDownCounter   = Counter[int64, number.Int64Traits]{}
 	_ syncfloat64.Counter       = Counter[float64, number.Float64Traits]{}
 	_ syncfloat64.UpDownCounter = Counter[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.SetCounter[int64]   = Counter[int64, number.Int64Traits]{}
+	_ sdkinstrument.SetCounter[float64] = Counter[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.BatchCounter[int64]   = Counter[int64, number.Int64Traits]{}
+	_ sdkinstrument.BatchCounter[float64] = Counter[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.BackfillCounter[int64]   = Counter[int64, number.Int64Traits]{}
+	_ sdkinstrument.BackfillCounter[float64] = Counter[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.Enabler = Counter[int64, number.Int64Traits]{}
+	_ sdkinstrument.Enabler = Counter[float64, number.Float64Traits]{}
 )
 
 // NewCounter returns a value that implements the Counter and UpDownCounter APIs.
@@ -48,3 +62,28 @@ func NewCounter[N number.Any, Traits number.Traits[N]](inst *Instrument) Counter
 func (c Counter[N, Traits]) Add(ctx context.Context, incr N, attrs ...attribute.KeyValue) {
 	capture[N, Traits](ctx, c.inst, incr, attrs)
 }
+
+// AddSet increments a Counter or UpDownCounter using an existing
+// attribute.Set, which avoids fingerprinting and comparing the
+// attribute list of each call.
+func (c Counter[N, Traits]) AddSet(ctx context.Context, incr N, set attribute.Set) {
+	captureSet[N, Traits](ctx, c.inst, incr, &set)
+}
+
+// AddBatch increments a Counter or UpDownCounter once for each
+// measurement in the batch, acquiring the state of each distinct
+// attribute set once.
+func (c Counter[N, Traits]) AddBatch(ctx context.Context, batch []sdkinstrument.Measurement[N]) {
+	captureBatch[N, Traits](ctx, c.inst, batch)
+}
+
+// AddWithStartTime increments a Counter or UpDownCounter with a
+// measurement that began at an explicit time, for backfilling.
+func (c Counter[N, Traits]) AddWithStartTime(ctx context.Context, incr N, start time.Time, attrs ...attribute.KeyValue) {
+	captureWithStartTime[N, Traits](ctx, c.inst, incr, start, attrs)
+}
+
+// SetEnabled implements sdkinstrument.Enabler.
+func (c Counter[N, Traits]) SetEnabled(enabled bool) {
+	c.inst.SetEnabled(enabled)
+}
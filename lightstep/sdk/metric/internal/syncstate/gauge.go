@@ -0,0 +1,54 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
+
+import (
+	"context"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// Gauge is a synchronous instrument having a Record() method.
+type Gauge[N number.Any, Traits number.Traits[N]] struct {
+	instrument.Synchronous // Note: wasted space
+
+	inst *Instrument
+}
+
+var (
+	_ sdkinstrument.Gauge[int64]   = Gauge[int64, number.Int64Traits]{}
+	_ sdkinstrument.Gauge[float64] = Gauge[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.Enabler = Gauge[int64, number.Int64Traits]{}
+	_ sdkinstrument.Enabler = Gauge[float64, number.Float64Traits]{}
+)
+
+// NewGauge returns a value that implements the sdkinstrument.Gauge API.
+func NewGauge[N number.Any, Traits number.Traits[N]](inst *Instrument) Gauge[N, Traits] {
+	return Gauge[N, Traits]{inst: inst}
+}
+
+// Record sets the current value of the Gauge.
+func (g Gauge[N, Traits]) Record(ctx context.Context, value N, attrs ...attribute.KeyValue) {
+	capture[N, Traits](ctx, g.inst, value, attrs)
+}
+
+// SetEnabled implements sdkinstrument.Enabler.
+func (g Gauge[N, Traits]) SetEnabled(enabled bool) {
+	g.inst.SetEnabled(enabled)
+}
@@ -16,8 +16,10 @@ package syncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk
 
 import (
 	"context"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric/instrument"
 	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
@@ -35,6 +37,21 @@ type Histogram[N number.Any, Traits number.Traits[N]] struct {
 var (
 	_ syncint64.Histogram   = Histogram[int64, number.Int64Traits]{}
 	_ syncfloat64.Histogram = Histogram[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.SetHistogram[int64]   = Histogram[int64, number.Int64Traits]{}
+	_ sdkinstrument.SetHistogram[float64] = Histogram[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.WeightedHistogram[int64]   = Histogram[int64, number.Int64Traits]{}
+	_ sdkinstrument.WeightedHistogram[float64] = Histogram[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.BucketHistogram[int64]   = Histogram[int64, number.Int64Traits]{}
+	_ sdkinstrument.BucketHistogram[float64] = Histogram[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.DurationHistogram = Histogram[int64, number.Int64Traits]{}
+	_ sdkinstrument.DurationHistogram = Histogram[float64, number.Float64Traits]{}
+
+	_ sdkinstrument.Enabler = Histogram[int64, number.Int64Traits]{}
+	_ sdkinstrument.Enabler = Histogram[float64, number.Float64Traits]{}
 )
 
 // NewCounter returns a value that implements the Histogram API.
@@ -46,3 +63,37 @@ func NewHistogram[N number.Any, Traits number.Traits[N]](inst *Instrument) Histo
 func (h Histogram[N, Traits]) Record(ctx context.Context, incr N, attrs ...attribute.KeyValue) {
 	capture[N, Traits](ctx, h.inst, incr, attrs)
 }
+
+// RecordSet records a Histogram observation using an existing
+// attribute.Set.
+func (h Histogram[N, Traits]) RecordSet(ctx context.Context, incr N, set attribute.Set) {
+	captureSet[N, Traits](ctx, h.inst, incr, &set)
+}
+
+// RecordWeighted records a Histogram observation that stands for
+// weight identical observations.
+func (h Histogram[N, Traits]) RecordWeighted(ctx context.Context, value N, weight uint64, attrs ...attribute.KeyValue) {
+	captureWeighted[N, Traits](ctx, h.inst, value, weight, attrs)
+}
+
+// RecordBucket merges count values summing to sum into the bucket
+// at index of explicit-bucket histogram outputs.
+func (h Histogram[N, Traits]) RecordBucket(ctx context.Context, index int, count uint64, sum N, attrs ...attribute.KeyValue) {
+	captureBucket[N, Traits](ctx, h.inst, index, count, sum, attrs)
+}
+
+// RecordDuration records a Histogram observation of a duration in
+// the unit of the instrument, see sdkinstrument.DurationValue.
+func (h Histogram[N, Traits]) RecordDuration(ctx context.Context, d time.Duration, attrs ...attribute.KeyValue) {
+	if h.inst == nil {
+		// Instrument was completely disabled by the view.
+		return
+	}
+	value := sdkinstrument.DurationValue(h.inst.descriptor.Unit, d)
+	capture[N, Traits](ctx, h.inst, N(value), attrs)
+}
+
+// SetEnabled implements sdkinstrument.Enabler.
+func (h Histogram[N, Traits]) SetEnabled(enabled bool) {
+	h.inst.SetEnabled(enabled)
+}
@@ -0,0 +1,307 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncstate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// IngestQueue is a bounded multi-producer, single-consumer ring
+// buffer of measurements.  Instruments that use the queue enqueue
+// each measurement without taking an exclusive lock, and a
+// background goroutine drains the queue into the instruments'
+// aggregators.  Every kind of synchronous measurement is enqueued, so
+// that the measurements of a goroutine are aggregated in order.
+// Measurements that do not fit are dropped.
+//
+// Each slot carries a sequence number, so that producers claim slots
+// by advancing head with compare-and-swap and publish them by
+// storing the sequence, while the consumer advances tail.
+type IngestQueue struct {
+	// head is the position of the next slot to claim, advanced
+	// by producers.
+	head uint64
+
+	// sleeping is 1 while the consumer waits for wake.
+	sleeping int32
+
+	// stopped is 1 after Stop, when measurements are no longer
+	// enqueued.
+	stopped int32
+
+	// producers is read-locked by the calls to enqueue in
+	// progress, so that Stop waits for measurements that were
+	// admitted before it.  A WaitGroup does not allow producers
+	// to register while Stop waits.
+	producers sync.RWMutex
+
+	// drainLock serializes consumers: the background goroutine,
+	// Flush, and Stop.
+	drainLock sync.Mutex
+
+	// tail is the position of the next slot to drain, protected
+	// by drainLock.
+	tail uint64
+
+	mask  uint64
+	slots []ingestSlot
+
+	wake chan struct{}
+	done chan struct{}
+	exit chan struct{}
+	once sync.Once
+}
+
+// ingestSlot is one entry of the ring.  seq equals the slot's
+// position when it is free to claim and the position plus one when
+// its measurement is published.
+type ingestSlot struct {
+	seq  uint64
+	item ingestItem
+}
+
+// ingestKind selects the capture function that aggregates an
+// ingestItem.
+type ingestKind uint8
+
+const (
+	ingestAttrs     ingestKind = iota // see capture
+	ingestSet                         // see captureSet and captureBatch
+	ingestWeighted                    // see captureWeighted
+	ingestBucket                      // see captureBucket
+	ingestStartTime                   // see captureWithStartTime
+)
+
+// ingestItem is a measurement waiting in the queue.
+type ingestItem struct {
+	kind  ingestKind
+	ctx   context.Context
+	inst  *Instrument
+	num   number.Number
+	attrs []attribute.KeyValue
+	set   attribute.Set
+
+	// weight is the weight of ingestWeighted items and the
+	// count of ingestBucket items.
+	weight uint64
+
+	// index is the bucket of ingestBucket items.
+	index int
+
+	// start is the start time of ingestStartTime items.
+	start time.Time
+}
+
+// IngestQueueProvider is implemented by the opaque value passed to
+// NewInstrument when measurements should be enqueued on a shared
+// IngestQueue.
+type IngestQueueProvider interface {
+	// IngestQueue returns the queue, nil if measurements are
+	// aggregated synchronously.
+	IngestQueue() *IngestQueue
+}
+
+// NewIngestQueue returns a queue with room for at least size
+// measurements and starts the goroutine that drains it.
+func NewIngestQueue(size int) *IngestQueue {
+	q := newIngestQueue(size)
+	go q.run()
+	return q
+}
+
+// newIngestQueue returns a queue that is drained only by Flush.
+func newIngestQueue(size int) *IngestQueue {
+	capacity := uint64(1)
+	for capacity < uint64(size) {
+		capacity <<= 1
+	}
+	q := &IngestQueue{
+		mask:  capacity - 1,
+		slots: make([]ingestSlot, capacity),
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+		exit:  make(chan struct{}),
+	}
+	for i := range q.slots {
+		q.slots[i].seq = uint64(i)
+	}
+	return q
+}
+
+// run drains the queue until Stop.
+func (q *IngestQueue) run() {
+	defer close(q.exit)
+	for {
+		q.Flush()
+
+		// Producers wake the consumer after publishing when
+		// they observe sleeping, so check once more after
+		// setting it.
+		atomic.StoreInt32(&q.sleeping, 1)
+		if q.ready() {
+			atomic.StoreInt32(&q.sleeping, 0)
+			continue
+		}
+		select {
+		case <-q.wake:
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// ready returns true when the next slot to drain is published.
+func (q *IngestQueue) ready() bool {
+	q.drainLock.Lock()
+	defer q.drainLock.Unlock()
+	slot := &q.slots[q.tail&q.mask]
+	return atomic.LoadUint64(&slot.seq) == q.tail+1
+}
+
+// Flush aggregates the measurements that were published before the
+// call.  The SDK calls this before each collection, so that
+// collections include the measurements that were waiting.
+func (q *IngestQueue) Flush() {
+	q.drainLock.Lock()
+	defer q.drainLock.Unlock()
+
+	end := atomic.LoadUint64(&q.head)
+	for q.tail != end {
+		slot := &q.slots[q.tail&q.mask]
+		if atomic.LoadUint64(&slot.seq) != q.tail+1 {
+			// The slot was claimed but not yet published.
+			return
+		}
+		item := slot.item
+		slot.item = ingestItem{}
+		atomic.StoreUint64(&slot.seq, q.tail+q.mask+1)
+		q.tail++
+
+		item.inst.apply(&item)
+	}
+}
+
+// Stop drains the queue and stops its goroutine.  Afterward,
+// instruments aggregate measurements synchronously.
+func (q *IngestQueue) Stop() {
+	q.once.Do(func() {
+		atomic.StoreInt32(&q.stopped, 1)
+		close(q.done)
+		<-q.exit
+
+		// Producers that observed stopped == 0 publish their
+		// measurement before leaving enqueue.
+		q.producers.Lock()
+		q.producers.Unlock() //nolint:staticcheck // Waits for producers.
+		q.Flush()
+	})
+}
+
+// enqueue adds a measurement with a copy of attrs to the queue,
+// returning false when the queue was stopped.  When the queue is
+// full, the measurement is dropped.
+func (q *IngestQueue) enqueue(item ingestItem, attrs []attribute.KeyValue) bool {
+	// Register before checking stopped, so that either Stop
+	// waits for this call or this call observes Stop.
+	q.producers.RLock()
+	defer q.producers.RUnlock()
+
+	if atomic.LoadInt32(&q.stopped) != 0 {
+		return false
+	}
+	for {
+		pos := atomic.LoadUint64(&q.head)
+		slot := &q.slots[pos&q.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+
+		switch {
+		case seq == pos:
+			if !atomic.CompareAndSwapUint64(&q.head, pos, pos+1) {
+				continue
+			}
+			item.attrs = append([]attribute.KeyValue(nil), attrs...)
+			slot.item = item
+			atomic.StoreUint64(&slot.seq, pos+1)
+
+			if atomic.CompareAndSwapInt32(&q.sleeping, 1, 0) {
+				select {
+				case q.wake <- struct{}{}:
+				default:
+				}
+			}
+			return true
+		case seq < pos:
+			// The slot has not been drained since the
+			// previous lap: the queue is full.
+			item.inst.dropped(item.num)
+			return true
+		}
+		// Another producer claimed the slot, try again.
+	}
+}
+
+// dropped counts a measurement that did not fit in the queue.
+func (inst *Instrument) dropped(num number.Number) {
+	atomic.AddInt64(&inst.ingestDropped, 1)
+
+	doevery.TimePeriod(time.Minute, func() {
+		otel.Handle(fmt.Errorf("%s: %w", inst.descriptor.Name, aggregator.ErrIngestDropped))
+	})
+	if inst.onError != nil {
+		inst.onError(inst.descriptor, num, aggregator.ErrIngestDropped)
+	}
+}
+
+// apply aggregates a measurement that was dequeued.
+func (inst *Instrument) apply(item *ingestItem) {
+	switch inst.descriptor.NumberKind {
+	case number.Int64Kind:
+		applyItem[int64, number.Int64Traits](item)
+	case number.Float64Kind:
+		applyItem[float64, number.Float64Traits](item)
+	case number.Uint64Kind:
+		applyItem[uint64, number.Uint64Traits](item)
+	}
+}
+
+// applyItem aggregates a dequeued measurement using the capture
+// function of its kind.
+func applyItem[N number.Any, Traits number.Traits[N]](item *ingestItem) {
+	var traits Traits
+	num := traits.FromNumber(item.num)
+
+	switch item.kind {
+	case ingestAttrs:
+		captureNow(item.ctx, item.inst, num, item.attrs)
+	case ingestSet:
+		captureSetNow(item.ctx, item.inst, num, &item.set)
+	case ingestWeighted:
+		captureWeightedNow(item.ctx, item.inst, num, item.weight, item.attrs)
+	case ingestBucket:
+		captureBucketNow[N, Traits](item.ctx, item.inst, item.index, item.weight, num, item.attrs)
+	case ingestStartTime:
+		captureWithStartTimeNow(item.ctx, item.inst, num, item.start, item.attrs)
+	}
+}
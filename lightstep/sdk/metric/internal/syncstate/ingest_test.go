@@ -0,0 +1,228 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncstate
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/pipeline"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/test"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+)
+
+type testIngestProvider struct {
+	queue *IngestQueue
+}
+
+func (tp testIngestProvider) IngestQueue() *IngestQueue {
+	return tp.queue
+}
+
+// newIngestCounter returns a counter that uses queue and its
+// compiler.
+func newIngestCounter(queue *IngestQueue, onError aggregator.MeasurementErrorHandler) (*Instrument, Counter[int64, number.Int64Traits], *viewstate.Compiler) {
+	vc := viewstate.New(instrumentation.Library{
+		Name: "testlib",
+	}, view.New("test"))
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+
+	inst := NewInstrument(desc, testIngestProvider{queue}, pipes, onError)
+	return inst, NewCounter[int64, number.Int64Traits](inst), vc
+}
+
+func TestIngestQueueDrop(t *testing.T) {
+	ctx := context.Background()
+
+	// Without the background goroutine, the queue is drained by
+	// Flush only.
+	queue := newIngestQueue(2)
+
+	var dropped []int64
+	onError := func(_ sdkinstrument.Descriptor, value number.Number, reason error) {
+		require.Equal(t, aggregator.ErrIngestDropped, reason)
+		dropped = append(dropped, number.ToInt64(value))
+	}
+	inst, cntr, vc := newIngestCounter(queue, onError)
+
+	cntr.Add(ctx, 1, testAttr.Int(1))
+	cntr.Add(ctx, 2, testAttr.Int(1))
+	cntr.Add(ctx, 4, testAttr.Int(1))
+
+	// Nothing is aggregated before the queue is flushed.
+	require.Equal(t, 0, len(inst.current))
+	require.Equal(t, []int64{4}, dropped)
+	require.Equal(t, int64(1), inst.Stats().Dropped)
+
+	queue.Flush()
+	inst.SnapshotAndProcess()
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vc.Collectors(), testSequence),
+		test.Instrument(
+			inst.descriptor,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), aggregation.CumulativeTemporality, testAttr.Int(1)),
+		),
+	)
+
+	// The drained slots are reused.
+	cntr.Add(ctx, 8, testAttr.Int(1))
+	cntr.Add(ctx, 16, testAttr.Int(1))
+	queue.Flush()
+	inst.SnapshotAndProcess()
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vc.Collectors(), testSequence),
+		test.Instrument(
+			inst.descriptor,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(27), aggregation.CumulativeTemporality, testAttr.Int(1)),
+		),
+	)
+	require.Equal(t, []int64{4}, dropped)
+}
+
+func TestIngestQueueConcurrency(t *testing.T) {
+	const (
+		numRoutines = 10
+		numUpdates  = 1e4
+	)
+	ctx := context.Background()
+	queue := NewIngestQueue(64)
+	inst, cntr, vc := newIngestCounter(queue, nil)
+
+	var writers sync.WaitGroup
+	writers.Add(numRoutines)
+	for i := 0; i < numRoutines; i++ {
+		go func(i int) {
+			defer writers.Done()
+			for j := 0; j < numUpdates/numRoutines; j++ {
+				cntr.Add(ctx, 1, testAttr.Int(i%3))
+			}
+		}(i)
+	}
+	writers.Wait()
+
+	queue.Stop()
+
+	// Every measurement was aggregated or dropped.
+	require.Equal(t, int64(numUpdates), ingestTotal(t, inst, vc)+inst.Stats().Dropped)
+
+	// After Stop, measurements are aggregated synchronously.
+	cntr.Add(ctx, 1, testAttr.Int(0))
+	require.Equal(t, int64(numUpdates)+1, ingestTotal(t, inst, vc)+inst.Stats().Dropped)
+}
+
+func TestIngestQueueStopConcurrency(t *testing.T) {
+	const (
+		numRoutines = 10
+		numUpdates  = 1e4
+	)
+	ctx := context.Background()
+	queue := NewIngestQueue(64)
+	inst, cntr, vc := newIngestCounter(queue, nil)
+
+	var writers sync.WaitGroup
+	writers.Add(numRoutines + 1)
+	for i := 0; i < numRoutines; i++ {
+		go func(i int) {
+			defer writers.Done()
+			for j := 0; j < numUpdates/numRoutines; j++ {
+				cntr.Add(ctx, 1, testAttr.Int(i%3))
+			}
+		}(i)
+	}
+	// Stop while the writers are running: measurements made
+	// before and after Stop are all accounted for.
+	go func() {
+		defer writers.Done()
+		queue.Stop()
+	}()
+	writers.Wait()
+
+	require.Equal(t, int64(numUpdates), ingestTotal(t, inst, vc)+inst.Stats().Dropped)
+}
+
+// ingestTotal returns the sum of the points of inst.
+func ingestTotal(t *testing.T, inst *Instrument, vc *viewstate.Compiler) int64 {
+	inst.SnapshotAndProcess()
+
+	var total int64
+	for _, pt := range test.CollectScope(t, vc.Collectors(), testSequence)[0].Points {
+		total += number.ToInt64(pt.Aggregation.(aggregation.Sum).Sum())
+	}
+	return total
+}
+
+// TestIngestQueueKinds tests that every kind of measurement is
+// enqueued, and that Reset discards the measurements waiting in the
+// queue.
+func TestIngestQueueKinds(t *testing.T) {
+	ctx := context.Background()
+	queue := newIngestQueue(16)
+	inst, cntr, vc := newIngestCounter(queue, nil)
+
+	set := attribute.NewSet(testAttr.Int(1))
+	cntr.Add(ctx, 1, testAttr.Int(1))
+	cntr.AddSet(ctx, 2, set)
+	cntr.AddBatch(ctx, []sdkinstrument.Measurement[int64]{
+		{Value: 4, Attrs: set},
+		{Value: 8, Attrs: set},
+	})
+	cntr.AddWithStartTime(ctx, 16, startTime, testAttr.Int(1))
+
+	hdesc := test.Descriptor("histogram", sdkinstrument.SyncHistogram, number.Int64Kind)
+	hpipes := make(pipeline.Register[viewstate.Instrument], 1)
+	hpipes[0], _ = vc.Compile(hdesc)
+	hinst := NewInstrument(hdesc, testIngestProvider{queue}, hpipes, nil)
+	NewHistogram[int64, number.Int64Traits](hinst).RecordWeighted(ctx, 3, 5)
+
+	// Nothing is aggregated before the queue is flushed.
+	require.Equal(t, 0, len(inst.current))
+	require.Equal(t, 0, len(hinst.current))
+
+	queue.Flush()
+	require.Equal(t, int64(31), ingestTotal(t, inst, vc))
+
+	hinst.SnapshotAndProcess()
+	for _, out := range test.CollectScope(t, vc.Collectors(), testSequence) {
+		if out.Descriptor.Name == "histogram" {
+			require.Equal(t, uint64(5), out.Points[0].Aggregation.(aggregation.Histogram).Count())
+		}
+	}
+
+	// Reset flushes the queue first, so that the waiting
+	// measurement is not aggregated after Reset.
+	cntr.Add(ctx, 32, testAttr.Int(1))
+	inst.Reset()
+	cntr.Add(ctx, 64, testAttr.Int(1))
+	queue.Flush()
+	require.Equal(t, int64(64), ingestTotal(t, inst, vc))
+}
@@ -0,0 +1,122 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncstate
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// InternPool shares the attribute lists and sets of records among
+// the instruments that use the pool, so that equal attribute sets
+// used by many instruments are stored once.  Entries are reference
+// counted by the records that use them and removed when the last
+// record is removed from its instrument.
+type InternPool struct {
+	lock    sync.Mutex
+	entries map[uint64][]*internEntry
+}
+
+// internEntry is one interned attribute list and its set.  Entries
+// with the same fingerprint have distinct lists, but may share a set
+// when their lists differ only in order or duplicates.
+type internEntry struct {
+	list []attribute.KeyValue
+	set  attribute.Set
+	refs int64
+}
+
+// NewInternPool returns an empty InternPool.
+func NewInternPool() *InternPool {
+	return &InternPool{
+		entries: map[uint64][]*internEntry{},
+	}
+}
+
+// Len returns the number of distinct attribute lists in the pool.
+func (p *InternPool) Len() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var n int
+	for _, list := range p.entries {
+		n += len(list)
+	}
+	return n
+}
+
+// acquire returns the interned copy of attrs and its set, with
+// fingerprint fp, adding a reference.  Each call to acquire must be
+// matched by a call to release.
+func (p *InternPool) acquire(fp uint64, attrs []attribute.KeyValue) ([]attribute.KeyValue, attribute.Set) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entries := p.entries[fp]
+	for _, e := range entries {
+		if attributesEqual(attrs, e.list) {
+			e.refs++
+			return e.list, e.set
+		}
+	}
+
+	acpy, aset := newAttributes(attrs)
+
+	// Building the set reorders acpy, which may now match.
+	for _, e := range entries {
+		if attributesEqual(acpy, e.list) {
+			e.refs++
+			return e.list, e.set
+		}
+	}
+	for _, e := range entries {
+		if e.set.Equals(&aset) {
+			aset = e.set
+			break
+		}
+	}
+	p.entries[fp] = append(entries, &internEntry{
+		list: acpy,
+		set:  aset,
+		refs: 1,
+	})
+	return acpy, aset
+}
+
+// release removes a reference to the interned list, which was
+// returned by acquire with fingerprint fp.
+func (p *InternPool) release(fp uint64, list []attribute.KeyValue) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entries := p.entries[fp]
+	for i, e := range entries {
+		if !attributesEqual(list, e.list) {
+			continue
+		}
+		e.refs--
+		if e.refs > 0 {
+			return
+		}
+		if len(entries) == 1 {
+			delete(p.entries, fp)
+			return
+		}
+		entries[i] = entries[len(entries)-1]
+		entries[len(entries)-1] = nil
+		p.entries[fp] = entries[:len(entries)-1]
+		return
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build otelmetriclockstats
+
+package syncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LockStatsEnabled is true when the SDK is built with the
+// otelmetriclockstats tag, which counts contention for the lock of
+// each synchronous instrument, see Stats.
+const LockStatsEnabled = true
+
+// statsRWMutex is a sync.RWMutex that counts the acquisitions that
+// had to wait and the time spent waiting.  Uncontended acquisitions
+// cost one extra TryLock.
+type statsRWMutex struct {
+	sync.RWMutex
+
+	// waits and waitTime (in nanoseconds) are updated atomically.
+	waits    int64
+	waitTime int64
+}
+
+func (m *statsRWMutex) Lock() {
+	if m.RWMutex.TryLock() {
+		return
+	}
+	start := time.Now()
+	m.RWMutex.Lock()
+	m.waited(start)
+}
+
+func (m *statsRWMutex) RLock() {
+	if m.RWMutex.TryRLock() {
+		return
+	}
+	start := time.Now()
+	m.RWMutex.RLock()
+	m.waited(start)
+}
+
+func (m *statsRWMutex) waited(start time.Time) {
+	atomic.AddInt64(&m.waits, 1)
+	atomic.AddInt64(&m.waitTime, int64(time.Since(start)))
+}
+
+// lockStats returns the number of acquisitions that waited and the
+// total time spent waiting.
+func (m *statsRWMutex) lockStats() (int64, time.Duration) {
+	return atomic.LoadInt64(&m.waits), time.Duration(atomic.LoadInt64(&m.waitTime))
+}
@@ -0,0 +1,38 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !otelmetriclockstats
+
+package syncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
+
+import (
+	"sync"
+	"time"
+)
+
+// LockStatsEnabled is true when the SDK is built with the
+// otelmetriclockstats tag, which counts contention for the lock of
+// each synchronous instrument, see Stats.
+const LockStatsEnabled = false
+
+// statsRWMutex is a sync.RWMutex, without the contention counts of
+// the otelmetriclockstats build.
+type statsRWMutex struct {
+	sync.RWMutex
+}
+
+// lockStats returns zeros.
+func (m *statsRWMutex) lockStats() (int64, time.Duration) {
+	return 0, 0
+}
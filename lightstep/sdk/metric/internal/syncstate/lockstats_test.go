@@ -0,0 +1,60 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build otelmetriclockstats
+
+package syncstate
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsRWMutex(t *testing.T) {
+	var m statsRWMutex
+
+	// Uncontended acquisitions are not counted.
+	m.Lock()
+	m.Unlock()
+	m.RLock()
+	m.RUnlock()
+
+	waits, waitTime := m.lockStats()
+	require.Equal(t, int64(0), waits)
+	require.Equal(t, time.Duration(0), waitTime)
+
+	var wg sync.WaitGroup
+	m.Lock()
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		m.Lock()
+		m.Unlock()
+	}()
+	go func() {
+		defer wg.Done()
+		m.RLock()
+		m.RUnlock()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	m.Unlock()
+	wg.Wait()
+
+	waits, waitTime = m.lockStats()
+	require.Equal(t, int64(2), waits)
+	require.Less(t, time.Duration(0), waitTime)
+}
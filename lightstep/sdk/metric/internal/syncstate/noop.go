@@ -0,0 +1,95 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
+
+import (
+	"context"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+)
+
+// Noop is a synchronous instrument whose methods do nothing, used
+// in place of Counter, Histogram, and Gauge when every view drops
+// the instrument, i.e., when NewInstrument returns nil.  Unlike those
+// types, Noop does not test its instrument or its arguments, so a
+// measurement costs only the method call.
+type Noop[N number.Any] struct {
+	instrument.Synchronous // Note: wasted space
+}
+
+// Noop satisfies every synchronous instrument API.
+var (
+	_ syncint64.Counter         = Noop[int64]{}
+	_ syncint64.UpDownCounter   = Noop[int64]{}
+	_ syncint64.Histogram       = Noop[int64]{}
+	_ syncfloat64.Counter       = Noop[float64]{}
+	_ syncfloat64.UpDownCounter = Noop[float64]{}
+	_ syncfloat64.Histogram     = Noop[float64]{}
+
+	_ sdkinstrument.SetCounter[int64]        = Noop[int64]{}
+	_ sdkinstrument.BatchCounter[int64]      = Noop[int64]{}
+	_ sdkinstrument.BackfillCounter[int64]   = Noop[int64]{}
+	_ sdkinstrument.SetHistogram[int64]      = Noop[int64]{}
+	_ sdkinstrument.WeightedHistogram[int64] = Noop[int64]{}
+	_ sdkinstrument.BucketHistogram[int64]   = Noop[int64]{}
+	_ sdkinstrument.Gauge[int64]             = Noop[int64]{}
+
+	_ sdkinstrument.SetCounter[float64]        = Noop[float64]{}
+	_ sdkinstrument.BatchCounter[float64]      = Noop[float64]{}
+	_ sdkinstrument.BackfillCounter[float64]   = Noop[float64]{}
+	_ sdkinstrument.SetHistogram[float64]      = Noop[float64]{}
+	_ sdkinstrument.WeightedHistogram[float64] = Noop[float64]{}
+	_ sdkinstrument.BucketHistogram[float64]   = Noop[float64]{}
+	_ sdkinstrument.Gauge[float64]             = Noop[float64]{}
+
+	_ sdkinstrument.DurationHistogram = Noop[int64]{}
+	_ sdkinstrument.Enabler           = Noop[int64]{}
+)
+
+// Add implements Counter.
+func (Noop[N]) Add(context.Context, N, ...attribute.KeyValue) {}
+
+// AddSet implements sdkinstrument.SetCounter.
+func (Noop[N]) AddSet(context.Context, N, attribute.Set) {}
+
+// AddBatch implements sdkinstrument.BatchCounter.
+func (Noop[N]) AddBatch(context.Context, []sdkinstrument.Measurement[N]) {}
+
+// AddWithStartTime implements sdkinstrument.BackfillCounter.
+func (Noop[N]) AddWithStartTime(context.Context, N, time.Time, ...attribute.KeyValue) {}
+
+// Record implements Histogram and Gauge.
+func (Noop[N]) Record(context.Context, N, ...attribute.KeyValue) {}
+
+// RecordSet implements sdkinstrument.SetHistogram.
+func (Noop[N]) RecordSet(context.Context, N, attribute.Set) {}
+
+// RecordWeighted implements sdkinstrument.WeightedHistogram.
+func (Noop[N]) RecordWeighted(context.Context, N, uint64, ...attribute.KeyValue) {}
+
+// RecordBucket implements sdkinstrument.BucketHistogram.
+func (Noop[N]) RecordBucket(context.Context, int, uint64, N, ...attribute.KeyValue) {}
+
+// RecordDuration implements sdkinstrument.DurationHistogram.
+func (Noop[N]) RecordDuration(context.Context, time.Duration, ...attribute.KeyValue) {}
+
+// SetEnabled implements sdkinstrument.Enabler.
+func (Noop[N]) SetEnabled(bool) {}
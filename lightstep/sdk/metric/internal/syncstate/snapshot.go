@@ -0,0 +1,259 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Snapshot is the state of an Instrument at a point in time, for
+// debugging.  See MarshalSnapshot.
+type Snapshot struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	NumberKind string `json:"number_kind"`
+	Unit       string `json:"unit,omitempty"`
+
+	// Records are the mapped attribute sets, including those
+	// with updates not yet processed by SnapshotAndProcess.
+	Records []SnapshotRecord `json:"records"`
+
+	// Points are the aggregated values of each output, as of the
+	// most recent SnapshotAndProcess.
+	Points []SnapshotPoint `json:"points"`
+}
+
+// SnapshotAttribute is one attribute of a Snapshot.
+type SnapshotAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SnapshotRecord is one record of a Snapshot.
+type SnapshotRecord struct {
+	Attributes []SnapshotAttribute `json:"attributes"`
+
+	// Updates is the number of updates to the record.
+	Updates int64 `json:"updates"`
+
+	// Pending is the number of updates not yet processed.
+	Pending int64 `json:"pending"`
+}
+
+// SnapshotPoint is the aggregated value of one attribute set of one
+// output of a Snapshot.  The fields that apply depend on the
+// aggregation.  Numbers are converted to float64.
+type SnapshotPoint struct {
+	Name        string              `json:"name"`
+	Aggregation string              `json:"aggregation"`
+	Attributes  []SnapshotAttribute `json:"attributes"`
+
+	Sum   *float64 `json:"sum,omitempty"`
+	Gauge *float64 `json:"gauge,omitempty"`
+	Count *uint64  `json:"count,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+
+	// Scale, ZeroCount, Positive, and Negative describe
+	// exponential histograms.
+	Scale     *int32           `json:"scale,omitempty"`
+	ZeroCount *uint64          `json:"zero_count,omitempty"`
+	Positive  *SnapshotBuckets `json:"positive,omitempty"`
+	Negative  *SnapshotBuckets `json:"negative,omitempty"`
+
+	// Boundaries and BucketCounts describe explicit histograms.
+	Boundaries   []float64 `json:"boundaries,omitempty"`
+	BucketCounts []uint64  `json:"bucket_counts,omitempty"`
+
+	// Quantiles describe summaries.
+	Quantiles []SnapshotQuantile `json:"quantiles,omitempty"`
+}
+
+// SnapshotBuckets are the buckets of an exponential histogram.
+type SnapshotBuckets struct {
+	Offset int32    `json:"offset"`
+	Counts []uint64 `json:"counts"`
+}
+
+// SnapshotQuantile is one quantile of a summary.
+type SnapshotQuantile struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+// MarshalSnapshot returns the JSON encoding of the Snapshot of this
+// instrument, with records and points in a stable order, so that
+// users can attach the state of an instrument to a bug report.  This
+// is safe to call concurrently with updates and SnapshotAndProcess,
+// and does not modify the instrument.  An error is returned for
+// values that JSON cannot encode, such as NaN.
+func (inst *Instrument) MarshalSnapshot() ([]byte, error) {
+	return json.Marshal(inst.snapshotState())
+}
+
+// UnmarshalSnapshot decodes the output of MarshalSnapshot, for
+// comparing the state of instruments in tests.
+func UnmarshalSnapshot(data []byte) (Snapshot, error) {
+	var snap Snapshot
+	err := json.Unmarshal(data, &snap)
+	return snap, err
+}
+
+// snapshotState builds the Snapshot of this instrument.
+func (inst *Instrument) snapshotState() Snapshot {
+	if inst == nil {
+		// Instrument was completely disabled by the view.
+		return Snapshot{}
+	}
+	snap := Snapshot{
+		Name:       inst.descriptor.Name,
+		Kind:       strings.TrimSuffix(inst.descriptor.Kind.String(), "Kind"),
+		NumberKind: strings.TrimSuffix(inst.descriptor.NumberKind.String(), "Kind"),
+		Unit:       string(inst.descriptor.Unit),
+		Records:    []SnapshotRecord{},
+		Points:     []SnapshotPoint{},
+	}
+
+	var recordKeys []string
+	inst.lock.RLock()
+	for _, reclist := range inst.current {
+		for rec := reclist; rec != nil; rec = rec.next {
+			updates := atomic.LoadInt64(&rec.updateCount)
+			snap.Records = append(snap.Records, SnapshotRecord{
+				Attributes: snapshotAttributes(rec.attributeSet),
+				Updates:    updates,
+				Pending:    updates - atomic.LoadInt64(&rec.collectedCount),
+			})
+			recordKeys = append(recordKeys, rec.attributeSet.Encoded(attribute.DefaultEncoder()))
+		}
+	}
+	inst.lock.RUnlock()
+
+	sort.Sort(byKey[SnapshotRecord]{keys: recordKeys, values: snap.Records})
+
+	var pointKeys []string
+	if inspector, ok := inst.compiledViews().compiled.(viewstate.Inspector); ok {
+		inspector.Inspect(func(desc sdkinstrument.Descriptor, kvs attribute.Set, agg aggregation.Aggregation) {
+			snap.Points = append(snap.Points, snapshotPoint(desc, kvs, agg))
+			pointKeys = append(pointKeys, desc.Name+"\x00"+kvs.Encoded(attribute.DefaultEncoder()))
+		})
+	}
+
+	sort.Sort(byKey[SnapshotPoint]{keys: pointKeys, values: snap.Points})
+
+	return snap
+}
+
+// byKey sorts values by parallel keys.
+type byKey[T any] struct {
+	keys   []string
+	values []T
+}
+
+func (b byKey[T]) Len() int {
+	return len(b.keys)
+}
+
+func (b byKey[T]) Less(i, j int) bool {
+	return b.keys[i] < b.keys[j]
+}
+
+func (b byKey[T]) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+}
+
+// snapshotAttributes returns the attributes of a set, in order.
+func snapshotAttributes(set attribute.Set) []SnapshotAttribute {
+	attrs := make([]SnapshotAttribute, 0, set.Len())
+	for iter := set.Iter(); iter.Next(); {
+		kv := iter.Attribute()
+		attrs = append(attrs, SnapshotAttribute{
+			Key:   string(kv.Key),
+			Value: kv.Value.Emit(),
+		})
+	}
+	return attrs
+}
+
+// snapshotPoint converts one aggregation to a SnapshotPoint.
+func snapshotPoint(desc sdkinstrument.Descriptor, kvs attribute.Set, agg aggregation.Aggregation) SnapshotPoint {
+	toFloat := func(n number.Number) *float64 {
+		f := n.CoerceToFloat64(desc.NumberKind)
+		return &f
+	}
+	pt := SnapshotPoint{
+		Name:        desc.Name,
+		Aggregation: strings.TrimSuffix(agg.Kind().String(), "Kind"),
+		Attributes:  snapshotAttributes(kvs),
+	}
+	if s, ok := agg.(aggregation.HasASum); ok {
+		pt.Sum = toFloat(s.Sum())
+	}
+	if g, ok := agg.(aggregation.Gauge); ok {
+		pt.Gauge = toFloat(g.Gauge())
+	}
+	if c, ok := agg.(interface{ Count() uint64 }); ok {
+		count := c.Count()
+		pt.Count = &count
+	}
+	if mm, ok := agg.(aggregation.MinMaxSumCount); ok {
+		if omm, ok := agg.(aggregation.OptionalMinMax); !ok || omm.HasMinMax() {
+			pt.Min = toFloat(mm.Min())
+			pt.Max = toFloat(mm.Max())
+		}
+	}
+	if h, ok := agg.(aggregation.Histogram); ok {
+		scale, zeros := h.Scale(), h.ZeroCount()
+		pt.Scale = &scale
+		pt.ZeroCount = &zeros
+		pt.Positive = snapshotBuckets(h.Positive())
+		pt.Negative = snapshotBuckets(h.Negative())
+	}
+	if e, ok := agg.(aggregation.ExplicitHistogram); ok {
+		pt.Boundaries = e.Boundaries()
+		pt.BucketCounts = e.BucketCounts()
+	}
+	if s, ok := agg.(aggregation.Summary); ok {
+		for _, qv := range s.Quantiles() {
+			pt.Quantiles = append(pt.Quantiles, SnapshotQuantile{
+				Quantile: qv.Quantile,
+				Value:    qv.Value,
+			})
+		}
+	}
+	return pt
+}
+
+// snapshotBuckets copies the buckets of an exponential histogram.
+func snapshotBuckets(b aggregation.Buckets) *SnapshotBuckets {
+	sb := &SnapshotBuckets{
+		Offset: b.Offset(),
+		Counts: make([]uint64, b.Len()),
+	}
+	for i := range sb.Counts {
+		sb.Counts[i] = b.At(uint32(i))
+	}
+	return sb
+}
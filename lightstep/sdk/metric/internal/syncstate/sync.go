@@ -16,17 +16,22 @@ package syncstate
 
 import (
 	"context"
+	"fmt"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/fprint"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/pipeline"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 )
 
 var sortableAttributesPool = sync.Pool{
@@ -47,24 +52,107 @@ type Instrument struct {
 	// instrument, unmodified by views.
 	descriptor sdkinstrument.Descriptor
 
+	// onError is called for measurements that fail the range
+	// test, if not nil.
+	onError aggregator.MeasurementErrorHandler
+
+	// views is the *compiledViews in use, replaced by
+	// SetCompiled.
+	views atomic.Value
+
+	// bypass is set by BypassFilter.
+	bypass bool
+
+	// pool (if non-nil) interns the attributes of records.
+	pool *InternPool
+
+	// signedHistogram permits negative histogram measurements.
+	signedHistogram bool
+
+	// cardinality (if non-nil) estimates the number of distinct
+	// attribute sets, updated when a record is inserted.
+	cardinality *cardinalitySketch
+
+	// ingest (if non-nil) is the queue that Add and Record
+	// measurements are aggregated through.
+	ingest *IngestQueue
+
+	// ingestDropped counts measurements dropped because the
+	// ingest queue was full, updated atomically.
+	ingestDropped int64
+
+	// disabled is non-zero while the instrument is paused by
+	// SetEnabled(false), read atomically.
+	disabled int32
+
+	// snapshotLock protects the snapshot generations below, with
+	// snapshotDone signaled when a snapshot finishes.
+	// Concurrent calls to SnapshotAndProcess share one snapshot
+	// rather than each taking lock in turn.
+	snapshotLock    sync.Mutex
+	snapshotDone    sync.Cond
+	snapshotRunning bool
+	snapshotStarted uint64
+	snapshotEnded   uint64
+
+	// lock protects current.  Contention is counted when built
+	// with the otelmetriclockstats tag.
+	lock statsRWMutex
+
+	// current is protected by lock.
+	current map[uint64]*record
+
+	// records is the number of records in current, written with
+	// lock held and read atomically.
+	records int64
+
+	// snapshotPending is true after Snapshot until the next
+	// SnapshotAndProcess, protected by lock.
+	snapshotPending bool
+
+	// pendingRecords and activeAggregators are written with
+	// lock held and read atomically by Stats().
+	pendingRecords    int64
+	activeAggregators int64
+}
+
+// compiledViews is the compiled instrument of an Instrument and the
+// settings derived from it.
+type compiledViews struct {
 	// compiled will be a single compiled instrument or a
 	// multi-instrument in case of multiple view behaviors
 	// and/or readers; these distinctions do not matter
 	// for synchronous aggregation.
 	compiled viewstate.Instrument
 
-	// lock protects current.
-	lock sync.RWMutex
+	// dropped is true when every pipeline dropped the instrument
+	// in SetCompiled, in which case compiled is the instrument
+	// it replaced.
+	dropped bool
 
-	// current is protected by lock.
-	current map[uint64]*record
+	// contextKeys are the baggage keys copied into measurement
+	// attributes, from the compiled views.
+	contextKeys []string
+
+	// reclaimer (if non-nil) removes expired attribute sets after
+	// each SnapshotAndProcess.
+	reclaimer viewstate.Reclaimer
+
+	// bypasser (if non-nil) creates the accumulators of records
+	// without the attribute filters of the views, see
+	// BypassFilter.
+	bypasser viewstate.Bypasser
+
+	// overflower (if non-nil) creates the overflow record, which
+	// measurements of new attribute sets share once current
+	// holds recordLimit records.
+	overflower  viewstate.Overflower
+	recordLimit int64
 }
 
-// NewInstruments builds a new synchronous instrument given the
-// per-pipeline instrument-views compiled.  Note that the unused
-// second parameter is an opaque value used in the asyncstate package,
-// passed here to make these two packages generalize.
-func NewInstrument(desc sdkinstrument.Descriptor, _ interface{}, compiled pipeline.Register[viewstate.Instrument]) *Instrument {
+// newCompiledViews combines the per-pipeline compiled instruments,
+// returning nil when every pipeline dropped the instrument.
+func newCompiledViews(desc sdkinstrument.Descriptor, compiled pipeline.Register[viewstate.Instrument], bypass bool) *compiledViews {
 	var nonnil []viewstate.Instrument
 	for _, comp := range compiled {
 		if comp != nil {
@@ -72,32 +160,356 @@ func NewInstrument(desc sdkinstrument.Descriptor, _ interface{}, compiled pipeli
 		}
 	}
 	if nonnil == nil {
+		return nil
+	}
+	// Note that viewstate.Route is used to eliminate the
+	// per-pipeline distinction that is useful in the asyncstate
+	// package.  Here, in the common case there will be one
+	// pipeline and one view, such that viewstate.Route produces
+	// a single concrete viewstate.Instrument.  Only when there
+	// are multiple views or multiple pipelines will the
+	// combination produce a viewstate.multiInstrument here, and
+	// only when views route attribute sets to pipelines will
+	// each record's accumulator combine a subset of them.
+	views := &compiledViews{
+		compiled: viewstate.Route(desc, nonnil...),
+	}
+	if ca, ok := views.compiled.(viewstate.ContextAttributer); ok {
+		views.contextKeys = ca.ContextAttributes()
+	}
+	if r, ok := views.compiled.(viewstate.Reclaimer); ok {
+		views.reclaimer = r
+	}
+	if o, ok := views.compiled.(viewstate.Overflower); ok && o.RecordLimit() != 0 {
+		views.overflower = o
+		views.recordLimit = int64(o.RecordLimit())
+	}
+	if b, ok := views.compiled.(viewstate.Bypasser); ok && bypass {
+		views.bypasser = b
+		views.contextKeys = nil
+	}
+	return views
+}
+
+// Stats describes an Instrument as of its most recent
+// SnapshotAndProcess.
+type Stats struct {
+	// PendingRecords is the number of records that were mapped
+	// when the snapshot began.
+	PendingRecords int64
+
+	// ActiveAggregators is the number of records that remained
+	// mapped after the snapshot, i.e., those that were in use.
+	ActiveAggregators int64
+
+	// LockWaits is the number of times the instrument lock was
+	// contended since the instrument was created, and
+	// LockWaitTime is the total time spent waiting.  These are
+	// zero unless LockStatsEnabled.
+	LockWaits    int64
+	LockWaitTime time.Duration
+
+	// Cardinality is the estimated number of distinct attribute
+	// sets used since the instrument was created.  This is zero
+	// unless cardinality estimation is enabled.
+	Cardinality int64
+
+	// Dropped is the number of measurements dropped because the
+	// ingest queue was full since the instrument was created,
+	// see IngestQueue.
+	Dropped int64
+}
+
+// InternPoolProvider is implemented by the opaque value passed to
+// NewInstrument when records should use a shared InternPool.
+type InternPoolProvider interface {
+	// InternPool returns the pool, nil if interning is disabled.
+	InternPool() *InternPool
+}
+
+// NegativeHistogramProvider is implemented by the opaque value passed
+// to NewInstrument when histograms should accept negative values.
+type NegativeHistogramProvider interface {
+	// NegativeHistogramValues returns true to accept negative
+	// histogram measurements.
+	NegativeHistogramValues() bool
+}
+
+// CardinalityEstimationProvider is implemented by the opaque value
+// passed to NewInstrument when instruments should estimate the number
+// of distinct attribute sets they use, see Stats.
+type CardinalityEstimationProvider interface {
+	// CardinalityEstimation returns true to estimate the number
+	// of distinct attribute sets.
+	CardinalityEstimation() bool
+}
+
+// NewInstruments builds a new synchronous instrument given the
+// per-pipeline instrument-views compiled.  Note that the second
+// parameter is an opaque value used in the asyncstate package,
+// passed here to make these two packages generalize; here it is
+// only tested for an InternPoolProvider, a NegativeHistogramProvider,
+// a CardinalityEstimationProvider, and an IngestQueueProvider.  The
+// onError handler, if
+// not nil, is called for invalid measurements.
+func NewInstrument(desc sdkinstrument.Descriptor, opaque interface{}, compiled pipeline.Register[viewstate.Instrument], onError aggregator.MeasurementErrorHandler) *Instrument {
+	views := newCompiledViews(desc, compiled, false)
+	if views == nil {
 		// When no readers enable the instrument, no need for an instrument.
 		return nil
 	}
-	return &Instrument{
+	inst := &Instrument{
 		descriptor: desc,
+		onError:    onError,
 		current:    map[uint64]*record{},
+	}
+	inst.views.Store(views)
+	inst.snapshotDone.L = &inst.snapshotLock
+	if pp, ok := opaque.(InternPoolProvider); ok {
+		inst.pool = pp.InternPool()
+	}
+	if np, ok := opaque.(NegativeHistogramProvider); ok {
+		inst.signedHistogram = np.NegativeHistogramValues()
+	}
+	if cp, ok := opaque.(CardinalityEstimationProvider); ok && cp.CardinalityEstimation() {
+		inst.cardinality = &cardinalitySketch{}
+	}
+	if ip, ok := opaque.(IngestQueueProvider); ok {
+		inst.ingest = ip.IngestQueue()
+	}
+	return inst
+}
 
-		// Note that viewstate.Combine is used to eliminate
-		// the per-pipeline distinction that is useful in the
-		// asyncstate package.  Here, in the common case there
-		// will be one pipeline and one view, such that
-		// viewstate.Combine produces a single concrete
-		// viewstate.Instrument.  Only when there are multiple
-		// views or multiple pipelines will the combination
-		// produce a viewstate.multiInstrument here.
-		compiled: viewstate.Combine(desc, nonnil...),
+// BypassFilter makes the instrument output the attribute set of each
+// measurement as-is, without the attribute filters or the context
+// attributes of the views, see sdkinstrument.WithBypassFilter.  It
+// must be called before the instrument is used.
+func (inst *Instrument) BypassFilter() {
+	views := *inst.compiledViews()
+	if b, ok := views.compiled.(viewstate.Bypasser); ok {
+		views.bypasser = b
+		views.contextKeys = nil
 	}
+	inst.bypass = true
+	inst.views.Store(&views)
+}
+
+// compiledViews returns the compiled instrument in use.
+func (inst *Instrument) compiledViews() *compiledViews {
+	return inst.views.Load().(*compiledViews)
+}
+
+// SetCompiled replaces the per-pipeline compiled instruments, as
+// returned by viewstate.Compiler.Lookup after the views change.
+// Pending records are processed and then removed if they are not in
+// use, so that new records use the replacement; records in use
+// continue to update the outputs they were created with until they
+// are removed by a later SnapshotAndProcess.  When every pipeline
+// drops the instrument, it is paused as for SetEnabled(false) until
+// it is replaced again.
+func (inst *Instrument) SetCompiled(compiled pipeline.Register[viewstate.Instrument]) {
+	if inst == nil {
+		// Instrument was completely disabled by the view.
+		return
+	}
+	views := newCompiledViews(inst.descriptor, compiled, inst.bypass)
+	if views == nil {
+		// Measurements that race with this call update the
+		// replaced outputs, which are no longer collected.
+		views = &compiledViews{
+			compiled: inst.compiledViews().compiled,
+			dropped:  true,
+		}
+	}
+	if inst.ingest != nil {
+		inst.ingest.Flush()
+	}
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	// As for Reset, the first pass processes records with
+	// pending updates, the second pass removes records that are
+	// not in use.
+	inst.snapshotAndProcessLocked()
+	inst.snapshotAndProcessLocked()
+	inst.snapshotPending = false
+
+	inst.views.Store(views)
+}
+
+// SetEnabled pauses or resumes the instrument.  While disabled,
+// measurements are dropped and SnapshotAndProcess does nothing, so
+// that no new data reaches the collectors; existing state, including
+// cumulative sums, is kept and continues once re-enabled.
+func (inst *Instrument) SetEnabled(enabled bool) {
+	if inst == nil {
+		// Instrument was completely disabled by the view.
+		return
+	}
+	var disabled int32
+	if !enabled {
+		disabled = 1
+	}
+	atomic.StoreInt32(&inst.disabled, disabled)
+}
+
+// paused returns true when inst is nil or disabled, or when every
+// pipeline dropped it in SetCompiled.
+func (inst *Instrument) paused() bool {
+	return inst == nil || atomic.LoadInt32(&inst.disabled) != 0 || inst.compiledViews().dropped
 }
 
 // SnapshotAndProcess calls SnapshotAndProcess() for all live
 // accumulators of this instrument.  Inactive accumulators will be
-// subsequently removed from the map.
+// subsequently removed from the map.  Afterward, aggregators of
+// attribute sets that expired according to the view's attribute-set
+// TTL are removed.  This does nothing while the instrument is
+// disabled.
+//
+// The snapshot processes records into the output storage of every
+// pipeline, so readers that call this concurrently share a single
+// snapshot that begins after each of their calls, and then collect
+// their own pipelines independently.
 func (inst *Instrument) SnapshotAndProcess() {
+	if inst.paused() {
+		return
+	}
+	inst.snapshotLock.Lock()
+	defer inst.snapshotLock.Unlock()
+
+	// Any snapshot that starts after this point includes the
+	// updates that precede this call.
+	want := inst.snapshotStarted + 1
+
+	for inst.snapshotEnded < want {
+		if inst.snapshotRunning {
+			inst.snapshotDone.Wait()
+			continue
+		}
+		inst.snapshotRunning = true
+		inst.snapshotStarted++
+		gen := inst.snapshotStarted
+
+		inst.snapshotLock.Unlock()
+		inst.snapshot()
+		inst.snapshotLock.Lock()
+
+		inst.snapshotRunning = false
+		inst.snapshotEnded = gen
+		inst.snapshotDone.Broadcast()
+	}
+}
+
+// Snapshot takes a snapshot of the accumulators with updates without
+// processing them, so that the next SnapshotAndProcess processes
+// the data as of this call instead of taking a new snapshot.
+// Calling Snapshot for many instruments before any of them are
+// collected gives a consistent view across instruments, see
+// MeterProvider.SnapshotAll.  Updates after this call are included
+// in the following collection.  This does nothing while the
+// instrument is disabled.
+func (inst *Instrument) Snapshot() {
+	if inst.paused() {
+		return
+	}
 	inst.lock.Lock()
 	defer inst.lock.Unlock()
 
+	for _, reclist := range inst.current {
+		for rec := reclist; rec != nil; rec = rec.next {
+			mods := atomic.LoadInt64(&rec.updateCount)
+			if mods == atomic.LoadInt64(&rec.collectedCount) {
+				continue
+			}
+			rec.accumulator.Snapshot()
+			atomic.StoreInt64(&rec.collectedCount, mods)
+		}
+	}
+	inst.snapshotPending = true
+}
+
+// snapshot is the body of SnapshotAndProcess, called by one reader
+// at a time.  After Snapshot, this processes the pending snapshots
+// instead of taking new ones; records are unmapped by the following
+// SnapshotAndProcess.
+func (inst *Instrument) snapshot() {
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	if inst.snapshotPending {
+		inst.snapshotPending = false
+		inst.processLocked()
+	} else {
+		inst.snapshotAndProcessLocked()
+	}
+
+	if r := inst.compiledViews().reclaimer; r != nil {
+		r.Reclaim()
+	}
+}
+
+// Reset discards the pending and aggregated state of this instrument,
+// intended for test isolation.  Pending records are processed and
+// then removed if they are not in use, after which the output
+// aggregators are reset.  The compiled instrument and its
+// collectors are preserved.  Reset is safe to call concurrently with
+// SnapshotAndProcess.
+func (inst *Instrument) Reset() {
+	if inst == nil {
+		// Instrument was completely disabled by the view.
+		return
+	}
+	// Measurements waiting in the ingest queue were made before
+	// Reset, so they are aggregated and discarded with the rest.
+	if inst.ingest != nil {
+		inst.ingest.Flush()
+	}
+	inst.lock.Lock()
+	defer inst.lock.Unlock()
+
+	// The first pass processes records with pending updates,
+	// the second pass removes records that are not in use.
+	inst.snapshotAndProcessLocked()
+	inst.snapshotAndProcessLocked()
+	inst.snapshotPending = false
+
+	inst.compiledViews().compiled.Reset()
+}
+
+// Stats returns statistics about the most recent SnapshotAndProcess.
+// This is safe to call concurrently with SnapshotAndProcess.
+func (inst *Instrument) Stats() Stats {
+	if inst == nil {
+		// Instrument was completely disabled by the view.
+		return Stats{}
+	}
+	waits, waitTime := inst.lock.lockStats()
+	stats := Stats{
+		PendingRecords:    atomic.LoadInt64(&inst.pendingRecords),
+		ActiveAggregators: atomic.LoadInt64(&inst.activeAggregators),
+		LockWaits:         waits,
+		LockWaitTime:      waitTime,
+		Dropped:           atomic.LoadInt64(&inst.ingestDropped),
+	}
+	if inst.cardinality != nil {
+		stats.Cardinality = inst.cardinality.Estimate()
+	}
+	return stats
+}
+
+// Descriptor returns the API-provided descriptor of the instrument.
+func (inst *Instrument) Descriptor() sdkinstrument.Descriptor {
+	return inst.descriptor
+}
+
+// snapshotAndProcessLocked is called with inst.lock held.
+func (inst *Instrument) snapshotAndProcessLocked() {
+	var pending, active int64
+	defer func() {
+		atomic.StoreInt64(&inst.pendingRecords, pending)
+		atomic.StoreInt64(&inst.activeAggregators, active)
+	}()
+
 	for key, reclist := range inst.current {
 		// reclist is a list of records for this fingerprint.
 		var head *record
@@ -109,20 +521,25 @@ func (inst *Instrument) SnapshotAndProcess() {
 		// linked list after filtering records that are no longer
 		// in use.
 		for rec := reclist; rec != nil; rec = rec.next {
-			if inst.singleSnapshotAndProcess(key, rec) {
-				if head == nil {
-					// The first time a record will be kept,
-					// it becomes the head and tail.
-					head = rec
-					tail = rec
-				} else {
-					// Subsequently, update the tail of the
-					// list.  Note that this creates a
-					// temporarily invalid list will be
-					// repaired outside the loop, below.
-					tail.next = rec
-					tail = rec
-				}
+			pending++
+			if !inst.singleSnapshotAndProcess(key, rec) {
+				// The record was unmapped.
+				atomic.AddInt64(&inst.records, -1)
+				continue
+			}
+			active++
+			if head == nil {
+				// The first time a record will be kept,
+				// it becomes the head and tail.
+				head = rec
+				tail = rec
+			} else {
+				// Subsequently, update the tail of the
+				// list.  Note that this creates a
+				// temporarily invalid list will be
+				// repaired outside the loop, below.
+				tail.next = rec
+				tail = rec
 			}
 		}
 
@@ -142,9 +559,22 @@ func (inst *Instrument) SnapshotAndProcess() {
 	}
 }
 
+// processLocked processes the snapshots taken by Snapshot, which is
+// called with inst.lock held.  Accumulators without a pending
+// snapshot are unaffected.
+func (inst *Instrument) processLocked() {
+	for _, reclist := range inst.current {
+		for rec := reclist; rec != nil; rec = rec.next {
+			rec.accumulator.Process(false)
+			inst.checkOverflow(rec)
+		}
+	}
+}
+
 // singleSnapshotAndProcess
 func (inst *Instrument) singleSnapshotAndProcess(fp uint64, rec *record) bool {
 	if rec.conditionalSnapshotAndProcess(false) {
+		inst.checkOverflow(rec)
 		return true
 	}
 
@@ -157,7 +587,12 @@ func (inst *Instrument) singleSnapshotAndProcess(fp uint64, rec *record) bool {
 	// see it.
 	// (b) if this is indeed the last reference, the collector needs the
 	// release signal.
-	_ = rec.conditionalSnapshotAndProcess(unmapped)
+	if rec.conditionalSnapshotAndProcess(unmapped) {
+		inst.checkOverflow(rec)
+	}
+	if unmapped {
+		inst.releaseAttributes(fp, rec.attributeList)
+	}
 
 	// When `unmapped` is true, any other goroutines are now
 	// trying to re-insert this entry in the map, they are busy
@@ -165,6 +600,25 @@ func (inst *Instrument) singleSnapshotAndProcess(fp uint64, rec *record) bool {
 	return !unmapped
 }
 
+// checkOverflow reports an int64 sum that overflowed while the
+// record was processed.  Other number kinds do not detect overflow.
+func (inst *Instrument) checkOverflow(rec *record) {
+	if inst.descriptor.NumberKind != number.Int64Kind {
+		return
+	}
+	value, overflow := rec.accumulator.(viewstate.OverflowReporter[int64]).TakeOverflow()
+	if !overflow {
+		return
+	}
+	doevery.TimePeriod(30*time.Second, func() {
+		otel.Handle(fmt.Errorf("%s: %w", inst.descriptor.Name, aggregator.ErrSumOverflow))
+	})
+	if inst.onError != nil {
+		var traits number.Int64Traits
+		inst.onError(inst.descriptor, traits.ToNumber(value), aggregator.ErrSumOverflow)
+	}
+}
+
 // record consists of an accumulator, a reference count, the number of
 // updates, and the number of collected updates.
 type record struct {
@@ -218,28 +672,326 @@ func (rec *record) conditionalSnapshotAndProcess(release bool) bool {
 	return true
 }
 
+// rangeTest applies the aggregator range test for this instrument.
+func rangeTest[N number.Any, Traits number.Traits[N]](inst *Instrument, num N) bool {
+	if inst.signedHistogram {
+		return aggregator.SignedHistogramRangeTest[N, Traits](num, inst.descriptor, inst.onError)
+	}
+	return aggregator.RangeTest[N, Traits](num, inst.descriptor, inst.onError)
+}
+
 // capture performs a single update for any synchronous instrument.
-func capture[N number.Any, Traits number.Traits[N]](_ context.Context, inst *Instrument, num N, attrs []attribute.KeyValue) {
-	if inst == nil {
-		// Instrument was completely disabled by the view.
+// With an ingest queue, the update is enqueued and aggregated later.
+func capture[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *Instrument, num N, attrs []attribute.KeyValue) {
+	if inst.paused() {
+		// Instrument was disabled by the view or SetEnabled.
+		return
+	}
+
+	if !rangeTest[N, Traits](inst, num) {
+		return
+	}
+
+	var traits Traits
+	if inst.enqueue(ingestItem{kind: ingestAttrs, ctx: ctx, num: traits.ToNumber(num)}, attrs) {
+		return
+	}
+	captureNow(ctx, inst, num, attrs)
+}
+
+// enqueue places a measurement that passed the range test on the
+// ingest queue, returning false when the instrument has no queue or
+// the queue was stopped, in which case the caller aggregates it.
+func (inst *Instrument) enqueue(item ingestItem, attrs []attribute.KeyValue) bool {
+	if inst.ingest == nil {
+		return false
+	}
+	item.inst = inst
+	return inst.ingest.enqueue(item, attrs)
+}
+
+// captureNow aggregates a single update that passed the range test.
+func captureNow[N number.Any](ctx context.Context, inst *Instrument, num N, attrs []attribute.KeyValue) {
+	rec := acquireRecord[N](inst, inst.withContextAttributes(ctx, attrs))
+	defer rec.refMapped.unref()
+
+	rec.accumulator.(viewstate.ContextUpdater[N]).UpdateContext(ctx, num)
+
+	// Record was modified.
+	atomic.AddInt64(&rec.updateCount, 1)
+}
+
+// captureSet is capture for an existing attribute.Set.  Since sets
+// are sorted and deduplicated by construction, the record is found
+// by comparing sets instead of attribute lists.
+func captureSet[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *Instrument, num N, set *attribute.Set) {
+	if inst.paused() {
+		// Instrument was disabled by the view or SetEnabled.
+		return
+	}
+
+	if !rangeTest[N, Traits](inst, num) {
+		return
+	}
+
+	var traits Traits
+	if inst.enqueue(ingestItem{kind: ingestSet, ctx: ctx, num: traits.ToNumber(num), set: *set}, nil) {
+		return
+	}
+	captureSetNow(ctx, inst, num, set)
+}
+
+// captureSetNow aggregates a captureSet update that passed the range
+// test.
+func captureSetNow[N number.Any](ctx context.Context, inst *Instrument, num N, set *attribute.Set) {
+	var rec *record
+	if extra := inst.contextAttributes(ctx); extra != nil {
+		rec = acquireRecord[N](inst, append(extra, set.ToSlice()...))
+	} else {
+		rec = acquireRecordSet(inst, set)
+	}
+	defer rec.refMapped.unref()
+
+	rec.accumulator.(viewstate.ContextUpdater[N]).UpdateContext(ctx, num)
+
+	// Record was modified.
+	atomic.AddInt64(&rec.updateCount, 1)
+}
+
+// captureWeighted is capture for a measurement with a weight.  A
+// zero weight records nothing.
+func captureWeighted[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *Instrument, num N, weight uint64, attrs []attribute.KeyValue) {
+	if inst.paused() || weight == 0 {
+		return
+	}
+
+	if !rangeTest[N, Traits](inst, num) {
+		return
+	}
+
+	var traits Traits
+	if inst.enqueue(ingestItem{kind: ingestWeighted, ctx: ctx, num: traits.ToNumber(num), weight: weight}, attrs) {
+		return
+	}
+	captureWeightedNow(ctx, inst, num, weight, attrs)
+}
+
+// captureWeightedNow aggregates a captureWeighted update that passed
+// the range test.
+func captureWeightedNow[N number.Any](ctx context.Context, inst *Instrument, num N, weight uint64, attrs []attribute.KeyValue) {
+	rec := acquireRecord[N](inst, inst.withContextAttributes(ctx, attrs))
+	defer rec.refMapped.unref()
+
+	rec.accumulator.(viewstate.WeightedUpdater[N]).UpdateWeighted(ctx, num, weight)
+
+	// Record was modified.
+	atomic.AddInt64(&rec.updateCount, 1)
+}
+
+// captureBucket is capture for pre-bucketed data.  A zero count
+// records nothing.
+func captureBucket[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *Instrument, index int, count uint64, sum N, attrs []attribute.KeyValue) {
+	if inst.paused() || count == 0 {
+		return
+	}
+
+	if !rangeTest[N, Traits](inst, sum) {
 		return
 	}
 
-	// Note: Here, this is the place to use context, e.g., extract baggage.
+	if index < 0 {
+		invalidBucket[N, Traits](inst, index)
+		return
+	}
 
-	if !aggregator.RangeTest[N, Traits](num, inst.descriptor) {
+	var traits Traits
+	if inst.enqueue(ingestItem{kind: ingestBucket, ctx: ctx, num: traits.ToNumber(sum), index: index, weight: count}, attrs) {
 		return
 	}
+	captureBucketNow[N, Traits](ctx, inst, index, count, sum, attrs)
+}
 
-	rec := acquireRecord[N](inst, attrs)
+// captureBucketNow aggregates a captureBucket update that passed the
+// range test.
+func captureBucketNow[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *Instrument, index int, count uint64, sum N, attrs []attribute.KeyValue) {
+	rec := acquireRecord[N](inst, inst.withContextAttributes(ctx, attrs))
 	defer rec.refMapped.unref()
 
-	rec.accumulator.(viewstate.Updater[N]).Update(num)
+	if !rec.accumulator.(viewstate.BucketUpdater[N]).UpdateBucket(index, count, sum) {
+		invalidBucket[N, Traits](inst, index)
+	}
 
 	// Record was modified.
 	atomic.AddInt64(&rec.updateCount, 1)
 }
 
+// invalidBucket reports pre-bucketed data that an output could not
+// merge.
+func invalidBucket[N number.Any, Traits number.Traits[N]](inst *Instrument, index int) {
+	if inst.onError == nil {
+		return
+	}
+	var traits Traits
+	inst.onError(inst.descriptor, traits.ToNumber(N(index)), aggregator.ErrInvalidBucket)
+}
+
+// captureWithStartTime is capture() for a measurement that began at
+// an explicit start time.
+func captureWithStartTime[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *Instrument, num N, start time.Time, attrs []attribute.KeyValue) {
+	if inst.paused() {
+		return
+	}
+
+	if !rangeTest[N, Traits](inst, num) {
+		return
+	}
+
+	var traits Traits
+	if inst.enqueue(ingestItem{kind: ingestStartTime, ctx: ctx, num: traits.ToNumber(num), start: start}, attrs) {
+		return
+	}
+	captureWithStartTimeNow(ctx, inst, num, start, attrs)
+}
+
+// captureWithStartTimeNow aggregates a captureWithStartTime update
+// that passed the range test.
+func captureWithStartTimeNow[N number.Any](ctx context.Context, inst *Instrument, num N, start time.Time, attrs []attribute.KeyValue) {
+	rec := acquireRecord[N](inst, inst.withContextAttributes(ctx, attrs))
+	defer rec.refMapped.unref()
+
+	rec.accumulator.(viewstate.StartTimeUpdater[N]).UpdateWithStartTime(ctx, num, start)
+
+	// Record was modified.
+	atomic.AddInt64(&rec.updateCount, 1)
+}
+
+// batchSearchSize is the number of distinct attribute sets in a
+// batch that are searched linearly, beyond which captureBatch uses a
+// map.
+const batchSearchSize = 16
+
+// batchRecord is a record acquired by captureBatch and the attribute
+// set of the measurement that acquired it.
+type batchRecord struct {
+	fp    uint64
+	attrs *attribute.Set
+	rec   *record
+}
+
+// captureBatch is captureSet for each measurement of a batch.  The
+// record of each distinct attribute set is acquired once and released
+// after the whole batch.  Measurements are applied in order, so that
+// a gauge keeps the last value of each attribute set.  With an ingest
+// queue, each measurement is enqueued as by captureSet.
+func captureBatch[N number.Any, Traits number.Traits[N]](ctx context.Context, inst *Instrument, batch []sdkinstrument.Measurement[N]) {
+	if inst.paused() || len(batch) == 0 {
+		return
+	}
+
+	if inst.ingest != nil {
+		var traits Traits
+		for i := range batch {
+			m := &batch[i]
+			if !rangeTest[N, Traits](inst, m.Value) {
+				continue
+			}
+			if !inst.enqueue(ingestItem{kind: ingestSet, ctx: ctx, num: traits.ToNumber(m.Value), set: m.Attrs}, nil) {
+				captureSetNow(ctx, inst, m.Value, &m.Attrs)
+			}
+		}
+		return
+	}
+
+	extra := inst.contextAttributes(ctx)
+
+	// Batches usually repeat a few attribute sets, which are
+	// searched linearly without allocating.
+	var small [batchSearchSize]batchRecord
+	recs := small[:0]
+	var many map[attribute.Distinct]*record
+
+	for i := range batch {
+		m := &batch[i]
+
+		if !rangeTest[N, Traits](inst, m.Value) {
+			continue
+		}
+
+		var rec *record
+		var fp uint64
+		if many != nil {
+			rec = many[m.Attrs.Equivalent()]
+		} else {
+			fp = fingerprintSet(&m.Attrs)
+			for _, br := range recs {
+				if br.fp == fp && br.attrs.Equals(&m.Attrs) {
+					rec = br.rec
+					break
+				}
+			}
+		}
+		if rec == nil {
+			if extra != nil {
+				attrs := make([]attribute.KeyValue, 0, len(extra)+m.Attrs.Len())
+				rec = acquireRecord[N](inst, append(append(attrs, extra...), m.Attrs.ToSlice()...))
+			} else {
+				rec = acquireRecordSet(inst, &m.Attrs)
+			}
+			recs = append(recs, batchRecord{fp: fp, attrs: &m.Attrs, rec: rec})
+
+			if many != nil {
+				many[m.Attrs.Equivalent()] = rec
+			} else if len(recs) == batchSearchSize {
+				many = make(map[attribute.Distinct]*record, len(recs))
+				for _, br := range recs {
+					many[br.attrs.Equivalent()] = br.rec
+				}
+			}
+		}
+
+		rec.accumulator.(viewstate.ContextUpdater[N]).UpdateContext(ctx, m.Value)
+	}
+
+	for _, br := range recs {
+		// Record was modified.
+		atomic.AddInt64(&br.rec.updateCount, 1)
+		br.rec.refMapped.unref()
+	}
+}
+
+// contextAttributes returns the configured baggage members of ctx as
+// attributes, nil if there are none.
+func (inst *Instrument) contextAttributes(ctx context.Context) []attribute.KeyValue {
+	contextKeys := inst.compiledViews().contextKeys
+	if contextKeys == nil {
+		return nil
+	}
+	bag := baggage.FromContext(ctx)
+	if bag.Len() == 0 {
+		return nil
+	}
+	var extra []attribute.KeyValue
+	for _, key := range contextKeys {
+		member := bag.Member(key)
+		if member.Key() == "" {
+			continue
+		}
+		extra = append(extra, viewstate.ContextAttributeKey(key).String(member.Value()))
+	}
+	return extra
+}
+
+// withContextAttributes places the context attributes before attrs.
+// Their keys are distinct from those of attrs, which take precedence
+// in the views (see viewstate.ContextAttributeKey).
+func (inst *Instrument) withContextAttributes(ctx context.Context, attrs []attribute.KeyValue) []attribute.KeyValue {
+	extra := inst.contextAttributes(ctx)
+	if extra == nil {
+		return attrs
+	}
+	return append(extra, attrs...)
+}
+
 func fingerprintAttributes(attrs []attribute.KeyValue) uint64 {
 	var fp uint64
 	for _, attr := range attrs {
@@ -252,6 +1004,20 @@ func fingerprintAttributes(attrs []attribute.KeyValue) uint64 {
 	return fp
 }
 
+// fingerprintSet equals fingerprintAttributes of the set's
+// attributes, since the fingerprint does not depend on order.
+func fingerprintSet(set *attribute.Set) uint64 {
+	var fp uint64
+	for iter := set.Iter(); iter.Next(); {
+		attr := iter.Attribute()
+		fp += fprint.Mix(
+			fprint.FingerprintString(string(attr.Key)),
+			fingerprintValue(attr.Value),
+		)
+	}
+	return fp
+}
+
 func fingerprintSlice[T any](slice []T, f func(T) uint64) uint64 {
 	var fp uint64
 	for _, item := range slice {
@@ -332,7 +1098,7 @@ func attributesEqual(a, b []attribute.KeyValue) bool {
 }
 
 // acquireRead acquires the read lock and searches for a `*record`.
-func acquireRead(inst *Instrument, fp uint64, attrs []attribute.KeyValue) *record {
+func acquireRead(inst *Instrument, fp uint64, match func(*record) bool) *record {
 	inst.lock.RLock()
 	defer inst.lock.RUnlock()
 
@@ -340,7 +1106,7 @@ func acquireRead(inst *Instrument, fp uint64, attrs []attribute.KeyValue) *recor
 
 	// Note: we could (optionally) allow collisions and not scan this list.
 	// The copied `attributeList` can be avoided in this case, as well.
-	for rec != nil && !attributesEqual(attrs, rec.attributeList) {
+	for rec != nil && !match(rec) {
 		rec = rec.next
 	}
 
@@ -355,34 +1121,98 @@ func acquireRead(inst *Instrument, fp uint64, attrs []attribute.KeyValue) *recor
 }
 
 // acquireRecord gets or creates a `*record` corresponding to `attrs`,
-// the input attributes.
+// the input attributes.  When the record exists, the lookup uses
+// only the fingerprint and a comparison with the record's attribute
+// list, so that the common case does not allocate; the attribute.Set
+// is built only for a new record.
 func acquireRecord[N number.Any](inst *Instrument, attrs []attribute.KeyValue) *record {
 	fp := fingerprintAttributes(attrs)
 
-	rec := acquireRead(inst, fp, attrs)
+	rec := acquireRead(inst, fp, func(rec *record) bool {
+		return attributesEqual(attrs, rec.attributeList)
+	})
 	if rec != nil {
 		return rec
 	}
 
-	// Build the attribute set.  Make a copy of the attribute list
-	// because we are keeping a copy in the record.
+	if inst.pool != nil {
+		acpy, aset := inst.pool.acquire(fp, attrs)
+		return insertRecord(inst, fp, acpy, aset)
+	}
+
+	acpy, aset := newAttributes(attrs)
+	return insertRecord(inst, fp, acpy, aset)
+}
+
+// newAttributes builds the attribute set.  It makes a copy of the
+// attribute list because the record keeps a copy.
+func newAttributes(attrs []attribute.KeyValue) ([]attribute.KeyValue, attribute.Set) {
 	acpy := make([]attribute.KeyValue, len(attrs))
 	copy(acpy, attrs)
 	tmp := sortableAttributesPool.Get().(*attribute.Sortable)
 	defer sortableAttributesPool.Put(tmp)
-	aset := attribute.NewSetWithSortable(acpy, tmp)
+	return acpy, attribute.NewSetWithSortable(acpy, tmp)
+}
+
+// acquireRecordSet is acquireRecord for an existing attribute.Set.
+func acquireRecordSet(inst *Instrument, set *attribute.Set) *record {
+	fp := fingerprintSet(set)
+
+	rec := acquireRead(inst, fp, func(rec *record) bool {
+		return rec.attributeSet.Equals(set)
+	})
+	if rec != nil {
+		return rec
+	}
+
+	if inst.pool != nil {
+		acpy, aset := inst.pool.acquire(fp, set.ToSlice())
+		return insertRecord(inst, fp, acpy, aset)
+	}
+	return insertRecord(inst, fp, set.ToSlice(), *set)
+}
+
+// releaseAttributes releases a record's interned attributes, if
+// the instrument uses an InternPool.
+func (inst *Instrument) releaseAttributes(fp uint64, attrs []attribute.KeyValue) {
+	if inst.pool != nil {
+		inst.pool.release(fp, attrs)
+	}
+}
+
+// insertRecord inserts a new record for the attributes or returns
+// the existing record inserted concurrently.  Once the instrument
+// has recordLimit records, the overflow record is returned instead.
+func insertRecord(inst *Instrument, fp uint64, acpy []attribute.KeyValue, aset attribute.Set) *record {
+	// Note: attribute sets in use are found by acquireRead,
+	// so the sketch is updated only when a record is missing,
+	// which includes every new attribute set.
+	if inst.cardinality != nil {
+		inst.cardinality.Add(fp)
+	}
+
+	if inst.recordsFull() {
+		inst.releaseAttributes(fp, acpy)
+		return acquireOverflowRecord(inst)
+	}
 
 	// Note: the accumulator set below is created speculatively;
 	// it will be released if it is never returned.
 	newRec := &record{
 		refMapped:     newRefcountMapped(),
-		accumulator:   inst.compiled.NewAccumulator(aset),
+		accumulator:   inst.newAccumulator(aset),
 		attributeList: acpy,
 		attributeSet:  aset,
 	}
+	return insertNewRecord(inst, fp, newRec, true)
+}
 
+// insertNewRecord inserts newRec or returns the existing record
+// inserted concurrently.  When limited, the overflow record is
+// returned once the instrument has recordLimit records.
+func insertNewRecord(inst *Instrument, fp uint64, newRec *record, limited bool) *record {
 	for {
-		acquired, loaded := acquireWrite(inst, fp, newRec)
+		acquired, loaded := acquireWrite(inst, fp, newRec, limited)
 
 		if !loaded {
 			// When this happens, we are waiting for the call to delete()
@@ -395,13 +1225,76 @@ func acquireRecord[N number.Any](inst *Instrument, attrs []attribute.KeyValue) *
 		if acquired != newRec {
 			// Release the speculative accumulator, since it was not used.
 			newRec.accumulator.SnapshotAndProcess(true)
+			inst.releaseAttributes(fp, newRec.attributeList)
+		}
+		if acquired == nil {
+			return acquireOverflowRecord(inst)
 		}
 		return acquired
 	}
 }
 
+// recordsFull returns true when measurements of new attribute sets
+// use the overflow record.
+func (inst *Instrument) recordsFull() bool {
+	limit := inst.compiledViews().recordLimit
+	return limit != 0 && atomic.LoadInt64(&inst.records) >= limit
+}
+
+// overflowAttributes are the attributes of the overflow record.
+var overflowAttributes = []attribute.KeyValue{
+	attribute.Bool("otel.metric.overflow", true),
+}
+
+// acquireOverflowRecord gets or creates the record whose accumulator
+// updates the overflow point of each view, see viewstate.Overflower.
+func acquireOverflowRecord(inst *Instrument) *record {
+	fp := fingerprintAttributes(overflowAttributes)
+
+	rec := acquireRead(inst, fp, func(rec *record) bool {
+		return attributesEqual(overflowAttributes, rec.attributeList)
+	})
+	if rec != nil {
+		return rec
+	}
+
+	var acpy []attribute.KeyValue
+	var aset attribute.Set
+	if inst.pool != nil {
+		acpy, aset = inst.pool.acquire(fp, overflowAttributes)
+	} else {
+		acpy, aset = newAttributes(overflowAttributes)
+	}
+	// Note: the views may have been replaced without an
+	// overflow limit since the caller found the records full.
+	var accumulator viewstate.Accumulator
+	if o := inst.compiledViews().overflower; o != nil {
+		accumulator = o.NewOverflowAccumulator()
+	} else {
+		accumulator = inst.newAccumulator(aset)
+	}
+	newRec := &record{
+		refMapped:     newRefcountMapped(),
+		accumulator:   accumulator,
+		attributeList: acpy,
+		attributeSet:  aset,
+	}
+	return insertNewRecord(inst, fp, newRec, false)
+}
+
+// newAccumulator returns the accumulator of a new record.
+func (inst *Instrument) newAccumulator(set attribute.Set) viewstate.Accumulator {
+	views := inst.compiledViews()
+	if views.bypasser != nil {
+		return views.bypasser.NewBypassAccumulator(set)
+	}
+	return views.compiled.NewAccumulator(set)
+}
+
 // acquireWrite acquires the write lock and gets or sets a `*record`.
-func acquireWrite(inst *Instrument, fp uint64, newRec *record) (*record, bool) {
+// When limited and the instrument has recordLimit records, it
+// returns a nil record instead of setting one.
+func acquireWrite(inst *Instrument, fp uint64, newRec *record, limited bool) (*record, bool) {
 	inst.lock.Lock()
 	defer inst.lock.Unlock()
 
@@ -416,7 +1309,12 @@ func acquireWrite(inst *Instrument, fp uint64, newRec *record) (*record, bool) {
 		}
 	}
 
+	if limited && inst.recordsFull() {
+		return nil, true
+	}
+
 	newRec.next = inst.current[fp]
 	inst.current[fp] = newRec
+	atomic.AddInt64(&inst.records, 1)
 	return newRec, true
 }
@@ -17,6 +17,7 @@ package syncstate
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"sync"
@@ -37,8 +38,10 @@ import (
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/metric/instrument"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -75,6 +78,16 @@ var (
 	keyFilter = view.WithClause(
 		view.WithKeys([]attribute.Key{}),
 	)
+
+	cardinalityLimit = view.WithClause(
+		view.WithCardinalityLimit(3),
+	)
+
+	keyRename = view.WithClause(
+		view.WithAttributeRename(map[attribute.Key]attribute.Key{
+			testAttr: "renamed",
+		}),
+	)
 )
 
 func TestSyncStateDeltaConcurrencyInt(t *testing.T) {
@@ -89,6 +102,18 @@ func TestSyncStateCumulativeConcurrencyIntFiltered(t *testing.T) {
 	testSyncStateConcurrency[int64, number.Int64Traits](t, cumulativeUpdate[int64], cumulativeSelector, keyFilter)
 }
 
+func TestSyncStateCumulativeConcurrencyIntRenamed(t *testing.T) {
+	testSyncStateConcurrency[int64, number.Int64Traits](t, cumulativeUpdate[int64], cumulativeSelector, keyRename)
+}
+
+func TestSyncStateDeltaConcurrencyIntLimited(t *testing.T) {
+	testSyncStateConcurrency[int64, number.Int64Traits](t, deltaUpdate[int64], deltaSelector, cardinalityLimit)
+}
+
+func TestSyncStateCumulativeConcurrencyIntLimited(t *testing.T) {
+	testSyncStateConcurrency[int64, number.Int64Traits](t, cumulativeUpdate[int64], cumulativeSelector, cardinalityLimit)
+}
+
 func TestSyncStateDeltaConcurrencyFloat(t *testing.T) {
 	testSyncStateConcurrency[float64, number.Float64Traits](t, deltaUpdate[float64], deltaSelector)
 }
@@ -101,7 +126,35 @@ func TestSyncStateCumulativeConcurrencyFloatFiltered(t *testing.T) {
 	testSyncStateConcurrency[float64, number.Float64Traits](t, cumulativeUpdate[float64], cumulativeSelector, keyFilter)
 }
 
+func TestSyncStateDeltaConcurrencyIntInterned(t *testing.T) {
+	pool := NewInternPool()
+	testSyncStateConcurrencyOpaque[int64, number.Int64Traits](t, deltaUpdate[int64], testPoolProvider{pool}, deltaSelector)
+
+	// Records were removed after the final collections.
+	require.Equal(t, 0, pool.Len())
+}
+
+func TestSyncStateCumulativeConcurrencyFloatInterned(t *testing.T) {
+	pool := NewInternPool()
+	testSyncStateConcurrencyOpaque[float64, number.Float64Traits](t, cumulativeUpdate[float64], testPoolProvider{pool}, cumulativeSelector)
+	require.Equal(t, 0, pool.Len())
+}
+
+type testPoolProvider struct {
+	pool *InternPool
+}
+
+func (tp testPoolProvider) InternPool() *InternPool {
+	return tp.pool
+}
+
 func testSyncStateConcurrency[N number.Any, Traits number.Traits[N]](t *testing.T, update func(old, new N) N, vopts ...view.Option) {
+	testSyncStateConcurrencyOpaque[N, Traits](t, update, nil, vopts...)
+}
+
+// testSyncStateConcurrencyOpaque is testSyncStateConcurrency with
+// the opaque value passed to NewInstrument.
+func testSyncStateConcurrencyOpaque[N number.Any, Traits number.Traits[N]](t *testing.T, update func(old, new N) N, opaque interface{}, vopts ...view.Option) {
 	// Note: prior to
 	// https://github.com/lightstep/otel-launcher-go/pull/206 this
 	// code was able to reproduce the race condition handled in
@@ -126,98 +179,1382 @@ func testSyncStateConcurrency[N number.Any, Traits number.Traits[N]](t *testing.
 	lib := instrumentation.Library{
 		Name: "testlib",
 	}
-	vcs := make([]*viewstate.Compiler, numReaders)
-	for vci := range vcs {
-		vcs[vci] = viewstate.New(lib, view.New("test", vopts...))
+	vcs := make([]*viewstate.Compiler, numReaders)
+	for vci := range vcs {
+		vcs[vci] = viewstate.New(lib, view.New("test", vopts...))
+	}
+	attrs := make([]attribute.KeyValue, numAttrs)
+	for i := range attrs {
+		attrs[i] = testAttr.Int(i)
+	}
+
+	desc := test.Descriptor("tester", sdkinstrument.SyncCounter, traits.Kind())
+
+	pipes := make(pipeline.Register[viewstate.Instrument], numReaders)
+	for vci := range vcs {
+		pipes[vci], _ = vcs[vci].Compile(desc)
+	}
+
+	inst := NewInstrument(desc, opaque, pipes, nil)
+	require.NotNil(t, inst)
+
+	cntr := NewCounter[N, Traits](inst)
+	require.NotNil(t, cntr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	partialCounts := make([]map[attribute.Set]N, numReaders)
+
+	for vci := range vcs {
+		partialCounts[vci] = map[attribute.Set]N{}
+	}
+
+	// Reader loops
+	for vci := range vcs {
+		go func(vci int, partial map[attribute.Set]N, vc *viewstate.Compiler) {
+			defer readers.Done()
+
+			// scope will be reused by this reader
+			var scope data.Scope
+			seq := data.Sequence{
+				Start: time.Now(),
+			}
+			seq.Now = seq.Start
+
+			collect := func() {
+				seq.Last = seq.Now
+				seq.Now = time.Now()
+
+				inst.SnapshotAndProcess()
+
+				scope.Reset()
+
+				vc.Collectors()[0].Collect(seq, &scope.Instruments)
+
+				for _, pt := range scope.Instruments[0].Points {
+					partial[pt.Attributes] = update(partial[pt.Attributes], traits.FromNumber(pt.Aggregation.(*sum.State[N, Traits, sum.Monotonic]).Sum()))
+				}
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					collect()
+					return
+				default:
+					collect()
+				}
+			}
+		}(vci, partialCounts[vci], vcs[vci])
+	}
+
+	// Writer loops
+	for i := 0; i < numRoutines; i++ {
+		go func() {
+			defer writers.Done()
+			rnd := rand.New(rand.NewSource(rand.Int63()))
+
+			for j := 0; j < numUpdates/numRoutines; j++ {
+				cntr.Add(ctx, 1, attrs[rnd.Intn(len(attrs))])
+			}
+		}()
+	}
+
+	writers.Wait()
+	cancel()
+	readers.Wait()
+
+	// Remove the records, which are unused.
+	inst.SnapshotAndProcess()
+	inst.SnapshotAndProcess()
+
+	for vci := range vcs {
+		var sum N
+		for _, count := range partialCounts[vci] {
+			sum += count
+		}
+		require.Equal(t, N(numUpdates), sum, "vci==%d", vci)
+	}
+}
+
+// TestSyncStateRecordLimit tests that the records of an instrument
+// are bounded by the cardinality limit, and that measurements of new
+// attribute sets beyond it are counted in the overflow point.
+func TestSyncStateRecordLimit(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("test", cardinalityLimit))
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	require.NotNil(t, inst)
+
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+
+	for i := 0; i < 10; i++ {
+		cntr.Add(ctx, 1, testAttr.Int(i))
+	}
+
+	// Three records and the overflow record.
+	require.Equal(t, int64(4), inst.records)
+	require.Equal(t, 4, len(inst.current))
+
+	inst.SnapshotAndProcess()
+
+	output := test.CollectScope(t, vc.Collectors(), testSequence)
+	require.Equal(t, 1, len(output))
+
+	counts := map[attribute.Set]int64{}
+	for _, pt := range output[0].Points {
+		counts[pt.Attributes] = number.ToInt64(pt.Aggregation.(*sum.MonotonicInt64).Sum())
+	}
+	require.Equal(t, map[attribute.Set]int64{
+		attribute.NewSet(testAttr.Int(0)):                              1,
+		attribute.NewSet(testAttr.Int(1)):                              1,
+		attribute.NewSet(testAttr.Int(2)):                              1,
+		attribute.NewSet(attribute.Bool("otel.metric.overflow", true)): 7,
+	}, counts)
+
+	// Unused records are removed, making room for new sets.
+	inst.SnapshotAndProcess()
+	require.Equal(t, int64(0), inst.records)
+}
+
+func TestSyncStateReset(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	for _, tempo := range []view.Option{deltaSelector, cumulativeSelector} {
+		vc := viewstate.New(lib, view.New("test", tempo))
+
+		desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+		pipes := make(pipeline.Register[viewstate.Instrument], 1)
+		pipes[0], _ = vc.Compile(desc)
+
+		inst := NewInstrument(desc, nil, pipes, nil)
+		require.NotNil(t, inst)
+
+		cntr := NewCounter[int64, number.Int64Traits](inst)
+
+		// Some of these updates are processed, some are pending.
+		cntr.Add(ctx, 1, testAttr.Int(1))
+		inst.SnapshotAndProcess()
+		cntr.Add(ctx, 10, testAttr.Int(1))
+		cntr.Add(ctx, 100, testAttr.Int(2))
+
+		inst.Reset()
+
+		require.Equal(t, 0, len(inst.current))
+		require.Equal(t, 0, vc.Collectors()[0].Size())
+
+		// The instrument remains usable, starting from zero.
+		cntr.Add(ctx, 1000, testAttr.Int(2))
+		inst.SnapshotAndProcess()
+
+		output := test.CollectScope(t, vc.Collectors(), testSequence)
+		require.Equal(t, 1, len(output))
+		require.Equal(t, 1, len(output[0].Points))
+		require.Equal(t, attribute.NewSet(testAttr.Int(2)), output[0].Points[0].Attributes)
+		require.Equal(t, int64(1000), number.ToInt64(output[0].Points[0].Aggregation.(*sum.MonotonicInt64).Sum()))
+	}
+}
+
+func TestSyncStateSetCompiled(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	views, err := view.Validate(view.New("test"))
+	require.NoError(t, err)
+	vc := viewstate.New(lib, views)
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	require.NotNil(t, inst)
+
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+	attr := attribute.String("a", "1")
+	collect := func(value int64) {
+		t.Helper()
+		inst.SnapshotAndProcess()
+
+		test.RequireEqualMetrics(
+			t,
+			test.CollectScope(t, vc.Collectors(), testSequence),
+			test.Instrument(
+				desc,
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(value), aggregation.CumulativeTemporality, attr),
+			),
+		)
+	}
+
+	// The pending update reaches the migrated output, and the
+	// record is replaced.
+	cntr.Add(ctx, 1, attr)
+
+	_, err = vc.AddClause(view.NewClause(
+		view.MatchInstrumentName("counter"),
+		view.WithName("counter"),
+	))
+	require.NoError(t, err)
+	pipes[0], _ = vc.Lookup(desc)
+	inst.SetCompiled(pipes)
+	require.Equal(t, 0, len(inst.current))
+
+	cntr.Add(ctx, 10, attr)
+	collect(11)
+
+	// The instrument is paused while every pipeline drops it.
+	inst.SetCompiled(make(pipeline.Register[viewstate.Instrument], 1))
+	cntr.Add(ctx, 100, attr)
+	require.Equal(t, 0, len(inst.current))
+	collect(11)
+
+	inst.SetCompiled(pipes)
+	cntr.Add(ctx, 1000, attr)
+	collect(1011)
+}
+
+func TestSyncStateStats(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("test", deltaSelector))
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	require.NotNil(t, inst)
+	require.Equal(t, Stats{}, inst.Stats())
+
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+	cntr.Add(ctx, 1, testAttr.Int(1))
+	cntr.Add(ctx, 1, testAttr.Int(2))
+
+	// Both records have updates and remain in use.
+	inst.SnapshotAndProcess()
+	require.Equal(t, Stats{PendingRecords: 2, ActiveAggregators: 2}, inst.Stats())
+
+	// One record has an update, the other is unmapped.
+	cntr.Add(ctx, 1, testAttr.Int(2))
+	inst.SnapshotAndProcess()
+	require.Equal(t, Stats{PendingRecords: 2, ActiveAggregators: 1}, inst.Stats())
+
+	inst.SnapshotAndProcess()
+	require.Equal(t, Stats{PendingRecords: 1, ActiveAggregators: 0}, inst.Stats())
+
+	// A disabled instrument has no stats.
+	var disabled *Instrument
+	require.Equal(t, Stats{}, disabled.Stats())
+}
+
+// TestSyncStateNoAllocs verifies that the hot path for an existing
+// record does not allocate.
+func TestSyncStateNoAllocs(t *testing.T) {
+	ctx := context.Background()
+	cntr := newNoAllocsCounter()
+
+	one := testAttr.Int(1)
+	two := attribute.String("other", "two")
+
+	require.Equal(t, 0.0, testing.AllocsPerRun(100, func() {
+		cntr.Add(ctx, 1, one)
+	}))
+	require.Equal(t, 0.0, testing.AllocsPerRun(100, func() {
+		cntr.Add(ctx, 1, one, two)
+	}))
+
+	set := attribute.NewSet(one, two)
+	require.Equal(t, 0.0, testing.AllocsPerRun(100, func() {
+		cntr.AddSet(ctx, 1, set)
+	}))
+}
+
+func TestSyncStateAddSet(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("test"))
+
+	cdesc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	hdesc := test.Descriptor("histogram", sdkinstrument.SyncHistogram, number.Float64Kind)
+
+	cpipes := make(pipeline.Register[viewstate.Instrument], 1)
+	cpipes[0], _ = vc.Compile(cdesc)
+	hpipes := make(pipeline.Register[viewstate.Instrument], 1)
+	hpipes[0], _ = vc.Compile(hdesc)
+
+	cinst := NewInstrument(cdesc, nil, cpipes, nil)
+	hinst := NewInstrument(hdesc, nil, hpipes, nil)
+
+	var cntr sdkinstrument.SetCounter[int64] = NewCounter[int64, number.Int64Traits](cinst)
+	var hist sdkinstrument.SetHistogram[float64] = NewHistogram[float64, number.Float64Traits](hinst)
+
+	a := attribute.String("a", "1")
+	b := attribute.String("b", "2")
+	set := attribute.NewSet(a, b)
+
+	// Add with attributes in either order and AddSet share one point.
+	cntr.(Counter[int64, number.Int64Traits]).Add(ctx, 1, b, a)
+	cntr.AddSet(ctx, 10, set)
+	cntr.AddSet(ctx, 100, set)
+	cntr.AddSet(ctx, 1000, attribute.NewSet())
+
+	hist.RecordSet(ctx, 1, set)
+	hist.RecordSet(ctx, 2, set)
+
+	cinst.SnapshotAndProcess()
+	hinst.SnapshotAndProcess()
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vc.Collectors(), testSequence),
+		test.Instrument(
+			cdesc,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(111), aggregation.CumulativeTemporality, a, b),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1000), aggregation.CumulativeTemporality),
+		),
+		test.Instrument(
+			hdesc,
+			test.Point(startTime, endTime, histogram.NewFloat64(histogram.NewConfig(), 1, 2), aggregation.CumulativeTemporality, a, b),
+		),
+	)
+}
+
+func TestSyncStateAddBatch(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("test"))
+
+	cdesc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	gdesc := test.Descriptor("gauge", sdkinstrument.SyncUpDownCounter, number.Int64Kind,
+		instrument.WithDescription(`{"aggregation": "gauge"}`))
+
+	cpipes := make(pipeline.Register[viewstate.Instrument], 1)
+	cpipes[0], _ = vc.Compile(cdesc)
+	gpipes := make(pipeline.Register[viewstate.Instrument], 1)
+	gpipes[0], _ = vc.Compile(gdesc)
+
+	cinst := NewInstrument(cdesc, nil, cpipes, nil)
+	ginst := NewInstrument(gdesc, nil, gpipes, nil)
+
+	var cntr sdkinstrument.BatchCounter[int64] = NewCounter[int64, number.Int64Traits](cinst)
+	var gaug sdkinstrument.BatchCounter[int64] = NewCounter[int64, number.Int64Traits](ginst)
+
+	a := attribute.NewSet(attribute.String("a", "1"))
+	b := attribute.NewSet(attribute.String("b", "2"))
+
+	batch := []sdkinstrument.Measurement[int64]{
+		{Value: 1, Attrs: a},
+		{Value: 10, Attrs: b},
+		{Value: 3, Attrs: a},
+		{Value: 2, Attrs: a},
+		{Value: 20, Attrs: b},
+	}
+	cntr.AddBatch(ctx, batch)
+	gaug.AddBatch(ctx, batch)
+	cntr.AddBatch(ctx, nil)
+
+	cinst.SnapshotAndProcess()
+	ginst.SnapshotAndProcess()
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vc.Collectors(), testSequence),
+		test.Instrument(
+			cdesc,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(6), aggregation.CumulativeTemporality, a.ToSlice()...),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(30), aggregation.CumulativeTemporality, b.ToSlice()...),
+		),
+		test.Instrument(
+			test.Descriptor("gauge", sdkinstrument.SyncUpDownCounter, number.Int64Kind),
+			test.Point(startTime, endTime, gauge.NewInt64(2), aggregation.CumulativeTemporality, a.ToSlice()...),
+			test.Point(startTime, endTime, gauge.NewInt64(20), aggregation.CumulativeTemporality, b.ToSlice()...),
+		),
+	)
+}
+
+func TestSyncStateSetEnabled(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	cvc := viewstate.New(lib, view.New("test"))
+	dvc := viewstate.New(lib, view.New("test", deltaSelector))
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 2)
+	pipes[0], _ = cvc.Compile(desc)
+	pipes[1], _ = dvc.Compile(desc)
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+
+	var enabler sdkinstrument.Enabler = cntr
+
+	attr := attribute.String("a", "1")
+	set := attribute.NewSet(attr)
+	add := func(value int64) {
+		cntr.Add(ctx, value, attr)
+		cntr.AddSet(ctx, value, set)
+		cntr.AddBatch(ctx, []sdkinstrument.Measurement[int64]{{Value: value, Attrs: set}})
+	}
+	collect := func(cumulative, delta int64) {
+		t.Helper()
+		inst.SnapshotAndProcess()
+
+		test.RequireEqualMetrics(
+			t,
+			test.CollectScope(t, cvc.Collectors(), testSequence),
+			test.Instrument(
+				desc,
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(cumulative), aggregation.CumulativeTemporality, attr),
+			),
+		)
+		var points []data.Point
+		if delta != 0 {
+			points = append(points, test.Point(middleTime, endTime, sum.NewMonotonicInt64(delta), aggregation.DeltaTemporality, attr))
+		}
+		test.RequireEqualMetrics(
+			t,
+			test.CollectScope(t, dvc.Collectors(), testSequence),
+			test.Instrument(desc, points...),
+		)
+	}
+
+	add(1)
+	collect(3, 3)
+
+	// Pending measurements are kept while paused.
+	add(2)
+	enabler.SetEnabled(false)
+	add(100)
+	collect(3, 0)
+
+	enabler.SetEnabled(true)
+	add(3)
+	collect(18, 15)
+}
+
+func TestSyncStateContextAttributes(t *testing.T) {
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vopts := []view.Option{
+		view.WithClause(
+			view.MatchInstrumentName("counter"),
+			view.WithContextAttributes([]string{"tenant", "region"}),
+			view.WithKeys([]attribute.Key{"tenant", "a"}),
+		),
+	}
+	vc := viewstate.New(lib, view.New("test", vopts...))
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	require.Equal(t, []string{"tenant", "region"}, inst.compiledViews().contextKeys)
+
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+
+	tenant, _ := baggage.NewMember("tenant", "t1")
+	region, _ := baggage.NewMember("region", "west")
+	other, _ := baggage.NewMember("other", "x")
+	bag, _ := baggage.New(tenant, region, other)
+	bctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	a := attribute.String("a", "1")
+	set := attribute.NewSet(a)
+
+	// Baggage is added, region is removed by WithKeys, and
+	// other is not configured.
+	cntr.Add(bctx, 1, a)
+	cntr.AddSet(bctx, 10, set)
+	// Attributes take precedence over baggage.
+	cntr.Add(bctx, 100, a, attribute.String("tenant", "t2"))
+	// No baggage.
+	cntr.Add(context.Background(), 1000, a)
+
+	inst.SnapshotAndProcess()
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vc.Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(11), aggregation.CumulativeTemporality, a, attribute.String("tenant", "t1")),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(100), aggregation.CumulativeTemporality, a, attribute.String("tenant", "t2")),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1000), aggregation.CumulativeTemporality, a),
+		),
+	)
+}
+
+// TestSyncStateContextAttributesPerView tests that context attributes
+// are output only by the views that configure them.
+func TestSyncStateContextAttributesPerView(t *testing.T) {
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vcs := []*viewstate.Compiler{
+		viewstate.New(lib, view.New("tenant", view.WithClause(
+			view.MatchInstrumentName("counter"),
+			view.WithContextAttributes([]string{"tenant"}),
+		))),
+		viewstate.New(lib, view.New("plain")),
+	}
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], len(vcs))
+	for i, vc := range vcs {
+		pipes[i], _ = vc.Compile(desc)
+	}
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+
+	tenant, _ := baggage.NewMember("tenant", "t1")
+	bag, _ := baggage.New(tenant)
+	bctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	a := attribute.String("a", "1")
+
+	cntr.Add(bctx, 1, a)
+	cntr.Add(context.Background(), 10, a)
+	// Attributes take precedence over baggage, and are output
+	// by every view.
+	cntr.Add(bctx, 100, a, attribute.String("tenant", "t2"))
+
+	inst.SnapshotAndProcess()
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vcs[0].Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), aggregation.CumulativeTemporality, a, attribute.String("tenant", "t1")),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(10), aggregation.CumulativeTemporality, a),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(100), aggregation.CumulativeTemporality, a, attribute.String("tenant", "t2")),
+		),
+	)
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vcs[1].Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(11), aggregation.CumulativeTemporality, a),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(100), aggregation.CumulativeTemporality, a, attribute.String("tenant", "t2")),
+		),
+	)
+}
+
+func TestSyncStateRecordWeighted(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("sum"),
+			view.WithAggregation(aggregation.MonotonicSumKind),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("*"),
+		),
+	))
+
+	hdesc := test.Descriptor("histogram", sdkinstrument.SyncHistogram, number.Float64Kind)
+	sdesc := test.Descriptor("sum", sdkinstrument.SyncHistogram, number.Float64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(hdesc)
+	hinst := NewInstrument(hdesc, nil, pipes, nil)
+
+	pipes = make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(sdesc)
+	sinst := NewInstrument(sdesc, nil, pipes, nil)
+
+	for _, inst := range []*Instrument{hinst, sinst} {
+		var hist sdkinstrument.WeightedHistogram[float64] = NewHistogram[float64, number.Float64Traits](inst)
+
+		hist.RecordWeighted(ctx, 2, 3)
+		hist.RecordWeighted(ctx, 10, 1)
+		hist.RecordWeighted(ctx, 100, 0)
+
+		inst.SnapshotAndProcess()
+	}
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vc.Collectors(), testSequence),
+		test.Instrument(
+			hdesc,
+			test.Point(startTime, endTime, histogram.NewFloat64(histogram.NewConfig(), 2, 2, 2, 10), aggregation.CumulativeTemporality),
+		),
+		test.Instrument(
+			sdesc,
+			test.Point(startTime, endTime, sum.NewMonotonicFloat64(16), aggregation.CumulativeTemporality),
+		),
+	)
+}
+
+func TestSyncStateRecordBucket(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	bounds := []float64{1, 10}
+	vc := viewstate.New(lib, view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("explicit"),
+			view.WithAggregation(aggregation.ExplicitHistogramKind),
+			view.WithAggregatorConfig(aggregator.Config{
+				HistogramBoundaries: histogram.WithExplicitBoundaries(bounds),
+			}),
+		),
+	))
+
+	var invalid []float64
+	onError := func(_ sdkinstrument.Descriptor, value number.Number, reason error) {
+		require.Equal(t, aggregator.ErrInvalidBucket, reason)
+		invalid = append(invalid, value.CoerceToFloat64(number.Float64Kind))
+	}
+
+	desc := test.Descriptor("explicit", sdkinstrument.SyncHistogram, number.Float64Kind)
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+	inst := NewInstrument(desc, nil, pipes, onError)
+
+	hist := NewHistogram[float64, number.Float64Traits](inst)
+	var bhist sdkinstrument.BucketHistogram[float64] = hist
+
+	hist.Record(ctx, 0.5)
+	bhist.RecordBucket(ctx, 1, 4, 20)
+	hist.Record(ctx, 50)
+	bhist.RecordBucket(ctx, 2, 1, 40)
+	bhist.RecordBucket(ctx, 0, 0, 0)
+	bhist.RecordBucket(ctx, 3, 1, 1)
+	bhist.RecordBucket(ctx, -1, 1, 1)
+
+	inst.SnapshotAndProcess()
+
+	require.Equal(t, []float64{3, -1}, invalid)
+
+	// As in TestSyncStateExplicitHistogram, build the expected
+	// value by merging.
+	var methods histogram.ExplicitFloat64Methods
+	mergeIn := histogram.NewExplicitFloat64(bounds, 0.5, 50)
+	methods.UpdateBucket(mergeIn, 1, 4, 20)
+	methods.UpdateBucket(mergeIn, 2, 1, 40)
+	expectHist := histogram.NewExplicitFloat64(bounds)
+	methods.Merge(mergeIn, expectHist)
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vc.Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(startTime, endTime, expectHist, aggregation.CumulativeTemporality),
+		),
+	)
+
+	// Other aggregations do not support pre-bucketed data.
+	invalid = nil
+	xvc := viewstate.New(lib, view.New("test"))
+	pipes = make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = xvc.Compile(desc)
+	xinst := NewInstrument(desc, nil, pipes, onError)
+
+	NewHistogram[float64, number.Float64Traits](xinst).RecordBucket(ctx, 0, 1, 1)
+	require.Equal(t, []float64{0}, invalid)
+}
+
+func TestSyncStateMarshalSnapshot(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("test", cumulativeSelector))
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+	inst := NewInstrument(desc, nil, pipes, nil)
+
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+
+	attrX := attribute.String("A", "x")
+	attrY := attribute.String("A", "y")
+	snapX := []SnapshotAttribute{{Key: "A", Value: "x"}}
+	snapY := []SnapshotAttribute{{Key: "A", Value: "y"}}
+
+	decode := func() Snapshot {
+		data, err := inst.MarshalSnapshot()
+		require.NoError(t, err)
+		snap, err := UnmarshalSnapshot(data)
+		require.NoError(t, err)
+		return snap
+	}
+
+	cntr.Add(ctx, 1, attrY)
+	cntr.Add(ctx, 2, attrX)
+
+	// The updates are pending.
+	snap := decode()
+	require.Equal(t, "counter", snap.Name)
+	require.Equal(t, "SyncCounter", snap.Kind)
+	require.Equal(t, "Int64", snap.NumberKind)
+	require.Equal(t, []SnapshotRecord{
+		{Attributes: snapX, Updates: 1, Pending: 1},
+		{Attributes: snapY, Updates: 1, Pending: 1},
+	}, snap.Records)
+	require.Empty(t, snap.Points)
+
+	inst.SnapshotAndProcess()
+
+	one, two := 1.0, 2.0
+	snap = decode()
+	for _, rec := range snap.Records {
+		require.Equal(t, int64(0), rec.Pending)
+	}
+	require.Equal(t, []SnapshotPoint{
+		{Name: "counter", Aggregation: "MonotonicSum", Attributes: snapX, Sum: &two},
+		{Name: "counter", Aggregation: "MonotonicSum", Attributes: snapY, Sum: &one},
+	}, snap.Points)
+
+	// The instrument is not modified.
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vc.Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(2), aggregation.CumulativeTemporality, attrX),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), aggregation.CumulativeTemporality, attrY),
+		),
+	)
+}
+
+func TestSyncStateAddWithStartTime(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	backfill := startTime.Add(-time.Hour)
+	attrOld := attribute.String("A", "old")
+	attrNew := attribute.String("A", "new")
+
+	cvc := viewstate.New(lib, view.New("cumulative", cumulativeSelector))
+	dvc := viewstate.New(lib, view.New("delta", deltaSelector))
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 2)
+	pipes[0], _ = cvc.Compile(desc)
+	pipes[1], _ = dvc.Compile(desc)
+	inst := NewInstrument(desc, nil, pipes, nil)
+
+	var cntr sdkinstrument.BackfillCounter[int64] = NewCounter[int64, number.Int64Traits](inst)
+
+	cntr.AddWithStartTime(ctx, 1, backfill, attrOld)
+	cntr.AddWithStartTime(ctx, 1, backfill.Add(time.Minute), attrOld)
+	NewCounter[int64, number.Int64Traits](inst).Add(ctx, 2, attrNew)
+
+	inst.SnapshotAndProcess()
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, cvc.Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(backfill, endTime, sum.NewMonotonicInt64(2), aggregation.CumulativeTemporality, attrOld),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(2), aggregation.CumulativeTemporality, attrNew),
+		),
+	)
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, dvc.Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(backfill, endTime, sum.NewMonotonicInt64(2), aggregation.DeltaTemporality, attrOld),
+			test.Point(middleTime, endTime, sum.NewMonotonicInt64(2), aggregation.DeltaTemporality, attrNew),
+		),
+	)
+
+	// The backfilled start time applies to one delta interval.
+	NewCounter[int64, number.Int64Traits](inst).Add(ctx, 3, attrOld)
+
+	inst.SnapshotAndProcess()
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, dvc.Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(middleTime, endTime, sum.NewMonotonicInt64(3), aggregation.DeltaTemporality, attrOld),
+		),
+	)
+}
+
+func TestSyncGaugeStaleAfter(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New(
+		"test",
+		cumulativeSelector,
+		view.WithClause(
+			view.WithAggregatorConfig(aggregator.Config{
+				GaugeStaleAfter: gauge.WithStaleAfter(time.Minute),
+			}),
+		),
+	))
+
+	indesc := test.Descriptor(
+		"syncgauge",
+		sdkinstrument.SyncUpDownCounter,
+		number.Int64Kind,
+		instrument.WithDescription(`{"aggregation": "gauge"}`),
+	)
+	desc := test.Descriptor("syncgauge", sdkinstrument.SyncUpDownCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(indesc)
+	inst := NewInstrument(indesc, nil, pipes, nil)
+
+	sg := NewCounter[int64, number.Int64Traits](inst)
+
+	collectAt := func(now time.Time) []data.Instrument {
+		inst.SnapshotAndProcess()
+		return test.CollectScope(t, vc.Collectors(), data.Sequence{
+			Start: startTime,
+			Last:  now,
+			Now:   now,
+		})
+	}
+
+	sg.Add(ctx, 10, attribute.String("A", "fresh"))
+	sg.Add(ctx, 20, attribute.String("A", "stale"))
+	first := time.Now()
+
+	output := collectAt(first)
+	require.Equal(t, 2, len(output[0].Points))
+
+	time.Sleep(100 * time.Millisecond)
+
+	// Only the updated gauge is reported one minute after the
+	// first updates.
+	sg.Add(ctx, 11, attribute.String("A", "fresh"))
+
+	test.RequireEqualMetrics(
+		t,
+		collectAt(first.Add(time.Minute).Add(50*time.Millisecond)),
+		test.Instrument(
+			desc,
+			test.Point(time.Time{}, time.Time{}, gauge.NewInt64(11), aggregation.CumulativeTemporality, attribute.String("A", "fresh")),
+		),
+	)
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestSyncGaugeStaleDrop(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New(
+		"test",
+		cumulativeSelector,
+		view.WithClause(
+			view.WithStaleGaugeDrop(time.Minute),
+		),
+	))
+	clock := &testClock{now: startTime}
+	vc.SetClock(clock)
+
+	desc := test.Descriptor("gauge", sdkinstrument.SyncGauge, number.Float64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+	inst := NewInstrument(desc, nil, pipes, nil)
+
+	g := NewGauge[float64, number.Float64Traits](inst)
+
+	collectAt := func(now time.Time) []data.Instrument {
+		inst.SnapshotAndProcess()
+		return test.CollectScope(t, vc.Collectors(), data.Sequence{
+			Start: startTime,
+			Last:  now,
+			Now:   now,
+		})
+	}
+
+	g.Record(ctx, 1, attribute.String("A", "fresh"))
+	g.Record(ctx, 2, attribute.String("A", "stale"))
+
+	output := collectAt(startTime.Add(time.Minute))
+	require.Equal(t, 2, len(output[0].Points))
+
+	clock.now = startTime.Add(time.Minute)
+	g.Record(ctx, 3, attribute.String("A", "fresh"))
+
+	output = collectAt(startTime.Add(time.Minute + time.Second))
+	require.Equal(t, 1, len(output[0].Points))
+	require.Equal(t, attribute.NewSet(attribute.String("A", "fresh")), output[0].Points[0].Attributes)
+
+	// The stale aggregator was reclaimed.
+	require.Equal(t, 1, vc.Collectors()[0].(interface{ Size() int }).Size())
+}
+
+func TestSyncAttributeSetTTL(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New(
+		"test",
+		cumulativeSelector,
+		view.WithClause(
+			view.WithAttributeSetTTL(time.Minute),
+		),
+	))
+	clock := &testClock{now: startTime}
+	vc.SetClock(clock)
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+	inst := NewInstrument(desc, nil, pipes, nil)
+
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+
+	collectAt := func(d time.Duration) []data.Instrument {
+		clock.now = startTime.Add(d)
+		inst.SnapshotAndProcess()
+		return test.CollectScope(t, vc.Collectors(), data.Sequence{
+			Start: startTime,
+			Last:  clock.now,
+			Now:   clock.now,
+		})
+	}
+	size := func() int {
+		return vc.Collectors()[0].(interface{ Size() int }).Size()
+	}
+
+	cntr.Add(ctx, 1, attribute.String("A", "kept"))
+	cntr.Add(ctx, 1, attribute.String("A", "expired"))
+	require.Equal(t, 2, len(collectAt(0)[0].Points))
+
+	clock.now = startTime.Add(30 * time.Second)
+	cntr.Add(ctx, 1, attribute.String("A", "kept"))
+	require.Equal(t, 2, len(collectAt(30 * time.Second)[0].Points))
+
+	// The untouched attribute set is reclaimed a minute after
+	// its last update.
+	end := startTime.Add(70 * time.Second)
+	test.RequireEqualMetrics(
+		t,
+		collectAt(70*time.Second),
+		test.Instrument(
+			desc,
+			test.Point(startTime, end, sum.NewMonotonicInt64(2), aggregation.CumulativeTemporality, attribute.String("A", "kept")),
+		),
+	)
+	require.Equal(t, 1, size())
+
+	// When it reappears, it starts a new series.
+	restart := startTime.Add(80 * time.Second)
+	clock.now = restart
+	cntr.Add(ctx, 5, attribute.String("A", "expired"))
+
+	end = startTime.Add(85 * time.Second)
+	test.RequireEqualMetrics(
+		t,
+		collectAt(85*time.Second),
+		test.Instrument(
+			desc,
+			test.Point(startTime, end, sum.NewMonotonicInt64(2), aggregation.CumulativeTemporality, attribute.String("A", "kept")),
+			test.Point(restart, end, sum.NewMonotonicInt64(5), aggregation.CumulativeTemporality, attribute.String("A", "expired")),
+		),
+	)
+	require.Equal(t, 2, size())
+
+	// The kept set expires too, while the new series is kept.
+	test.RequireEqualMetrics(
+		t,
+		collectAt(130*time.Second),
+		test.Instrument(
+			desc,
+			test.Point(restart, startTime.Add(130*time.Second), sum.NewMonotonicInt64(5), aggregation.CumulativeTemporality, attribute.String("A", "expired")),
+		),
+	)
+	require.Equal(t, 1, size())
+}
+
+// TestSyncRoute tests that attribute sets routed to one pipeline are
+// not delivered to the others, which receive the remainder.
+func TestSyncRoute(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	critical := attribute.String("tier", "critical")
+	isCritical := func(set attribute.Set) bool {
+		value, _ := set.Value("tier")
+		return value == critical.Value
+	}
+	vcs := []*viewstate.Compiler{
+		viewstate.New(lib, view.New("critical", view.WithClause(view.WithRoute(isCritical)))),
+		viewstate.New(lib, view.New("default")),
+		viewstate.New(lib, view.New("also critical", view.WithClause(view.WithRoute(isCritical)))),
+	}
+
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], len(vcs))
+	for i, vc := range vcs {
+		pipes[i], _ = vc.Compile(desc)
+	}
+	inst := NewInstrument(desc, nil, pipes, nil)
+	cntr := NewCounter[int64, number.Int64Traits](inst)
+
+	cntr.Add(ctx, 1, critical)
+	cntr.Add(ctx, 2, attribute.String("tier", "low"))
+	cntr.Add(ctx, 3)
+	inst.SnapshotAndProcess()
+
+	criticalMetrics := test.Instrument(
+		desc,
+		test.Point(startTime, endTime, sum.NewMonotonicInt64(1), aggregation.CumulativeTemporality, critical),
+	)
+	test.RequireEqualMetrics(t, test.CollectScope(t, vcs[0].Collectors(), testSequence), criticalMetrics)
+	test.RequireEqualMetrics(t, test.CollectScope(t, vcs[2].Collectors(), testSequence), criticalMetrics)
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(t, vcs[1].Collectors(), testSequence),
+		test.Instrument(
+			desc,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), aggregation.CumulativeTemporality),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(2), aggregation.CumulativeTemporality, attribute.String("tier", "low")),
+		),
+	)
+}
+
+func BenchmarkSyncStateCounterAddOneAttr(b *testing.B) {
+	ctx := context.Background()
+	cntr := newNoAllocsCounter()
+	attr := testAttr.Int(1)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		cntr.Add(ctx, 1, attr)
+	}
+}
+
+// BenchmarkSyncStateConcurrency compares repeated Add() with
+// AddBatch() under the concurrency of testSyncStateConcurrency:
+// parallel writers of a few attribute sets and a collecting reader.
+func BenchmarkSyncStateConcurrency(b *testing.B) {
+	const (
+		numAttrs  = 10
+		batchSize = 100
+	)
+	ctx := context.Background()
+	sets := make([]attribute.Set, numAttrs)
+	for i := range sets {
+		sets[i] = attribute.NewSet(testAttr.Int(i))
+	}
+
+	run := func(b *testing.B, write func(cntr Counter[int64, number.Int64Traits], batch []sdkinstrument.Measurement[int64])) {
+		insts, cntrs := newSharedCounters(1, nil)
+		done := make(chan struct{})
+		var reader sync.WaitGroup
+		reader.Add(1)
+		go func() {
+			defer reader.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					insts[0].SnapshotAndProcess()
+				}
+			}
+		}()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			batch := make([]sdkinstrument.Measurement[int64], batchSize)
+			for i := range batch {
+				batch[i] = sdkinstrument.Measurement[int64]{Value: 1, Attrs: sets[i%numAttrs]}
+			}
+			for pb.Next() {
+				write(cntrs[0], batch)
+			}
+		})
+		b.StopTimer()
+		close(done)
+		reader.Wait()
+	}
+
+	b.Run("Add", func(b *testing.B) {
+		run(b, func(cntr Counter[int64, number.Int64Traits], batch []sdkinstrument.Measurement[int64]) {
+			for _, m := range batch {
+				cntr.AddSet(ctx, m.Value, m.Attrs)
+			}
+		})
+	})
+	b.Run("AddBatch", func(b *testing.B) {
+		run(b, func(cntr Counter[int64, number.Int64Traits], batch []sdkinstrument.Measurement[int64]) {
+			cntr.AddBatch(ctx, batch)
+		})
+	})
+}
+
+// BenchmarkSyncStateReaders measures collection by an increasing
+// number of readers, each with its own pipeline, while parallel
+// writers update a counter.  Each reader collects b.N times;
+// concurrent readers share snapshots, so the time per operation
+// should not grow in proportion to the number of readers.
+func BenchmarkSyncStateReaders(b *testing.B) {
+	const (
+		numWriters = 4
+		numAttrs   = 10
+	)
+	for _, numReaders := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprint("readers=", numReaders), func(b *testing.B) {
+			ctx := context.Background()
+			lib := instrumentation.Library{
+				Name: "testlib",
+			}
+			desc := test.Descriptor("tester", sdkinstrument.SyncCounter, number.Int64Kind)
+
+			vcs := make([]*viewstate.Compiler, numReaders)
+			pipes := make(pipeline.Register[viewstate.Instrument], numReaders)
+			for vci := range vcs {
+				vcs[vci] = viewstate.New(lib, view.New("test"))
+				pipes[vci], _ = vcs[vci].Compile(desc)
+			}
+			inst := NewInstrument(desc, nil, pipes, nil)
+			cntr := NewCounter[int64, number.Int64Traits](inst)
+
+			sets := make([]attribute.Set, numAttrs)
+			for i := range sets {
+				sets[i] = attribute.NewSet(testAttr.Int(i))
+			}
+
+			done := make(chan struct{})
+			var writers sync.WaitGroup
+			writers.Add(numWriters)
+			for w := 0; w < numWriters; w++ {
+				go func(w int) {
+					defer writers.Done()
+					for i := w; ; i++ {
+						select {
+						case <-done:
+							return
+						default:
+							cntr.AddSet(ctx, 1, sets[i%numAttrs])
+						}
+					}
+				}(w)
+			}
+
+			var readers sync.WaitGroup
+			readers.Add(numReaders)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for vci := range vcs {
+				go func(vc *viewstate.Compiler) {
+					defer readers.Done()
+					var scope data.Scope
+					for i := 0; i < b.N; i++ {
+						inst.SnapshotAndProcess()
+						scope.Reset()
+						vc.Collectors()[0].Collect(testSequence, &scope.Instruments)
+					}
+				}(vcs[vci])
+			}
+			readers.Wait()
+			b.StopTimer()
+			close(done)
+			writers.Wait()
+		})
+	}
+}
+
+func TestSyncStateInternPool(t *testing.T) {
+	ctx := context.Background()
+	pool := NewInternPool()
+	insts, cntrs := newSharedCounters(3, testPoolProvider{pool})
+
+	a := attribute.String("a", "1")
+	b := attribute.String("b", "2")
+
+	for _, cntr := range cntrs {
+		cntr.Add(ctx, 1, a, b)
+	}
+	// Out of order, the sorted copy matches the same entry.
+	cntrs[0].Add(ctx, 1, b, a)
+	require.Equal(t, 1, pool.Len())
+
+	var recs []*record
+	for _, inst := range insts {
+		for _, rec := range inst.current {
+			for ; rec != nil; rec = rec.next {
+				recs = append(recs, rec)
+			}
+		}
+	}
+	require.Equal(t, 3, len(recs))
+	for _, rec := range recs[1:] {
+		require.Equal(t, &recs[0].attributeList[0], &rec.attributeList[0])
+		require.Equal(t, recs[0].attributeSet, rec.attributeSet)
 	}
-	attrs := make([]attribute.KeyValue, numAttrs)
-	for i := range attrs {
-		attrs[i] = testAttr.Int(i)
+
+	for _, inst := range insts {
+		inst.SnapshotAndProcess()
+		inst.SnapshotAndProcess()
 	}
+	require.Equal(t, 0, pool.Len())
+}
 
-	desc := test.Descriptor("tester", sdkinstrument.SyncCounter, traits.Kind())
+// BenchmarkSyncStateSharedAttributes measures the cost of creating a
+// record for attributes that another instrument is using.
+func BenchmarkSyncStateSharedAttributes(b *testing.B) {
+	b.Run("nopool", func(b *testing.B) {
+		benchmarkSyncStateSharedAttributes(b, nil)
+	})
+	b.Run("pool", func(b *testing.B) {
+		benchmarkSyncStateSharedAttributes(b, testPoolProvider{NewInternPool()})
+	})
+}
 
-	pipes := make(pipeline.Register[viewstate.Instrument], numReaders)
-	for vci := range vcs {
-		pipes[vci], _ = vcs[vci].Compile(desc)
+func benchmarkSyncStateSharedAttributes(b *testing.B, opaque interface{}) {
+	ctx := context.Background()
+	insts, cntrs := newSharedCounters(10, opaque)
+	attrs := []attribute.KeyValue{
+		attribute.String("a", "1"),
+		attribute.String("b", "2"),
+		attribute.String("c", "3"),
+		attribute.String("d", "4"),
 	}
 
-	inst := NewInstrument(desc, nil, pipes)
-	require.NotNil(t, inst)
+	// The first instrument is never collected, keeping its record.
+	cntrs[0].Add(ctx, 1, attrs...)
+	b.ReportAllocs()
+	b.ResetTimer()
 
-	cntr := NewCounter[N, Traits](inst)
-	require.NotNil(t, cntr)
+	for i := 0; i < b.N; i++ {
+		k := 1 + i%(len(cntrs)-1)
+		cntrs[k].Add(ctx, 1, attrs...)
+		insts[k].SnapshotAndProcess()
+		insts[k].SnapshotAndProcess()
+	}
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
+func newSharedCounters(n int, opaque interface{}) ([]*Instrument, []Counter[int64, number.Int64Traits]) {
+	vc := viewstate.New(instrumentation.Library{
+		Name: "testlib",
+	}, view.New("test"))
 
-	partialCounts := make([]map[attribute.Set]N, numReaders)
+	insts := make([]*Instrument, n)
+	cntrs := make([]Counter[int64, number.Int64Traits], n)
+	for i := range insts {
+		desc := test.Descriptor(fmt.Sprint("counter", i), sdkinstrument.SyncCounter, number.Int64Kind)
 
-	for vci := range vcs {
-		partialCounts[vci] = map[attribute.Set]N{}
+		pipes := make(pipeline.Register[viewstate.Instrument], 1)
+		pipes[0], _ = vc.Compile(desc)
+
+		insts[i] = NewInstrument(desc, opaque, pipes, nil)
+		cntrs[i] = NewCounter[int64, number.Int64Traits](insts[i])
 	}
+	return insts, cntrs
+}
 
-	// Reader loops
-	for vci := range vcs {
-		go func(vci int, partial map[attribute.Set]N, vc *viewstate.Compiler) {
-			defer readers.Done()
+func newNoAllocsCounter() Counter[int64, number.Int64Traits] {
+	vc := viewstate.New(instrumentation.Library{
+		Name: "testlib",
+	}, view.New("test"))
 
-			// scope will be reused by this reader
-			var scope data.Scope
-			seq := data.Sequence{
-				Start: time.Now(),
-			}
-			seq.Now = seq.Start
+	desc := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
 
-			collect := func() {
-				seq.Last = seq.Now
-				seq.Now = time.Now()
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
 
-				inst.SnapshotAndProcess()
+	return NewCounter[int64, number.Int64Traits](NewInstrument(desc, nil, pipes, nil))
+}
 
-				scope.Reset()
+func TestSyncStateHistogramExemplars(t *testing.T) {
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("test", view.WithClause(
+		view.WithAggregatorConfig(aggregator.Config{
+			HistogramExemplars: histogram.DefaultExemplars,
+		}),
+	)))
 
-				vc.Collectors()[0].Collect(seq, &scope.Instruments)
+	desc := test.Descriptor("latency", sdkinstrument.SyncHistogram, number.Float64Kind)
 
-				for _, pt := range scope.Instruments[0].Points {
-					partial[pt.Attributes] = update(partial[pt.Attributes], traits.FromNumber(pt.Aggregation.(*sum.State[N, Traits, sum.Monotonic]).Sum()))
-				}
-			}
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
 
-			for {
-				select {
-				case <-ctx.Done():
-					collect()
-					return
-				default:
-					collect()
-				}
-			}
-		}(vci, partialCounts[vci], vcs[vci])
-	}
+	inst := NewInstrument(desc, nil, pipes, nil)
+	require.NotNil(t, inst)
 
-	// Writer loops
-	for i := 0; i < numRoutines; i++ {
-		go func() {
-			defer writers.Done()
-			rnd := rand.New(rand.NewSource(rand.Int63()))
+	hist := NewHistogram[float64, number.Float64Traits](inst)
 
-			for j := 0; j < numUpdates/numRoutines; j++ {
-				cntr.Add(ctx, 1, attrs[rnd.Intn(len(attrs))])
-			}
-		}()
-	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	hist.Record(context.Background(), 1)
+	hist.Record(trace.ContextWithSpanContext(context.Background(), sc), 2)
 
-	writers.Wait()
-	cancel()
-	readers.Wait()
+	inst.SnapshotAndProcess()
 
-	for vci := range vcs {
-		var sum N
-		for _, count := range partialCounts[vci] {
-			sum += count
-		}
-		require.Equal(t, N(numUpdates), sum, "vci==%d", vci)
-	}
+	output := test.CollectScope(t, vc.Collectors(), testSequence)
+	require.Equal(t, 1, len(output))
+	require.Equal(t, 1, len(output[0].Points))
+
+	exemplars := output[0].Points[0].Aggregation.(aggregation.HasExemplars).Exemplars()
+	require.Equal(t, 1, len(exemplars))
+	require.Equal(t, 2.0, number.ToFloat64(exemplars[0].Value))
+	require.Equal(t, sc, exemplars[0].SpanContext)
 }
 
 func TestSyncStatePartialNoopInstrument(t *testing.T) {
@@ -244,7 +1581,7 @@ func TestSyncStatePartialNoopInstrument(t *testing.T) {
 	require.Nil(t, pipes[0])
 	require.NotNil(t, pipes[1])
 
-	inst := NewInstrument(desc, nil, pipes)
+	inst := NewInstrument(desc, nil, pipes, nil)
 	require.NotNil(t, inst)
 
 	hist := NewHistogram[float64, number.Float64Traits](inst)
@@ -291,6 +1628,234 @@ func TestSyncStatePartialNoopInstrument(t *testing.T) {
 	)
 }
 
+func TestSyncStateExplicitHistogram(t *testing.T) {
+	ctx := context.Background()
+	bounds := []float64{0.1, 0.25, 0.5, 1, 2.5, 5}
+	vopts := []view.Option{
+		view.WithClause(
+			view.MatchInstrumentName("slo"),
+			view.WithAggregation(aggregation.HistogramKind),
+			view.WithAggregatorConfig(aggregator.Config{
+				HistogramBoundaries: histogram.WithExplicitBoundaries(bounds),
+			}),
+		),
+	}
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("explicit", vopts...))
+
+	desc := test.Descriptor("slo", sdkinstrument.SyncHistogram, number.Float64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	require.NotNil(t, inst)
+
+	hist := NewHistogram[float64, number.Float64Traits](inst)
+	require.NotNil(t, hist)
+
+	hist.Record(ctx, 0.1)
+	hist.Record(ctx, 0.3)
+	hist.Record(ctx, 7)
+
+	inst.SnapshotAndProcess()
+
+	// As in TestSyncStatePartialNoopInstrument, build the
+	// expected value by merging.
+	expectHist := histogram.NewExplicitFloat64(bounds)
+	mergeIn := histogram.NewExplicitFloat64(bounds, 0.1, 0.3, 7)
+	var methods histogram.ExplicitFloat64Methods
+	methods.Merge(mergeIn, expectHist)
+
+	test.RequireEqualMetrics(
+		t,
+		test.CollectScope(
+			t,
+			vc.Collectors(),
+			testSequence,
+		),
+		test.Instrument(
+			desc,
+			test.Point(startTime, endTime,
+				expectHist,
+				aggregation.CumulativeTemporality,
+			),
+		),
+	)
+	require.Equal(t, []uint64{1, 0, 1, 0, 0, 0, 1}, expectHist.BucketCounts())
+
+	// The cumulative min and max merge across collections.
+	hist.Record(ctx, 0.05)
+	hist.Record(ctx, 3)
+
+	inst.SnapshotAndProcess()
+
+	output := test.CollectScope(t, vc.Collectors(), testSequence)
+	require.Equal(t, 1, len(output))
+	require.Equal(t, 1, len(output[0].Points))
+
+	agg := output[0].Points[0].Aggregation.(aggregation.OptionalMinMax)
+	require.True(t, agg.HasMinMax())
+	require.Equal(t, 0.05, number.ToFloat64(agg.Min()))
+	require.Equal(t, 7.0, number.ToFloat64(agg.Max()))
+}
+
+// TestSyncStateDeterministic tests that cumulative histogram and
+// gauge output does not depend on when collection happens.
+func TestSyncStateDeterministic(t *testing.T) {
+	ctx := context.Background()
+	bounds := []float64{0, 1, 10}
+	values := []float64{3, 0.5, 20, -1, 7}
+
+	for _, ik := range []sdkinstrument.Kind{sdkinstrument.SyncHistogram, sdkinstrument.SyncGauge} {
+		var outputs [][]data.Instrument
+
+		for _, every := range []int{0, 1, 2} {
+			vc := viewstate.New(instrumentation.Library{Name: "testlib"}, view.New("test",
+				view.WithDefaultAggregationConfigSelector(func(sdkinstrument.Kind) (int64Config, float64Config aggregator.Config) {
+					return aggregator.Config{}, aggregator.Config{
+						HistogramBoundaries: histogram.WithExplicitBoundaries(bounds),
+					}
+				}),
+			))
+			desc := test.Descriptor("inst", ik, number.Float64Kind)
+
+			pipes := make(pipeline.Register[viewstate.Instrument], 1)
+			pipes[0], _ = vc.Compile(desc)
+			inst := NewInstrument(desc, nil, pipes, nil)
+
+			var updates []func()
+			for _, value := range values {
+				value := value
+				if ik == sdkinstrument.SyncGauge {
+					g := NewGauge[float64, number.Float64Traits](inst)
+					updates = append(updates, func() { g.Record(ctx, value) })
+				} else if value >= 0 {
+					h := NewHistogram[float64, number.Float64Traits](inst)
+					updates = append(updates, func() { h.Record(ctx, value) })
+				}
+			}
+
+			outputs = append(outputs, test.RunDeterministic(t, inst, vc.Collectors(), testSequence, updates, every))
+		}
+
+		expect := test.Instrument(
+			test.Descriptor("inst", ik, number.Float64Kind),
+			test.Point(startTime, endTime,
+				histogram.NewExplicitFloat64(bounds, 3, 0.5, 20, 7),
+				aggregation.CumulativeTemporality,
+			),
+		)
+		if ik == sdkinstrument.SyncGauge {
+			expect.Points[0].Aggregation = gauge.NewFloat64(7)
+		}
+		for _, output := range outputs {
+			test.RequireEqualMetrics(t, output, expect)
+		}
+	}
+}
+
+func TestSyncStateTwoHistogramLayouts(t *testing.T) {
+	ctx := context.Background()
+	bounds := []float64{10, 100, 1000}
+	vopts := []view.Option{
+		view.WithClause(
+			view.MatchInstrumentName("latency"),
+			view.WithName("latency.exponential"),
+			view.WithAggregation(aggregation.HistogramKind),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("latency"),
+			view.WithName("latency.explicit"),
+			view.WithAggregatorConfig(aggregator.Config{
+				HistogramBoundaries: histogram.WithExplicitBoundaries(bounds),
+			}),
+		),
+	}
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("layouts", vopts...))
+
+	desc := test.Descriptor("latency", sdkinstrument.SyncHistogram, number.Float64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	var conflicts viewstate.ViewConflictsBuilder
+	pipes[0], conflicts = vc.Compile(desc)
+	require.NoError(t, conflicts.AsError())
+	require.Equal(t, 2, len(vc.Collectors()))
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	hist := NewHistogram[float64, number.Float64Traits](inst)
+
+	const (
+		numRoutines = 10
+		numRecords  = 1000
+	)
+	// Collect concurrently with the workload.
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				inst.SnapshotAndProcess()
+			}
+		}
+	}()
+	for r := 0; r < numRoutines; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			attr := attribute.Int("routine", r%2)
+			for i := 1; i <= numRecords; i++ {
+				hist.Record(ctx, float64(i), attr)
+			}
+		}(r)
+	}
+	wg.Wait()
+	close(stop)
+	<-stopped
+
+	inst.SnapshotAndProcess()
+
+	output := test.CollectScope(t, vc.Collectors(), testSequence)
+	require.Equal(t, 2, len(output))
+
+	const expectCount = numRoutines / 2 * numRecords
+	const expectSum = numRoutines / 2 * numRecords * (numRecords + 1) / 2
+
+	for _, out := range output {
+		require.Equal(t, 2, len(out.Points), "%v", out.Descriptor.Name)
+		for _, pt := range out.Points {
+			switch agg := pt.Aggregation.(type) {
+			case aggregation.Histogram:
+				require.Equal(t, "latency.exponential", out.Descriptor.Name)
+				require.Equal(t, uint64(expectCount), agg.Count())
+				require.Equal(t, float64(expectSum), number.ToFloat64(agg.Sum()))
+			case aggregation.ExplicitHistogram:
+				require.Equal(t, "latency.explicit", out.Descriptor.Name)
+				require.Equal(t, uint64(expectCount), agg.Count())
+				require.Equal(t, float64(expectSum), number.ToFloat64(agg.Sum()))
+				require.Equal(t, []uint64{
+					numRoutines / 2 * 10,
+					numRoutines / 2 * 90,
+					numRoutines / 2 * 900,
+					0,
+				}, agg.BucketCounts())
+			default:
+				t.Fatalf("unexpected aggregation %T", agg)
+			}
+		}
+	}
+}
+
 func TestSyncStateFullNoopInstrument(t *testing.T) {
 	ctx := context.Background()
 	vopts := []view.Option{
@@ -315,7 +1880,7 @@ func TestSyncStateFullNoopInstrument(t *testing.T) {
 	require.Nil(t, pipes[0])
 	require.Nil(t, pipes[1])
 
-	inst := NewInstrument(desc, nil, pipes)
+	inst := NewInstrument(desc, nil, pipes, nil)
 	require.Nil(t, inst)
 
 	hist := NewHistogram[float64, number.Float64Traits](inst)
@@ -328,6 +1893,10 @@ func TestSyncStateFullNoopInstrument(t *testing.T) {
 	// There's no instrument, nothing to Snapshot
 	require.Equal(t, 0, len(vcs[0].Collectors()))
 	require.Equal(t, 0, len(vcs[1].Collectors()))
+
+	// Reset and Stats are safe on the nil instrument.
+	inst.Reset()
+	require.Equal(t, Stats{}, inst.Stats())
 }
 
 func TestOutOfRangeValues(t *testing.T) {
@@ -351,7 +1920,7 @@ func TestOutOfRangeValues(t *testing.T) {
 		pipes := make(pipeline.Register[viewstate.Instrument], 1)
 		pipes[0], _ = vcs[0].Compile(desc)
 
-		inst := NewInstrument(desc, nil, pipes)
+		inst := NewInstrument(desc, nil, pipes, nil)
 		require.NotNil(t, inst)
 
 		var negOne aggregation.Aggregation
@@ -421,6 +1990,60 @@ func TestOutOfRangeValues(t *testing.T) {
 	require.True(t, haveNeg)
 }
 
+// TestUint64Instruments tests that uint64 counters saturate instead
+// of overflowing and that uint64 histograms use MinMaxSumCount.
+func TestUint64Instruments(t *testing.T) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New("test"))
+
+	cdesc := test.Descriptor("cu", sdkinstrument.SyncCounter, number.Uint64Kind)
+	hdesc := test.Descriptor("hu", sdkinstrument.SyncHistogram, number.Uint64Kind)
+
+	cpipes := make(pipeline.Register[viewstate.Instrument], 1)
+	cpipes[0], _ = vc.Compile(cdesc)
+	hpipes := make(pipeline.Register[viewstate.Instrument], 1)
+	hpipes[0], _ = vc.Compile(hdesc)
+
+	cinst := NewInstrument(cdesc, nil, cpipes, nil)
+	hinst := NewInstrument(hdesc, nil, hpipes, nil)
+	require.NotNil(t, cinst)
+	require.NotNil(t, hinst)
+
+	cntr := NewCounter[uint64, number.Uint64Traits](cinst)
+	cntr.Add(ctx, math.MaxUint64-1)
+	cntr.Add(ctx, 1)
+	cntr.Add(ctx, 1)
+
+	hist := NewHistogram[uint64, number.Uint64Traits](hinst)
+	hist.Record(ctx, 3)
+	hist.Record(ctx, 7)
+
+	cinst.SnapshotAndProcess()
+	hinst.SnapshotAndProcess()
+
+	output := test.CollectScope(t, vc.Collectors(), testSequence)
+	require.Equal(t, 2, len(output))
+
+	test.RequireEqualMetrics(t,
+		output[:1],
+		test.Instrument(
+			cdesc,
+			test.Point(startTime, endTime, sum.NewMonotonicUint64(math.MaxUint64), aggregation.CumulativeTemporality),
+		),
+	)
+
+	require.Equal(t, 1, len(output[1].Points))
+	mmsc := output[1].Points[0].Aggregation.(aggregation.MinMaxSumCount)
+	require.Equal(t, aggregation.MinMaxSumCountKind, mmsc.Kind())
+	require.Equal(t, uint64(2), mmsc.Count())
+	require.Equal(t, uint64(10), number.ToUint64(mmsc.Sum()))
+	require.Equal(t, uint64(3), number.ToUint64(mmsc.Min()))
+	require.Equal(t, uint64(7), number.ToUint64(mmsc.Max()))
+}
+
 func TestSyncGaugeDeltaInstrument(t *testing.T) {
 	ctx := context.Background()
 	lib := instrumentation.Library{
@@ -432,6 +2055,9 @@ func TestSyncGaugeDeltaInstrument(t *testing.T) {
 		deltaSelector,
 		view.WithClause(
 			view.WithKeys([]attribute.Key{"A", "C"}),
+			view.WithAggregatorConfig(aggregator.Config{
+				GaugeTimestamps: true,
+			}),
 		),
 	))
 
@@ -456,7 +2082,7 @@ func TestSyncGaugeDeltaInstrument(t *testing.T) {
 
 	require.NotNil(t, pipes[0])
 
-	inst := NewInstrument(indesc, nil, pipes)
+	inst := NewInstrument(indesc, nil, pipes, nil)
 	require.NotNil(t, inst)
 
 	sg := NewCounter[float64, number.Float64Traits](inst)
@@ -498,17 +2124,27 @@ func TestSyncGaugeDeltaInstrument(t *testing.T) {
 	)
 
 	// Set again
+	before := time.Now()
 	sg.Add(ctx, 172)
 	sg.Add(ctx, 175)
+	after := time.Now()
 
 	inst.SnapshotAndProcess()
+	output := test.CollectScope(
+		t,
+		vcs[0].Collectors(),
+		testSequence,
+	)
+	require.Equal(t, 1, len(output[0].Points))
+
+	// The point carries the time of the update.
+	updated := output[0].Points[0].Aggregation.(aggregation.TimestampedGauge).LastUpdateTime()
+	require.False(t, updated.Before(before))
+	require.False(t, updated.After(after))
+
 	test.RequireEqualMetrics(
 		t,
-		test.CollectScope(
-			t,
-			vcs[0].Collectors(),
-			testSequence,
-		),
+		output,
 		test.Instrument(
 			outdesc,
 			test.Point(middleTime, endTime,
@@ -579,6 +2215,105 @@ func TestSyncGaugeDeltaInstrument(t *testing.T) {
 	)
 }
 
+func TestSyncHistogramDeltaInstrument(t *testing.T) {
+	bounds := []float64{1, 10, 100}
+	for _, tc := range []struct {
+		name   string
+		acfg   aggregator.Config
+		expect func(...float64) aggregation.Aggregation
+	}{
+		{
+			name: "explicit",
+			acfg: aggregator.Config{
+				HistogramBoundaries: histogram.WithExplicitBoundaries(bounds),
+			},
+			expect: func(fs ...float64) aggregation.Aggregation {
+				return histogram.NewExplicitFloat64(bounds, fs...)
+			},
+		},
+		{
+			name: "exponential",
+			expect: func(fs ...float64) aggregation.Aggregation {
+				return histogram.NewFloat64(histogram.NewConfig(), fs...)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testSyncHistogramDeltaInstrument(t, tc.acfg, tc.expect)
+		})
+	}
+}
+
+func testSyncHistogramDeltaInstrument(t *testing.T, acfg aggregator.Config, expect func(...float64) aggregation.Aggregation) {
+	ctx := context.Background()
+	lib := instrumentation.Library{
+		Name: "testlib",
+	}
+	vc := viewstate.New(lib, view.New(
+		"test",
+		deltaSelector,
+		view.WithClause(
+			view.WithAggregation(aggregation.HistogramKind),
+			view.WithAggregatorConfig(acfg),
+		),
+	))
+
+	desc := test.Descriptor("hist", sdkinstrument.SyncHistogram, number.Float64Kind)
+
+	pipes := make(pipeline.Register[viewstate.Instrument], 1)
+	pipes[0], _ = vc.Compile(desc)
+
+	inst := NewInstrument(desc, nil, pipes, nil)
+	require.NotNil(t, inst)
+
+	hist := NewHistogram[float64, number.Float64Traits](inst)
+
+	// Each collection's window starts at the previous Now.
+	times := []time.Time{startTime}
+	for i := 1; i <= 4; i++ {
+		times = append(times, startTime.Add(time.Duration(i)*time.Second))
+	}
+	collect := func(i int) []data.Instrument {
+		inst.SnapshotAndProcess()
+		return test.CollectScope(t, vc.Collectors(), data.Sequence{
+			Start: startTime,
+			Last:  times[i-1],
+			Now:   times[i],
+		})
+	}
+
+	hist.Record(ctx, 2)
+	hist.Record(ctx, 50)
+
+	test.RequireEqualMetrics(t, collect(1),
+		test.Instrument(desc,
+			test.Point(times[0], times[1], expect(2, 50), aggregation.DeltaTemporality),
+		),
+	)
+
+	// The histogram resets after each collection.
+	hist.Record(ctx, 500)
+
+	test.RequireEqualMetrics(t, collect(2),
+		test.Instrument(desc,
+			test.Point(times[1], times[2], expect(500), aggregation.DeltaTemporality),
+		),
+	)
+
+	// With no records, the point is omitted.
+	test.RequireEqualMetrics(t, collect(3),
+		test.Instrument(desc),
+	)
+
+	hist.Record(ctx, 0.5)
+
+	test.RequireEqualMetrics(t, collect(4),
+		test.Instrument(desc,
+			test.Point(times[3], times[4], expect(0.5), aggregation.DeltaTemporality),
+		),
+	)
+}
+
 func TestFingerprinting(t *testing.T) {
 	// Coverage
 	require.NotEqual(
@@ -754,7 +2489,7 @@ func TestDuplicateFingerprint(t *testing.T) {
 	require.NotNil(t, pipes[0])
 	require.NotNil(t, pipes[1])
 
-	inst := NewInstrument(desc, nil, pipes)
+	inst := NewInstrument(desc, nil, pipes, nil)
 	require.NotNil(t, inst)
 
 	sg := NewCounter[float64, number.Float64Traits](inst)
@@ -0,0 +1,257 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/test"
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DiffMetrics returns a readable description of the differences
+// between the expected and actual instruments, or the empty string
+// when they are equivalent.  Instruments are compared in order, as
+// for RequireEqualMetrics, and points are matched by attribute set.
+// For each point, the temporality, the start and end times, and the
+// aggregation are compared.  Zero times in the expectation match any
+// time.
+//
+// Aggregations are compared by value through the aggregation
+// interfaces, not by their internal structure, so that an aggregator
+// built by merging equals one built by updating with the same values.
+// Gauge sequence numbers are ignored, as are gauge update times when
+// the expectation has none.
+func DiffMetrics(expected, actual []data.Instrument) string {
+	var d differ
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		switch {
+		case i >= len(actual):
+			d.linef("instrument %d %q: missing", i, expected[i].Descriptor.Name)
+		case i >= len(expected):
+			d.linef("instrument %d %q: unexpected", i, actual[i].Descriptor.Name)
+		default:
+			d.diffInstrument(i, &expected[i], &actual[i])
+		}
+	}
+	return d.String()
+}
+
+// differ accumulates the lines of a diff.
+type differ struct {
+	strings.Builder
+}
+
+func (d *differ) linef(format string, args ...interface{}) {
+	fmt.Fprintf(d, format, args...)
+	d.WriteByte('\n')
+}
+
+// field writes a differing field of the current point or instrument.
+func (d *differ) field(name, expected, actual string) {
+	d.linef("    - %s: %s", name, expected)
+	d.linef("    + %s: %s", name, actual)
+}
+
+func (d *differ) diffInstrument(idx int, expected, actual *data.Instrument) {
+	var inner differ
+
+	ed, ad := expected.Descriptor, actual.Descriptor
+	if ed.Name != ad.Name {
+		inner.field("name", ed.Name, ad.Name)
+	}
+	if ed.Kind != ad.Kind {
+		inner.field("kind", ed.Kind.String(), ad.Kind.String())
+	}
+	if ed.NumberKind != ad.NumberKind {
+		inner.field("number kind", ed.NumberKind.String(), ad.NumberKind.String())
+	}
+	if ed.Description != ad.Description {
+		inner.field("description", strconv.Quote(ed.Description), strconv.Quote(ad.Description))
+	}
+	if ed.Unit != ad.Unit {
+		inner.field("unit", strconv.Quote(string(ed.Unit)), strconv.Quote(string(ad.Unit)))
+	}
+
+	expectByKey := pointsByKey(expected.Points)
+	actualByKey := pointsByKey(actual.Points)
+
+	for _, key := range sortedKeys(expectByKey, actualByKey) {
+		eps, aps := expectByKey[key], actualByKey[key]
+		for i := 0; i < len(eps) || i < len(aps); i++ {
+			switch {
+			case i >= len(aps):
+				inner.linef("  point {%s}: missing", key)
+			case i >= len(eps):
+				inner.linef("  point {%s}: unexpected", key)
+			default:
+				inner.diffPoint(key, ed.NumberKind, &eps[i], &aps[i])
+			}
+		}
+	}
+
+	if inner.Len() != 0 {
+		d.linef("instrument %d %q:", idx, ed.Name)
+		d.WriteString(inner.String())
+	}
+}
+
+func (d *differ) diffPoint(key string, nk number.Kind, expected, actual *data.Point) {
+	var inner differ
+
+	if expected.Temporality != actual.Temporality {
+		inner.field("temporality", expected.Temporality.String(), actual.Temporality.String())
+	}
+	if !expected.Start.IsZero() && !expected.Start.Equal(actual.Start) {
+		inner.field("start", formatTime(expected.Start), formatTime(actual.Start))
+	}
+	if !expected.End.IsZero() && !expected.End.Equal(actual.End) {
+		inner.field("end", formatTime(expected.End), formatTime(actual.End))
+	}
+	ea := formatAggregation(expected.Aggregation, nk, nil)
+	aa := formatAggregation(actual.Aggregation, nk, expected.Aggregation)
+	if ea != aa {
+		inner.field("aggregation", ea, aa)
+	}
+
+	if inner.Len() != 0 {
+		d.linef("  point {%s}:", key)
+		d.WriteString(inner.String())
+	}
+}
+
+// pointsByKey groups points by their encoded attribute set.
+func pointsByKey(points []data.Point) map[string][]data.Point {
+	enc := attribute.DefaultEncoder()
+	byKey := map[string][]data.Point{}
+	for _, pt := range points {
+		key := pt.Attributes.Encoded(enc)
+		byKey[key] = append(byKey[key], pt)
+	}
+	return byKey
+}
+
+// sortedKeys returns the keys of both maps in order.
+func sortedKeys(a, b map[string][]data.Point) []string {
+	var keys []string
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatNumber(n number.Number, nk number.Kind) string {
+	switch nk {
+	case number.Int64Kind:
+		return strconv.FormatInt(number.ToInt64(n), 10)
+	case number.Uint64Kind:
+		return strconv.FormatUint(number.ToUint64(n), 10)
+	}
+	return strconv.FormatFloat(number.ToFloat64(n), 'g', -1, 64)
+}
+
+// formatAggregation describes the value of agg.  When expect is not
+// nil, it is the expected aggregation, whose gauge update time
+// decides whether the update time of agg is included.
+func formatAggregation(agg aggregation.Aggregation, nk number.Kind, expect aggregation.Aggregation) string {
+	if agg == nil {
+		return "nil"
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimSuffix(agg.Kind().String(), "Kind"))
+
+	item := func(name, value string) {
+		fmt.Fprintf(&b, " %s=%s", name, value)
+	}
+
+	switch a := agg.(type) {
+	case aggregation.Sum:
+		item("sum", formatNumber(a.Sum(), nk))
+		item("monotonic", strconv.FormatBool(a.IsMonotonic()))
+	case aggregation.Gauge:
+		item("gauge", formatNumber(a.Gauge(), nk))
+		if cg, ok := a.(aggregation.ClampedGauge); ok && cg.ClampedCount() != 0 {
+			item("clamped", strconv.FormatUint(cg.ClampedCount(), 10))
+		}
+		if tg, ok := a.(aggregation.TimestampedGauge); ok && !tg.LastUpdateTime().IsZero() {
+			if et, ok := expect.(aggregation.TimestampedGauge); expect == nil || (ok && !et.LastUpdateTime().IsZero()) {
+				item("updated", formatTime(tg.LastUpdateTime()))
+			}
+		}
+	case aggregation.Histogram:
+		item("count", strconv.FormatUint(a.Count(), 10))
+		item("sum", formatNumber(a.Sum(), nk))
+		if a.Count() != 0 {
+			item("min", formatNumber(a.Min(), nk))
+			item("max", formatNumber(a.Max(), nk))
+		}
+		item("scale", strconv.Itoa(int(a.Scale())))
+		item("zero", strconv.FormatUint(a.ZeroCount(), 10))
+		item("positive", formatBuckets(a.Positive()))
+		item("negative", formatBuckets(a.Negative()))
+	case aggregation.ExplicitHistogram:
+		item("count", strconv.FormatUint(a.Count(), 10))
+		item("sum", formatNumber(a.Sum(), nk))
+		if mm, ok := a.(aggregation.OptionalMinMax); ok && mm.HasMinMax() {
+			item("min", formatNumber(mm.Min(), nk))
+			item("max", formatNumber(mm.Max(), nk))
+		}
+		item("boundaries", fmt.Sprint(a.Boundaries()))
+		item("counts", fmt.Sprint(a.BucketCounts()))
+	case aggregation.Summary:
+		item("count", strconv.FormatUint(a.Count(), 10))
+		item("sum", formatNumber(a.Sum(), nk))
+		for _, q := range a.Quantiles() {
+			item("q"+strconv.FormatFloat(q.Quantile, 'g', -1, 64), strconv.FormatFloat(q.Value, 'g', -1, 64))
+		}
+	case aggregation.MinMaxSumCount:
+		item("count", strconv.FormatUint(a.Count(), 10))
+		item("sum", formatNumber(a.Sum(), nk))
+		item("min", formatNumber(a.Min(), nk))
+		item("max", formatNumber(a.Max(), nk))
+	default:
+		fmt.Fprintf(&b, " %+v", agg)
+	}
+	return b.String()
+}
+
+// formatBuckets lists the non-empty buckets by index, so that
+// buckets that differ only by empty leading or trailing buckets are
+// equal.
+func formatBuckets(buckets aggregation.Buckets) string {
+	var parts []string
+	for i := uint32(0); i < buckets.Len(); i++ {
+		if c := buckets.At(i); c != 0 {
+			parts = append(parts, fmt.Sprintf("%d:%d", int64(buckets.Offset())+int64(i), c))
+		}
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
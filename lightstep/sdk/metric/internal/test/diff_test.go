@@ -0,0 +1,95 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	diffEnd   = time.Unix(1000, 0)
+	diffStart = diffEnd.Add(-time.Minute)
+
+	cumulative = aggregation.CumulativeTemporality
+	delta      = aggregation.DeltaTemporality
+)
+
+func TestDiffMetricsEqual(t *testing.T) {
+	// Copying into an empty histogram produces a different
+	// struct with the same value.
+	copied := histogram.NewFloat64(histogram.NewConfig())
+	var methods histogram.Float64Methods
+	methods.Copy(histogram.NewFloat64(histogram.NewConfig(), 1, 2, 3), copied)
+
+	desc := Descriptor("hist", sdkinstrument.SyncHistogram, number.Float64Kind)
+	expect := []data.Instrument{
+		Instrument(desc,
+			Point(diffStart, diffEnd, histogram.NewFloat64(histogram.NewConfig(), 1, 2, 3), cumulative, attribute.Int("a", 1)),
+			Point(time.Time{}, time.Time{}, sum.NewMonotonicFloat64(1), cumulative),
+		),
+	}
+	actual := []data.Instrument{
+		Instrument(desc,
+			Point(diffStart, diffEnd, sum.NewMonotonicFloat64(1), cumulative),
+			Point(diffStart, diffEnd, copied, cumulative, attribute.Int("a", 1)),
+		),
+	}
+
+	require.Equal(t, "", DiffMetrics(expect, actual))
+}
+
+func TestDiffMetricsDifferent(t *testing.T) {
+	desc := Descriptor("cnt", sdkinstrument.SyncCounter, number.Int64Kind)
+	other := Descriptor("cnt", sdkinstrument.SyncUpDownCounter, number.Int64Kind)
+
+	expect := []data.Instrument{
+		Instrument(desc,
+			Point(diffStart, diffEnd, sum.NewMonotonicInt64(1), cumulative, attribute.String("k", "changed")),
+			Point(diffStart, diffEnd, sum.NewMonotonicInt64(1), cumulative, attribute.String("k", "missing")),
+		),
+		Instrument(desc),
+	}
+	actual := []data.Instrument{
+		Instrument(other,
+			Point(diffEnd, diffEnd, sum.NewMonotonicInt64(2), delta, attribute.String("k", "changed")),
+			Point(diffStart, diffEnd, sum.NewMonotonicInt64(1), cumulative, attribute.String("k", "unexpected")),
+		),
+	}
+
+	require.Equal(t, `instrument 0 "cnt":
+    - kind: SyncCounter
+    + kind: SyncUpDownCounter
+  point {k=changed}:
+    - temporality: CumulativeTemporality
+    + temporality: DeltaTemporality
+    - start: 1970-01-01T00:15:40Z
+    + start: 1970-01-01T00:16:40Z
+    - aggregation: MonotonicSum sum=1 monotonic=true
+    + aggregation: MonotonicSum sum=2 monotonic=true
+  point {k=missing}: missing
+  point {k=unexpected}: unexpected
+instrument 1 "cnt": missing
+`, DiffMetrics(expect, actual))
+}
@@ -95,17 +95,49 @@ func CollectScopeReuse(t *testing.T, collectors []data.Collector, seq data.Seque
 	return output.Instruments
 }
 
+// Snapshotter is implemented by synchronous instruments, see
+// syncstate.Instrument.
+type Snapshotter interface {
+	SnapshotAndProcess()
+}
+
+// RunDeterministic applies each of the updates to inst in order on
+// the calling goroutine.  After every collectEvery updates and once
+// more at the end, the instrument is processed and the collectors
+// are collected using CollectScope.  The output of the final
+// collection is returned; with cumulative temporality this reflects
+// every update.  When collectEvery is zero, only the final collection
+// happens.
+func RunDeterministic(t *testing.T, inst Snapshotter, collectors []data.Collector, seq data.Sequence, updates []func(), collectEvery int) []data.Instrument {
+	t.Helper()
+
+	for i, update := range updates {
+		update()
+
+		if collectEvery > 0 && (i+1)%collectEvery == 0 && i+1 < len(updates) {
+			inst.SnapshotAndProcess()
+			_ = CollectScope(t, collectors, seq)
+		}
+	}
+	inst.SnapshotAndProcess()
+	return CollectScope(t, collectors, seq)
+}
+
 func RequireEqualPoints(t *testing.T, output []data.Point, expected ...data.Point) {
 	t.Helper()
 
 	require.Equal(t, len(output), len(expected), "points have different length")
+	if len(expected) == 0 {
+		return
+	}
 
 	cpy := make([]data.Point, len(expected))
 	copy(cpy, expected)
 
 	// If the expectations have zero timestamps, the output
 	// timestamps are zeroed so they will match exactly.  Gauge
-	// sequence numbers are set to match test conditions.
+	// sequence numbers are set to match test conditions, as are
+	// gauge update times when the expectation has none.
 	for idx := range cpy {
 		exp := &cpy[idx]
 		out := &output[idx]
@@ -120,13 +152,24 @@ func RequireEqualPoints(t *testing.T, output []data.Point, expected ...data.Poin
 
 		if outig, ok := out.Aggregation.(*gauge.Int64); ok {
 			outig.SetSequenceForTesting()
+			if expig, ok := exp.Aggregation.(*gauge.Int64); ok && expig.LastUpdateTime().IsZero() {
+				outig.ClearUpdateTimeForTesting()
+			}
 		}
 		if outfg, ok := out.Aggregation.(*gauge.Float64); ok {
 			outfg.SetSequenceForTesting()
+			if expfg, ok := exp.Aggregation.(*gauge.Float64); ok && expfg.LastUpdateTime().IsZero() {
+				outfg.ClearUpdateTimeForTesting()
+			}
 		}
 	}
 
-	require.ElementsMatch(t, cpy, output)
+	// Sorting both produces a readable difference when they do
+	// not match.
+	data.SortPoints(cpy)
+	data.SortPoints(output)
+
+	require.Equal(t, cpy, output)
 }
 
 // RequireEqualMetrics checks that an output equals the expected
@@ -139,6 +182,12 @@ func RequireEqualMetrics(
 	expected ...data.Instrument) {
 	t.Helper()
 
+	// The diff is computed first because RequireEqualPoints
+	// modifies the output to match the expectation.
+	if diff := DiffMetrics(expected, output); diff != "" {
+		t.Logf("metrics differ (- expected, + actual):\n%s", diff)
+	}
+
 	require.Equal(t, len(expected), len(output))
 
 	for idx := range output {
@@ -15,22 +15,67 @@
 package viewstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 
 import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/doevery"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 )
 
 // compiledSyncBase is any synchronous instrument view.
 type compiledSyncBase[N number.Any, Storage any, Methods aggregator.Methods[N, Storage]] struct {
-	instrumentBase[N, Storage, int64, Methods]
+	instrumentBase[N, Storage, syncAuxiliary, Methods]
+
+	// expiry orders the entries of the data map by the time they
+	// may expire, when there is an attribute-set TTL.  Protected
+	// by instLock.
+	expiry expiryQueue[Storage]
 }
 
 // NewAccumulator returns a Accumulator for a synchronous instrument view.
 func (c *compiledSyncBase[N, Storage, Methods]) NewAccumulator(kvs attribute.Set) Accumulator {
-	sc := &syncAccumulator[N, Storage, Methods]{}
+	return c.adaptInput(c.newAccumulator(c.applyKeysFilter(kvs)))
+}
+
+// NewBypassAccumulator returns a Accumulator for a synchronous
+// instrument view that uses the attribute set as-is.
+func (c *compiledSyncBase[N, Storage, Methods]) NewBypassAccumulator(kvs attribute.Set) Accumulator {
+	return c.adaptInput(c.newAccumulator(kvs))
+}
+
+// RecordLimit implements Overflower.  Only when attribute sets are
+// output unmodified does each measurement attribute set have its own
+// output, so that the cardinality limit bounds them.
+func (c *compiledSyncBase[N, Storage, Methods]) RecordLimit() int {
+	if c.keysFilter != nil || c.rename != nil || c.normalize != nil || c.valueLimit != 0 || len(c.contextKeys) != 0 || c.route != nil {
+		return 0
+	}
+	return c.limit
+}
+
+// NewOverflowAccumulator implements Overflower.
+func (c *compiledSyncBase[N, Storage, Methods]) NewOverflowAccumulator() Accumulator {
+	return c.adaptInput(c.newAccumulator(c.overflow()))
+}
+
+// newAccumulator returns a Accumulator for a filtered attribute set.
+func (c *compiledSyncBase[N, Storage, Methods]) newAccumulator(kvs attribute.Set) Accumulator {
+	sc := &syncAccumulator[N, Storage, Methods]{
+		transform:         c.transform,
+		restartOnOverflow: c.acfg.SumOverflow == aggregator.SumOverflowReset,
+	}
+	if c.ttl != 0 {
+		sc.now = c.now
+	}
 	c.initStorage(&sc.current)
 	c.initStorage(&sc.snapshot)
 
@@ -38,21 +83,157 @@ func (c *compiledSyncBase[N, Storage, Methods]) NewAccumulator(kvs attribute.Set
 	return sc
 }
 
-// findStorage locates the output Storage and adds to the auxiliary
-// reference count for synchronous instruments.
+// findStorage locates the output Storage of a filtered attribute set
+// and adds to the auxiliary reference count for synchronous
+// instruments.
 func (c *compiledSyncBase[N, Storage, Methods]) findStorage(
 	kvs attribute.Set,
-) *storageHolder[Storage, int64] {
-	kvs = c.applyKeysFilter(kvs)
-
+) *storageHolder[Storage, syncAuxiliary] {
 	c.instLock.Lock()
 	defer c.instLock.Unlock()
 
 	entry := c.getOrCreateEntry(kvs)
-	atomic.AddInt64(&entry.auxiliary, 1)
+	if c.ttl != 0 && entry.auxiliary.created == 0 {
+		// The entry is new.  Its key is the overflow set when
+		// the cardinality limit is reached.
+		set := kvs
+		if c.data[set] != entry {
+			set = c.overflow()
+		}
+		now := c.now().UnixNano()
+		entry.auxiliary.created = now
+		entry.auxiliary.touched = now
+		heap.Push(&c.expiry, expiryItem[Storage]{
+			deadline: now + int64(c.ttl),
+			set:      set,
+			entry:    entry,
+		})
+	}
+	atomic.AddInt64(&entry.auxiliary.refs, 1)
 	return entry
 }
 
+// Reclaim removes the entries that were not updated within the
+// attribute-set TTL and have no Accumulator references.  Entries are
+// examined in order of the time they may expire, so the cost is
+// proportional to the number of entries that are due, not the size
+// of the map.
+func (c *compiledSyncBase[N, Storage, Methods]) Reclaim() {
+	if c.ttl == 0 {
+		return
+	}
+	now := c.now().UnixNano()
+
+	c.instLock.Lock()
+	defer c.instLock.Unlock()
+
+	var retry []expiryItem[Storage]
+	for len(c.expiry) != 0 && c.expiry[0].deadline <= now {
+		item := heap.Pop(&c.expiry).(expiryItem[Storage])
+
+		if c.data[item.set] != item.entry {
+			// The entry was removed by Collect or Reset.
+			continue
+		}
+		if touched := atomic.LoadInt64(&item.entry.auxiliary.touched); now-touched < int64(c.ttl) {
+			item.deadline = touched + int64(c.ttl)
+			heap.Push(&c.expiry, item)
+			continue
+		}
+		if atomic.LoadInt64(&item.entry.auxiliary.refs) != 0 {
+			// Expired but still referenced; check again
+			// after the references are released.
+			retry = append(retry, item)
+			continue
+		}
+		delete(c.data, item.set)
+	}
+	for _, item := range retry {
+		heap.Push(&c.expiry, item)
+	}
+}
+
+// migrateFrom moves the data and expiry queue of an equivalent
+// instrument into this one.
+func (c *compiledSyncBase[N, Storage, Methods]) migrateFrom(prev leafInstrument) bool {
+	if !c.instrumentBase.migrateFrom(prev) {
+		return false
+	}
+	other := prev.(interface {
+		syncBase() *compiledSyncBase[N, Storage, Methods]
+	}).syncBase()
+
+	other.instLock.Lock()
+	defer other.instLock.Unlock()
+	c.instLock.Lock()
+	defer c.instLock.Unlock()
+
+	c.expiry, other.expiry = other.expiry, nil
+	return true
+}
+
+func (c *compiledSyncBase[N, Storage, Methods]) syncBase() *compiledSyncBase[N, Storage, Methods] {
+	return c
+}
+
+// expiryItem is an entry of the data map with the time it may
+// expire, in Unix nanoseconds.
+type expiryItem[Storage any] struct {
+	deadline int64
+	set      attribute.Set
+	entry    *storageHolder[Storage, syncAuxiliary]
+}
+
+// expiryQueue is a heap.Interface ordered by deadline.
+type expiryQueue[Storage any] []expiryItem[Storage]
+
+func (q expiryQueue[Storage]) Len() int {
+	return len(q)
+}
+
+func (q expiryQueue[Storage]) Less(i, j int) bool {
+	return q[i].deadline < q[j].deadline
+}
+
+func (q expiryQueue[Storage]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *expiryQueue[Storage]) Push(x any) {
+	*q = append(*q, x.(expiryItem[Storage]))
+}
+
+func (q *expiryQueue[Storage]) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = expiryItem[Storage]{}
+	*q = old[:n-1]
+	return item
+}
+
+// Reset resets the output storage for a synchronous instrument view.
+func (c *compiledSyncBase[N, Storage, Methods]) Reset() {
+	var methods Methods
+	var discard Storage
+	c.initStorage(&discard)
+
+	c.instLock.Lock()
+	defer c.instLock.Unlock()
+
+	for set, entry := range c.data {
+		if atomic.LoadInt64(&entry.auxiliary.refs) == 0 {
+			delete(c.data, set)
+			continue
+		}
+		// Move is synchronized against concurrent Merge().
+		methods.Move(&entry.storage, &discard)
+		atomic.StoreInt64(&entry.auxiliary.backfill, 0)
+		atomic.StoreUint32(&entry.auxiliary.dirty, 1)
+	}
+	c.resetTime = c.now()
+}
+
 // compiledAsyncBase is any asynchronous instrument view.
 type compiledAsyncBase[N number.Any, Storage any, Methods aggregator.Methods[N, Storage]] struct {
 	instrumentBase[N, Storage, notUsed, Methods]
@@ -60,10 +241,12 @@ type compiledAsyncBase[N number.Any, Storage any, Methods aggregator.Methods[N,
 
 // NewAccumulator returns a Accumulator for an asynchronous instrument view.
 func (c *compiledAsyncBase[N, Storage, Methods]) NewAccumulator(kvs attribute.Set) Accumulator {
-	ac := &asyncAccumulator[N, Storage, Methods]{}
+	ac := &asyncAccumulator[N, Storage, Methods]{
+		transform: c.transform,
+	}
 
 	ac.holder = c.findStorage(kvs)
-	return ac
+	return c.adaptInput(ac)
 }
 
 // findStorage locates the output Storage for asynchronous instruments.
@@ -78,6 +261,15 @@ func (c *compiledAsyncBase[N, Storage, Methods]) findStorage(
 	return c.getOrCreateEntry(kvs)
 }
 
+// Reset clears the output storage for an asynchronous instrument view.
+func (c *compiledAsyncBase[N, Storage, Methods]) Reset() {
+	c.instLock.Lock()
+	defer c.instLock.Unlock()
+
+	c.data = map[attribute.Set]*storageHolder[Storage, notUsed]{}
+	c.resetTime = c.now()
+}
+
 // multiAccumulator
 type multiAccumulator[N number.Any] []Accumulator
 
@@ -87,12 +279,91 @@ func (a multiAccumulator[N]) SnapshotAndProcess(release bool) {
 	}
 }
 
+func (a multiAccumulator[N]) Snapshot() {
+	for _, coll := range a {
+		coll.Snapshot()
+	}
+}
+
+func (a multiAccumulator[N]) Process(release bool) {
+	for _, coll := range a {
+		coll.Process(release)
+	}
+}
+
 func (a multiAccumulator[N]) Update(value N) {
 	for _, coll := range a {
 		coll.(Updater[N]).Update(value)
 	}
 }
 
+func (a multiAccumulator[N]) UpdateContext(ctx context.Context, value N) {
+	for _, coll := range a {
+		coll.(ContextUpdater[N]).UpdateContext(ctx, value)
+	}
+}
+
+func (a multiAccumulator[N]) UpdateWeighted(ctx context.Context, value N, weight uint64) {
+	for _, coll := range a {
+		coll.(WeightedUpdater[N]).UpdateWeighted(ctx, value, weight)
+	}
+}
+
+func (a multiAccumulator[N]) UpdateBucket(index int, count uint64, sum N) bool {
+	ok := true
+	for _, coll := range a {
+		ok = coll.(BucketUpdater[N]).UpdateBucket(index, count, sum) && ok
+	}
+	return ok
+}
+
+func (a multiAccumulator[N]) UpdateWithStartTime(ctx context.Context, value N, start time.Time) {
+	for _, coll := range a {
+		coll.(StartTimeUpdater[N]).UpdateWithStartTime(ctx, value, start)
+	}
+}
+
+func (a multiAccumulator[N]) TakeOverflow() (value N, overflow bool) {
+	for _, coll := range a {
+		if v, ok := coll.(OverflowReporter[N]).TakeOverflow(); ok && !overflow {
+			value, overflow = v, true
+		}
+	}
+	return value, overflow
+}
+
+// promotedAccumulator adapts the Accumulator of a float64 output to
+// measurements of another number kind, see adaptInput.
+type promotedAccumulator[N number.Any] struct {
+	Accumulator
+}
+
+func (a promotedAccumulator[N]) Update(value N) {
+	a.Accumulator.(Updater[float64]).Update(float64(value))
+}
+
+func (a promotedAccumulator[N]) UpdateContext(ctx context.Context, value N) {
+	a.Accumulator.(ContextUpdater[float64]).UpdateContext(ctx, float64(value))
+}
+
+func (a promotedAccumulator[N]) UpdateWeighted(ctx context.Context, value N, weight uint64) {
+	a.Accumulator.(WeightedUpdater[float64]).UpdateWeighted(ctx, float64(value), weight)
+}
+
+func (a promotedAccumulator[N]) UpdateBucket(index int, count uint64, sum N) bool {
+	return a.Accumulator.(BucketUpdater[float64]).UpdateBucket(index, count, float64(sum))
+}
+
+func (a promotedAccumulator[N]) UpdateWithStartTime(ctx context.Context, value N, start time.Time) {
+	a.Accumulator.(StartTimeUpdater[float64]).UpdateWithStartTime(ctx, float64(value), start)
+}
+
+// TakeOverflow implements OverflowReporter.  Float64 sums do not
+// overflow.
+func (a promotedAccumulator[N]) TakeOverflow() (value N, overflow bool) {
+	return 0, false
+}
+
 // syncAccumulator
 type syncAccumulator[N number.Any, Storage any, Methods aggregator.Methods[N, Storage]] struct {
 	// syncLock prevents two readers from calling
@@ -100,24 +371,251 @@ type syncAccumulator[N number.Any, Storage any, Methods aggregator.Methods[N, St
 	syncLock sync.Mutex
 	current  Storage
 	snapshot Storage
-	holder   *storageHolder[Storage, int64]
+	holder   *storageHolder[Storage, syncAuxiliary]
+
+	// pending is true when snapshot has not been processed, and
+	// pendingDirty is the dirty flag of the snapshot.  These are
+	// protected by syncLock.
+	pending      bool
+	pendingDirty uint32
+
+	transform *valueTransform
+
+	// now (if non-nil) is the clock used to record the time the
+	// holder is updated, for the attribute-set TTL.
+	now func() time.Time
+
+	// restartOnOverflow is set for the aggregator.SumOverflowReset
+	// policy, to flag the holder when its sum restarts.
+	restartOnOverflow bool
+
+	// dirty is set to 1 by updates and cleared when current is
+	// moved into the snapshot, updated atomically.
+	dirty uint32
+}
+
+// markDirty flags the accumulator as updated.  The flag is tested
+// first, so that repeated updates only read it.
+func (a *syncAccumulator[N, Storage, Methods]) markDirty() {
+	if atomic.LoadUint32(&a.dirty) == 0 {
+		atomic.StoreUint32(&a.dirty, 1)
+	}
+}
+
+// valueTransform is a view's transform of measurements, shared by
+// the accumulators of an output.
+type valueTransform struct {
+	fn   func(float64) float64
+	desc sdkinstrument.Descriptor
+
+	// custom is the view's value transform, nil for a unit
+	// conversion only.
+	custom func(float64) float64
+
+	// factor is the unit conversion factor, zero for none.
+	factor float64
+}
+
+// newValueTransform returns the transform of a view behavior, nil if
+// none.
+func newValueTransform(behavior singleBehavior) *valueTransform {
+	if behavior.transform == nil && behavior.unitFactor == 0 {
+		return nil
+	}
+	fn := behavior.transform
+	if behavior.unitFactor != 0 {
+		fn = scaleTransform(fn, behavior.unitFactor)
+	}
+	return &valueTransform{
+		fn:     fn,
+		desc:   behavior.desc,
+		custom: behavior.transform,
+		factor: behavior.unitFactor,
+	}
+}
+
+// equal tests whether two transforms are known to be equal, i.e.,
+// neither is set or both are the same unit conversion.
+func (vt *valueTransform) equal(other *valueTransform) bool {
+	if vt == nil || other == nil {
+		return vt == other
+	}
+	return vt.custom == nil && other.custom == nil && vt.factor == other.factor
+}
+
+// rangeTest tests a transformed value like the measurement was
+// tested, returning false for NaN and infinite values and for
+// negative values of counters and histograms, unless the measurement
+// was negative, i.e., accepted as a negative histogram value.
+func (vt *valueTransform) rangeTest(input, value float64) bool {
+	var err error
+	switch {
+	case math.IsNaN(value):
+		err = aggregator.ErrNaNInput
+	case math.IsInf(value, 0):
+		err = aggregator.ErrInfInput
+	case value < 0 && input >= 0 && (vt.desc.Kind == sdkinstrument.SyncCounter || vt.desc.Kind == sdkinstrument.SyncHistogram):
+		err = aggregator.ErrNegativeInput
+	default:
+		return true
+	}
+	doevery.TimePeriod(30*time.Second, func() {
+		otel.Handle(fmt.Errorf("%s: transformed value: %w", vt.desc.Name, err))
+	})
+	return false
+}
+
+// applyTransform returns the value after a view's transform, if any,
+// and false when the transformed value fails the range test.
+func applyTransform[N number.Any](transform *valueTransform, value N) (N, bool) {
+	if transform == nil {
+		return value, true
+	}
+	input := float64(value)
+	output := transform.fn(input)
+	if !transform.rangeTest(input, output) {
+		return value, false
+	}
+	return N(output), true
 }
 
 func (a *syncAccumulator[N, Storage, Methods]) Update(number N) {
 	var methods Methods
+	number, ok := applyTransform(a.transform, number)
+	if !ok {
+		return
+	}
 	methods.Update(&a.current, number)
+	a.markDirty()
 }
 
-func (a *syncAccumulator[N, Storage, Methods]) SnapshotAndProcess(release bool) {
+func (a *syncAccumulator[N, Storage, Methods]) UpdateContext(ctx context.Context, number N) {
 	var methods Methods
+	number, ok := applyTransform(a.transform, number)
+	if !ok {
+		return
+	}
+	if cm, ok := any(methods).(aggregator.ContextMethods[N, Storage]); ok {
+		cm.UpdateContext(ctx, &a.current, number)
+	} else {
+		methods.Update(&a.current, number)
+	}
+	a.markDirty()
+}
+
+func (a *syncAccumulator[N, Storage, Methods]) UpdateWeighted(ctx context.Context, number N, weight uint64) {
+	var methods Methods
+	if wm, ok := any(methods).(aggregator.WeightedMethods[N, Storage]); ok {
+		number, ok := applyTransform(a.transform, number)
+		if !ok {
+			return
+		}
+		wm.UpdateWeighted(ctx, &a.current, number, weight)
+		a.markDirty()
+		return
+	}
+	a.UpdateContext(ctx, number)
+}
+
+// UpdateBucket implements BucketUpdater.  A value transform cannot
+// be applied to pre-bucketed data.
+func (a *syncAccumulator[N, Storage, Methods]) UpdateBucket(index int, count uint64, sum N) bool {
+	var methods Methods
+	if bm, ok := any(methods).(aggregator.BucketMethods[N, Storage]); ok && a.transform == nil {
+		if !bm.UpdateBucket(&a.current, index, count, sum) {
+			return false
+		}
+		a.markDirty()
+		return true
+	}
+	return false
+}
+
+func (a *syncAccumulator[N, Storage, Methods]) UpdateWithStartTime(ctx context.Context, number N, start time.Time) {
+	a.UpdateContext(ctx, number)
+
+	// Keep the earliest start time.
+	nanos := start.UnixNano()
+	for {
+		old := atomic.LoadInt64(&a.holder.auxiliary.backfill)
+		if old != 0 && old <= nanos {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&a.holder.auxiliary.backfill, old, nanos) {
+			return
+		}
+	}
+}
+
+func (a *syncAccumulator[N, Storage, Methods]) SnapshotAndProcess(release bool) {
 	a.syncLock.Lock()
 	defer a.syncLock.Unlock()
+	a.snapshotLocked()
+	a.processLocked(release)
+}
+
+func (a *syncAccumulator[N, Storage, Methods]) Snapshot() {
+	a.syncLock.Lock()
+	defer a.syncLock.Unlock()
+	a.snapshotLocked()
+}
+
+func (a *syncAccumulator[N, Storage, Methods]) Process(release bool) {
+	a.syncLock.Lock()
+	defer a.syncLock.Unlock()
+	a.processLocked(release)
+}
+
+// snapshotLocked moves current into the snapshot, first processing
+// a snapshot that is pending.  The caller holds syncLock.
+func (a *syncAccumulator[N, Storage, Methods]) snapshotLocked() {
+	var methods Methods
+	if a.pending {
+		a.processLocked(false)
+	}
+	// Note: the flag is cleared before Move(), so that an update
+	// that is not moved remains flagged, and the holder is
+	// flagged after Merge(), so that a collection that clears
+	// the flag sees the update.
+	a.pendingDirty = atomic.SwapUint32(&a.dirty, 0)
 	methods.Move(&a.current, &a.snapshot)
-	methods.Merge(&a.snapshot, &a.holder.storage)
+	a.pending = true
+	if methods.HasChange(&a.snapshot) && a.now != nil {
+		atomic.StoreInt64(&a.holder.auxiliary.touched, a.now().UnixNano())
+	}
+}
+
+// processLocked merges a pending snapshot into the output.  The
+// caller holds syncLock.
+func (a *syncAccumulator[N, Storage, Methods]) processLocked(release bool) {
+	var methods Methods
+	if a.pending {
+		a.pending = false
+		methods.Merge(&a.snapshot, &a.holder.storage)
+		if a.pendingDirty != 0 {
+			atomic.StoreUint32(&a.holder.auxiliary.dirty, 1)
+		}
+	}
 	if release {
 		// On the final snapshot-and-process, decrement the auxiliary reference count.
-		atomic.AddInt64(&a.holder.auxiliary, -1)
+		atomic.AddInt64(&a.holder.auxiliary.refs, -1)
+	}
+}
+
+// TakeOverflow implements OverflowReporter.  Under the
+// aggregator.SumOverflowReset policy, an overflow restarts the sum,
+// which is flagged for the collector to advance the start time.
+func (a *syncAccumulator[N, Storage, Methods]) TakeOverflow() (N, bool) {
+	var methods Methods
+	om, ok := any(methods).(aggregator.OverflowMethods[N, Storage])
+	if !ok {
+		return 0, false
+	}
+	value, overflow := om.TakeOverflow(&a.holder.storage)
+	if overflow && a.restartOnOverflow {
+		atomic.StoreUint32(&a.holder.auxiliary.restarted, 1)
 	}
+	return value, overflow
 }
 
 // asyncAccumulator
@@ -125,9 +623,22 @@ type asyncAccumulator[N number.Any, Storage any, Methods aggregator.Methods[N, S
 	asyncLock sync.Mutex
 	current   N
 	holder    *storageHolder[Storage, notUsed]
+	transform *valueTransform
+
+	// snapshot is the value taken by Snapshot, when pending is
+	// true.  These are protected by asyncLock.
+	snapshot N
+	pending  bool
 }
 
+// Update records an observation.  A transformed value that fails the
+// range test is not recorded.
 func (a *asyncAccumulator[N, Storage, Methods]) Update(number N) {
+	number, ok := applyTransform(a.transform, number)
+	if !ok {
+		return
+	}
+
 	a.asyncLock.Lock()
 	defer a.asyncLock.Unlock()
 	a.current = number
@@ -138,5 +649,26 @@ func (a *asyncAccumulator[N, Storage, Methods]) SnapshotAndProcess(_ bool) {
 	defer a.asyncLock.Unlock()
 
 	var methods Methods
+	a.pending = false
 	methods.Update(&a.holder.storage, a.current)
 }
+
+func (a *asyncAccumulator[N, Storage, Methods]) Snapshot() {
+	a.asyncLock.Lock()
+	defer a.asyncLock.Unlock()
+
+	a.snapshot = a.current
+	a.pending = true
+}
+
+func (a *asyncAccumulator[N, Storage, Methods]) Process(_ bool) {
+	a.asyncLock.Lock()
+	defer a.asyncLock.Unlock()
+
+	if !a.pending {
+		return
+	}
+	var methods Methods
+	a.pending = false
+	methods.Update(&a.holder.storage, a.snapshot)
+}
@@ -16,8 +16,10 @@ package viewstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
@@ -33,8 +35,8 @@ import (
 // auxiliary field.  Storage will be one of the aggregators.  The
 // auxiliary type depends on whether synchronous or asynchronous.
 //
-// Auxiliary is an int64 reference count for synchronous instruments
-// and notUsed for asynchronous instruments.
+// Auxiliary is syncAuxiliary for synchronous instruments and notUsed
+// for asynchronous instruments.
 type storageHolder[Storage, Auxiliary any] struct {
 	auxiliary Auxiliary
 	storage   Storage
@@ -43,6 +45,50 @@ type storageHolder[Storage, Auxiliary any] struct {
 // notUsed is the Auxiliary type for asynchronous instruments.
 type notUsed struct{}
 
+// syncAuxiliary is the Auxiliary type for synchronous instruments.
+type syncAuxiliary struct {
+	// refs is the number of Accumulators referring to the
+	// storage, updated atomically.
+	refs int64
+
+	// touched is the time of the last update merged into the
+	// storage, in Unix nanoseconds, updated atomically.  This and
+	// created are maintained only with an attribute-set TTL.
+	touched int64
+
+	// created is the time the storage was created, in Unix
+	// nanoseconds.
+	created int64
+
+	// backfill is the earliest explicit start time of an update
+	// (see StartTimeUpdater), in Unix nanoseconds, updated
+	// atomically.  Zero means none.
+	backfill int64
+
+	// dirty is set to 1 when an update is merged into the
+	// storage and cleared by collection, updated atomically.
+	dirty uint32
+
+	// restarted is set to 1 when an int64 sum restarts under
+	// aggregator.SumOverflowReset and cleared by cumulative
+	// collection, updated atomically.
+	restarted uint32
+
+	// restartStart is the start time of cumulative points after
+	// the sum restarted, in Unix nanoseconds, zero if it never
+	// did.  Protected by instLock.
+	restartStart int64
+}
+
+// backfillStart returns the earlier of start and a backfilled start
+// time, if any.
+func backfillStart(start time.Time, backfill int64) time.Time {
+	if backfill != 0 && backfill < start.UnixNano() {
+		return time.Unix(0, backfill)
+	}
+	return start
+}
+
 // instrumentBase is the common type embedded in any of the compiled instrument views.
 type instrumentBase[N number.Any, Storage, Auxiliary any, Methods aggregator.Methods[N, Storage]] struct {
 	instLock sync.Mutex
@@ -53,6 +99,82 @@ type instrumentBase[N number.Any, Storage, Auxiliary any, Methods aggregator.Met
 
 	keysSet    *attribute.Set
 	keysFilter *attribute.Filter
+
+	renameSet *attribute.Set
+	rename    map[attribute.Key]attribute.Key
+
+	// valueLimit truncates string attribute values, zero means
+	// unlimited.
+	valueLimit int
+
+	// normalSet contains the keys whose string values are
+	// normalized by normalize, nil means none.
+	normalSet *attribute.Set
+	normalize func(string) string
+
+	// filterCache (if non-nil) caches the result of
+	// applyKeysFilter.
+	filterCache *filterCache
+
+	// contextKeys are the baggage keys copied into measurement
+	// attributes by the synchronous instrument.
+	contextKeys []string
+
+	// limit is the cardinality limit, zero means unlimited.
+	limit int
+
+	// overflowSet is the attribute set of the overflow point, nil
+	// means the default.
+	overflowSet *attribute.Set
+
+	// transform is applied to measurements, nil means identity.
+	transform *valueTransform
+
+	// input is the number kind of the measurements, which is
+	// float64 for a promoted output (see adaptInput).
+	input number.Kind
+
+	// processor is applied to collected points, nil means none.
+	processor func(*data.Point)
+
+	// ttl is the attribute-set TTL of synchronous instruments,
+	// zero means unlimited.
+	ttl time.Duration
+
+	// custom is the registered custom aggregation, nil for the
+	// built-in aggregations.
+	custom *customAggregation
+
+	// route selects the attribute sets routed to this
+	// instrument, nil means it is not routed.
+	route func(attribute.Set) bool
+
+	// startEpoch is the start time of cumulative points, zero
+	// means the start of the collection sequence.
+	startEpoch time.Time
+
+	// tempo is the output temporality.
+	tempo aggregation.Temporality
+
+	// accumulate is set when asynchronous observations are
+	// accumulated into a running total.
+	accumulate bool
+
+	// resetTime is the time of the last Reset(), zero if never
+	// reset.  Protected by instLock.
+	resetTime time.Time
+}
+
+// defaultOverflowSet is the attribute set used for measurements that
+// exceed the cardinality limit, unless configured otherwise.
+var defaultOverflowSet = attribute.NewSet(attribute.Bool("otel.metric.overflow", true))
+
+// overflow returns the attribute set of the overflow point.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) overflow() attribute.Set {
+	if metric.overflowSet != nil {
+		return *metric.overflowSet
+	}
+	return defaultOverflowSet
 }
 
 // Size reports the size of the data map.
@@ -62,11 +184,87 @@ func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) Size() int {
 	return len(metric.data)
 }
 
+// MemorySize estimates the memory held by the data map.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) MemorySize() int {
+	metric.instLock.Lock()
+	defer metric.instLock.Unlock()
+	return memorySize[N, Storage, Auxiliary, Methods](metric.data)
+}
+
+// memorySize estimates the memory held by a map of storage: for
+// each entry, the key, the holder and its pointer, the attributes,
+// and variable-size storage reported by aggregator.MemoryMethods.
+func memorySize[N number.Any, Storage, Auxiliary any, Methods aggregator.Methods[N, Storage]](data map[attribute.Set]*storageHolder[Storage, Auxiliary]) int {
+	var methods Methods
+	mm, hasMemory := any(methods).(aggregator.MemoryMethods[N, Storage])
+
+	var holder storageHolder[Storage, Auxiliary]
+	entrySize := int(unsafe.Sizeof(attribute.Set{}) + unsafe.Sizeof(&holder) + unsafe.Sizeof(holder))
+
+	size := 0
+	for set, entry := range data {
+		size += entrySize + attributesSize(set)
+		if hasMemory {
+			size += mm.MemorySize(&entry.storage)
+		}
+	}
+	return size
+}
+
+// attributesSize estimates the memory held by an attribute set,
+// counting keys and string values.
+func attributesSize(set attribute.Set) int {
+	size := set.Len() * int(unsafe.Sizeof(attribute.KeyValue{}))
+	for iter := set.Iter(); iter.Next(); {
+		kv := iter.Attribute()
+		size += len(kv.Key)
+		if kv.Value.Type() == attribute.STRING {
+			size += len(kv.Value.AsString())
+		}
+	}
+	return size
+}
+
 func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) Aggregation() aggregation.Kind {
+	if metric.custom != nil {
+		return metric.custom.kind
+	}
 	var methods Methods
 	return methods.Kind()
 }
 
+// AggregationKind implements Output.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) AggregationKind() aggregation.Kind {
+	return metric.Aggregation()
+}
+
+// Routed implements Router.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) Routed() bool {
+	return metric.route != nil
+}
+
+// Route implements Router.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) Route(kvs attribute.Set) bool {
+	return metric.route != nil && metric.route(metric.applyContextAttributes(kvs))
+}
+
+// Inspect implements Inspector.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) Inspect(callback func(sdkinstrument.Descriptor, attribute.Set, aggregation.Aggregation)) {
+	var methods Methods
+
+	metric.instLock.Lock()
+	defer metric.instLock.Unlock()
+
+	for set, entry := range metric.data {
+		if !methods.HasChange(&entry.storage) {
+			continue
+		}
+		cpy := metric.newStorage()
+		methods.Copy(&entry.storage, cpy)
+		callback(metric.desc, set, methods.ToAggregation(cpy))
+	}
+}
+
 func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) OriginalName() string {
 	return metric.fromName
 }
@@ -75,15 +273,137 @@ func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) Descriptor() sdkin
 	return metric.desc
 }
 
+// HasPointProcessor implements PointProcessing.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) HasPointProcessor() bool {
+	return metric.processor != nil
+}
+
 func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) Keys() *attribute.Set {
 	return metric.keysSet
 }
 
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) ContextAttributes() []string {
+	return metric.contextKeys
+}
+
+// adaptInput returns acc for measurements of the input number kind.
+// Outputs that transform values are float64 regardless of the input
+// (see Compile), so that fractional values are not truncated.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) adaptInput(acc Accumulator) Accumulator {
+	var n N
+	if _, ok := any(n).(float64); !ok {
+		return acc
+	}
+	switch metric.input {
+	case number.Int64Kind:
+		return promotedAccumulator[int64]{Accumulator: acc}
+	case number.Uint64Kind:
+		return promotedAccumulator[uint64]{Accumulator: acc}
+	}
+	return acc
+}
+
 func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) Config() aggregator.Config {
 	return metric.acfg
 }
 
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) base() *instrumentBase[N, Storage, Auxiliary, Methods] {
+	return metric
+}
+
+// equivalent tests whether two instrumentBase objects produce the
+// same output given the same input.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) equivalent(other *instrumentBase[N, Storage, Auxiliary, Methods]) bool {
+	return metric.fromName == other.fromName &&
+		metric.desc == other.desc &&
+		metric.sameConfig(other)
+}
+
+// sameOutput implements leafInstrument.  Instruments of another
+// number kind, storage, or aggregator do not produce the same output.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) sameOutput(prev leafInstrument) bool {
+	pb, ok := prev.(interface {
+		base() *instrumentBase[N, Storage, Auxiliary, Methods]
+	})
+	return ok && metric.sameConfig(pb.base())
+}
+
+// sameConfig tests whether two instrumentBase objects have the same
+// configuration, except for the descriptor.  Functions cannot be
+// compared, so instruments configured with a value transform,
+// normalization, point processor, or route are never the same.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) sameConfig(other *instrumentBase[N, Storage, Auxiliary, Methods]) bool {
+	return metric.input == other.input &&
+		equalConfigs(metric.acfg, other.acfg) &&
+		equalSets(metric.keysSet, other.keysSet) &&
+		equalSets(metric.renameSet, other.renameSet) &&
+		metric.valueLimit == other.valueLimit &&
+		equalSets(metric.normalSet, other.normalSet) &&
+		equalStrings(metric.contextKeys, other.contextKeys) &&
+		metric.limit == other.limit &&
+		equalSets(metric.overflowSet, other.overflowSet) &&
+		metric.ttl == other.ttl &&
+		metric.startEpoch.Equal(other.startEpoch) &&
+		metric.tempo == other.tempo &&
+		metric.accumulate == other.accumulate &&
+		equalCustom(metric.custom, other.custom) &&
+		metric.transform.equal(other.transform) &&
+		metric.normalize == nil && other.normalize == nil &&
+		metric.processor == nil && other.processor == nil &&
+		metric.route == nil && other.route == nil
+}
+
+// migrateFrom moves the data of an equivalent instrument into this
+// one.  Both instruments are locked, the other instrument is left
+// empty.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) migrateFrom(prev leafInstrument) bool {
+	pb, ok := prev.(interface {
+		base() *instrumentBase[N, Storage, Auxiliary, Methods]
+	})
+	if !ok {
+		return false
+	}
+	other := pb.base()
+	if !metric.equivalent(other) {
+		return false
+	}
+
+	other.instLock.Lock()
+	defer other.instLock.Unlock()
+	metric.instLock.Lock()
+	defer metric.instLock.Unlock()
+
+	metric.data, other.data = other.data, map[attribute.Set]*storageHolder[Storage, Auxiliary]{}
+	metric.resetTime = other.resetTime
+	return true
+}
+
+// equalSets tests for equal nil-ness or equal value.
+func equalSets(a, b *attribute.Set) bool {
+	if (a == nil) != (b == nil) {
+		return false
+	}
+	return a == nil || *a == *b
+}
+
+// equalStrings tests for equal lists of strings.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) initStorage(s *Storage) {
+	if cs, ok := any(s).(*customStorage[N]); ok {
+		cs.init(metric.custom, metric.acfg)
+		return
+	}
 	var methods Methods
 	methods.Init(s, metric.acfg)
 }
@@ -109,7 +429,145 @@ func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) invalidAttributeFi
 	return isValidAttribute(kv) && (metric.keysFilter == nil || (*metric.keysFilter)(kv))
 }
 
+// applyRename renames attribute keys as configured by the view.
+// attribute.NewSet keeps the last of duplicate keys, which is the
+// original key that sorts last, since the input is sorted.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) applyRename(kvs attribute.Set) attribute.Set {
+	if metric.rename == nil {
+		return kvs
+	}
+	var attrs []attribute.KeyValue
+	for iter := kvs.Iter(); iter.Next(); {
+		idx, kv := iter.IndexedAttribute()
+		to, has := metric.rename[kv.Key]
+		if !has {
+			continue
+		}
+		if attrs == nil {
+			attrs = kvs.ToSlice()
+		}
+		attrs[idx].Key = to
+	}
+	if attrs == nil {
+		return kvs
+	}
+	return attribute.NewSet(attrs...)
+}
+
+// applyNormalization replaces the string values of the configured
+// keys by their normalized value.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) applyNormalization(kvs attribute.Set) attribute.Set {
+	if metric.normalize == nil {
+		return kvs
+	}
+	var attrs []attribute.KeyValue
+	for iter := kvs.Iter(); iter.Next(); {
+		idx, kv := iter.IndexedAttribute()
+		if kv.Value.Type() != attribute.STRING || !metric.normalSet.HasValue(kv.Key) {
+			continue
+		}
+		normal := metric.normalize(kv.Value.AsString())
+		if normal == kv.Value.AsString() {
+			continue
+		}
+		if attrs == nil {
+			attrs = kvs.ToSlice()
+		}
+		attrs[idx].Value = attribute.StringValue(normal)
+	}
+	if attrs == nil {
+		return kvs
+	}
+	return attribute.NewSet(attrs...)
+}
+
+// applyContextAttributes resolves the context attributes, which are
+// marked by contextKeyPrefix (see ContextAttributeKey).  Those this
+// view configures are output under their own key, unless the set has
+// an attribute with the same key, and the others are removed.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) applyContextAttributes(kvs attribute.Set) attribute.Set {
+	// Marked keys sort first.
+	if first, ok := kvs.Get(0); !ok || !strings.HasPrefix(string(first.Key), contextKeyPrefix) {
+		return kvs
+	}
+	attrs := make([]attribute.KeyValue, 0, kvs.Len())
+	for iter := kvs.Iter(); iter.Next(); {
+		kv := iter.Attribute()
+		if !strings.HasPrefix(string(kv.Key), contextKeyPrefix) {
+			attrs = append(attrs, kv)
+			continue
+		}
+		key := strings.TrimPrefix(string(kv.Key), contextKeyPrefix)
+		if !containsString(metric.contextKeys, key) || kvs.HasValue(attribute.Key(key)) {
+			continue
+		}
+		attrs = append(attrs, attribute.KeyValue{Key: attribute.Key(key), Value: kv.Value})
+	}
+	return attribute.NewSet(attrs...)
+}
+
+// applyValueLimit truncates string attribute values to the
+// configured number of runes.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) applyValueLimit(kvs attribute.Set) attribute.Set {
+	if metric.valueLimit <= 0 {
+		return kvs
+	}
+	var attrs []attribute.KeyValue
+	for iter := kvs.Iter(); iter.Next(); {
+		idx, kv := iter.IndexedAttribute()
+		if kv.Value.Type() != attribute.STRING {
+			continue
+		}
+		truncated, ok := truncateRunes(kv.Value.AsString(), metric.valueLimit)
+		if !ok {
+			continue
+		}
+		if attrs == nil {
+			attrs = kvs.ToSlice()
+		}
+		attrs[idx].Value = attribute.StringValue(truncated)
+	}
+	if attrs == nil {
+		return kvs
+	}
+	return attribute.NewSet(attrs...)
+}
+
+// truncateRunes returns the first limit runes of s and true, or s
+// and false when it is not longer than limit runes.
+func truncateRunes(s string, limit int) (string, bool) {
+	if len(s) <= limit {
+		// Every rune is at least one byte.
+		return s, false
+	}
+	count := 0
+	for idx := range s {
+		if count == limit {
+			return s[:idx], true
+		}
+		count++
+	}
+	return s, false
+}
+
 func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) applyKeysFilter(kvs attribute.Set) attribute.Set {
+	if metric.filterCache == nil {
+		return metric.computeKeysFilter(kvs)
+	}
+	if res, ok := metric.filterCache.get(kvs); ok {
+		return res
+	}
+	res := metric.computeKeysFilter(kvs)
+	metric.filterCache.put(kvs, res)
+	return res
+}
+
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) computeKeysFilter(kvs attribute.Set) attribute.Set {
+	kvs = metric.applyContextAttributes(kvs)
+	kvs = metric.applyRename(kvs)
+	kvs = metric.applyNormalization(kvs)
+	kvs = metric.applyValueLimit(kvs)
+
 	invalidFilter := false
 	for iter := kvs.Iter(); iter.Next(); {
 		kv := iter.Attribute()
@@ -125,12 +583,49 @@ func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) applyKeysFilter(kv
 	if !invalidFilter && metric.keysFilter == nil {
 		return kvs
 	}
-	var res attribute.Set
 	if !invalidFilter {
-		res, _ = kvs.Filter(*metric.keysFilter)
-	} else {
-		res, _ = kvs.Filter(metric.invalidAttributeFilter)
+		return filterSet(kvs, *metric.keysFilter)
+	}
+	return filterSet(kvs, metric.invalidAttributeFilter)
+}
+
+// filterBuffer is scratch space for filterSet.
+type filterBuffer struct {
+	kvs []attribute.KeyValue
+	tmp attribute.Sortable
+}
+
+var filterBufferPool = sync.Pool{
+	New: func() any {
+		return new(filterBuffer)
+	},
+}
+
+// filterSet is like kvs.Filter(filter), except that it collects the
+// retained attributes in a pooled buffer instead of allocating a
+// slice per call.  The resulting set does not alias the buffer,
+// because attribute.NewSetWithSortable copies its input.  Returns
+// kvs when every attribute is retained.
+func filterSet(kvs attribute.Set, filter attribute.Filter) attribute.Set {
+	buf := filterBufferPool.Get().(*filterBuffer)
+	defer filterBufferPool.Put(buf)
+
+	for iter := kvs.Iter(); iter.Next(); {
+		if kv := iter.Attribute(); filter(kv) {
+			buf.kvs = append(buf.kvs, kv)
+		}
 	}
+
+	res := kvs
+	if len(buf.kvs) != kvs.Len() {
+		res = attribute.NewSetWithSortable(buf.kvs, &buf.tmp)
+	}
+
+	// Clear the buffer so it does not retain attribute values.
+	for i := range buf.kvs {
+		buf.kvs[i] = attribute.KeyValue{}
+	}
+	buf.kvs = buf.kvs[:0]
 	return res
 }
 
@@ -140,13 +635,32 @@ func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) getOrCreateEntry(k
 		return entry
 	}
 
-	var methods Methods
+	if metric.limit > 0 && len(metric.data) >= metric.limit {
+		doevery.TimePeriod(time.Minute, func() {
+			otel.Handle(fmt.Errorf("metric %q exceeded its cardinality limit of %d", metric.desc.Name, metric.limit))
+		})
+		kvs = metric.overflow()
+
+		entry, has = metric.data[kvs]
+		if has {
+			return entry
+		}
+	}
+
 	entry = &storageHolder[Storage, Auxiliary]{}
-	methods.Init(&entry.storage, metric.acfg)
+	metric.initStorage(&entry.storage)
 	metric.data[kvs] = entry
 	return entry
 }
 
+// now returns the current time from the configured clock.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) now() time.Time {
+	if metric.acfg.Clock != nil {
+		return metric.acfg.Clock.Now()
+	}
+	return time.Now()
+}
+
 // newStorage allocates and initializes a new Storage.
 func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) newStorage() *Storage {
 	ns := new(Storage)
@@ -166,38 +680,134 @@ func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) appendInstrument(o
 	return inst
 }
 
-// appendPoint adds a new point to the output.  Note that the existing
-// slice will be extended, if possible, and the existing Aggregation
-// is potentially re-used.  The variable `reset` determines whether
-// Move() or Copy() is used.  Note that both Move and Copy are
-// synchronized with respect to Update() and Merge(), necessary for the
-// synchronous code path which may see concurrent collection.
-func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) appendPoint(inst *data.Instrument, set attribute.Set, storage *Storage, tempo aggregation.Temporality, start, end time.Time, reset bool) {
-	var methods Methods
+// cumulativeStart returns the start time for cumulative points, which
+// is the later of the sequence start and the last Reset(), so that
+// consumers observe a discontinuity after Reset().  The aligned
+// start time, if configured and not after the collection, replaces
+// the sequence start.  Requires instLock.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) cumulativeStart(seq data.Sequence) time.Time {
+	start := seq.Start
+	if !metric.startEpoch.IsZero() && !metric.startEpoch.After(seq.Now) {
+		start = metric.startEpoch
+	}
+	if metric.resetTime.After(start) {
+		if metric.resetTime.After(seq.Now) {
+			return seq.Now
+		}
+		return metric.resetTime
+	}
+	return start
+}
 
-	// Possibly re-use the underlying storage.
-	point, out := metric.appendOrReusePoint(inst)
-	if out == nil {
-		out = metric.newStorage()
+// stale returns true for gauge points that were not updated within
+// the configured GaugeStaleAfter of now.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) stale(point data.Point, now time.Time) bool {
+	if metric.acfg.GaugeStaleAfter == 0 {
+		return false
+	}
+	tg, ok := point.Aggregation.(aggregation.TimestampedGauge)
+	if !ok {
+		return false
 	}
+	return now.Sub(tg.LastUpdateTime()) > metric.acfg.GaugeStaleAfter
+}
+
+// preparePoint fills scratch from storage and returns a Point
+// referring to it, over the interval from start to end.  The variable
+// `reset` determines whether Move() or Copy() is used.  Note that both Move and Copy are synchronized with
+// respect to Update() and Merge(), necessary for the synchronous code
+// path which may see concurrent collection.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) preparePoint(scratch *Storage, set attribute.Set, storage *Storage, tempo aggregation.Temporality, start, end time.Time, reset bool) data.Point {
+	var methods Methods
 
 	if reset {
 		// Note: synchronized move uses swap for expensive
 		// copies, like histogram.
-		methods.Move(storage, out)
+		methods.Move(storage, scratch)
 	} else {
-		methods.Copy(storage, out)
+		methods.Copy(storage, scratch)
+	}
+	if im, ok := any(methods).(aggregator.IntervalMethods[N, Storage]); ok {
+		im.SetInterval(scratch, start, end)
 	}
 
-	point.Attributes = set
-	point.Aggregation = methods.ToAggregation(out)
-	point.Temporality = tempo
-	point.Start = start
-	point.End = end
+	return data.Point{
+		Attributes:  set,
+		Aggregation: methods.ToAggregation(scratch),
+		Temporality: tempo,
+		Start:       start,
+		End:         end,
+	}
+}
+
+// collectAppend implements the slice-based Collect() in terms of
+// the streaming CollectInto().  Each point is moved out of the
+// scratch storage used by CollectInto into the output, where the
+// existing slice will be extended, if possible, and the existing
+// Aggregation is potentially re-used.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) collectAppend(output *[]data.Instrument, collectInto func(func(data.Point) error) error) {
+	var methods Methods
+
+	ioutput := metric.appendInstrument(output)
+
+	_ = collectInto(func(pt data.Point) error {
+		point, out := metric.appendOrReusePoint(ioutput)
+		if out == nil {
+			out = metric.newStorage()
+		}
+		in, _ := methods.ToStorage(pt.Aggregation)
+		methods.Move(in, out)
+
+		*point = pt
+		point.Aggregation = methods.ToAggregation(out)
+		return nil
+	})
+
+	if metric.processor != nil {
+		metric.processPoints(ioutput)
+	}
+}
+
+// processPoints applies the point processor, after collectInto has
+// released the instrument lock.  When the processor changes
+// attributes, the points are sorted and those with equal attributes
+// are merged.  Points are swapped, not copied, so that the storage of
+// merged points remains available for re-use beyond the new length.
+func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) processPoints(inst *data.Instrument) {
+	var methods Methods
+
+	changed := false
+	for i := range inst.Points {
+		pt := &inst.Points[i]
+		agg, attrs := pt.Aggregation, pt.Attributes
+
+		metric.processor(pt)
+
+		pt.Aggregation = agg
+		changed = changed || !attrs.Equals(&pt.Attributes)
+	}
+	if !changed || len(inst.Points) < 2 {
+		return
+	}
+
+	data.SortPoints(inst.Points)
+
+	last := 0
+	for i := 1; i < len(inst.Points); i++ {
+		if inst.Points[i].Attributes.Equals(&inst.Points[last].Attributes) {
+			from, _ := methods.ToStorage(inst.Points[i].Aggregation)
+			to, _ := methods.ToStorage(inst.Points[last].Aggregation)
+			methods.Merge(from, to)
+			continue
+		}
+		last++
+		inst.Points[last], inst.Points[i] = inst.Points[i], inst.Points[last]
+	}
+	inst.Points = inst.Points[:last+1]
 }
 
-// appendOrReusePoint is an alternate to appendPoint; this form is used when
-// the storage will be reset on collection.
+// appendOrReusePoint extends the instrument's points, returning the
+// existing storage of the new point when it can be re-used.
 func (metric *instrumentBase[N, Storage, Auxiliary, Methods]) appendOrReusePoint(inst *data.Instrument) (*data.Point, *Storage) {
 	point := data.ReallocateFrom(&inst.Points)
 
@@ -0,0 +1,242 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package viewstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
+
+import (
+	"reflect"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
+	"go.uber.org/multierr"
+)
+
+// Clone returns a new Compiler for the same library configured with
+// newViews, having compiled every instrument previously compiled by
+// this Compiler.  Output instruments that are unchanged by the new
+// views, meaning they have the same name, descriptor, aggregation,
+// temporality, aggregator configuration, and attribute filters, take
+// over the state of the existing output, including cumulative sums
+// and histograms.  Other outputs start empty.  Outputs that use a
+// value transform or value normalization are never considered
+// unchanged, since functions cannot be compared.
+//
+// Accumulators created by this Compiler continue to update the
+// migrated state until they are released, so that no measurements
+// are lost.  Callers replace the Instruments returned by this
+// Compiler's Compile() with those returned by the clone's Lookup(),
+// e.g., using the SetCompiled methods of the syncstate and asyncstate
+// Instruments.  This Compiler should not be used after Clone.
+//
+// As for New(), newViews are expected to be validated.  The returned
+// error combines conflicts while compiling, in which case the
+// returned Compiler is usable, as for Compile().
+func (v *Compiler) Clone(newViews *view.Views) (*Compiler, error) {
+	var err error
+	clone := New(v.library, newViews)
+	clone.clock = v.clock
+	clone.process = v.process
+
+	v.compilerLock.Lock()
+	defer v.compilerLock.Unlock()
+
+	for _, entry := range v.compiled {
+		_, conflicts := clone.Compile(entry.desc)
+		err = multierr.Append(err, conflicts.AsError())
+	}
+
+	// Each existing output may be migrated once.
+	migrated := map[leafInstrument]bool{}
+
+	for name, leaves := range clone.names {
+		for _, leaf := range leaves {
+			for _, prev := range v.names[name] {
+				if migrated[prev] || reflect.TypeOf(prev) != reflect.TypeOf(leaf) {
+					continue
+				}
+				if leaf.migrateFrom(prev) {
+					migrated[prev] = true
+					break
+				}
+			}
+		}
+	}
+	return clone, err
+}
+
+// Lookup returns the Instrument returned by the first call to
+// Compile() for desc, which may have been made by Clone().
+func (v *Compiler) Lookup(desc sdkinstrument.Descriptor) (Instrument, bool) {
+	v.compilerLock.Lock()
+	defer v.compilerLock.Unlock()
+
+	for _, entry := range v.compiled {
+		if entry.desc == desc {
+			return entry.inst, true
+		}
+	}
+	return nil, false
+}
+
+// AddClause adds a view clause that follows the configured clauses
+// in subsequent calls to Compile, and recompiles the previously
+// compiled instruments that it matches.  As for Clone, outputs of
+// the recompiled instruments that are unchanged take over the state
+// of the existing output, and other outputs start empty.  Outputs
+// that are shared with instruments the clause does not match are
+// kept, and those instruments are not modified.
+//
+// AddClause returns the descriptors of the recompiled instruments,
+// whose Instruments callers replace with those returned by Lookup(),
+// as for Clone; see MeterProvider.AddViewClause.  Instruments
+// compiled concurrently with AddClause are either recompiled or
+// observe the clause.  Clone() does not copy added clauses.
+//
+// The clause is validated as for view.Validate, and the returned
+// error combines its corrections with conflicts while recompiling.
+func (v *Compiler) AddClause(clause view.ClauseConfig) ([]sdkinstrument.Descriptor, error) {
+	valid, err := view.Validate(&view.Views{
+		Name: v.views.Name,
+		Config: view.Config{
+			Clauses:  []view.ClauseConfig{clause},
+			Defaults: v.views.Defaults,
+		},
+	})
+	clause = valid.Clauses[0]
+
+	v.compilerLock.Lock()
+	defer v.compilerLock.Unlock()
+
+	v.added = append(v.added[:len(v.added):len(v.added)], clause)
+
+	// Find the instruments to recompile, and the outputs of the
+	// others, which are kept.
+	var redo []int
+	kept := map[leafInstrument]bool{}
+	for i, entry := range v.compiled {
+		if clause.Matches(v.library, processDescriptor(v.process, entry.desc)) {
+			redo = append(redo, i)
+			continue
+		}
+		for _, leaf := range leavesOf(entry.inst) {
+			kept[leaf] = true
+		}
+	}
+
+	// Remove the other outputs, so that the recompiled outputs
+	// do not conflict with them.  Each may be migrated once.
+	prev := map[string][]leafInstrument{}
+	removed := map[leafInstrument]bool{}
+	for _, i := range redo {
+		for _, leaf := range leavesOf(v.compiled[i].inst) {
+			if kept[leaf] || removed[leaf] {
+				continue
+			}
+			removed[leaf] = true
+			name := leaf.Descriptor().Name
+			prev[name] = append(prev[name], leaf)
+		}
+	}
+	if len(removed) != 0 {
+		v.collectors = removeCollectors(v.collectors, removed)
+		for name, leaves := range v.names {
+			if leaves = removeLeaves(leaves, removed); len(leaves) == 0 {
+				delete(v.names, name)
+			} else {
+				v.names[name] = leaves
+			}
+		}
+	}
+
+	// The lock is held while recompiling, so that instruments
+	// compiled concurrently neither register outputs that
+	// conflict with the recompiled outputs nor observe the
+	// removed outputs.
+	var descs []sdkinstrument.Descriptor
+	seen := map[sdkinstrument.Descriptor]bool{}
+	migrated := map[leafInstrument]bool{}
+	for _, i := range redo {
+		entry := &v.compiled[i]
+		inst, conflicts := v.compileLocked(entry.desc)
+		err = multierr.Append(err, conflicts.AsError())
+		entry.inst = inst
+
+		if !seen[entry.desc] {
+			seen[entry.desc] = true
+			descs = append(descs, entry.desc)
+		}
+
+		for _, leaf := range leavesOf(inst) {
+			if kept[leaf] || migrated[leaf] {
+				continue
+			}
+			migrated[leaf] = true
+			for _, p := range prev[leaf.Descriptor().Name] {
+				if migrated[p] || reflect.TypeOf(p) != reflect.TypeOf(leaf) {
+					continue
+				}
+				if leaf.migrateFrom(p) {
+					migrated[p] = true
+					break
+				}
+			}
+		}
+	}
+	return descs, err
+}
+
+// leavesOf returns the outputs of an Instrument returned by Compile.
+func leavesOf(inst Instrument) []leafInstrument {
+	var insts []Instrument
+	switch t := inst.(type) {
+	case nil:
+		return nil
+	case leafInstrument:
+		return []leafInstrument{t}
+	case multiInstrument[int64]:
+		insts = t
+	case multiInstrument[float64]:
+		insts = t
+	case multiInstrument[uint64]:
+		insts = t
+	}
+	leaves := make([]leafInstrument, len(insts))
+	for i, inst := range insts {
+		leaves[i] = inst.(leafInstrument)
+	}
+	return leaves
+}
+
+// removeLeaves returns leaves without the removed outputs.
+func removeLeaves(leaves []leafInstrument, removed map[leafInstrument]bool) []leafInstrument {
+	var result []leafInstrument
+	for _, leaf := range leaves {
+		if !removed[leaf] {
+			result = append(result, leaf)
+		}
+	}
+	return result
+}
+
+// removeCollectors returns collectors without the removed outputs.
+func removeCollectors(collectors []data.Collector, removed map[leafInstrument]bool) []data.Collector {
+	var result []data.Collector
+	for _, coll := range collectors {
+		if leaf, ok := coll.(leafInstrument); !ok || !removed[leaf] {
+			result = append(result, coll)
+		}
+	}
+	return result
+}
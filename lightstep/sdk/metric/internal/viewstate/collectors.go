@@ -16,6 +16,7 @@ package viewstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk
 
 import (
 	"sync/atomic"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
@@ -29,16 +30,94 @@ type statefulSyncInstrument[N number.Any, Storage any, Methods aggregator.Method
 	compiledSyncBase[N, Storage, Methods]
 }
 
+// Temporality implements Output.
+func (p *statefulSyncInstrument[N, Storage, Methods]) Temporality() aggregation.Temporality {
+	return aggregation.CumulativeTemporality
+}
+
 // Collect for synchronous cumulative temporality.
 func (p *statefulSyncInstrument[N, Storage, Methods]) Collect(seq data.Sequence, output *[]data.Instrument) {
+	p.collectAppend(output, func(callback func(data.Point) error) error {
+		return p.CollectInto(seq, callback)
+	})
+}
+
+// CollectChanged for synchronous cumulative temporality outputs the
+// points that were updated since the previous collection.
+func (p *statefulSyncInstrument[N, Storage, Methods]) CollectChanged(seq data.Sequence, output *[]data.Instrument) {
+	p.collectAppend(output, func(callback func(data.Point) error) error {
+		return p.collectInto(seq, callback, true)
+	})
+}
+
+// CollectInto for synchronous cumulative temporality.
+func (p *statefulSyncInstrument[N, Storage, Methods]) CollectInto(seq data.Sequence, callback func(data.Point) error) error {
+	return p.collectInto(seq, callback, false)
+}
+
+// collectInto implements CollectInto, and CollectChanged when
+// changedOnly is set.  Either clears the flag of updated entries.
+func (p *statefulSyncInstrument[N, Storage, Methods]) collectInto(seq data.Sequence, callback func(data.Point) error, changedOnly bool) error {
 	p.instLock.Lock()
 	defer p.instLock.Unlock()
 
-	ioutput := p.appendInstrument(output)
+	scratch := p.newStorage()
+	start := p.cumulativeStart(seq)
 
 	for set, entry := range p.data {
-		p.appendPoint(ioutput, set, &entry.storage, aggregation.CumulativeTemporality, seq.Start, seq.Now, false)
+		dirty := atomic.SwapUint32(&entry.auxiliary.dirty, 0) != 0
+
+		entryStart := backfillStart(p.entryStart(seq, start, entry), atomic.LoadInt64(&entry.auxiliary.backfill))
+		point := p.preparePoint(scratch, set, &entry.storage, aggregation.CumulativeTemporality, entryStart, seq.Now, false)
+
+		if p.stale(point, seq.Now) {
+			// Stale entries without accumulator
+			// references are removed from the map.
+			if atomic.LoadInt64(&entry.auxiliary.refs) == 0 {
+				delete(p.data, set)
+			}
+			continue
+		}
+		if changedOnly && !dirty {
+			continue
+		}
+
+		if err := callback(point); err != nil {
+			if dirty {
+				// The point was not output.
+				atomic.StoreUint32(&entry.auxiliary.dirty, 1)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// entryStart returns the start time of a cumulative point.  With an
+// attribute-set TTL, an attribute set that reappears after it was
+// reclaimed starts a new series, so the start time is no earlier
+// than the creation of its storage.  A sum that restarted after an
+// overflow starts a new series at the previous collection, since the
+// restart happened after it.  Requires instLock.
+func (p *statefulSyncInstrument[N, Storage, Methods]) entryStart(seq data.Sequence, start time.Time, entry *storageHolder[Storage, syncAuxiliary]) time.Time {
+	if atomic.SwapUint32(&entry.auxiliary.restarted, 0) != 0 {
+		entry.auxiliary.restartStart = seq.Last.UnixNano()
+	}
+	if restart := time.Unix(0, entry.auxiliary.restartStart); entry.auxiliary.restartStart != 0 && restart.After(start) {
+		start = restart
+	}
+	now := seq.Now
+	if p.ttl == 0 {
+		return start
+	}
+	created := time.Unix(0, entry.auxiliary.created)
+	switch {
+	case created.After(now):
+		return now
+	case created.After(start):
+		return created
 	}
+	return start
 }
 
 // statelessSyncInstrument is a synchronous instrument that maintains no state.
@@ -46,49 +125,55 @@ type statelessSyncInstrument[N number.Any, Storage any, Methods aggregator.Metho
 	compiledSyncBase[N, Storage, Methods]
 }
 
+// Temporality implements Output.
+func (p *statelessSyncInstrument[N, Storage, Methods]) Temporality() aggregation.Temporality {
+	return aggregation.DeltaTemporality
+}
+
 // Collect for synchronous delta temporality.
 func (p *statelessSyncInstrument[N, Storage, Methods]) Collect(seq data.Sequence, output *[]data.Instrument) {
+	p.collectAppend(output, func(callback func(data.Point) error) error {
+		return p.CollectInto(seq, callback)
+	})
+}
+
+// CollectInto for synchronous delta temporality.
+func (p *statelessSyncInstrument[N, Storage, Methods]) CollectInto(seq data.Sequence, callback func(data.Point) error) error {
 	var methods Methods
 
 	p.instLock.Lock()
 	defer p.instLock.Unlock()
 
-	ioutput := p.appendInstrument(output)
+	scratch := p.newStorage()
 
 	for set, entry := range p.data {
 		// capture the number of references before the Move() call
 		// below.  we're holding the lock that prevents new refs, so
 		// the value before Move() indicates when it's safe to remove
 		// this entry from the map.
-		numRefs := atomic.LoadInt64(&entry.auxiliary)
+		numRefs := atomic.LoadInt64(&entry.auxiliary.refs)
 
-		p.appendPoint(ioutput, set, &entry.storage, aggregation.DeltaTemporality, seq.Last, seq.Now, true)
+		// By passing reset=true, the aggregator data in
+		// entry.storage is moved into scratch.
+		point := p.preparePoint(scratch, set, &entry.storage, aggregation.DeltaTemporality, seq.Last, seq.Now, true)
 
-		// By passing reset=true above, the aggregator data in
-		// entry.storage has been moved into the last index of
-		// ioutput.Points.
-		ptsArr := ioutput.Points
-		point := &ptsArr[len(ptsArr)-1]
+		// A backfilled start time applies to one interval.
+		point.Start = backfillStart(point.Start, atomic.SwapInt64(&entry.auxiliary.backfill, 0))
 
-		cpy, _ := methods.ToStorage(point.Aggregation)
-
-		if methods.HasChange(cpy) {
+		if !methods.HasChange(scratch) {
+			// If there are no more accumulator references to the
+			// entry, remove from the map.
+			if numRefs == 0 {
+				delete(p.data, set)
+			}
 			continue
 		}
 
-		// We allowed the array to grow before this
-		// test speculatively, since when it succeeds
-		// we are able to re-use the underlying
-		// aggregator.
-		ioutput.Points = ptsArr[0 : len(ptsArr)-1 : cap(ptsArr)]
-
-		// If there are no more accumulator references to the
-		// entry, remove from the map.
-		if numRefs == 0 {
-			delete(p.data, set)
+		if err := callback(point); err != nil {
+			return err
 		}
-
 	}
+	return nil
 }
 
 // statelessAsyncInstrument is an asynchronous instrument that keeps
@@ -97,28 +182,182 @@ type statelessAsyncInstrument[N number.Any, Storage any, Methods aggregator.Meth
 	compiledAsyncBase[N, Storage, Methods]
 }
 
+// Temporality implements Output.
+func (p *statelessAsyncInstrument[N, Storage, Methods]) Temporality() aggregation.Temporality {
+	return aggregation.CumulativeTemporality
+}
+
 // Collect for asynchronous cumulative temporality.
 func (p *statelessAsyncInstrument[N, Storage, Methods]) Collect(seq data.Sequence, output *[]data.Instrument) {
+	p.collectAppend(output, func(callback func(data.Point) error) error {
+		return p.CollectInto(seq, callback)
+	})
+}
+
+// CollectInto for asynchronous cumulative temporality.
+func (p *statelessAsyncInstrument[N, Storage, Methods]) CollectInto(seq data.Sequence, callback func(data.Point) error) error {
 	p.instLock.Lock()
 	defer p.instLock.Unlock()
 
-	ioutput := p.appendInstrument(output)
+	scratch := p.newStorage()
+	start := p.cumulativeStart(seq)
 
+	var err error
 	for set, entry := range p.data {
-		p.appendPoint(ioutput, set, &entry.storage, aggregation.CumulativeTemporality, seq.Start, seq.Now, false)
+		if err = callback(p.preparePoint(scratch, set, &entry.storage, aggregation.CumulativeTemporality, start, seq.Now, false)); err != nil {
+			break
+		}
 	}
 
 	// Reset the entire map.
 	p.data = map[attribute.Set]*storageHolder[Storage, notUsed]{}
+	return err
+}
+
+// accumulatingAsyncInstrument is an asynchronous instrument whose
+// observations are deltas, e.g., gauges behind a delta-preferring
+// reader, that keeps a running total per attribute set in order to
+// perform delta to cumulative translation.
+type accumulatingAsyncInstrument[N number.Any, Storage any, Methods aggregator.Methods[N, Storage]] struct {
+	compiledAsyncBase[N, Storage, Methods]
+	totals map[attribute.Set]*storageHolder[Storage, totalState]
+}
+
+// totalState is the Auxiliary type of the totals map.
+type totalState struct {
+	// start is the start time of the running total.
+	start time.Time
+}
+
+// Temporality implements Output.
+func (p *accumulatingAsyncInstrument[N, Storage, Methods]) Temporality() aggregation.Temporality {
+	return aggregation.CumulativeTemporality
+}
+
+// Size (special case) reports the size of the totals map, since
+// data is emptied on Collect().
+func (p *accumulatingAsyncInstrument[N, Storage, Methods]) Size() int {
+	p.instLock.Lock()
+	defer p.instLock.Unlock()
+	return len(p.totals)
+}
+
+// MemorySize (special case) includes the totals map.
+func (p *accumulatingAsyncInstrument[N, Storage, Methods]) MemorySize() int {
+	p.instLock.Lock()
+	defer p.instLock.Unlock()
+	return memorySize[N, Storage, notUsed, Methods](p.data) +
+		memorySize[N, Storage, totalState, Methods](p.totals)
+}
+
+// Reset (special case) also clears the totals map, so that the next
+// observation starts a new running total.
+func (p *accumulatingAsyncInstrument[N, Storage, Methods]) Reset() {
+	p.instLock.Lock()
+	defer p.instLock.Unlock()
+
+	p.data = map[attribute.Set]*storageHolder[Storage, notUsed]{}
+	p.totals = map[attribute.Set]*storageHolder[Storage, totalState]{}
+	p.resetTime = p.now()
+}
+
+// migrateFrom (special case) also moves the totals map, so that the
+// next observation is added to the running total.
+func (p *accumulatingAsyncInstrument[N, Storage, Methods]) migrateFrom(prev leafInstrument) bool {
+	other, ok := prev.(*accumulatingAsyncInstrument[N, Storage, Methods])
+	if !ok || !p.compiledAsyncBase.migrateFrom(prev) {
+		return false
+	}
+
+	other.instLock.Lock()
+	defer other.instLock.Unlock()
+	p.instLock.Lock()
+	defer p.instLock.Unlock()
+
+	p.totals, other.totals = other.totals, map[attribute.Set]*storageHolder[Storage, totalState]{}
+	return true
+}
+
+// Collect for asynchronous delta-to-cumulative conversion.
+func (p *accumulatingAsyncInstrument[N, Storage, Methods]) Collect(seq data.Sequence, output *[]data.Instrument) {
+	p.collectAppend(output, func(callback func(data.Point) error) error {
+		return p.CollectInto(seq, callback)
+	})
+}
+
+// CollectInto for asynchronous delta-to-cumulative conversion.  Each
+// observation is added to the running total of its attribute set,
+// which is reported with the start time of the total.  A total
+// starts at the collection before its first observation, and an
+// attribute set that is not observed by a collection is reset, so
+// that when it reappears a new total starts.
+func (p *accumulatingAsyncInstrument[N, Storage, Methods]) CollectInto(seq data.Sequence, callback func(data.Point) error) error {
+	var methods Methods
+	am := any(methods).(aggregator.AccumulateMethods[N, Storage])
+
+	p.instLock.Lock()
+	defer p.instLock.Unlock()
+
+	scratch := p.newStorage()
+	start := p.cumulativeStart(seq)
+	if seq.Last.After(start) {
+		start = seq.Last
+	}
+	totals := make(map[attribute.Set]*storageHolder[Storage, totalState], len(p.data))
+
+	var err error
+	for set, entry := range p.data {
+		total, has := p.totals[set]
+		if !has {
+			total = &storageHolder[Storage, totalState]{
+				auxiliary: totalState{start: start},
+			}
+			p.initStorage(&total.storage)
+		}
+		am.Accumulate(&entry.storage, &total.storage)
+		totals[set] = total
+
+		// After a callback error, only the totals are
+		// updated, since the observations are not kept.
+		if err == nil {
+			err = callback(p.preparePoint(scratch, set, &total.storage, aggregation.CumulativeTemporality, total.auxiliary.start, seq.Now, false))
+		}
+	}
+
+	// Totals of the attribute sets that were not observed are
+	// discarded.
+	p.totals = totals
+	p.data = map[attribute.Set]*storageHolder[Storage, notUsed]{}
+	return err
 }
 
 // statefulAsyncInstrument is an instrument that keeps asynchronous instrument state
 // in order to perform cumulative to delta translation.
 type statefulAsyncInstrument[N number.Any, Storage any, Methods aggregator.Methods[N, Storage]] struct {
 	compiledAsyncBase[N, Storage, Methods]
-	prior map[attribute.Set]*storageHolder[Storage, notUsed]
+	prior map[attribute.Set]*storageHolder[Storage, priorState]
 }
 
+// Temporality implements Output.
+func (p *statefulAsyncInstrument[N, Storage, Methods]) Temporality() aggregation.Temporality {
+	return aggregation.DeltaTemporality
+}
+
+// priorState is the Auxiliary type of the prior map.
+type priorState struct {
+	// seen is the time of the last collection that observed
+	// the attribute set.
+	seen time.Time
+
+	// missed counts the consecutive collections that did not
+	// observe the attribute set.
+	missed int
+}
+
+// maxMissedCollections is the number of consecutive collections that
+// may omit an attribute set before its prior value is reclaimed.
+const maxMissedCollections = 10
+
 // Size (special case) reports the size of the prior map, since
 // data is emptied on Collect().
 func (p *statefulAsyncInstrument[N, Storage, Methods]) Size() int {
@@ -127,38 +366,127 @@ func (p *statefulAsyncInstrument[N, Storage, Methods]) Size() int {
 	return len(p.prior)
 }
 
+// MemorySize (special case) includes the prior map.
+func (p *statefulAsyncInstrument[N, Storage, Methods]) MemorySize() int {
+	p.instLock.Lock()
+	defer p.instLock.Unlock()
+	return memorySize[N, Storage, notUsed, Methods](p.data) +
+		memorySize[N, Storage, priorState, Methods](p.prior)
+}
+
+// Reset (special case) also clears the prior map, so that the next
+// observation is not subtracted from a value recorded before Reset.
+func (p *statefulAsyncInstrument[N, Storage, Methods]) Reset() {
+	p.instLock.Lock()
+	defer p.instLock.Unlock()
+
+	p.data = map[attribute.Set]*storageHolder[Storage, notUsed]{}
+	p.prior = map[attribute.Set]*storageHolder[Storage, priorState]{}
+	p.resetTime = p.now()
+}
+
+// migrateFrom (special case) also moves the prior map, so that the
+// next observation is subtracted from the last observation.
+func (p *statefulAsyncInstrument[N, Storage, Methods]) migrateFrom(prev leafInstrument) bool {
+	if !p.compiledAsyncBase.migrateFrom(prev) {
+		return false
+	}
+	other := prev.(*statefulAsyncInstrument[N, Storage, Methods])
+
+	other.instLock.Lock()
+	defer other.instLock.Unlock()
+	p.instLock.Lock()
+	defer p.instLock.Unlock()
+
+	p.prior, other.prior = other.prior, map[attribute.Set]*storageHolder[Storage, priorState]{}
+	return true
+}
+
 // Collect for asynchronous delta temporality.  Note this code path is
 // not used for Gauge instruments.
 func (p *statefulAsyncInstrument[N, Storage, Methods]) Collect(seq data.Sequence, output *[]data.Instrument) {
+	p.collectAppend(output, func(callback func(data.Point) error) error {
+		return p.CollectInto(seq, callback)
+	})
+}
+
+// CollectInto for asynchronous delta temporality.  Each observation
+// is reported as the difference from the prior observation of the
+// same attribute set, starting at the time of the prior observation.
+// When the aggregator detects a reset, i.e., a monotonic sum that
+// decreased, the full observation is reported instead, starting at
+// the last collection.  Aggregators that depend on the interval,
+// i.e., rates, skip the first observation and resets, since the
+// change over the interval is unknown.
+func (p *statefulAsyncInstrument[N, Storage, Methods]) CollectInto(seq data.Sequence, callback func(data.Point) error) error {
 	var methods Methods
+	rm, hasReset := any(methods).(aggregator.ResetMethods[N, Storage])
+	_, isInterval := any(methods).(aggregator.IntervalMethods[N, Storage])
 
 	p.instLock.Lock()
 	defer p.instLock.Unlock()
 
-	ioutput := p.appendInstrument(output)
+	scratch := p.newStorage()
+	prior := make(map[attribute.Set]*storageHolder[Storage, priorState], len(p.data))
 
+	var err error
 	for set, entry := range p.data {
-		// Compute the difference.
 		pval, has := p.prior[set]
-		if has {
-			// This does `*pval := *storage - *pval`
-			methods.SubtractSwap(&pval.storage, &entry.storage)
+		if !has {
+			pval = &storageHolder[Storage, priorState]{}
+			p.initStorage(&pval.storage)
+		}
 
+		// After a callback error, only the prior values are
+		// updated, since the current data holds the
+		// cumulative values.
+		if err == nil {
+			var point data.Point
+			changed := true
+			switch {
+			case !has:
+				point = p.preparePoint(scratch, set, &entry.storage, aggregation.DeltaTemporality, seq.Last, seq.Now, false)
+				changed = !isInterval
+			case hasReset && rm.IsReset(&pval.storage, &entry.storage):
+				point = p.preparePoint(scratch, set, &entry.storage, aggregation.DeltaTemporality, seq.Last, seq.Now, false)
+				changed = !isInterval
+			default:
+				// This does `*pval := *storage - *pval`
+				methods.SubtractSwap(&pval.storage, &entry.storage)
+				point = p.preparePoint(scratch, set, &pval.storage, aggregation.DeltaTemporality, pval.auxiliary.seen, seq.Now, false)
+				changed = methods.HasChange(scratch)
+			}
 			// Skip the series if it has not changed.
-			if !methods.HasChange(&pval.storage) {
-				continue
+			if changed {
+				err = callback(point)
 			}
-			entry = pval
 		}
-		p.appendPoint(ioutput, set, &entry.storage, aggregation.DeltaTemporality, seq.Last, seq.Now, false)
+
+		methods.Move(&entry.storage, &pval.storage)
+		pval.auxiliary = priorState{seen: seq.Now}
+		prior[set] = pval
 	}
-	// TODO: Values that are contained in prior but not in data
-	// should be copied so they are not forgotten and do not
-	// output spurious counts in the future when they reappear.
-	// This is only an issue for asynchronous instruments with
-	// delta temporality.
-
-	// Copy the current to the prior and reset.
-	p.prior = p.data
+
+	// Attribute sets that were not observed keep their prior
+	// value, so that they do not output spurious counts when they
+	// reappear, until they are missed by maxMissedCollections
+	// consecutive collections.  The cardinality limit bounds the
+	// number of prior values retained this way.
+	for set, pval := range p.prior {
+		if _, ok := prior[set]; ok {
+			continue
+		}
+		pval.auxiliary.missed++
+		if pval.auxiliary.missed > maxMissedCollections {
+			continue
+		}
+		if p.limit > 0 && len(prior) >= p.limit {
+			continue
+		}
+		prior[set] = pval
+	}
+
+	p.prior = prior
 	p.data = map[attribute.Set]*storageHolder[Storage, notUsed]{}
+	return err
 }
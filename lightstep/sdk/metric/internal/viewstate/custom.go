@@ -0,0 +1,155 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package viewstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
+
+import (
+	"context"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+)
+
+// customAggregation is a custom aggregation registered with
+// aggregator.Register.
+type customAggregation struct {
+	kind    aggregation.Kind
+	factory aggregator.Factory
+}
+
+// equalCustom tests for equal nil-ness or equal kind, which names
+// one registered factory.
+func equalCustom(a, b *customAggregation) bool {
+	if (a == nil) != (b == nil) {
+		return false
+	}
+	return a == nil || a.kind == b.kind
+}
+
+// customStorage is the Storage of a custom aggregation: the
+// registered Methods with the type-erased Storage they operate on.
+type customStorage[N number.Any] struct {
+	methods aggregator.Methods[N, any]
+	value   *any
+}
+
+// customMethods adapts the registered Methods of a custom aggregation
+// to the zero-value Methods used by the compiled instruments.  Since
+// a zero-value customMethods does not know the registered Methods,
+// instrumentBase.initStorage initializes customStorage and
+// ToStorage consults the registry.
+type customMethods[N number.Any] struct{}
+
+var (
+	_ aggregator.Methods[int64, customStorage[int64]]        = customMethods[int64]{}
+	_ aggregator.ContextMethods[int64, customStorage[int64]] = customMethods[int64]{}
+	_ aggregator.MemoryMethods[int64, customStorage[int64]]  = customMethods[int64]{}
+)
+
+// customMethodsFor returns the Methods of factory for number type N,
+// nil if unsupported.
+func customMethodsFor[N number.Any](factory aggregator.Factory) aggregator.Methods[N, any] {
+	var zero N
+	var methods any
+	switch any(zero).(type) {
+	case int64:
+		methods = factory.Int64
+	case float64:
+		methods = factory.Float64
+	case uint64:
+		methods = factory.Uint64
+	}
+	m, _ := methods.(aggregator.Methods[N, any])
+	return m
+}
+
+// init initializes the storage using the registered Methods.
+func (s *customStorage[N]) init(custom *customAggregation, cfg aggregator.Config) {
+	s.methods = customMethodsFor[N](custom.factory)
+	s.value = new(any)
+	s.methods.Init(s.value, cfg)
+}
+
+// Init is not used, see instrumentBase.initStorage.
+func (customMethods[N]) Init(ptr *customStorage[N], cfg aggregator.Config) {}
+
+func (customMethods[N]) Update(ptr *customStorage[N], number N) {
+	ptr.methods.Update(ptr.value, number)
+}
+
+func (customMethods[N]) UpdateContext(ctx context.Context, ptr *customStorage[N], number N) {
+	if cm, ok := ptr.methods.(aggregator.ContextMethods[N, any]); ok {
+		cm.UpdateContext(ctx, ptr.value, number)
+		return
+	}
+	ptr.methods.Update(ptr.value, number)
+}
+
+func (customMethods[N]) Move(input, output *customStorage[N]) {
+	input.methods.Move(input.value, output.value)
+}
+
+func (customMethods[N]) Merge(input, output *customStorage[N]) {
+	output.methods.Merge(input.value, output.value)
+}
+
+func (customMethods[N]) Copy(input, output *customStorage[N]) {
+	input.methods.Copy(input.value, output.value)
+}
+
+func (customMethods[N]) SubtractSwap(operand, argument *customStorage[N]) {
+	operand.methods.SubtractSwap(operand.value, argument.value)
+}
+
+func (customMethods[N]) ToAggregation(ptr *customStorage[N]) aggregation.Aggregation {
+	return ptr.methods.ToAggregation(ptr.value)
+}
+
+// ToStorage finds the registered Methods by the Kind of the
+// Aggregation.
+func (customMethods[N]) ToStorage(agg aggregation.Aggregation) (*customStorage[N], bool) {
+	if agg == nil {
+		return nil, false
+	}
+	factory, ok := aggregator.Lookup(agg.Kind())
+	if !ok {
+		return nil, false
+	}
+	methods := customMethodsFor[N](factory)
+	if methods == nil {
+		return nil, false
+	}
+	value, ok := methods.ToStorage(agg)
+	if !ok {
+		return nil, false
+	}
+	return &customStorage[N]{methods: methods, value: value}, true
+}
+
+// Kind is not used, see instrumentBase.Aggregation.
+func (customMethods[N]) Kind() aggregation.Kind {
+	return aggregation.UndefinedKind
+}
+
+func (customMethods[N]) HasChange(ptr *customStorage[N]) bool {
+	return ptr.methods.HasChange(ptr.value)
+}
+
+func (customMethods[N]) MemorySize(ptr *customStorage[N]) int {
+	if mm, ok := ptr.methods.(aggregator.MemoryMethods[N, any]); ok {
+		return mm.MemorySize(ptr.value)
+	}
+	return 0
+}
@@ -15,6 +15,7 @@
 package viewstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -71,6 +72,19 @@ func (ViewConflictsError) Is(err error) bool {
 	return ok
 }
 
+// As finds the first semantic error matching target, for example an
+// *IncompatibleAggregationError, in any reader's conflicts.
+func (vc ViewConflictsError) As(target interface{}) bool {
+	for _, conflicts := range vc {
+		for _, c := range conflicts {
+			if c.Semantic != nil && errors.As(c.Semantic, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (vc *ViewConflictsBuilder) Add(name string, c Conflict) {
 	if *vc == nil {
 		*vc = ViewConflictsBuilder{}
@@ -103,8 +117,10 @@ func (vc *ViewConflictsBuilder) AsError() error {
 type Conflict struct {
 	// Duplicates
 	Duplicates []Duplicate
-	// Semantic will be a SemanticError if there was an instrument
-	// vs. aggregation conflict or nil otherwise.
+	// Semantic will be an IncompatibleAggregationError if there
+	// was an instrument vs. aggregation conflict, a
+	// UnitConversionError if there was an instrument vs. unit
+	// conversion conflict, both if both, or nil otherwise.
 	Semantic error
 }
 
@@ -204,6 +220,56 @@ func (SemanticError) Is(err error) bool {
 	return ok
 }
 
+// IncompatibleAggregationError is the SemanticError returned by
+// Compile with details about the instrument and the aggregation
+// used in place of the requested one.  The instrument is still
+// compiled, using the Fallback aggregation.
+type IncompatibleAggregationError struct {
+	// Descriptor is the instrument, before views apply.
+	Descriptor sdkinstrument.Descriptor
+	// Aggregation is the requested aggregation.
+	Aggregation aggregation.Kind
+	// Fallback is the default aggregation for the instrument
+	// kind, which is used instead.
+	Fallback aggregation.Kind
+	// Reason explains which aggregations are supported.
+	Reason string
+}
+
+var _ error = IncompatibleAggregationError{}
+
+func (e IncompatibleAggregationError) Error() string {
+	return e.Unwrap().Error()
+}
+
+// Unwrap returns the equivalent SemanticError.
+func (e IncompatibleAggregationError) Unwrap() error {
+	return SemanticError{
+		Instrument:  e.Descriptor.Kind,
+		Aggregation: e.Aggregation,
+	}
+}
+
+// UnitConversionError is the semantic error returned by Compile when
+// a view converts units (see view.WithUnitConversion) from a unit
+// other than the instrument's.  The instrument is still compiled,
+// without the conversion.
+type UnitConversionError struct {
+	// Descriptor is the instrument, before views apply.
+	Descriptor sdkinstrument.Descriptor
+	// From and To are the units of the conversion.
+	From string
+	To   string
+}
+
+var _ error = UnitConversionError{}
+
+func (e UnitConversionError) Error() string {
+	return fmt.Sprintf("%q instrument unit %q does not match unit conversion from %q to %q",
+		e.Descriptor.Name, e.Descriptor.Unit, e.From, e.To,
+	)
+}
+
 // fullNameString helps rendering concise error descriptions by
 // showing the original name only when it is different.
 func fullNameString(d Duplicate) string {
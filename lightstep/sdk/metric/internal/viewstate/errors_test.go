@@ -143,3 +143,75 @@ func TestConflictError(t *testing.T) {
 
 	require.NotEqual(t, inst1, inst2)
 }
+
+// TestIncompatibleAggregationError tests every instrument kind with
+// every explicit aggregation kind.
+func TestIncompatibleAggregationError(t *testing.T) {
+	incompatible := map[sdkinstrument.Kind][]aggregation.Kind{
+		sdkinstrument.SyncCounter:        {aggregation.GaugeKind},
+		sdkinstrument.SyncHistogram:      {aggregation.GaugeKind},
+		sdkinstrument.SyncUpDownCounter:  {aggregation.MonotonicSumKind, aggregation.GaugeKind, aggregation.HistogramKind, aggregation.MinMaxSumCountKind, aggregation.ExplicitHistogramKind, aggregation.SummaryKind},
+		sdkinstrument.AsyncUpDownCounter: {aggregation.MonotonicSumKind, aggregation.GaugeKind, aggregation.HistogramKind, aggregation.MinMaxSumCountKind, aggregation.ExplicitHistogramKind, aggregation.SummaryKind},
+		sdkinstrument.AsyncCounter:       {aggregation.GaugeKind, aggregation.HistogramKind, aggregation.MinMaxSumCountKind, aggregation.ExplicitHistogramKind, aggregation.SummaryKind},
+		sdkinstrument.AsyncGauge:         {aggregation.MonotonicSumKind, aggregation.NonMonotonicSumKind, aggregation.HistogramKind, aggregation.MinMaxSumCountKind, aggregation.ExplicitHistogramKind, aggregation.SummaryKind},
+		sdkinstrument.SyncGauge:          {aggregation.MonotonicSumKind, aggregation.NonMonotonicSumKind, aggregation.HistogramKind, aggregation.MinMaxSumCountKind, aggregation.ExplicitHistogramKind, aggregation.SummaryKind},
+	}
+	require.Equal(t, int(sdkinstrument.NumKinds), len(incompatible))
+
+	isIncompatible := func(ik sdkinstrument.Kind, ak aggregation.Kind) bool {
+		for _, k := range incompatible[ik] {
+			if k == ak {
+				return true
+			}
+		}
+		return false
+	}
+
+	for ik := sdkinstrument.Kind(0); ik < sdkinstrument.NumKinds; ik++ {
+		for _, ak := range []aggregation.Kind{
+			aggregation.MonotonicSumKind,
+			aggregation.NonMonotonicSumKind,
+			aggregation.GaugeKind,
+			aggregation.HistogramKind,
+			aggregation.MinMaxSumCountKind,
+			aggregation.ExplicitHistogramKind,
+			aggregation.SummaryKind,
+		} {
+			vc := New(testLib, view.New("test", view.WithClause(
+				view.WithAggregation(ak),
+			)))
+			desc := test.Descriptor("foo", ik, number.Float64Kind)
+
+			inst, conflicts := vc.Compile(desc)
+			require.NotNil(t, inst, "%v %v", ik, ak)
+
+			err := conflicts.AsError()
+			if !isIncompatible(ik, ak) {
+				require.NoError(t, err, "%v %v", ik, ak)
+				continue
+			}
+
+			var iae IncompatibleAggregationError
+			require.True(t, errors.As(err, &iae), "%v %v", ik, ak)
+			require.Equal(t, desc, iae.Descriptor)
+			require.Equal(t, ak, iae.Aggregation)
+			require.Equal(t, view.StandardAggregationKind(ik), iae.Fallback)
+			require.NotEmpty(t, iae.Reason)
+			require.True(t, errors.Is(iae, SemanticError{}))
+			require.Equal(t, iae.Fallback, vc.Collectors()[0].(Duplicate).Aggregation())
+		}
+	}
+}
+
+// TestDropCompatible tests that the drop aggregation is compatible
+// with every instrument kind, compiling to a nil instrument.
+func TestDropCompatible(t *testing.T) {
+	for ik := sdkinstrument.Kind(0); ik < sdkinstrument.NumKinds; ik++ {
+		vc := New(testLib, view.New("test", view.WithClause(
+			view.WithAggregation(aggregation.DropKind),
+		)))
+		inst, conflicts := vc.Compile(test.Descriptor("foo", ik, number.Float64Kind))
+		require.Nil(t, inst)
+		require.NoError(t, conflicts.AsError())
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package viewstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
+
+import (
+	"container/list"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// filterCache is a fixed-size LRU cache from input attribute set to
+// the result of renaming and filtering it.  Synchronous instruments
+// compute the filtered set each time a record is created, which
+// happens repeatedly for the same attributes when records are
+// released between collections.
+type filterCache struct {
+	lock    sync.Mutex
+	size    int
+	order   *list.List // of *filterCacheEntry, most recent first
+	entries map[attribute.Set]*list.Element
+}
+
+type filterCacheEntry struct {
+	input  attribute.Set
+	output attribute.Set
+}
+
+// newFilterCache returns a cache holding up to size entries, or nil
+// if size is not positive.
+func newFilterCache(size int) *filterCache {
+	if size <= 0 {
+		return nil
+	}
+	return &filterCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[attribute.Set]*list.Element{},
+	}
+}
+
+// get returns the cached output for input, if present.
+func (fc *filterCache) get(input attribute.Set) (attribute.Set, bool) {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	elem, ok := fc.entries[input]
+	if !ok {
+		return attribute.Set{}, false
+	}
+	fc.order.MoveToFront(elem)
+	return elem.Value.(*filterCacheEntry).output, true
+}
+
+// put stores the output for input, evicting the least-recently used
+// entry when the cache is full.
+func (fc *filterCache) put(input, output attribute.Set) {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	if elem, ok := fc.entries[input]; ok {
+		fc.order.MoveToFront(elem)
+		return
+	}
+	if fc.order.Len() >= fc.size {
+		oldest := fc.order.Back()
+		fc.order.Remove(oldest)
+		delete(fc.entries, oldest.Value.(*filterCacheEntry).input)
+	}
+	fc.entries[input] = fc.order.PushFront(&filterCacheEntry{
+		input:  input,
+		output: output,
+	})
+}
+
+// len returns the number of cached entries.
+func (fc *filterCache) len() int {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	return fc.order.Len()
+}
@@ -15,24 +15,31 @@
 package viewstate // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"path"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/gauge"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/minmaxsumcount"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/rate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/summary"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument/hint"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/unit"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.uber.org/multierr"
 )
 
 // Compiler implements Views for a single Meter.  A single Compiler
@@ -42,20 +49,21 @@ import (
 // Information flows through the Compiler as follows:
 //
 // When new instruments are created:
-// - The Compiler.Compile() method returns an Instrument value and possible
-//   duplicate or semantic conflict error.
+//   - The Compiler.Compile() method returns an Instrument value and possible
+//     duplicate or semantic conflict error.
 //
 // When instruments are used:
 // - The Instrument.NewAccumulator() method returns an Accumulator value for each attribute.Set used
 // - The Accumulator.Update() aggregates one value for each measurement.
 //
 // During collection:
-// - The Accumulator.SnapshotAndProcess() method captures the current value
-//   and conveys it to the output storage
-// - The Compiler.Collectors() interface returns one Collector per output
-//   Metric in the Meter (duplicate definitions included).
-// - The Collector.Collect() method outputs one Point for each attribute.Set
-//   in the result.
+//   - The Accumulator.SnapshotAndProcess() method captures the current value
+//     and conveys it to the output storage, or Snapshot() and Process()
+//     perform these two steps separately
+//   - The Compiler.Collectors() interface returns one Collector per output
+//     Metric in the Meter (duplicate definitions included).
+//   - The Collector.Collect() method outputs one Point for each attribute.Set
+//     in the result.
 type Compiler struct {
 	// views is the configuration of this compiler.
 	views *view.Views
@@ -74,6 +82,27 @@ type Compiler struct {
 	// names is the map of output names for metrics
 	// produced by this compiler.
 	names map[string][]leafInstrument
+
+	// compiled lists each call to Compile, for use by Clone.
+	compiled []compiledEntry
+
+	// clock (if non-nil) is set in each aggregator.Config.
+	clock aggregator.Clock
+
+	// process (if non-nil) rewrites each descriptor before it is
+	// compiled.
+	process func(sdkinstrument.Descriptor) sdkinstrument.Descriptor
+
+	// added are the clauses added by AddClause, which follow
+	// the clauses of views.  The slice is replaced, not
+	// modified, when a clause is added.
+	added []view.ClauseConfig
+}
+
+// compiledEntry is the input and output of one call to Compile.
+type compiledEntry struct {
+	desc sdkinstrument.Descriptor
+	inst Instrument
 }
 
 // Instrument is a compiled implementation of an instrument
@@ -90,6 +119,110 @@ type Instrument interface {
 	// called since the last collection and to ensure that each
 	// of them has SnapshotAndProcess() called.
 	NewAccumulator(kvs attribute.Set) Accumulator
+
+	// Reset returns the output aggregators to their initial
+	// state.  Entries that are no longer referenced by any
+	// Accumulator are removed.  Subsequent cumulative points
+	// start at the time of the Reset.
+	Reset()
+}
+
+// Bypasser is implemented by synchronous Instruments, which accept
+// attribute sets that bypass the attribute filters of the views (see
+// sdkinstrument.WithBypassFilter).
+type Bypasser interface {
+	// NewBypassAccumulator is NewAccumulator for an attribute
+	// set that is used as-is, without applying the keys,
+	// renames, and value length limits of the views.
+	NewBypassAccumulator(kvs attribute.Set) Accumulator
+}
+
+// newBypassAccumulator calls NewBypassAccumulator for a Bypasser,
+// otherwise NewAccumulator.
+func newBypassAccumulator(inst Instrument, kvs attribute.Set) Accumulator {
+	if b, ok := inst.(Bypasser); ok {
+		return b.NewBypassAccumulator(kvs)
+	}
+	return inst.NewAccumulator(kvs)
+}
+
+// Reclaimer is implemented by synchronous Instruments, which remove
+// the output storage of attribute sets not updated within the view's
+// TTL (see view.WithAttributeSetTTL).
+type Reclaimer interface {
+	// Reclaim removes the output storage of expired attribute
+	// sets that are not referenced by any Accumulator.
+	Reclaim()
+}
+
+// Overflower is implemented by synchronous Instruments whose views
+// limit the number of attribute sets (see view.WithCardinalityLimit),
+// so that callers can bound the measurement attribute sets they keep.
+type Overflower interface {
+	// RecordLimit returns the number of distinct measurement
+	// attribute sets after which every output overflows, zero
+	// when there is no such number, e.g., because views filter
+	// attributes.
+	RecordLimit() int
+
+	// NewOverflowAccumulator returns an Accumulator that updates
+	// the overflow point of each output.
+	NewOverflowAccumulator() Accumulator
+}
+
+// Router is implemented by Instruments to support attribute-based
+// routing among pipelines (see view.WithRoute).
+type Router interface {
+	// Routed returns true if any attribute sets are routed to
+	// this Instrument.
+	Routed() bool
+
+	// Route returns true if the attribute set is routed to this
+	// Instrument.
+	Route(kvs attribute.Set) bool
+}
+
+// Inspector is implemented by Instruments to support debugging.
+type Inspector interface {
+	// Inspect calls the callback with a copy of the aggregated
+	// state of each attribute set of each output, in no
+	// particular order, without modifying the Instrument.
+	// Outputs without an aggregated change, such as those whose
+	// updates have not been processed, are skipped.  The
+	// callback must not use the Instrument.
+	Inspect(callback func(desc sdkinstrument.Descriptor, kvs attribute.Set, agg aggregation.Aggregation))
+}
+
+// PointProcessing is implemented by Collectors.  CollectInto does not
+// apply the view's point processor (see view.WithPointProcessor),
+// which may merge points, so streaming callers use Collect instead
+// when HasPointProcessor returns true.
+type PointProcessing interface {
+	// HasPointProcessor returns true when a point processor
+	// applies to the collected points.
+	HasPointProcessor() bool
+}
+
+// ContextAttributer is implemented by Instruments that add attributes
+// from the measurement context (see view.WithContextAttributes).
+type ContextAttributer interface {
+	// ContextAttributes returns the baggage keys to copy into
+	// measurement attributes, nil if none.  Callers add them
+	// using ContextAttributeKey, so that each view outputs only
+	// the context attributes that it configures.
+	ContextAttributes() []string
+}
+
+// contextKeyPrefix marks the keys of context attributes.  It sorts
+// before any printable key.
+const contextKeyPrefix = "\x00ctx:"
+
+// ContextAttributeKey returns the attribute key for the context
+// attribute named key.  Views that configure key output it under its
+// own name, unless the measurement has an attribute with the same
+// key, and other views remove it.
+func ContextAttributeKey(key string) attribute.Key {
+	return attribute.Key(contextKeyPrefix + key)
 }
 
 // Updater captures single measurements, for N an int64 or float64.
@@ -101,6 +234,56 @@ type Updater[N number.Any] interface {
 	Update(value N)
 }
 
+// ContextUpdater is an Updater that also receives the context of the
+// measurement, implemented by synchronous instrument Accumulators.
+type ContextUpdater[N number.Any] interface {
+	// UpdateContext captures a single measurement with its
+	// context.  Aggregators that do not use the context treat
+	// this the same as Update.
+	UpdateContext(ctx context.Context, value N)
+}
+
+// WeightedUpdater is a ContextUpdater that accepts one measurement
+// standing for weight identical measurements, implemented by
+// synchronous instrument Accumulators.
+type WeightedUpdater[N number.Any] interface {
+	// UpdateWeighted captures weight measurements of value.
+	// Aggregators that do not support weights, such as gauges,
+	// treat this the same as UpdateContext.
+	UpdateWeighted(ctx context.Context, value N, weight uint64)
+}
+
+// BucketUpdater is implemented by synchronous instrument
+// Accumulators to merge data that was bucketed elsewhere.
+type BucketUpdater[N number.Any] interface {
+	// UpdateBucket adds count to the bucket at index and sum to
+	// the sum of explicit-bucket histogram outputs.  Returns
+	// false if any output has no bucket at index, uses another
+	// aggregation, or transforms values, in which case that
+	// output is not modified.
+	UpdateBucket(index int, count uint64, sum N) bool
+}
+
+// StartTimeUpdater is a ContextUpdater for measurements that began
+// at an explicit time, implemented by synchronous instrument
+// Accumulators.
+type StartTimeUpdater[N number.Any] interface {
+	// UpdateWithStartTime captures a single measurement, after
+	// which the points of the attribute set start no later than
+	// start.
+	UpdateWithStartTime(ctx context.Context, value N, start time.Time)
+}
+
+// OverflowReporter is implemented by synchronous instrument
+// Accumulators, for aggregators that detect overflow (see
+// aggregator.OverflowMethods).
+type OverflowReporter[N number.Any] interface {
+	// TakeOverflow returns the output value and true if the
+	// output overflowed since the last call.  This is meant to
+	// be called after SnapshotAndProcess.
+	TakeOverflow() (N, bool)
+}
+
 // Accumulator is an intermediate interface used for short-term
 // aggregation.  Every Accumulator is also an Updater.  The owner of
 // an Accumulator is responsible for maintaining the current set
@@ -120,6 +303,21 @@ type Accumulator interface {
 	// will be snapshot/processed (according to the caller's
 	// reference counting) and it can be forgotten.
 	SnapshotAndProcess(release bool)
+
+	// Snapshot() is the first half of SnapshotAndProcess(): it
+	// takes a snapshot of data aggregated through Update() and
+	// resets the current aggregator, without merging the
+	// snapshot into the output.  This is meant for taking
+	// consistent snapshots of many Accumulators before any of
+	// them are processed.  A snapshot that was not processed is
+	// processed before the next one is taken, so no data is lost.
+	Snapshot()
+
+	// Process() is the second half of SnapshotAndProcess(): it
+	// merges the snapshot taken by Snapshot() into the output,
+	// doing nothing when there is no unprocessed snapshot.
+	// `release` is as for SnapshotAndProcess().
+	Process(release bool)
 }
 
 // leafInstrument is one of the (synchronous or asynchronous),
@@ -132,10 +330,22 @@ type leafInstrument interface {
 	data.Collector
 	// Duplicate is how other instruments this in a conflict.
 	Duplicate
+	// Output describes the compiled behavior.
+	Output
 
 	// mergeDescription handles the special case allowing
 	// descriptions to be merged instead of conflict.
 	mergeDescription(string)
+
+	// sameOutput tests whether the instrument produces the same
+	// output as another given the same input, except for the
+	// descriptor, for comparing duplicates.
+	sameOutput(leafInstrument) bool
+
+	// migrateFrom moves the state of an equivalent instrument
+	// compiled by another Compiler into this one, returning
+	// false when the instrument is not equivalent.
+	migrateFrom(leafInstrument) bool
 }
 
 // singleBehavior is one instrument-view behavior, including the
@@ -156,6 +366,11 @@ type singleBehavior struct {
 	// tempo is the configured aggregation temporality.
 	tempo aggregation.Temporality
 
+	// accumulate is set when asynchronous observations are
+	// deltas, accumulated into a running total for cumulative
+	// temporality.
+	accumulate bool
+
 	// acfg is the aggregator configuration.
 	acfg aggregator.Config
 
@@ -168,6 +383,79 @@ type singleBehavior struct {
 	// keysFilter (if non-nil) is the constructed keys filter.
 	keysFilter *attribute.Filter
 
+	// renameSet (if non-nil) is an attribute set containing each
+	// renamed key with its new name as the value.  This is used
+	// to compare against potential duplicates, like keysSet.
+	renameSet *attribute.Set
+
+	// rename (if non-nil) maps original to renamed keys.
+	rename map[attribute.Key]attribute.Key
+
+	// valueLimit (if non-zero) is the maximum length in runes of
+	// string attribute values.
+	valueLimit int
+
+	// normalSet (if non-nil) is an attribute set containing each
+	// key whose string values are normalized.  This is used to
+	// look up the keys and to compare against potential
+	// duplicates, like keysSet.
+	normalSet *attribute.Set
+
+	// normalize (if non-nil) is applied to the string values of
+	// the keys in normalSet.
+	normalize func(string) string
+
+	// contextKeys (if non-nil) are the baggage keys copied into
+	// measurement attributes.
+	contextKeys []string
+
+	// limit (if non-zero) is the maximum number of distinct
+	// attribute sets, beyond which new sets overflow.
+	limit int
+
+	// overflowSet (if non-nil) replaces the default attribute
+	// set of the overflow point.
+	overflowSet *attribute.Set
+
+	// filterCacheSize (if non-zero) is the number of filtered
+	// attribute sets to cache.
+	filterCacheSize int
+
+	// input is the number kind of the measurements.
+	input number.Kind
+
+	// transform (if non-nil) is applied to measurements before
+	// they are aggregated.
+	transform func(float64) float64
+
+	// unitFactor (if non-zero) multiplies measurements after
+	// transform, for a unit conversion.
+	unitFactor float64
+
+	// processor (if non-nil) is applied to collected points.
+	processor func(*data.Point)
+
+	// unitErr (if non-nil) is the UnitConversionError of a view
+	// whose unit conversion does not match the instrument.
+	unitErr error
+
+	// ttl (if non-zero) is the time after which the output
+	// storage of a synchronous instrument's attribute set is
+	// removed when it has not been updated.
+	ttl time.Duration
+
+	// custom (if non-nil) is the registered custom aggregation
+	// named by kind.
+	custom *customAggregation
+
+	// route (if non-nil) selects the attribute sets routed to
+	// this view's pipeline.
+	route func(attribute.Set) bool
+
+	// startEpoch (if non-zero) is the start time of cumulative
+	// points.
+	startEpoch time.Time
+
 	// hinted is true when the aggregation was set
 	// programmatically via a hint. this bypasses semantic
 	// compatibility checking and allows hints to create a
@@ -184,71 +472,182 @@ func New(library instrumentation.Library, views *view.Views) *Compiler {
 	}
 }
 
+// SetClock configures the clock used by aggregators of instruments
+// compiled after this call, see aggregator.Config.Clock.
+func (v *Compiler) SetClock(clock aggregator.Clock) {
+	v.compilerLock.Lock()
+	defer v.compilerLock.Unlock()
+	v.clock = clock
+}
+
+// SetDescriptorProcessor configures a function that rewrites the
+// descriptor of instruments compiled after this call, before hints
+// are parsed from the description and before view clauses are
+// matched, so that clauses match the rewritten name.  Since hints are
+// parsed afterward, process should preserve a hint in the
+// description.  Changes to the instrument and number kinds are
+// ignored.
+func (v *Compiler) SetDescriptorProcessor(process func(sdkinstrument.Descriptor) sdkinstrument.Descriptor) {
+	v.compilerLock.Lock()
+	defer v.compilerLock.Unlock()
+	v.process = process
+}
+
 func (v *Compiler) Collectors() []data.Collector {
 	v.compilerLock.Lock()
 	defer v.compilerLock.Unlock()
 	return v.collectors
 }
 
+// MemorySize returns the sum of MemorySize() for the Collectors.
+func (v *Compiler) MemorySize() int {
+	size := 0
+	for _, coll := range v.Collectors() {
+		size += coll.MemorySize()
+	}
+	return size
+}
+
+// Instruments returns the descriptor of each instrument compiled by
+// this Compiler in the order they were first compiled, excluding
+// instruments that every view dropped.  Descriptors are the input to
+// Compile, before views rename them.
+func (v *Compiler) Instruments() []sdkinstrument.Descriptor {
+	v.compilerLock.Lock()
+	defer v.compilerLock.Unlock()
+
+	var descs []sdkinstrument.Descriptor
+	seen := map[sdkinstrument.Descriptor]struct{}{}
+	for _, entry := range v.compiled {
+		if entry.inst == nil {
+			continue
+		}
+		if _, ok := seen[entry.desc]; ok {
+			continue
+		}
+		seen[entry.desc] = struct{}{}
+		descs = append(descs, entry.desc)
+	}
+	return descs
+}
+
+// Output describes one metric output of a Compiler, as configured
+// after hints and views are applied.
+type Output interface {
+	// Descriptor describes the output, after renaming.
+	Descriptor() sdkinstrument.Descriptor
+
+	// AggregationKind is the aggregation that was compiled.
+	AggregationKind() aggregation.Kind
+
+	// Temporality is the temporality of the collected points.
+	Temporality() aggregation.Temporality
+}
+
+// Outputs returns one Output per Collector, in the same order as
+// Collectors(), allowing the compiled configuration to be inspected
+// without collecting data.
+func (v *Compiler) Outputs() []Output {
+	v.compilerLock.Lock()
+	defer v.compilerLock.Unlock()
+
+	outputs := make([]Output, len(v.collectors))
+	for i, coll := range v.collectors {
+		outputs[i] = coll.(Output)
+	}
+	return outputs
+}
+
 // tryToApplyHint looks for a Lightstep-specified hint structure
 // encoded as JSON in the description.  If valid, returns the modified
 // configuration, otherwise returns the default for the instrument.
-func (v *Compiler) tryToApplyHint(instrument sdkinstrument.Descriptor) (_ sdkinstrument.Descriptor, akind aggregation.Kind, acfg aggregator.Config, hinted bool) {
+func (v *Compiler) tryToApplyHint(instrument sdkinstrument.Descriptor) (_ sdkinstrument.Descriptor, akind aggregation.Kind, acfg aggregator.Config, tempo aggregation.Temporality, hinted bool) {
 	// These are the default behaviors, we'll use them unless there's a valid hint.
 	akind = v.views.Defaults.Aggregation(instrument.Kind)
 	acfg = v.views.Defaults.AggregationConfig(
 		instrument.Kind,
 		instrument.NumberKind,
 	)
+	tempo = v.views.Defaults.Temporality(instrument.Kind)
 
-	// Check for required JSON symbols, empty strings, ...
-	if !strings.Contains(instrument.Description, "{") {
-		return instrument, akind, acfg, hinted
+	h, desc, err := hint.Parse(instrument.Description)
+	if err != nil {
+		otel.Handle(err)
 	}
-
-	var hint view.Hint
-	if err := json.Unmarshal([]byte(instrument.Description), &hint); err != nil {
-		// This could be noisy if valid descriptions contain spurious '{' chars.
-		otel.Handle(fmt.Errorf("hint parse error: %w", err))
-		return instrument, akind, acfg, hinted
+	if !h.Found() {
+		return instrument, akind, acfg, tempo, hinted
 	}
 
 	// Replace the hint input with its embedded description.
-	instrument.Description = hint.Description
+	instrument.Description = desc
 
 	// Bypass semantic compatibility check.
 	hinted = true
 
-	// Potentially set the aggregation kind and aggregator config.
-	if hint.Aggregation != "" {
-		parseKind, ok := aggregation.ParseKind(hint.Aggregation)
-		if !ok {
-			otel.Handle(fmt.Errorf("hint invalid aggregation: %v", hint.Aggregation))
-		} else if parseKind != aggregation.UndefinedKind {
-			akind = parseKind
-		}
+	// Potentially set the aggregation kind, aggregator config,
+	// and temporality.
+	if h.Aggregation != aggregation.UndefinedKind {
+		akind = h.Aggregation
 	}
-
-	if hint.Config != (aggregator.JSONConfig{}) {
-		cfg := hint.Config.ToConfig()
-		cfg, err := cfg.Validate()
-		if err != nil {
-			otel.Handle(fmt.Errorf("hint invalid aggregator config: %w", err))
+	if h.Config != nil {
+		// The bucket limit protects against hints with
+		// excessive boundaries, so it is not replaced.
+		maxBuckets := acfg.HistogramMaxBuckets
+		acfg = *h.Config
+		if acfg.HistogramMaxBuckets == 0 {
+			acfg.HistogramMaxBuckets = maxBuckets
 		}
-		acfg = cfg
+	}
+	if h.Temporality != aggregation.UndefinedTemporality {
+		tempo = h.Temporality
 	}
 
-	return instrument, akind, acfg, hinted
+	return instrument, akind, acfg, tempo, hinted
 }
 
 // Compile is called during NewInstrument by the Meter
 // implementation, the result saved in the instrument and used to
 // construct new Accumulators throughout its lifetime.
 func (v *Compiler) Compile(instrument sdkinstrument.Descriptor) (Instrument, ViewConflictsBuilder) {
+	v.compilerLock.Lock()
+	defer v.compilerLock.Unlock()
+
+	result, conflicts := v.compileLocked(instrument)
+
+	v.compiled = append(v.compiled, compiledEntry{
+		desc: instrument,
+		inst: result,
+	})
+	return result, conflicts
+}
+
+// processDescriptor applies the descriptor processor, if any,
+// preserving the instrument and number kinds.
+func processDescriptor(process func(sdkinstrument.Descriptor) sdkinstrument.Descriptor, instrument sdkinstrument.Descriptor) sdkinstrument.Descriptor {
+	if process == nil {
+		return instrument
+	}
+	processed := process(instrument)
+	processed.Kind = instrument.Kind
+	processed.NumberKind = instrument.NumberKind
+	return processed
+}
+
+// compileLocked is Compile without recording the result for Clone
+// and Lookup.  The caller holds compilerLock.
+func (v *Compiler) compileLocked(instrument sdkinstrument.Descriptor) (Instrument, ViewConflictsBuilder) {
+	process := v.process
+	clauses := v.views.Clauses
+	if len(v.added) != 0 {
+		clauses = append(clauses[:len(clauses):len(clauses)], v.added...)
+	}
+
+	instrument = processDescriptor(process, instrument)
+
 	var behaviors []singleBehavior
 	var matches []view.ClauseConfig
 
-	for _, view := range v.views.Clauses {
+	for _, view := range clauses {
 		if !view.Matches(v.library, instrument) {
 			continue
 		}
@@ -261,7 +660,7 @@ func (v *Compiler) Compile(instrument sdkinstrument.Descriptor) (Instrument, Vie
 			continue
 		}
 
-		modified, hintAkind, hintAcfg, hinted := v.tryToApplyHint(instrument)
+		modified, hintAkind, hintAcfg, hintTempo, hinted := v.tryToApplyHint(instrument)
 		instrument = modified // the hint erases itself from the description
 
 		if akind == aggregation.UndefinedKind {
@@ -269,25 +668,74 @@ func (v *Compiler) Compile(instrument sdkinstrument.Descriptor) (Instrument, Vie
 		}
 
 		cf := singleBehavior{
-			fromName: instrument.Name,
-			desc:     viewDescriptor(instrument, view),
-			kind:     akind,
-			acfg:     pickAggConfig(hintAcfg, view.AggregatorConfig()),
-			tempo:    v.views.Defaults.Temporality(instrument.Kind),
-			hinted:   hinted,
+			fromName:    instrument.Name,
+			desc:        viewDescriptor(instrument, view),
+			kind:        akind,
+			acfg:        pickAggConfig(hintAcfg, view.AggregatorConfig()),
+			tempo:       hintTempo,
+			hinted:      hinted,
+			limit:       view.CardinalityLimit(),
+			transform:   view.ValueTransform(),
+			contextKeys: view.ContextAttributes(),
+			valueLimit:  view.AttributeValueLengthLimit(),
+			processor:   view.PointProcessor(),
+			ttl:         view.AttributeSetTTL(),
+			route:       view.Route(),
+			startEpoch:  view.StartTimeAlignment(),
+		}
+
+		if view.TemporalityConversion() {
+			cf.tempo = aggregation.CumulativeTemporality
+			cf.accumulate = true
+		}
+		if view.DeltaTemporalityConversion() {
+			cf.tempo = aggregation.DeltaTemporality
+		}
+		if view.TrimEmptyBuckets() {
+			cf.acfg.HistogramTrimEmptyBuckets = true
+		}
+		if d := view.StaleGaugeDrop(); d > 0 {
+			cf.acfg.GaugeStaleAfter = d
 		}
 
-		keys := view.Keys()
-		if keys != nil {
-			cf.keysSet = keysToSet(view.Keys())
-			cf.keysFilter = keysToFilter(view.Keys())
+		keys, patterns := view.Keys(), view.KeyPatterns()
+		if keys != nil || patterns != nil {
+			cf.keysSet = keysToSet(keys, patterns)
+			cf.keysFilter = keysToFilter(keys, patterns)
+		}
+		if rename := view.AttributeRename(); len(rename) != 0 {
+			cf.renameSet = renameToSet(rename)
+			cf.rename = rename
+		}
+		if from, to, factor := view.UnitConversion(); factor != 0 {
+			if instrument.Unit != unit.Unit(from) {
+				cf.unitErr = UnitConversionError{
+					Descriptor: instrument,
+					From:       from,
+					To:         to,
+				}
+			} else {
+				cf.desc.Unit = unit.Unit(to)
+				cf.unitFactor = factor
+			}
+		}
+		if kv := view.OverflowAttribute(); kv.Valid() {
+			set := attribute.NewSet(kv)
+			cf.overflowSet = &set
+		}
+		if keys, fn := view.ValueNormalization(); len(keys) != 0 && fn != nil {
+			cf.normalSet = keysToSet(keys, nil)
+			cf.normalize = fn
+		}
+		if cf.keysFilter != nil || cf.rename != nil || cf.normalize != nil || cf.valueLimit > 0 {
+			cf.filterCacheSize = view.FilterCacheSize()
 		}
 		behaviors = append(behaviors, cf)
 	}
 
 	// If there were no matching views, set the default aggregation.
 	if len(matches) == 0 {
-		modified, akind, acfg, hinted := v.tryToApplyHint(instrument)
+		modified, akind, acfg, tempo, hinted := v.tryToApplyHint(instrument)
 		instrument = modified // the hint erases itself from the description
 
 		if akind != aggregation.DropKind {
@@ -296,23 +744,70 @@ func (v *Compiler) Compile(instrument sdkinstrument.Descriptor) (Instrument, Vie
 				desc:     instrument,
 				kind:     akind,
 				acfg:     acfg,
-				tempo:    v.views.Defaults.Temporality(instrument.Kind),
+				tempo:    tempo,
 				hinted:   hinted,
 			})
 		}
 	}
 
-	v.compilerLock.Lock()
-	defer v.compilerLock.Unlock()
-
 	var conflicts ViewConflictsBuilder
 	var compiled []Instrument
 
 	for _, behavior := range behaviors {
+		if v.clock != nil {
+			behavior.acfg.Clock = v.clock
+		}
+
 		// the following checks semantic compatibility
 		// and if necessary fixes the aggregation kind
 		// to the default, via in place update.
-		semanticErr := checkSemanticCompatibility(instrument.Kind, &behavior)
+		semanticErr := checkSemanticCompatibility(instrument, &behavior)
+		semanticErr = multierr.Append(semanticErr, behavior.unitErr)
+
+		// Explicit boundaries select the explicit-bucket
+		// histogram, which otherwise uses default boundaries.
+		switch {
+		case behavior.kind == aggregation.HistogramKind && behavior.acfg.HistogramBoundaries.Defined():
+			behavior.kind = aggregation.ExplicitHistogramKind
+		case behavior.kind == aggregation.ExplicitHistogramKind && !behavior.acfg.HistogramBoundaries.Defined():
+			behavior.acfg.HistogramBoundaries = histogram.WithExplicitBoundaries(histogram.DefaultExplicitBoundaries())
+		}
+		if behavior.kind == aggregation.ExplicitHistogramKind {
+			behavior.acfg.HistogramBoundaries = behavior.acfg.HistogramBoundaries.Limit(behavior.acfg.HistogramMaxBuckets)
+		}
+
+		// A rate is the float64 gauge of a delta sum.
+		if behavior.kind == aggregation.RateKind {
+			behavior.tempo = aggregation.DeltaTemporality
+			behavior.desc.NumberKind = number.Float64Kind
+		}
+
+		// A transformed value may be fractional, so outputs
+		// that transform values are float64, except for custom
+		// aggregations, which support the input number kind.
+		behavior.input = instrument.NumberKind
+		buildKind := instrument.NumberKind
+		if (behavior.transform != nil || behavior.unitFactor != 0) && behavior.custom == nil {
+			behavior.desc.NumberKind = number.Float64Kind
+			buildKind = number.Float64Kind
+		}
+
+		// The exponential histogram does not support uint64,
+		// use MinMaxSumCount in its place.
+		if behavior.desc.NumberKind == number.Uint64Kind && behavior.kind == aggregation.HistogramKind {
+			behavior.kind = aggregation.MinMaxSumCountKind
+		}
+
+		build := func() leafInstrument {
+			switch buildKind {
+			case number.Int64Kind:
+				return buildView[int64, number.Int64Traits](behavior)
+			case number.Float64Kind:
+				return buildView[float64, number.Float64Traits](behavior)
+			}
+			return buildUint64View(behavior)
+		}
+		candidate := build()
 
 		existingInsts := v.names[behavior.desc.Name]
 		var leaf leafInstrument
@@ -337,17 +832,9 @@ func (v *Compiler) Compile(instrument sdkinstrument.Descriptor) (Instrument, Vie
 			if inst.Descriptor().NumberKind != behavior.desc.NumberKind {
 				continue
 			}
-			if !equalConfigs(inst.Config(), behavior.acfg) {
-				continue
-			}
-
-			// For attribute keys, test for equal nil-ness or equal value.
-			instKeys := inst.Keys()
-			confKeys := behavior.keysSet
-			if (instKeys == nil) != (confKeys == nil) {
-				continue
-			}
-			if instKeys != nil && *instKeys != *confKeys {
+			// Likewise for the remaining configuration,
+			// e.g., attribute keys, limits, and temporality.
+			if !inst.sameOutput(candidate) {
 				continue
 			}
 			// We can return the previously-compiled instrument,
@@ -357,17 +844,35 @@ func (v *Compiler) Compile(instrument sdkinstrument.Descriptor) (Instrument, Vie
 			leaf = inst
 			break
 		}
+		// dropped is set when the duplicate policy keeps only
+		// the first of conflicting instruments.
+		dropped := false
 		if leaf == nil {
-			switch behavior.desc.NumberKind {
-			case number.Int64Kind:
-				leaf = buildView[int64, number.Int64Traits](behavior)
-			case number.Float64Kind:
-				leaf = buildView[float64, number.Float64Traits](behavior)
+			name := behavior.desc.Name
+			leaf = candidate
+			if len(existingInsts) != 0 {
+				switch v.views.Defaults.Duplicates {
+				case view.KeepFirstDuplicate:
+					dropped = true
+				case view.RenameDuplicates:
+					behavior.desc.Name = v.unusedName(behavior.desc.Name)
+					leaf = build()
+				}
 			}
 
-			v.collectors = append(v.collectors, leaf)
+			// The conflict lists the new instrument even when
+			// it is dropped or renamed.
 			existingInsts = append(existingInsts, leaf)
-			v.names[behavior.desc.Name] = existingInsts
+			if !dropped {
+				v.collectors = append(v.collectors, leaf)
+				v.names[name] = existingInsts
+			}
+			if !dropped && behavior.desc.Name != name {
+				// A renamed instrument is found under both
+				// names, so that it is deduplicated when
+				// registered again.
+				v.names[behavior.desc.Name] = append(v.names[behavior.desc.Name], leaf)
+			}
 		}
 		if len(existingInsts) > 1 || semanticErr != nil {
 			c := Conflict{
@@ -379,20 +884,49 @@ func (v *Compiler) Compile(instrument sdkinstrument.Descriptor) (Instrument, Vie
 			}
 			conflicts.Add(v.views.Name, c)
 		}
-		compiled = append(compiled, leaf)
+		if !dropped {
+			compiled = append(compiled, leaf)
+		}
 	}
 	return Combine(instrument, compiled...), conflicts
 }
 
+// unusedName returns name with the first numeric suffix, starting
+// at 2, that is not the name of an output of this Compiler.
+func (v *Compiler) unusedName(name string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s.%d", name, n)
+		if len(v.names[candidate]) == 0 {
+			return candidate
+		}
+	}
+}
+
 // buildView compiles either a synchronous or asynchronous instrument
 // given its behavior and generic number type/traits.
-func buildView[N number.Any, Traits number.Traits[N]](behavior singleBehavior) leafInstrument {
+func buildView[N number.Signed, Traits number.Traits[N]](behavior singleBehavior) leafInstrument {
 	if behavior.desc.Kind.Synchronous() {
+		if behavior.kind == aggregation.HistogramKind {
+			return newSyncView[
+				N,
+				histogram.Histogram[N, Traits],
+				histogram.Methods[N, Traits],
+			](behavior)
+		}
 		return compileSync[N, Traits](behavior)
 	}
 	return compileAsync[N, Traits](behavior)
 }
 
+// buildUint64View is buildView for uint64 instruments, for which
+// Compile() has already replaced the histogram aggregation.
+func buildUint64View(behavior singleBehavior) leafInstrument {
+	if behavior.desc.Kind.Synchronous() {
+		return compileSync[uint64, number.Uint64Traits](behavior)
+	}
+	return compileAsync[uint64, number.Uint64Traits](behavior)
+}
+
 // newSyncView returns a compiled synchronous instrument.  If the view
 // calls for delta temporality, a stateless instrument is returned,
 // otherwise for cumulative temporality a stateful instrument will be
@@ -406,13 +940,31 @@ func newSyncView[
 	// is being copied before the new object is returned to the
 	// user, and the extra allocation cost here would be
 	// noticeable.
-	metric := instrumentBase[N, Storage, int64, Methods]{
-		fromName:   behavior.fromName,
-		desc:       behavior.desc,
-		acfg:       behavior.acfg,
-		data:       map[attribute.Set]*storageHolder[Storage, int64]{},
-		keysSet:    behavior.keysSet,
-		keysFilter: behavior.keysFilter,
+	metric := instrumentBase[N, Storage, syncAuxiliary, Methods]{
+		fromName:    behavior.fromName,
+		desc:        behavior.desc,
+		acfg:        behavior.acfg,
+		data:        map[attribute.Set]*storageHolder[Storage, syncAuxiliary]{},
+		keysSet:     behavior.keysSet,
+		keysFilter:  behavior.keysFilter,
+		renameSet:   behavior.renameSet,
+		rename:      behavior.rename,
+		valueLimit:  behavior.valueLimit,
+		normalSet:   behavior.normalSet,
+		normalize:   behavior.normalize,
+		filterCache: newFilterCache(behavior.filterCacheSize),
+		contextKeys: behavior.contextKeys,
+		limit:       behavior.limit,
+		overflowSet: behavior.overflowSet,
+		transform:   newValueTransform(behavior),
+		input:       behavior.input,
+		processor:   behavior.processor,
+		ttl:         behavior.ttl,
+		custom:      behavior.custom,
+		route:       behavior.route,
+		startEpoch:  behavior.startEpoch,
+		tempo:       behavior.tempo,
+		accumulate:  behavior.accumulate,
 	}
 	instrument := compiledSyncBase[N, Storage, Methods]{
 		instrumentBase: metric, //nolint:govet
@@ -429,21 +981,43 @@ func newSyncView[
 }
 
 // compileSync calls newSyncView to compile a synchronous
-// instrument with specific aggregator storage and methods.
+// instrument with specific aggregator storage and methods.  The
+// exponential histogram aggregation is handled by buildView, since it
+// does not support every number.Any.  Custom aggregations use
+// customMethods.
 func compileSync[N number.Any, Traits number.Traits[N]](behavior singleBehavior) leafInstrument {
-	switch behavior.kind {
-	case aggregation.HistogramKind:
+	if behavior.custom != nil {
 		return newSyncView[
 			N,
-			histogram.Histogram[N, Traits],
-			histogram.Methods[N, Traits],
+			customStorage[N],
+			customMethods[N],
 		](behavior)
+	}
+	switch behavior.kind {
 	case aggregation.MinMaxSumCountKind:
 		return newSyncView[
 			N,
 			minmaxsumcount.State[N, Traits],
 			minmaxsumcount.Methods[N, Traits],
 		](behavior)
+	case aggregation.ExplicitHistogramKind:
+		return newSyncView[
+			N,
+			histogram.Explicit[N, Traits],
+			histogram.ExplicitMethods[N, Traits],
+		](behavior)
+	case aggregation.SummaryKind:
+		return newSyncView[
+			N,
+			summary.State[N, Traits],
+			summary.Methods[N, Traits],
+		](behavior)
+	case aggregation.RateKind:
+		return newSyncView[
+			N,
+			rate.State[N, Traits],
+			rate.Methods[N, Traits],
+		](behavior)
 	case aggregation.NonMonotonicSumKind:
 		return newSyncView[
 			N,
@@ -472,7 +1046,9 @@ func compileSync[N number.Any, Traits number.Traits[N]](behavior singleBehavior)
 // view calls for delta temporality, a stateful instrument is
 // returned, otherwise for cumulative temporality a stateless
 // instrument will be used.  I.e., Cumulative->Stateless,
-// Delta->Stateful.
+// Delta->Stateful.  When the view converts delta observations of
+// aggregators that support it, i.e., gauges, to cumulative
+// temporality, an accumulating instrument is returned.
 func newAsyncView[
 	N number.Any,
 	Storage any,
@@ -483,17 +1059,43 @@ func newAsyncView[
 	// user, and the extra allocation cost here would be
 	// noticeable.
 	metric := instrumentBase[N, Storage, notUsed, Methods]{
-		fromName:   behavior.fromName,
-		desc:       behavior.desc,
-		acfg:       behavior.acfg,
-		data:       map[attribute.Set]*storageHolder[Storage, notUsed]{},
-		keysSet:    behavior.keysSet,
-		keysFilter: behavior.keysFilter,
+		fromName:    behavior.fromName,
+		desc:        behavior.desc,
+		acfg:        behavior.acfg,
+		data:        map[attribute.Set]*storageHolder[Storage, notUsed]{},
+		keysSet:     behavior.keysSet,
+		keysFilter:  behavior.keysFilter,
+		renameSet:   behavior.renameSet,
+		rename:      behavior.rename,
+		valueLimit:  behavior.valueLimit,
+		normalSet:   behavior.normalSet,
+		normalize:   behavior.normalize,
+		filterCache: newFilterCache(behavior.filterCacheSize),
+		contextKeys: behavior.contextKeys,
+		limit:       behavior.limit,
+		overflowSet: behavior.overflowSet,
+		transform:   newValueTransform(behavior),
+		input:       behavior.input,
+		processor:   behavior.processor,
+		custom:      behavior.custom,
+		startEpoch:  behavior.startEpoch,
+		tempo:       behavior.tempo,
+		accumulate:  behavior.accumulate,
 	}
 	instrument := compiledAsyncBase[N, Storage, Methods]{
 		instrumentBase: metric, //nolint:govet
 	}
 
+	if behavior.accumulate {
+		var methods Methods
+		if _, ok := any(methods).(aggregator.AccumulateMethods[N, Storage]); ok {
+			return &accumulatingAsyncInstrument[N, Storage, Methods]{
+				compiledAsyncBase: instrument, //nolint:govet
+				totals:            map[attribute.Set]*storageHolder[Storage, totalState]{},
+			}
+		}
+	}
+
 	if behavior.tempo == aggregation.DeltaTemporality {
 		var methods Methods
 		if methods.Kind() != aggregation.GaugeKind {
@@ -511,9 +1113,23 @@ func newAsyncView[
 }
 
 // compileAsync calls newAsyncView to compile an asynchronous
-// instrument with specific aggregator storage and methods.
+// instrument with specific aggregator storage and methods.  Custom
+// aggregations use customMethods.
 func compileAsync[N number.Any, Traits number.Traits[N]](behavior singleBehavior) leafInstrument {
+	if behavior.custom != nil {
+		return newAsyncView[
+			N,
+			customStorage[N],
+			customMethods[N],
+		](behavior)
+	}
 	switch behavior.kind {
+	case aggregation.RateKind:
+		return newAsyncView[
+			N,
+			rate.State[N, Traits],
+			rate.Methods[N, Traits],
+		](behavior)
 	case aggregation.MonotonicSumKind:
 		return newAsyncView[
 			N,
@@ -547,12 +1163,95 @@ func Combine(desc sdkinstrument.Descriptor, insts ...Instrument) Instrument {
 	if len(insts) == 1 {
 		return insts[0]
 	}
-	if desc.NumberKind == number.Float64Kind {
+	switch desc.NumberKind {
+	case number.Float64Kind:
 		return multiInstrument[float64](insts)
+	case number.Uint64Kind:
+		return multiInstrument[uint64](insts)
 	}
 	return multiInstrument[int64](insts)
 }
 
+// Route is Combine for the per-pipeline instruments of a synchronous
+// instrument, some of which may route attribute sets (see
+// view.WithRoute).  Each attribute set is delivered to the routed
+// instruments that route it or, when there are none, to the
+// instruments that are not routed.  Without routes, this returns
+// Combine(desc, insts...).
+func Route(desc sdkinstrument.Descriptor, insts ...Instrument) Instrument {
+	switch desc.NumberKind {
+	case number.Float64Kind:
+		return newRoutedInstrument[float64](desc, insts)
+	case number.Uint64Kind:
+		return newRoutedInstrument[uint64](desc, insts)
+	}
+	return newRoutedInstrument[int64](desc, insts)
+}
+
+// routedInstrument is used by Route() to deliver each attribute set
+// to the pipelines that route it.
+type routedInstrument[N number.Any] struct {
+	multiInstrument[N]
+
+	// routed are the instruments that route attribute sets,
+	// unrouted receive the attribute sets that none route.
+	routed   []Instrument
+	unrouted []Instrument
+}
+
+func newRoutedInstrument[N number.Any](desc sdkinstrument.Descriptor, insts []Instrument) Instrument {
+	ri := &routedInstrument[N]{
+		multiInstrument: insts,
+	}
+	for _, inst := range insts {
+		if r, ok := inst.(Router); ok && r.Routed() {
+			ri.routed = append(ri.routed, inst)
+		} else {
+			ri.unrouted = append(ri.unrouted, inst)
+		}
+	}
+	if ri.routed == nil {
+		return Combine(desc, insts...)
+	}
+	return ri
+}
+
+// NewAccumulator returns an Accumulator for the instruments that
+// receive the attribute set, which may be none.
+func (ri *routedInstrument[N]) NewAccumulator(kvs attribute.Set) Accumulator {
+	var accs multiAccumulator[N]
+
+	for _, inst := range ri.routed {
+		if inst.(Router).Route(kvs) {
+			accs = append(accs, inst.NewAccumulator(kvs))
+		}
+	}
+	if accs == nil {
+		for _, inst := range ri.unrouted {
+			accs = append(accs, inst.NewAccumulator(kvs))
+		}
+	}
+	return accs
+}
+
+// NewBypassAccumulator is NewAccumulator for an attribute set that
+// bypasses the attribute filters of the views.
+func (ri *routedInstrument[N]) NewBypassAccumulator(kvs attribute.Set) Accumulator {
+	var accs multiAccumulator[N]
+
+	for _, inst := range ri.routed {
+		if inst.(Router).Route(kvs) {
+			accs = append(accs, newBypassAccumulator(inst, kvs))
+		}
+	}
+	if accs == nil {
+		for _, inst := range ri.unrouted {
+			accs = append(accs, newBypassAccumulator(inst, kvs))
+		}
+	}
+	return accs
+}
+
 // multiInstrument is used by Combine() to combine the effects of
 // multiple instrument-view behaviors.  These instruments produce
 // multiAccumulators in NewAccumulator.
@@ -568,11 +1267,140 @@ func (mi multiInstrument[N]) NewAccumulator(kvs attribute.Set) Accumulator {
 	return multiAccumulator[N](accs)
 }
 
-// Uses a int(0)-value attribute to identify distinct key sets.
-func keysToSet(keys []attribute.Key) *attribute.Set {
-	attrs := make([]attribute.KeyValue, len(keys))
-	for i, key := range keys {
-		attrs[i] = key.Int(0)
+// NewBypassAccumulator is NewAccumulator for an attribute set that
+// bypasses the attribute filters of the views.
+func (mi multiInstrument[N]) NewBypassAccumulator(kvs attribute.Set) Accumulator {
+	accs := make([]Accumulator, 0, len(mi))
+
+	for _, inst := range mi {
+		accs = append(accs, newBypassAccumulator(inst, kvs))
+	}
+	return multiAccumulator[N](accs)
+}
+
+// Reset resets each of the combined instruments.
+func (mi multiInstrument[N]) Reset() {
+	for _, inst := range mi {
+		inst.Reset()
+	}
+}
+
+// Routed returns true if any of the combined instruments are routed.
+func (mi multiInstrument[N]) Routed() bool {
+	for _, inst := range mi {
+		if r, ok := inst.(Router); ok && r.Routed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Route returns true if any of the combined instruments route the
+// attribute set.
+func (mi multiInstrument[N]) Route(kvs attribute.Set) bool {
+	for _, inst := range mi {
+		if r, ok := inst.(Router); ok && r.Route(kvs) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reclaim reclaims expired attribute sets of each of the combined
+// instruments.
+func (mi multiInstrument[N]) Reclaim() {
+	for _, inst := range mi {
+		if r, ok := inst.(Reclaimer); ok {
+			r.Reclaim()
+		}
+	}
+}
+
+// RecordLimit returns the largest record limit of the combined
+// instruments, zero if any of them is unlimited.
+func (mi multiInstrument[N]) RecordLimit() int {
+	limit := 0
+	for _, inst := range mi {
+		o, ok := inst.(Overflower)
+		if !ok || o.RecordLimit() == 0 {
+			return 0
+		}
+		if o.RecordLimit() > limit {
+			limit = o.RecordLimit()
+		}
+	}
+	return limit
+}
+
+// NewOverflowAccumulator returns an Accumulator for the overflow
+// points of the combined instruments.
+func (mi multiInstrument[N]) NewOverflowAccumulator() Accumulator {
+	accs := make([]Accumulator, 0, len(mi))
+
+	for _, inst := range mi {
+		accs = append(accs, inst.(Overflower).NewOverflowAccumulator())
+	}
+	return multiAccumulator[N](accs)
+}
+
+// Inspect implements Inspector.
+func (mi multiInstrument[N]) Inspect(callback func(sdkinstrument.Descriptor, attribute.Set, aggregation.Aggregation)) {
+	for _, inst := range mi {
+		if i, ok := inst.(Inspector); ok {
+			i.Inspect(callback)
+		}
+	}
+}
+
+// ContextAttributes returns the union of the combined instruments'
+// context attributes.  Each instrument removes the ones it does not
+// configure.
+func (mi multiInstrument[N]) ContextAttributes() []string {
+	var keys []string
+	for _, inst := range mi {
+		ca, ok := inst.(ContextAttributer)
+		if !ok {
+			continue
+		}
+		for _, key := range ca.ContextAttributes() {
+			if !containsString(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Uses a int(0)-value attribute to identify distinct key sets.  Key
+// patterns use a bool-valued attribute, so that a pattern is distinct
+// from an exact key with the same spelling.
+func keysToSet(keys []attribute.Key, patterns []string) *attribute.Set {
+	attrs := make([]attribute.KeyValue, 0, len(keys)+len(patterns))
+	for _, key := range keys {
+		attrs = append(attrs, key.Int(0))
+	}
+	for _, pattern := range patterns {
+		attrs = append(attrs, attribute.Bool(pattern, true))
+	}
+	ns := attribute.NewSet(attrs...)
+	return &ns
+}
+
+// renameToSet returns a set of the renamed keys with the new names
+// as their values.
+func renameToSet(rename map[attribute.Key]attribute.Key) *attribute.Set {
+	attrs := make([]attribute.KeyValue, 0, len(rename))
+	for from, to := range rename {
+		attrs = append(attrs, from.String(string(to)))
 	}
 	ns := attribute.NewSet(attrs...)
 	return &ns
@@ -588,13 +1416,54 @@ func (ks keyFilter) filter(kv attribute.KeyValue) bool {
 	return has
 }
 
-// keysToFilter constructs a keyFilter.
-func keysToFilter(keys []attribute.Key) *attribute.Filter {
+// patternFilter provides an attribute.Filter implementation based on
+// exact keys and key patterns.  Patterns that end in a single "*"
+// are tested as prefixes, others with path.Match.
+type patternFilter struct {
+	keys     keyFilter
+	prefixes []string
+	globs    []string
+}
+
+// filter is an attribute.Filter.
+func (pf *patternFilter) filter(kv attribute.KeyValue) bool {
+	if pf.keys.filter(kv) {
+		return true
+	}
+	key := string(kv.Key)
+	for _, prefix := range pf.prefixes {
+		if strings.HasPrefix(key, prefix) && !strings.Contains(key[len(prefix):], "/") {
+			return true
+		}
+	}
+	for _, glob := range pf.globs {
+		if match, _ := path.Match(glob, key); match {
+			return true
+		}
+	}
+	return false
+}
+
+// keysToFilter constructs a keyFilter, or a patternFilter when there
+// are key patterns.  Patterns were validated by view.Validate.
+func keysToFilter(keys []attribute.Key, patterns []string) *attribute.Filter {
 	kf := keyFilter{}
 	for _, k := range keys {
 		kf[k] = struct{}{}
 	}
 	var af attribute.Filter = kf.filter
+	if len(patterns) != 0 {
+		pf := &patternFilter{keys: kf}
+		for _, pattern := range patterns {
+			prefix := strings.TrimSuffix(pattern, "*")
+			if len(prefix) == len(pattern)-1 && !strings.ContainsAny(prefix, `*?[\`) {
+				pf.prefixes = append(pf.prefixes, prefix)
+				continue
+			}
+			pf.globs = append(pf.globs, pattern)
+		}
+		af = pf.filter
+	}
 	return &af
 }
 
@@ -614,15 +1483,33 @@ func pickAggConfig(def, vcfg aggregator.Config) aggregator.Config {
 
 // checkSemanticCompatibility checks whether an instrument /
 // aggregator pairing is well defined.
-func checkSemanticCompatibility(ik sdkinstrument.Kind, behavior *singleBehavior) error {
+func checkSemanticCompatibility(instrument sdkinstrument.Descriptor, behavior *singleBehavior) error {
+	ik := instrument.Kind
+	agg := behavior.kind
+	cat := agg.Category(ik)
+
+	if factory, ok := aggregator.Lookup(agg); ok {
+		if !factory.Supports(instrument.NumberKind) {
+			behavior.kind = view.StandardAggregationKind(ik)
+			return IncompatibleAggregationError{
+				Descriptor:  instrument,
+				Aggregation: agg,
+				Fallback:    behavior.kind,
+				Reason:      fmt.Sprintf("the custom aggregation does not support %v", instrument.NumberKind),
+			}
+		}
+		cat = factory.Category
+		behavior.custom = &customAggregation{
+			kind:    agg,
+			factory: factory,
+		}
+	}
+
 	if behavior.hinted {
 		// Anything goes!
 		return nil
 	}
 
-	agg := behavior.kind
-	cat := agg.Category(ik)
-
 	if agg == aggregation.AnySumKind {
 		switch cat {
 		case aggregation.MonotonicSumCategory, aggregation.HistogramCategory:
@@ -635,42 +1522,63 @@ func checkSemanticCompatibility(ik sdkinstrument.Kind, behavior *singleBehavior)
 		behavior.kind = agg
 	}
 
+	var reason string
 	switch ik {
 	case sdkinstrument.SyncCounter, sdkinstrument.SyncHistogram:
 		switch cat {
 		case aggregation.MonotonicSumCategory, aggregation.NonMonotonicSumCategory, aggregation.HistogramCategory:
 			return nil
 		}
+		reason = "a sum or histogram aggregation is required"
 
 	case sdkinstrument.SyncUpDownCounter, sdkinstrument.AsyncUpDownCounter:
 		switch cat {
 		case aggregation.NonMonotonicSumCategory:
 			return nil
 		}
+		reason = "a non-monotonic sum aggregation is required"
 
 	case sdkinstrument.AsyncCounter:
 		switch cat {
 		case aggregation.NonMonotonicSumCategory, aggregation.MonotonicSumCategory:
 			return nil
 		}
+		reason = "a sum aggregation is required"
 
-	case sdkinstrument.AsyncGauge:
+	case sdkinstrument.AsyncGauge, sdkinstrument.SyncGauge:
 		switch cat {
 		case aggregation.GaugeCategory:
 			return nil
 		}
+		reason = "a gauge aggregation is required"
 	}
 
 	behavior.kind = view.StandardAggregationKind(ik)
-	return SemanticError{
-		Instrument:  ik,
+	behavior.custom = nil
+	return IncompatibleAggregationError{
+		Descriptor:  instrument,
 		Aggregation: agg,
+		Fallback:    behavior.kind,
+		Reason:      reason,
+	}
+}
+
+// scaleTransform returns fn followed by multiplication by factor.
+func scaleTransform(fn func(float64) float64, factor float64) func(float64) float64 {
+	if fn == nil {
+		return func(value float64) float64 {
+			return value * factor
+		}
+	}
+	return func(value float64) float64 {
+		return fn(value) * factor
 	}
 }
 
 // viewDescriptor returns the modified sdkinstrument.Descriptor of a
 // view.  It retains the original instrument kind, numebr kind, and
-// unit, while allowing the name and description to change.
+// unit, while allowing the name and description to change.  A unit
+// conversion changes the unit afterward.
 func viewDescriptor(instrument sdkinstrument.Descriptor, v view.ClauseConfig) sdkinstrument.Descriptor {
 	ikind := instrument.Kind
 	nkind := instrument.NumberKind
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -29,6 +31,7 @@ import (
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/gauge"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/minmaxsumcount"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/rate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/test"
@@ -366,6 +369,59 @@ func TestDuplicateMultipleConflicts(t *testing.T) {
 	}
 }
 
+// TestDuplicateKeepFirst verifies that the KeepFirstDuplicate
+// policy drops a conflicting instrument and reports both.
+func TestDuplicateKeepFirst(t *testing.T) {
+	vc := New(testLib, view.New("test", view.WithDuplicatePolicy(view.KeepFirstDuplicate)))
+
+	inst1, err1 := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Int64Kind, instrument.WithUnit("ms"))
+	require.NoError(t, err1)
+	require.NotNil(t, inst1)
+
+	inst2, err2 := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Int64Kind, instrument.WithUnit("s"))
+	require.Error(t, err2)
+	require.Nil(t, inst2)
+	require.Contains(t, err2.Error(), "test: name \"foo\" conflicts SyncCounter-Int64-MonotonicSum-ms, SyncCounter-Int64-MonotonicSum-s")
+
+	dups := err2.(ViewConflictsError)["test"][0].Duplicates
+	require.Equal(t, 2, len(dups))
+	require.Equal(t, "ms", string(dups[0].Descriptor().Unit))
+	require.Equal(t, "s", string(dups[1].Descriptor().Unit))
+
+	require.Equal(t, 1, len(vc.Collectors()))
+}
+
+// TestDuplicateRename verifies that the RenameDuplicates policy
+// outputs conflicting instruments under distinct names.
+func TestDuplicateRename(t *testing.T) {
+	vc := New(testLib, view.New("test", view.WithDuplicatePolicy(view.RenameDuplicates)))
+
+	inst1, err1 := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err1)
+
+	inst2, err2 := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Float64Kind)
+	require.Error(t, err2)
+	require.Contains(t, err2.Error(), "(original \"foo\")")
+
+	inst3, err3 := testCompile(vc, "foo", sdkinstrument.SyncUpDownCounter, number.Int64Kind)
+	require.Error(t, err3)
+
+	// Equivalent to the renamed instrument, still in conflict.
+	inst4, err4 := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Float64Kind)
+	require.Error(t, err4)
+	require.Equal(t, inst2, inst4)
+
+	inst1.NewAccumulator(attribute.NewSet()).(Updater[int64]).Update(1)
+	inst2.NewAccumulator(attribute.NewSet()).(Updater[float64]).Update(2)
+	inst3.NewAccumulator(attribute.NewSet()).(Updater[int64]).Update(3)
+
+	var names []string
+	for _, out := range vc.Outputs() {
+		names = append(names, out.Descriptor().Name)
+	}
+	require.Equal(t, []string{"foo", "foo.2", "foo.3"}, names)
+}
+
 // TestDuplicateFilterConflicts verifies several cases where
 // instruments output the same metric w/ different filters create conflicts.
 func TestDuplicateFilterConflicts(t *testing.T) {
@@ -392,6 +448,54 @@ func TestDuplicateFilterConflicts(t *testing.T) {
 	}
 }
 
+// TestDuplicateOutputConflicts verifies that instruments renamed to
+// the same output with different limits, temporality, transforms, or
+// point processors are in conflict, and that the same unit
+// conversion is not.
+func TestDuplicateOutputConflicts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		opt      view.ClauseOption
+		conflict bool
+	}{
+		{"limit", view.WithCardinalityLimit(10), true},
+		{"overflow", view.WithOverflowAttribute(attribute.Bool("overflow", true)), true},
+		{"temporality", view.WithDeltaTemporalityConversion(true), true},
+		{"transform", view.WithValueTransform(func(x float64) float64 { return x }), true},
+		{"processor", view.WithPointProcessor(func(*data.Point) {}), true},
+		{"unit", view.WithUnitConversion("ms", "s", 1e-3), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vc := New(testLib, view.New(
+				"test",
+				view.WithClause(
+					view.MatchInstrumentName("foo"),
+					view.WithName("bar"),
+					view.WithUnitConversion("ms", "s", 1e-3),
+				),
+				view.WithClause(
+					view.MatchInstrumentName("bar"),
+					view.WithUnitConversion("ms", "s", 1e-3),
+					tc.opt,
+				),
+			))
+
+			inst1, err1 := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Int64Kind, instrument.WithUnit("ms"))
+			require.NoError(t, err1)
+
+			inst2, err2 := testCompile(vc, "bar", sdkinstrument.SyncCounter, number.Int64Kind, instrument.WithUnit("ms"))
+			if !tc.conflict {
+				require.NoError(t, err2)
+				require.Equal(t, inst1, inst2)
+				return
+			}
+			require.Error(t, err2)
+			require.True(t, errors.Is(err2, ViewConflictsError{}))
+			require.NotEqual(t, inst1, inst2)
+		})
+	}
+}
+
 // TestDeduplicateSameFilters thests that when one instrument is
 // renamed to match another exactly, including filters, they are not
 // in conflict.
@@ -522,6 +626,114 @@ func TestKeyFilters(t *testing.T) {
 	)
 }
 
+func TestCompilerInstruments(t *testing.T) {
+	vc := New(testLib, view.New("test", dropHistInstView))
+	require.Empty(t, vc.Instruments())
+
+	counter := test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	hist := test.Descriptor("hist", sdkinstrument.SyncHistogram, number.Float64Kind)
+	gauge := test.Descriptor("gauge", sdkinstrument.AsyncGauge, number.Float64Kind)
+
+	for _, desc := range []sdkinstrument.Descriptor{counter, hist, gauge, counter} {
+		_, conflicts := vc.Compile(desc)
+		require.NoError(t, conflicts.AsError())
+	}
+
+	require.Equal(t, []sdkinstrument.Descriptor{counter, gauge}, vc.Instruments())
+}
+
+func TestKeyFiltersCached(t *testing.T) {
+	views := view.New("test",
+		view.WithClause(
+			view.WithKeys([]attribute.Key{"a"}),
+			view.WithFilterCacheSize(2),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	base := inst.(*statefulSyncInstrument[int64, sum.MonotonicInt64, sum.MonotonicInt64Methods]).base()
+	require.NotNil(t, base.filterCache)
+
+	for i := 0; i < 3; i++ {
+		for _, b := range []string{"1", "2", "3"} {
+			acc := inst.NewAccumulator(
+				attribute.NewSet(attribute.String("a", "1"), attribute.String("b", b)),
+			)
+			acc.(Updater[int64]).Update(1)
+			acc.SnapshotAndProcess(true)
+		}
+	}
+	require.Equal(t, 2, base.filterCache.len())
+
+	output := testCollect(t, vc)
+
+	require.Equal(t, 1, len(output))
+	require.Equal(t, test.Instrument(
+		test.Descriptor("foo", sdkinstrument.SyncCounter, number.Int64Kind),
+		test.Point(
+			startTime, endTime, sum.NewMonotonicInt64(9), cumulative,
+			attribute.String("a", "1"),
+		)), output[0],
+	)
+}
+
+func TestFilterCacheEviction(t *testing.T) {
+	require.Nil(t, newFilterCache(0))
+
+	fc := newFilterCache(2)
+	set := func(v string) attribute.Set {
+		return attribute.NewSet(attribute.String("k", v))
+	}
+
+	fc.put(set("a"), set("A"))
+	fc.put(set("b"), set("B"))
+
+	// Using "a" makes "b" the least-recently used.
+	out, ok := fc.get(set("a"))
+	require.True(t, ok)
+	require.Equal(t, set("A"), out)
+
+	fc.put(set("c"), set("C"))
+	require.Equal(t, 2, fc.len())
+
+	_, ok = fc.get(set("b"))
+	require.False(t, ok)
+
+	out, ok = fc.get(set("c"))
+	require.True(t, ok)
+	require.Equal(t, set("C"), out)
+}
+
+func TestFilterSet(t *testing.T) {
+	onlyA := func(kv attribute.KeyValue) bool {
+		return kv.Key == "a"
+	}
+	input := func(v string) attribute.Set {
+		return attribute.NewSet(attribute.String("a", v), attribute.String("b", v))
+	}
+
+	// The results do not alias the pooled buffer.
+	first := filterSet(input("1"), onlyA)
+	second := filterSet(input("2"), onlyA)
+	require.Equal(t, attribute.NewSet(attribute.String("a", "1")), first)
+	require.Equal(t, attribute.NewSet(attribute.String("a", "2")), second)
+
+	// Retaining every attribute returns the input.
+	require.Equal(t, input("3"), filterSet(input("3"), func(attribute.KeyValue) bool { return true }))
+	require.Equal(t, attribute.NewSet(), filterSet(input("4"), func(attribute.KeyValue) bool { return false }))
+
+	// The buffer is reused, only the set is allocated.
+	set := input("5")
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = filterSet(set, onlyA)
+	})
+	require.LessOrEqual(t, allocs, 1.0)
+}
+
 // TestTwoViewsOneInt64Instrument verifies that multiple int64
 // instrument behaviors work; in this case, viewing a Sum in each
 // of three independent dimensions.
@@ -672,6 +884,75 @@ func TestAllKeysFilter(t *testing.T) {
 	)
 }
 
+// TestKeyPatternsFilter tests that view.WithKeyPatterns keeps the
+// union of the exact keys and the keys matching a pattern.
+func TestKeyPatternsFilter(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithKeys([]attribute.Key{"region"}),
+			view.WithKeyPatterns([]string{"http.*", "rpc.?"}),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	acc := inst.NewAccumulator(attribute.NewSet(
+		attribute.String("region", "west"),
+		attribute.String("http.method", "get"),
+		attribute.Int("http.status_code", 200),
+		attribute.String("http/path", "x"),
+		attribute.String("rpc.a", "a"),
+		attribute.String("rpc.ab", "ab"),
+		attribute.String("host", "h"),
+	))
+	acc.(Updater[int64]).Update(1)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("foo", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(
+				startTime, endTime, sum.NewMonotonicInt64(1), cumulative,
+				attribute.String("region", "west"),
+				attribute.String("http.method", "get"),
+				attribute.Int("http.status_code", 200),
+				attribute.String("rpc.a", "a"),
+			),
+		),
+	)
+}
+
+// TestKeyPatternsDuplicate tests that a key pattern is distinct from
+// an exact key with the same spelling.
+func TestKeyPatternsDuplicate(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("foo"),
+			view.WithKeys([]attribute.Key{"http.*"}),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("bar"),
+			view.WithName("foo"),
+			view.WithKeyPatterns([]string{"http.*"}),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst1, err := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	inst2, err := testCompile(vc, "bar", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ViewConflictsError{}))
+	require.NotEqual(t, inst1, inst2)
+}
+
 // TestAnySumAggregation checks that the proper aggregation inference
 // is performed for each of the inbstrument types when
 // aggregation.AnySum kind is configured.
@@ -891,6 +1172,234 @@ func TestDeltaTemporalityCounter(t *testing.T) {
 	}
 }
 
+func TestTemporalityConversion(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithTemporalityConversion(true),
+		),
+		view.WithDefaultAggregationTemporalitySelector(view.DeltaPreferredTemporality),
+	)
+
+	vc := New(testLib, views)
+
+	inst1, err := testCompile(vc, "sync", sdkinstrument.SyncCounter, number.Float64Kind)
+	require.NoError(t, err)
+
+	inst2, err := testCompile(vc, "async", sdkinstrument.AsyncCounter, number.Float64Kind)
+	require.NoError(t, err)
+
+	setA := attribute.NewSet(attribute.String("A", "1"))
+	setB := attribute.NewSet(attribute.String("B", "1"))
+
+	seq := testSequence
+	total := 0.0
+
+	for rounds := 1; rounds <= 3; rounds++ {
+		accs := []Accumulator{
+			inst1.NewAccumulator(setA),
+			inst2.NewAccumulator(setA),
+		}
+		if rounds == 1 {
+			// setB is observed once, then disappears.
+			accs = append(accs, inst2.NewAccumulator(setB))
+		}
+		for _, acc := range accs {
+			acc.(Updater[float64]).Update(float64(rounds))
+			acc.SnapshotAndProcess(false)
+		}
+		total += float64(rounds)
+
+		expectAsync := []data.Point{
+			test.Point(seq.Start, seq.Now, sum.NewMonotonicFloat64(float64(rounds)), cumulative, setA.ToSlice()...),
+		}
+		if rounds == 1 {
+			expectAsync = append(expectAsync,
+				test.Point(seq.Start, seq.Now, sum.NewMonotonicFloat64(1), cumulative, setB.ToSlice()...),
+			)
+		}
+
+		test.RequireEqualMetrics(t, testCollectSequence(t, vc, seq),
+			test.Instrument(
+				test.Descriptor("sync", sdkinstrument.SyncCounter, number.Float64Kind),
+				test.Point(seq.Start, seq.Now, sum.NewMonotonicFloat64(total), cumulative, setA.ToSlice()...),
+			),
+			test.Instrument(
+				test.Descriptor("async", sdkinstrument.AsyncCounter, number.Float64Kind),
+				expectAsync...,
+			),
+		)
+
+		// Update the test sequence
+		seq.Last = seq.Now
+		seq.Now = time.Now()
+	}
+}
+
+// TestTemporalityConversionAsyncGauge tests that an asynchronous
+// gauge behind a delta-preferring reader accumulates its observations
+// into a running total per attribute set, carrying over the start
+// time, and that an attribute set that disappears is reset.
+func TestTemporalityConversionAsyncGauge(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithTemporalityConversion(true),
+		),
+		view.WithDefaultAggregationTemporalitySelector(view.DeltaPreferredTemporality),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "gauge", sdkinstrument.AsyncGauge, number.Int64Kind)
+	require.NoError(t, err)
+
+	setA := attribute.NewSet(attribute.String("A", "1"))
+	setB := attribute.NewSet(attribute.String("B", "1"))
+
+	observe := func(set attribute.Set, x int64) {
+		acc := inst.NewAccumulator(set)
+		acc.(Updater[int64]).Update(x)
+		acc.SnapshotAndProcess(true)
+	}
+	seq := testSequence
+	expect := func(points ...data.Point) {
+		test.RequireEqualMetrics(t, testCollectSequence(t, vc, seq),
+			test.Instrument(
+				test.Descriptor("gauge", sdkinstrument.AsyncGauge, number.Int64Kind),
+				points...,
+			),
+		)
+		seq.Last = seq.Now
+		seq.Now = seq.Now.Add(time.Second)
+	}
+
+	// The totals start at the prior collection.
+	observe(setA, 5)
+	observe(setB, 2)
+	first := seq.Last
+	expect(
+		test.Point(first, seq.Now, gauge.NewInt64(5), cumulative, setA.ToSlice()...),
+		test.Point(first, seq.Now, gauge.NewInt64(2), cumulative, setB.ToSlice()...),
+	)
+
+	// B disappears, so its total is reset.
+	observe(setA, 3)
+	expect(
+		test.Point(first, seq.Now, gauge.NewInt64(8), cumulative, setA.ToSlice()...),
+	)
+	require.Equal(t, 1, inst.(data.Collector).Size())
+
+	// A continues its total, B starts a new one.
+	observe(setA, 1)
+	observe(setB, 4)
+	expect(
+		test.Point(first, seq.Now, gauge.NewInt64(9), cumulative, setA.ToSlice()...),
+		test.Point(seq.Last, seq.Now, gauge.NewInt64(4), cumulative, setB.ToSlice()...),
+	)
+}
+
+// TestTemporalityConversionDelta tests cumulative-to-delta conversion
+// of an asynchronous counter, including resets and reclaiming the
+// prior value of attribute sets that stop reporting.
+func TestTemporalityConversionDelta(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithDeltaTemporalityConversion(true),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "async", sdkinstrument.AsyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	setA := attribute.NewSet(attribute.String("A", "1"))
+	setB := attribute.NewSet(attribute.String("B", "1"))
+
+	observe := func(set attribute.Set, x int64) {
+		acc := inst.NewAccumulator(set)
+		acc.(Updater[int64]).Update(x)
+		acc.SnapshotAndProcess(true)
+	}
+	seq := testSequence
+	expect := func(points ...data.Point) {
+		test.RequireEqualMetrics(t, testCollectSequence(t, vc, seq),
+			test.Instrument(
+				test.Descriptor("async", sdkinstrument.AsyncCounter, number.Int64Kind),
+				points...,
+			),
+		)
+		seq.Last = seq.Now
+		seq.Now = seq.Now.Add(time.Second)
+	}
+
+	observe(setA, 5)
+	observe(setB, 2)
+	seen := seq.Now
+	expect(
+		test.Point(seq.Last, seq.Now, sum.NewMonotonicInt64(5), delta, setA.ToSlice()...),
+		test.Point(seq.Last, seq.Now, sum.NewMonotonicInt64(2), delta, setB.ToSlice()...),
+	)
+
+	observe(setA, 8)
+	expect(
+		test.Point(seq.Last, seq.Now, sum.NewMonotonicInt64(3), delta, setA.ToSlice()...),
+	)
+	require.Equal(t, 2, inst.(data.Collector).Size())
+
+	expect()
+
+	// A was reset, B continues from its prior observation.
+	observe(setA, 4)
+	observe(setB, 3)
+	expect(
+		test.Point(seq.Last, seq.Now, sum.NewMonotonicInt64(4), delta, setA.ToSlice()...),
+		test.Point(seen, seq.Now, sum.NewMonotonicInt64(1), delta, setB.ToSlice()...),
+	)
+
+	// B's prior value is reclaimed after it stops reporting.
+	for i := 0; i <= maxMissedCollections; i++ {
+		observe(setA, 4)
+		expect()
+	}
+	require.Equal(t, 1, inst.(data.Collector).Size())
+
+	observe(setB, 3)
+	expect(
+		test.Point(seq.Last, seq.Now, sum.NewMonotonicInt64(3), delta, setB.ToSlice()...),
+	)
+}
+
+// TestTemporalityConversionDeltaLimit tests that the cardinality limit
+// bounds the prior values retained for attribute sets that stop
+// reporting.
+func TestTemporalityConversionDeltaLimit(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithDeltaTemporalityConversion(true),
+			view.WithCardinalityLimit(2),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "async", sdkinstrument.AsyncUpDownCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	seq := testSequence
+	for i := 0; i < 10; i++ {
+		acc := inst.NewAccumulator(attribute.NewSet(attribute.Int("i", i)))
+		acc.(Updater[int64]).Update(1)
+		acc.SnapshotAndProcess(true)
+
+		_ = testCollectSequence(t, vc, seq)
+		require.LessOrEqual(t, inst.(data.Collector).Size(), 2)
+	}
+}
+
 // TestDeltaTemporalityAsyncCounter ensures that the asynchronous counter
 // is not reported when the value is unchanged and also when the instrument
 // is not used.  (This is different than async Gauge, since HasChange()
@@ -954,19 +1463,24 @@ func TestDeltaTemporalityAsyncCounter(t *testing.T) {
 
 	observe(10)
 	expectValues(10, seq)
+	seen := seq.Now
 	tick()
 	require.Equal(t, 1, instF.(data.Collector).Size())
 
+	// The prior value is retained while the series is not
+	// observed.
 	expectNone(seq)
 	tick()
-	require.Equal(t, 0, instF.(data.Collector).Size())
+	require.Equal(t, 1, instF.(data.Collector).Size())
 
 	expectNone(seq)
 	tick()
-	require.Equal(t, 0, instF.(data.Collector).Size())
+	require.Equal(t, 1, instF.(data.Collector).Size())
 
+	// The delta starts at the prior observation.
 	observe(11)
-	expectValues(11, seq)
+	seq.Last = seen
+	expectValues(1, seq)
 	tick()
 	require.Equal(t, 1, instF.(data.Collector).Size())
 
@@ -982,20 +1496,9 @@ func TestDeltaTemporalityAsyncCounter(t *testing.T) {
 	// a value.
 	require.Equal(t, 1, instF.(data.Collector).Size())
 
-	expectNone(seq)
-	tick()
-	// TODO: This is ill-specified and probably not right, but
-	// putting this test here to document it.  Async-delta case
-	// after multiple periods of no observation should probably
-	// keep track of the last value, otherwise it will reappear as
-	// new (and impact any rate calculations on the data).  Same
-	// for the two cases above.
-	require.Equal(t, 0, instF.(data.Collector).Size())
-
 	observe(11)
-	expectValues(11, seq)
+	expectNone(seq)
 	tick()
-
 	require.Equal(t, 1, instF.(data.Collector).Size())
 }
 
@@ -1108,6 +1611,13 @@ func TestDeltaTemporalitySyncGauge(t *testing.T) {
 	instI, err := testCompile(vc, "gaugeI", sdkinstrument.SyncUpDownCounter, number.Int64Kind, asGauge)
 	require.NoError(t, err)
 
+	outputs := vc.Outputs()
+	require.Equal(t, 2, len(outputs))
+	for _, out := range outputs {
+		require.Equal(t, aggregation.GaugeKind, out.AggregationKind())
+		require.Equal(t, aggregation.DeltaTemporality, out.Temporality())
+	}
+
 	set := attribute.NewSet()
 
 	var accI Accumulator
@@ -1500,262 +2010,1707 @@ func TestDeltaTemporalityMinMaxSumCount(t *testing.T) {
 	)
 }
 
-func TestViewHints(t *testing.T) {
-	views := view.New("test")
+func TestHistogramMaxScale(t *testing.T) {
+	acfg := aggregator.Config{
+		HistogramMaxScale: aggregator.NewMaxScale(1),
+	}
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentKind(sdkinstrument.SyncHistogram),
+			view.WithAggregation(aggregation.HistogramKind),
+			view.WithAggregatorConfig(acfg),
+		),
+	)
+
 	vc := New(testLib, views)
-	otelErrs := test.OTelErrors()
 
-	histo, err := testCompile(
-		vc,
-		"histo",
-		sdkinstrument.SyncCounter, // counter->small histogram
-		number.Float64Kind,
-		instrument.WithDescription(`{
-  "aggregation": "histogram",
-  "config": {
-    "histogram": {
-      "max_size": 3
-    }
-  }
-}`))
+	inst, err := testCompile(vc, "coarse", sdkinstrument.SyncHistogram, number.Float64Kind)
 	require.NoError(t, err)
 
-	mmsc, err := testCompile(
-		vc,
-		"mmsc",
-		sdkinstrument.SyncHistogram, // histogram->minmaxsumcount
-		number.Float64Kind,
-		instrument.WithDescription(`{
-  "description": "heyyy",
-  "aggregation": "minmaxsumcount"
-}`))
-	require.NoError(t, err)
+	inputs := []float64{1, 2, 3, 4, 5, 6, 7, 8}
 
-	gg, err := testCompile(
-		vc,
-		"gauge",
-		sdkinstrument.SyncUpDownCounter, // updowncounter->gauge
-		number.Float64Kind,
-		instrument.WithDescription(`{
-  "description": "check it",
-  "aggregation": "gauge"
-}`))
-	require.NoError(t, err)
+	var expect histogram.Float64
+	histogram.Float64Methods{}.Init(&expect, acfg)
 
-	set := attribute.NewSet(attribute.String("test", "attr"))
-	seq := testSequence
-	inputs := []float64{1, 2, 3, 4, 5, 6, 7, 8}
-	sum := 0.0
-	numInputs := len(inputs)
+	acc := inst.NewAccumulator(attribute.NewSet())
 	for _, inp := range inputs {
-		sum += inp
+		acc.(Updater[float64]).Update(inp)
+		histogram.Float64Methods{}.Update(&expect, inp)
 	}
+	acc.SnapshotAndProcess(false)
 
-	for _, acc := range []Accumulator{
-		histo.NewAccumulator(set),
-		mmsc.NewAccumulator(set),
-		gg.NewAccumulator(set),
-	} {
-		for _, inp := range inputs {
-			acc.(Updater[float64]).Update(inp)
-		}
-		acc.SnapshotAndProcess(false)
+	require.Equal(t, int32(1), expect.Scale())
+
+	output := testCollect(t, vc)
+	require.Equal(t, 1, len(output))
+	require.Equal(t, 1, len(output[0].Points))
+
+	// The internal structure differs after merging, so compare
+	// the aggregation's output.
+	hist := output[0].Points[0].Aggregation.(aggregation.Histogram)
+	require.Equal(t, expect.Scale(), hist.Scale())
+	require.Equal(t, expect.Count(), hist.Count())
+	require.Equal(t, expect.Sum(), hist.Sum())
+	require.Equal(t, expect.Positive().Offset(), hist.Positive().Offset())
+	require.Equal(t, expect.Positive().Len(), hist.Positive().Len())
+	for i := uint32(0); i < hist.Positive().Len(); i++ {
+		require.Equal(t, expect.Positive().At(i), hist.Positive().At(i))
 	}
+}
 
-	test.RequireEqualMetrics(t, testCollectSequence(t, vc, seq),
-		test.Instrument(
-			test.Descriptor("histo", sdkinstrument.SyncCounter, number.Float64Kind),
-			test.Point(seq.Start, seq.Now, histogram.NewFloat64(histogram.NewConfig(histogram.WithMaxSize(3)), inputs...), cumulative, set.ToSlice()...),
-		),
-		test.Instrument(
-			test.Descriptor("mmsc", sdkinstrument.SyncHistogram, number.Float64Kind, instrument.WithDescription("heyyy")),
-			test.Point(seq.Start, seq.Now, minmaxsumcount.NewFloat64(inputs...), cumulative, set.ToSlice()...),
-		),
-		test.Instrument(
-			test.Descriptor("gauge", sdkinstrument.SyncUpDownCounter, number.Float64Kind, instrument.WithDescription("check it")),
-			test.Point(seq.Start, seq.Now, gauge.NewFloat64(inputs[numInputs-1]), cumulative, set.ToSlice()...),
+func TestCardinalityLimit(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("limited"),
+			view.WithCardinalityLimit(2),
 		),
 	)
 
-	require.Nil(t, *otelErrs)
-}
-
-func TestViewHintErrors(t *testing.T) {
-	views := view.New("test")
 	vc := New(testLib, views)
 	otelErrs := test.OTelErrors()
 
-	_, err := testCompile(
-		vc,
-		"extra_comma",
-		sdkinstrument.SyncCounter,
-		number.Float64Kind,
-		instrument.WithDescription(`{
-  "aggregation": "histogram",
-}`))
-	require.NoError(t, err)
-
-	_, err = testCompile(
-		vc,
-		"accidental_json_parse",
-		sdkinstrument.SyncHistogram,
-		number.Float64Kind,
-		instrument.WithDescription("accidental { parse"))
+	inst, err := testCompile(vc, "limited", sdkinstrument.SyncCounter, number.Int64Kind)
 	require.NoError(t, err)
 
-	_, err = testCompile(
-		vc,
-		"invalid_aggregation",
-		sdkinstrument.SyncUpDownCounter,
-		number.Float64Kind,
-		instrument.WithDescription(`{
-  "aggregation": "cardinality"
-}`))
-	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		acc := inst.NewAccumulator(attribute.NewSet(attribute.Int("i", i)))
+		acc.(Updater[int64]).Update(int64(i + 1))
+		acc.SnapshotAndProcess(true)
+	}
 
-	_, err = testCompile(
-		vc,
-		"bad_max_size",
-		sdkinstrument.SyncCounter,
-		number.Float64Kind,
-		instrument.WithDescription(`{
-  "aggregation": "histogram",
-  "config": {
-    "histogram": {
-      "max_size": -3
-    }
-  }
-}`))
-	require.NoError(t, err)
+	// Sets 0 and 1 are kept, the rest (3+4+5) overflow.
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("limited", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative, attribute.Int("i", 0)),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(2), cumulative, attribute.Int("i", 1)),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(12), cumulative, attribute.Bool("otel.metric.overflow", true)),
+		),
+	)
 
-	require.Equal(t, 4, len(*otelErrs))
-	require.Contains(t, (*otelErrs)[0].Error(), "invalid character")
-	require.Contains(t, (*otelErrs)[1].Error(), "looking for beginning")
-	require.Contains(t, (*otelErrs)[2].Error(), "invalid aggregation")
-	require.Contains(t, (*otelErrs)[3].Error(), "invalid aggregator config")
+	require.Equal(t, 3, inst.(data.Collector).Size())
+	require.Equal(t, 1, len(*otelErrs))
+	require.Contains(t, (*otelErrs)[0].Error(), "cardinality limit")
 }
 
-func TestViewHintNoOverrideEmpty(t *testing.T) {
-	views := view.New("test",
-		view.WithDefaultAggregationConfigSelector(
-			func(_ sdkinstrument.Kind) (int64Config, float64Config aggregator.Config) {
-				cfg := aggregator.Config{
-					Histogram: histogram.NewConfig(histogram.WithMaxSize(127)),
-				}
-				return cfg, cfg
-			},
+// TestOverflowAttribute tests that a configured overflow attribute
+// marks the single overflow point.
+func TestOverflowAttribute(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("limited"),
+			view.WithCardinalityLimit(1),
+			view.WithOverflowAttribute(attribute.String("overflow", "yes")),
 		),
 	)
+
 	vc := New(testLib, views)
-	otelErrs := test.OTelErrors()
 
-	inst, err := testCompile(
-		vc,
-		"histo",
-		sdkinstrument.SyncCounter,
-		// sdkinstrument.SyncHistogram,
-		number.Float64Kind,
-		instrument.WithDescription(`{
-  "aggregation": "histogram"
-}`),
-	)
-	// note empty config
+	inst, err := testCompile(vc, "limited", sdkinstrument.SyncCounter, number.Int64Kind)
 	require.NoError(t, err)
-	require.Nil(t, *otelErrs, "see %v", *otelErrs)
 
-	seq := testSequence
-	set := attribute.NewSet(attribute.String("test", "attr"))
-	acc := inst.NewAccumulator(set)
-	acc.(Updater[float64]).Update(1)
-	acc.SnapshotAndProcess(false)
+	for i := 0; i < 4; i++ {
+		acc := inst.NewAccumulator(attribute.NewSet(attribute.Int("i", i)))
+		acc.(Updater[int64]).Update(int64(i + 1))
+		acc.SnapshotAndProcess(true)
+	}
 
-	test.RequireEqualMetrics(t, testCollectSequence(t, vc, seq),
+	// Set 0 is kept, the rest (2+3+4) overflow into one point.
+	test.RequireEqualMetrics(t, testCollect(t, vc),
 		test.Instrument(
-			test.Descriptor(
-				"histo",
-				sdkinstrument.SyncCounter,
-				// sdkinstrument.SyncHistogram,
-				number.Float64Kind,
-			),
-			test.Point(
-				seq.Start,
-				seq.Now,
-				histogram.NewFloat64(histogram.NewConfig(histogram.WithMaxSize(127)), 1),
-				cumulative,
-				set.ToSlice()...),
+			test.Descriptor("limited", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative, attribute.Int("i", 0)),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(9), cumulative, attribute.String("overflow", "yes")),
 		),
 	)
 }
 
-// TestEmptyKeyFilter ensures no empty keys are used (w/o view config).
-func TestEmptyKeyFilter(t *testing.T) {
-	views := view.New("test")
+// TestMemorySize tests that the memory estimate grows with
+// attributes and histogram buckets.
+func TestMemorySize(t *testing.T) {
+	vc := New(testLib, view.New("test"))
 
-	vc := New(testLib, views)
+	ctr, err := testCompile(vc, "ctr", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+	hist, err := testCompile(vc, "hist", sdkinstrument.SyncHistogram, number.Float64Kind)
+	require.NoError(t, err)
 
-	// Note: this has to be the first test that uses an empty key,
-	// so the doevery logic triggers.
-	errs := new([]error)
-	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
-		*errs = append(*errs, err)
-	}))
+	require.Equal(t, 0, vc.MemorySize())
 
-	inst, err := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Float64Kind)
-	require.NoError(t, err)
+	update := func(inst Instrument, value float64, attrs ...attribute.KeyValue) {
+		acc := inst.NewAccumulator(attribute.NewSet(attrs...))
+		switch u := acc.(type) {
+		case Updater[int64]:
+			u.Update(int64(value))
+		case Updater[float64]:
+			u.Update(value)
+		}
+		acc.SnapshotAndProcess(true)
+	}
 
-	acc1 := inst.NewAccumulator(attribute.NewSet(attribute.String("", "1value"), attribute.String("K", "V")))
-	acc1.(Updater[float64]).Update(1)
-	acc1.SnapshotAndProcess(false)
+	update(ctr, 1)
+	ctrSize := ctr.(data.Collector).MemorySize()
+	require.Less(t, 0, ctrSize)
 
-	acc2 := inst.NewAccumulator(attribute.NewSet(attribute.String("", "2value"), attribute.String("K", "V")))
-	acc2.(Updater[float64]).Update(1)
-	acc2.SnapshotAndProcess(false)
+	// A second entry with a long attribute value is larger.
+	update(ctr, 1, attribute.String("k", strings.Repeat("v", 1000)))
+	require.Less(t, 2*ctrSize+1000, ctr.(data.Collector).MemorySize())
 
-	output := testCollect(t, vc)
+	update(hist, 1)
+	histSize := hist.(data.Collector).MemorySize()
 
-	test.RequireEqualMetrics(t, output,
-		test.Instrument(
-			test.Descriptor("foo", sdkinstrument.SyncCounter, number.Float64Kind),
-			test.Point(
-				startTime, endTime, sum.NewMonotonicFloat64(2), cumulative,
-				attribute.String("K", "V"),
-			),
-		),
-	)
+	// A wider range of values uses more buckets.
+	update(hist, 1000)
+	require.Less(t, histSize, hist.(data.Collector).MemorySize())
 
-	require.Equal(t, 1, len(*errs))
-	require.Equal(t, "use of empty attribute key, e.g., metric name \"foo\" with value \"1value\"", (*errs)[0].Error())
+	require.Equal(t,
+		ctr.(data.Collector).MemorySize()+hist.(data.Collector).MemorySize(),
+		vc.MemorySize(),
+	)
 }
 
-// TestEmptyKeyFilterAndView ensures no empty keys are used (with a view config).
-func TestEmptyKeyFilterAndView(t *testing.T) {
-	views := view.New("test",
+// TestAttributeRename tests that renamed attribute sets merge into
+// one point, in combination with a keys filter.
+func TestAttributeRename(t *testing.T) {
+	const (
+		before = attribute.Key("http_status_code")
+		after  = attribute.Key("http.status_code")
+	)
+	views := view.New(
+		"test",
 		view.WithClause(
-			view.WithKeys([]attribute.Key{"a"}),
+			view.WithAttributeRename(map[attribute.Key]attribute.Key{
+				before: after,
+			}),
+			view.WithKeys([]attribute.Key{after}),
 		),
 	)
 
 	vc := New(testLib, views)
 
-	inst, err := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Float64Kind)
+	instC, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
 	require.NoError(t, err)
 
-	acc1 := inst.NewAccumulator(attribute.NewSet(attribute.String("a", "1"), attribute.String("", "empty"), attribute.String("b", "ignored")))
-	acc1.(Updater[float64]).Update(1)
-	acc1.SnapshotAndProcess(false)
+	instG, err := testCompile(vc, "gauge", sdkinstrument.AsyncGauge, number.Int64Kind)
+	require.NoError(t, err)
 
-	acc2 := inst.NewAccumulator(attribute.NewSet(attribute.String("", "different"), attribute.String("a", "1")))
-	acc2.(Updater[float64]).Update(1)
-	acc2.SnapshotAndProcess(false)
+	for i, set := range []attribute.Set{
+		attribute.NewSet(before.Int(200), attribute.String("other", "x")),
+		attribute.NewSet(after.Int(200)),
+		attribute.NewSet(before.Int(404)),
+	} {
+		accC := instC.NewAccumulator(set)
+		accC.(Updater[int64]).Update(int64(i + 1))
+		accC.SnapshotAndProcess(false)
 
-	output := testCollect(t, vc)
+		accG := instG.NewAccumulator(set)
+		accG.(Updater[int64]).Update(int64(i + 1))
+		accG.SnapshotAndProcess(true)
+	}
 
-	test.RequireEqualMetrics(t, output,
+	test.RequireEqualMetrics(t,
+		testCollect(t, vc),
 		test.Instrument(
-			test.Descriptor("foo", sdkinstrument.SyncCounter, number.Float64Kind),
-			test.Point(
-				startTime, endTime, sum.NewMonotonicFloat64(2), cumulative, attribute.String("a", "1"),
-			),
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), cumulative, after.Int(200)),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), cumulative, after.Int(404)),
+		),
+		test.Instrument(
+			test.Descriptor("gauge", sdkinstrument.AsyncGauge, number.Int64Kind),
+			test.Point(startTime, endTime, gauge.NewInt64(2), cumulative, after.Int(200)),
+			test.Point(startTime, endTime, gauge.NewInt64(3), cumulative, after.Int(404)),
+		),
+	)
+}
+
+// TestAttributeRenameCollision tests renaming two keys of one
+// attribute set to the same key.
+func TestAttributeRenameCollision(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithAttributeRename(map[attribute.Key]attribute.Key{
+				"a": "c",
+				"b": "c",
+			}),
 		),
 	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	acc := inst.NewAccumulator(attribute.NewSet(attribute.String("b", "B"), attribute.String("a", "A")))
+	acc.(Updater[int64]).Update(1)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t,
+		testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative, attribute.String("c", "B")),
+		),
+	)
+}
+
+// TestAttributeValueLengthLimit tests that string values that are
+// equal after truncation are aggregated into one point, for
+// synchronous and asynchronous instruments.
+func TestAttributeValueLengthLimit(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithAttributeValueLengthLimit(3),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	instC, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	instG, err := testCompile(vc, "gauge", sdkinstrument.AsyncGauge, number.Int64Kind)
+	require.NoError(t, err)
+
+	for i, set := range []attribute.Set{
+		attribute.NewSet(attribute.String("path", "/api/v1"), attribute.Int("n", 12345)),
+		attribute.NewSet(attribute.String("path", "/api/v2"), attribute.Int("n", 12345)),
+		attribute.NewSet(attribute.String("path", "/ap"), attribute.Int("n", 12345)),
+		attribute.NewSet(attribute.String("path", "ñññññ"), attribute.Int("n", 12345)),
+	} {
+		accC := instC.NewAccumulator(set)
+		accC.(Updater[int64]).Update(int64(i + 1))
+		accC.SnapshotAndProcess(false)
+
+		accG := instG.NewAccumulator(set)
+		accG.(Updater[int64]).Update(int64(i + 1))
+		accG.SnapshotAndProcess(true)
+	}
+
+	test.RequireEqualMetrics(t,
+		testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(6), cumulative, attribute.String("path", "/ap"), attribute.Int("n", 12345)),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(4), cumulative, attribute.String("path", "ñññ"), attribute.Int("n", 12345)),
+		),
+		test.Instrument(
+			test.Descriptor("gauge", sdkinstrument.AsyncGauge, number.Int64Kind),
+			test.Point(startTime, endTime, gauge.NewInt64(3), cumulative, attribute.String("path", "/ap"), attribute.Int("n", 12345)),
+			test.Point(startTime, endTime, gauge.NewInt64(4), cumulative, attribute.String("path", "ñññ"), attribute.Int("n", 12345)),
+		),
+	)
+}
+
+// TestValueNormalization tests that string values of the named keys
+// that are equal after normalization are aggregated into one point,
+// and that other keys and non-string values are not modified.
+func TestValueNormalization(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithValueNormalization([]attribute.Key{"method", "code"}, strings.ToLower),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	for i, set := range []attribute.Set{
+		attribute.NewSet(attribute.String("method", "GET"), attribute.String("host", "A"), attribute.Int("code", 200)),
+		attribute.NewSet(attribute.String("method", "get"), attribute.String("host", "A"), attribute.Int("code", 200)),
+		attribute.NewSet(attribute.String("method", "Get"), attribute.String("host", "a"), attribute.Int("code", 200)),
+	} {
+		acc := inst.NewAccumulator(set)
+		acc.(Updater[int64]).Update(int64(i + 1))
+		acc.SnapshotAndProcess(false)
+	}
+
+	test.RequireEqualMetrics(t,
+		testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), cumulative, attribute.String("method", "get"), attribute.String("host", "A"), attribute.Int("code", 200)),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), cumulative, attribute.String("method", "get"), attribute.String("host", "a"), attribute.Int("code", 200)),
+		),
+	)
+}
+
+// TestSnapshotThenProcess tests that Process merges the data as of
+// Snapshot, once, and that later updates wait for the next snapshot.
+func TestSnapshotThenProcess(t *testing.T) {
+	vc := New(testLib, view.New("test"))
+
+	instC, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	instG, err := testCompile(vc, "gauge", sdkinstrument.AsyncGauge, number.Int64Kind)
+	require.NoError(t, err)
+
+	accC := instC.NewAccumulator(attribute.NewSet())
+	accG := instG.NewAccumulator(attribute.NewSet())
+
+	accC.(Updater[int64]).Update(1)
+	accG.(Updater[int64]).Update(1)
+	accC.Snapshot()
+	accG.Snapshot()
+	accC.(Updater[int64]).Update(2)
+	accG.(Updater[int64]).Update(2)
+	accC.Process(false)
+	accG.Process(false)
+
+	// A second Process does nothing.
+	accC.Process(false)
+	accG.Process(false)
+
+	test.RequireEqualMetrics(t,
+		testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("gauge", sdkinstrument.AsyncGauge, number.Int64Kind),
+			test.Point(startTime, endTime, gauge.NewInt64(1), cumulative),
+		),
+	)
+
+	// Asynchronous accumulators are used for one collection.
+	accC.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t,
+		testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("gauge", sdkinstrument.AsyncGauge, number.Int64Kind),
+		),
+	)
+}
+
+// TestPointProcessor tests that a point processor may add an
+// attribute, that points with equal attributes are merged, and that
+// the processor cannot replace the aggregation.
+func TestPointProcessor(t *testing.T) {
+	region := func(pt *data.Point) {
+		host, _ := pt.Attributes.Value("host")
+		pt.Attributes = attribute.NewSet(attribute.String("region", host.AsString()[:1]))
+		pt.Aggregation = sum.NewMonotonicInt64(1000)
+	}
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("counter"),
+			view.WithPointProcessor(region),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	for i, host := range []string{"a1", "b1", "a2", "a3"} {
+		acc := inst.NewAccumulator(attribute.NewSet(attribute.String("host", host)))
+		acc.(Updater[int64]).Update(int64(i + 1))
+		acc.SnapshotAndProcess(false)
+	}
+
+	// Repeat to test re-use of the merged points' storage.
+	var output data.Scope
+	for i := 0; i < 2; i++ {
+		test.RequireEqualMetrics(t,
+			testCollectSequenceReuse(t, vc, testSequence, &output),
+			test.Instrument(
+				test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(8), cumulative, attribute.String("region", "a")),
+				test.Point(startTime, endTime, sum.NewMonotonicInt64(2), cumulative, attribute.String("region", "b")),
+			),
+		)
+	}
+}
+
+// TestCollectInto tests the streaming collection API.
+// TestValueTransform tests that a value transform applies to one
+// view, before bucketing, in combination with a keys filter.
+func TestValueTransform(t *testing.T) {
+	bounds := []float64{0.5, 1}
+	nanosToSeconds := func(x float64) float64 {
+		return x / 1e9
+	}
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("latency"),
+			view.WithName("latency_ns"),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("latency"),
+			view.WithName("latency_s"),
+			view.WithAggregatorConfig(aggregator.Config{
+				HistogramBoundaries: histogram.WithExplicitBoundaries(bounds),
+			}),
+			view.WithKeys([]attribute.Key{"method"}),
+			view.WithValueTransform(nanosToSeconds),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("latency"),
+			view.WithAggregation(aggregation.DropKind),
+			view.WithValueTransform(nanosToSeconds),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "latency", sdkinstrument.SyncHistogram, number.Float64Kind)
+	require.NoError(t, err)
+
+	acc := inst.NewAccumulator(attribute.NewSet(attribute.String("method", "get"), attribute.Int("other", 1)))
+	acc.(Updater[float64]).Update(2e8)
+	acc.(Updater[float64]).Update(7e8)
+	acc.(Updater[float64]).Update(3e9)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("latency_ns", sdkinstrument.SyncHistogram, number.Float64Kind),
+			test.Point(
+				startTime, endTime, histogram.NewFloat64(defaultAggregatorConfig.Histogram, 2e8, 7e8, 3e9), cumulative,
+				attribute.String("method", "get"), attribute.Int("other", 1),
+			),
+		),
+		test.Instrument(
+			test.Descriptor("latency_s", sdkinstrument.SyncHistogram, number.Float64Kind),
+			test.Point(
+				startTime, endTime, histogram.NewExplicitFloat64(bounds, 0.2, 0.7, 3), cumulative,
+				attribute.String("method", "get"),
+			),
+		),
+	)
+}
+
+// TestValueTransformInt64 tests that transformed int64 measurements
+// output float64 values, and that transformed values failing the
+// range test are dropped.
+func TestValueTransformInt64(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("latency"),
+			view.WithUnitConversion("ns", "s", 1e-9),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("count"),
+			view.WithValueTransform(func(x float64) float64 {
+				if x == 2 {
+					return math.NaN()
+				}
+				return 1.5 - x
+			}),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	latency, err := testCompile(vc, "latency", sdkinstrument.SyncCounter, number.Int64Kind, instrument.WithUnit("ns"))
+	require.NoError(t, err)
+
+	count, err := testCompile(vc, "count", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	acc := latency.NewAccumulator(attribute.NewSet())
+	acc.(Updater[int64]).Update(250000000)
+	acc.(Updater[int64]).Update(1500000000)
+	acc.SnapshotAndProcess(false)
+
+	// 1 becomes 0.5, 2 becomes NaN and 3 becomes -1.5.
+	acc = count.NewAccumulator(attribute.NewSet())
+	acc.(Updater[int64]).Update(1)
+	acc.(Updater[int64]).Update(2)
+	acc.(Updater[int64]).Update(3)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("latency", sdkinstrument.SyncCounter, number.Float64Kind, instrument.WithUnit("s")),
+			test.Point(startTime, endTime, sum.NewMonotonicFloat64(1.75), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("count", sdkinstrument.SyncCounter, number.Float64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicFloat64(0.5), cumulative),
+		),
+	)
+}
+
+// TestUnitConversion tests that a unit conversion scales sums and
+// histograms and changes the unit, and that instruments with another
+// unit are not converted.
+func TestUnitConversion(t *testing.T) {
+	bounds := []float64{1, 4}
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithAggregatorConfig(aggregator.Config{
+				HistogramBoundaries: histogram.WithExplicitBoundaries(bounds),
+			}),
+			view.WithUnitConversion("By", "KiBy", 1.0/1024),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	total, err := testCompile(vc, "total", sdkinstrument.SyncCounter, number.Float64Kind, instrument.WithUnit("By"))
+	require.NoError(t, err)
+
+	size, err := testCompile(vc, "size", sdkinstrument.SyncHistogram, number.Float64Kind, instrument.WithUnit("By"))
+	require.NoError(t, err)
+
+	other, err := testCompile(vc, "other", sdkinstrument.SyncCounter, number.Float64Kind, instrument.WithUnit("ms"))
+	require.Error(t, err)
+	var unitErr UnitConversionError
+	require.True(t, errors.As(err, &unitErr))
+	require.Equal(t, "ms", string(unitErr.Descriptor.Unit))
+	require.Contains(t, err.Error(), `"other" instrument unit "ms" does not match unit conversion from "By" to "KiBy"`)
+
+	for _, value := range []float64{512, 2048, 8192} {
+		for _, inst := range []Instrument{total, size, other} {
+			acc := inst.NewAccumulator(attribute.NewSet())
+			acc.(Updater[float64]).Update(value)
+			acc.SnapshotAndProcess(true)
+		}
+	}
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("total", sdkinstrument.SyncCounter, number.Float64Kind, instrument.WithUnit("KiBy")),
+			test.Point(startTime, endTime, sum.NewMonotonicFloat64(10.5), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("size", sdkinstrument.SyncHistogram, number.Float64Kind, instrument.WithUnit("KiBy")),
+			test.Point(startTime, endTime, histogram.NewExplicitFloat64(bounds, 0.5, 2, 8), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("other", sdkinstrument.SyncCounter, number.Float64Kind, instrument.WithUnit("ms")),
+			test.Point(startTime, endTime, sum.NewMonotonicFloat64(10752), cumulative),
+		),
+	)
+}
+
+// TestResetStartTime tests that cumulative points start at the time
+// of the last Reset.
+func TestResetStartTime(t *testing.T) {
+	vc := New(testLib, view.New("test"))
+
+	inst, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	acc := inst.NewAccumulator(attribute.NewSet())
+	acc.(Updater[int64]).Update(1)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative),
+		),
+	)
+
+	before := time.Now()
+	inst.Reset()
+	after := time.Now()
+
+	acc.(Updater[int64]).Update(2)
+	acc.SnapshotAndProcess(false)
+
+	seq := data.Sequence{
+		Start: startTime,
+		Last:  endTime,
+		Now:   time.Now(),
+	}
+	output := testCollectSequence(t, vc, seq)
+	require.Equal(t, 1, len(output))
+	require.Equal(t, 1, len(output[0].Points))
+
+	point := output[0].Points[0]
+	require.Equal(t, sum.NewMonotonicInt64(2), point.Aggregation)
+	require.False(t, point.Start.Before(before))
+	require.False(t, point.Start.After(after))
+	require.Equal(t, seq.Now, point.End)
+}
+
+// fixedClock is an aggregator.Clock that always returns the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// TestResetStartTimeClock tests that Reset takes the start time of
+// cumulative points from the configured clock.
+func TestResetStartTimeClock(t *testing.T) {
+	resetTime := middleTime
+
+	vc := New(testLib, view.New("test"))
+	vc.SetClock(fixedClock(resetTime))
+
+	for _, ik := range []sdkinstrument.Kind{sdkinstrument.SyncCounter, sdkinstrument.AsyncCounter} {
+		inst, err := testCompile(vc, ik.String(), ik, number.Int64Kind)
+		require.NoError(t, err)
+
+		inst.Reset()
+
+		acc := inst.NewAccumulator(attribute.NewSet())
+		acc.(Updater[int64]).Update(1)
+		acc.SnapshotAndProcess(false)
+	}
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor(sdkinstrument.SyncCounter.String(), sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(resetTime, endTime, sum.NewMonotonicInt64(1), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor(sdkinstrument.AsyncCounter.String(), sdkinstrument.AsyncCounter, number.Int64Kind),
+			test.Point(resetTime, endTime, sum.NewMonotonicInt64(1), cumulative),
+		),
+	)
+}
+
+// TestStartTimeAlignment tests that cumulative points start at the
+// configured epoch, except when it is after the collection, and that
+// delta points are not affected.
+func TestStartTimeAlignment(t *testing.T) {
+	epoch := startTime.Add(-time.Hour)
+	future := endTime.Add(time.Hour)
+
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("aligned"),
+			view.WithStartTimeAlignment(epoch),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("future"),
+			view.WithStartTimeAlignment(future),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("delta"),
+			view.WithStartTimeAlignment(epoch),
+			view.WithDeltaTemporalityConversion(true),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	for _, name := range []string{"aligned", "future", "delta"} {
+		inst, err := testCompile(vc, name, sdkinstrument.SyncCounter, number.Int64Kind)
+		require.NoError(t, err)
+
+		acc := inst.NewAccumulator(attribute.NewSet())
+		acc.(Updater[int64]).Update(1)
+		acc.SnapshotAndProcess(false)
+	}
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("aligned", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(epoch, endTime, sum.NewMonotonicInt64(1), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("future", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("delta", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(middleTime, endTime, sum.NewMonotonicInt64(1), delta),
+		),
+	)
+}
+
+// TestDescriptorProcessor tests that view clauses match the
+// rewritten instrument name and that kind changes are ignored.
+func TestDescriptorProcessor(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentName("platform.requests"),
+			view.WithAggregation(aggregation.DropKind),
+		),
+	)
+
+	vc := New(testLib, views)
+	vc.SetDescriptorProcessor(func(desc sdkinstrument.Descriptor) sdkinstrument.Descriptor {
+		desc.Name = "platform." + strings.ToLower(desc.Name)
+		desc.Kind = sdkinstrument.SyncHistogram
+		return desc
+	})
+
+	dropped, err := testCompile(vc, "Requests", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+	require.Nil(t, dropped)
+
+	inst, err := testCompile(vc, "Bytes", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	acc := inst.NewAccumulator(attribute.NewSet())
+	acc.(Updater[int64]).Update(1)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("platform.bytes", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative),
+		),
+	)
+}
+
+// TestClone tests that Clone migrates state for unchanged outputs.
+func TestClone(t *testing.T) {
+	rename := func(name string) view.Option {
+		return view.WithClause(
+			view.MatchInstrumentName("c"),
+			view.WithName(name),
+		)
+	}
+	vc := New(testLib, view.New("test", rename("c_old")))
+
+	descA := test.Descriptor("a", sdkinstrument.SyncCounter, number.Int64Kind)
+	descH := test.Descriptor("h", sdkinstrument.SyncHistogram, number.Int64Kind)
+	descC := test.Descriptor("c", sdkinstrument.SyncCounter, number.Int64Kind)
+
+	var accs []Accumulator
+	for _, desc := range []sdkinstrument.Descriptor{descA, descH, descC} {
+		inst, conflicts := vc.Compile(desc)
+		require.NoError(t, conflicts.AsError())
+
+		acc := inst.NewAccumulator(attribute.NewSet())
+		acc.(Updater[int64]).Update(1)
+		acc.(Updater[int64]).Update(2)
+		acc.SnapshotAndProcess(false)
+		accs = append(accs, acc)
+	}
+
+	clone, err := vc.Clone(view.New("test", rename("c_new")))
+	require.NoError(t, err)
+
+	// An accumulator of the original compiler updates the clone.
+	accs[0].(Updater[int64]).Update(10)
+	accs[0].SnapshotAndProcess(true)
+
+	for _, desc := range []sdkinstrument.Descriptor{descA, descC} {
+		inst, ok := clone.Lookup(desc)
+		require.True(t, ok)
+
+		acc := inst.NewAccumulator(attribute.NewSet())
+		acc.(Updater[int64]).Update(100)
+		acc.SnapshotAndProcess(true)
+	}
+
+	_, ok := clone.Lookup(test.Descriptor("x", sdkinstrument.SyncCounter, number.Int64Kind))
+	require.False(t, ok)
+
+	test.RequireEqualMetrics(t, testCollect(t, clone),
+		test.Instrument(
+			descA,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(113), cumulative),
+		),
+		test.Instrument(
+			descH,
+			test.Point(startTime, endTime, histogram.NewInt64(defaultAggregatorConfig.Histogram, 1, 2), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("c_new", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(100), cumulative),
+		),
+	)
+}
+
+func TestAddClause(t *testing.T) {
+	views, err := view.Validate(view.New("test"))
+	require.NoError(t, err)
+	vc := New(testLib, views)
+
+	descA := test.Descriptor("a", sdkinstrument.SyncCounter, number.Int64Kind)
+	descB := test.Descriptor("b", sdkinstrument.SyncCounter, number.Int64Kind)
+	attrs := attribute.NewSet(attribute.String("k", "v"))
+
+	insts := map[sdkinstrument.Descriptor]Instrument{}
+	for _, desc := range []sdkinstrument.Descriptor{descA, descB} {
+		inst, conflicts := vc.Compile(desc)
+		require.NoError(t, conflicts.AsError())
+		insts[desc] = inst
+
+		acc := inst.NewAccumulator(attrs)
+		acc.(Updater[int64]).Update(1)
+		acc.SnapshotAndProcess(false)
+	}
+
+	// An unchanged output keeps its state.
+	descs, err := vc.AddClause(view.NewClause(
+		view.MatchInstrumentName("b"),
+		view.WithName("b"),
+	))
+	require.NoError(t, err)
+	require.Equal(t, []sdkinstrument.Descriptor{descB}, descs)
+
+	// The instrument that does not match is not modified.
+	inst, ok := vc.Lookup(descA)
+	require.True(t, ok)
+	require.Equal(t, insts[descA], inst)
+
+	inst, ok = vc.Lookup(descB)
+	require.True(t, ok)
+	acc := inst.NewAccumulator(attrs)
+	acc.(Updater[int64]).Update(10)
+	acc.SnapshotAndProcess(false)
+
+	// A changed output starts empty.
+	descs, err = vc.AddClause(view.NewClause(
+		view.MatchInstrumentName("a"),
+		view.WithName("a_keys"),
+		view.WithKeys([]attribute.Key{}),
+	))
+	require.NoError(t, err)
+	require.Equal(t, []sdkinstrument.Descriptor{descA}, descs)
+
+	inst, ok = vc.Lookup(descA)
+	require.True(t, ok)
+	acc = inst.NewAccumulator(attrs)
+	acc.(Updater[int64]).Update(5)
+	acc.SnapshotAndProcess(false)
+
+	// The clauses apply to subsequent calls to Compile.
+	inst, conflicts := vc.Compile(descA)
+	require.NoError(t, conflicts.AsError())
+	acc = inst.NewAccumulator(attrs)
+	acc.(Updater[int64]).Update(100)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			descB,
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(11), cumulative, attrs.ToSlice()...),
+		),
+		test.Instrument(
+			test.Descriptor("a_keys", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(105), cumulative),
+		),
+	)
+
+	// An invalid clause is corrected.
+	_, err = vc.AddClause(view.NewClause(
+		view.MatchInstrumentName("c"),
+		view.WithKeys([]attribute.Key{""}),
+	))
+	require.Error(t, err)
+}
+
+func TestCollectInto(t *testing.T) {
+	views := view.New("test")
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		acc := inst.NewAccumulator(attribute.NewSet(attribute.Int("i", i)))
+		acc.(Updater[int64]).Update(int64(i))
+		acc.SnapshotAndProcess(false)
+	}
+
+	coll := vc.Collectors()[0]
+	require.Equal(t, test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind), coll.Descriptor())
+
+	values := map[int64]int64{}
+	require.NoError(t, coll.CollectInto(testSequence, func(pt data.Point) error {
+		require.Equal(t, cumulative, pt.Temporality)
+		require.Equal(t, startTime, pt.Start)
+		require.Equal(t, endTime, pt.End)
+
+		key, _ := pt.Attributes.Value("i")
+		values[key.AsInt64()] = number.ToInt64(pt.Aggregation.(aggregation.Sum).Sum())
+		return nil
+	}))
+	require.Equal(t, map[int64]int64{1: 1, 2: 2, 3: 3}, values)
+
+	// The callback's error stops collection.
+	stop := fmt.Errorf("stop")
+	calls := 0
+	require.ErrorIs(t, coll.CollectInto(testSequence, func(pt data.Point) error {
+		calls++
+		return stop
+	}), stop)
+	require.Equal(t, 1, calls)
+
+	// The slice-based API is unchanged.
+	test.RequireEqualMetrics(t,
+		testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative, attribute.Int("i", 1)),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(2), cumulative, attribute.Int("i", 2)),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), cumulative, attribute.Int("i", 3)),
+		),
+	)
+}
+
+func TestCollectChanged(t *testing.T) {
+	views := view.New("test")
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "counter", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	setA := attribute.NewSet(attribute.String("a", "1"))
+	setB := attribute.NewSet(attribute.String("b", "1"))
+	accA := inst.NewAccumulator(setA)
+	accB := inst.NewAccumulator(setB)
+
+	coll := vc.Collectors()[0].(data.ChangedCollector)
+	changed := func() []data.Instrument {
+		var output []data.Instrument
+		coll.CollectChanged(testSequence, &output)
+		return output
+	}
+
+	accA.(Updater[int64]).Update(1)
+	accA.SnapshotAndProcess(false)
+	accB.(Updater[int64]).Update(2)
+	accB.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, changed(),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(1), cumulative, setA.ToSlice()...),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(2), cumulative, setB.ToSlice()...),
+		),
+	)
+
+	// Only the updated attribute set is output.
+	accA.(Updater[int64]).Update(1)
+	accA.SnapshotAndProcess(false)
+	accB.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, changed(),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(2), cumulative, setA.ToSlice()...),
+		),
+	)
+
+	// An update that is not processed before collection is
+	// output by the following collection.
+	accB.(Updater[int64]).Update(1)
+	test.RequireEqualMetrics(t, changed(),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+		),
+	)
+	accB.SnapshotAndProcess(false)
+
+	// Collect also clears the flags, and outputs every point.
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(2), cumulative, setA.ToSlice()...),
+			test.Point(startTime, endTime, sum.NewMonotonicInt64(3), cumulative, setB.ToSlice()...),
+		),
+	)
+	test.RequireEqualMetrics(t, changed(),
+		test.Instrument(
+			test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind),
+		),
+	)
+}
+
+func TestViewHints(t *testing.T) {
+	views := view.New("test")
+	vc := New(testLib, views)
+	otelErrs := test.OTelErrors()
+
+	histo, err := testCompile(
+		vc,
+		"histo",
+		sdkinstrument.SyncCounter, // counter->small histogram
+		number.Float64Kind,
+		instrument.WithDescription(`{
+  "aggregation": "histogram",
+  "config": {
+    "histogram": {
+      "max_size": 3
+    }
+  }
+}`))
+	require.NoError(t, err)
+
+	mmsc, err := testCompile(
+		vc,
+		"mmsc",
+		sdkinstrument.SyncHistogram, // histogram->minmaxsumcount
+		number.Float64Kind,
+		instrument.WithDescription(`{
+  "description": "heyyy",
+  "aggregation": "minmaxsumcount"
+}`))
+	require.NoError(t, err)
+
+	gg, err := testCompile(
+		vc,
+		"gauge",
+		sdkinstrument.SyncUpDownCounter, // updowncounter->gauge
+		number.Float64Kind,
+		instrument.WithDescription(`{
+  "description": "check it",
+  "aggregation": "gauge"
+}`))
+	require.NoError(t, err)
+
+	set := attribute.NewSet(attribute.String("test", "attr"))
+	seq := testSequence
+	inputs := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+	sum := 0.0
+	numInputs := len(inputs)
+	for _, inp := range inputs {
+		sum += inp
+	}
+
+	for _, acc := range []Accumulator{
+		histo.NewAccumulator(set),
+		mmsc.NewAccumulator(set),
+		gg.NewAccumulator(set),
+	} {
+		for _, inp := range inputs {
+			acc.(Updater[float64]).Update(inp)
+		}
+		acc.SnapshotAndProcess(false)
+	}
+
+	test.RequireEqualMetrics(t, testCollectSequence(t, vc, seq),
+		test.Instrument(
+			test.Descriptor("histo", sdkinstrument.SyncCounter, number.Float64Kind),
+			test.Point(seq.Start, seq.Now, histogram.NewFloat64(histogram.NewConfig(histogram.WithMaxSize(3)), inputs...), cumulative, set.ToSlice()...),
+		),
+		test.Instrument(
+			test.Descriptor("mmsc", sdkinstrument.SyncHistogram, number.Float64Kind, instrument.WithDescription("heyyy")),
+			test.Point(seq.Start, seq.Now, minmaxsumcount.NewFloat64(inputs...), cumulative, set.ToSlice()...),
+		),
+		test.Instrument(
+			test.Descriptor("gauge", sdkinstrument.SyncUpDownCounter, number.Float64Kind, instrument.WithDescription("check it")),
+			test.Point(seq.Start, seq.Now, gauge.NewFloat64(inputs[numInputs-1]), cumulative, set.ToSlice()...),
+		),
+	)
+
+	require.Nil(t, *otelErrs)
+}
+
+func TestViewHintErrors(t *testing.T) {
+	views := view.New("test")
+	vc := New(testLib, views)
+	otelErrs := test.OTelErrors()
+
+	_, err := testCompile(
+		vc,
+		"extra_comma",
+		sdkinstrument.SyncCounter,
+		number.Float64Kind,
+		instrument.WithDescription(`{
+  "aggregation": "histogram",
+}`))
+	require.NoError(t, err)
+
+	_, err = testCompile(
+		vc,
+		"accidental_json_parse",
+		sdkinstrument.SyncHistogram,
+		number.Float64Kind,
+		instrument.WithDescription("accidental { parse"))
+	require.NoError(t, err)
+
+	_, err = testCompile(
+		vc,
+		"invalid_aggregation",
+		sdkinstrument.SyncUpDownCounter,
+		number.Float64Kind,
+		instrument.WithDescription(`{
+  "aggregation": "cardinality"
+}`))
+	require.NoError(t, err)
+
+	_, err = testCompile(
+		vc,
+		"bad_max_size",
+		sdkinstrument.SyncCounter,
+		number.Float64Kind,
+		instrument.WithDescription(`{
+  "aggregation": "histogram",
+  "config": {
+    "histogram": {
+      "max_size": -3
+    }
+  }
+}`))
+	require.NoError(t, err)
+
+	require.Equal(t, 4, len(*otelErrs))
+	require.Contains(t, (*otelErrs)[0].Error(), "invalid character")
+	require.Contains(t, (*otelErrs)[1].Error(), "looking for beginning")
+	require.Contains(t, (*otelErrs)[2].Error(), "invalid aggregation")
+	require.Contains(t, (*otelErrs)[3].Error(), "invalid aggregator config")
+}
+
+// TestViewHintTemporality tests that a hint overrides the default
+// temporality.
+func TestViewHintTemporality(t *testing.T) {
+	views := view.New("test")
+	vc := New(testLib, views)
+	otelErrs := test.OTelErrors()
+
+	inst, err := testCompile(
+		vc,
+		"counter",
+		sdkinstrument.SyncCounter,
+		number.Int64Kind,
+		instrument.WithDescription(`{
+  "description": "delta please",
+  "temporality": "delta"
+}`))
+	require.NoError(t, err)
+	require.Nil(t, *otelErrs)
+
+	set := attribute.NewSet()
+	seq := testSequence
+
+	for _, x := range []int64{3, 5} {
+		acc := inst.NewAccumulator(set)
+		acc.(Updater[int64]).Update(x)
+		acc.SnapshotAndProcess(true)
+
+		test.RequireEqualMetrics(t, testCollectSequence(t, vc, seq),
+			test.Instrument(
+				test.Descriptor("counter", sdkinstrument.SyncCounter, number.Int64Kind, instrument.WithDescription("delta please")),
+				test.Point(seq.Last, seq.Now, sum.NewMonotonicInt64(x), delta),
+			),
+		)
+		seq.Last = seq.Now
+		seq.Now = time.Now()
+	}
+}
+
+// TestViewHintExplicitHistogram tests that hinted boundaries select
+// the explicit-bucket histogram.
+func TestViewHintExplicitHistogram(t *testing.T) {
+	views := view.New("test")
+	vc := New(testLib, views)
+	otelErrs := test.OTelErrors()
+
+	explicit, err := testCompile(
+		vc,
+		"explicit",
+		sdkinstrument.SyncHistogram,
+		number.Int64Kind,
+		instrument.WithDescription(`{
+  "aggregation": "histogram",
+  "config": {
+    "histogram": {
+      "boundaries": [1, 2.5, 10]
+    }
+  }
+}`))
+	require.NoError(t, err)
+
+	defaulted, err := testCompile(
+		vc,
+		"defaulted",
+		sdkinstrument.SyncHistogram,
+		number.Int64Kind,
+		instrument.WithDescription(`{
+  "aggregation": "explicit_histogram"
+}`))
+	require.NoError(t, err)
+	require.Nil(t, *otelErrs)
+
+	set := attribute.NewSet()
+	inputs := []int64{1, 2, 3, 20}
+
+	for _, inst := range []Instrument{explicit, defaulted} {
+		acc := inst.NewAccumulator(set)
+		for _, x := range inputs {
+			acc.(Updater[int64]).Update(x)
+		}
+		acc.SnapshotAndProcess(false)
+	}
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("explicit", sdkinstrument.SyncHistogram, number.Int64Kind),
+			test.Point(startTime, endTime, histogram.NewExplicitInt64([]float64{1, 2.5, 10}, inputs...), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("defaulted", sdkinstrument.SyncHistogram, number.Int64Kind),
+			test.Point(startTime, endTime, histogram.NewExplicitInt64(histogram.DefaultBoundaries, inputs...), cumulative),
+		),
+	)
+}
+
+// TestExplicitHistogramEnvBoundaries tests that the environment
+// configures the default explicit-histogram boundaries.
+func TestExplicitHistogramEnvBoundaries(t *testing.T) {
+	t.Setenv(histogram.DefaultBoundariesEnv, "1,10")
+
+	views := view.New("test",
+		view.WithClause(
+			view.MatchInstrumentName("explicit"),
+			view.WithAggregatorConfig(aggregator.Config{
+				HistogramBoundaries: histogram.WithExplicitBoundaries([]float64{5}),
+			}),
+		),
+		view.WithClause(
+			view.MatchInstrumentName("defaulted"),
+			view.WithAggregation(aggregation.ExplicitHistogramKind),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	explicit, err := testCompile(vc, "explicit", sdkinstrument.SyncHistogram, number.Int64Kind)
+	require.NoError(t, err)
+
+	defaulted, err := testCompile(vc, "defaulted", sdkinstrument.SyncHistogram, number.Int64Kind)
+	require.NoError(t, err)
+
+	set := attribute.NewSet()
+	inputs := []int64{1, 2, 3, 20}
+
+	for _, inst := range []Instrument{explicit, defaulted} {
+		acc := inst.NewAccumulator(set)
+		for _, x := range inputs {
+			acc.(Updater[int64]).Update(x)
+		}
+		acc.SnapshotAndProcess(false)
+	}
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("explicit", sdkinstrument.SyncHistogram, number.Int64Kind),
+			test.Point(startTime, endTime, histogram.NewExplicitInt64([]float64{5}, inputs...), cumulative),
+		),
+		test.Instrument(
+			test.Descriptor("defaulted", sdkinstrument.SyncHistogram, number.Int64Kind),
+			test.Point(startTime, endTime, histogram.NewExplicitInt64([]float64{1, 10}, inputs...), cumulative),
+		),
+	)
+}
+
+// TestViewHintMaxBuckets tests that the default bucket limit applies
+// to hinted boundaries.
+func TestViewHintMaxBuckets(t *testing.T) {
+	views := view.New("test",
+		view.WithDefaultAggregationConfigSelector(
+			func(_ sdkinstrument.Kind) (int64Config, float64Config aggregator.Config) {
+				cfg := aggregator.Config{
+					HistogramMaxBuckets: histogram.WithMaxBuckets(3),
+				}
+				return cfg, cfg
+			},
+		),
+	)
+	vc := New(testLib, views)
+
+	inst, err := testCompile(
+		vc,
+		"limited",
+		sdkinstrument.SyncHistogram,
+		number.Float64Kind,
+		instrument.WithDescription(`{
+  "aggregation": "histogram",
+  "config": {
+    "histogram": {
+      "boundaries": [1, 2, 3, 4, 5]
+    }
+  }
+}`))
+	require.NoError(t, err)
+
+	acc := inst.NewAccumulator(attribute.NewSet())
+	acc.(Updater[float64]).Update(3)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("limited", sdkinstrument.SyncHistogram, number.Float64Kind),
+			test.Point(startTime, endTime, histogram.NewExplicitFloat64([]float64{1, 5}, 3), cumulative),
+		),
+	)
+}
+
+func TestViewHintNoOverrideEmpty(t *testing.T) {
+	views := view.New("test",
+		view.WithDefaultAggregationConfigSelector(
+			func(_ sdkinstrument.Kind) (int64Config, float64Config aggregator.Config) {
+				cfg := aggregator.Config{
+					Histogram: histogram.NewConfig(histogram.WithMaxSize(127)),
+				}
+				return cfg, cfg
+			},
+		),
+	)
+	vc := New(testLib, views)
+	otelErrs := test.OTelErrors()
+
+	inst, err := testCompile(
+		vc,
+		"histo",
+		sdkinstrument.SyncCounter,
+		// sdkinstrument.SyncHistogram,
+		number.Float64Kind,
+		instrument.WithDescription(`{
+  "aggregation": "histogram"
+}`),
+	)
+	// note empty config
+	require.NoError(t, err)
+	require.Nil(t, *otelErrs, "see %v", *otelErrs)
+
+	seq := testSequence
+	set := attribute.NewSet(attribute.String("test", "attr"))
+	acc := inst.NewAccumulator(set)
+	acc.(Updater[float64]).Update(1)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t, testCollectSequence(t, vc, seq),
+		test.Instrument(
+			test.Descriptor(
+				"histo",
+				sdkinstrument.SyncCounter,
+				// sdkinstrument.SyncHistogram,
+				number.Float64Kind,
+			),
+			test.Point(
+				seq.Start,
+				seq.Now,
+				histogram.NewFloat64(histogram.NewConfig(histogram.WithMaxSize(127)), 1),
+				cumulative,
+				set.ToSlice()...),
+		),
+	)
+}
+
+// TestEmptyKeyFilter ensures no empty keys are used (w/o view config).
+func TestEmptyKeyFilter(t *testing.T) {
+	views := view.New("test")
+
+	vc := New(testLib, views)
+
+	// Note: this has to be the first test that uses an empty key,
+	// so the doevery logic triggers.
+	errs := new([]error)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		*errs = append(*errs, err)
+	}))
+
+	inst, err := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Float64Kind)
+	require.NoError(t, err)
+
+	acc1 := inst.NewAccumulator(attribute.NewSet(attribute.String("", "1value"), attribute.String("K", "V")))
+	acc1.(Updater[float64]).Update(1)
+	acc1.SnapshotAndProcess(false)
+
+	acc2 := inst.NewAccumulator(attribute.NewSet(attribute.String("", "2value"), attribute.String("K", "V")))
+	acc2.(Updater[float64]).Update(1)
+	acc2.SnapshotAndProcess(false)
+
+	output := testCollect(t, vc)
+
+	test.RequireEqualMetrics(t, output,
+		test.Instrument(
+			test.Descriptor("foo", sdkinstrument.SyncCounter, number.Float64Kind),
+			test.Point(
+				startTime, endTime, sum.NewMonotonicFloat64(2), cumulative,
+				attribute.String("K", "V"),
+			),
+		),
+	)
+
+	require.Equal(t, 1, len(*errs))
+	require.Equal(t, "use of empty attribute key, e.g., metric name \"foo\" with value \"1value\"", (*errs)[0].Error())
+}
+
+// TestEmptyKeyFilterAndView ensures no empty keys are used (with a view config).
+func TestEmptyKeyFilterAndView(t *testing.T) {
+	views := view.New("test",
+		view.WithClause(
+			view.WithKeys([]attribute.Key{"a"}),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "foo", sdkinstrument.SyncCounter, number.Float64Kind)
+	require.NoError(t, err)
+
+	acc1 := inst.NewAccumulator(attribute.NewSet(attribute.String("a", "1"), attribute.String("", "empty"), attribute.String("b", "ignored")))
+	acc1.(Updater[float64]).Update(1)
+	acc1.SnapshotAndProcess(false)
+
+	acc2 := inst.NewAccumulator(attribute.NewSet(attribute.String("", "different"), attribute.String("a", "1")))
+	acc2.(Updater[float64]).Update(1)
+	acc2.SnapshotAndProcess(false)
+
+	output := testCollect(t, vc)
+
+	test.RequireEqualMetrics(t, output,
+		test.Instrument(
+			test.Descriptor("foo", sdkinstrument.SyncCounter, number.Float64Kind),
+			test.Point(
+				startTime, endTime, sum.NewMonotonicFloat64(2), cumulative, attribute.String("a", "1"),
+			),
+		),
+	)
+}
+
+// TestRateAggregation tests the rate of synchronous and asynchronous
+// counters, which is output as a float64 gauge of delta sums.
+func TestRateAggregation(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithAggregation(aggregation.RateKind),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	instS, err := testCompile(vc, "sync", sdkinstrument.SyncCounter, number.Int64Kind)
+	require.NoError(t, err)
+
+	instA, err := testCompile(vc, "async", sdkinstrument.AsyncCounter, number.Float64Kind)
+	require.NoError(t, err)
+
+	set := attribute.NewSet()
+
+	add := func(x int64) {
+		acc := instS.NewAccumulator(set)
+		acc.(Updater[int64]).Update(x)
+		acc.SnapshotAndProcess(false)
+	}
+	observe := func(x float64) {
+		acc := instA.NewAccumulator(set)
+		acc.(Updater[float64]).Update(x)
+		acc.SnapshotAndProcess(true)
+	}
+	expect := func(seq data.Sequence, syncPoints, asyncPoints []data.Point) {
+		test.RequireEqualMetrics(t,
+			testCollectSequence(t, vc, seq),
+			test.Instrument(
+				test.Descriptor("sync", sdkinstrument.SyncCounter, number.Float64Kind),
+				syncPoints...,
+			),
+			test.Instrument(
+				test.Descriptor("async", sdkinstrument.AsyncCounter, number.Float64Kind),
+				asyncPoints...,
+			),
+		)
+	}
+
+	seq := data.Sequence{
+		Start: startTime,
+		Last:  startTime,
+		Now:   startTime.Add(10 * time.Second),
+	}
+	tick := func(d time.Duration) {
+		seq.Last = seq.Now
+		seq.Now = seq.Now.Add(d)
+	}
+
+	// The first observation has no rate.
+	add(20)
+	observe(100)
+	expect(seq, []data.Point{
+		test.Point(seq.Last, seq.Now, rate.NewInt64(20, 10*time.Second), delta),
+	}, nil)
+
+	tick(5 * time.Second)
+	add(5)
+	observe(150)
+	expect(seq, []data.Point{
+		test.Point(seq.Last, seq.Now, rate.NewInt64(5, 5*time.Second), delta),
+	}, []data.Point{
+		test.Point(seq.Last, seq.Now, rate.NewFloat64(50, 5*time.Second), delta),
+	})
+
+	// Intervals without change and resets have no rate.
+	tick(5 * time.Second)
+	observe(20)
+	expect(seq, nil, nil)
+
+	tick(5 * time.Second)
+	observe(30)
+	expect(seq, nil, []data.Point{
+		test.Point(seq.Last, seq.Now, rate.NewFloat64(10, 5*time.Second), delta),
+	})
+
+	// An empty interval has no rate.
+	seq.Last = seq.Now
+	add(1)
+	observe(40)
+	expect(seq, nil, nil)
+}
+
+// TestRateFallback tests that a rate is not used for non-monotonic
+// instruments.
+func TestRateFallback(t *testing.T) {
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.WithAggregation(aggregation.RateKind),
+		),
+	)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "updown", sdkinstrument.SyncUpDownCounter, number.Int64Kind)
+	require.Error(t, err)
+
+	acc := inst.NewAccumulator(attribute.NewSet())
+	acc.(Updater[int64]).Update(-1)
+	acc.SnapshotAndProcess(false)
+
+	test.RequireEqualMetrics(t,
+		testCollect(t, vc),
+		test.Instrument(
+			test.Descriptor("updown", sdkinstrument.SyncUpDownCounter, number.Int64Kind),
+			test.Point(startTime, endTime, sum.NewNonMonotonicInt64(-1), cumulative),
+		),
+	)
+}
+
+// testCountKind is a custom aggregation that counts measurements.
+const testCountKind = aggregation.FirstCustomKind
+
+type testCount struct {
+	count uint64
+}
+
+func (c *testCount) Kind() aggregation.Kind {
+	return testCountKind
+}
+
+type testCountMethods[N number.Any] struct{}
+
+func (testCountMethods[N]) Init(ptr *any, _ aggregator.Config) {
+	*ptr = &testCount{}
+}
+
+func (testCountMethods[N]) Update(ptr *any, _ N) {
+	atomic.AddUint64(&(*ptr).(*testCount).count, 1)
+}
+
+func (testCountMethods[N]) Move(input, output *any) {
+	(*output).(*testCount).count = atomic.SwapUint64(&(*input).(*testCount).count, 0)
+}
+
+func (testCountMethods[N]) Merge(input, output *any) {
+	atomic.AddUint64(&(*output).(*testCount).count, (*input).(*testCount).count)
+}
+
+func (testCountMethods[N]) Copy(input, output *any) {
+	(*output).(*testCount).count = atomic.LoadUint64(&(*input).(*testCount).count)
+}
+
+func (testCountMethods[N]) SubtractSwap(operand, argument *any) {
+	op := (*operand).(*testCount)
+	op.count = (*argument).(*testCount).count - op.count
+}
+
+func (testCountMethods[N]) ToAggregation(ptr *any) aggregation.Aggregation {
+	return (*ptr).(*testCount)
+}
+
+func (testCountMethods[N]) ToStorage(agg aggregation.Aggregation) (*any, bool) {
+	c, ok := agg.(*testCount)
+	if !ok {
+		return nil, false
+	}
+	var value any = c
+	return &value, true
+}
+
+func (testCountMethods[N]) Kind() aggregation.Kind {
+	return testCountKind
+}
+
+func (testCountMethods[N]) HasChange(ptr *any) bool {
+	return (*ptr).(*testCount).count != 0
+}
+
+func init() {
+	if err := aggregator.Register(testCountKind, aggregator.Factory{
+		Category: aggregation.HistogramCategory,
+		Int64:    testCountMethods[int64]{},
+		Float64:  testCountMethods[float64]{},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// TestCustomAggregation tests a custom aggregation registered with
+// aggregator.Register.
+func TestCustomAggregation(t *testing.T) {
+	require.Error(t, aggregator.Register(testCountKind, aggregator.Factory{
+		Int64: testCountMethods[int64]{},
+	}))
+	require.Error(t, aggregator.Register(aggregation.SummaryKind, aggregator.Factory{
+		Int64: testCountMethods[int64]{},
+	}))
+	require.Error(t, aggregator.Register(testCountKind+1, aggregator.Factory{}))
+
+	views := view.New(
+		"test",
+		view.WithClause(
+			view.MatchInstrumentKind(sdkinstrument.SyncHistogram),
+			view.WithAggregation(testCountKind),
+		),
+	)
+	views, err := view.Validate(views)
+	require.NoError(t, err)
+
+	vc := New(testLib, views)
+
+	inst, err := testCompile(vc, "foo", sdkinstrument.SyncHistogram, number.Int64Kind)
+	require.NoError(t, err)
+
+	acc := inst.NewAccumulator(attribute.NewSet(attribute.String("a", "1")))
+	acc.(Updater[int64]).Update(10)
+	acc.(Updater[int64]).Update(20)
+	acc.(Updater[int64]).Update(30)
+	acc.SnapshotAndProcess(false)
+
+	var output data.Scope
+	for i := 0; i < 2; i++ {
+		// The second collection re-uses the output storage.
+		test.RequireEqualMetrics(t, testCollectSequenceReuse(t, vc, testSequence, &output),
+			test.Instrument(
+				test.Descriptor("foo", sdkinstrument.SyncHistogram, number.Int64Kind),
+				test.Point(startTime, endTime, &testCount{count: 3}, cumulative, attribute.String("a", "1")),
+			),
+		)
+	}
+
+	// The custom aggregation does not support uint64, which
+	// uses the default aggregation.
+	_, err = testCompile(vc, "bar", sdkinstrument.SyncHistogram, number.Uint64Kind)
+	var iae IncompatibleAggregationError
+	require.True(t, errors.As(err, &iae))
+	require.Equal(t, testCountKind, iae.Aggregation)
+	require.Equal(t, aggregation.HistogramKind, iae.Fallback)
 }
@@ -16,14 +16,17 @@ package metric // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/me
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/asyncstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/pipeline"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/instrument"
@@ -32,6 +35,8 @@ import (
 	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
 	"go.opentelemetry.io/otel/metric/instrument/syncint64"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.uber.org/multierr"
 )
 
 // meter handles the creation and coordination of all metric instruments. A
@@ -41,6 +46,7 @@ import (
 type meter struct {
 	library   instrumentation.Library
 	provider  *MeterProvider
+	resource  *resource.Resource // nil unless MeterWithResource
 	compilers pipeline.Register[*viewstate.Compiler]
 
 	lock       sync.Mutex
@@ -53,6 +59,45 @@ type meter struct {
 // Compile-time check meter implements metric.Meter.
 var _ metric.Meter = (*meter)(nil)
 
+// Compile-time check meter implements sdkinstrument.GaugeProvider.
+var _ sdkinstrument.GaugeProvider = (*meter)(nil)
+
+// Compile-time check meter implements syncstate.InternPoolProvider.
+var _ syncstate.InternPoolProvider = (*meter)(nil)
+
+// InternPool returns the provider's attribute intern pool, nil unless
+// configured by WithAttributeInterning.
+func (m *meter) InternPool() *syncstate.InternPool {
+	return m.provider.pool
+}
+
+// Compile-time check meter implements syncstate.NegativeHistogramProvider.
+var _ syncstate.NegativeHistogramProvider = (*meter)(nil)
+
+// NegativeHistogramValues returns true when configured by
+// WithNegativeHistogramValues.
+func (m *meter) NegativeHistogramValues() bool {
+	return m.provider.cfg.negativeHistogramValues
+}
+
+// Compile-time check meter implements syncstate.CardinalityEstimationProvider.
+var _ syncstate.CardinalityEstimationProvider = (*meter)(nil)
+
+// CardinalityEstimation returns true when configured by
+// WithCardinalityEstimation.
+func (m *meter) CardinalityEstimation() bool {
+	return m.provider.cfg.cardinalityEstimation
+}
+
+// Compile-time check meter implements syncstate.IngestQueueProvider.
+var _ syncstate.IngestQueueProvider = (*meter)(nil)
+
+// IngestQueue returns the provider's ingest queue, nil unless
+// configured by WithAsyncIngest.
+func (m *meter) IngestQueue() *syncstate.IngestQueue {
+	return m.provider.ingest
+}
+
 // AsyncInt64 returns the asynchronous integer instrument provider.
 func (m *meter) AsyncInt64() asyncint64.InstrumentProvider {
 	return asyncint64Instruments{m}
@@ -87,16 +132,66 @@ func (m *meter) SyncFloat64() syncfloat64.InstrumentProvider {
 }
 
 // instrumentConstructor refers to either the syncstate or asyncstate
-// NewInstrument method.  Although both receive an opaque interface{}
-// to distinguish providers, only the asyncstate package needs to know
-// this information.  The unused parameter is passed to the syncstate
-// package for the generalization used here to work.
+// NewInstrument method.  Both receive an opaque interface{}, the
+// meter: the asyncstate package uses it to distinguish providers and
+// the syncstate package uses it to locate the InternPool, the
+// negative histogram setting, and the cardinality estimation
+// setting.
 type instrumentConstructor[T any] func(
 	instrument sdkinstrument.Descriptor,
 	opaque interface{},
 	compiled pipeline.Register[viewstate.Instrument],
+	onError aggregator.MeasurementErrorHandler,
 ) *T
 
+// filterBypasser is implemented by instruments that support
+// sdkinstrument.WithBypassFilter.
+type filterBypasser interface {
+	BypassFilter()
+}
+
+// compiledSetter is implemented by instruments that replace their
+// compiled instruments after the views change.
+type compiledSetter interface {
+	SetCompiled(compiled pipeline.Register[viewstate.Instrument])
+}
+
+// addClause adds a view clause to the compilers of pipes and replaces
+// the compiled instruments of the instruments it matches.  Holding
+// the meter's lock ensures that instruments are not registered
+// concurrently.
+func (m *meter) addClause(pipes []int, clause view.ClauseConfig) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var err error
+	var descs []sdkinstrument.Descriptor
+	seen := map[sdkinstrument.Descriptor]bool{}
+	for _, pipe := range pipes {
+		redo, addErr := m.compilers[pipe].AddClause(clause)
+		err = multierr.Append(err, addErr)
+
+		for _, desc := range redo {
+			if !seen[desc] {
+				seen[desc] = true
+				descs = append(descs, desc)
+			}
+		}
+	}
+	for _, desc := range descs {
+		setter, ok := m.byDesc[desc].(compiledSetter)
+		if !ok {
+			continue
+		}
+		compiled := pipeline.NewRegister[viewstate.Instrument](len(m.compilers))
+		for pipe, compiler := range m.compilers {
+			compiled[pipe], _ = compiler.Lookup(desc)
+		}
+		setter.SetCompiled(compiled)
+	}
+	return err
+}
+
 // configureInstrument applies the instrument configuration, checks
 // for an existing definition for the same descriptor, and compiles
 // and constructs the instrument if necessary.
@@ -110,6 +205,7 @@ func configureInstrument[T any](
 	ctor instrumentConstructor[T],
 ) (*T, error) {
 	// Compute the instrument descriptor
+	opts, bypass := sdkinstrument.BypassFilter(opts)
 	cfg := instrument.NewConfig(opts...)
 	desc := sdkinstrument.NewDescriptor(name, ik, nk, cfg.Description(), cfg.Unit())
 
@@ -129,6 +225,18 @@ func configureInstrument[T any](
 		return lookup.(*T), conflicts.AsError()
 	}
 
+	// Count the new instrument against the provider's limit.
+	if !m.provider.reserveInstrument() {
+		err := fmt.Errorf("%w: %s", ErrTooManyInstruments, desc.Name)
+		otel.Handle(err)
+
+		// Construct the instrument with no pipelines, as if
+		// every view dropped it, and do not cache it so that a
+		// repeat registration reports the error again.
+		disabled := pipeline.NewRegister[viewstate.Instrument](len(m.compilers))
+		return ctor(desc, m, disabled, m.provider.cfg.onMeasurementError), err
+	}
+
 	// Compile the instrument for each pipeline. the first time.
 	var conflicts viewstate.ViewConflictsBuilder
 	compiled := pipeline.NewRegister[viewstate.Instrument](len(m.compilers))
@@ -140,10 +248,13 @@ func configureInstrument[T any](
 	}
 
 	// Build the new instrument, cache it, append to the list.
-	inst := ctor(desc, m, compiled)
+	inst := ctor(desc, m, compiled, m.provider.cfg.onMeasurementError)
 	err := conflicts.AsError()
 
 	if inst != nil {
+		if b, ok := any(inst).(filterBypasser); ok && bypass {
+			b.BypassFilter()
+		}
 		m.byDesc[desc] = inst
 	}
 	*listPtr = append(*listPtr, inst)
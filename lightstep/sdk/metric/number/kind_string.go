@@ -10,11 +10,12 @@ func _() {
 	var x [1]struct{}
 	_ = x[Int64Kind-0]
 	_ = x[Float64Kind-1]
+	_ = x[Uint64Kind-2]
 }
 
-const _Kind_name = "Int64KindFloat64Kind"
+const _Kind_name = "Int64KindFloat64KindUint64Kind"
 
-var _Kind_index = [...]uint8{0, 9, 20}
+var _Kind_index = [...]uint8{0, 9, 20, 30}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
@@ -27,6 +27,9 @@ const (
 
 	// Float64Kind indicates float64.
 	Float64Kind
+
+	// Uint64Kind indicates uint64.
+	Uint64Kind
 )
 
 // Number is a 64bit numeric value, one of the Any interface types.
@@ -34,13 +37,23 @@ type Number uint64
 
 // Any is any of the supported generic Number types.
 type Any interface {
+	int64 | uint64 | float64
+}
+
+// Signed is the subset of Any that supports negative values.  This is
+// the constraint of aggregators that do not support uint64, e.g., the
+// exponential histogram.
+type Signed interface {
 	int64 | float64
 }
 
 // CoerceToFloat64 converts Number to float64 according to Kind.
 func (n Number) CoerceToFloat64(k Kind) float64 {
-	if k == Int64Kind {
-		return float64(n)
+	switch k {
+	case Int64Kind:
+		return float64(int64(n))
+	case Uint64Kind:
+		return float64(uint64(n))
 	}
 	return math.Float64frombits(uint64(n))
 }
@@ -54,3 +67,8 @@ func ToFloat64(n Number) float64 {
 func ToInt64(n Number) int64 {
 	return int64(n)
 }
+
+// ToUint64 converts Number to uint64.
+func ToUint64(n Number) uint64 {
+	return uint64(n)
+}
@@ -40,10 +40,10 @@ type Traits[N Any] interface {
 	// SwapAtomic sets `ptr` to `value` and returns the former value.
 	SwapAtomic(ptr *N, value N) N
 
-	// IsNaN indicates whether `math.IsNaN()` is true (impossible for int64).
+	// IsNaN indicates whether `math.IsNaN()` is true (impossible for integers).
 	IsNaN(value N) bool
 
-	// IsInf indicates whether `math.IsInf()` is true (impossible for int64).
+	// IsInf indicates whether `math.IsInf()` is true (impossible for integers).
 	IsInf(value N) bool
 
 	// Kind returns the number kind of these Traits.
@@ -91,6 +91,58 @@ func (Int64Traits) Kind() Kind {
 	return Int64Kind
 }
 
+// Uint64Traits implements Traits[uint64].  Note that AddAtomic
+// saturates at math.MaxUint64 instead of overflowing.
+type Uint64Traits struct{}
+
+var _ Traits[uint64] = Uint64Traits{}
+
+func (Uint64Traits) ToNumber(x uint64) Number {
+	return Number(x)
+}
+
+func (Uint64Traits) FromNumber(n Number) uint64 {
+	return uint64(n)
+}
+
+func (Uint64Traits) GetAtomic(ptr *uint64) uint64 {
+	return atomic.LoadUint64(ptr)
+}
+
+func (Uint64Traits) SetAtomic(ptr *uint64, value uint64) {
+	atomic.StoreUint64(ptr, value)
+}
+
+func (Uint64Traits) SwapAtomic(ptr *uint64, value uint64) uint64 {
+	return atomic.SwapUint64(ptr, value)
+}
+
+func (Uint64Traits) AddAtomic(ptr *uint64, value uint64) {
+	for {
+		old := atomic.LoadUint64(ptr)
+		sum := old + value
+		if sum < old {
+			sum = math.MaxUint64
+		}
+
+		if atomic.CompareAndSwapUint64(ptr, old, sum) {
+			return
+		}
+	}
+}
+
+func (Uint64Traits) IsNaN(_ uint64) bool {
+	return false
+}
+
+func (Uint64Traits) IsInf(_ uint64) bool {
+	return false
+}
+
+func (Uint64Traits) Kind() Kind {
+	return Uint64Kind
+}
+
 // Float64Traits implements Traits[float64].
 type Float64Traits struct{}
 
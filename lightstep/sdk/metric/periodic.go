@@ -22,6 +22,7 @@ import (
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 	"go.opentelemetry.io/otel"
+	"go.uber.org/multierr"
 )
 
 const DefaultInterval = 30 * time.Second
@@ -45,6 +46,18 @@ type PushExporter interface {
 	ForceFlushMetrics(context.Context, data.Metrics) error
 }
 
+// StreamExporter is a PushExporter that builds its periodic exports
+// directly from a StreamProducer, skipping the data.Metrics that
+// ExportMetrics would receive.  ShutdownMetrics and ForceFlushMetrics
+// are called as for any PushExporter.
+type StreamExporter interface {
+	PushExporter
+
+	// ExportStream is called periodically in place of
+	// ExportMetrics to collect from the producer and export.
+	ExportStream(ctx context.Context, producer StreamProducer) error
+}
+
 // PeriodicReader is an implementation of Reader that manages periodic
 // exporter, flush, and shutdown.  This implementation re-uses data
 // from one collection to the next, to lower memory costs.
@@ -118,7 +131,7 @@ func (pr *PeriodicReader) start(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := pr.collectWithTimeout(ctx, pr.exporter.ExportMetrics); err != nil {
+			if err := pr.exportWithTimeout(ctx); err != nil {
 				otel.Handle(err)
 			}
 		}
@@ -131,6 +144,28 @@ func (pr *PeriodicReader) collectWithTimeout(ctx context.Context, method func(co
 	return pr.collect(ctx, method)
 }
 
+// exportWithTimeout performs a periodic export, using ExportStream
+// when both the exporter and the producer support it.
+func (pr *PeriodicReader) exportWithTimeout(ctx context.Context) error {
+	se, ok := pr.exporter.(StreamExporter)
+	if !ok {
+		return pr.collectWithTimeout(ctx, pr.exporter.ExportMetrics)
+	}
+	sp, ok := pr.producer.(StreamProducer)
+	if !ok {
+		return pr.collectWithTimeout(ctx, pr.exporter.ExportMetrics)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pr.timeout)
+	defer cancel()
+
+	// See the note about exclusive collection in collect().
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	return se.ExportStream(ctx, sp)
+}
+
 // Shutdown stops the export loop, canceling its Context, and waits
 // for it to return.  Then it issues a ShutdownMetrics with final
 // data.  There is no automatic timeout; to apply one, use
@@ -161,7 +196,15 @@ func (pr *PeriodicReader) collect(ctx context.Context, method func(context.Conte
 	// exclusive.  Note that shutdown will cancel a concurrent
 	// (ordinary) export, while flush will wait for a concurrent
 	// export.
-	pr.data = pr.producer.Produce(&pr.data)
+	cp, ok := pr.producer.(ContextProducer)
+	if !ok {
+		pr.data = pr.producer.Produce(&pr.data)
+		return method(ctx, pr.data)
+	}
 
-	return method(ctx, pr.data)
+	// Partial data is exported, since the instruments that were
+	// collected would otherwise lose their data.
+	var err error
+	pr.data, err = cp.ProduceContext(ctx, &pr.data)
+	return multierr.Append(err, method(ctx, pr.data))
 }
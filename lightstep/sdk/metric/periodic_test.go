@@ -29,9 +29,20 @@ import (
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/resource"
 )
 
+// streamExporter is a StreamExporter built on the mock PushExporter.
+type streamExporter struct {
+	*MockPushExporter
+	export func(context.Context, StreamProducer) error
+}
+
+func (se streamExporter) ExportStream(ctx context.Context, producer StreamProducer) error {
+	return se.export(ctx, producer)
+}
+
 func TestPeriodicRepeats(t *testing.T) {
 	var notime time.Time
 	const cumulative = aggregation.CumulativeTemporality
@@ -214,4 +225,38 @@ func TestPeriodicRepeats(t *testing.T) {
 		require.Equal(t, DefaultInterval, periodic.interval)
 		require.Equal(t, DefaultTimeout, periodic.timeout)
 	})
+
+	t.Run("export_stream", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock := NewMockPushExporter(ctrl)
+		mock.EXPECT().String().Return("mock").AnyTimes()
+
+		var visitor testVisitor
+		var periodic *PeriodicReader
+
+		// ExportMetrics is not expected.
+		exporter := streamExporter{
+			MockPushExporter: mock,
+			export: func(ctx context.Context, producer StreamProducer) error {
+				defer periodic.stop()
+				return producer.ProduceStream(ctx, &visitor)
+			},
+		}
+		periodic = NewPeriodicReader(exporter, time.Millisecond*25)
+
+		provider := NewMeterProvider(WithResource(resource.Empty()), WithReader(periodic))
+		ctr := must(provider.Meter("test").SyncInt64().Counter("hello"))
+		ctr.Add(context.Background(), 2)
+
+		periodic.wait.Wait()
+
+		require.Equal(t, []streamedPoint{{
+			scope: "test",
+			name:  "hello",
+			attrs: attribute.EmptySet().Equivalent(),
+			value: 2,
+		}}, visitor.points)
+	})
 }
@@ -21,6 +21,8 @@ import (
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/asyncstate"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 )
 
 // providerProducer is the binding between the MeterProvider and the
@@ -42,22 +44,36 @@ func (mp *MeterProvider) producerFor(pipe int) Producer {
 	}
 }
 
+var (
+	_ StreamProducer  = &providerProducer{}
+	_ ChangedProducer = &providerProducer{}
+)
+
 // Produce runs collection and produces a new metrics data object.
 func (pp *providerProducer) Produce(inout *data.Metrics) data.Metrics {
+	output, _ := pp.ProduceContext(context.Background(), inout)
+	return output
+}
+
+// ProduceContext runs collection until ctx is done and produces a new
+// metrics data object.
+func (pp *providerProducer) ProduceContext(ctx context.Context, inout *data.Metrics) (data.Metrics, error) {
+	return pp.produce(ctx, inout, false)
+}
+
+// ProduceChanged is ProduceContext for the points that were updated
+// since the previous collection.
+func (pp *providerProducer) ProduceChanged(ctx context.Context, inout *data.Metrics) (data.Metrics, error) {
+	return pp.produce(ctx, inout, true)
+}
+
+// produce implements ProduceContext, and ProduceChanged when
+// changedOnly is set.
+func (pp *providerProducer) produce(ctx context.Context, inout *data.Metrics, changedOnly bool) (data.Metrics, error) {
+	pp.provider.flushIngest()
 	ordered := pp.provider.getOrdered()
 
-	// Note: the Last time is only used in delta-temporality
-	// scenarios.  This lock protects the only stateful change in
-	// `pp` but does not prevent concurrent collection.  If a
-	// delta-temporality reader were to call Produce
-	// concurrently, the results would be be recorded with
-	// non-overlapping timestamps but would have been collected in
-	// an overlapping way.
-	pp.lock.Lock()
-	lastTime := pp.lastCollect
-	nowTime := time.Now()
-	pp.lastCollect = nowTime
-	pp.lock.Unlock()
+	sequence := pp.nextSequence()
 
 	var output data.Metrics
 	if inout != nil {
@@ -67,28 +83,101 @@ func (pp *providerProducer) Produce(inout *data.Metrics) data.Metrics {
 
 	output.Resource = pp.provider.cfg.res
 
-	sequence := data.Sequence{
-		Start: pp.provider.startTime,
-		Last:  lastTime,
-		Now:   nowTime,
+	var uncollected []string
+	var times collectTimes
+
+	for _, meter := range ordered {
+		uncollected = meter.collectFor(
+			ctx,
+			pp.pipe,
+			sequence,
+			&output,
+			changedOnly,
+			uncollected,
+			&times,
+		)
 	}
 
-	ctx := context.Background()
+	pp.provider.selfObs.record(pp.provider.cfg.readers[pp.pipe].String(), times)
+
+	if uncollected != nil {
+		return output, &PartialCollectionError{
+			Uncollected: uncollected,
+			Err:         ctx.Err(),
+		}
+	}
+	return output, nil
+}
+
+// ProduceStream runs collection until ctx is done, passing each point
+// to the visitor.
+func (pp *providerProducer) ProduceStream(ctx context.Context, visitor data.Visitor) error {
+	pp.provider.flushIngest()
+	ordered := pp.provider.getOrdered()
+	sequence := pp.nextSequence()
+
+	var uncollected []string
+	var times collectTimes
+	var err error
 
 	for _, meter := range ordered {
-		meter.collectFor(
+		uncollected, err = meter.streamFor(
 			ctx,
 			pp.pipe,
 			sequence,
-			&output,
+			visitor,
+			uncollected,
+			&times,
 		)
+		if err != nil {
+			break
+		}
 	}
 
-	return output
+	pp.provider.selfObs.record(pp.provider.cfg.readers[pp.pipe].String(), times)
+
+	if err != nil {
+		return err
+	}
+	if uncollected != nil {
+		return &PartialCollectionError{
+			Uncollected: uncollected,
+			Err:         ctx.Err(),
+		}
+	}
+	return nil
 }
 
-// collectFor collects from a single meter.
-func (m *meter) collectFor(ctx context.Context, pipe int, seq data.Sequence, output *data.Metrics) {
+// nextSequence returns the timestamps of a new collection.
+func (pp *providerProducer) nextSequence() data.Sequence {
+	// Note: the Last time is only used in delta-temporality
+	// scenarios.  This lock protects the only stateful change in
+	// `pp` but does not prevent concurrent collection.  If a
+	// delta-temporality reader were to call Produce
+	// concurrently, the results would be be recorded with
+	// non-overlapping timestamps but would have been collected in
+	// an overlapping way.
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	lastTime := pp.lastCollect
+	nowTime := pp.provider.cfg.clock.Now()
+	pp.lastCollect = nowTime
+
+	return data.Sequence{
+		Start: pp.provider.startTime,
+		Last:  lastTime,
+		Now:   nowTime,
+	}
+}
+
+// collectFor collects from a single meter.  When ctx is done, the
+// names of instruments that are not collected are appended to
+// uncollected, which is returned.  Synchronous instruments that are
+// not collected retain their data for the next collection.  The time
+// spent in each phase is added to times.  When changedOnly is set,
+// collectors that support it output only the points that changed.
+func (m *meter) collectFor(ctx context.Context, pipe int, seq data.Sequence, output *data.Metrics, changedOnly bool, uncollected []string, times *collectTimes) []string {
 	// Use m.lock to briefly access the current lists: syncInsts,
 	// asyncInsts, callbacks.  By releasing these locks, we allow
 	// new instruments and callbacks to be registered while
@@ -104,24 +193,121 @@ func (m *meter) collectFor(ctx context.Context, pipe int, seq data.Sequence, out
 	callbacks := m.callbacks
 	m.lock.Unlock()
 
+	collectors := m.compilers[pipe].Collectors()
+
+	if !m.snapshotFor(ctx, pipe, syncInsts, asyncInsts, callbacks, times) {
+		return skipCollectors(collectors, uncollected)
+	}
+
+	scope := data.ReallocateFrom(&output.Scopes)
+	scope.Library = m.library
+	scope.Resource = m.resource
+
+	start := time.Now()
+	for i, coll := range collectors {
+		if ctx.Err() != nil {
+			times.collect += time.Since(start)
+			return skipCollectors(collectors[i:], uncollected)
+		}
+		if cc, ok := coll.(data.ChangedCollector); ok && changedOnly {
+			cc.CollectChanged(seq, &scope.Instruments)
+			continue
+		}
+		coll.Collect(seq, &scope.Instruments)
+	}
+	times.collect += time.Since(start)
+	return uncollected
+}
+
+// streamFor collects from a single meter like collectFor, passing
+// each point to the visitor.  Instruments with a point processor are
+// collected through a scratch Scope, since CollectInto does not apply
+// the processor.  A visitor error stops collection and is returned.
+func (m *meter) streamFor(ctx context.Context, pipe int, seq data.Sequence, visitor data.Visitor, uncollected []string, times *collectTimes) ([]string, error) {
+	// See the locking note in collectFor.
+	m.lock.Lock()
+	syncInsts := m.syncInsts
+	asyncInsts := m.asyncInsts
+	callbacks := m.callbacks
+	m.lock.Unlock()
+
+	collectors := m.compilers[pipe].Collectors()
+
+	if !m.snapshotFor(ctx, pipe, syncInsts, asyncInsts, callbacks, times) {
+		return skipCollectors(collectors, uncollected), nil
+	}
+
+	res := m.resource
+	if res == nil {
+		res = m.provider.cfg.res
+	}
+	visitor.VisitScope(res, m.library)
+
+	var scratch data.Scope
+
+	start := time.Now()
+	defer func() {
+		times.collect += time.Since(start)
+	}()
+	for i, coll := range collectors {
+		if ctx.Err() != nil {
+			return skipCollectors(collectors[i:], uncollected), nil
+		}
+		visitor.VisitInstrument(coll.Descriptor(), coll.Size())
+
+		if pp, ok := coll.(viewstate.PointProcessing); ok && pp.HasPointProcessor() {
+			scratch.Reset()
+			coll.Collect(seq, &scratch.Instruments)
+
+			for _, inst := range scratch.Instruments {
+				for _, point := range inst.Points {
+					if err := visitor.VisitPoint(point); err != nil {
+						return uncollected, err
+					}
+				}
+			}
+			continue
+		}
+		if err := coll.CollectInto(seq, visitor.VisitPoint); err != nil {
+			return uncollected, err
+		}
+	}
+	return uncollected, nil
+}
+
+// snapshotFor runs the callbacks and snapshots the instruments of a
+// single meter, returning false if ctx is done first.  The time spent
+// is added to times.
+func (m *meter) snapshotFor(ctx context.Context, pipe int, syncInsts []*syncstate.Instrument, asyncInsts []*asyncstate.Instrument, callbacks []*asyncstate.Callback, times *collectTimes) bool {
 	asyncState := asyncstate.NewState(pipe)
 
-	for _, cb := range callbacks {
-		cb.Run(ctx, asyncState)
+	m.provider.cfg.callbackRunner.Run(ctx, callbacks, asyncState)
+
+	if ctx.Err() != nil {
+		return false
 	}
 
+	start := time.Now()
+	defer func() {
+		times.snapshot += time.Since(start)
+	}()
 	for _, inst := range syncInsts {
+		if ctx.Err() != nil {
+			return false
+		}
 		inst.SnapshotAndProcess()
 	}
 
 	for _, inst := range asyncInsts {
 		inst.SnapshotAndProcess(asyncState)
 	}
+	return true
+}
 
-	scope := data.ReallocateFrom(&output.Scopes)
-	scope.Library = m.library
-
-	for _, coll := range m.compilers[pipe].Collectors() {
-		coll.Collect(seq, &scope.Instruments)
+// skipCollectors appends the names of collectors to uncollected.
+func skipCollectors(collectors []data.Collector, uncollected []string) []string {
+	for _, coll := range collectors {
+		uncollected = append(uncollected, coll.Descriptor().Name)
 	}
+	return uncollected
 }
@@ -18,11 +18,15 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/pipeline"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -38,7 +42,25 @@ type MeterProvider struct {
 	startTime time.Time
 	lock      sync.Mutex
 	ordered   []*meter
-	meters    map[instrumentation.Library]*meter
+	meters    map[meterKey]*meter
+	selfObs   *selfObservability
+	pool      *syncstate.InternPool
+	ingest    *syncstate.IngestQueue
+
+	// added are the view clauses of each pipeline added by
+	// AddViewClause, protected by lock.
+	added pipeline.Register[[]view.ClauseConfig]
+
+	// instruments counts the distinct instruments of all meters,
+	// see WithMaxInstruments.
+	instruments int64
+}
+
+// meterKey identifies a meter by its scope and the distinct
+// resource attributes it was created with, if any.
+type meterKey struct {
+	library  instrumentation.Library
+	resource attribute.Distinct
 }
 
 // Compile-time check MeterProvider implements metric.MeterProvider.
@@ -46,6 +68,14 @@ var _ metric.MeterProvider = (*MeterProvider)(nil)
 
 var ErrAlreadyShutdown = fmt.Errorf("provider was already shut down")
 
+// ErrUnknownReader is returned by AddViewClause for a Reader that
+// was not configured with WithReader.
+var ErrUnknownReader = fmt.Errorf("reader is not registered with this provider")
+
+// ErrTooManyInstruments is returned when a new instrument would
+// exceed the limit configured by WithMaxInstruments.
+var ErrTooManyInstruments = fmt.Errorf("too many instruments")
+
 // NewMeterProvider returns a new and configured MeterProvider.
 //
 // By default, the returned MeterProvider is configured with the default
@@ -59,10 +89,21 @@ func NewMeterProvider(options ...Option) *MeterProvider {
 	for _, option := range options {
 		cfg = option.apply(cfg)
 	}
+	if cfg.clock == nil {
+		cfg.clock = realClock{}
+	}
 	p := &MeterProvider{
 		cfg:       cfg,
-		startTime: time.Now(),
-		meters:    map[instrumentation.Library]*meter{},
+		startTime: cfg.clock.Now(),
+		meters:    map[meterKey]*meter{},
+		added:     pipeline.NewRegister[[]view.ClauseConfig](len(cfg.readers)),
+	}
+	p.selfObs = newSelfObservability(cfg.selfMeter, p)
+	if cfg.internAttributes {
+		p.pool = syncstate.NewInternPool()
+	}
+	if cfg.asyncIngest > 0 {
+		p.ingest = syncstate.NewIngestQueue(cfg.asyncIngest)
 	}
 	for pipe := 0; pipe < len(cfg.readers); pipe++ {
 		cfg.readers[pipe].Register(p.producerFor(pipe))
@@ -70,6 +111,24 @@ func NewMeterProvider(options ...Option) *MeterProvider {
 	return p
 }
 
+// reserveInstrument counts a new instrument, returning false if the
+// instrument would exceed the limit configured by WithMaxInstruments.
+func (mp *MeterProvider) reserveInstrument() bool {
+	max := int64(mp.cfg.maxInstruments)
+	if max <= 0 {
+		return true
+	}
+	for {
+		cnt := atomic.LoadInt64(&mp.instruments)
+		if cnt >= max {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&mp.instruments, cnt, cnt+1) {
+			return true
+		}
+	}
+}
+
 // Meter returns a Meter with the given name and configured with options.
 //
 // The name should be the name of the instrumentation scope creating
@@ -84,31 +143,69 @@ func NewMeterProvider(options ...Option) *MeterProvider {
 //
 // This method is safe to call concurrently.
 func (mp *MeterProvider) Meter(name string, options ...metric.MeterOption) metric.Meter {
+	return mp.getMeter(name, nil, options)
+}
+
+// MeterWithResource returns a Meter like Meter, whose data is
+// associated with the MeterProvider's Resource merged with the
+// additional resource attributes.  Where the keys conflict, the
+// attributes given here take precedence.  This allows components
+// sharing one MeterProvider to report distinct resource
+// attributes, see data.Scope.Resource.
+//
+// Meters with the same name and options but different resource
+// attributes are distinct.
+func (mp *MeterProvider) MeterWithResource(name string, attrs []attribute.KeyValue, options ...metric.MeterOption) metric.Meter {
+	if len(attrs) == 0 {
+		return mp.getMeter(name, nil, options)
+	}
+	// Merging schemaless attributes does not fail.
+	res, _ := resource.Merge(mp.cfg.res, resource.NewSchemaless(attrs...))
+	return mp.getMeter(name, res, options)
+}
+
+// getMeter returns the meter for the scope described by name and
+// options, with an optional Resource.
+func (mp *MeterProvider) getMeter(name string, res *resource.Resource, options []metric.MeterOption) metric.Meter {
 	cfg := metric.NewMeterConfig(options...)
 	lib := instrumentation.Library{
 		Name:      name,
 		Version:   cfg.InstrumentationVersion(),
 		SchemaURL: cfg.SchemaURL(),
 	}
+	key := meterKey{
+		library: lib,
+	}
+	if res != nil {
+		key.resource = res.Equivalent()
+	}
 
 	mp.lock.Lock()
 	defer mp.lock.Unlock()
 
-	m := mp.meters[lib]
+	m := mp.meters[key]
 	if m != nil {
 		return m
 	}
 	m = &meter{
 		provider:  mp,
 		library:   lib,
+		resource:  res,
 		byDesc:    map[sdkinstrument.Descriptor]interface{}{},
 		compilers: pipeline.NewRegister[*viewstate.Compiler](len(mp.cfg.readers)),
 	}
 	for pipe := range m.compilers {
 		m.compilers[pipe] = viewstate.New(lib, mp.cfg.views[pipe])
+		m.compilers[pipe].SetClock(mp.cfg.clock)
+		m.compilers[pipe].SetDescriptorProcessor(mp.cfg.descriptorProcessor)
+
+		for _, clause := range mp.added[pipe] {
+			// Errors were reported by AddViewClause.
+			_, _ = m.compilers[pipe].AddClause(clause)
+		}
 	}
 	mp.ordered = append(mp.ordered, m)
-	mp.meters[lib] = m
+	mp.meters[key] = m
 	return m
 }
 
@@ -157,6 +254,10 @@ func (mp *MeterProvider) Shutdown(ctx context.Context) error {
 		return ErrAlreadyShutdown
 	}
 
+	if mp.ingest != nil {
+		mp.ingest.Stop()
+	}
+
 	for _, r := range mp.cfg.readers {
 		err = multierr.Append(err, r.Shutdown(ctx))
 	}
@@ -164,6 +265,97 @@ func (mp *MeterProvider) Shutdown(ctx context.Context) error {
 	return err
 }
 
+// MemorySize estimates the number of bytes of aggregator state held
+// by this MeterProvider for all readers, including attribute sets
+// and histogram buckets.  Records held by synchronous instruments
+// between collections are not included.  This is meant for enforcing
+// a memory budget, e.g., refusing to create new instruments when the
+// estimate is too large.
+func (mp *MeterProvider) MemorySize() int {
+	size := 0
+	for _, m := range mp.getOrdered() {
+		for _, comp := range m.compilers {
+			size += comp.MemorySize()
+		}
+	}
+	return size
+}
+
+// AddViewClause adds a view clause to the views of Reader r, following
+// the clauses it was configured with.  Existing instruments that the
+// clause matches are recompiled and use the new outputs for
+// subsequent measurements; outputs that the clause leaves unchanged
+// keep their state, and other outputs start empty.  Meters created
+// later use the clause as well.  Synchronous instruments that every
+// view dropped when they were created are not affected.
+//
+// The clause is validated as for WithReader, and the returned error
+// combines its corrections with conflicts while recompiling.
+func (mp *MeterProvider) AddViewClause(r Reader, clause view.ClauseConfig) error {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+
+	if mp.meters == nil {
+		return ErrAlreadyShutdown
+	}
+	var pipes []int
+	for pipe, reader := range mp.cfg.readers {
+		if reader == r {
+			pipes = append(pipes, pipe)
+		}
+	}
+	if pipes == nil {
+		return ErrUnknownReader
+	}
+
+	valid, err := view.Validate(&view.Views{
+		Name: r.String(),
+		Config: view.Config{
+			Clauses:  []view.ClauseConfig{clause},
+			Defaults: mp.cfg.views[pipes[0]].Defaults,
+		},
+	})
+	clause = valid.Clauses[0]
+
+	for _, pipe := range pipes {
+		mp.added[pipe] = append(mp.added[pipe], clause)
+	}
+	for _, m := range mp.ordered {
+		err = multierr.Append(err, m.addClause(pipes, clause))
+	}
+	return err
+}
+
+// SnapshotAll takes a snapshot of the pending updates of every
+// synchronous instrument without processing them, so that the next
+// collection reports the data as of this call for all instruments.
+// Because processing is deferred, the snapshots are taken close
+// together, which improves consistency across instruments compared
+// with a collection that snapshots and processes each instrument in
+// turn.  Updates after this call are reported by the following
+// collection.  Asynchronous instruments are not affected.
+func (mp *MeterProvider) SnapshotAll() {
+	mp.flushIngest()
+
+	for _, m := range mp.getOrdered() {
+		m.lock.Lock()
+		insts := m.syncInsts
+		m.lock.Unlock()
+
+		for _, inst := range insts {
+			inst.Snapshot()
+		}
+	}
+}
+
+// flushIngest aggregates the measurements waiting in the ingest
+// queue, if configured by WithAsyncIngest.
+func (mp *MeterProvider) flushIngest() {
+	if mp.ingest != nil {
+		mp.ingest.Flush()
+	}
+}
+
 // getOrdered returns meters in the order they were registered.
 func (mp *MeterProvider) getOrdered() []*meter {
 	mp.lock.Lock()
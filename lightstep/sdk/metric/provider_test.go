@@ -18,18 +18,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/gauge"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/test"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/viewstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
 	"go.opentelemetry.io/otel/sdk/resource"
 )
 
@@ -301,3 +308,664 @@ func TestDuplicateInstrumentConflict(t *testing.T) {
 	require.Equal(t, 1, len(*errs))
 	require.True(t, errors.Is((*errs)[0], viewstate.ViewConflictsError{}))
 }
+
+func TestProduceContextCanceled(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	res := resource.Empty()
+	provider := NewMeterProvider(WithReader(rdr), WithResource(res))
+
+	meter := provider.Meter("test")
+	c1 := must(meter.SyncInt64().Counter("c1"))
+	c2 := must(meter.SyncInt64().Counter("c2"))
+
+	c1.Add(ctx, 1)
+	c2.Add(ctx, 2)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	var output data.Metrics
+	output, err := rdr.Producer.(ContextProducer).ProduceContext(canceled, &output)
+
+	var partial *PartialCollectionError
+	require.ErrorAs(t, err, &partial)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []string{"c1", "c2"}, partial.Uncollected)
+	require.Contains(t, err.Error(), "c1, c2")
+
+	// The uncollected data is retained.
+	output, err = rdr.Producer.(ContextProducer).ProduceContext(ctx, &output)
+	require.NoError(t, err)
+
+	var notime time.Time
+	const cumulative = aggregation.CumulativeTemporality
+
+	test.RequireEqualResourceMetrics(
+		t, output, res,
+		test.Scope(
+			test.Library("test"),
+			test.Instrument(
+				test.Descriptor("c1", sdkinstrument.SyncCounter, number.Int64Kind),
+				test.Point(notime, notime, sum.NewMonotonicInt64(1), cumulative),
+			),
+			test.Instrument(
+				test.Descriptor("c2", sdkinstrument.SyncCounter, number.Int64Kind),
+				test.Point(notime, notime, sum.NewMonotonicInt64(2), cumulative),
+			),
+		),
+	)
+}
+
+func TestSelfObservability(t *testing.T) {
+	ctx := context.Background()
+	selfRdr := NewManualReader("self")
+	selfProvider := NewMeterProvider(WithResource(resource.Empty()), WithReader(selfRdr))
+
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(rdr),
+		WithSelfObservability(selfProvider.Meter("sdk")),
+	)
+
+	ctr := must(provider.Meter("test").SyncInt64().Counter("hello"))
+	ctr.Add(ctx, 1)
+
+	_ = rdr.Produce(nil)
+	_ = rdr.Produce(nil)
+
+	self := selfRdr.Produce(nil)
+	require.Equal(t, 1, len(self.Scopes))
+
+	insts := self.Scopes[0].Instruments
+	if syncstate.LockStatsEnabled {
+		require.Equal(t, 4, len(insts))
+		require.Equal(t, lockWaitsName, insts[2].Descriptor.Name)
+		require.Equal(t, lockWaitTimeName, insts[3].Descriptor.Name)
+		insts = insts[:2]
+	}
+	require.Equal(t, 2, len(insts))
+	require.Equal(t, snapshotDurationName, insts[0].Descriptor.Name)
+	require.Equal(t, collectDurationName, insts[1].Descriptor.Name)
+
+	for _, inst := range insts {
+		require.Equal(t, 1, len(inst.Points))
+
+		point := inst.Points[0]
+		require.Equal(t, attribute.NewSet(readerKey.String("test")), point.Attributes)
+		require.Equal(t, uint64(2), point.Aggregation.(aggregation.Histogram).Count())
+	}
+}
+
+func TestCardinalityEstimation(t *testing.T) {
+	ctx := context.Background()
+	selfRdr := NewManualReader("self")
+	selfProvider := NewMeterProvider(WithResource(resource.Empty()), WithReader(selfRdr))
+
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(rdr),
+		WithSelfObservability(selfProvider.Meter("sdk")),
+		WithCardinalityEstimation(true),
+	)
+
+	ctr := must(provider.Meter("test").SyncInt64().Counter("hello"))
+	for i := 0; i < 1000; i++ {
+		ctr.Add(ctx, 1, attribute.Int("i", i))
+		ctr.Add(ctx, 1, attribute.Int("i", i))
+	}
+	_ = rdr.Produce(nil)
+
+	var estimate int64
+	for _, inst := range selfRdr.Produce(nil).Scopes[0].Instruments {
+		if inst.Descriptor.Name != cardinalityName {
+			continue
+		}
+		require.Equal(t, 1, len(inst.Points))
+		require.Equal(t, attribute.NewSet(
+			scopeKey.String("test"),
+			instrumentKey.String("hello"),
+		), inst.Points[0].Attributes)
+		estimate = number.ToInt64(inst.Points[0].Aggregation.(aggregation.Gauge).Gauge())
+	}
+	require.InDelta(t, 1000, estimate, 100)
+}
+
+func TestBypassFilter(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(rdr,
+			view.WithClause(
+				view.MatchInstrumentName("dropped"),
+				view.WithAggregation(aggregation.DropKind),
+			),
+			view.WithClause(
+				view.MatchInstrumentNameRegexp(regexp.MustCompile("^(filtered|bypassed)$")),
+				view.WithKeys([]attribute.Key{"a"}),
+			),
+		),
+	)
+	meter := provider.Meter("test")
+
+	filtered := must(meter.SyncInt64().Counter("filtered"))
+	bypassed := must(meter.SyncInt64().Counter("bypassed", sdkinstrument.WithBypassFilter(), instrument.WithUnit("1")))
+	dropped := must(meter.SyncInt64().Counter("dropped", sdkinstrument.WithBypassFilter()))
+
+	attrs := []attribute.KeyValue{attribute.String("a", "1"), attribute.String("b", "2")}
+	filtered.Add(ctx, 1, attrs...)
+	bypassed.Add(ctx, 2, attrs...)
+	dropped.Add(ctx, 3, attrs...)
+
+	test.RequireEqualMetrics(t,
+		rdr.Produce(nil).Scopes[0].Instruments,
+		test.Instrument(
+			test.Descriptor("filtered", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(time.Time{}, time.Time{}, sum.NewMonotonicInt64(1), aggregation.CumulativeTemporality, attrs[0]),
+		),
+		test.Instrument(
+			test.Descriptor("bypassed", sdkinstrument.SyncCounter, number.Int64Kind, instrument.WithUnit("1")),
+			test.Point(time.Time{}, time.Time{}, sum.NewMonotonicInt64(2), aggregation.CumulativeTemporality, attrs...),
+		),
+	)
+}
+
+func TestAddViewClause(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(WithResource(resource.Empty()), WithReader(rdr))
+	meter := provider.Meter("test")
+
+	changed := must(meter.SyncInt64().Counter("changed"))
+	unchanged := must(meter.SyncInt64().Counter("unchanged"))
+	observed := must(meter.AsyncInt64().Gauge("observed"))
+	attr := attribute.String("k", "v")
+	require.NoError(t, meter.RegisterCallback([]instrument.Asynchronous{observed}, func(ctx context.Context) {
+		observed.Observe(ctx, 1, attr)
+	}))
+
+	changed.Add(ctx, 1, attr)
+	unchanged.Add(ctx, 1, attr)
+
+	require.NoError(t, provider.AddViewClause(rdr, view.NewClause(
+		view.MatchInstrumentName("unchanged"),
+		view.WithName("unchanged"),
+	)))
+	require.NoError(t, provider.AddViewClause(rdr, view.NewClause(
+		view.MatchInstrumentNameRegexp(regexp.MustCompile("^(changed|observed)$")),
+		view.WithKeys([]attribute.Key{}),
+	)))
+
+	// Subsequent measurements use the recompiled instruments.
+	changed.Add(ctx, 5, attr)
+	unchanged.Add(ctx, 10, attr)
+
+	// Meters created later use the clauses as well.
+	other := must(provider.Meter("other").SyncInt64().Counter("changed"))
+	other.Add(ctx, 3, attr)
+
+	output := rdr.Produce(nil)
+	test.RequireEqualMetrics(t,
+		output.Scopes[0].Instruments,
+		test.Instrument(
+			test.Descriptor("unchanged", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(time.Time{}, time.Time{}, sum.NewMonotonicInt64(11), aggregation.CumulativeTemporality, attr),
+		),
+		test.Instrument(
+			test.Descriptor("changed", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(time.Time{}, time.Time{}, sum.NewMonotonicInt64(5), aggregation.CumulativeTemporality),
+		),
+		test.Instrument(
+			test.Descriptor("observed", sdkinstrument.AsyncGauge, number.Int64Kind),
+			test.Point(time.Time{}, time.Time{}, gauge.NewInt64(1), aggregation.CumulativeTemporality),
+		),
+	)
+	test.RequireEqualMetrics(t,
+		output.Scopes[1].Instruments,
+		test.Instrument(
+			test.Descriptor("changed", sdkinstrument.SyncCounter, number.Int64Kind),
+			test.Point(time.Time{}, time.Time{}, sum.NewMonotonicInt64(3), aggregation.CumulativeTemporality),
+		),
+	)
+
+	// An invalid clause is corrected.
+	require.Error(t, provider.AddViewClause(rdr, view.NewClause(
+		view.MatchInstrumentName("changed"),
+		view.WithKeys([]attribute.Key{""}),
+	)))
+
+	require.ErrorIs(t, provider.AddViewClause(NewManualReader("other"), view.NewClause()), ErrUnknownReader)
+
+	require.NoError(t, provider.Shutdown(ctx))
+	require.ErrorIs(t, provider.AddViewClause(rdr, view.NewClause()), ErrAlreadyShutdown)
+}
+
+func TestProduceChanged(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(WithResource(resource.Empty()), WithReader(rdr))
+
+	ctr := must(provider.Meter("test").SyncInt64().Counter("hello"))
+	gauge := must(provider.Meter("test").AsyncInt64().Gauge("observed"))
+	require.NoError(t, provider.Meter("test").RegisterCallback([]instrument.Asynchronous{gauge}, func(ctx context.Context) {
+		gauge.Observe(ctx, 1)
+	}))
+
+	producer := rdr.Producer.(ChangedProducer)
+	changed := func() []data.Instrument {
+		output, err := producer.ProduceChanged(ctx, nil)
+		require.NoError(t, err)
+		return output.Scopes[0].Instruments
+	}
+	points := func(insts []data.Instrument) (names []string) {
+		for _, inst := range insts {
+			for _, pt := range inst.Points {
+				val, _ := pt.Attributes.Value("k")
+				names = append(names, inst.Descriptor.Name+"/"+val.AsString())
+			}
+		}
+		return names
+	}
+
+	ctr.Add(ctx, 1, attribute.String("k", "a"))
+	ctr.Add(ctx, 1, attribute.String("k", "b"))
+	require.ElementsMatch(t, []string{"hello/a", "hello/b", "observed/"}, points(changed()))
+
+	// Asynchronous instruments are observed by each collection.
+	ctr.Add(ctx, 1, attribute.String("k", "b"))
+	require.ElementsMatch(t, []string{"hello/b", "observed/"}, points(changed()))
+
+	// Produce outputs every point.
+	require.ElementsMatch(t, []string{"hello/a", "hello/b", "observed/"}, points(rdr.Produce(nil).Scopes[0].Instruments))
+}
+
+func TestSnapshotAll(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(WithResource(resource.Empty()), WithReader(rdr))
+
+	requests := must(provider.Meter("a").SyncInt64().Counter("requests"))
+	failures := must(provider.Meter("b").SyncInt64().Counter("failures"))
+
+	totals := func() map[string]int64 {
+		r := map[string]int64{}
+		for _, scope := range rdr.Produce(nil).Scopes {
+			for _, inst := range scope.Instruments {
+				for _, pt := range inst.Points {
+					r[inst.Descriptor.Name] = number.ToInt64(pt.Aggregation.(aggregation.Sum).Sum())
+				}
+			}
+		}
+		return r
+	}
+
+	requests.Add(ctx, 10)
+	failures.Add(ctx, 1)
+
+	provider.SnapshotAll()
+
+	// Updates after the snapshot are not in the next collection.
+	requests.Add(ctx, 5)
+	failures.Add(ctx, 2)
+	require.Equal(t, map[string]int64{"requests": 10, "failures": 1}, totals())
+
+	// They are in the following collection.
+	require.Equal(t, map[string]int64{"requests": 15, "failures": 3}, totals())
+
+	// A second snapshot before collection loses nothing.
+	requests.Add(ctx, 1)
+	provider.SnapshotAll()
+	requests.Add(ctx, 1)
+	provider.SnapshotAll()
+	require.Equal(t, map[string]int64{"requests": 17, "failures": 3}, totals())
+}
+
+func TestAsyncIngest(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(WithResource(resource.Empty()), WithReader(rdr), WithAsyncIngest(16))
+
+	requests := must(provider.Meter("test").SyncInt64().Counter("requests"))
+
+	total := func() int64 {
+		var r int64
+		for _, scope := range rdr.Produce(nil).Scopes {
+			for _, inst := range scope.Instruments {
+				for _, pt := range inst.Points {
+					r += number.ToInt64(pt.Aggregation.(aggregation.Sum).Sum())
+				}
+			}
+		}
+		return r
+	}
+
+	// Collection includes the measurements waiting in the queue.
+	for i := 0; i < 10; i++ {
+		requests.Add(ctx, 1)
+	}
+	require.Equal(t, int64(10), total())
+
+	require.NoError(t, provider.Shutdown(ctx))
+
+	// After Shutdown, measurements are aggregated synchronously.
+	requests.Add(ctx, 1)
+	require.Equal(t, int64(11), total())
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	clock := &testClock{now: time.Unix(100, 0)}
+
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithClock(clock),
+		WithReader(rdr,
+			view.WithDefaultAggregationTemporalitySelector(view.DeltaPreferredTemporality),
+			view.WithClause(
+				view.MatchInstrumentName("gauge"),
+				view.WithAggregatorConfig(aggregator.Config{
+					GaugeTimestamps: true,
+				}),
+			),
+		),
+	)
+
+	m := provider.Meter("test")
+	ctr := must(m.SyncInt64().Counter("counter"))
+	gauge := must(m.(*meter).Float64Gauge("gauge"))
+
+	clock.now = time.Unix(101, 0)
+	ctr.Add(ctx, 1)
+	gauge.Record(ctx, 10)
+
+	clock.now = time.Unix(102, 0)
+	output := rdr.Produce(nil)
+
+	require.Equal(t, 1, len(output.Scopes))
+	insts := output.Scopes[0].Instruments
+	require.Equal(t, 2, len(insts))
+
+	for _, inst := range insts {
+		require.Equal(t, 1, len(inst.Points))
+		require.Equal(t, time.Unix(100, 0), inst.Points[0].Start)
+		require.Equal(t, time.Unix(102, 0), inst.Points[0].End)
+	}
+	gpoint := insts[1].Points[0]
+	require.Equal(t, time.Unix(101, 0), gpoint.Aggregation.(aggregation.TimestampedGauge).LastUpdateTime())
+
+	// The next delta interval starts at the previous collection.
+	ctr.Add(ctx, 1)
+	clock.now = time.Unix(105, 0)
+	output = rdr.Produce(nil)
+
+	cpoint := output.Scopes[0].Instruments[0].Points[0]
+	require.Equal(t, time.Unix(102, 0), cpoint.Start)
+	require.Equal(t, time.Unix(105, 0), cpoint.End)
+}
+
+func TestAttributeInterning(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(rdr),
+		WithAttributeInterning(true),
+	)
+	m := provider.Meter("test")
+	attr := attribute.String("K", "V")
+
+	for _, name := range []string{"a", "b"} {
+		ctr := must(m.SyncInt64().Counter(name))
+		ctr.Add(ctx, 1, attr)
+	}
+	require.Equal(t, 1, m.(*meter).InternPool().Len())
+
+	output := rdr.Produce(nil)
+	for _, inst := range output.Scopes[0].Instruments {
+		require.Equal(t, 1, len(inst.Points))
+		require.Equal(t, attribute.NewSet(attr), inst.Points[0].Attributes)
+	}
+
+	// Without updates, the second collection removes the records.
+	_ = rdr.Produce(nil)
+	require.Equal(t, 0, m.(*meter).InternPool().Len())
+}
+
+func TestCallbackTimeout(t *testing.T) {
+	errs := test.OTelErrors()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(rdr),
+		WithCallbackTimeout(10*time.Millisecond),
+		WithCallbackConcurrency(2),
+	)
+	m := provider.Meter("test")
+	g := must(m.AsyncInt64().Gauge("g"))
+
+	var release chan struct{}
+	value := int64(5)
+	require.NoError(t, m.RegisterCallback([]instrument.Asynchronous{g}, func(ctx context.Context) {
+		g.Observe(ctx, value)
+		if release != nil {
+			<-release
+		}
+	}))
+
+	observed := func() int64 {
+		output := rdr.Produce(nil)
+		return number.ToInt64(output.Scopes[0].Instruments[0].Points[0].Aggregation.(*gauge.Int64).Gauge())
+	}
+
+	require.Equal(t, int64(5), observed())
+	require.Nil(t, *errs)
+
+	// The blocked callback is abandoned, its last value repeats.
+	value = 6
+	release = make(chan struct{})
+	require.Equal(t, int64(5), observed())
+	close(release)
+
+	require.Equal(t, 1, len(*errs))
+	require.ErrorIs(t, (*errs)[0], context.DeadlineExceeded)
+}
+
+func TestMaxInstruments(t *testing.T) {
+	ctx := context.Background()
+	errs := test.OTelErrors()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(rdr),
+		WithMaxInstruments(2),
+	)
+	m1 := provider.Meter("one")
+	m2 := provider.Meter("two")
+
+	ctr := must(m1.SyncInt64().Counter("a"))
+	_ = must(m2.SyncInt64().Counter("b"))
+
+	// Past the limit, in either meter, new instruments are no-ops.
+	hist, err := m1.SyncFloat64().Histogram("c")
+	require.ErrorIs(t, err, ErrTooManyInstruments)
+	require.Equal(t, syncstate.Noop[float64]{}, hist)
+
+	gauge, err := m2.AsyncInt64().Gauge("d")
+	require.ErrorIs(t, err, ErrTooManyInstruments)
+	require.NoError(t, m2.RegisterCallback([]instrument.Asynchronous{gauge}, func(ctx context.Context) {
+		gauge.Observe(ctx, 1)
+	}))
+
+	// Existing instruments are returned without error.
+	ctr2, err := m1.SyncInt64().Counter("a")
+	require.NoError(t, err)
+	require.Equal(t, ctr, ctr2)
+
+	ctr.Add(ctx, 1)
+	hist.Record(ctx, 1)
+
+	output := rdr.Produce(nil)
+	require.Equal(t, 2, len(output.Scopes))
+	require.Equal(t, 1, len(output.Scopes[0].Instruments))
+	require.Equal(t, "a", output.Scopes[0].Instruments[0].Descriptor.Name)
+	require.Equal(t, 1, len(output.Scopes[1].Instruments))
+	require.Equal(t, "b", output.Scopes[1].Instruments[0].Descriptor.Name)
+
+	require.Equal(t, 2, len(*errs))
+	require.ErrorIs(t, (*errs)[0], ErrTooManyInstruments)
+}
+
+// streamedPoint is a point recorded by testVisitor.
+type streamedPoint struct {
+	scope string
+	name  string
+	attrs attribute.Distinct
+	value int64
+}
+
+// testVisitor records the sums of streamed points.
+type testVisitor struct {
+	scope  string
+	name   string
+	points []streamedPoint
+}
+
+func (tv *testVisitor) VisitScope(_ *resource.Resource, library instrumentation.Library) {
+	tv.scope = library.Name
+}
+
+func (tv *testVisitor) VisitInstrument(desc sdkinstrument.Descriptor, _ int) {
+	tv.name = desc.Name
+}
+
+func (tv *testVisitor) VisitPoint(pt data.Point) error {
+	tv.points = append(tv.points, streamedPoint{
+		scope: tv.scope,
+		name:  tv.name,
+		attrs: pt.Attributes.Equivalent(),
+		value: number.ToInt64(pt.Aggregation.(aggregation.Sum).Sum()),
+	})
+	return nil
+}
+
+func TestProduceStream(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(rdr,
+			view.WithClause(
+				view.MatchInstrumentName("merged"),
+				view.WithPointProcessor(func(pt *data.Point) {
+					pt.Attributes = *attribute.EmptySet()
+				}),
+			),
+		),
+	)
+
+	for _, name := range []string{"a", "b"} {
+		m := provider.Meter(name)
+		for _, inst := range []string{"plain", "merged"} {
+			ctr := must(m.SyncInt64().Counter(inst))
+			ctr.Add(ctx, 1, attribute.String("K", "V"))
+			ctr.Add(ctx, 2, attribute.String("K", "W"))
+		}
+	}
+
+	var visitor testVisitor
+	require.NoError(t, rdr.Producer.(StreamProducer).ProduceStream(ctx, &visitor))
+
+	// The stream matches the produced data, including the
+	// point processor.
+	var expect []streamedPoint
+	for _, scope := range rdr.Produce(nil).Scopes {
+		for _, inst := range scope.Instruments {
+			for _, pt := range inst.Points {
+				expect = append(expect, streamedPoint{
+					scope: scope.Library.Name,
+					name:  inst.Descriptor.Name,
+					attrs: pt.Attributes.Equivalent(),
+					value: number.ToInt64(pt.Aggregation.(aggregation.Sum).Sum()),
+				})
+			}
+		}
+	}
+	require.Equal(t, 6, len(expect))
+	require.ElementsMatch(t, expect, visitor.points)
+}
+
+func TestMemorySize(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(WithResource(resource.Empty()), WithReader(rdr))
+	require.Equal(t, 0, provider.MemorySize())
+
+	ctr := must(provider.Meter("test").SyncInt64().Counter("c"))
+	ctr.Add(ctx, 1, attribute.String("K", "V"))
+	_ = rdr.Produce(nil)
+
+	size := provider.MemorySize()
+	require.Less(t, 0, size)
+
+	ctr.Add(ctx, 1, attribute.String("K", "W"))
+	_ = rdr.Produce(nil)
+	require.Equal(t, 2*size, provider.MemorySize())
+}
+
+func TestMeterWithResource(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "svc"),
+			attribute.String("component", "none"),
+		)),
+		WithReader(rdr),
+	)
+
+	plain := provider.Meter("test")
+	tenantA := provider.MeterWithResource("test", []attribute.KeyValue{attribute.String("component", "a")})
+	tenantB := provider.MeterWithResource("test", []attribute.KeyValue{attribute.String("component", "b")})
+
+	// Meters are distinct by resource; equal attributes yield
+	// the same meter and none yields the plain meter.
+	require.NotEqual(t, plain, tenantA)
+	require.NotEqual(t, tenantA, tenantB)
+	require.Equal(t, tenantA, provider.MeterWithResource("test", []attribute.KeyValue{attribute.String("component", "a")}))
+	require.Equal(t, plain, provider.MeterWithResource("test", nil))
+
+	must(plain.SyncInt64().Counter("c")).Add(ctx, 1)
+	must(tenantA.SyncInt64().Counter("c")).Add(ctx, 2)
+	must(tenantB.SyncInt64().Counter("c")).Add(ctx, 3)
+
+	output := rdr.Produce(nil)
+	require.Equal(t, 3, len(output.Scopes))
+	require.Nil(t, output.Scopes[0].Resource)
+
+	// The meter's attributes override the provider's.
+	expectA := resource.NewSchemaless(attribute.String("service.name", "svc"), attribute.String("component", "a"))
+	expectB := resource.NewSchemaless(attribute.String("service.name", "svc"), attribute.String("component", "b"))
+	require.Equal(t, expectA.Equivalent(), output.Scopes[1].Resource.Equivalent())
+	require.Equal(t, expectB.Equivalent(), output.Scopes[2].Resource.Equivalent())
+
+	groups := output.ByResource()
+	require.Equal(t, 3, len(groups))
+	for i, group := range groups {
+		require.Equal(t, 1, len(group.Scopes))
+		require.Equal(t, int64(i+1), number.ToInt64(group.Scopes[0].Instruments[0].Points[0].Aggregation.(aggregation.Sum).Sum()))
+	}
+	require.Equal(t, output.Resource, groups[0].Resource)
+	require.Equal(t, expectA.Equivalent(), groups[1].Resource.Equivalent())
+}
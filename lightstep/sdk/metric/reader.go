@@ -16,6 +16,8 @@ package metric // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/me
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 )
@@ -63,3 +65,66 @@ type Producer interface {
 	// When `in` is nil, a new Metrics object is returned.
 	Produce(in *data.Metrics) data.Metrics
 }
+
+// ContextProducer is a Producer that supports cancellation.  The
+// Producer passed to Register() implements this interface.
+type ContextProducer interface {
+	Producer
+
+	// ProduceContext is Produce with a Context that is checked
+	// between instruments.  When the Context is done before
+	// every instrument is collected, the partial collection is
+	// returned along with a *PartialCollectionError.
+	ProduceContext(ctx context.Context, in *data.Metrics) (data.Metrics, error)
+}
+
+// StreamProducer is a ContextProducer that passes the result of a
+// collection to a data.Visitor, one point at a time, instead of
+// building a data.Metrics.  The Producer passed to Register()
+// implements this interface.
+type StreamProducer interface {
+	ContextProducer
+
+	// ProduceStream runs a collection, passing each point to the
+	// visitor.  When the Context is done before every instrument
+	// is collected, a *PartialCollectionError is returned after
+	// visiting the instruments that were collected.  When the
+	// visitor returns an error, collection stops and the error is
+	// returned.
+	ProduceStream(ctx context.Context, visitor data.Visitor) error
+}
+
+// ChangedProducer is a ContextProducer that can omit the points that
+// were not updated since the previous collection, e.g., to stream the
+// changes of cumulative data to a debugging interface.  The Producer
+// passed to Register() implements this interface.
+type ChangedProducer interface {
+	ContextProducer
+
+	// ProduceChanged is ProduceContext, except that the
+	// cumulative points of synchronous instruments that were
+	// not updated since the previous collection by this
+	// Producer are omitted.  Instruments without any points
+	// are still included.
+	ProduceChanged(ctx context.Context, in *data.Metrics) (data.Metrics, error)
+}
+
+// PartialCollectionError is returned by a collection that was
+// interrupted by its Context.
+type PartialCollectionError struct {
+	// Uncollected lists the names of instruments that were not
+	// collected.  Their data is retained for the next collection.
+	Uncollected []string
+
+	// Err is the error returned by the Context.
+	Err error
+}
+
+func (e *PartialCollectionError) Error() string {
+	return fmt.Sprintf("partial collection: %v: %d instrument(s) not collected: %s",
+		e.Err, len(e.Uncollected), strings.Join(e.Uncollected, ", "))
+}
+
+func (e *PartialCollectionError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,64 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdkinstrument
+
+import (
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// bypassFilter is the option returned by WithBypassFilter.  Since
+// instrument.Option has an unexported method, it embeds a nil
+// Option, and the SDK removes it (see BypassFilter) before applying
+// the other options.
+type bypassFilter struct {
+	instrument.Option
+}
+
+// WithBypassFilter returns an instrument option that makes a
+// synchronous instrument use the attributes of each measurement as
+// the attribute set of its output, without applying the attribute
+// filters of the views: the keys, key patterns, renames, value
+// length limits, and context attributes they configure.  Views that
+// drop the instrument, cardinality limits, and attribute-set TTLs
+// still apply.
+//
+// This is a performance escape hatch for high-frequency instruments
+// whose callers know the exact attribute sets to output.  When a
+// view would have changed the attributes, the bypassed instrument
+// outputs them unchanged, so views that filter attributes to limit
+// cardinality have no effect.
+//
+// The option applies when the instrument is created; an instrument
+// registered again with the same descriptor keeps its original
+// behavior.  Only this SDK recognizes the option, which must not be
+// passed to other implementations of the metrics API.
+func WithBypassFilter() instrument.Option {
+	return bypassFilter{}
+}
+
+// BypassFilter returns the options without WithBypassFilter and
+// whether it was present.
+func BypassFilter(opts []instrument.Option) ([]instrument.Option, bool) {
+	bypass := false
+	rest := make([]instrument.Option, 0, len(opts))
+	for _, opt := range opts {
+		if _, ok := opt.(bypassFilter); ok {
+			bypass = true
+			continue
+		}
+		rest = append(rest, opt)
+	}
+	return rest, bypass
+}
@@ -15,7 +15,10 @@
 package sdkinstrument
 
 import (
+	"fmt"
+
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.opentelemetry.io/otel/metric/instrument"
 	"go.opentelemetry.io/otel/metric/unit"
 )
 
@@ -51,3 +54,32 @@ func NewDescriptor(name string, ikind Kind, nkind number.Kind, description strin
 		Unit:        unit,
 	}
 }
+
+// BuildDescriptor returns a Descriptor configured by the instrument
+// options, e.g., instrument.WithDescription and instrument.WithUnit,
+// for use outside the meter API, e.g., to set up a pipeline by hand.
+// Unlike NewDescriptor, this returns an error when the name is empty,
+// the instrument or number kind is not defined, or the number kind
+// does not suit the instrument kind: uint64 up-down counters cannot
+// decrease.  Options that only the SDK recognizes, e.g.,
+// WithBypassFilter, are ignored.
+func BuildDescriptor(name string, ikind Kind, nkind number.Kind, opts ...instrument.Option) (Descriptor, error) {
+	if name == "" {
+		return Descriptor{}, fmt.Errorf("empty instrument name")
+	}
+	if ikind < 0 || ikind >= NumKinds {
+		return Descriptor{}, fmt.Errorf("invalid instrument kind: %v", ikind)
+	}
+	switch nkind {
+	case number.Int64Kind, number.Float64Kind:
+	case number.Uint64Kind:
+		if ikind == SyncUpDownCounter || ikind == AsyncUpDownCounter {
+			return Descriptor{}, fmt.Errorf("%v instrument does not support %v", ikind, nkind)
+		}
+	default:
+		return Descriptor{}, fmt.Errorf("invalid number kind: %v", nkind)
+	}
+	opts, _ = BypassFilter(opts)
+	cfg := instrument.NewConfig(opts...)
+	return NewDescriptor(name, ikind, nkind, cfg.Description(), cfg.Unit()), nil
+}
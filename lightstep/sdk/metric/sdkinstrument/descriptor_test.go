@@ -0,0 +1,62 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdkinstrument
+
+import (
+	"testing"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+func TestBuildDescriptor(t *testing.T) {
+	desc, err := BuildDescriptor(
+		"latency",
+		SyncHistogram,
+		number.Float64Kind,
+		instrument.WithDescription("Request latency"),
+		instrument.WithUnit(unit.Milliseconds),
+		WithBypassFilter(),
+	)
+	require.NoError(t, err)
+	require.Equal(t, NewDescriptor("latency", SyncHistogram, number.Float64Kind, "Request latency", unit.Milliseconds), desc)
+
+	desc, err = BuildDescriptor("count", AsyncCounter, number.Uint64Kind)
+	require.NoError(t, err)
+	require.Equal(t, NewDescriptor("count", AsyncCounter, number.Uint64Kind, "", ""), desc)
+}
+
+func TestBuildDescriptorInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		ikind  Kind
+		nkind  number.Kind
+		expect string
+	}{
+		{"", SyncCounter, number.Int64Kind, "empty instrument name"},
+		{"x", NumKinds, number.Int64Kind, "invalid instrument kind"},
+		{"x", -1, number.Int64Kind, "invalid instrument kind"},
+		{"x", SyncCounter, number.Kind(-1), "invalid number kind"},
+		{"x", SyncUpDownCounter, number.Uint64Kind, "SyncUpDownCounter instrument does not support Uint64Kind"},
+		{"x", AsyncUpDownCounter, number.Uint64Kind, "AsyncUpDownCounter instrument does not support Uint64Kind"},
+	} {
+		desc, err := BuildDescriptor(test.name, test.ikind, test.nkind)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), test.expect)
+		require.Equal(t, Descriptor{}, desc)
+	}
+}
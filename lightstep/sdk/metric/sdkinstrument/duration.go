@@ -0,0 +1,56 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdkinstrument
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+// DurationHistogram is implemented by the SDK's synchronous
+// Histogram instruments, for callers that measure latency.  The
+// duration is converted to the unit of the instrument.  For example:
+//
+//	hist.(sdkinstrument.DurationHistogram).RecordDuration(ctx, time.Since(start), attrs...)
+type DurationHistogram interface {
+	// RecordDuration is equivalent to Record() with the duration
+	// converted by DurationValue() to the instrument's unit.
+	// Integer histograms truncate toward zero.
+	RecordDuration(ctx context.Context, d time.Duration, attrs ...attribute.KeyValue)
+}
+
+// DurationValue converts d to a value in the given unit, which is
+// one of the UCUM time units "ns", "us", "ms", "s", "min", or "h".
+// Other units, including the empty unit, use seconds as recommended
+// by the OpenTelemetry semantic conventions.
+func DurationValue(u unit.Unit, d time.Duration) float64 {
+	switch u {
+	case "ns":
+		return float64(d)
+	case "us":
+		return float64(d) / float64(time.Microsecond)
+	case unit.Milliseconds:
+		return float64(d) / float64(time.Millisecond)
+	case "min":
+		return d.Minutes()
+	case "h":
+		return d.Hours()
+	default:
+		return d.Seconds()
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdkinstrument
+
+// Enabler is implemented by the SDK's synchronous instruments, for
+// callers that pause recording an instrument without removing it,
+// e.g., during a maintenance window.  For example:
+//
+//	cntr.(sdkinstrument.Enabler).SetEnabled(false)
+type Enabler interface {
+	// SetEnabled pauses or resumes the instrument.  While
+	// disabled, measurements are dropped and no new data is
+	// collected; existing state is kept and continues once
+	// re-enabled.
+	SetEnabled(enabled bool)
+}
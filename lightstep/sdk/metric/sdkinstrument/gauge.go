@@ -0,0 +1,47 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdkinstrument
+
+import (
+	"context"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// Gauge is a synchronous instrument that records the current value
+// of a measurement, for values that are not naturally observed
+// through a callback.  Gauge instruments are not part of the
+// OpenTelemetry metrics API; they are created through the
+// GaugeProvider interface of the SDK's Meter.
+type Gauge[N number.Any] interface {
+	instrument.Synchronous
+
+	// Record sets the current value of the gauge.
+	Record(ctx context.Context, value N, attrs ...attribute.KeyValue)
+}
+
+// GaugeProvider is implemented by the SDK's Meter.  For example:
+//
+//	gauge, err := meter.(sdkinstrument.GaugeProvider).Float64Gauge("queue.size")
+type GaugeProvider interface {
+	// Int64Gauge creates a synchronous integer Gauge instrument.
+	Int64Gauge(name string, opts ...instrument.Option) (Gauge[int64], error)
+
+	// Float64Gauge creates a synchronous floating-point Gauge
+	// instrument.
+	Float64Gauge(name string, opts ...instrument.Option) (Gauge[float64], error)
+}
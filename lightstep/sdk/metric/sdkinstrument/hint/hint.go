@@ -0,0 +1,132 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hint parses the Lightstep-specified hint structure that may
+// be encoded as JSON in an instrument description, for example:
+//
+//	{"aggregation":"gauge","description":"incredible"}
+//
+// The SDK replaces such a description with its embedded description
+// ("incredible") and uses the remaining fields to configure the
+// instrument when no view clause applies to it.
+package hint // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument/hint"
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"go.uber.org/multierr"
+)
+
+// Encoding is the JSON structure of a hint.  Producers may serialize
+// this structure into an instrument description.
+type Encoding struct {
+	// Description takes the place of the hint after decoding
+	// this Hint from the Description.
+	Description string `json:"description"`
+
+	// Aggregation determines the kind of aggregator used.  When
+	// this is set, semantic compatibility checking is bypassed.
+	Aggregation string `json:"aggregation"`
+
+	// Temporality, either "cumulative" or "delta", overrides the
+	// temporality preferred by the reader.
+	Temporality string `json:"temporality,omitempty"`
+
+	// Config configures the aggregator selected in the
+	// Aggregation field.
+	Config aggregator.JSONConfig `json:"config"`
+}
+
+// Hint is the decoded form of an Encoding.
+type Hint struct {
+	// Aggregation is the hinted aggregation kind, UndefinedKind
+	// when not set.
+	Aggregation aggregation.Kind
+
+	// Temporality is the hinted temporality, UndefinedTemporality
+	// when not set.
+	Temporality aggregation.Temporality
+
+	// Config is the hinted aggregator configuration, nil when not
+	// set.
+	Config *aggregator.Config
+
+	found bool
+}
+
+// Found returns true when the description contained a hint.  Note
+// that a hint with no fields set is still found, since its presence
+// bypasses semantic compatibility checking.
+func (h Hint) Found() bool {
+	return h.found
+}
+
+// Parse decodes a hint from an instrument description, returning the
+// hint and the description that takes its place.  When the
+// description does not contain a hint, the zero Hint and the original
+// description are returned.
+//
+// A non-nil error is returned when the hint cannot be decoded, in
+// which case the original description is returned, or when some of
+// its fields are invalid, in which case the valid portion of the hint
+// is returned.
+func Parse(desc string) (Hint, string, error) {
+	// Check for required JSON symbols, empty strings, ...
+	if !strings.Contains(desc, "{") {
+		return Hint{}, desc, nil
+	}
+
+	var enc Encoding
+	if err := json.Unmarshal([]byte(desc), &enc); err != nil {
+		// This could be noisy if valid descriptions contain spurious '{' chars.
+		return Hint{}, desc, fmt.Errorf("hint parse error: %w", err)
+	}
+
+	hint := Hint{
+		found: true,
+	}
+	var errs error
+
+	if enc.Aggregation != "" {
+		kind, ok := aggregation.ParseKind(enc.Aggregation)
+		if !ok {
+			errs = multierr.Append(errs, fmt.Errorf("hint invalid aggregation: %v", enc.Aggregation))
+		} else {
+			hint.Aggregation = kind
+		}
+	}
+
+	if enc.Temporality != "" {
+		tempo, ok := aggregation.ParseTemporality(enc.Temporality)
+		if !ok {
+			errs = multierr.Append(errs, fmt.Errorf("hint invalid temporality: %v", enc.Temporality))
+		} else {
+			hint.Temporality = tempo
+		}
+	}
+
+	if !enc.Config.Empty() {
+		cfg, err := enc.Config.ToConfig().Validate()
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("hint invalid aggregator config: %w", err))
+		}
+		hint.Config = &cfg
+	}
+
+	return hint, enc.Description, errs
+}
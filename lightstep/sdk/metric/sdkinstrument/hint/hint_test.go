@@ -0,0 +1,106 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNoHint(t *testing.T) {
+	for _, desc := range []string{"", "incredible"} {
+		h, clean, err := Parse(desc)
+		require.NoError(t, err)
+		require.False(t, h.Found())
+		require.Equal(t, Hint{}, h)
+		require.Equal(t, desc, clean)
+	}
+}
+
+func TestParse(t *testing.T) {
+	h, clean, err := Parse(`{"aggregation":"gauge","description":"incredible"}`)
+	require.NoError(t, err)
+	require.True(t, h.Found())
+	require.Equal(t, "incredible", clean)
+	require.Equal(t, aggregation.GaugeKind, h.Aggregation)
+	require.Equal(t, aggregation.UndefinedTemporality, h.Temporality)
+	require.Nil(t, h.Config)
+
+	h, clean, err = Parse(`{
+  "temporality": "delta",
+  "config": {
+    "histogram": {
+      "max_size": 3
+    }
+  }
+}`)
+	require.NoError(t, err)
+	require.True(t, h.Found())
+	require.Equal(t, "", clean)
+	require.Equal(t, aggregation.UndefinedKind, h.Aggregation)
+	require.Equal(t, aggregation.DeltaTemporality, h.Temporality)
+	require.Equal(t, &aggregator.Config{
+		Histogram: histogram.NewConfig(histogram.WithMaxSize(3)),
+	}, h.Config)
+}
+
+func TestParseErrors(t *testing.T) {
+	const accidental = "accidental { parse"
+	h, clean, err := Parse(accidental)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "hint parse error")
+	require.False(t, h.Found())
+	require.Equal(t, accidental, clean)
+
+	// Invalid fields are reported, the rest of the hint applies.
+	h, clean, err = Parse(`{
+  "description": "partial",
+  "aggregation": "cardinality",
+  "temporality": "sometimes",
+  "config": {
+    "histogram": {
+      "max_size": 3
+    }
+  }
+}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid aggregation")
+	require.Contains(t, err.Error(), "invalid temporality")
+	require.True(t, h.Found())
+	require.Equal(t, "partial", clean)
+	require.Equal(t, aggregation.UndefinedKind, h.Aggregation)
+	require.Equal(t, aggregation.UndefinedTemporality, h.Temporality)
+	require.NotNil(t, h.Config)
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	data, err := json.Marshal(Encoding{
+		Description: "incredible",
+		Aggregation: "gauge",
+		Temporality: "cumulative",
+	})
+	require.NoError(t, err)
+
+	h, clean, err := Parse(string(data))
+	require.NoError(t, err)
+	require.Equal(t, "incredible", clean)
+	require.Equal(t, aggregation.GaugeKind, h.Aggregation)
+	require.Equal(t, aggregation.CumulativeTemporality, h.Temporality)
+}
@@ -35,6 +35,11 @@ const (
 	// AsyncGauge indicates an GaugeObserver instrument.
 	AsyncGauge
 
+	// SyncGauge indicates a synchronous Gauge instrument, which
+	// records the last value set.  This kind follows the
+	// asynchronous kinds so that existing values are unchanged.
+	SyncGauge
+
 	// NumKinds is the size of an array, useful for indexing by instrument kind.
 	NumKinds
 )
@@ -42,7 +47,7 @@ const (
 // Synchronous returns whether this is a synchronous kind of instrument.
 func (k Kind) Synchronous() bool {
 	switch k {
-	case SyncCounter, SyncUpDownCounter, SyncHistogram:
+	case SyncCounter, SyncUpDownCounter, SyncHistogram, SyncGauge:
 		return true
 	}
 	return false
@@ -14,12 +14,13 @@ func _() {
 	_ = x[AsyncCounter-3]
 	_ = x[AsyncUpDownCounter-4]
 	_ = x[AsyncGauge-5]
-	_ = x[NumKinds-6]
+	_ = x[SyncGauge-6]
+	_ = x[NumKinds-7]
 }
 
-const _Kind_name = "SyncCounterSyncUpDownCounterSyncHistogramAsyncCounterAsyncUpDownCounterAsyncGaugeNumKinds"
+const _Kind_name = "SyncCounterSyncUpDownCounterSyncHistogramAsyncCounterAsyncUpDownCounterAsyncGaugeSyncGaugeNumKinds"
 
-var _Kind_index = [...]uint8{0, 11, 28, 41, 53, 71, 81, 89}
+var _Kind_index = [...]uint8{0, 11, 28, 41, 53, 71, 81, 90, 98}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
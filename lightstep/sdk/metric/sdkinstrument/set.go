@@ -0,0 +1,101 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdkinstrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SetCounter is implemented by the SDK's synchronous Counter and
+// UpDownCounter instruments, for callers that already have an
+// attribute.Set.  For example:
+//
+//	cntr.(sdkinstrument.SetCounter[int64]).AddSet(ctx, 1, set)
+type SetCounter[N number.Any] interface {
+	// AddSet is equivalent to Add() with the attributes of set.
+	AddSet(ctx context.Context, incr N, set attribute.Set)
+}
+
+// Measurement is one value and attribute set of a batch.
+type Measurement[N number.Any] struct {
+	Value N
+	Attrs attribute.Set
+}
+
+// BatchCounter is implemented by the SDK's synchronous Counter and
+// UpDownCounter instruments, for callers that accumulate many
+// measurements before flushing them.  For example:
+//
+//	cntr.(sdkinstrument.BatchCounter[int64]).AddBatch(ctx, batch)
+type BatchCounter[N number.Any] interface {
+	// AddBatch is equivalent to AddSet() for each measurement,
+	// in order, but acquires the state of each distinct
+	// attribute set once.
+	AddBatch(ctx context.Context, batch []Measurement[N])
+}
+
+// BackfillCounter is implemented by the SDK's synchronous Counter
+// and UpDownCounter instruments, for callers that import historical
+// measurements alongside real-time ones.  For example:
+//
+//	cntr.(sdkinstrument.BackfillCounter[int64]).AddWithStartTime(ctx, 1, start, attrs...)
+type BackfillCounter[N number.Any] interface {
+	// AddWithStartTime is equivalent to Add(), except that the
+	// points of the attribute set start no later than start.
+	// Cumulative points keep the earlier start time, delta
+	// points use it for the interval that includes the
+	// increment.  The end of each point is the collection time.
+	AddWithStartTime(ctx context.Context, incr N, start time.Time, attrs ...attribute.KeyValue)
+}
+
+// SetHistogram is implemented by the SDK's synchronous Histogram
+// instruments, for callers that already have an attribute.Set.
+type SetHistogram[N number.Any] interface {
+	// RecordSet is equivalent to Record() with the attributes of set.
+	RecordSet(ctx context.Context, value N, set attribute.Set)
+}
+
+// WeightedHistogram is implemented by the SDK's synchronous
+// Histogram instruments, for callers that record sampled
+// measurements.  For example, to record a value that was sampled
+// with probability 1/10:
+//
+//	hist.(sdkinstrument.WeightedHistogram[float64]).RecordWeighted(ctx, value, 10, attrs...)
+type WeightedHistogram[N number.Any] interface {
+	// RecordWeighted is equivalent to calling Record() weight
+	// times with the same value and attributes.
+	RecordWeighted(ctx context.Context, value N, weight uint64, attrs ...attribute.KeyValue)
+}
+
+// BucketHistogram is implemented by the SDK's synchronous Histogram
+// instruments, for callers that bridge histograms bucketed by
+// another system into explicit-bucket histogram views.  For example,
+// to merge 10 values summing to 35.5 into the third bucket:
+//
+//	hist.(sdkinstrument.BucketHistogram[float64]).RecordBucket(ctx, 2, 10, 35.5, attrs...)
+type BucketHistogram[N number.Any] interface {
+	// RecordBucket adds count to the bucket at index of each
+	// explicit-bucket histogram output and sum to its sum,
+	// without changing its minimum and maximum.  Bucket i counts
+	// values in (boundaries[i-1], boundaries[i]].  Outputs that
+	// have no bucket at index or use another aggregation are not
+	// modified, which is reported to the measurement error
+	// handler.
+	RecordBucket(ctx context.Context, index int, count uint64, sum N, attrs ...attribute.KeyValue)
+}
@@ -0,0 +1,235 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric"
+
+import (
+	"context"
+	"time"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.uber.org/multierr"
+)
+
+const (
+	// snapshotDurationName is the histogram of time spent in
+	// SnapshotAndProcess per collection, in seconds.
+	snapshotDurationName = "otel.sdk.metric.snapshot.duration"
+
+	// collectDurationName is the histogram of time spent in
+	// Collect per collection, in seconds.
+	collectDurationName = "otel.sdk.metric.collect.duration"
+
+	// lockWaitsName is the count of contended acquisitions of
+	// the lock of each synchronous instrument.
+	lockWaitsName = "otel.sdk.metric.lock.waits"
+
+	// lockWaitTimeName is the time spent waiting for the lock of
+	// each synchronous instrument, in seconds.
+	lockWaitTimeName = "otel.sdk.metric.lock.wait_time"
+
+	// cardinalityName is the estimated number of distinct
+	// attribute sets of each synchronous instrument.
+	cardinalityName = "otel.sdk.metric.cardinality"
+
+	// ingestDroppedName is the count of measurements of each
+	// synchronous instrument dropped by a full ingest queue.
+	ingestDroppedName = "otel.sdk.metric.ingest.dropped"
+
+	// readerKey is the attribute naming the Reader that collected.
+	readerKey = attribute.Key("reader")
+
+	// scopeKey and instrumentKey are the attributes naming the
+	// Meter and the instrument of the lock statistics, the
+	// cardinality estimates, and the dropped counts.
+	scopeKey      = attribute.Key("scope")
+	instrumentKey = attribute.Key("instrument")
+)
+
+// selfObservability records the duration of each collection, see
+// WithSelfObservability.
+type selfObservability struct {
+	snapshot syncfloat64.Histogram
+	collect  syncfloat64.Histogram
+}
+
+// collectTimes is the time spent in each phase of one collection,
+// summed over meters.
+type collectTimes struct {
+	snapshot time.Duration
+	collect  time.Duration
+}
+
+// newSelfObservability returns nil when meter is nil or the
+// histograms cannot be created.  When the SDK is built with the
+// otelmetriclockstats tag, the lock statistics of the synchronous
+// instruments of mp are observed as well, and likewise their
+// cardinality estimates when configured by WithCardinalityEstimation
+// and their dropped measurements when configured by WithAsyncIngest.
+func newSelfObservability(meter metric.Meter, mp *MeterProvider) *selfObservability {
+	if meter == nil {
+		return nil
+	}
+	snapshot, err1 := meter.SyncFloat64().Histogram(
+		snapshotDurationName,
+		instrument.WithUnit(unit.Unit("s")),
+		instrument.WithDescription("Time spent processing synchronous and asynchronous instruments per collection"),
+	)
+	collect, err2 := meter.SyncFloat64().Histogram(
+		collectDurationName,
+		instrument.WithUnit(unit.Unit("s")),
+		instrument.WithDescription("Time spent producing points from the processed instruments per collection"),
+	)
+	if err := multierr.Append(err1, err2); err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	if syncstate.LockStatsEnabled {
+		if err := observeLockStats(meter, mp); err != nil {
+			otel.Handle(err)
+		}
+	}
+	if mp.cfg.cardinalityEstimation {
+		if err := observeCardinality(meter, mp); err != nil {
+			otel.Handle(err)
+		}
+	}
+	if mp.cfg.asyncIngest > 0 {
+		if err := observeDropped(meter, mp); err != nil {
+			otel.Handle(err)
+		}
+	}
+	return &selfObservability{
+		snapshot: snapshot,
+		collect:  collect,
+	}
+}
+
+// observeLockStats registers counters of the lock contention of each
+// synchronous instrument of mp, see syncstate.Stats.
+func observeLockStats(meter metric.Meter, mp *MeterProvider) error {
+	waits, err1 := meter.AsyncInt64().Counter(
+		lockWaitsName,
+		instrument.WithDescription("Contended acquisitions of the lock of each synchronous instrument"),
+	)
+	waitTime, err2 := meter.AsyncFloat64().Counter(
+		lockWaitTimeName,
+		instrument.WithUnit(unit.Unit("s")),
+		instrument.WithDescription("Time spent waiting for the lock of each synchronous instrument"),
+	)
+	if err := multierr.Append(err1, err2); err != nil {
+		return err
+	}
+	return meter.RegisterCallback([]instrument.Asynchronous{waits, waitTime}, func(ctx context.Context) {
+		for _, m := range mp.getOrdered() {
+			m.lock.Lock()
+			insts := m.syncInsts
+			m.lock.Unlock()
+
+			for _, inst := range insts {
+				if inst == nil {
+					// Instrument was completely disabled by the view.
+					continue
+				}
+				stats := inst.Stats()
+				attrs := []attribute.KeyValue{
+					scopeKey.String(m.library.Name),
+					instrumentKey.String(inst.Descriptor().Name),
+				}
+				waits.Observe(ctx, stats.LockWaits, attrs...)
+				waitTime.Observe(ctx, stats.LockWaitTime.Seconds(), attrs...)
+			}
+		}
+	})
+}
+
+// observeCardinality registers a gauge of the estimated number of
+// distinct attribute sets of each synchronous instrument of mp, see
+// syncstate.Stats.
+func observeCardinality(meter metric.Meter, mp *MeterProvider) error {
+	cardinality, err := meter.AsyncInt64().Gauge(
+		cardinalityName,
+		instrument.WithDescription("Estimated number of distinct attribute sets used by each synchronous instrument"),
+	)
+	if err != nil {
+		return err
+	}
+	return meter.RegisterCallback([]instrument.Asynchronous{cardinality}, func(ctx context.Context) {
+		for _, m := range mp.getOrdered() {
+			m.lock.Lock()
+			insts := m.syncInsts
+			m.lock.Unlock()
+
+			for _, inst := range insts {
+				if inst == nil {
+					// Instrument was completely disabled by the view.
+					continue
+				}
+				cardinality.Observe(ctx, inst.Stats().Cardinality,
+					scopeKey.String(m.library.Name),
+					instrumentKey.String(inst.Descriptor().Name),
+				)
+			}
+		}
+	})
+}
+
+// observeDropped registers a counter of the measurements of each
+// synchronous instrument of mp dropped by a full ingest queue, see
+// syncstate.Stats.
+func observeDropped(meter metric.Meter, mp *MeterProvider) error {
+	dropped, err := meter.AsyncInt64().Counter(
+		ingestDroppedName,
+		instrument.WithDescription("Measurements of each synchronous instrument dropped by a full ingest queue"),
+	)
+	if err != nil {
+		return err
+	}
+	return meter.RegisterCallback([]instrument.Asynchronous{dropped}, func(ctx context.Context) {
+		for _, m := range mp.getOrdered() {
+			m.lock.Lock()
+			insts := m.syncInsts
+			m.lock.Unlock()
+
+			for _, inst := range insts {
+				if inst == nil {
+					// Instrument was completely disabled by the view.
+					continue
+				}
+				dropped.Observe(ctx, inst.Stats().Dropped,
+					scopeKey.String(m.library.Name),
+					instrumentKey.String(inst.Descriptor().Name),
+				)
+			}
+		}
+	})
+}
+
+// record records the times of one collection by the named reader.
+// This is called after collection is finished.
+func (so *selfObservability) record(reader string, times collectTimes) {
+	if so == nil {
+		return
+	}
+	ctx := context.Background()
+	attr := readerKey.String(reader)
+	so.snapshot.Record(ctx, times.snapshot.Seconds(), attr)
+	so.collect.Record(ctx, times.collect.Seconds(), attr)
+}
@@ -30,30 +30,74 @@ type (
 
 func (i syncint64Instruments) Counter(name string, opts ...instrument.Option) (syncint64.Counter, error) {
 	inst, err := i.synchronousInstrument(name, opts, number.Int64Kind, sdkinstrument.SyncCounter)
+	if inst == nil {
+		// Every view drops the instrument.
+		return syncstate.Noop[int64]{}, err
+	}
 	return syncstate.NewCounter[int64, number.Int64Traits](inst), err
 }
 
 func (i syncint64Instruments) UpDownCounter(name string, opts ...instrument.Option) (syncint64.UpDownCounter, error) {
 	inst, err := i.synchronousInstrument(name, opts, number.Int64Kind, sdkinstrument.SyncUpDownCounter)
+	if inst == nil {
+		// Every view drops the instrument.
+		return syncstate.Noop[int64]{}, err
+	}
 	return syncstate.NewCounter[int64, number.Int64Traits](inst), err
 }
 
 func (i syncint64Instruments) Histogram(name string, opts ...instrument.Option) (syncint64.Histogram, error) {
 	inst, err := i.synchronousInstrument(name, opts, number.Int64Kind, sdkinstrument.SyncHistogram)
+	if inst == nil {
+		// Every view drops the instrument.
+		return syncstate.Noop[int64]{}, err
+	}
 	return syncstate.NewHistogram[int64, number.Int64Traits](inst), err
 }
 
 func (f syncfloat64Instruments) Counter(name string, opts ...instrument.Option) (syncfloat64.Counter, error) {
 	inst, err := f.synchronousInstrument(name, opts, number.Float64Kind, sdkinstrument.SyncCounter)
+	if inst == nil {
+		// Every view drops the instrument.
+		return syncstate.Noop[float64]{}, err
+	}
 	return syncstate.NewCounter[float64, number.Float64Traits](inst), err
 }
 
 func (f syncfloat64Instruments) UpDownCounter(name string, opts ...instrument.Option) (syncfloat64.UpDownCounter, error) {
 	inst, err := f.synchronousInstrument(name, opts, number.Float64Kind, sdkinstrument.SyncUpDownCounter)
+	if inst == nil {
+		// Every view drops the instrument.
+		return syncstate.Noop[float64]{}, err
+	}
 	return syncstate.NewCounter[float64, number.Float64Traits](inst), err
 }
 
 func (f syncfloat64Instruments) Histogram(name string, opts ...instrument.Option) (syncfloat64.Histogram, error) {
 	inst, err := f.synchronousInstrument(name, opts, number.Float64Kind, sdkinstrument.SyncHistogram)
+	if inst == nil {
+		// Every view drops the instrument.
+		return syncstate.Noop[float64]{}, err
+	}
 	return syncstate.NewHistogram[float64, number.Float64Traits](inst), err
 }
+
+// Int64Gauge returns a synchronous integer Gauge instrument.
+func (m *meter) Int64Gauge(name string, opts ...instrument.Option) (sdkinstrument.Gauge[int64], error) {
+	inst, err := m.synchronousInstrument(name, opts, number.Int64Kind, sdkinstrument.SyncGauge)
+	if inst == nil {
+		// Every view drops the instrument.
+		return syncstate.Noop[int64]{}, err
+	}
+	return syncstate.NewGauge[int64, number.Int64Traits](inst), err
+}
+
+// Float64Gauge returns a synchronous floating-point Gauge instrument.
+func (m *meter) Float64Gauge(name string, opts ...instrument.Option) (sdkinstrument.Gauge[float64], error) {
+	inst, err := m.synchronousInstrument(name, opts, number.Float64Kind, sdkinstrument.SyncGauge)
+	if inst == nil {
+		// Every view drops the instrument.
+		return syncstate.Noop[float64]{}, err
+	}
+	return syncstate.NewGauge[float64, number.Float64Traits](inst), err
+}
@@ -16,18 +16,25 @@ package metric
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/gauge"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/histogram"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/sum"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/summary"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/syncstate"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/internal/test"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
 	"go.opentelemetry.io/otel/sdk/resource"
 )
 
@@ -108,3 +115,326 @@ func TestSyncInsts(t *testing.T) {
 		),
 	)
 }
+
+func TestMeasurementErrorHandler(t *testing.T) {
+	type dropped struct {
+		name   string
+		value  float64
+		reason error
+	}
+	var drops []dropped
+
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(rdr),
+		WithMeasurementErrorHandler(func(desc sdkinstrument.Descriptor, value number.Number, reason error) {
+			drops = append(drops, dropped{
+				name:   desc.Name,
+				value:  value.CoerceToFloat64(desc.NumberKind),
+				reason: reason,
+			})
+		}),
+	)
+
+	ci := must(provider.Meter("test").SyncInt64().Counter("icount"))
+	cf := must(provider.Meter("test").SyncFloat64().Counter("fcount"))
+	uf := must(provider.Meter("test").SyncFloat64().UpDownCounter("fupcount"))
+	hi := must(provider.Meter("test").SyncInt64().Histogram("ihistogram"))
+
+	ci.Add(ctx, -1)
+	ci.Add(ctx, 1)
+	cf.Add(ctx, math.Inf(+1))
+	uf.Add(ctx, -1)
+	uf.Add(ctx, math.NaN())
+	hi.Record(ctx, -2)
+
+	require.Equal(t, 4, len(drops))
+	require.Equal(t, dropped{"icount", -1, aggregator.ErrNegativeInput}, drops[0])
+	require.Equal(t, dropped{"fcount", math.Inf(+1), aggregator.ErrInfInput}, drops[1])
+	require.Equal(t, "fupcount", drops[2].name)
+	require.True(t, math.IsNaN(drops[2].value))
+	require.Equal(t, aggregator.ErrNaNInput, drops[2].reason)
+	require.Equal(t, dropped{"ihistogram", -2, aggregator.ErrNegativeInput}, drops[3])
+}
+
+func TestSumOverflowPolicy(t *testing.T) {
+	var overflows []number.Number
+
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(
+			rdr,
+			view.WithClause(
+				view.WithAggregatorConfig(aggregator.Config{
+					SumOverflow: sum.WithOverflowPolicy(sum.Saturate),
+				}),
+			),
+		),
+		WithMeasurementErrorHandler(func(desc sdkinstrument.Descriptor, value number.Number, reason error) {
+			require.Equal(t, "icount", desc.Name)
+			require.Equal(t, aggregator.ErrSumOverflow, reason)
+			overflows = append(overflows, value)
+		}),
+	)
+
+	ci := must(provider.Meter("test").SyncInt64().Counter("icount"))
+
+	ci.Add(ctx, math.MaxInt64-1)
+	_ = rdr.Produce(nil)
+	require.Equal(t, 0, len(overflows))
+
+	ci.Add(ctx, 10)
+	data := rdr.Produce(nil)
+	require.Equal(t, []number.Number{number.Number(math.MaxInt64)}, overflows)
+
+	point := data.Scopes[0].Instruments[0].Points[0]
+	require.Equal(t, int64(math.MaxInt64), number.ToInt64(point.Aggregation.(aggregation.Sum).Sum()))
+}
+
+// TestSumOverflowReset tests that a sum restarted by an overflow
+// starts its cumulative points at the previous collection.
+func TestSumOverflowReset(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(
+			rdr,
+			view.WithClause(
+				view.WithAggregatorConfig(aggregator.Config{
+					SumOverflow: sum.WithOverflowPolicy(sum.Reset),
+				}),
+			),
+		),
+	)
+
+	ci := must(provider.Meter("test").SyncInt64().Counter("icount"))
+
+	ci.Add(ctx, math.MaxInt64-1)
+	first := rdr.Produce(nil).Scopes[0].Instruments[0].Points[0]
+
+	ci.Add(ctx, 10)
+	second := rdr.Produce(nil).Scopes[0].Instruments[0].Points[0]
+	require.Equal(t, int64(10), number.ToInt64(second.Aggregation.(aggregation.Sum).Sum()))
+	require.True(t, first.End.Equal(second.Start))
+
+	ci.Add(ctx, 1)
+	third := rdr.Produce(nil).Scopes[0].Instruments[0].Points[0]
+	require.Equal(t, int64(11), number.ToInt64(third.Aggregation.(aggregation.Sum).Sum()))
+	require.True(t, second.Start.Equal(third.Start))
+	require.True(t, first.Start.Before(third.Start))
+}
+
+func TestSyncGauge(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	res := resource.Empty()
+	provider := NewMeterProvider(WithResource(res), WithReader(rdr))
+
+	gp := provider.Meter("test").(sdkinstrument.GaugeProvider)
+	gi := must(gp.Int64Gauge("igauge"))
+	gf := must(gp.Float64Gauge("fgauge"))
+
+	attr := attribute.String("a", "B")
+
+	gi.Record(ctx, 2, attr)
+	gi.Record(ctx, -5, attr)
+	gf.Record(ctx, 3.5, attr)
+	gf.Record(ctx, 1.25, attr)
+
+	data := rdr.Produce(nil)
+	notime := time.Time{}
+	cumulative := aggregation.CumulativeTemporality
+
+	test.RequireEqualResourceMetrics(
+		t, data, res,
+		test.Scope(
+			test.Library("test"),
+			test.Instrument(
+				test.Descriptor("igauge", sdkinstrument.SyncGauge, number.Int64Kind),
+				test.Point(notime, notime, gauge.NewInt64(-5), cumulative, attr),
+			),
+			test.Instrument(
+				test.Descriptor("fgauge", sdkinstrument.SyncGauge, number.Float64Kind),
+				test.Point(notime, notime, gauge.NewFloat64(1.25), cumulative, attr),
+			),
+		),
+	)
+}
+
+func TestNegativeHistogramValues(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithNegativeHistogramValues(true),
+		WithReader(
+			rdr,
+			view.WithClause(
+				view.MatchInstrumentName("h"),
+			),
+			view.WithClause(
+				view.MatchInstrumentName("h"),
+				view.WithName("h.explicit"),
+				view.WithAggregation(aggregation.ExplicitHistogramKind),
+				view.WithAggregatorConfig(aggregator.Config{
+					HistogramBoundaries: aggregator.NewBoundaries([]float64{-2, 0, 1}),
+				}),
+			),
+		),
+		WithMeasurementErrorHandler(func(desc sdkinstrument.Descriptor, value number.Number, reason error) {
+			t.Errorf("unexpected drop: %s %v", desc.Name, reason)
+		}),
+	)
+
+	h := must(provider.Meter("test").SyncFloat64().Histogram("h"))
+	for _, v := range []float64{-3.2, -1, 0, 2} {
+		h.Record(ctx, v)
+	}
+
+	output := rdr.Produce(nil)
+	insts := output.Scopes[0].Instruments
+	require.Equal(t, 2, len(insts))
+
+	// The exponential histogram keeps negative buckets distinct.
+	expo := insts[0].Points[0].Aggregation.(aggregation.Histogram)
+	require.Equal(t, uint64(4), expo.Count())
+	require.Equal(t, uint64(1), expo.ZeroCount())
+	require.InDelta(t, -2.2, number.ToFloat64(expo.Sum()), 1e-9)
+	require.Equal(t, -3.2, number.ToFloat64(expo.Min()))
+	require.Equal(t, 2.0, number.ToFloat64(expo.Max()))
+
+	bucketTotal := func(b aggregation.Buckets) (total uint64) {
+		for i := uint32(0); i < b.Len(); i++ {
+			total += b.At(i)
+		}
+		return total
+	}
+	require.Equal(t, uint64(2), bucketTotal(expo.Negative()))
+	require.Equal(t, uint64(1), bucketTotal(expo.Positive()))
+
+	// The explicit histogram counts them below zero.
+	require.Equal(t, "h.explicit", insts[1].Descriptor.Name)
+	explicit := insts[1].Points[0].Aggregation.(aggregation.ExplicitHistogram)
+	require.Equal(t, []uint64{1, 2, 0, 1}, explicit.BucketCounts())
+
+	// Counters still reject negative values.
+	var drops []error
+	provider = NewMeterProvider(
+		WithNegativeHistogramValues(true),
+		WithMeasurementErrorHandler(func(_ sdkinstrument.Descriptor, _ number.Number, reason error) {
+			drops = append(drops, reason)
+		}),
+		WithReader(NewManualReader("test")),
+	)
+	must(provider.Meter("test").SyncFloat64().Counter("c")).Add(ctx, -1)
+	require.Equal(t, []error{aggregator.ErrNegativeInput}, drops)
+}
+
+func TestSummaryAggregation(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(
+		WithResource(resource.Empty()),
+		WithReader(
+			rdr,
+			view.WithClause(
+				view.MatchInstrumentName("latency"),
+				view.WithAggregation(aggregation.SummaryKind),
+				view.WithAggregatorConfig(aggregator.Config{
+					SummaryQuantiles:     summary.WithQuantiles([]float64{0.5, 0.9, 1}),
+					SummaryRelativeError: summary.WithRelativeError(0.001),
+				}),
+			),
+		),
+	)
+
+	h := must(provider.Meter("test").SyncInt64().Histogram("latency"))
+	for i := int64(1); i <= 100; i++ {
+		h.Record(ctx, i)
+	}
+
+	// Summaries are cumulative, like the other histogram
+	// aggregations by default.
+	for j := 0; j < 2; j++ {
+		output := rdr.Produce(nil)
+		pt := output.Scopes[0].Instruments[0].Points[0]
+		require.Equal(t, aggregation.CumulativeTemporality, pt.Temporality)
+
+		summ := pt.Aggregation.(aggregation.Summary)
+		require.Equal(t, uint64(100), summ.Count())
+		require.Equal(t, int64(5050), number.ToInt64(summ.Sum()))
+
+		qvs := summ.Quantiles()
+		require.Equal(t, 3, len(qvs))
+		require.InDelta(t, 50, qvs[0].Value, 0.05)
+		require.InDelta(t, 90, qvs[1].Value, 0.09)
+		require.Equal(t, aggregation.QuantileValue{Quantile: 1, Value: 100}, qvs[2])
+	}
+}
+
+func TestRecordDuration(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(WithResource(resource.Empty()), WithReader(rdr))
+
+	meter := provider.Meter("test")
+	hs := must(meter.SyncFloat64().Histogram("seconds", instrument.WithUnit("s")))
+	hms := must(meter.SyncFloat64().Histogram("millis", instrument.WithUnit(unit.Milliseconds)))
+	hi := must(meter.SyncInt64().Histogram("imillis", instrument.WithUnit(unit.Milliseconds)))
+	hnone := must(meter.SyncFloat64().Histogram("unitless"))
+
+	for _, h := range []interface{}{hs, hms, hi, hnone} {
+		h.(sdkinstrument.DurationHistogram).RecordDuration(ctx, 1500*time.Microsecond)
+	}
+
+	sums := map[string]float64{}
+	for _, inst := range rdr.Produce(nil).Scopes[0].Instruments {
+		agg := inst.Points[0].Aggregation.(aggregation.HasASum)
+		sums[inst.Descriptor.Name] = agg.Sum().CoerceToFloat64(inst.Descriptor.NumberKind)
+	}
+	require.Equal(t, map[string]float64{
+		"seconds":  0.0015,
+		"millis":   1.5,
+		"imillis":  1,
+		"unitless": 0.0015,
+	}, sums)
+}
+
+func TestDroppedInstrumentNoop(t *testing.T) {
+	ctx := context.Background()
+	rdr := NewManualReader("test")
+	provider := NewMeterProvider(WithReader(rdr, view.WithClause(
+		view.MatchInstrumentName("dropme"),
+		view.WithAggregation(aggregation.DropKind),
+	)))
+
+	meter := provider.Meter("test")
+	hist := must(meter.SyncFloat64().Histogram("dropme"))
+	cntr := must(meter.SyncInt64().Counter("dropme"))
+	gauge := must(meter.(sdkinstrument.GaugeProvider).Float64Gauge("dropme"))
+	kept := must(meter.SyncInt64().Counter("keepme"))
+
+	require.IsType(t, syncstate.Noop[float64]{}, hist)
+	require.IsType(t, syncstate.Noop[int64]{}, cntr)
+	require.IsType(t, syncstate.Noop[float64]{}, gauge)
+	require.IsType(t, syncstate.Counter[int64, number.Int64Traits]{}, kept)
+
+	attrs := []attribute.KeyValue{attribute.String("K", "V")}
+	allocs := testing.AllocsPerRun(100, func() {
+		hist.Record(ctx, 1, attrs...)
+		hist.(sdkinstrument.DurationHistogram).RecordDuration(ctx, time.Second, attrs...)
+		cntr.Add(ctx, 1, attrs...)
+		gauge.Record(ctx, 1, attrs...)
+	})
+	require.Equal(t, 0.0, allocs)
+
+	insts := rdr.Produce(nil).Scopes
+	require.Equal(t, 1, len(insts))
+	require.Equal(t, 1, len(insts[0].Instruments))
+	require.Equal(t, "keepme", insts[0].Instruments[0].Descriptor.Name)
+}
@@ -16,9 +16,11 @@ package view // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metr
 
 import (
 	"regexp"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/data"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"go.opentelemetry.io/otel/attribute"
@@ -37,10 +39,31 @@ type ClauseConfig struct {
 
 	// Properties of the view
 	keys        []attribute.Key // nil implies all keys, []attribute.Key{} implies none
+	keyPatterns []string
+	rename      map[attribute.Key]attribute.Key
+	valueLimit  int
+	normalKeys  []attribute.Key
+	normalize   func(string) string
+	contextKeys []string
 	name        string
 	description string
 	aggregation aggregation.Kind
 	acfg        aggregator.Config
+	limit       int
+	overflow    attribute.KeyValue
+	cacheSize   int
+	cumulative  bool
+	delta       bool
+	trim        bool
+	staleAfter  time.Duration
+	transform   func(float64) float64
+	unitFrom    string
+	unitTo      string
+	unitFactor  float64
+	processor   func(*data.Point)
+	ttl         time.Duration
+	route       func(attribute.Set) bool
+	startEpoch  time.Time
 }
 
 const (
@@ -108,6 +131,74 @@ func WithKeys(keys []attribute.Key) ClauseOption {
 	})
 }
 
+// WithKeyPatterns keeps the attribute keys that match any of the
+// patterns, which use the syntax of path.Match, e.g., "http.*" keeps
+// every key starting with "http.".  When both WithKeys and
+// WithKeyPatterns are set, the union of the keys they select is
+// kept.  Patterns are compiled once, when the view is applied to an
+// instrument.
+func WithKeyPatterns(patterns []string) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.keyPatterns = patterns
+		return clause
+	})
+}
+
+// WithAttributeRename renames attribute keys, mapping from the key
+// used by the instrumentation to the key that will be output.
+// Renaming happens before the WithKeys filter is applied, so WithKeys
+// refers to the renamed keys.  Attribute sets that are identical
+// after renaming are aggregated into one point.  When two keys of
+// one attribute set are renamed to the same key, the value of the
+// original key that sorts last is used.
+func WithAttributeRename(rename map[attribute.Key]attribute.Key) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.rename = rename
+		return clause
+	})
+}
+
+// WithAttributeValueLengthLimit truncates string attribute values to
+// at most n runes, after WithAttributeRename and before the attribute
+// set is aggregated, so that values that are equal after truncation
+// are aggregated into one point.  Values of other types are not
+// modified.  Zero means no limit.
+func WithAttributeValueLengthLimit(n int) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.valueLimit = n
+		return clause
+	})
+}
+
+// WithValueNormalization replaces the string values of the named
+// attribute keys with fn(value), after WithAttributeRename and before
+// WithAttributeValueLengthLimit, so that values that are equal after
+// normalization are aggregated into one point.  For example,
+// strings.ToLower merges "GET" and "get".  The keys refer to the
+// renamed keys.  Values of other types and of other keys are not
+// modified.
+func WithValueNormalization(keys []attribute.Key, fn func(string) string) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.normalKeys = keys
+		clause.normalize = fn
+		return clause
+	})
+}
+
+// WithContextAttributes copies the named baggage members from the
+// context of each synchronous measurement into its attributes, as
+// string values.  Attributes passed by the caller take precedence
+// over baggage with the same key.  Context attributes are output only
+// by the views that configure them; WithKeys applies after they are
+// added, so a view with WithKeys must list them to output them.
+// Asynchronous instruments are not affected.
+func WithContextAttributes(keys []string) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.contextKeys = keys
+		return clause
+	})
+}
+
 func WithName(name string) ClauseOption {
 	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
 		clause.name = name
@@ -136,6 +227,190 @@ func WithAggregatorConfig(acfg aggregator.Config) ClauseOption {
 	})
 }
 
+// WithCardinalityLimit limits the number of distinct attribute sets
+// an instrument will track.  Once the limit is reached, measurements
+// for new attribute sets are aggregated into a single overflow point.
+// When no view of a synchronous instrument modifies attribute sets,
+// e.g., using WithKeys, the instrument also stops creating
+// aggregators for new attribute sets.  Zero means no limit.
+func WithCardinalityLimit(limit int) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.limit = limit
+		return clause
+	})
+}
+
+// WithOverflowAttribute sets the attribute of the overflow point
+// that WithCardinalityLimit aggregates new attribute sets into.  The
+// default is `otel.metric.overflow=true`.
+func WithOverflowAttribute(kv attribute.KeyValue) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.overflow = kv
+		return clause
+	})
+}
+
+// WithFilterCacheSize caches up to size results of applying the
+// WithKeys, WithAttributeRename, WithValueNormalization, and
+// WithAttributeValueLengthLimit options, keyed by the input attribute
+// set, so that repeated attribute combinations are filtered once.
+// The least-recently used entry is evicted when the cache is full.
+// Zero, the default, disables the cache.
+func WithFilterCacheSize(size int) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.cacheSize = size
+		return clause
+	})
+}
+
+// WithTemporalityConversion, when true, causes matching instruments
+// to report cumulative temporality even when the reader prefers delta
+// temporality.  Synchronous instruments accumulate deltas into a
+// running total per attribute set.  Asynchronous gauges treat their
+// observations as deltas and accumulate them into a running total
+// per attribute set, which is reset when the attribute set is not
+// observed by a collection; other asynchronous instruments report
+// their observations unmodified.
+func WithTemporalityConversion(convert bool) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.cumulative = convert
+		return clause
+	})
+}
+
+// WithDeltaTemporalityConversion, when true, causes matching
+// instruments to report delta temporality even when the reader
+// prefers cumulative temporality.  Asynchronous sums report the
+// difference from the prior observation of each attribute set, or
+// the full observation when a monotonic sum decreases, which
+// indicates a reset.  Prior observations are reclaimed once an
+// attribute set stops reporting, and their number is bounded by the
+// cardinality limit.
+func WithDeltaTemporalityConversion(convert bool) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.delta = convert
+		return clause
+	})
+}
+
+// WithTrimEmptyBuckets, when true, causes exponential histograms to
+// omit leading and trailing empty buckets from their output.  The
+// bucket offset is adjusted, so trimming does not change the
+// boundaries of the remaining buckets.
+func WithTrimEmptyBuckets(trim bool) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.trim = trim
+		return clause
+	})
+}
+
+// WithStaleGaugeDrop, when non-zero, causes cumulative gauges to
+// omit points that were not updated within d of the collection.
+// Stale points without active references are removed, reclaiming
+// their aggregators.  This sets the aggregator.Config
+// GaugeStaleAfter field of matching instruments, overriding the
+// aggregator configuration.
+func WithStaleGaugeDrop(d time.Duration) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.staleAfter = d
+		return clause
+	})
+}
+
+// WithValueTransform applies fn to each measurement before it is
+// aggregated by matching instruments, for example to convert units.
+// The transform applies to this view only, so views of the same
+// instrument may use different units.  The view outputs float64
+// values, also for integer instruments, except with a custom
+// aggregator.  Transformed values are range-tested like
+// measurements, e.g., a counter's negative result is dropped.
+func WithValueTransform(fn func(float64) float64) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.transform = fn
+		return clause
+	})
+}
+
+// WithUnitConversion converts the measurements of matching
+// instruments whose unit is from into unit to, multiplying each
+// measurement by factor before it is aggregated and outputting the
+// instrument with unit to.  Since measurements are converted, sums,
+// gauges, and histograms agree, and explicit histogram boundaries
+// are in unit to.  The conversion applies after WithValueTransform.
+// The view outputs float64 values, as for WithValueTransform.  An
+// instrument whose unit is not from is
+// compiled without conversion, with an error.
+func WithUnitConversion(from, to string, factor float64) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.unitFrom = from
+		clause.unitTo = to
+		clause.unitFactor = factor
+		return clause
+	})
+}
+
+// WithPointProcessor calls fn with each point of matching
+// instruments after it is collected and before it is output, e.g.,
+// to add an attribute computed from the others.  fn is called
+// without holding any instrument or aggregator lock.  fn may replace
+// the point's attributes; points whose attributes become equal are
+// merged.  Changes to the point's Aggregation are discarded.
+func WithPointProcessor(fn func(*data.Point)) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.processor = fn
+		return clause
+	})
+}
+
+// WithAttributeSetTTL, when non-zero, causes synchronous instruments
+// to remove the aggregator of an attribute set that was not updated
+// within d, during the next collection, freeing its memory.  For
+// cumulative temporality this discards the attribute set's history:
+// when it is used again, it starts a new series with a new start
+// time.  Zero, the default, keeps attribute sets indefinitely.
+func WithAttributeSetTTL(d time.Duration) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.ttl = d
+		return clause
+	})
+}
+
+// WithRoute routes the attribute sets of matching synchronous
+// instruments for which fn returns true to this pipeline, i.e., the
+// reader configured with these views.  Routed attribute sets are not
+// delivered to other pipelines, except those that also route them.
+// Attribute sets that no pipeline routes fall through to the
+// pipelines that do not route the instrument.  fn receives the
+// unfiltered attributes and is not called for every measurement.
+// Asynchronous instruments are not routed.
+func WithRoute(fn func(attribute.Set) bool) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.route = fn
+		return clause
+	})
+}
+
+// WithStartTimeAlignment, when non-zero, causes cumulative points of
+// matching instruments to start at epoch instead of the time the
+// reader started, so that series report the same start time across
+// process restarts.  Points still start later than epoch after a
+// Reset(), after an attribute set is reclaimed (see
+// WithAttributeSetTTL), or when epoch is after the collection time.
+// Delta points are not affected.
+//
+// Consumers that detect a reset of a cumulative series by a change
+// of start time will not observe a restart, since the start time is
+// unchanged; the value decreases instead.  Backends that treat a
+// decrease of a monotonic sum as a reset still compute continuous
+// rates, while those that rely on the start time alone compute a
+// negative rate across the restart.
+func WithStartTimeAlignment(epoch time.Time) ClauseOption {
+	return clauseOptionFunction(func(clause ClauseConfig) ClauseConfig {
+		clause.startEpoch = epoch
+		return clause
+	})
+}
+
 // IsSingleInstrument is a requirement when HasName().
 func (c *ClauseConfig) IsSingleInstrument() bool {
 	return c.instrumentName != ""
@@ -154,6 +429,28 @@ func (c *ClauseConfig) Keys() []attribute.Key {
 	return c.keys
 }
 
+func (c *ClauseConfig) KeyPatterns() []string {
+	return c.keyPatterns
+}
+
+func (c *ClauseConfig) AttributeRename() map[attribute.Key]attribute.Key {
+	return c.rename
+}
+
+func (c *ClauseConfig) AttributeValueLengthLimit() int {
+	return c.valueLimit
+}
+
+// ValueNormalization returns the keys and the function configured
+// by WithValueNormalization.
+func (c *ClauseConfig) ValueNormalization() ([]attribute.Key, func(string) string) {
+	return c.normalKeys, c.normalize
+}
+
+func (c *ClauseConfig) ContextAttributes() []string {
+	return c.contextKeys
+}
+
 func (c *ClauseConfig) Description() string {
 	return c.description
 }
@@ -166,6 +463,62 @@ func (c *ClauseConfig) AggregatorConfig() aggregator.Config {
 	return c.acfg
 }
 
+func (c *ClauseConfig) CardinalityLimit() int {
+	return c.limit
+}
+
+// OverflowAttribute returns the attribute configured by
+// WithOverflowAttribute, which is not Valid() when unset.
+func (c *ClauseConfig) OverflowAttribute() attribute.KeyValue {
+	return c.overflow
+}
+
+func (c *ClauseConfig) FilterCacheSize() int {
+	return c.cacheSize
+}
+
+func (c *ClauseConfig) TemporalityConversion() bool {
+	return c.cumulative
+}
+
+func (c *ClauseConfig) DeltaTemporalityConversion() bool {
+	return c.delta
+}
+
+func (c *ClauseConfig) TrimEmptyBuckets() bool {
+	return c.trim
+}
+
+func (c *ClauseConfig) StaleGaugeDrop() time.Duration {
+	return c.staleAfter
+}
+
+func (c *ClauseConfig) ValueTransform() func(float64) float64 {
+	return c.transform
+}
+
+// UnitConversion returns the units and factor configured by
+// WithUnitConversion, where a zero factor means no conversion.
+func (c *ClauseConfig) UnitConversion() (from, to string, factor float64) {
+	return c.unitFrom, c.unitTo, c.unitFactor
+}
+
+func (c *ClauseConfig) PointProcessor() func(*data.Point) {
+	return c.processor
+}
+
+func (c *ClauseConfig) AttributeSetTTL() time.Duration {
+	return c.ttl
+}
+
+func (c *ClauseConfig) Route() func(attribute.Set) bool {
+	return c.route
+}
+
+func (c *ClauseConfig) StartTimeAlignment() time.Time {
+	return c.startEpoch
+}
+
 func stringMismatch(test, value string) bool {
 	return test != "" && test != value
 }
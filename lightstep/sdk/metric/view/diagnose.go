@@ -0,0 +1,116 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package view // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
+
+import (
+	"fmt"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+)
+
+// DefaultClause is the Diagnostic Clause index used when no clause
+// matches an instrument and the default settings apply.
+const DefaultClause = -1
+
+// Diagnostic describes the effect of a Views on one instrument, as
+// computed by Diagnose.
+type Diagnostic struct {
+	// Instrument is the diagnosed instrument.
+	Instrument sdkinstrument.Descriptor
+
+	// Clause is the index into Views.Clauses of the matching
+	// clause, or DefaultClause.
+	Clause int
+
+	// Name is the name of the resulting metric.
+	Name string
+
+	// Aggregation is the configured aggregation kind, or the
+	// default for the instrument kind when the clause does not
+	// set one.  DropKind indicates the clause drops the
+	// instrument.
+	Aggregation aggregation.Kind
+
+	// Conflict is non-nil when an earlier diagnostic for the same
+	// instrument produces a metric of the same name with a
+	// different aggregation.
+	Conflict error
+}
+
+// Diagnose reports which clauses of v match each of the descriptors,
+// for instruments of the instrumentation library lib, without
+// constructing an SDK.  Each matching clause yields a Diagnostic; an
+// instrument that matches no clause yields one Diagnostic with
+// DefaultClause.  Diagnose uses the same matching rules as the SDK
+// but does not account for instrument hints or for corrections made
+// by the SDK to aggregations that are invalid for the instrument
+// kind.  The Views should be checked using Validate first.
+func Diagnose(v *Views, lib instrumentation.Library, descriptors []sdkinstrument.Descriptor) []Diagnostic {
+	var diags []Diagnostic
+
+	for _, desc := range descriptors {
+		start := len(diags)
+
+		for idx := range v.Clauses {
+			clause := &v.Clauses[idx]
+			if !clause.Matches(lib, desc) {
+				continue
+			}
+			diag := Diagnostic{
+				Instrument:  desc,
+				Clause:      idx,
+				Name:        desc.Name,
+				Aggregation: clause.Aggregation(),
+			}
+			if clause.HasName() {
+				diag.Name = clause.Name()
+			}
+			if diag.Aggregation == aggregation.UndefinedKind {
+				diag.Aggregation = v.Defaults.Aggregation(desc.Kind)
+			}
+			diag.Conflict = findConflict(diags[start:], diag)
+			diags = append(diags, diag)
+		}
+
+		if len(diags) == start {
+			diags = append(diags, Diagnostic{
+				Instrument:  desc,
+				Clause:      DefaultClause,
+				Name:        desc.Name,
+				Aggregation: v.Defaults.Aggregation(desc.Kind),
+			})
+		}
+	}
+	return diags
+}
+
+// findConflict returns an error when one of the prior diagnostics
+// produces the same output as diag with a different aggregation.
+func findConflict(prior []Diagnostic, diag Diagnostic) error {
+	if diag.Aggregation == aggregation.DropKind {
+		return nil
+	}
+	for _, other := range prior {
+		if other.Aggregation == aggregation.DropKind || other.Name != diag.Name {
+			continue
+		}
+		if other.Aggregation != diag.Aggregation {
+			return fmt.Errorf("clauses %d and %d configure %s as %v and %v", other.Clause, diag.Clause, diag.Name, other.Aggregation, diag.Aggregation)
+		}
+	}
+	return nil
+}
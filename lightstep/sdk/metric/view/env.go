@@ -0,0 +1,62 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package view // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
+	"go.opentelemetry.io/otel"
+)
+
+// TemporalityPreferenceEnv is the environment variable that sets the
+// default temporality preference of every Views, one of
+// "cumulative", "delta", or "lowmemory".
+const TemporalityPreferenceEnv = "OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE"
+
+// TemporalityPreferenceSelector returns the temporality selector for
+// a standard temporality preference, one of "cumulative", "delta",
+// or "lowmemory" (case-insensitive).
+func TemporalityPreferenceSelector(pref string) (aggregation.TemporalitySelector, error) {
+	switch strings.ToLower(pref) {
+	case "cumulative":
+		return StandardTemporality, nil
+	case "delta":
+		return DeltaPreferredTemporality, nil
+	case "lowmemory":
+		return LowMemoryTemporality, nil
+	}
+	return nil, fmt.Errorf("invalid temporality preference: %q", pref)
+}
+
+// withEnvTemporalityPreference applies the temporality preference
+// set in the environment, if any.  An invalid setting is reported
+// through the OpenTelemetry error handler and ignored.
+func withEnvTemporalityPreference() Option {
+	return optionFunction(func(cfg Config) Config {
+		pref, ok := os.LookupEnv(TemporalityPreferenceEnv)
+		if !ok || pref == "" {
+			return cfg
+		}
+		selector, err := TemporalityPreferenceSelector(pref)
+		if err != nil {
+			otel.Handle(fmt.Errorf("%s: %w", TemporalityPreferenceEnv, err))
+			return cfg
+		}
+		return WithDefaultAggregationTemporalitySelector(selector).apply(cfg)
+	})
+}
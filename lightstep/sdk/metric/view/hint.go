@@ -15,22 +15,11 @@
 package view // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
 
 import (
-	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument/hint"
 )
 
 // Hint is a structure that can be serialized into a Description field
 // to control the aggregation and config. These hints are only taken
-// when no other View clauses match the instrument.
-type Hint struct {
-	// Description takes the place of the hint after decoding
-	// this Hint from the Description.
-	Description string `json:"description"`
-
-	// Aggregation determines the kind of aggregator used.  When
-	// this is set, semantic compatibility checking is bypassed.
-	Aggregation string `json:"aggregation"`
-
-	// Config configures the aggregator selected in the
-	// Aggregation field.
-	Config aggregator.JSONConfig `json:"config"`
-}
+// when no other View clauses match the instrument.  See the hint
+// package, which parses this structure.
+type Hint = hint.Encoding
@@ -27,7 +27,7 @@ func StandardAggregationKind(ik sdkinstrument.Kind) aggregation.Kind {
 	case sdkinstrument.SyncHistogram:
 		// Note: the default is Exponential Histogram, not MinMaxSumCount.
 		return aggregation.HistogramKind
-	case sdkinstrument.AsyncGauge:
+	case sdkinstrument.AsyncGauge, sdkinstrument.SyncGauge:
 		return aggregation.GaugeKind
 	case sdkinstrument.SyncUpDownCounter, sdkinstrument.AsyncUpDownCounter:
 		return aggregation.NonMonotonicSumKind
@@ -54,6 +54,19 @@ func DeltaPreferredTemporality(ik sdkinstrument.Kind) aggregation.Temporality {
 	}
 }
 
+// LowMemoryTemporality returns a function that configures a
+// preference for Delta temporality for synchronous Counter and
+// Histogram instruments, which avoids keeping cumulative state for
+// them, and Cumulative temporality for all other instrument kinds.
+func LowMemoryTemporality(ik sdkinstrument.Kind) aggregation.Temporality {
+	switch ik {
+	case sdkinstrument.SyncCounter, sdkinstrument.SyncHistogram:
+		return aggregation.DeltaTemporality
+	default:
+		return aggregation.CumulativeTemporality
+	}
+}
+
 // StandardConfig returns a function that configures two default aggregator.Configs.
 func StandardConfig(ik sdkinstrument.Kind) (ints, floats aggregator.Config) {
 	return aggregator.Config{}, aggregator.Config{}
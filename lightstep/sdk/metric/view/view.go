@@ -25,6 +25,7 @@ import (
 //
 // The configurable aspects are:
 // - Clauses in effect
+// - The policy for conflicting duplicate instruments
 // - Defaults by instrument kind for:
 //   - Aggregation Kind
 //   - Aggregation Temporality
@@ -43,6 +44,39 @@ type DefaultConfig struct {
 		Int64       aggregator.Config
 		Float64     aggregator.Config
 	}
+
+	// Duplicates is the policy for conflicting duplicate
+	// instruments.
+	Duplicates DuplicatePolicy
+}
+
+// DuplicatePolicy determines how conflicting duplicate instruments
+// are output.  Instruments of one Meter conflict when they have the
+// same name after views apply but cannot be combined, for example
+// because their kinds or units differ.  Regardless of the policy,
+// the conflict is returned to the caller that registered the later
+// instrument, with the descriptors of the instruments involved.
+type DuplicatePolicy int
+
+const (
+	// KeepAllDuplicates outputs each of the conflicting
+	// instruments under the same name.  This is the default.
+	KeepAllDuplicates DuplicatePolicy = iota
+
+	// KeepFirstDuplicate outputs the first of the conflicting
+	// instruments and drops the later ones.
+	KeepFirstDuplicate
+
+	// RenameDuplicates outputs the later conflicting instruments
+	// under the name with the first unused numeric suffix, e.g.,
+	// "name.2".
+	RenameDuplicates
+)
+
+// Valid returns true when the value is one of the enumerated
+// constants.
+func (p DuplicatePolicy) Valid() bool {
+	return p >= KeepAllDuplicates && p <= RenameDuplicates
 }
 
 // Aggregation returns the default aggregation.Kind for each instrument kind.
@@ -57,27 +91,33 @@ func (d *DefaultConfig) Temporality(k sdkinstrument.Kind) aggregation.Temporalit
 
 // AggregationConfig returns the default aggregation.Temporality for each instrument kind.
 func (d *DefaultConfig) AggregationConfig(k sdkinstrument.Kind, nk number.Kind) aggregator.Config {
-	if nk == number.Int64Kind {
-		return d.ByInstrumentKind[k].Int64
+	if nk == number.Float64Kind {
+		return d.ByInstrumentKind[k].Float64
 	}
-	return d.ByInstrumentKind[k].Float64
+	return d.ByInstrumentKind[k].Int64
 }
 
 // WithClause adds a clause to the Views configuration.
 func WithClause(options ...ClauseOption) Option {
 	return optionFunction(func(cfg Config) Config {
-		clause := ClauseConfig{
-			instrumentKind: unsetInstrumentKind,
-			numberKind:     unsetNumberKind,
-		}
-		for _, option := range options {
-			clause = option.apply(clause)
-		}
-		cfg.Clauses = append(cfg.Clauses, clause)
+		cfg.Clauses = append(cfg.Clauses, NewClause(options...))
 		return cfg
 	})
 }
 
+// NewClause returns a clause configured as by WithClause, for use
+// outside a Views configuration.
+func NewClause(options ...ClauseOption) ClauseConfig {
+	clause := ClauseConfig{
+		instrumentKind: unsetInstrumentKind,
+		numberKind:     unsetNumberKind,
+	}
+	for _, option := range options {
+		clause = option.apply(clause)
+	}
+	return clause
+}
+
 // WithDefaultAggregationKindSelector configures the default
 // aggregation.Kind to use with each kind of instrument.  This
 // overwrites previous settings of the same option.
@@ -114,6 +154,15 @@ func WithDefaultAggregationConfigSelector(d aggregator.ConfigSelector) Option {
 	})
 }
 
+// WithDuplicatePolicy configures how conflicting duplicate
+// instruments are output, see DuplicatePolicy.
+func WithDuplicatePolicy(p DuplicatePolicy) Option {
+	return optionFunction(func(cfg Config) Config {
+		cfg.Defaults.Duplicates = p
+		return cfg
+	})
+}
+
 // Option applies a configuration option value to a view Config.
 type Option interface {
 	apply(Config) Config
@@ -127,11 +176,14 @@ func (of optionFunction) apply(in Config) Config {
 	return of(in)
 }
 
-// NewConfig returns a new and configured view Config.
+// NewConfig returns a new and configured view Config.  The default
+// temporality is cumulative unless the environment sets a preference,
+// see TemporalityPreferenceEnv; options take precedence over both.
 func NewConfig(options ...Option) Config {
 	standard := []Option{
 		WithDefaultAggregationKindSelector(StandardAggregationKind),
 		WithDefaultAggregationTemporalitySelector(StandardTemporality),
+		withEnvTemporalityPreference(),
 		WithDefaultAggregationConfigSelector(StandardConfig),
 	}
 	var cfg Config
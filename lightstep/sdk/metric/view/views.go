@@ -16,10 +16,13 @@ package view // import "github.com/lightstep/otel-launcher-go/lightstep/sdk/metr
 
 import (
 	"fmt"
+	"math"
+	"path"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation" // Views is a configured set of view clauses with an associated Name
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/multierr"
 )
 
@@ -41,7 +44,7 @@ func New(name string, opts ...Option) *Views {
 }
 
 func checkAggregation(err error, agg *aggregation.Kind, def aggregation.Kind) error {
-	if !agg.Valid() {
+	if _, custom := aggregator.Lookup(*agg); !agg.Valid() && !custom {
 		err = multierr.Append(err, fmt.Errorf("invalid aggregation: %v", *agg))
 		*agg = def
 	}
@@ -91,6 +94,10 @@ func Validate(v *Views) (*Views, error) {
 		err = checkAggConfig(err, &valid.Defaults.ByInstrumentKind[i].Int64)
 		err = checkAggConfig(err, &valid.Defaults.ByInstrumentKind[i].Float64)
 	}
+	if !valid.Defaults.Duplicates.Valid() {
+		err = multierr.Append(err, fmt.Errorf("invalid duplicate policy: %d", valid.Defaults.Duplicates))
+		valid.Defaults.Duplicates = KeepAllDuplicates
+	}
 
 	for i := range valid.Clauses {
 		clause := &valid.Clauses[i]
@@ -103,6 +110,13 @@ func Validate(v *Views) (*Views, error) {
 		err = checkAggregation(err, &clause.aggregation, aggregation.UndefinedKind)
 		err = checkAggConfig(err, &clause.acfg)
 
+		if clause.cumulative && clause.delta {
+			err = multierr.Append(err, fmt.Errorf("view has cumulative and delta temporality conversion"))
+			// Note: correct by dropping both conversions.
+			clause.cumulative = false
+			clause.delta = false
+		}
+
 		if clause.instrumentName != "" && clause.instrumentNameRegexp != nil {
 			err = multierr.Append(err, fmt.Errorf("view has instrument name and regexp matches"))
 			// Note: prefer the name over the regexp.
@@ -114,6 +128,62 @@ func Validate(v *Views) (*Views, error) {
 				err = multierr.Append(err, fmt.Errorf("view has empty string in keys"))
 			}
 		}
+
+		if clause.keyPatterns != nil {
+			patterns := make([]string, 0, len(clause.keyPatterns))
+			for _, pattern := range clause.keyPatterns {
+				if _, perr := path.Match(pattern, ""); pattern == "" || perr != nil {
+					// Note: correct by dropping the pattern.
+					err = multierr.Append(err, fmt.Errorf("view has invalid key pattern: %q", pattern))
+					continue
+				}
+				patterns = append(patterns, pattern)
+			}
+			clause.keyPatterns = patterns
+		}
+
+		for from, to := range clause.rename {
+			if from == "" || to == "" {
+				err = multierr.Append(err, fmt.Errorf("view has empty string in attribute rename"))
+				break
+			}
+		}
+
+		if clause.normalKeys != nil && clause.normalize == nil {
+			// Note: correct by dropping the keys.
+			err = multierr.Append(err, fmt.Errorf("view has value normalization keys without a function"))
+			clause.normalKeys = nil
+		}
+		for _, key := range clause.normalKeys {
+			if key == "" {
+				err = multierr.Append(err, fmt.Errorf("view has empty string in value normalization keys"))
+				break
+			}
+		}
+
+		if clause.limit < 0 {
+			err = multierr.Append(err, fmt.Errorf("invalid cardinality limit: %d", clause.limit))
+			clause.limit = 0
+		}
+
+		if (clause.overflow.Key != "" || clause.overflow.Value.Type() != attribute.INVALID) && !clause.overflow.Valid() {
+			// Note: correct by using the default attribute.
+			err = multierr.Append(err, fmt.Errorf("view has invalid overflow attribute: %q", clause.overflow.Key))
+			clause.overflow = attribute.KeyValue{}
+		}
+
+		if clause.unitFrom != "" || clause.unitTo != "" || clause.unitFactor != 0 {
+			if !(clause.unitFactor > 0) || math.IsInf(clause.unitFactor, 0) {
+				// Note: correct by dropping the conversion.
+				err = multierr.Append(err, fmt.Errorf("invalid unit conversion factor: %v", clause.unitFactor))
+				clause.unitFrom, clause.unitTo, clause.unitFactor = "", "", 0
+			}
+		}
+
+		if clause.ttl < 0 {
+			err = multierr.Append(err, fmt.Errorf("invalid attribute set TTL: %v", clause.ttl))
+			clause.ttl = 0
+		}
 	}
 
 	return valid, err
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
@@ -25,6 +26,7 @@ import (
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/number"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 )
@@ -109,6 +111,13 @@ func TestClauseProperties(t *testing.T) {
 		WithClause(WithAggregatorConfig(aggregator.Config{
 			Histogram: histogram.NewConfig(histogram.WithMaxSize(177)),
 		})),
+		WithClause(WithCardinalityLimit(100)),
+		WithClause(WithTemporalityConversion(true)),
+		WithClause(WithAttributeRename(map[attribute.Key]attribute.Key{"a": "b"})),
+		WithClause(WithTrimEmptyBuckets(true)),
+		WithClause(WithValueTransform(func(x float64) float64 { return x * 2 })),
+		WithClause(WithStaleGaugeDrop(time.Minute)),
+		WithClause(WithDeltaTemporalityConversion(true)),
 	)
 
 	views, err := Validate(views)
@@ -119,6 +128,19 @@ func TestClauseProperties(t *testing.T) {
 	require.Equal(t, []attribute.Key{}, views.Clauses[3].Keys())
 	require.Equal(t, aggregation.DropKind, views.Clauses[4].Aggregation())
 	require.Equal(t, aggregator.Config{Histogram: histogram.NewConfig(histogram.WithMaxSize(177))}, views.Clauses[5].AggregatorConfig())
+	require.Equal(t, 100, views.Clauses[6].CardinalityLimit())
+	require.True(t, views.Clauses[7].TemporalityConversion())
+	require.False(t, views.Clauses[6].TemporalityConversion())
+	require.Equal(t, map[attribute.Key]attribute.Key{"a": "b"}, views.Clauses[8].AttributeRename())
+	require.Nil(t, views.Clauses[7].AttributeRename())
+	require.True(t, views.Clauses[9].TrimEmptyBuckets())
+	require.False(t, views.Clauses[8].TrimEmptyBuckets())
+	require.Equal(t, 6.0, views.Clauses[10].ValueTransform()(3))
+	require.Nil(t, views.Clauses[9].ValueTransform())
+	require.Equal(t, time.Minute, views.Clauses[11].StaleGaugeDrop())
+	require.Equal(t, time.Duration(0), views.Clauses[10].StaleGaugeDrop())
+	require.True(t, views.Clauses[12].DeltaTemporalityConversion())
+	require.False(t, views.Clauses[7].DeltaTemporalityConversion())
 }
 
 func TestNameAndRegexp(t *testing.T) {
@@ -146,6 +168,109 @@ func TestEmptyKeyString(t *testing.T) {
 	require.Contains(t, err.Error(), "view has empty string in keys")
 }
 
+func TestInvalidKeyPattern(t *testing.T) {
+	views := New("test", WithClause(
+		WithKeyPatterns([]string{"http.*", "[a", ""}),
+	))
+
+	valid, err := Validate(views)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `view has invalid key pattern: "[a"`)
+	require.Contains(t, err.Error(), `view has invalid key pattern: ""`)
+	require.Equal(t, []string{"http.*"}, valid.Clauses[0].KeyPatterns())
+	require.Equal(t, []string{"http.*", "[a", ""}, views.Clauses[0].KeyPatterns())
+}
+
+func TestEmptyAttributeRename(t *testing.T) {
+	views := New("test", WithClause(
+		WithAttributeRename(map[attribute.Key]attribute.Key{
+			"a": "",
+		}),
+	))
+
+	_, err := Validate(views)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "view has empty string in attribute rename")
+}
+
+func TestInvalidValueNormalization(t *testing.T) {
+	views := New("test", WithClause(
+		WithValueNormalization([]attribute.Key{"method"}, nil),
+	))
+
+	valid, err := Validate(views)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "view has value normalization keys without a function")
+	keys, _ := valid.Clauses[0].ValueNormalization()
+	require.Nil(t, keys)
+}
+
+func TestNegativeCardinalityLimit(t *testing.T) {
+	views := New("test", WithClause(
+		WithCardinalityLimit(-1),
+	))
+
+	valid, err := Validate(views)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid cardinality limit")
+	require.Equal(t, 0, valid.Clauses[0].CardinalityLimit())
+}
+
+func TestInvalidOverflowAttribute(t *testing.T) {
+	views := New("test", WithClause(
+		WithOverflowAttribute(attribute.KeyValue{Key: "overflow"}),
+	))
+
+	valid, err := Validate(views)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid overflow attribute")
+	require.False(t, valid.Clauses[0].OverflowAttribute().Valid())
+}
+
+func TestInvalidUnitConversion(t *testing.T) {
+	views := New("test", WithClause(
+		WithUnitConversion("By", "KiBy", -1),
+	))
+
+	valid, err := Validate(views)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid unit conversion factor")
+	_, _, factor := valid.Clauses[0].UnitConversion()
+	require.Equal(t, 0.0, factor)
+}
+
+func TestNegativeAttributeSetTTL(t *testing.T) {
+	views := New("test", WithClause(
+		WithAttributeSetTTL(-time.Second),
+	))
+
+	valid, err := Validate(views)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid attribute set TTL")
+	require.Equal(t, time.Duration(0), valid.Clauses[0].AttributeSetTTL())
+}
+
+func TestConflictingTemporalityConversion(t *testing.T) {
+	views := New("test", WithClause(
+		WithTemporalityConversion(true),
+		WithDeltaTemporalityConversion(true),
+	))
+
+	valid, err := Validate(views)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cumulative and delta temporality conversion")
+	require.False(t, valid.Clauses[0].TemporalityConversion())
+	require.False(t, valid.Clauses[0].DeltaTemporalityConversion())
+}
+
 func TestSingleNameConflict(t *testing.T) {
 	views := New("test", WithClause(
 		WithName("aha"),
@@ -184,6 +309,20 @@ func TestDeltaPreferredTemporality(t *testing.T) {
 	}
 }
 
+func TestLowMemoryTemporality(t *testing.T) {
+	views := New("test",
+		WithDefaultAggregationTemporalitySelector(LowMemoryTemporality),
+	)
+	for i := sdkinstrument.Kind(0); i < sdkinstrument.NumKinds; i++ {
+		switch i {
+		case sdkinstrument.SyncCounter, sdkinstrument.SyncHistogram:
+			require.Equal(t, aggregation.DeltaTemporality, views.Defaults.Temporality(i))
+		default:
+			require.Equal(t, aggregation.CumulativeTemporality, views.Defaults.Temporality(i))
+		}
+	}
+}
+
 func TestStandardAggregation(t *testing.T) {
 	views := New("test",
 		WithDefaultAggregationKindSelector(StandardAggregationKind),
@@ -194,7 +333,7 @@ func TestStandardAggregation(t *testing.T) {
 func expectStandardAggregation(t *testing.T, v *Views) {
 	for i := sdkinstrument.Kind(0); i < sdkinstrument.NumKinds; i++ {
 		switch i {
-		case sdkinstrument.AsyncGauge:
+		case sdkinstrument.AsyncGauge, sdkinstrument.SyncGauge:
 			require.Equal(t, aggregation.GaugeKind, v.Defaults.Aggregation(i))
 		case sdkinstrument.SyncCounter, sdkinstrument.AsyncCounter:
 			require.Equal(t, aggregation.MonotonicSumKind, v.Defaults.Aggregation(i))
@@ -246,13 +385,15 @@ func TestInvalidViewDefaults(t *testing.T) {
 
 func TestHintEncoding(t *testing.T) {
 	var hint Hint
+	maxScale := int32(4)
 
 	require.NoError(t, json.Unmarshal([]byte(`{
   "description": "lala",
   "aggregation": "lolo",
   "config": {
     "histogram": {
-      "max_size": 199
+      "max_size": 199,
+      "max_scale": 4
     }
   }
 }`), &hint))
@@ -261,8 +402,109 @@ func TestHintEncoding(t *testing.T) {
 		Aggregation: "lolo",
 		Config: aggregator.JSONConfig{
 			Histogram: aggregator.JSONHistogramConfig{
-				MaxSize: 199,
+				MaxSize:  199,
+				MaxScale: &maxScale,
 			},
 		},
 	}, hint)
 }
+
+func TestEnvTemporalityPreference(t *testing.T) {
+	const (
+		cumulative = aggregation.CumulativeTemporality
+		delta      = aggregation.DeltaTemporality
+	)
+	for _, test := range []struct {
+		pref   string
+		expect [sdkinstrument.NumKinds]aggregation.Temporality
+	}{
+		{"", [...]aggregation.Temporality{cumulative, cumulative, cumulative, cumulative, cumulative, cumulative, cumulative}},
+		{"cumulative", [...]aggregation.Temporality{cumulative, cumulative, cumulative, cumulative, cumulative, cumulative, cumulative}},
+		{"Delta", [...]aggregation.Temporality{delta, cumulative, delta, delta, cumulative, delta, delta}},
+		{"lowmemory", [...]aggregation.Temporality{delta, cumulative, delta, cumulative, cumulative, cumulative, cumulative}},
+	} {
+		t.Run(test.pref, func(t *testing.T) {
+			t.Setenv(TemporalityPreferenceEnv, test.pref)
+
+			views := New("test")
+			for k := sdkinstrument.Kind(0); k < sdkinstrument.NumKinds; k++ {
+				require.Equal(t, test.expect[k], views.Defaults.Temporality(k), "%v", k)
+			}
+
+			// Options take precedence.
+			views = New("test", WithDefaultAggregationTemporalitySelector(StandardTemporality))
+			for k := sdkinstrument.Kind(0); k < sdkinstrument.NumKinds; k++ {
+				require.Equal(t, cumulative, views.Defaults.Temporality(k), "%v", k)
+			}
+		})
+	}
+}
+
+func TestEnvTemporalityPreferenceInvalid(t *testing.T) {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+
+	t.Setenv(TemporalityPreferenceEnv, "sometimes")
+
+	views := New("test")
+	for k := sdkinstrument.Kind(0); k < sdkinstrument.NumKinds; k++ {
+		require.Equal(t, aggregation.CumulativeTemporality, views.Defaults.Temporality(k))
+	}
+	require.Equal(t, 1, len(errs))
+	require.Contains(t, errs[0].Error(), "invalid temporality preference")
+}
+
+func TestDiagnose(t *testing.T) {
+	views, err := Validate(New("test",
+		WithClause(MatchInstrumentName("a"), WithAggregation(aggregation.DropKind)),
+		WithClause(MatchInstrumentName("b")),
+		WithClause(MatchInstrumentName("b"), WithAggregation(aggregation.MinMaxSumCountKind)),
+		WithClause(MatchInstrumentName("c"), WithName("c1"), WithAggregation(aggregation.ExplicitHistogramKind)),
+		WithClause(MatchInstrumentName("c"), WithName("c2")),
+	))
+	require.NoError(t, err)
+
+	descA := sdkinstrument.NewDescriptor("a", sdkinstrument.SyncCounter, number.Int64Kind, "", "")
+	descB := sdkinstrument.NewDescriptor("b", sdkinstrument.SyncHistogram, number.Float64Kind, "", "")
+	descC := sdkinstrument.NewDescriptor("c", sdkinstrument.SyncHistogram, number.Float64Kind, "", "")
+	descD := sdkinstrument.NewDescriptor("d", sdkinstrument.AsyncGauge, number.Int64Kind, "", "")
+
+	diags := Diagnose(views, instrumentation.Library{}, []sdkinstrument.Descriptor{descA, descB, descC, descD})
+	require.Equal(t, 6, len(diags))
+
+	// "a" is dropped.
+	require.Equal(t, Diagnostic{
+		Instrument:  descA,
+		Clause:      0,
+		Name:        "a",
+		Aggregation: aggregation.DropKind,
+	}, diags[0])
+
+	// "b" matches two clauses with different aggregations for
+	// the same output name.
+	require.Equal(t, 1, diags[1].Clause)
+	require.Equal(t, aggregation.HistogramKind, diags[1].Aggregation)
+	require.NoError(t, diags[1].Conflict)
+	require.Equal(t, 2, diags[2].Clause)
+	require.Equal(t, aggregation.MinMaxSumCountKind, diags[2].Aggregation)
+	require.Error(t, diags[2].Conflict)
+	require.Contains(t, diags[2].Conflict.Error(), "clauses 1 and 2")
+
+	// "c" is renamed twice without conflict.
+	require.Equal(t, "c1", diags[3].Name)
+	require.Equal(t, aggregation.ExplicitHistogramKind, diags[3].Aggregation)
+	require.Equal(t, "c2", diags[4].Name)
+	require.Equal(t, aggregation.HistogramKind, diags[4].Aggregation)
+	require.NoError(t, diags[4].Conflict)
+
+	// "d" uses the defaults.
+	require.Equal(t, Diagnostic{
+		Instrument:  descD,
+		Clause:      DefaultClause,
+		Name:        "d",
+		Aggregation: aggregation.GaugeKind,
+	}, diags[5])
+}
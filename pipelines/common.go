@@ -15,6 +15,8 @@
 package pipelines
 
 import (
+	"crypto/tls"
+
 	"google.golang.org/grpc/credentials"
 
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
@@ -59,6 +61,41 @@ type PipelineConfig struct {
 	// UseLightstepMetricsSDK determines whether to use the metrics
 	// SDK at ../lightstep/sdk/metric.
 	UseLightstepMetricsSDK bool
+
+	// PrometheusAddress, when set, is the listen address of an
+	// HTTP server exposing metrics at /metrics in the Prometheus
+	// text format, alongside the OTLP exporter.  Requires the
+	// Lightstep metrics SDK and cumulative temporality.
+	PrometheusAddress string
+
+	// RetryInitialInterval, RetryMaxInterval, and
+	// RetryMaxElapsedTime configure the OTLP metrics exporter's
+	// exponential backoff for transient errors, as durations
+	// (e.g., "5s").  Empty values use the exporter's defaults.
+	// The elapsed time is limited to the reporting period.
+	RetryInitialInterval string
+	RetryMaxInterval     string
+	RetryMaxElapsedTime  string
+
+	// DiskBufferPath, when set, is a directory in which the OTLP
+	// metrics exporter keeps batches that it fails to export, to
+	// be replayed oldest-first once the endpoint is reachable.
+	// DiskBufferSize limits the bytes kept there; zero uses
+	// DefaultDiskBufferSize.
+	DiskBufferPath string
+	DiskBufferSize int64
+
+	// Protocol selects the OTLP metrics protocol, ProtocolGRPC
+	// (the default) or ProtocolHTTPProtobuf.
+	Protocol string
+
+	// Compression is "gzip" (the default) or "none", for the OTLP
+	// metrics exporter.
+	Compression string
+
+	// TLSConfig, when set, carries the OTLP metrics exporter's TLS
+	// settings.  Credentials takes precedence for gRPC.
+	TLSConfig *tls.Config
 }
 
 type PipelineSetupFunc func(PipelineConfig) (func() error, error)
@@ -68,6 +105,8 @@ func (p PipelineConfig) secureMetricOption() otlpmetricgrpc.Option {
 		return otlpmetricgrpc.WithInsecure()
 	} else if p.Credentials != nil {
 		return otlpmetricgrpc.WithTLSCredentials(p.Credentials)
+	} else if p.TLSConfig != nil {
+		return otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(p.TLSConfig))
 	}
 	return otlpmetricgrpc.WithTLSCredentials(
 		credentials.NewClientTLSFromCert(nil, ""),
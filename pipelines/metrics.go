@@ -18,6 +18,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +28,9 @@ import (
 	sdkmetric "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/aggregator/aggregation"
 	otlpmetric "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp/diskbuffer"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp/otlpmetrichttp"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/prometheus"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/sdkinstrument"
 	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/view"
 
@@ -50,6 +55,8 @@ import (
 	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
 	selector "go.opentelemetry.io/otel/sdk/metric/selector/simple"
 
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/metadata"
@@ -117,6 +124,11 @@ func NewMetricsPipeline(c PipelineConfig) (func() error, error) {
 			return nil, fmt.Errorf("invalid metric reporting period: %v", c.ReportingPeriod)
 		}
 	}
+	retry, err := c.retryConfig(period)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metric retry configuration: %v", err)
+	}
+
 	var provider metric.MeterProvider
 	var shutdown func() error
 
@@ -124,30 +136,52 @@ func NewMetricsPipeline(c PipelineConfig) (func() error, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid metric view configuration: %v", err)
 	}
+	if err := checkPrometheusConfig(c); err != nil {
+		return nil, fmt.Errorf("invalid prometheus configuration: %v", err)
+	}
 
 	if c.UseLightstepMetricsSDK {
 		// Install the Lightstep metrics SDK
-		metricExporter, err := c.newMetricsExporter()
+		metricExporter, err := c.newMetricsExporter(retry)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create metric exporter: %v", err)
 		}
 
-		sdk := sdkmetric.NewMeterProvider(
+		opts := []sdkmetric.Option{
 			sdkmetric.WithResource(c.Resource),
 			sdkmetric.WithReader(
 				sdkmetric.NewPeriodicReader(metricExporter, period),
 				newPref,
 			),
-		)
+		}
+
+		var promServer *http.Server
+		if c.PrometheusAddress != "" {
+			promExporter := prometheus.New()
+			opts = append(opts, sdkmetric.WithReader(promExporter, newPref))
+
+			promServer, err = startPrometheusServer(c.PrometheusAddress, promExporter)
+			if err != nil {
+				return nil, fmt.Errorf("failed to start prometheus exporter: %v", err)
+			}
+		}
+
+		sdk := sdkmetric.NewMeterProvider(opts...)
 
 		provider = sdk
 		shutdown = func() error {
-			return sdk.Shutdown(context.Background())
+			err := sdk.Shutdown(context.Background())
+			if promServer != nil {
+				if cerr := promServer.Close(); err == nil {
+					err = cerr
+				}
+			}
+			return err
 		}
 
 	} else {
 		// Install the OTel-Go community metrics SDK.
-		metricExporter, err := c.newOldMetricsExporter(oldPref)
+		metricExporter, err := c.newOldMetricsExporter(oldPref, retry)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create metric exporter: %v", err)
 		}
@@ -269,36 +303,234 @@ func interceptor(
 			otel.Handle(fmt.Errorf("metrics partial failure: %v", string(data)))
 		}
 	}
+	if err == nil {
+		handlePartialSuccess(req, reply)
+	}
 	return err
 }
 
-func (c PipelineConfig) newClient() otlpmetric.Client {
-	return otlpmetricgrpc.NewClient(
+// handlePartialSuccess reports the data points rejected by the
+// collector in the response to a successful export, if any, as an
+// *otlpmetric.PartialSuccessError.
+func handlePartialSuccess(req, reply interface{}) {
+	resp, ok := reply.(*colmetricpb.ExportMetricsServiceResponse)
+	if !ok {
+		return
+	}
+	ps := resp.GetPartialSuccess()
+
+	// The instruments mentioned in the message are located in
+	// every resource of the request.
+	var batch metricpb.ResourceMetrics
+	if r, ok := req.(*colmetricpb.ExportMetricsServiceRequest); ok {
+		for _, rm := range r.GetResourceMetrics() {
+			batch.ScopeMetrics = append(batch.ScopeMetrics, rm.GetScopeMetrics()...)
+		}
+	}
+	if err := otlpmetric.NewPartialSuccessError(ps.GetRejectedDataPoints(), ps.GetErrorMessage(), &batch); err != nil {
+		otel.Handle(err)
+	}
+}
+
+// Defaults for the metrics exporter retry configuration; these match
+// the OTLP exporter's defaults.
+const (
+	defaultRetryInitialInterval = 5 * time.Second
+	defaultRetryMaxInterval     = 30 * time.Second
+	defaultRetryMaxElapsedTime  = time.Minute
+)
+
+// retryConfig returns the exporter's retry configuration.  The gRPC
+// client retries transient errors (e.g., Unavailable) with randomized
+// exponential backoff and fails fast on others (e.g.,
+// InvalidArgument).  The total retry time is limited to the reporting
+// period so that a failing export does not delay the next one.
+func (c PipelineConfig) retryConfig(period time.Duration) (otlpmetricgrpc.RetryConfig, error) {
+	rc := otlpmetricgrpc.RetryConfig{
+		Enabled: true,
+	}
+	var err error
+	if rc.InitialInterval, err = parseRetryDuration("initial interval", c.RetryInitialInterval, defaultRetryInitialInterval); err != nil {
+		return rc, err
+	}
+	if rc.MaxInterval, err = parseRetryDuration("max interval", c.RetryMaxInterval, defaultRetryMaxInterval); err != nil {
+		return rc, err
+	}
+	if rc.MaxElapsedTime, err = parseRetryDuration("max elapsed time", c.RetryMaxElapsedTime, defaultRetryMaxElapsedTime); err != nil {
+		return rc, err
+	}
+	if rc.MaxInterval < rc.InitialInterval {
+		return rc, fmt.Errorf("max interval %v is less than initial interval %v", rc.MaxInterval, rc.InitialInterval)
+	}
+	if rc.MaxElapsedTime > period {
+		rc.MaxElapsedTime = period
+	}
+	return rc, nil
+}
+
+// parseRetryDuration parses a positive duration, empty for the default.
+func parseRetryDuration(what, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", what, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s: %v", what, value)
+	}
+	return d, nil
+}
+
+// DefaultDiskBufferSize is the default limit on the size of the
+// metrics disk buffer.
+const DefaultDiskBufferSize = 64 << 20
+
+// Values of PipelineConfig.Protocol.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// newClient returns the OTLP client for the configured protocol,
+// wrapped in a disk buffer when one is configured.
+func (c PipelineConfig) newClient(retry otlpmetricgrpc.RetryConfig) (otlpmetric.Client, error) {
+	gzipped, err := c.useGzip()
+	if err != nil {
+		return nil, err
+	}
+	var client otlpmetric.Client
+	switch strings.ToLower(c.Protocol) {
+	case "", ProtocolGRPC:
+		client = c.newGRPCClient(retry, gzipped)
+	case ProtocolHTTPProtobuf:
+		client = c.newHTTPClient(retry, gzipped)
+	default:
+		return nil, fmt.Errorf("invalid metrics protocol: %q", c.Protocol)
+	}
+	if c.DiskBufferPath == "" {
+		return client, nil
+	}
+	size := c.DiskBufferSize
+	if size == 0 {
+		size = DefaultDiskBufferSize
+	}
+	return diskbuffer.New(client, c.DiskBufferPath, size)
+}
+
+// useGzip returns whether the configured compression is gzip, which
+// is the default.
+func (c PipelineConfig) useGzip() (bool, error) {
+	switch strings.ToLower(c.Compression) {
+	case "", "gzip":
+		return true, nil
+	case "none":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid metrics compression: %q", c.Compression)
+	}
+}
+
+func (c PipelineConfig) newGRPCClient(retry otlpmetricgrpc.RetryConfig, gzipped bool) otlpmetric.Client {
+	opts := []otlpmetricgrpc.Option{
 		c.secureMetricOption(),
 		otlpmetricgrpc.WithEndpoint(c.Endpoint),
 		otlpmetricgrpc.WithHeaders(c.Headers),
-		otlpmetricgrpc.WithCompressor(gzip.Name),
+		otlpmetricgrpc.WithRetry(retry),
 		otlpmetricgrpc.WithDialOption(
 			grpc.WithUnaryInterceptor(interceptor),
 		),
-	)
+	}
+	if gzipped {
+		opts = append(opts, otlpmetricgrpc.WithCompressor(gzip.Name))
+	}
+	return otlpmetricgrpc.NewClient(opts...)
+}
+
+// newHTTPClient returns an OTLP HTTP/protobuf client.  The client
+// reports partial successes to the OTel error handler.
+func (c PipelineConfig) newHTTPClient(retry otlpmetricgrpc.RetryConfig, gzipped bool) otlpmetric.Client {
+	opts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(c.Endpoint),
+		otlpmetrichttp.WithHeaders(c.Headers),
+		otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig(retry)),
+	}
+	if c.Insecure {
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	} else if c.TLSConfig != nil {
+		opts = append(opts, otlpmetrichttp.WithTLSClientConfig(c.TLSConfig))
+	}
+	if gzipped {
+		opts = append(opts, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
+	} else {
+		opts = append(opts, otlpmetrichttp.WithCompression(otlpmetrichttp.NoCompression))
+	}
+	return otlpmetrichttp.NewClient(opts...)
 }
 
-func (c PipelineConfig) newMetricsExporter() (*otlpmetric.Exporter, error) {
+func (c PipelineConfig) newMetricsExporter(retry otlpmetricgrpc.RetryConfig) (*otlpmetric.Exporter, error) {
+	client, err := c.newClient(retry)
+	if err != nil {
+		return nil, err
+	}
 	return otlpmetric.New(
 		context.Background(),
-		c.newClient(),
+		client,
 	)
 }
 
-func (c PipelineConfig) newOldMetricsExporter(tempo oldaggregation.TemporalitySelector) (*oldotlpmetric.Exporter, error) {
+func (c PipelineConfig) newOldMetricsExporter(tempo oldaggregation.TemporalitySelector, retry otlpmetricgrpc.RetryConfig) (*oldotlpmetric.Exporter, error) {
+	client, err := c.newClient(retry)
+	if err != nil {
+		return nil, err
+	}
 	return oldotlpmetric.New(
 		context.Background(),
-		c.newClient(),
+		client,
 		oldotlpmetric.WithMetricAggregationTemporalitySelector(tempo),
 	)
 }
 
+// checkPrometheusConfig rejects configurations that cannot be served
+// by the Prometheus exporter, which reads from the Lightstep metrics
+// SDK and cannot represent Delta temporality.
+func checkPrometheusConfig(c PipelineConfig) error {
+	if c.PrometheusAddress == "" {
+		return nil
+	}
+	if !c.UseLightstepMetricsSDK {
+		return fmt.Errorf("the Prometheus exporter requires the Lightstep metrics SDK")
+	}
+	switch lower := strings.ToLower(c.TemporalityPreference); lower {
+	case "", "cumulative":
+		return nil
+	default:
+		return fmt.Errorf("the Prometheus exporter requires cumulative temporality, have %q", c.TemporalityPreference)
+	}
+}
+
+// startPrometheusServer serves the exporter at /metrics on addr.
+func startPrometheusServer(addr string, exp *prometheus.Exporter) (*http.Server, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exp)
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(lis); err != nil && err != http.ErrServerClosed {
+			otel.Handle(fmt.Errorf("prometheus exporter: %w", err))
+		}
+	}()
+	return srv, nil
+}
+
 func tempoOptions(c PipelineConfig) (view.Option, oldaggregation.TemporalitySelector, error) {
 	syncPref := aggregation.CumulativeTemporality
 	asyncPref := aggregation.CumulativeTemporality
@@ -19,19 +19,26 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/protobuf/encoding/prototext"
 
+	otlpmetric "github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp"
+	"github.com/lightstep/otel-launcher-go/lightstep/sdk/metric/exporters/otlp/diskbuffer"
 	"github.com/lightstep/otel-launcher-go/pipelines/test"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	metricglobal "go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
 )
 
 func newTLSConfig() *tls.Config {
@@ -252,3 +259,154 @@ func TestBuiltins(t *testing.T) {
 		testBuiltinMetrics(t, test.Builtins, test.Expect)
 	}
 }
+
+func TestPrometheusConfig(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config PipelineConfig
+		expect string
+	}{
+		{"disabled", PipelineConfig{TemporalityPreference: "delta"}, ""},
+		{"cumulative", PipelineConfig{PrometheusAddress: ":9464", UseLightstepMetricsSDK: true}, ""},
+		{"old sdk", PipelineConfig{PrometheusAddress: ":9464"}, "requires the Lightstep metrics SDK"},
+		{"delta", PipelineConfig{PrometheusAddress: ":9464", UseLightstepMetricsSDK: true, TemporalityPreference: "delta"}, "requires cumulative temporality"},
+		{"stateless", PipelineConfig{PrometheusAddress: ":9464", UseLightstepMetricsSDK: true, TemporalityPreference: "stateless"}, "requires cumulative temporality"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkPrometheusConfig(test.config)
+			if test.expect == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.expect)
+
+			_, err = NewMetricsPipeline(test.config)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.expect)
+		})
+	}
+}
+
+func TestRetryConfig(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		config  PipelineConfig
+		initial time.Duration
+		max     time.Duration
+		elapsed time.Duration
+		expect  string
+	}{
+		{"defaults", PipelineConfig{}, 5 * time.Second, 30 * time.Second, 30 * time.Second, ""},
+		{"configured", PipelineConfig{RetryInitialInterval: "1s", RetryMaxInterval: "4s", RetryMaxElapsedTime: "10s"}, time.Second, 4 * time.Second, 10 * time.Second, ""},
+		{"elapsed limit", PipelineConfig{RetryMaxElapsedTime: "5m"}, 5 * time.Second, 30 * time.Second, 30 * time.Second, ""},
+		{"invalid", PipelineConfig{RetryInitialInterval: "soon"}, 0, 0, 0, "initial interval"},
+		{"negative", PipelineConfig{RetryMaxElapsedTime: "-1s"}, 0, 0, 0, "max elapsed time"},
+		{"inverted", PipelineConfig{RetryInitialInterval: "10s", RetryMaxInterval: "1s"}, 0, 0, 0, "less than initial interval"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rc, err := test.config.retryConfig(30 * time.Second)
+			if test.expect != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.expect)
+
+				_, err = NewMetricsPipeline(test.config)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "invalid metric retry configuration")
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, rc.Enabled)
+			require.Equal(t, test.initial, rc.InitialInterval)
+			require.Equal(t, test.max, rc.MaxInterval)
+			require.Equal(t, test.elapsed, rc.MaxElapsedTime)
+		})
+	}
+}
+
+func TestDiskBuffer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "buffer")
+	retry, err := PipelineConfig{}.retryConfig(30 * time.Second)
+	require.NoError(t, err)
+
+	client, err := PipelineConfig{DiskBufferPath: dir}.newClient(retry)
+	require.NoError(t, err)
+	require.IsType(t, &diskbuffer.Client{}, client)
+	require.DirExists(t, dir)
+
+	_, err = PipelineConfig{DiskBufferPath: dir, DiskBufferSize: -1}.newClient(retry)
+	require.Error(t, err)
+
+	client, err = PipelineConfig{}.newClient(retry)
+	require.NoError(t, err)
+	_, ok := client.(*diskbuffer.Client)
+	require.False(t, ok)
+}
+
+func TestProtocolAndCompression(t *testing.T) {
+	retry, err := PipelineConfig{}.retryConfig(30 * time.Second)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		protocol, compression string
+		valid                 bool
+	}{
+		{"", "", true},
+		{"grpc", "none", true},
+		{"http/protobuf", "gzip", true},
+		{"HTTP/Protobuf", "none", true},
+		{"http/json", "", false},
+		{"grpc", "zstd", false},
+	} {
+		t.Run(test.protocol+"/"+test.compression, func(t *testing.T) {
+			client, err := PipelineConfig{
+				Protocol:    test.protocol,
+				Compression: test.compression,
+				TLSConfig:   newTLSConfig(),
+			}.newClient(retry)
+			if !test.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, client)
+		})
+	}
+}
+
+func TestInterceptorPartialSuccess(t *testing.T) {
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer otel.SetErrorHandler(otel.ErrorHandlerFunc(func(error) {}))
+
+	req := &colmetricpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{{
+			ScopeMetrics: []*metricpb.ScopeMetrics{{
+				Metrics: []*metricpb.Metric{{Name: "requests"}, {Name: "latency"}},
+			}},
+		}},
+	}
+	invoke := func(rejected int64, message string) error {
+		return interceptor(context.Background(), "/export", req, &colmetricpb.ExportMetricsServiceResponse{},
+			nil, func(_ context.Context, _ string, _, reply interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+				reply.(*colmetricpb.ExportMetricsServiceResponse).PartialSuccess = &colmetricpb.ExportMetricsPartialSuccess{
+					RejectedDataPoints: rejected,
+					ErrorMessage:       message,
+				}
+				return nil
+			})
+	}
+
+	require.NoError(t, invoke(0, ""))
+	require.Empty(t, errs)
+
+	require.NoError(t, invoke(3, "invalid unit for latency"))
+	require.Len(t, errs, 1)
+	require.Equal(t, &otlpmetric.PartialSuccessError{
+		RejectedDataPoints: 3,
+		ErrorMessage:       "invalid unit for latency",
+		Instruments:        []string{"latency"},
+	}, errs[0])
+}